@@ -11,7 +11,9 @@ import (
 	"github.com/helixml/helix/api/pkg/cli/knowledge"
 	"github.com/helixml/helix/api/pkg/cli/mcp"
 	"github.com/helixml/helix/api/pkg/cli/provider"
+	"github.com/helixml/helix/api/pkg/cli/scheduler"
 	"github.com/helixml/helix/api/pkg/cli/secret"
+	"github.com/helixml/helix/api/pkg/cli/usage"
 )
 
 var Fatal = FatalErrorHandler
@@ -36,6 +38,8 @@ func NewRootCmd() *cobra.Command {
 	RootCmd.AddCommand(secret.New())
 	RootCmd.AddCommand(mcp.New())
 	RootCmd.AddCommand(provider.New())
+	RootCmd.AddCommand(scheduler.New())
+	RootCmd.AddCommand(usage.New())
 	// Commands available on all platforms
 	RootCmd.AddCommand(newServeCmd())
 	RootCmd.AddCommand(newVersionCommand())
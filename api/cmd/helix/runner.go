@@ -33,6 +33,7 @@ func NewRunnerOptions() *RunnerOptions {
 			GetTaskDelayMilliseconds:     getDefaultServeOptionInt("GET_TASK_DELAY_MILLISECONDS", 100),
 			ReportStateDelaySeconds:      getDefaultServeOptionInt("REPORT_STATE_DELAY_SECONDS", 1),
 			Labels:                       getDefaultServeOptionMap("LABELS", map[string]string{}),
+			Taints:                       getDefaultServeOptionMap("TAINTS", map[string]string{}),
 			SchedulingDecisionBufferSize: getDefaultServeOptionInt("SCHEDULING_DECISION_BUFFER_SIZE", 100),
 			JobHistoryBufferSize:         getDefaultServeOptionInt("JOB_HISTORY_BUFFER_SIZE", 100),
 			MockRunner:                   getDefaultServeOptionBool("MOCK_RUNNER", false),
@@ -120,6 +121,11 @@ func newRunnerCmd() *cobra.Command {
 		`Labels to attach to this runner`,
 	)
 
+	runnerCmd.PersistentFlags().StringToStringVar(
+		&allOptions.Runner.Taints, "taint", allOptions.Runner.Taints,
+		`Taints to attach to this runner, only workloads tolerating them are scheduled on it`,
+	)
+
 	runnerCmd.PersistentFlags().IntVar(
 		&allOptions.Runner.SchedulingDecisionBufferSize, "scheduling-decision-buffer-size", allOptions.Runner.SchedulingDecisionBufferSize,
 		`How many scheduling decisions to buffer before we start dropping them.`,
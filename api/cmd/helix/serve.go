@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -22,10 +23,12 @@ import (
 	"github.com/helixml/helix/api/pkg/license"
 	"github.com/helixml/helix/api/pkg/notification"
 	"github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/openai/cache"
 	"github.com/helixml/helix/api/pkg/openai/logger"
 	"github.com/helixml/helix/api/pkg/openai/manager"
 	"github.com/helixml/helix/api/pkg/pubsub"
 	"github.com/helixml/helix/api/pkg/rag"
+	"github.com/helixml/helix/api/pkg/ratelimit"
 	"github.com/helixml/helix/api/pkg/scheduler"
 	"github.com/helixml/helix/api/pkg/server"
 	"github.com/helixml/helix/api/pkg/store"
@@ -279,31 +282,46 @@ func serve(cmd *cobra.Command, cfg *config.ServerConfig) error {
 		return err
 	}
 
+	var schedulerTrace io.Writer
+	if cfg.Providers.Helix.SchedulerTraceFile != "" {
+		traceFile, err := os.OpenFile(cfg.Providers.Helix.SchedulerTraceFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open scheduler trace file: %w", err)
+		}
+		defer traceFile.Close()
+		schedulerTrace = traceFile
+		log.Info().Str("file", cfg.Providers.Helix.SchedulerTraceFile).Msg("recording scheduler trace")
+	}
+
 	var appController *controller.Controller
 
 	scheduler, err := scheduler.NewScheduler(ctx, cfg, &scheduler.Params{
 		RunnerController: runnerController,
 		QueueSize:        100,
+		StateStore:       postgresStore,
+		Trace:            schedulerTrace,
+		// Also called for the inference requests failed when the queue is
+		// restored, before the controller is created
 		OnSchedulingErr: func(work *scheduler.Workload, err error) {
-			if appController != nil {
-				switch work.WorkloadType {
-				case scheduler.WorkloadTypeLLMInferenceRequest:
-					request := work.LLMInferenceRequest()
-					response := types.RunnerNatsReplyResponse{
-						OwnerID:   request.OwnerID,
-						RequestID: request.RequestID,
-						Error:     err.Error(),
-						Response:  []byte{},
-					}
-					bts, err := json.Marshal(response)
-					if err != nil {
-						log.Error().Err(err).Msg("error marshalling runner response")
-					}
-					err = ps.Publish(ctx, pubsub.GetRunnerResponsesQueue(request.OwnerID, request.RequestID), bts)
-					if err != nil {
-						log.Error().Err(err).Msg("error publishing runner response")
-					}
-				case scheduler.WorkloadTypeSession:
+			switch work.WorkloadType {
+			case scheduler.WorkloadTypeLLMInferenceRequest:
+				request := work.LLMInferenceRequest()
+				response := types.RunnerNatsReplyResponse{
+					OwnerID:   request.OwnerID,
+					RequestID: request.RequestID,
+					Error:     err.Error(),
+					Response:  []byte{},
+				}
+				bts, err := json.Marshal(response)
+				if err != nil {
+					log.Error().Err(err).Msg("error marshalling runner response")
+				}
+				err = ps.Publish(ctx, pubsub.GetRunnerResponsesQueue(request.OwnerID, request.RequestID), bts)
+				if err != nil {
+					log.Error().Err(err).Msg("error publishing runner response")
+				}
+			case scheduler.WorkloadTypeSession:
+				if appController != nil {
 					appController.ErrorSession(ctx, work.Session(), err)
 				}
 			}
@@ -327,11 +345,35 @@ func serve(cmd *cobra.Command, cfg *config.ServerConfig) error {
 		}
 	}
 
-	providerManager := manager.NewProviderManager(cfg, postgresStore, helixInference, logStores...)
+	// The rate limiter counts the tokens of the logged LLM calls
+	var rateLimiter *ratelimit.Limiter
+	if cfg.RateLimits.Enabled {
+		rateLimiter = ratelimit.NewLimiter(cfg, postgresStore)
+		logStores = append(logStores, rateLimiter)
+	}
+
+	// Apps opt in to the response cache, it's only created when allowed
+	var responseCache *cache.ResponseCache
+	if cfg.ResponseCache.Enabled {
+		responseCache = cache.NewResponseCache(&cfg.ResponseCache)
+		go responseCache.Run(ctx)
+	}
+
+	providerManager := manager.NewProviderManager(cfg, postgresStore, helixInference, responseCache, rateLimiter, logStores...)
 
 	// Will run async and watch for changes in the API keys, non-blocking
 	providerManager.StartRefresh(ctx)
 
+	if responseCache != nil && cfg.ResponseCache.EmbeddingsProvider != "" {
+		embeddingsClient, err := providerManager.GetClient(ctx, &manager.GetClientRequest{
+			Provider: cfg.ResponseCache.EmbeddingsProvider,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get response cache embeddings client: %w", err)
+		}
+		responseCache.SetEmbeddingsClient(embeddingsClient)
+	}
+
 	dataprepOpenAIClient, err := createDataPrepOpenAIClient(cfg, helixInference)
 	if err != nil {
 		return err
@@ -383,6 +425,7 @@ func serve(cmd *cobra.Command, cfg *config.ServerConfig) error {
 		DataprepOpenAIClient: dataprepOpenAIClient,
 		Scheduler:            scheduler,
 		RunnerController:     runnerController,
+		RateLimiter:          rateLimiter,
 	}
 
 	appController, err = controller.NewController(ctx, controllerOptions)
@@ -395,6 +438,8 @@ func serve(cmd *cobra.Command, cfg *config.ServerConfig) error {
 		return err
 	}
 
+	go appController.RunToolApprovalSweeper(ctx)
+
 	// Initialize browser pool
 	browserPool, err := browser.New(cfg)
 	if err != nil {
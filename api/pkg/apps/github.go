@@ -12,6 +12,7 @@ import (
 	"github.com/helixml/helix/api/pkg/system"
 	"github.com/helixml/helix/api/pkg/tools"
 	"github.com/helixml/helix/api/pkg/types"
+	"github.com/rs/zerolog/log"
 	"gopkg.in/yaml.v2"
 )
 
@@ -288,6 +289,26 @@ func (g *App) processConfig(config *types.AppHelixConfig) (*types.AppHelixConfig
 		return nil, fmt.Errorf("error processing repo files: %w", err)
 	}
 
+	// Only admins can let the app on tainted runners, the repository can't.
+	// The tolerations an admin set on the app are kept.
+	if config.RunnerSelector != nil && len(config.RunnerSelector.Tolerations) > 0 {
+		log.Warn().
+			Str("app_id", g.App.ID).
+			Msg("ignoring runner tolerations of helix.yaml, they can only be set by admins")
+	}
+
+	var tolerations []types.RunnerToleration
+	if g.App.Config.Helix.RunnerSelector != nil {
+		tolerations = g.App.Config.Helix.RunnerSelector.Tolerations
+	}
+
+	switch {
+	case config.RunnerSelector != nil:
+		config.RunnerSelector.Tolerations = tolerations
+	case len(tolerations) > 0:
+		config.RunnerSelector = &types.RunnerSelector{Tolerations: tolerations}
+	}
+
 	return config, nil
 }
 
@@ -0,0 +1,44 @@
+package apps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func TestGithubApp_processConfig_KeepsTolerations(t *testing.T) {
+	tolerations := []types.RunnerToleration{
+		{Key: "dedicated", Operator: types.RunnerTolerationOperatorEqual, Value: "team-a"},
+	}
+
+	app := &App{
+		GithubConfig: config.GitHub{RepoFolder: t.TempDir()},
+		App: &types.App{
+			ID: "app_1",
+			Config: types.AppConfig{
+				Helix: types.AppHelixConfig{
+					RunnerSelector: &types.RunnerSelector{Tolerations: tolerations},
+				},
+			},
+		},
+	}
+
+	// The repository can't change the tolerations set by an admin
+	processed, err := app.processConfig(&types.AppHelixConfig{
+		RunnerSelector: &types.RunnerSelector{
+			NodeSelector: map[string]string{"gpu": "a100"},
+			Tolerations:  []types.RunnerToleration{{Operator: types.RunnerTolerationOperatorExists}},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"gpu": "a100"}, processed.RunnerSelector.NodeSelector)
+	require.Equal(t, tolerations, processed.RunnerSelector.Tolerations)
+
+	// They're kept when the repository has no selector
+	processed, err = app.processConfig(&types.AppHelixConfig{})
+	require.NoError(t, err)
+	require.Equal(t, tolerations, processed.RunnerSelector.Tolerations)
+}
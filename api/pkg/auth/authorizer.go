@@ -0,0 +1,319 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+var (
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Action is an operation a user wants to perform on a resource
+type Action string
+
+const (
+	// ActionUse allows running an app, querying knowledge or chatting
+	ActionUse Action = "use"
+	// ActionRead allows viewing the resource details, history and logs
+	ActionRead Action = "read"
+	// ActionUpdate allows changing the resource
+	ActionUpdate Action = "update"
+	// ActionDelete allows deleting the resource
+	ActionDelete Action = "delete"
+	// ActionManageAccess allows granting and revoking access to the resource
+	ActionManageAccess Action = "manage_access"
+)
+
+// roleActions lists the actions each role can perform
+var roleActions = map[types.ResourceRole][]Action{
+	types.ResourceRoleViewer: {ActionUse, ActionRead},
+	types.ResourceRoleEditor: {ActionUse, ActionRead, ActionUpdate},
+	types.ResourceRoleOwner:  {ActionUse, ActionRead, ActionUpdate, ActionDelete, ActionManageAccess},
+}
+
+// RoleAllows checks whether the role can perform the action
+func RoleAllows(role types.ResourceRole, action Action) bool {
+	for _, a := range roleActions[role] {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Resource describes what is being accessed
+type Resource struct {
+	Type      types.ResourceType
+	ID        string
+	Owner     string
+	OwnerType types.OwnerType
+	// PublicActions can be performed by any user, for example using a
+	// global app or reading a shared session
+	PublicActions []Action
+	// AppID, if set, is the app the resource belongs to. Users get the
+	// same role on the resource as they have on the app.
+	AppID string
+}
+
+func AppResource(app *types.App) Resource {
+	resource := Resource{
+		Type:      types.ResourceTypeApp,
+		ID:        app.ID,
+		Owner:     app.Owner,
+		OwnerType: app.OwnerType,
+	}
+	if app.Global || app.Shared {
+		resource.PublicActions = []Action{ActionUse}
+	}
+	return resource
+}
+
+func KnowledgeResource(knowledge *types.Knowledge) Resource {
+	return Resource{
+		Type:      types.ResourceTypeKnowledge,
+		ID:        knowledge.ID,
+		Owner:     knowledge.Owner,
+		OwnerType: knowledge.OwnerType,
+		AppID:     knowledge.AppID,
+	}
+}
+
+func SessionResource(session *types.Session) Resource {
+	resource := Resource{
+		Type:      types.ResourceTypeSession,
+		ID:        session.ID,
+		Owner:     session.Owner,
+		OwnerType: session.OwnerType,
+	}
+	if session.Metadata.Shared {
+		resource.PublicActions = []Action{ActionRead}
+	}
+	return resource
+}
+
+func SecretResource(secret *types.Secret) Resource {
+	return Resource{
+		Type:      types.ResourceTypeSecret,
+		ID:        secret.ID,
+		Owner:     secret.Owner,
+		OwnerType: secret.OwnerType,
+	}
+}
+
+func ProviderEndpointResource(endpoint *types.ProviderEndpoint) Resource {
+	resource := Resource{
+		Type:      types.ResourceTypeProviderEndpoint,
+		ID:        endpoint.ID,
+		Owner:     endpoint.Owner,
+		OwnerType: endpoint.OwnerType,
+	}
+	// Organization and team endpoints are owned by the organization or team set
+	// in the owner field
+	switch endpoint.EndpointType {
+	case types.ProviderEndpointTypeOrg:
+		resource.OwnerType = types.OwnerTypeOrg
+	case types.ProviderEndpointTypeTeam:
+		resource.OwnerType = types.OwnerTypeTeam
+	}
+	return resource
+}
+
+func ProviderRoutingGroupResource(group *types.ProviderRoutingGroup) Resource {
+	resource := Resource{
+		Type:      types.ResourceTypeProviderRoutingGroup,
+		ID:        group.ID,
+		Owner:     group.Owner,
+		OwnerType: group.OwnerType,
+	}
+	// Organization and team groups are owned by the organization or team set
+	// in the owner field
+	switch group.EndpointType {
+	case types.ProviderEndpointTypeOrg:
+		resource.OwnerType = types.OwnerTypeOrg
+	case types.ProviderEndpointTypeTeam:
+		resource.OwnerType = types.OwnerTypeTeam
+	}
+	return resource
+}
+
+func DataEntityResource(dataEntity *types.DataEntity) Resource {
+	return Resource{
+		Type:      types.ResourceTypeDataEntity,
+		ID:        dataEntity.ID,
+		Owner:     dataEntity.Owner,
+		OwnerType: dataEntity.OwnerType,
+	}
+}
+
+// IsGrantable returns true for the resource types that can be shared with
+// other users, teams and organizations through access grants
+func IsGrantable(resourceType types.ResourceType) bool {
+	switch resourceType {
+	case types.ResourceTypeApp, types.ResourceTypeKnowledge, types.ResourceTypeSession:
+		return true
+	default:
+		return false
+	}
+}
+
+// Authorizer is the central policy that decides what a user can do with a
+// resource. Roles come from (in order): being an admin, owning the resource
+// directly, being a member of the organization or team that owns it, access
+// grants on the resource and finally the role on the app the resource
+// belongs to.
+type Authorizer interface {
+	// GetRole returns the highest role the user has on the resource
+	GetRole(ctx context.Context, user *types.User, resource Resource) (types.ResourceRole, error)
+	// Authorize returns ErrForbidden if the user cannot perform the action
+	Authorize(ctx context.Context, user *types.User, resource Resource, action Action) error
+}
+
+type authorizer struct {
+	store store.Store
+}
+
+func NewAuthorizer(s store.Store) Authorizer {
+	return &authorizer{store: s}
+}
+
+func (a *authorizer) Authorize(ctx context.Context, user *types.User, resource Resource, action Action) error {
+	for _, publicAction := range resource.PublicActions {
+		if publicAction == action {
+			return nil
+		}
+	}
+
+	role, err := a.GetRole(ctx, user, resource)
+	if err != nil {
+		return err
+	}
+
+	if !RoleAllows(role, action) {
+		return fmt.Errorf("%w: cannot %s %s %s", ErrForbidden, action, resource.Type, resource.ID)
+	}
+
+	return nil
+}
+
+func (a *authorizer) GetRole(ctx context.Context, user *types.User, resource Resource) (types.ResourceRole, error) {
+	if user == nil || user.ID == "" {
+		return types.ResourceRoleNone, nil
+	}
+
+	if user.Admin {
+		return types.ResourceRoleOwner, nil
+	}
+
+	role, err := a.getOwnerRole(ctx, user, resource)
+	if err != nil {
+		return types.ResourceRoleNone, err
+	}
+
+	if role == types.ResourceRoleOwner {
+		return role, nil
+	}
+
+	if IsGrantable(resource.Type) && resource.ID != "" {
+		grantRole, err := a.getGrantRole(ctx, user, resource)
+		if err != nil {
+			return types.ResourceRoleNone, err
+		}
+		role = maxRole(role, grantRole)
+	}
+
+	if resource.AppID != "" && resource.Type != types.ResourceTypeApp && !role.AtLeast(types.ResourceRoleOwner) {
+		appRole, err := a.getAppRole(ctx, user, resource.AppID)
+		if err != nil {
+			return types.ResourceRoleNone, err
+		}
+		role = maxRole(role, appRole)
+	}
+
+	return role, nil
+}
+
+// getOwnerRole returns the role that comes from owning the resource.
+// Organization and team owners get the owner role, members can view.
+func (a *authorizer) getOwnerRole(ctx context.Context, user *types.User, resource Resource) (types.ResourceRole, error) {
+	switch resource.OwnerType {
+	case types.OwnerTypeOrg, types.OwnerTypeTeam:
+		isMember, orgRole, err := store.IsOwnerMember(ctx, a.store, user.ID, resource.Owner, resource.OwnerType)
+		if err != nil {
+			return types.ResourceRoleNone, err
+		}
+		if !isMember {
+			return types.ResourceRoleNone, nil
+		}
+		if orgRole == types.OrganizationRoleOwner {
+			return types.ResourceRoleOwner, nil
+		}
+		return types.ResourceRoleViewer, nil
+	default:
+		if resource.Owner != "" && resource.Owner == user.ID {
+			return types.ResourceRoleOwner, nil
+		}
+		return types.ResourceRoleNone, nil
+	}
+}
+
+func (a *authorizer) getGrantRole(ctx context.Context, user *types.User, resource Resource) (types.ResourceRole, error) {
+	grants, err := a.store.ListAccessGrants(ctx, &store.ListAccessGrantsQuery{
+		ResourceType: resource.Type,
+		ResourceID:   resource.ID,
+	})
+	if err != nil {
+		return types.ResourceRoleNone, fmt.Errorf("failed to list access grants: %w", err)
+	}
+
+	role := types.ResourceRoleNone
+
+	for _, grant := range grants {
+		if role.AtLeast(grant.Role) {
+			continue
+		}
+
+		switch grant.GranteeType {
+		case types.OwnerTypeOrg, types.OwnerTypeTeam:
+			isMember, _, err := store.IsOwnerMember(ctx, a.store, user.ID, grant.Grantee, grant.GranteeType)
+			if err != nil {
+				return types.ResourceRoleNone, err
+			}
+			if isMember {
+				role = grant.Role
+			}
+		default:
+			if grant.Grantee == user.ID {
+				role = grant.Role
+			}
+		}
+	}
+
+	return role, nil
+}
+
+func (a *authorizer) getAppRole(ctx context.Context, user *types.User, appID string) (types.ResourceRole, error) {
+	app, err := a.store.GetApp(ctx, appID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return types.ResourceRoleNone, nil
+		}
+		return types.ResourceRoleNone, fmt.Errorf("failed to get app: %w", err)
+	}
+
+	return a.GetRole(ctx, user, AppResource(app))
+}
+
+func maxRole(a, b types.ResourceRole) types.ResourceRole {
+	if a.AtLeast(b) {
+		return a
+	}
+	return b
+}
+
+// Compile-time interface check:
+var _ Authorizer = (*authorizer)(nil)
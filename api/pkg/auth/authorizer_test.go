@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+type AuthorizerSuite struct {
+	suite.Suite
+
+	ctx   context.Context
+	store *store.MockStore
+
+	authorizer Authorizer
+}
+
+func TestAuthorizerSuite(t *testing.T) {
+	suite.Run(t, new(AuthorizerSuite))
+}
+
+func (suite *AuthorizerSuite) SetupTest() {
+	ctrl := gomock.NewController(suite.T())
+
+	suite.ctx = context.Background()
+	suite.store = store.NewMockStore(ctrl)
+	suite.authorizer = NewAuthorizer(suite.store)
+}
+
+func (suite *AuthorizerSuite) Test_Owner() {
+	app := &types.App{ID: "app_1", Owner: "user_1", OwnerType: types.OwnerTypeUser}
+
+	role, err := suite.authorizer.GetRole(suite.ctx, &types.User{ID: "user_1"}, AppResource(app))
+	suite.Require().NoError(err)
+	suite.Equal(types.ResourceRoleOwner, role)
+}
+
+func (suite *AuthorizerSuite) Test_Admin() {
+	app := &types.App{ID: "app_1", Owner: "user_1", OwnerType: types.OwnerTypeUser}
+
+	err := suite.authorizer.Authorize(suite.ctx, &types.User{ID: "admin", Admin: true}, AppResource(app), ActionManageAccess)
+	suite.Require().NoError(err)
+}
+
+func (suite *AuthorizerSuite) Test_ViewerGrant() {
+	app := &types.App{ID: "app_1", Owner: "user_1", OwnerType: types.OwnerTypeUser}
+	user := &types.User{ID: "user_2"}
+
+	suite.store.EXPECT().ListAccessGrants(suite.ctx, &store.ListAccessGrantsQuery{
+		ResourceType: types.ResourceTypeApp,
+		ResourceID:   "app_1",
+	}).Return([]*types.AccessGrant{
+		{Grantee: "user_3", GranteeType: types.OwnerTypeUser, Role: types.ResourceRoleOwner},
+		{Grantee: "user_2", GranteeType: types.OwnerTypeUser, Role: types.ResourceRoleViewer},
+	}, nil).Times(2)
+
+	err := suite.authorizer.Authorize(suite.ctx, user, AppResource(app), ActionRead)
+	suite.Require().NoError(err)
+
+	err = suite.authorizer.Authorize(suite.ctx, user, AppResource(app), ActionUpdate)
+	suite.Require().Error(err)
+	suite.True(errors.Is(err, ErrForbidden))
+}
+
+func (suite *AuthorizerSuite) Test_TeamGrant() {
+	session := &types.Session{ID: "ses_1", Owner: "user_1", OwnerType: types.OwnerTypeUser}
+	user := &types.User{ID: "user_2"}
+
+	suite.store.EXPECT().ListAccessGrants(suite.ctx, &store.ListAccessGrantsQuery{
+		ResourceType: types.ResourceTypeSession,
+		ResourceID:   "ses_1",
+	}).Return([]*types.AccessGrant{
+		{Grantee: "team_1", GranteeType: types.OwnerTypeTeam, Role: types.ResourceRoleEditor},
+	}, nil)
+
+	suite.store.EXPECT().GetTeam(suite.ctx, &store.GetTeamQuery{ID: "team_1"}).
+		Return(&types.Team{ID: "team_1", OrganizationID: "org_1"}, nil)
+	suite.store.EXPECT().GetOrganizationMembership(suite.ctx, "org_1", "user_2").
+		Return(&types.OrganizationMembership{Role: types.OrganizationRoleMember}, nil)
+	suite.store.EXPECT().GetTeamMembership(suite.ctx, "team_1", "user_2").
+		Return(&types.TeamMembership{}, nil)
+
+	role, err := suite.authorizer.GetRole(suite.ctx, user, SessionResource(session))
+	suite.Require().NoError(err)
+	suite.Equal(types.ResourceRoleEditor, role)
+}
+
+func (suite *AuthorizerSuite) Test_OrganizationMember() {
+	app := &types.App{ID: "app_1", Owner: "org_1", OwnerType: types.OwnerTypeOrg}
+	user := &types.User{ID: "user_2"}
+
+	suite.store.EXPECT().GetOrganizationMembership(suite.ctx, "org_1", "user_2").
+		Return(&types.OrganizationMembership{Role: types.OrganizationRoleMember}, nil)
+	suite.store.EXPECT().ListAccessGrants(suite.ctx, gomock.Any()).Return(nil, nil)
+
+	role, err := suite.authorizer.GetRole(suite.ctx, user, AppResource(app))
+	suite.Require().NoError(err)
+	suite.Equal(types.ResourceRoleViewer, role)
+}
+
+func (suite *AuthorizerSuite) Test_GlobalAppCanOnlyBeUsed() {
+	app := &types.App{ID: "app_1", Owner: "admin", OwnerType: types.OwnerTypeUser, Global: true}
+	user := &types.User{ID: "user_2"}
+
+	err := suite.authorizer.Authorize(suite.ctx, user, AppResource(app), ActionUse)
+	suite.Require().NoError(err)
+
+	suite.store.EXPECT().ListAccessGrants(suite.ctx, gomock.Any()).Return(nil, nil)
+
+	// Using a global app doesn't give access to its logs
+	err = suite.authorizer.Authorize(suite.ctx, user, AppResource(app), ActionRead)
+	suite.True(errors.Is(err, ErrForbidden))
+}
+
+func (suite *AuthorizerSuite) Test_KnowledgeInheritsAppRole() {
+	knowledge := &types.Knowledge{ID: "kno_1", Owner: "user_1", OwnerType: types.OwnerTypeUser, AppID: "app_1"}
+	user := &types.User{ID: "user_2"}
+
+	suite.store.EXPECT().ListAccessGrants(suite.ctx, &store.ListAccessGrantsQuery{
+		ResourceType: types.ResourceTypeKnowledge,
+		ResourceID:   "kno_1",
+	}).Return(nil, nil)
+	suite.store.EXPECT().GetApp(suite.ctx, "app_1").
+		Return(&types.App{ID: "app_1", Owner: "user_1", OwnerType: types.OwnerTypeUser}, nil)
+	suite.store.EXPECT().ListAccessGrants(suite.ctx, &store.ListAccessGrantsQuery{
+		ResourceType: types.ResourceTypeApp,
+		ResourceID:   "app_1",
+	}).Return([]*types.AccessGrant{
+		{Grantee: "user_2", GranteeType: types.OwnerTypeUser, Role: types.ResourceRoleEditor},
+	}, nil)
+
+	role, err := suite.authorizer.GetRole(suite.ctx, user, KnowledgeResource(knowledge))
+	suite.Require().NoError(err)
+	suite.Equal(types.ResourceRoleEditor, role)
+}
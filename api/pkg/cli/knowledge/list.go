@@ -61,6 +61,12 @@ var listCmd = &cobra.Command{
 				sourceStr = "web"
 			case k.Source.Filestore != nil:
 				sourceStr = "filestore"
+			case k.Source.Github != nil:
+				sourceStr = "github"
+			case k.Source.S3 != nil:
+				sourceStr = "s3"
+			case k.Source.GCS != nil:
+				sourceStr = "gcs"
 			}
 
 			var stateStr string
@@ -2,14 +2,12 @@ package mcp
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"time"
 
-	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -17,12 +15,13 @@ import (
 
 	"github.com/helixml/helix/api/pkg/client"
 	"github.com/helixml/helix/api/pkg/config"
-	"github.com/helixml/helix/api/pkg/store"
-	"github.com/helixml/helix/api/pkg/tools"
+	"github.com/helixml/helix/api/pkg/mcpserver"
 	"github.com/helixml/helix/api/pkg/types"
 )
 
 func init() {
+	runProxyCmd.Flags().StringP("app", "a", "", "ID or name of the app, defaults to HELIX_APP_ID")
+
 	rootCmd.AddCommand(runProxyCmd)
 }
 
@@ -51,8 +50,14 @@ func setup() {
 var runProxyCmd = &cobra.Command{
 	Use:   "run",
 	Short: "Run Helix mpc (model context protocol) proxy",
-	Long:  `TODO`,
-	RunE: func(_ *cobra.Command, _ []string) error {
+	Long: `Run the model context protocol server of a Helix app over stdio. The
+API tools, GPTScripts and Zapier integrations of all the assistants are
+exposed as tools, and each knowledge as a search tool and a resource.
+
+The app is selected by ID or name with --app or the HELIX_APP_ID variable.
+Remote clients can connect to the API server instead at /api/v1/mcp/sse
+with a Helix API key.`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
 		setup()
 
 		cfg, err := config.LoadCliConfig()
@@ -61,17 +66,19 @@ var runProxyCmd = &cobra.Command{
 			return err
 		}
 
-		helixAppID := os.Getenv("HELIX_APP_ID")
+		appRef, _ := cmd.Flags().GetString("app")
+		if appRef == "" {
+			appRef = os.Getenv("HELIX_APP_ID")
+		}
 
 		log.Trace().
-			Str("app_id", helixAppID).
+			Str("app", appRef).
 			Str("helix_url", cfg.URL).
-			Str("helix_api_key", cfg.APIKey).
 			Msg("starting mcp proxy")
 
-		if helixAppID == "" {
-			log.Error().Msg("HELIX_APP_ID is not set")
-			return fmt.Errorf("HELIX_APP_ID is not set")
+		if appRef == "" {
+			log.Error().Msg("app is not set")
+			return fmt.Errorf("app is not set, use --app or HELIX_APP_ID")
 		}
 
 		apiClient, err := client.NewClient(cfg.URL, cfg.APIKey)
@@ -82,49 +89,35 @@ var runProxyCmd = &cobra.Command{
 
 		srv := &ModelContextProtocolServer{
 			apiClient: apiClient,
-			appID:     helixAppID,
+			appRef:    appRef,
 		}
 
-		return srv.Start()
+		return srv.Start(cmd.Context())
 	},
 }
 
 type ModelContextProtocolServer struct {
-	appID     string
+	// appRef is the ID or the name of the app
+	appRef    string
 	apiClient client.Client
 }
 
-func (mcps *ModelContextProtocolServer) Start() error {
-	// Create a new MCP server
-	s := server.NewMCPServer(
-		"Helix ML",
-		"1.0.0",
-		server.WithResourceCapabilities(true, true),
-		server.WithLogging(),
-	)
-
-	app, err := mcps.apiClient.GetApp(context.Background(), mcps.appID)
+func (mcps *ModelContextProtocolServer) Start(ctx context.Context) error {
+	app, err := lookupApp(ctx, mcps.apiClient, mcps.appRef)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to get app")
+		log.Error().Err(err).Str("app", mcps.appRef).Msg("failed to get app")
 		return err
 	}
 
-	// TODO: configure assistant
-	mcpTools, err := mcps.getModelContextProtocolTools(&app.Config.Helix.Assistants[0])
+	s, err := mcpserver.NewServer(ctx, mcps.apiClient, app)
 	if err != nil {
 		log.Error().
-			Str("app_id", mcps.appID).
+			Str("app_id", app.ID).
 			Err(err).
-			Msg("failed to get mcp tools for the assistant")
+			Msg("failed to create mcp server for the app")
 		return err
 	}
 
-	log.Info().Any("mcpTools", mcpTools).Msg("adding tools")
-
-	for _, mt := range mcpTools {
-		s.AddTool(mt.tool, mt.handler)
-	}
-
 	// Start the server
 	if err := server.ServeStdio(s); err != nil {
 		fmt.Printf("Server error: %v\n", err)
@@ -133,185 +126,18 @@ func (mcps *ModelContextProtocolServer) Start() error {
 	return nil
 }
 
-type helixMCPTool struct {
-	tool    mcp.Tool
-	handler func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error)
-}
-
-// TODO: load gptscript, zapier tools as well
-func (mcps *ModelContextProtocolServer) getModelContextProtocolTools(app *types.AssistantConfig) ([]*helixMCPTool, error) {
-	var mcpTools []*helixMCPTool
-
-	for _, apiTool := range app.APIs {
-		tool, err := store.ConvertAPIToTool(apiTool)
-		if err != nil {
-			log.Error().
-				Err(err).
-				Msg("failed to convert api tool to mcp tool")
-			continue
-		}
-
-		// Each API tool has a list of actions, adding them separately
-		for _, action := range tool.Config.API.Actions {
-			parameters, err := tools.GetParametersFromSchema(tool.Config.API.Schema, action.Name)
-			if err != nil {
-				log.Error().
-					Err(err).
-					Str("tool", tool.Name).
-					Str("action", action.Name).
-					Msg("failed to get parameters from schema")
-				continue
-			}
-
-			var mcpParams []mcp.ToolOption
-
-			mcpParams = append(mcpParams, mcp.WithDescription(action.Description))
-
-			for _, param := range parameters {
-				if param.Required {
-					mcpParams = append(mcpParams, mcp.WithString(param.Name,
-						mcp.Required(),
-						mcp.Description(param.Description),
-					))
-				} else {
-					mcpParams = append(mcpParams, mcp.WithString(param.Name,
-						mcp.Description(param.Description),
-					))
-				}
-			}
-
-			mcpTool := mcp.NewTool(action.Name,
-				mcpParams...,
-			)
-
-			log.Info().Any("tool", action).Msg("adding tool")
-
-			mcpTools = append(mcpTools, &helixMCPTool{
-				tool:    mcpTool,
-				handler: mcps.getAPIToolHandler(mcps.appID, tool, action.Name),
-			})
-		}
-	}
-
-	for _, gptScript := range app.GPTScripts {
-		mcpTool := mcp.NewTool(gptScript.Name,
-			mcp.WithDescription(gptScript.Description),
-		)
-
-		mcpTools = append(mcpTools, &helixMCPTool{
-			tool:    mcpTool,
-			handler: mcps.gptScriptToolHandler,
-		})
-	}
-
-	for _, zapier := range app.Zapier {
-		mcpTool := mcp.NewTool(zapier.Name,
-			mcp.WithDescription(zapier.Description),
-		)
-
-		mcpTools = append(mcpTools, &helixMCPTool{
-			tool:    mcpTool,
-			handler: mcps.zapierToolHandler,
-		})
-	}
-
-	knowledges, err := mcps.apiClient.ListKnowledge(context.Background(), &client.KnowledgeFilter{
-		AppID: mcps.appID,
-	})
+// lookupApp returns the app with the ID or the name
+func lookupApp(ctx context.Context, apiClient client.Client, ref string) (*types.App, error) {
+	apps, err := apiClient.ListApps(ctx, &client.AppFilter{})
 	if err != nil {
-		log.Error().Err(err).Msg("failed to list knowledge")
-		return nil, err
+		return nil, fmt.Errorf("failed to list apps: %w", err)
 	}
 
-	for _, knowledge := range knowledges {
-		mcpTool := mcp.NewTool(knowledge.Name,
-			mcp.WithDescription(fmt.Sprintf("Knowledge tool to search for: '%s'. Returns fragments from the database", knowledge.Description)),
-			mcp.WithString("prompt",
-				mcp.Required(),
-				mcp.Description("The prompt to search knowledge with, use concise, main keywords as the engine is performing both semantic and full text search"),
-			),
-		)
-
-		mcpTools = append(mcpTools, &helixMCPTool{
-			tool:    mcpTool,
-			handler: mcps.getKnowledgeToolHandler(knowledge.ID),
-		})
-	}
-
-	return mcpTools, nil
-}
-
-func (mcps *ModelContextProtocolServer) getAPIToolHandler(appID string, tool *types.Tool, action string) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		log.Info().
-			Str("tool", tool.Name).
-			Str("action", action).
-			Msg("api tool handler")
-
-		params := make(map[string]string)
-
-		for k, v := range request.Params.Arguments {
-			val, ok := v.(string)
-			if !ok {
-				log.Error().
-					Str("tool", tool.Name).
-					Str("action", action).
-					Str("param", k).
-					Any("value", v).
-					Msg("param is not a string")
-				continue
-			}
-			params[k] = val
+	for _, app := range apps {
+		if app.ID == ref || app.Config.Helix.Name == ref {
+			return app, nil
 		}
-
-		resp, err := mcps.apiClient.RunAPIAction(ctx, appID, action, params)
-		if err != nil {
-			log.Error().Err(err).Msg("failed to run api action")
-			return mcp.NewToolResultError(err.Error()), nil
-		}
-
-		return mcp.NewToolResultText(resp.Response), nil
 	}
-}
-
-func (mcps *ModelContextProtocolServer) gptScriptToolHandler(_ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) { //nolint:unparam
-	// TODO: implement gpt script tool handler
-	return mcp.NewToolResultText("Hello, World!"), nil
-}
 
-func (mcps *ModelContextProtocolServer) zapierToolHandler(_ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) { //nolint:unparam
-	// TODO: implement zapier tool handler
-	return mcp.NewToolResultText("Hello, World!"), nil
-}
-
-func (mcps *ModelContextProtocolServer) getKnowledgeToolHandler(knowledgeID string) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		prompt, ok := request.Params.Arguments["prompt"]
-		if !ok {
-			return mcp.NewToolResultError("prompt is required"), nil
-		}
-
-		promptStr, ok := prompt.(string)
-		if !ok {
-			return mcp.NewToolResultError("prompt must be a string"), nil
-		}
-
-		results, err := mcps.apiClient.SearchKnowledge(ctx, &client.KnowledgeSearchQuery{
-			AppID:       mcps.appID,
-			KnowledgeID: knowledgeID,
-			Prompt:      promptStr,
-		})
-		if err != nil {
-			log.Error().Err(err).Msg("failed to search knowledge")
-			return mcp.NewToolResultError(err.Error()), nil
-		}
-
-		resultsJSON, err := json.Marshal(results)
-		if err != nil {
-			log.Error().Err(err).Msg("failed to marshal knowledge search results")
-			return mcp.NewToolResultError(err.Error()), nil
-		}
-
-		return mcp.NewToolResultText(string(resultsJSON)), nil
-	}
+	return nil, fmt.Errorf("app not found: %s", ref)
 }
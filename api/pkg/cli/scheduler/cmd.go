@@ -0,0 +1,16 @@
+package scheduler
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "scheduler",
+	Short: "Scheduler tools",
+	Long:  `Tools for tuning the scheduler, like replaying recorded workload traces.`,
+}
+
+// New returns the root command for the scheduler tools
+func New() *cobra.Command {
+	return rootCmd
+}
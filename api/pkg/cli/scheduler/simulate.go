@@ -0,0 +1,219 @@
+package scheduler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/olekukonko/tablewriter"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/helixml/helix/api/pkg/scheduler"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func init() {
+	rootCmd.AddCommand(simulateCmd)
+
+	simulateCmd.Flags().String("trace", "", "JSON lines trace recorded with HELIX_SCHEDULER_TRACE_FILE, - for stdin")
+	simulateCmd.Flags().StringArray("runner", nil, "Runner to simulate as id=memory, e.g. gpu-1=80GB, can be repeated")
+	simulateCmd.Flags().Duration("model-ttl", 10*time.Second, "How long to keep models warm before allowing other work to be scheduled")
+	simulateCmd.Flags().Duration("slot-ttl", 300*time.Second, "How long to wait for work to complete before slots are considered dead")
+	simulateCmd.Flags().Int("queue-size", 100, "The size of the queue")
+	simulateCmd.Flags().Int("owner-queue-size", 50, "The maximum number of queued workloads per owner, 0 for no limit")
+	simulateCmd.Flags().Duration("cold-start", 30*time.Second, "How long runners take to load a model")
+	simulateCmd.Flags().Duration("duration", 10*time.Second, "How long work runs when the trace doesn't say")
+	simulateCmd.Flags().Duration("timeout", 24*time.Hour, "Stop the simulation after this much simulated time")
+	simulateCmd.Flags().Uint64("seed", 0, "Seed of the tie-breaks between equally good runners, replays with the same seed are identical")
+	simulateCmd.Flags().Bool("json", false, "Print the report as JSON")
+	simulateCmd.Flags().String("log-level", "error", "Log level of the scheduler")
+
+	_ = simulateCmd.MarkFlagRequired("trace")
+	_ = simulateCmd.MarkFlagRequired("runner")
+}
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Replay a workload trace through the scheduler",
+	Long: `Replays a recorded trace of workloads through the scheduler against fake runners on a
+simulated clock, and prints the wait times, cold starts and evictions. Use it to tune the model
+TTL and slot timeouts before changing them in production.`,
+	Example: `  helix scheduler simulate --trace trace.jsonl --runner gpu-1=80GB --runner gpu-2=24GB --model-ttl 5m`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		level, _ := cmd.Flags().GetString("log-level")
+		logLevel, err := zerolog.ParseLevel(level)
+		if err != nil {
+			return fmt.Errorf("invalid log level: %w", err)
+		}
+		log.Logger = log.Level(logLevel)
+
+		cfg, err := simulationConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		tracePath, _ := cmd.Flags().GetString("trace")
+		trace, err := readTrace(cmd, tracePath)
+		if err != nil {
+			return err
+		}
+
+		report, err := scheduler.Simulate(cmd.Context(), cfg, trace)
+		if err != nil {
+			return fmt.Errorf("simulation failed: %w", err)
+		}
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		}
+
+		printReport(cmd.OutOrStdout(), report)
+		return nil
+	},
+}
+
+func simulationConfig(cmd *cobra.Command) (*scheduler.SimulationConfig, error) {
+	cfg := &scheduler.SimulationConfig{}
+
+	runners, _ := cmd.Flags().GetStringArray("runner")
+	for _, r := range runners {
+		runner, err := parseRunner(r)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Runners = append(cfg.Runners, runner)
+	}
+
+	cfg.ModelTTL, _ = cmd.Flags().GetDuration("model-ttl")
+	cfg.SlotTTL, _ = cmd.Flags().GetDuration("slot-ttl")
+	cfg.QueueSize, _ = cmd.Flags().GetInt("queue-size")
+	cfg.OwnerQueueSize, _ = cmd.Flags().GetInt("owner-queue-size")
+	cfg.ColdStart, _ = cmd.Flags().GetDuration("cold-start")
+	cfg.Duration, _ = cmd.Flags().GetDuration("duration")
+	cfg.Timeout, _ = cmd.Flags().GetDuration("timeout")
+	cfg.Seed, _ = cmd.Flags().GetUint64("seed")
+
+	return cfg, nil
+}
+
+func parseRunner(s string) (*scheduler.SimulatedRunner, error) {
+	id, memory, ok := strings.Cut(s, "=")
+	if !ok || id == "" {
+		return nil, fmt.Errorf("invalid runner %q, expected id=memory", s)
+	}
+	bytes, err := humanize.ParseBytes(memory)
+	if err != nil {
+		return nil, fmt.Errorf("invalid memory of runner %s: %w", id, err)
+	}
+	return &scheduler.SimulatedRunner{ID: id, Memory: bytes}, nil
+}
+
+func readTrace(cmd *cobra.Command, path string) ([]*types.SchedulerTraceEntry, error) {
+	var r io.Reader
+	if path == "-" {
+		r = cmd.InOrStdin()
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var trace []*types.SchedulerTraceEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var entry types.SchedulerTraceEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid trace entry on line %d: %w", line, err)
+		}
+		trace = append(trace, &entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read trace: %w", err)
+	}
+	return trace, nil
+}
+
+func printReport(w io.Writer, report *scheduler.SimulationReport) {
+	table := newTable(w, []string{"ID", "Model", "Owner", "Class", "Arrived", "Wait", "Cold Start", "Runner", "Error"})
+	started, notStarted := 0, 0
+	for _, wl := range report.Workloads {
+		wait := "-"
+		coldStart := "-"
+		if !wl.Started.IsZero() {
+			started++
+			wait = wl.Wait.Round(time.Millisecond).String()
+			coldStart = fmt.Sprint(wl.ColdStart)
+		} else {
+			notStarted++
+		}
+		table.Append([]string{
+			wl.ID,
+			wl.Model,
+			wl.OwnerID,
+			string(wl.PriorityClass),
+			"+" + wl.Arrived.Sub(report.Start).Round(time.Millisecond).String(),
+			wait,
+			coldStart,
+			wl.RunnerID,
+			wl.Error,
+		})
+	}
+	table.Render()
+
+	if len(report.Evictions) > 0 {
+		fmt.Fprintln(w)
+		table = newTable(w, []string{"Evicted", "Runner", "Model"})
+		for _, e := range report.Evictions {
+			table.Append([]string{
+				"+" + e.Time.Sub(report.Start).Round(time.Millisecond).String(),
+				e.RunnerID,
+				e.Model,
+			})
+		}
+		table.Render()
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Simulated:   %s\n", report.End.Sub(report.Start).Round(time.Second))
+	fmt.Fprintf(w, "Workloads:   %d started, %d not started\n", started, notStarted)
+	fmt.Fprintf(w, "Cold starts: %d\n", report.ColdStarts)
+	fmt.Fprintf(w, "Evictions:   %d\n", len(report.Evictions))
+	fmt.Fprintf(w, "Wait:        p50 %s, p95 %s, max %s\n",
+		report.WaitPercentile(50).Round(time.Millisecond),
+		report.WaitPercentile(95).Round(time.Millisecond),
+		report.WaitPercentile(100).Round(time.Millisecond),
+	)
+}
+
+func newTable(w io.Writer, header []string) *tablewriter.Table {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(header)
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding(" ")
+	table.SetNoWhiteSpace(false)
+	return table
+}
@@ -0,0 +1,121 @@
+package usage
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/helixml/helix/api/pkg/client"
+	"github.com/helixml/helix/api/pkg/usage"
+)
+
+var (
+	from     string
+	to       string
+	groupBy  string
+	userID   string
+	appID    string
+	model    string
+	provider string
+	csvOut   bool
+	output   string
+)
+
+func init() {
+	rootCmd.Flags().StringVar(&from, "from", "", "Start date (2006-01-02) or time (RFC3339), defaults to the start of the month")
+	rootCmd.Flags().StringVar(&to, "to", "", "End date, inclusive, or time, defaults to now")
+	rootCmd.Flags().StringVar(&groupBy, "group-by", "day", "Comma separated dimensions: user, app, model, provider, day")
+	rootCmd.Flags().StringVar(&userID, "user", "", "Only the usage of this user, admin only")
+	rootCmd.Flags().StringVar(&appID, "app", "", "Only the usage of this app")
+	rootCmd.Flags().StringVar(&model, "model", "", "Only the usage of this model")
+	rootCmd.Flags().StringVar(&provider, "provider", "", "Only the usage of this provider")
+	rootCmd.Flags().BoolVar(&csvOut, "csv", false, "Print the report as CSV")
+	rootCmd.Flags().StringVarP(&output, "output", "o", "", "Write the report as CSV to this file")
+}
+
+var rootCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show token usage and cost",
+	Long: `Show the tokens and estimated cost of the LLM calls grouped by user, app, model, provider and day.
+Costs are estimated with the model prices set by the admins.`,
+	Example: `  helix usage --group-by user,model --from 2025-01-01 --to 2025-01-31
+  helix usage --group-by app,day --output usage.csv`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		apiClient, err := client.NewClientFromEnv()
+		if err != nil {
+			return err
+		}
+
+		// Check the dimensions before asking the server
+		if _, err := usage.ParseGroupBy(groupBy); err != nil {
+			return err
+		}
+
+		report, err := apiClient.GetUsage(cmd.Context(), &client.UsageFilter{
+			From:     from,
+			To:       to,
+			GroupBy:  groupBy,
+			UserID:   userID,
+			AppID:    appID,
+			Model:    model,
+			Provider: provider,
+		})
+		if err != nil {
+			return err
+		}
+
+		if output != "" {
+			f, err := os.Create(output)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", output, err)
+			}
+			defer f.Close()
+
+			if err := usage.WriteCSV(f, report); err != nil {
+				return fmt.Errorf("failed to write %s: %w", output, err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Wrote %d rows to %s\n", len(report.Entries), output)
+			return nil
+		}
+
+		if csvOut {
+			return usage.WriteCSV(cmd.OutOrStdout(), report)
+		}
+
+		table := tablewriter.NewWriter(cmd.OutOrStdout())
+
+		table.SetHeader(usage.Columns(report))
+
+		table.SetAutoWrapText(false)
+		table.SetAutoFormatHeaders(true)
+		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetCenterSeparator("")
+		table.SetColumnSeparator("")
+		table.SetRowSeparator("")
+		table.SetHeaderLine(false)
+		table.SetBorder(false)
+		table.SetTablePadding(" ")
+		table.SetNoWhiteSpace(false)
+
+		for _, entry := range report.Entries {
+			table.Append(usage.Row(report, entry))
+		}
+
+		total := usage.Row(report, report.Total)
+		if len(report.GroupBy) > 0 {
+			total[0] = "TOTAL"
+		}
+		table.Append(total)
+
+		table.Render()
+
+		return nil
+	},
+}
+
+func New() *cobra.Command {
+	return rootCmd
+}
@@ -99,12 +99,7 @@ func (c *HelixClient) GetAppByName(ctx context.Context, name string) (*types.App
 	return nil, fmt.Errorf("app with name %s not found", name)
 }
 
-func (c *HelixClient) RunAPIAction(ctx context.Context, appID string, action string, parameters map[string]string) (*types.RunAPIActionResponse, error) {
-	req := types.RunAPIActionRequest{
-		Action:     action,
-		Parameters: parameters,
-	}
-
+func (c *HelixClient) RunAPIAction(ctx context.Context, appID string, req *types.RunAPIActionRequest) (*types.RunAPIActionResponse, error) {
 	bts, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
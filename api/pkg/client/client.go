@@ -24,7 +24,7 @@ type Client interface {
 	DeleteApp(ctx context.Context, appID string, deleteKnowledge bool) error
 	ListApps(ctx context.Context, f *AppFilter) ([]*types.App, error)
 
-	RunAPIAction(ctx context.Context, appID string, action string, parameters map[string]string) (*types.RunAPIActionResponse, error)
+	RunAPIAction(ctx context.Context, appID string, req *types.RunAPIActionRequest) (*types.RunAPIActionResponse, error)
 
 	ListKnowledge(ctx context.Context, f *KnowledgeFilter) ([]*types.Knowledge, error)
 	GetKnowledge(ctx context.Context, id string) (*types.Knowledge, error)
@@ -48,6 +48,8 @@ type Client interface {
 	CreateProviderEndpoint(ctx context.Context, endpoint *types.ProviderEndpoint) (*types.ProviderEndpoint, error)
 	UpdateProviderEndpoint(ctx context.Context, endpoint *types.ProviderEndpoint) (*types.ProviderEndpoint, error)
 	DeleteProviderEndpoint(ctx context.Context, id string) error
+
+	GetUsage(ctx context.Context, f *UsageFilter) (*types.UsageReport, error)
 }
 
 // HelixClient is the client for the helix api
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+type UsageFilter struct {
+	// From and To are dates (2006-01-02) or RFC3339 times, To is inclusive
+	From     string
+	To       string
+	GroupBy  string
+	UserID   string
+	AppID    string
+	Model    string
+	Provider string
+}
+
+// GetUsage retrieves the tokens and estimated cost of the LLM calls
+func (c *HelixClient) GetUsage(ctx context.Context, f *UsageFilter) (*types.UsageReport, error) {
+	params := url.Values{}
+	for key, value := range map[string]string{
+		"from":     f.From,
+		"to":       f.To,
+		"group_by": f.GroupBy,
+		"user_id":  f.UserID,
+		"app_id":   f.AppID,
+		"model":    f.Model,
+		"provider": f.Provider,
+	} {
+		if value != "" {
+			params.Add(key, value)
+		}
+	}
+
+	var report *types.UsageReport
+	err := c.makeRequest(ctx, http.MethodGet, "/usage?"+params.Encode(), nil, &report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+	return report, nil
+}
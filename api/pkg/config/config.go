@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -27,6 +28,8 @@ type ServerConfig struct {
 	PubSub             PubSub
 	WebServer          WebServer
 	SubscriptionQuotas SubscriptionQuotas
+	RateLimits         RateLimits
+	ResponseCache      ResponseCache
 	GitHub             GitHub
 	FineTuning         FineTuning
 	Apps               Apps
@@ -94,6 +97,27 @@ type Helix struct {
 	RunnerTTL          time.Duration `envconfig:"HELIX_RUNNER_TTL" default:"30s"`                         // How long before runners are considered dead
 	SchedulingStrategy string        `envconfig:"HELIX_SCHEDULING_STRATEGY" default:"max_spread" description:"The strategy to use for scheduling workloads."`
 	QueueSize          int           `envconfig:"HELIX_QUEUE_SIZE" default:"100" description:"The size of the queue when buffering workloads."`
+	OwnerQueueSize     int           `envconfig:"HELIX_OWNER_QUEUE_SIZE" default:"50" description:"The maximum number of queued workloads per owner, 0 for no limit."`
+	SchedulerTraceFile string        `envconfig:"HELIX_SCHEDULER_TRACE_FILE" description:"Records the scheduled workloads as JSON lines, to replay with helix scheduler simulate."`
+	// ModelRunnerSelectors restricts the runners models are placed on, e.g.
+	// {"llama3.1:70b-instruct-q8_0":{"node_selector":{"gpu":"a100"}}}
+	ModelRunnerSelectors ModelRunnerSelectors `envconfig:"HELIX_MODEL_RUNNER_SELECTORS" description:"JSON map of model names to runner selectors."`
+}
+
+// ModelRunnerSelectors maps model names to the runners they can be placed on
+type ModelRunnerSelectors map[string]*types.RunnerSelector
+
+// Decode implements envconfig.Decoder, the value is a JSON object
+func (m *ModelRunnerSelectors) Decode(value string) error {
+	if value == "" {
+		return nil
+	}
+	selectors := make(map[string]*types.RunnerSelector)
+	if err := json.Unmarshal([]byte(value), &selectors); err != nil {
+		return fmt.Errorf("invalid model runner selectors: %w", err)
+	}
+	*m = selectors
+	return nil
 }
 
 type Tools struct {
@@ -108,6 +132,29 @@ type Tools struct {
 	// IsActionableTemplate is used to determine whether Helix should
 	// use a tool or not. Leave empty for default
 	IsActionableTemplate string `envconfig:"TOOLS_IS_ACTIONABLE_TEMPLATE"` // Either plain text, base64 or path to a file
+
+	// NativeToolCalling declares the tools as functions and lets the model pick
+	// the action and its parameters with tool calls, for models that support
+	// them. The prompts are used when the tool calls fail. Assistants can
+	// enable it too.
+	NativeToolCalling bool `envconfig:"TOOLS_NATIVE_TOOL_CALLING" default:"false"`
+
+	// ApprovalTimeout is how long tool actions wait for approval before they
+	// expire
+	ApprovalTimeout time.Duration `envconfig:"TOOLS_APPROVAL_TIMEOUT" default:"1h"`
+
+	// MCPStdioEnabled lets the MCP tools start their servers as commands on the
+	// API server. The commands inherit the environment of the server, only
+	// enable it when the app authors are trusted. Servers with URLs always work.
+	MCPStdioEnabled bool `envconfig:"TOOLS_MCP_STDIO_ENABLED" default:"false"`
+	// MCPTimeout limits listing the tools of an MCP server or calling one
+	MCPTimeout time.Duration `envconfig:"TOOLS_MCP_TIMEOUT" default:"1m"`
+	// MCPToolsCacheTTL is how long the tools listed by the MCP servers are
+	// reused before they are listed again
+	MCPToolsCacheTTL time.Duration `envconfig:"TOOLS_MCP_TOOLS_CACHE_TTL" default:"5m"`
+	// MCPSessionIdleTimeout is how long the sessions with the MCP servers are
+	// kept open between the calls of a user, 0 opens a session for each call
+	MCPSessionIdleTimeout time.Duration `envconfig:"TOOLS_MCP_SESSION_IDLE_TIMEOUT" default:"5m"`
 }
 
 // Keycloak is used for authentication. You can find keycloak documentation
@@ -232,6 +279,23 @@ type RAG struct {
 		RAGDeleteURL string `envconfig:"RAG_DELETE_URL" default:"http://llamaindex:5000/api/v1/rag" description:"The URL to delete RAG records."`
 	}
 
+	ObjectStore struct {
+		// AmbientCredentials lets S3 and GCS knowledge sources without credential
+		// secrets use the credentials of the server (AWS_ACCESS_KEY_ID,
+		// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables, Google
+		// application default credentials). IRSA, web identity and instance
+		// profile credentials of AWS aren't picked up. Anyone creating knowledge can
+		// then index what the server can read, so it is off by default and never
+		// applies to sources with a custom endpoint.
+		AmbientCredentials bool `envconfig:"RAG_OBJECTSTORE_AMBIENT_CREDENTIALS" default:"false" description:"Whether S3 and GCS knowledge sources without credential secrets can use the credentials of the server."`
+	}
+
+	Git struct {
+		// AllowedHosts limits the hosts that git knowledge sources with a URL
+		// can be cloned from, any public host is allowed when empty
+		AllowedHosts []string `envconfig:"RAG_GIT_ALLOWED_HOSTS" description:"The hosts git knowledge sources can be cloned from, all public hosts are allowed when empty. Set it to clone from hosts on private networks."`
+	}
+
 	Crawler struct {
 		ChromeURL       string `envconfig:"RAG_CRAWLER_CHROME_URL" default:"http://chrome:9222" description:"The URL to the Chrome instance."`
 		LauncherEnabled bool   `envconfig:"RAG_CRAWLER_LAUNCHER_ENABLED" default:"true" description:"Whether to use the Launcher to start the browser."`
@@ -395,6 +459,30 @@ type SubscriptionQuotas struct {
 	}
 }
 
+// RateLimits are the default limits of the chat completions of users and API
+// keys, admins can set other limits per user, API key, app and provider
+type RateLimits struct {
+	Enabled                 bool  `envconfig:"RATE_LIMITS_ENABLED" default:"true"`
+	UserRequestsPerMinute   int64 `envconfig:"RATE_LIMITS_USER_REQUESTS_PER_MINUTE" default:"0" description:"Requests per minute of every user, 0 for no limit."`
+	UserTokensPerDay        int64 `envconfig:"RATE_LIMITS_USER_TOKENS_PER_DAY" default:"0" description:"Tokens per day of every user, 0 for no limit."`
+	UserTokensPerMonth      int64 `envconfig:"RATE_LIMITS_USER_TOKENS_PER_MONTH" default:"0" description:"Tokens per month of every user, 0 for no limit."`
+	APIKeyRequestsPerMinute int64 `envconfig:"RATE_LIMITS_API_KEY_REQUESTS_PER_MINUTE" default:"0" description:"Requests per minute of every API key, 0 for no limit."`
+	APIKeyTokensPerDay      int64 `envconfig:"RATE_LIMITS_API_KEY_TOKENS_PER_DAY" default:"0" description:"Tokens per day of every API key, 0 for no limit."`
+	APIKeyTokensPerMonth    int64 `envconfig:"RATE_LIMITS_API_KEY_TOKENS_PER_MONTH" default:"0" description:"Tokens per month of every API key, 0 for no limit."`
+}
+
+// ResponseCache caches the chat completions of the apps that enable it. Prompts
+// are embedded with the embeddings provider to find answers to similar
+// questions, only identical requests are matched when it isn't set.
+type ResponseCache struct {
+	Enabled             bool          `envconfig:"RESPONSE_CACHE_ENABLED" default:"true" description:"Allow apps to enable the response cache."`
+	MaxEntries          int           `envconfig:"RESPONSE_CACHE_MAX_ENTRIES" default:"10000" description:"The maximum number of cached responses, the least recently used are evicted."`
+	TTL                 time.Duration `envconfig:"RESPONSE_CACHE_TTL" default:"1h" description:"How long responses are cached if the app doesn't set a TTL."`
+	SimilarityThreshold float64       `envconfig:"RESPONSE_CACHE_SIMILARITY_THRESHOLD" default:"0.95" description:"The cosine similarity above which a question gets the answer of a similar one."`
+	EmbeddingsProvider  string        `envconfig:"RESPONSE_CACHE_EMBEDDINGS_PROVIDER" description:"The provider to embed prompts with, one of openai, togetherai, vllm, helix or a provider endpoint."`
+	EmbeddingsModel     string        `envconfig:"RESPONSE_CACHE_EMBEDDINGS_MODEL" default:"text-embedding-3-small" description:"The model to embed prompts with."`
+}
+
 type GitHub struct {
 	Enabled      bool   `envconfig:"GITHUB_INTEGRATION_ENABLED" default:"false" description:"Enable github integration."`
 	ClientID     string `envconfig:"GITHUB_INTEGRATION_CLIENT_ID" description:"The github app client id."`
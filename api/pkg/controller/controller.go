@@ -15,6 +15,7 @@ import (
 	"github.com/helixml/helix/api/pkg/openai/manager"
 	"github.com/helixml/helix/api/pkg/pubsub"
 	"github.com/helixml/helix/api/pkg/rag"
+	"github.com/helixml/helix/api/pkg/ratelimit"
 	"github.com/helixml/helix/api/pkg/scheduler"
 	"github.com/helixml/helix/api/pkg/store"
 	"github.com/helixml/helix/api/pkg/tools"
@@ -36,6 +37,9 @@ type Options struct {
 	DataprepOpenAIClient openai.Client
 	Scheduler            *scheduler.Scheduler
 	RunnerController     *scheduler.RunnerController
+	// RateLimiter enforces the rate limits of the chat completions, not set
+	// when they're disabled
+	RateLimiter *ratelimit.Limiter
 }
 
 type Controller struct {
@@ -452,6 +452,7 @@ func (c *Controller) getRAGResults(session *types.Session) ([]*types.SessionRAGR
 		DistanceThreshold: session.Metadata.RagSettings.Threshold,
 		DistanceFunction:  session.Metadata.RagSettings.DistanceFunction,
 		MaxResults:        session.Metadata.RagSettings.ResultsCount,
+		SearchMode:        session.Metadata.RagSettings.SearchMode,
 	})
 }
 
@@ -120,6 +120,7 @@ func (c *Controller) GetDashboardData(_ context.Context) (*types.DashboardData,
 			TotalMemory: runnerStatus.TotalMemory,
 			FreeMemory:  runnerStatus.FreeMemory,
 			Labels:      runnerStatus.Labels,
+			Taints:      runnerStatus.Taints,
 			Slots:       runnerSlots,
 		})
 	}
@@ -3,15 +3,20 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/helixml/helix/api/pkg/auth"
 	"github.com/helixml/helix/api/pkg/data"
 	"github.com/helixml/helix/api/pkg/model"
 	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/openai/cache"
 	"github.com/helixml/helix/api/pkg/openai/manager"
 	"github.com/helixml/helix/api/pkg/prompts"
 	"github.com/helixml/helix/api/pkg/pubsub"
 	"github.com/helixml/helix/api/pkg/rag"
+	"github.com/helixml/helix/api/pkg/ratelimit"
 	"github.com/helixml/helix/api/pkg/store"
 	"github.com/helixml/helix/api/pkg/tools"
 	"github.com/helixml/helix/api/pkg/types"
@@ -28,6 +33,26 @@ type ChatCompletionOptions struct {
 	Provider    string
 
 	QueryParams map[string]string
+
+	// RunnerSelector is set by the controller to the runner selector of the
+	// app, restricting the runners its requests are scheduled on
+	RunnerSelector *types.RunnerSelector
+
+	// ResponseCache is set by the controller to the response cache config of
+	// the app
+	ResponseCache *types.ResponseCacheConfig
+
+	// NativeToolCalling is set by the controller when the assistant uses the
+	// function calling of the model for its tools
+	NativeToolCalling bool
+
+	// Citations are set by the controller to the knowledge chunks that were
+	// added to the prompt, so callers can show the sources of the answer
+	Citations []*types.Citation
+
+	// RateLimit is set by the controller to the rate limit status of the
+	// request, so callers can return it in the x-ratelimit headers
+	RateLimit *ratelimit.Status
 }
 
 // ChatCompletion is used by the OpenAI compatible API. Doesn't handle any historical sessions, etc.
@@ -40,10 +65,19 @@ func (c *Controller) ChatCompletion(ctx context.Context, user *types.User, req o
 		return nil, nil, err
 	}
 
+	if opts.RunnerSelector != nil {
+		ctx = oai.SetContextRunnerSelector(ctx, opts.RunnerSelector)
+	}
+
 	if assistant.Provider != "" {
 		opts.Provider = assistant.Provider
 	}
 
+	ctx, err = c.checkRateLimits(ctx, user, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	if len(assistant.Tools) > 0 {
 		// Check whether the app is configured for the call,
 		// if yes, execute the tools and return the response
@@ -74,11 +108,15 @@ func (c *Controller) ChatCompletion(ctx context.Context, user *types.User, req o
 		opts.RAGSourceID = assistant.RAGSourceID
 	}
 
+	prompt := getLastMessage(req)
+
 	err = c.enrichPromptWithKnowledge(ctx, user, &req, assistant, opts)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to enrich prompt with knowledge: %w", err)
 	}
 
+	ctx = setResponseCacheContext(ctx, prompt, opts)
+
 	client, err := c.getClient(ctx, user.ID, opts.Provider)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get client: %v", err)
@@ -104,10 +142,19 @@ func (c *Controller) ChatCompletionStream(ctx context.Context, user *types.User,
 		return nil, nil, err
 	}
 
+	if opts.RunnerSelector != nil {
+		ctx = oai.SetContextRunnerSelector(ctx, opts.RunnerSelector)
+	}
+
 	if assistant.Provider != "" {
 		opts.Provider = assistant.Provider
 	}
 
+	ctx, err = c.checkRateLimits(ctx, user, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	if len(assistant.Tools) > 0 {
 		// Check whether the app is configured for the call,
 		// if yes, execute the tools and return the response
@@ -142,12 +189,16 @@ func (c *Controller) ChatCompletionStream(ctx context.Context, user *types.User,
 		opts.Provider = assistant.Provider
 	}
 
+	prompt := getLastMessage(req)
+
 	// Check for knowledge
 	err = c.enrichPromptWithKnowledge(ctx, user, &req, assistant, opts)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to enrich prompt with knowledge: %w", err)
 	}
 
+	ctx = setResponseCacheContext(ctx, prompt, opts)
+
 	client, err := c.getClient(ctx, user.ID, opts.Provider)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get client: %v", err)
@@ -162,6 +213,50 @@ func (c *Controller) ChatCompletionStream(ctx context.Context, user *types.User,
 	return stream, &req, nil
 }
 
+// checkRateLimits counts the request in the rate limits of the user, API key
+// and app. The returned context accounts the tokens of the LLM calls to them,
+// the provider is limited by the client of the provider serving the request.
+func (c *Controller) checkRateLimits(ctx context.Context, user *types.User, opts *ChatCompletionOptions) (context.Context, error) {
+	if c.Options.RateLimiter == nil {
+		return ctx, nil
+	}
+
+	subjects := ratelimit.Subjects(user, opts.AppID)
+
+	status, err := c.Options.RateLimiter.Allow(ctx, subjects)
+	opts.RateLimit = status
+	if err != nil {
+		return ctx, err
+	}
+
+	return ratelimit.SetContextSubjects(ctx, subjects), nil
+}
+
+// setResponseCacheContext enables the response cache for the request if the app
+// enabled it. It's only set for the final completion, the tool calls aren't
+// cached. The prompt is the question before it was enriched with knowledge.
+func setResponseCacheContext(ctx context.Context, prompt string, opts *ChatCompletionOptions) context.Context {
+	if opts.ResponseCache == nil || !opts.ResponseCache.Enabled {
+		return ctx
+	}
+
+	cacheOpts := &cache.Options{
+		Scope:               opts.AppID,
+		Prompt:              prompt,
+		Knowledge:           opts.Citations,
+		SimilarityThreshold: opts.ResponseCache.SimilarityThreshold,
+	}
+	if opts.ResponseCache.TTL != "" {
+		ttl, err := time.ParseDuration(opts.ResponseCache.TTL)
+		if err != nil {
+			log.Warn().Err(err).Str("app_id", opts.AppID).Msg("invalid response cache TTL, using the default")
+		}
+		cacheOpts.TTL = ttl
+	}
+
+	return cache.SetContextOptions(ctx, cacheOpts)
+}
+
 func (c *Controller) getClient(ctx context.Context, owner, provider string) (oai.Client, error) {
 	if provider == "" {
 		// If not set, use the default provider
@@ -217,7 +312,14 @@ func (c *Controller) evaluateToolUsage(ctx context.Context, user *types.User, re
 		return nil, false, fmt.Errorf("failed to get client: %w", err)
 	}
 
-	options = append(options, tools.WithClient(apieClient))
+	options = append(options, tools.WithClient(apieClient), tools.WithOwner(user.ID), tools.WithAppID(opts.AppID))
+
+	if opts.NativeToolCalling {
+		options = append(options, tools.WithNativeToolCalling(true))
+	}
+	if isActionable.Arguments != nil {
+		options = append(options, tools.WithArguments(isActionable.Arguments))
+	}
 
 	resp, err := c.ToolsPlanner.RunAction(ctx, vals.SessionID, vals.InteractionID, selectedTool, history, isActionable.API, options...)
 	if err != nil {
@@ -283,7 +385,14 @@ func (c *Controller) evaluateToolUsageStream(ctx context.Context, user *types.Us
 		return nil, false, fmt.Errorf("failed to get client: %w", err)
 	}
 
-	options = append(options, tools.WithClient(apieClient))
+	options = append(options, tools.WithClient(apieClient), tools.WithOwner(user.ID), tools.WithAppID(opts.AppID))
+
+	if opts.NativeToolCalling {
+		options = append(options, tools.WithNativeToolCalling(true))
+	}
+	if isActionable.Arguments != nil {
+		options = append(options, tools.WithArguments(isActionable.Arguments))
+	}
 
 	stream, err := c.ToolsPlanner.RunActionStream(ctx, vals.SessionID, vals.InteractionID, selectedTool, history, isActionable.API, options...)
 	if err != nil {
@@ -347,7 +456,13 @@ func (c *Controller) selectAndConfigureTool(ctx context.Context, user *types.Use
 		return nil, nil, false, fmt.Errorf("failed to get client: %w", err)
 	}
 
-	options = append(options, tools.WithClient(apieClient))
+	// The owner and app select the secrets of the MCP servers listing their
+	// tools
+	options = append(options, tools.WithClient(apieClient), tools.WithOwner(user.ID), tools.WithAppID(opts.AppID))
+
+	if opts.NativeToolCalling {
+		options = append(options, tools.WithNativeToolCalling(true))
+	}
 
 	history := types.HistoryFromChatCompletionRequest(req)
 
@@ -387,6 +502,26 @@ func (c *Controller) selectAndConfigureTool(ctx context.Context, user *types.Use
 		return nil, nil, false, fmt.Errorf("tool not found for action: %s", isActionable.API)
 	}
 
+	// Chat completions can't wait for approvals, actions that need one are
+	// left to the model like the denied ones
+	if policy := tools.GetActionPolicy(selectedTool, isActionable.API); policy != types.ToolActionPolicyAuto {
+		log.Info().
+			Str("tool", selectedTool.Name).
+			Str("action", isActionable.API).
+			Str("policy", string(policy)).
+			Msg("tool action is not run automatically, skipping")
+
+		if err := c.emitStepInfo(ctx, &types.StepInfo{
+			Name:    selectedTool.Name,
+			Type:    types.StepInfoTypeToolUse,
+			Message: fmt.Sprintf("Action %s is not allowed to run automatically, skipping", isActionable.API),
+		}); err != nil {
+			log.Debug().Err(err).Msg("failed to emit step info")
+		}
+
+		return nil, nil, false, nil
+	}
+
 	// If assistant has configured a model, give the hint to the tool that it should use that model too
 	if assistant != nil && assistant.Model != "" {
 		if selectedTool.Config.API != nil && selectedTool.Config.API.Model == "" {
@@ -426,8 +561,12 @@ func (c *Controller) loadAssistant(ctx context.Context, user *types.User, opts *
 		return nil, fmt.Errorf("error getting app: %w", err)
 	}
 
-	if (!app.Global && !app.Shared) && app.Owner != user.ID {
-		return nil, fmt.Errorf("you do not have access to the app with the id: %s", app.ID)
+	err = auth.NewAuthorizer(c.Options.Store).Authorize(ctx, user, auth.AppResource(app), auth.ActionUse)
+	if err != nil {
+		if errors.Is(err, auth.ErrForbidden) {
+			return nil, fmt.Errorf("you do not have access to the app with the id: %s", app.ID)
+		}
+		return nil, fmt.Errorf("error checking app access: %w", err)
 	}
 
 	// Load secrets into the app
@@ -438,10 +577,15 @@ func (c *Controller) loadAssistant(ctx context.Context, user *types.User, opts *
 
 	assistant := data.GetAssistant(app, opts.AssistantID)
 
+	opts.RunnerSelector = app.Config.Helix.RunnerSelector
+	opts.ResponseCache = app.Config.Helix.ResponseCache
+
 	if assistant == nil {
 		return nil, fmt.Errorf("we could not find the assistant with ID %s, in app %s", opts.AssistantID, app.ID)
 	}
 
+	opts.NativeToolCalling = assistant.NativeToolCalling
+
 	return assistant, nil
 }
 
@@ -453,6 +597,19 @@ func (c *Controller) evaluateSecrets(ctx context.Context, user *types.User, app
 		return nil, fmt.Errorf("failed to list secrets: %w", err)
 	}
 
+	// Apps owned by an organization or team also get the owner's secrets
+	switch app.OwnerType {
+	case types.OwnerTypeOrg, types.OwnerTypeTeam:
+		ownerSecrets, err := c.Options.Store.ListSecrets(ctx, &store.ListSecretsQuery{
+			Owner:     app.Owner,
+			OwnerType: app.OwnerType,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s secrets: %w", app.OwnerType, err)
+		}
+		secrets = append(secrets, ownerSecrets...)
+	}
+
 	var filteredSecrets []*types.Secret
 
 	// Filter out secrets that are not for the current app
@@ -517,9 +674,39 @@ func (c *Controller) enrichPromptWithKnowledge(ctx context.Context, user *types.
 		}
 	}
 
+	opts.Citations = getCitations(ragResults, knowledgeResults)
+
 	return nil
 }
 
+// getCitations returns the citations for the knowledge that was added to the prompt
+func getCitations(ragResults []*prompts.RagContent, knowledgeResults []*prompts.BackgroundKnowledge) []*types.Citation {
+	citations := make([]*types.Citation, 0, len(ragResults)+len(knowledgeResults))
+
+	for _, result := range ragResults {
+		citations = append(citations, &types.Citation{
+			DataEntityID:  result.DataEntityID,
+			DocumentID:    result.DocumentID,
+			Source:        result.Source,
+			ContentOffset: result.ContentOffset,
+			Content:       result.Content,
+		})
+	}
+
+	for _, result := range knowledgeResults {
+		citations = append(citations, &types.Citation{
+			KnowledgeID:      result.KnowledgeID,
+			KnowledgeVersion: result.KnowledgeVersion,
+			DocumentID:       result.DocumentID,
+			Source:           result.Source,
+			ContentOffset:    result.ContentOffset,
+			Content:          result.Content,
+		})
+	}
+
+	return citations
+}
+
 func (c *Controller) evaluateRAG(ctx context.Context, user *types.User, req openai.ChatCompletionRequest, opts *ChatCompletionOptions) ([]*prompts.RagContent, error) {
 	if opts.RAGSourceID == "" {
 		return []*prompts.RagContent{}, nil
@@ -534,12 +721,13 @@ func (c *Controller) evaluateRAG(ctx context.Context, user *types.User, req open
 		return nil, fmt.Errorf("you do not have access to the data entity with the id: %s", entity.ID)
 	}
 
-	ragResults, err := c.Options.RAG.Query(ctx, &types.SessionRAGQuery{
+	ragResults, err := c.queryRAG(ctx, c.Options.RAG, user.ID, types.OwnerTypeUser, &entity.Config.RAGSettings, &types.SessionRAGQuery{
 		Prompt:            getLastMessage(req),
 		DataEntityID:      entity.ID,
 		DistanceThreshold: entity.Config.RAGSettings.Threshold,
 		DistanceFunction:  entity.Config.RAGSettings.DistanceFunction,
 		MaxResults:        entity.Config.RAGSettings.ResultsCount,
+		SearchMode:        entity.Config.RAGSettings.SearchMode,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error querying RAG: %w", err)
@@ -548,8 +736,11 @@ func (c *Controller) evaluateRAG(ctx context.Context, user *types.User, req open
 	ragContent := make([]*prompts.RagContent, 0, len(ragResults))
 	for _, result := range ragResults {
 		ragContent = append(ragContent, &prompts.RagContent{
-			DocumentID: result.DocumentID,
-			Content:    result.Content,
+			DataEntityID:  entity.ID,
+			DocumentID:    result.DocumentID,
+			Source:        result.Source,
+			ContentOffset: result.ContentOffset,
+			Content:       result.Content,
 		})
 	}
 
@@ -581,8 +772,10 @@ func (c *Controller) evaluateKnowledge(
 		// without anything else (no database to search in)
 		case knowledge.Source.Content != nil:
 			backgroundKnowledge = append(backgroundKnowledge, &prompts.BackgroundKnowledge{
-				Description: knowledge.Description,
-				Content:     *knowledge.Source.Content,
+				KnowledgeID:      knowledge.ID,
+				KnowledgeVersion: knowledge.Version,
+				Description:      knowledge.Description,
+				Content:          *knowledge.Source.Content,
 			})
 
 			usedKnowledge = knowledge
@@ -600,12 +793,13 @@ func (c *Controller) evaluateKnowledge(
 				log.Debug().Err(err).Msg("failed to emit step info")
 			}
 
-			ragResults, err := ragClient.Query(ctx, &types.SessionRAGQuery{
+			ragResults, err := c.queryRAG(ctx, ragClient, knowledge.Owner, knowledge.OwnerType, &knowledge.RAGSettings, &types.SessionRAGQuery{
 				Prompt:            prompt,
 				DataEntityID:      knowledge.GetDataEntityID(),
 				DistanceThreshold: knowledge.RAGSettings.Threshold,
 				DistanceFunction:  knowledge.RAGSettings.DistanceFunction,
 				MaxResults:        knowledge.RAGSettings.ResultsCount,
+				SearchMode:        knowledge.RAGSettings.SearchMode,
 			})
 			if err != nil {
 				return nil, nil, fmt.Errorf("error querying RAG: %w", err)
@@ -621,10 +815,13 @@ func (c *Controller) evaluateKnowledge(
 
 			for _, result := range ragResults {
 				backgroundKnowledge = append(backgroundKnowledge, &prompts.BackgroundKnowledge{
-					Description: knowledge.Description,
-					DocumentID:  result.DocumentID,
-					Source:      result.Source,
-					Content:     result.Content,
+					KnowledgeID:      knowledge.ID,
+					KnowledgeVersion: knowledge.Version,
+					Description:      knowledge.Description,
+					DocumentID:       result.DocumentID,
+					Source:           result.Source,
+					ContentOffset:    result.ContentOffset,
+					Content:          result.Content,
 				})
 			}
 
@@ -637,6 +834,64 @@ func (c *Controller) evaluateKnowledge(
 	return backgroundKnowledge, usedKnowledge, nil
 }
 
+// queryRAG searches the index for the query. If the settings have a reranker,
+// more candidates are fetched and reordered by the reranker before being cut
+// down to the requested number of results. The reranker is looked up in the
+// providers of the owner, the results aren't reranked if it can't be created.
+func (c *Controller) queryRAG(ctx context.Context, ragClient rag.RAG, owner string, ownerType types.OwnerType, settings *types.RAGSettings, query *types.SessionRAGQuery) ([]*types.SessionRAGResult, error) {
+	if settings.Reranker.Type == types.RerankerTypeNone {
+		return ragClient.Query(ctx, query)
+	}
+
+	reranker, err := rag.NewReranker(ctx, c.providerManager, owner, ownerType, &settings.Reranker)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("data_entity_id", query.DataEntityID).
+			Str("reranker", string(settings.Reranker.Type)).
+			Msg("failed to create RAG reranker, results won't be reranked")
+		return ragClient.Query(ctx, query)
+	}
+
+	resultsCount := query.MaxResults
+	if resultsCount == 0 {
+		resultsCount = rag.DefaultMaxResults
+	}
+
+	candidates := settings.Reranker.Candidates
+	if candidates == 0 {
+		candidates = rag.DefaultRerankCandidates
+	}
+	if candidates < resultsCount {
+		candidates = resultsCount
+	}
+
+	candidatesQuery := *query
+	candidatesQuery.MaxResults = candidates
+
+	results, err := ragClient.Query(ctx, &candidatesQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	reranked, err := reranker.Rerank(ctx, query.Prompt, results)
+	if err != nil {
+		// Fall back to the search order rather than failing the whole request
+		log.Warn().
+			Err(err).
+			Str("data_entity_id", query.DataEntityID).
+			Str("reranker", string(settings.Reranker.Type)).
+			Msg("failed to rerank RAG results")
+		reranked = results
+	}
+
+	if len(reranked) > resultsCount {
+		reranked = reranked[:resultsCount]
+	}
+
+	return reranked, nil
+}
+
 func (c *Controller) emitStepInfo(ctx context.Context, stepInfo *types.StepInfo) error {
 	vals, ok := oai.GetContextValues(ctx)
 	if !ok {
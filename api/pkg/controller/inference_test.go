@@ -2,6 +2,7 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"testing"
 
@@ -194,10 +195,12 @@ func (suite *ControllerSuite) Test_BasicInferenceWithKnowledge() {
 		},
 	}, nil)
 
-	resp, _, err := suite.controller.ChatCompletion(suite.ctx, suite.user, req, &ChatCompletionOptions{
+	opts := &ChatCompletionOptions{
 		AppID:       "app_id",
 		AssistantID: "0",
-	})
+	}
+
+	resp, _, err := suite.controller.ChatCompletion(suite.ctx, suite.user, req, opts)
 	suite.NoError(err)
 	suite.Equal(&openai.ChatCompletionResponse{
 		Choices: []openai.ChatCompletionChoice{
@@ -208,6 +211,179 @@ func (suite *ControllerSuite) Test_BasicInferenceWithKnowledge() {
 			},
 		},
 	}, resp)
+
+	suite.Equal([]*types.Citation{
+		{
+			KnowledgeID: "knowledge_id",
+			Content:     "foo bar",
+		},
+	}, opts.Citations)
+}
+
+func (suite *ControllerSuite) Test_InferenceWithKnowledgeCitations() {
+	req := openai.ChatCompletionRequest{
+		Model: openai.GPT4TurboPreview,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: "how do I install the agent?",
+			},
+		},
+	}
+
+	app := &types.App{
+		ID:     "app_id",
+		Global: true,
+		Config: types.AppConfig{
+			Helix: types.AppHelixConfig{
+				Assistants: []types.AssistantConfig{
+					{
+						ID: "0",
+						Knowledge: []*types.AssistantKnowledge{
+							{
+								Name: "docs",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	suite.store.EXPECT().GetAppWithTools(suite.ctx, "app_id").Return(app, nil)
+	suite.store.EXPECT().ListSecrets(gomock.Any(), &store.ListSecretsQuery{
+		Owner: suite.user.ID,
+	}).Return([]*types.Secret{}, nil)
+
+	knowledge := &types.Knowledge{
+		ID:      "knowledge_id",
+		AppID:   "app_id",
+		Version: "2024-01-01-00-00-00",
+		Source: types.KnowledgeSource{
+			Web: &types.KnowledgeSourceWeb{
+				URLs: []string{"https://docs.example.com"},
+			},
+		},
+	}
+
+	suite.store.EXPECT().LookupKnowledge(suite.ctx, &store.LookupKnowledgeQuery{
+		Name:  "docs",
+		AppID: "app_id",
+	}).Return(knowledge, nil)
+
+	suite.rag.EXPECT().Query(suite.ctx, gomock.Any()).Return([]*types.SessionRAGResult{
+		{
+			DocumentID:    "doc_1",
+			Source:        "https://docs.example.com/agent/install",
+			ContentOffset: 2048,
+			Content:       "run the install script",
+		},
+	}, nil)
+
+	suite.openAiClient.EXPECT().CreateChatCompletion(suite.ctx, gomock.Any()).Return(openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{
+			{
+				Message: openai.ChatCompletionMessage{
+					Content: "Run the install script",
+				},
+			},
+		},
+	}, nil)
+
+	opts := &ChatCompletionOptions{
+		AppID:       "app_id",
+		AssistantID: "0",
+	}
+
+	_, _, err := suite.controller.ChatCompletion(suite.ctx, suite.user, req, opts)
+	suite.Require().NoError(err)
+
+	suite.Equal([]*types.Citation{
+		{
+			KnowledgeID:      "knowledge_id",
+			KnowledgeVersion: "2024-01-01-00-00-00",
+			DocumentID:       "doc_1",
+			Source:           "https://docs.example.com/agent/install",
+			ContentOffset:    2048,
+			Content:          "run the install script",
+		},
+	}, opts.Citations)
+}
+
+func (suite *ControllerSuite) Test_QueryRAGWithReranker() {
+	settings := &types.RAGSettings{
+		ResultsCount: 2,
+		Reranker: types.RerankerSettings{
+			Type:       types.RerankerTypeCrossEncoder,
+			Provider:   "vllm",
+			Model:      "BAAI/bge-reranker-v2-m3",
+			Candidates: 4,
+		},
+	}
+
+	suite.rag.EXPECT().Query(suite.ctx, &types.SessionRAGQuery{
+		Prompt:       "what is E1234?",
+		DataEntityID: "knowledge_id",
+		MaxResults:   4,
+	}).Return([]*types.SessionRAGResult{
+		{DocumentID: "doc_1", Content: "one"},
+		{DocumentID: "doc_2", Content: "two"},
+		{DocumentID: "doc_3", Content: "three"},
+	}, nil)
+
+	suite.openAiClient.EXPECT().Rerank(suite.ctx, gomock.Any()).Return(oai.RerankResponse{
+		Results: []oai.RerankResult{
+			{Index: 0, RelevanceScore: 0.1},
+			{Index: 1, RelevanceScore: 0.5},
+			{Index: 2, RelevanceScore: 0.9},
+		},
+	}, nil)
+
+	results, err := suite.controller.queryRAG(suite.ctx, suite.rag, suite.user.ID, types.OwnerTypeUser, settings, &types.SessionRAGQuery{
+		Prompt:       "what is E1234?",
+		DataEntityID: "knowledge_id",
+		MaxResults:   settings.ResultsCount,
+	})
+	suite.Require().NoError(err)
+	suite.Require().Len(results, 2)
+	suite.Equal("doc_3", results[0].DocumentID)
+	suite.Equal("doc_2", results[1].DocumentID)
+}
+
+func (suite *ControllerSuite) Test_QueryRAGWithReranker_ProviderNotFound() {
+	settings := &types.RAGSettings{
+		ResultsCount: 2,
+		Reranker: types.RerankerSettings{
+			Type:     types.RerankerTypeCrossEncoder,
+			Provider: "vllm",
+			Model:    "BAAI/bge-reranker-v2-m3",
+		},
+	}
+
+	providerManager := manager.NewMockProviderManager(gomock.NewController(suite.T()))
+	providerManager.EXPECT().GetClient(suite.ctx, &manager.GetClientRequest{
+		Provider:  "vllm",
+		Owner:     "org_id",
+		OwnerType: types.OwnerTypeOrg,
+	}).Return(nil, errors.New("provider not found"))
+	suite.controller.providerManager = providerManager
+
+	query := &types.SessionRAGQuery{
+		Prompt:       "what is E1234?",
+		DataEntityID: "knowledge_id",
+		MaxResults:   settings.ResultsCount,
+	}
+
+	suite.rag.EXPECT().Query(suite.ctx, query).Return([]*types.SessionRAGResult{
+		{DocumentID: "doc_1", Content: "one"},
+		{DocumentID: "doc_2", Content: "two"},
+	}, nil)
+
+	results, err := suite.controller.queryRAG(suite.ctx, suite.rag, "org_id", types.OwnerTypeOrg, settings, query)
+	suite.Require().NoError(err)
+	suite.Require().Len(results, 2)
+	suite.Equal("doc_1", results[0].DocumentID)
+	suite.Equal("doc_2", results[1].DocumentID)
 }
 
 func (suite *ControllerSuite) Test_EvaluateSecrets() {
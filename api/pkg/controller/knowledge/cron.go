@@ -148,6 +148,22 @@ func (r *Reconciler) getCronTask(ctx context.Context, knowledgeID string) gocron
 			return
 		}
 
+		// Nothing to re-index if the repository has no new commits
+		unchanged, err := r.gitSourceUnchanged(ctx, knowledge)
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Str("knowledge_id", knowledgeID).
+				Msg("failed to check the latest commit, re-indexing")
+		}
+		if unchanged {
+			log.Info().
+				Str("knowledge_id", knowledgeID).
+				Str("commit", knowledge.CrawledSources.Commit).
+				Msg("repository unchanged, skipping knowledge refresh")
+			return
+		}
+
 		// Generate a new version ID
 		version := system.GenerateVersion()
 
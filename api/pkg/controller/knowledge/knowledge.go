@@ -36,6 +36,7 @@ type Reconciler struct {
 	ragClient    rag.RAG                                   // Default server RAG client
 	newRagClient func(settings *types.RAGSettings) rag.RAG // Custom RAG server client constructor
 	newCrawler   func(k *types.Knowledge) (crawler.Crawler, error)
+	checkGitURL  func(ctx context.Context, rawURL string) error // Validates the git urls before they are cloned
 	progressMu   *sync.RWMutex
 	progress     map[string]types.KnowledgeProgress
 	cron         gocron.Scheduler
@@ -59,6 +60,9 @@ func New(config *config.ServerConfig, store store.Store, filestore filestore.Fil
 		newRagClient: func(settings *types.RAGSettings) rag.RAG {
 			return rag.NewLlamaindex(settings)
 		},
+		checkGitURL: func(ctx context.Context, rawURL string) error {
+			return validateGitRemote(ctx, config, rawURL)
+		},
 		// newCrawler: ,
 		progressMu: &sync.RWMutex{},
 		progress:   make(map[string]types.KnowledgeProgress),
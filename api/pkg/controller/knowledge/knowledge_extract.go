@@ -22,6 +22,12 @@ func (r *Reconciler) getIndexingData(ctx context.Context, k *types.Knowledge) ([
 		return r.extractDataFromWeb(ctx, k)
 	case k.Source.Filestore != nil:
 		return r.extractDataFromHelixFilestore(ctx, k)
+	case k.Source.Github != nil:
+		return r.extractDataFromGit(ctx, k)
+	case k.Source.S3 != nil:
+		return r.extractDataFromS3(ctx, k)
+	case k.Source.GCS != nil:
+		return r.extractDataFromGCS(ctx, k)
 	default:
 		return nil, fmt.Errorf("unknown source: %+v", k.Source)
 	}
@@ -73,6 +79,7 @@ func (r *Reconciler) extractDataFromWeb(ctx context.Context, k *types.Knowledge)
 				Data:            []byte(extracted),
 				Source:          u,
 				DocumentGroupID: getDocumentGroupID(u),
+				ContentHash:     getContentHash([]byte(extracted)),
 			})
 
 			continue
@@ -88,6 +95,7 @@ func (r *Reconciler) extractDataFromWeb(ctx context.Context, k *types.Knowledge)
 			Data:            bts,
 			Source:          u,
 			DocumentGroupID: getDocumentGroupID(u),
+			ContentHash:     getContentHash(bts),
 		})
 	}
 
@@ -139,6 +147,7 @@ func (r *Reconciler) extractDataFromWebWithCrawler(ctx context.Context, k *types
 			StatusCode:      doc.StatusCode,
 			DurationMs:      doc.DurationMs,
 			Message:         doc.Message,
+			ContentHash:     getContentHash([]byte(doc.Content)),
 		})
 	}
 
@@ -193,6 +202,12 @@ func (r *Reconciler) extractDataFromHelixFilestore(ctx context.Context, k *types
 		Int64("count", int64(len(data))).
 		Msg("filestore data found")
 
+	return r.extractFileData(ctx, k, data)
+}
+
+// extractFileData extracts the text from the raw files, for example PDFs or
+// Word documents
+func (r *Reconciler) extractFileData(ctx context.Context, k *types.Knowledge, data []*indexerData) ([]*indexerData, error) {
 	// Optional mode to disable text extractor and chunking,
 	// useful when the indexing server will know how to handle
 	// raw data directly
@@ -200,10 +215,24 @@ func (r *Reconciler) extractDataFromHelixFilestore(ctx context.Context, k *types
 		return data, nil
 	}
 
+	// Unchanged files are copied from the previous version, no need to extract them again
+	previous := r.getPreviousDocuments(ctx, k)
+
 	// Chunking enabled, extracting text
 	var extractedData []*indexerData
 
 	for _, d := range data {
+		if p, ok := previous[d.getDocumentGroupID()]; ok && p.ContentHash == d.ContentHash {
+			extractedData = append(extractedData, &indexerData{
+				Source:          d.Source,
+				DocumentGroupID: d.getDocumentGroupID(),
+				ContentHash:     d.ContentHash,
+				Unchanged:       true,
+				Size:            p.Size,
+			})
+			continue
+		}
+
 		extractedText, err := r.extractor.Extract(ctx, &extract.Request{
 			Content: d.Data,
 		})
@@ -214,7 +243,8 @@ func (r *Reconciler) extractDataFromHelixFilestore(ctx context.Context, k *types
 		extractedData = append(extractedData, &indexerData{
 			Data:            []byte(extractedText),
 			Source:          d.Source,
-			DocumentGroupID: getDocumentGroupID(d.Source),
+			DocumentGroupID: d.getDocumentGroupID(),
+			ContentHash:     d.ContentHash,
 		})
 	}
 
@@ -253,6 +283,7 @@ func (r *Reconciler) getFilestoreFiles(ctx context.Context, fs filestore.FileSto
 					Data:            bts,
 					Source:          item.Path,
 					DocumentGroupID: getDocumentGroupID(item.Path),
+					ContentHash:     getContentHash(bts),
 				})
 			}
 		}
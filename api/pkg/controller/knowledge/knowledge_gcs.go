@@ -0,0 +1,110 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud.google.com/go/storage"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func (r *Reconciler) extractDataFromGCS(ctx context.Context, k *types.Knowledge) ([]*indexerData, error) {
+	client, err := r.newGCSClient(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	source := k.Source.GCS
+	bucket := client.Bucket(source.Bucket)
+
+	var data []*indexerData
+
+	it := bucket.Objects(ctx, &storage.Query{Prefix: source.Path})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects in gs://%s/%s: %w", source.Bucket, source.Path, err)
+		}
+
+		if !objectIndexable(attrs.Name, attrs.Size, source.FilterExtensions) {
+			continue
+		}
+
+		bts, err := readGCSObject(ctx, bucket, attrs.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get gs://%s/%s: %w", source.Bucket, attrs.Name, err)
+		}
+
+		u := fmt.Sprintf("gs://%s/%s", source.Bucket, attrs.Name)
+
+		data = append(data, &indexerData{
+			Data:            bts,
+			Source:          u,
+			DocumentGroupID: getDocumentGroupID(u),
+			ContentHash:     getContentHash(bts),
+		})
+	}
+
+	log.Info().
+		Str("knowledge_id", k.ID).
+		Str("bucket", source.Bucket).
+		Str("prefix", source.Path).
+		Int("count", len(data)).
+		Msg("gcs objects found")
+
+	return r.extractFileData(ctx, k, data)
+}
+
+func (r *Reconciler) newGCSClient(ctx context.Context, k *types.Knowledge) (*storage.Client, error) {
+	secrets, err := r.getSourceSecrets(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+
+	source := k.Source.GCS
+
+	var opts []option.ClientOption
+
+	if source.Endpoint != "" {
+		opts = append(opts, option.WithEndpoint(source.Endpoint))
+	}
+
+	switch key := secrets["GCS_SERVICE_ACCOUNT_KEY"]; {
+	case key != "":
+		opts = append(opts, option.WithCredentialsJSON([]byte(key)))
+	case source.Endpoint != "":
+		// Custom endpoints are usually emulators that don't need credentials,
+		// the server's own are never sent there
+		opts = append(opts, option.WithoutAuthentication())
+	case r.ambientCredentials(source.Endpoint):
+		// The application default credentials of the server are used
+	default:
+		return nil, fmt.Errorf("GCS_SERVICE_ACCOUNT_KEY secret is required to index gs://%s", source.Bucket)
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage client: %w", err)
+	}
+
+	return client, nil
+}
+
+func readGCSObject(ctx context.Context, bucket *storage.BucketHandle, name string) ([]byte, error) {
+	reader, err := bucket.Object(name).NewReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return readObject(reader)
+}
@@ -0,0 +1,274 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/github"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// maxGitFileSize files larger than this are skipped, they are usually
+// generated or vendored
+const maxGitFileSize = 1024 * 1024
+
+type gitRemote struct {
+	url  string
+	auth transport.AuthMethod
+}
+
+func (r *Reconciler) extractDataFromGit(ctx context.Context, k *types.Knowledge) ([]*indexerData, error) {
+	remote, err := r.getGitRemote(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "helix-knowledge-git-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cloneOpts := &git.CloneOptions{
+		URL:          remote.url,
+		Auth:         remote.auth,
+		SingleBranch: true,
+		Depth:        1,
+	}
+	if k.Source.Github.Branch != "" {
+		cloneOpts.ReferenceName = plumbing.NewBranchReferenceName(k.Source.Github.Branch)
+	}
+
+	repo, err := git.PlainCloneContext(ctx, dir, false, cloneOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone %s: %w", remote.url, err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD of %s: %w", remote.url, err)
+	}
+
+	commit := head.Hash().String()
+
+	data, err := readGitFiles(dir, k.Source.Github)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range data {
+		// The group of the chunks follows the path so unchanged files can be
+		// reused, while the citations open the indexed version of the file
+		d.DocumentGroupID = getDocumentGroupID(d.Source)
+		d.Source = getGitSourceURL(k.Source.Github, commit, d.Source)
+		d.Commit = commit
+	}
+
+	log.Info().
+		Str("knowledge_id", k.ID).
+		Str("repository", remote.url).
+		Str("commit", commit).
+		Int("count", len(data)).
+		Msg("git repository files found")
+
+	return data, nil
+}
+
+// gitSourceUnchanged checks whether the latest commit of the branch is the one
+// that was indexed last time, so the refresh can be skipped
+func (r *Reconciler) gitSourceUnchanged(ctx context.Context, k *types.Knowledge) (bool, error) {
+	if k.Source.Github == nil || k.CrawledSources == nil || k.CrawledSources.Commit == "" {
+		return false, nil
+	}
+
+	remote, err := r.getGitRemote(ctx, k)
+	if err != nil {
+		return false, err
+	}
+
+	commit, err := getRemoteCommit(ctx, remote, k.Source.Github.Branch)
+	if err != nil {
+		return false, err
+	}
+
+	return commit == k.CrawledSources.Commit, nil
+}
+
+func (r *Reconciler) getGitRemote(ctx context.Context, k *types.Knowledge) (*gitRemote, error) {
+	source := k.Source.Github
+
+	if source.URL != "" {
+		if err := r.checkGitURL(ctx, source.URL); err != nil {
+			return nil, err
+		}
+
+		return &gitRemote{url: source.URL}, nil
+	}
+
+	// Use the deploy key of the app if it's connected to GitHub, otherwise
+	// the repository has to be public
+	if k.AppID != "" {
+		app, err := r.store.GetApp(ctx, k.AppID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get app %s: %w", k.AppID, err)
+		}
+
+		if app.Config.Github != nil && app.Config.Github.KeyPair.PrivateKey != "" {
+			return &gitRemote{
+				url:  fmt.Sprintf("git@github.com:%s/%s.git", source.Owner, source.Repository),
+				auth: github.MakeAuth(app.Config.Github.KeyPair),
+			}, nil
+		}
+	}
+
+	return &gitRemote{
+		url: fmt.Sprintf("https://github.com/%s/%s.git", source.Owner, source.Repository),
+	}, nil
+}
+
+// getRemoteCommit lists the remote references without cloning the repository
+func getRemoteCommit(ctx context.Context, remote *gitRemote, branch string) (string, error) {
+	rem := git.NewRemote(memory.NewStorage(), &gitconfig.RemoteConfig{
+		Name: "origin",
+		URLs: []string{remote.url},
+	})
+
+	refs, err := rem.ListContext(ctx, &git.ListOptions{
+		Auth: remote.auth,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list references of %s: %w", remote.url, err)
+	}
+
+	name := plumbing.HEAD
+	if branch != "" {
+		name = plumbing.NewBranchReferenceName(branch)
+	}
+
+	hashes := make(map[plumbing.ReferenceName]plumbing.Hash, len(refs))
+	for _, ref := range refs {
+		hashes[ref.Name()] = ref.Hash()
+	}
+
+	for _, ref := range refs {
+		if ref.Name() != name {
+			continue
+		}
+
+		if ref.Type() == plumbing.SymbolicReference {
+			if hash, ok := hashes[ref.Target()]; ok {
+				return hash.String(), nil
+			}
+			break
+		}
+
+		return ref.Hash().String(), nil
+	}
+
+	return "", fmt.Errorf("reference %s not found in %s", name, remote.url)
+}
+
+// readGitFiles reads the text files of the checked out repository, applying
+// the path and extension filters. Sources are the paths relative to the
+// repository root.
+func readGitFiles(dir string, source *types.KnowledgeSourceGithub) ([]*indexerData, error) {
+	var result []*indexerData
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if !matchesGitFilters(relPath, source) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if info.Size() == 0 || info.Size() > maxGitFileSize {
+			return nil
+		}
+
+		bts, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %w", relPath, err)
+		}
+
+		// Skip binary files
+		if bytes.IndexByte(bts, 0) != -1 {
+			return nil
+		}
+
+		result = append(result, &indexerData{
+			Data:        bts,
+			Source:      relPath,
+			ContentHash: getContentHash(bts),
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read repository files: %w", err)
+	}
+
+	return result, nil
+}
+
+func matchesGitFilters(path string, source *types.KnowledgeSourceGithub) bool {
+	if len(source.FilterPaths) > 0 {
+		matched := false
+		for _, p := range source.FilterPaths {
+			if strings.HasPrefix(path, strings.TrimPrefix(p, "/")) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return matchesExtensions(path, source.FilterExtensions)
+}
+
+// getGitSourceURL links GitHub files to the indexed commit so citations open the
+// exact version of the file, other repositories use the path in the repository
+func getGitSourceURL(source *types.KnowledgeSourceGithub, commit, path string) string {
+	if source.URL == "" {
+		return fmt.Sprintf("https://github.com/%s/%s/blob/%s/%s", source.Owner, source.Repository, commit, path)
+	}
+
+	return path
+}
@@ -0,0 +1,158 @@
+package knowledge
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// createGitRepo creates a local repository with the files committed on the main branch
+func (suite *ExtractorSuite) createGitRepo(files map[string]string) (string, *git.Repository) {
+	dir := suite.T().TempDir()
+
+	repo, err := git.PlainInit(dir, false)
+	suite.Require().NoError(err)
+
+	suite.commitFiles(dir, repo, files)
+
+	// Clone the local repository
+	suite.reconciler.checkGitURL = func(_ context.Context, _ string) error {
+		return nil
+	}
+
+	return dir, repo
+}
+
+func (suite *ExtractorSuite) commitFiles(dir string, repo *git.Repository, files map[string]string) string {
+	worktree, err := repo.Worktree()
+	suite.Require().NoError(err)
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		suite.Require().NoError(os.MkdirAll(filepath.Dir(path), 0o755))
+		suite.Require().NoError(os.WriteFile(path, []byte(content), 0o644))
+
+		_, err = worktree.Add(name)
+		suite.Require().NoError(err)
+	}
+
+	hash, err := worktree.Commit("update", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	suite.Require().NoError(err)
+
+	return hash.String()
+}
+
+func (suite *ExtractorSuite) Test_getIndexingData_Git() {
+	dir, repo := suite.createGitRepo(map[string]string{
+		"README.md":        "# Project",
+		"docs/install.md":  "Run the installer",
+		"pkg/server.go":    "package pkg\n\nfunc Serve() {}\n",
+		"pkg/logo.png":     "\x89PNG\x00\x00",
+		"docs/notes.txt":   "some notes",
+		"vendor/lib/x.go":  "package lib",
+		"docs/api/spec.go": "package api",
+	})
+
+	head, err := repo.Head()
+	suite.Require().NoError(err)
+
+	knowledge := &types.Knowledge{
+		ID: "knowledge_id",
+		Source: types.KnowledgeSource{
+			Github: &types.KnowledgeSourceGithub{
+				URL:              "file://" + dir,
+				FilterPaths:      []string{"docs/", "pkg/"},
+				FilterExtensions: []string{"md", ".go", ".png"},
+			},
+		},
+	}
+
+	data, err := suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+
+	sources := make(map[string]string)
+	for _, d := range data {
+		sources[d.Source] = string(d.Data)
+		suite.Equal(head.Hash().String(), d.Commit)
+		suite.Equal(getDocumentGroupID(d.Source), d.DocumentGroupID)
+	}
+
+	suite.Equal(map[string]string{
+		"docs/install.md":  "Run the installer",
+		"docs/api/spec.go": "package api",
+		"pkg/server.go":    "package pkg\n\nfunc Serve() {}\n",
+	}, sources)
+}
+
+func (suite *ExtractorSuite) Test_gitSourceUnchanged() {
+	dir, repo := suite.createGitRepo(map[string]string{
+		"README.md": "# Project",
+	})
+
+	head, err := repo.Head()
+	suite.Require().NoError(err)
+
+	knowledge := &types.Knowledge{
+		ID: "knowledge_id",
+		Source: types.KnowledgeSource{
+			Github: &types.KnowledgeSourceGithub{
+				URL:    "file://" + dir,
+				Branch: head.Name().Short(),
+			},
+		},
+		CrawledSources: &types.CrawledSources{
+			Commit: head.Hash().String(),
+		},
+	}
+
+	unchanged, err := suite.reconciler.gitSourceUnchanged(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+	suite.True(unchanged)
+
+	suite.commitFiles(dir, repo, map[string]string{
+		"README.md": "# Project v2",
+	})
+
+	unchanged, err = suite.reconciler.gitSourceUnchanged(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+	suite.False(unchanged)
+}
+
+func (suite *ExtractorSuite) Test_getIndexingData_Git_LocalPath() {
+	for url, message := range map[string]string{
+		"file:///etc":                    "git url must start with https:// or ssh://",
+		"/etc":                           "git url must start with https:// or ssh://",
+		"https://127.0.0.1/org/repo.git": "git host 127.0.0.1 is not allowed",
+		"ssh://git@localhost/repo.git":   "git host localhost is not allowed",
+	} {
+		knowledge := &types.Knowledge{
+			ID: "knowledge_id",
+			Source: types.KnowledgeSource{
+				Github: &types.KnowledgeSourceGithub{URL: url},
+			},
+		}
+
+		_, err := suite.reconciler.getIndexingData(suite.ctx, knowledge)
+		suite.Require().Error(err)
+		suite.Contains(err.Error(), message)
+	}
+}
+
+func (suite *ExtractorSuite) Test_getGitSourceURL() {
+	suite.Equal(
+		"https://github.com/helixml/helix/blob/abc123/docs/install.md",
+		getGitSourceURL(&types.KnowledgeSourceGithub{Owner: "helixml", Repository: "helix", Branch: "release"}, "abc123", "docs/install.md"),
+	)
+	suite.Equal(
+		"docs/install.md",
+		getGitSourceURL(&types.KnowledgeSourceGithub{URL: "https://gitlab.com/org/repo.git"}, "abc123", "docs/install.md"),
+	)
+}
@@ -0,0 +1,144 @@
+package knowledge
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/rag"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// getPreviousDocuments returns the documents of the current knowledge version by
+// their document group. These can be copied into the new version if their content
+// hasn't changed. Returns nil if everything has to be indexed again, for example
+// when the chunking settings have changed or the RAG backend can't copy.
+func (r *Reconciler) getPreviousDocuments(ctx context.Context, k *types.Knowledge) map[string]*types.CrawledURL {
+	if k.Version == "" {
+		return nil
+	}
+
+	if _, ok := r.getRagClient(k).(rag.Copier); !ok {
+		return nil
+	}
+
+	versions, err := r.store.ListKnowledgeVersions(ctx, &store.ListKnowledgeVersionQuery{
+		KnowledgeID: k.ID,
+	})
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("knowledge_id", k.ID).
+			Msg("failed to list knowledge versions, indexing all documents")
+		return nil
+	}
+
+	var current *types.KnowledgeVersion
+	for _, v := range versions {
+		if v.Version == k.Version && v.State == types.KnowledgeStateReady {
+			current = v
+			break
+		}
+	}
+
+	if current == nil || current.CrawledSources == nil {
+		return nil
+	}
+
+	if current.CrawledSources.SettingsHash != r.getSettingsHash(k) {
+		return nil
+	}
+
+	documents := make(map[string]*types.CrawledURL, len(current.CrawledSources.URLs))
+	for _, u := range current.CrawledSources.URLs {
+		if u.ContentHash == "" {
+			continue
+		}
+
+		groupID := u.DocumentGroupID
+		if groupID == "" {
+			// Versions indexed before the group was kept
+			groupID = getDocumentGroupID(u.URL)
+		}
+		documents[groupID] = u
+	}
+
+	return documents
+}
+
+// getSettingsHash hashes the settings that change how documents are chunked
+// and embedded, documents indexed with different settings can't be reused
+func (r *Reconciler) getSettingsHash(k *types.Knowledge) string {
+	bts, _ := json.Marshal(struct {
+		TextSplitter    types.TextSplitterType
+		ChunkSize       int
+		ChunkOverflow   int
+		DisableChunking bool
+		EmbeddingsModel string
+		Provider        string
+	}{
+		TextSplitter:    k.RAGSettings.TextSplitter,
+		ChunkSize:       k.RAGSettings.ChunkSize,
+		ChunkOverflow:   k.RAGSettings.ChunkOverflow,
+		DisableChunking: k.RAGSettings.DisableChunking,
+		EmbeddingsModel: r.config.RAG.PGVector.EmbeddingsModel,
+		Provider:        string(r.config.RAG.DefaultRagProvider),
+	})
+
+	return getContentHash(bts)
+}
+
+func getContentHash(contents []byte) string {
+	hash := sha256.Sum256(contents)
+	return hex.EncodeToString(hash[:])
+}
+
+// markUnchangedData flags the documents that have the same content as in the
+// previous version
+func markUnchangedData(data []*indexerData, previous map[string]*types.CrawledURL) {
+	for _, d := range data {
+		if d.ContentHash == "" {
+			continue
+		}
+
+		if p, ok := previous[d.getDocumentGroupID()]; ok && p.ContentHash == d.ContentHash {
+			d.Unchanged = true
+		}
+	}
+}
+
+// copyUnchangedData copies the chunks of the unchanged documents from the
+// current version into the new one
+func (r *Reconciler) copyUnchangedData(ctx context.Context, k *types.Knowledge, version string, data []*indexerData) error {
+	groupIDs := make([]string, 0, len(data))
+	for _, d := range data {
+		if d.Unchanged {
+			groupIDs = append(groupIDs, d.getDocumentGroupID())
+		}
+	}
+
+	if len(groupIDs) == 0 {
+		return nil
+	}
+
+	copier, ok := r.getRagClient(k).(rag.Copier)
+	if !ok {
+		return fmt.Errorf("RAG backend can't copy documents between versions")
+	}
+
+	err := copier.Copy(ctx, &types.CopyIndexRequest{
+		FromDataEntityID: k.GetDataEntityID(),
+		ToDataEntityID:   types.GetDataEntityID(k.ID, version),
+		DocumentGroupIDs: groupIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy unchanged documents, error: %w", err)
+	}
+
+	return nil
+}
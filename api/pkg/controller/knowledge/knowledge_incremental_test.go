@@ -0,0 +1,223 @@
+package knowledge
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/extract"
+	"github.com/helixml/helix/api/pkg/filestore"
+	"github.com/helixml/helix/api/pkg/rag"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// copierRAG is a RAG backend that can also copy documents between versions
+type copierRAG struct {
+	*rag.MockRAG
+	*rag.MockCopier
+}
+
+func (suite *IndexerSuite) TestIndex_CopiesUnchangedDocuments() {
+	ctrl := gomock.NewController(suite.T())
+	copier := rag.NewMockCopier(ctrl)
+
+	suite.reconciler.ragClient = &copierRAG{MockRAG: suite.rag, MockCopier: copier}
+	suite.cfg.RAG.MaxVersions = 3
+
+	knowledge := &types.Knowledge{
+		ID:      "knowledge_id",
+		Version: "v1",
+		RAGSettings: types.RAGSettings{
+			TextSplitter: types.TextSplitterTypeText,
+			ChunkSize:    2048,
+		},
+		Source: types.KnowledgeSource{
+			Web: &types.KnowledgeSourceWeb{
+				URLs: []string{"https://example.com"},
+				Crawler: &types.WebsiteCrawler{
+					Enabled: true,
+				},
+			},
+		},
+	}
+
+	previous := &types.KnowledgeVersion{
+		KnowledgeID: knowledge.ID,
+		Version:     "v1",
+		State:       types.KnowledgeStateReady,
+		CrawledSources: &types.CrawledSources{
+			SettingsHash: suite.reconciler.getSettingsHash(knowledge),
+			URLs: []*types.CrawledURL{
+				{URL: "https://example.com/same", ContentHash: getContentHash([]byte("Same content"))},
+				{URL: "https://example.com/changed", ContentHash: getContentHash([]byte("Old content"))},
+				{URL: "https://example.com/removed", ContentHash: getContentHash([]byte("Removed content"))},
+			},
+		},
+	}
+
+	suite.store.EXPECT().ListKnowledgeVersions(gomock.Any(), &store.ListKnowledgeVersionQuery{
+		KnowledgeID: knowledge.ID,
+	}).Return([]*types.KnowledgeVersion{previous}, nil).AnyTimes()
+
+	suite.crawler.EXPECT().Crawl(gomock.Any()).Return([]*types.CrawledDocument{
+		{Content: "Same content", SourceURL: "https://example.com/same"},
+		{Content: "New content", SourceURL: "https://example.com/changed"},
+	}, nil)
+
+	suite.store.EXPECT().UpdateKnowledge(gomock.Any(), gomock.Any()).Return(knowledge, nil).AnyTimes()
+	suite.store.EXPECT().UpdateKnowledgeState(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	// Only the changed document is embedded again
+	suite.rag.EXPECT().Index(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, chunks ...*types.SessionRAGIndexChunk) error {
+			suite.Require().Len(chunks, 1)
+			chunk := chunks[0]
+			suite.Equal("https://example.com/changed", chunk.Source)
+			suite.Equal("New content", chunk.Content)
+			suite.Equal("knowledge_id-v2", chunk.DataEntityID)
+			return nil
+		},
+	)
+
+	copier.EXPECT().Copy(gomock.Any(), &types.CopyIndexRequest{
+		FromDataEntityID: "knowledge_id-v1",
+		ToDataEntityID:   "knowledge_id-v2",
+		DocumentGroupIDs: []string{getDocumentGroupID("https://example.com/same")},
+	}).Return(nil)
+
+	suite.store.EXPECT().CreateKnowledgeVersion(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, v *types.KnowledgeVersion) (*types.KnowledgeVersion, error) {
+			suite.Equal("v2", v.Version)
+			suite.Require().Len(v.CrawledSources.URLs, 2)
+			suite.Equal(previous.CrawledSources.SettingsHash, v.CrawledSources.SettingsHash)
+
+			for _, u := range v.CrawledSources.URLs {
+				suite.NotEmpty(u.ContentHash, u.URL)
+				suite.Equal(getDocumentGroupID(u.URL), u.DocumentGroupID)
+			}
+
+			return v, nil
+		},
+	)
+
+	err := suite.reconciler.indexKnowledge(suite.ctx, knowledge, "v2")
+	suite.Require().NoError(err)
+}
+
+func (suite *IndexerSuite) Test_getIndexingData_SkipsExtractingUnchangedFiles() {
+	ctrl := gomock.NewController(suite.T())
+	suite.reconciler.ragClient = &copierRAG{MockRAG: suite.rag, MockCopier: rag.NewMockCopier(ctrl)}
+
+	knowledge := &types.Knowledge{
+		ID: "knowledge_id",
+		RAGSettings: types.RAGSettings{
+			TextSplitter: types.TextSplitterTypeText,
+		},
+		Source: types.KnowledgeSource{
+			Filestore: &types.KnowledgeSourceHelixFilestore{Path: "docs"},
+		},
+	}
+
+	suite.filestore.EXPECT().List(gomock.Any(), gomock.Any()).Return([]filestore.Item{{Path: "docs/report.pdf"}}, nil).Times(2)
+	suite.filestore.EXPECT().OpenFile(gomock.Any(), "docs/report.pdf").DoAndReturn(func(_ context.Context, _ string) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader("%PDF report")), nil
+	}).Times(2)
+
+	// Only the first run extracts the file
+	suite.extractor.EXPECT().Extract(gomock.Any(), &extract.Request{Content: []byte("%PDF report")}).Return("Report", nil).Times(1)
+
+	data, err := suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+	suite.Require().Len(data, 1)
+	suite.False(data[0].Unchanged)
+
+	knowledge.Version = "v1"
+	suite.store.EXPECT().ListKnowledgeVersions(gomock.Any(), gomock.Any()).Return([]*types.KnowledgeVersion{
+		{
+			Version: "v1",
+			State:   types.KnowledgeStateReady,
+			CrawledSources: &types.CrawledSources{
+				SettingsHash: suite.reconciler.getSettingsHash(knowledge),
+				URLs:         getCrawledSources(data),
+			},
+		},
+	}, nil)
+
+	data, err = suite.reconciler.getIndexingData(suite.ctx, knowledge)
+	suite.Require().NoError(err)
+	suite.Require().Len(data, 1)
+	suite.True(data[0].Unchanged)
+	suite.Equal("docs/report.pdf", data[0].Source)
+}
+
+func (suite *IndexerSuite) Test_getPreviousDocuments_SettingsChanged() {
+	ctrl := gomock.NewController(suite.T())
+	copier := rag.NewMockCopier(ctrl)
+
+	suite.reconciler.ragClient = &copierRAG{MockRAG: suite.rag, MockCopier: copier}
+
+	knowledge := &types.Knowledge{
+		ID:      "knowledge_id",
+		Version: "v1",
+		RAGSettings: types.RAGSettings{
+			TextSplitter: types.TextSplitterTypeText,
+			ChunkSize:    1024,
+		},
+	}
+
+	suite.store.EXPECT().ListKnowledgeVersions(gomock.Any(), gomock.Any()).Return([]*types.KnowledgeVersion{
+		{
+			Version: "v1",
+			State:   types.KnowledgeStateReady,
+			CrawledSources: &types.CrawledSources{
+				SettingsHash: "different",
+				URLs: []*types.CrawledURL{
+					{URL: "https://example.com/same", ContentHash: getContentHash([]byte("Same content"))},
+				},
+			},
+		},
+	}, nil)
+
+	suite.Nil(suite.reconciler.getPreviousDocuments(suite.ctx, knowledge))
+}
+
+func (suite *IndexerSuite) Test_getPreviousDocuments_NoCopier() {
+	knowledge := &types.Knowledge{
+		ID:      "knowledge_id",
+		Version: "v1",
+	}
+
+	// The mock RAG can't copy, so the store isn't queried
+	suite.Nil(suite.reconciler.getPreviousDocuments(suite.ctx, knowledge))
+}
+
+func (suite *IndexerSuite) Test_markUnchangedData() {
+	data := []*indexerData{
+		{Source: "a", ContentHash: "hash-a"},
+		{Source: "b", ContentHash: "hash-b2"},
+		{Source: "c", ContentHash: "hash-c"},
+		{Source: "d"},
+		// Git files link to the commit, they are matched by their path
+		{Source: "https://github.com/org/repo/blob/c2/e.md", DocumentGroupID: getDocumentGroupID("e.md"), ContentHash: "hash-e"},
+	}
+
+	markUnchangedData(data, map[string]*types.CrawledURL{
+		getDocumentGroupID("a"):    {URL: "a", ContentHash: "hash-a"},
+		getDocumentGroupID("b"):    {URL: "b", ContentHash: "hash-b1"},
+		getDocumentGroupID("d"):    {URL: "d", ContentHash: ""},
+		getDocumentGroupID("e.md"): {URL: "https://github.com/org/repo/blob/c1/e.md", ContentHash: "hash-e"},
+	})
+
+	var unchanged []string
+	for _, d := range data {
+		if d.Unchanged {
+			unchanged = append(unchanged, d.Source)
+		}
+	}
+
+	suite.Equal([]string{"a", "https://github.com/org/repo/blob/c2/e.md"}, unchanged)
+	suite.Len(getChangedData(data), 3)
+}
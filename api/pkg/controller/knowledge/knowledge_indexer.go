@@ -116,6 +116,9 @@ func (r *Reconciler) indexKnowledge(ctx context.Context, k *types.Knowledge, ver
 		return fmt.Errorf("failed to update progress when retrieving data: %v", err)
 	}
 
+	// Documents from the current version that can be reused if they haven't changed
+	previous := r.getPreviousDocuments(ctx, k)
+
 	data, err := r.getIndexingData(ctx, k)
 	if err != nil {
 		return fmt.Errorf("failed to get indexing data, error: %w", err)
@@ -127,18 +130,27 @@ func (r *Reconciler) indexKnowledge(ctx context.Context, k *types.Knowledge, ver
 		return err
 	}
 
+	markUnchangedData(data, previous)
+
 	crawledSources := getCrawledSources(data)
 
+	changed := getChangedData(data)
+
 	elapsed := time.Since(start)
 	log.Info().
 		Str("knowledge_id", k.ID).
 		Float64("elapsed_seconds", elapsed.Seconds()).
 		Int("crawled_sources", len(crawledSources)).
+		Int("changed", len(changed)).
+		Int("unchanged", len(data)-len(changed)).
+		Int("removed", max(len(previous)-(len(data)-len(changed)), 0)).
 		Msg("indexing data loaded")
 
 	k.Message = "indexing data"
 	k.CrawledSources = &types.CrawledSources{
-		URLs: crawledSources,
+		URLs:         crawledSources,
+		Commit:       getCommit(data),
+		SettingsHash: r.getSettingsHash(k),
 	}
 
 	r.updateKnowledgeProgress(k.ID, types.KnowledgeProgress{
@@ -159,9 +171,17 @@ func (r *Reconciler) indexKnowledge(ctx context.Context, k *types.Knowledge, ver
 
 	start = time.Now()
 
-	err = r.indexData(ctx, k, version, data, start)
+	if len(changed) > 0 {
+		err = r.indexData(ctx, k, version, changed, start)
+		if err != nil {
+			return fmt.Errorf("indexing failed, error: %w", err)
+		}
+	}
+
+	// Documents that were removed from the source are not copied
+	err = r.copyUnchangedData(ctx, k, version, data)
 	if err != nil {
-		return fmt.Errorf("indexing failed, error: %w", err)
+		return err
 	}
 	elapsed = time.Since(start)
 	log.Info().
@@ -279,7 +299,7 @@ func (r *Reconciler) deleteKnowledgeVersion(ctx context.Context, k *types.Knowle
 func getSize(data []*indexerData) int64 {
 	size := int64(0)
 	for _, d := range data {
-		size += int64(len(d.Data))
+		size += d.getSize()
 	}
 	return size
 }
@@ -334,7 +354,7 @@ func (r *Reconciler) indexDataDirectly(ctx context.Context, k *types.Knowledge,
 			Filename:        d.Source,
 			Source:          d.Source,
 			DocumentID:      getDocumentID(d.Data),
-			DocumentGroupID: getDocumentGroupID(d.Source),
+			DocumentGroupID: d.getDocumentGroupID(),
 			ContentOffset:   0,
 			Content:         string(d.Data),
 		})
@@ -435,6 +455,39 @@ type indexerData struct {
 	StatusCode      int
 	DurationMs      int64
 	Message         string
+	Commit          string // Set for git sources
+	ContentHash     string // Hash of the source contents, before extraction
+	// Unchanged is set when the document is the same as in the previous
+	// version, its chunks are copied instead of indexed again. Data can be
+	// empty if the extraction was skipped, then Size is set.
+	Unchanged bool
+	Size      int64
+}
+
+// getDocumentGroupID returns the group of the document's chunks, it stays the
+// same between versions of the knowledge
+func (d *indexerData) getDocumentGroupID() string {
+	if d.DocumentGroupID != "" {
+		return d.DocumentGroupID
+	}
+	return getDocumentGroupID(d.Source)
+}
+
+func (d *indexerData) getSize() int64 {
+	if len(d.Data) == 0 {
+		return d.Size
+	}
+	return int64(len(d.Data))
+}
+
+func getChangedData(data []*indexerData) []*indexerData {
+	changed := make([]*indexerData, 0, len(data))
+	for _, d := range data {
+		if !d.Unchanged {
+			changed = append(changed, d)
+		}
+	}
+	return changed
 }
 
 func convertChunksIntoBatches(chunks []*text.DataPrepTextSplitterChunk, batchSize int) [][]*text.DataPrepTextSplitterChunk {
@@ -473,7 +526,7 @@ func checkContents(data []*indexerData) error {
 	}
 
 	for _, d := range data {
-		if len(d.Data) > 0 {
+		if len(d.Data) > 0 || d.Unchanged {
 			return nil
 		}
 	}
@@ -481,15 +534,28 @@ func checkContents(data []*indexerData) error {
 	return fmt.Errorf("couldn't extract any data for indexing, check your data source or configuration")
 }
 
+func getCommit(data []*indexerData) string {
+	for _, d := range data {
+		if d.Commit != "" {
+			return d.Commit
+		}
+	}
+
+	return ""
+}
+
 func getCrawledSources(data []*indexerData) []*types.CrawledURL {
 	var crawledSources []*types.CrawledURL
 
 	for _, d := range data {
 		crawledSources = append(crawledSources, &types.CrawledURL{
-			URL:        d.Source,
-			StatusCode: d.StatusCode,
-			DurationMs: d.DurationMs,
-			Message:    d.Message,
+			URL:             d.Source,
+			StatusCode:      d.StatusCode,
+			DurationMs:      d.DurationMs,
+			Message:         d.Message,
+			ContentHash:     d.ContentHash,
+			DocumentGroupID: d.getDocumentGroupID(),
+			Size:            d.getSize(),
 		})
 	}
 
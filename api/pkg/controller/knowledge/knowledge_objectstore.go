@@ -0,0 +1,83 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// maxObjectSize objects larger than this are skipped when indexing S3 and GCS
+// buckets, the objects are held in memory until they are extracted
+const maxObjectSize = 20 * 1024 * 1024
+
+// getSourceSecrets returns the secrets of the knowledge owner by name, secrets
+// scoped to another app are skipped
+func (r *Reconciler) getSourceSecrets(ctx context.Context, k *types.Knowledge) (map[string]string, error) {
+	secrets, err := r.store.ListSecrets(ctx, &store.ListSecretsQuery{
+		Owner:     k.Owner,
+		OwnerType: k.OwnerType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	result := make(map[string]string, len(secrets))
+	for _, secret := range secrets {
+		if secret.AppID != "" && secret.AppID != k.AppID {
+			continue
+		}
+		result[secret.Name] = string(secret.Value)
+	}
+
+	return result, nil
+}
+
+// ambientCredentials reports whether the source can fall back to the
+// credentials of the server. Admins have to enable it and it never applies to
+// custom endpoints, the credentials would be sent to hosts the user picks.
+func (r *Reconciler) ambientCredentials(endpoint string) bool {
+	return r.config.RAG.ObjectStore.AmbientCredentials && endpoint == ""
+}
+
+// objectIndexable skips directory placeholders, empty and large objects and
+// objects that don't match the extension filter
+func objectIndexable(key string, size int64, extensions []string) bool {
+	if strings.HasSuffix(key, "/") || size == 0 || size > maxObjectSize {
+		return false
+	}
+
+	return matchesExtensions(key, extensions)
+}
+
+// readObject reads the content of an object, failing if it's larger than
+// maxObjectSize in case it grew since it was listed
+func readObject(r io.Reader) ([]byte, error) {
+	bts, err := io.ReadAll(io.LimitReader(r, maxObjectSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(bts) > maxObjectSize {
+		return nil, fmt.Errorf("object is larger than %d bytes", maxObjectSize)
+	}
+	return bts, nil
+}
+
+func matchesExtensions(path string, extensions []string) bool {
+	if len(extensions) == 0 {
+		return true
+	}
+
+	ext := filepath.Ext(path)
+	for _, e := range extensions {
+		if strings.EqualFold(ext, "."+strings.TrimPrefix(e, ".")) {
+			return true
+		}
+	}
+
+	return false
+}
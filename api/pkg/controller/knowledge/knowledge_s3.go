@@ -0,0 +1,396 @@
+package knowledge
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+const (
+	s3DefaultRegion = "us-east-1"
+	// s3EmptyPayloadHash is the SHA256 of an empty body, all our requests are GETs
+	s3EmptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	// s3MaxErrorSize is how much of an error response is read to report it
+	s3MaxErrorSize = 64 * 1024
+)
+
+// s3Client is a minimal S3 client that lists and downloads objects, requests
+// are signed with AWS Signature Version 4.
+type s3Client struct {
+	httpClient *http.Client
+
+	endpoint  *url.URL
+	pathStyle bool
+	region    string
+
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+
+	now func() time.Time
+}
+
+type s3Object struct {
+	Key  string `xml:"Key"`
+	Size int64  `xml:"Size"`
+}
+
+type s3ListBucketResult struct {
+	Contents              []s3Object `xml:"Contents"`
+	IsTruncated           bool       `xml:"IsTruncated"`
+	NextContinuationToken string     `xml:"NextContinuationToken"`
+}
+
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (r *Reconciler) extractDataFromS3(ctx context.Context, k *types.Knowledge) ([]*indexerData, error) {
+	client, err := r.newS3Client(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+
+	source := k.Source.S3
+
+	var data []*indexerData
+
+	err = client.walkObjects(ctx, source.Bucket, source.Path, func(obj s3Object) error {
+		if !objectIndexable(obj.Key, obj.Size, source.FilterExtensions) {
+			return nil
+		}
+
+		bts, err := client.getObject(ctx, source.Bucket, obj.Key)
+		if err != nil {
+			return err
+		}
+
+		u := fmt.Sprintf("s3://%s/%s", source.Bucket, obj.Key)
+
+		data = append(data, &indexerData{
+			Data:            bts,
+			Source:          u,
+			DocumentGroupID: getDocumentGroupID(u),
+			ContentHash:     getContentHash(bts),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("knowledge_id", k.ID).
+		Str("bucket", source.Bucket).
+		Str("prefix", source.Path).
+		Int("count", len(data)).
+		Msg("s3 objects found")
+
+	return r.extractFileData(ctx, k, data)
+}
+
+// newS3Client creates the client with the credentials from the secrets of the
+// knowledge owner. Ambient credentials are only read from the AWS_*
+// environment variables of the server, the AWS SDK credential chain (IRSA, web
+// identity and instance profiles) isn't supported.
+func (r *Reconciler) newS3Client(ctx context.Context, k *types.Knowledge) (*s3Client, error) {
+	secrets, err := r.getSourceSecrets(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+
+	source := k.Source.S3
+
+	endpoint := source.Endpoint
+	if endpoint == "" {
+		endpoint = firstNonEmpty(secrets["AWS_ENDPOINT_URL_S3"], secrets["AWS_ENDPOINT_URL"])
+	}
+
+	var (
+		region                                     = source.Region
+		accessKeyID, secretAccessKey, sessionToken string
+	)
+
+	switch {
+	case secrets["AWS_ACCESS_KEY_ID"] != "" && secrets["AWS_SECRET_ACCESS_KEY"] != "":
+		accessKeyID = secrets["AWS_ACCESS_KEY_ID"]
+		secretAccessKey = secrets["AWS_SECRET_ACCESS_KEY"]
+		sessionToken = secrets["AWS_SESSION_TOKEN"]
+		region = firstNonEmpty(region, secrets["AWS_REGION"], secrets["AWS_DEFAULT_REGION"])
+	case r.ambientCredentials(endpoint):
+		accessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		secretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		sessionToken = os.Getenv("AWS_SESSION_TOKEN")
+		region = firstNonEmpty(region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"))
+		endpoint = firstNonEmpty(os.Getenv("AWS_ENDPOINT_URL_S3"), os.Getenv("AWS_ENDPOINT_URL"))
+	default:
+		return nil, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY secrets are required to index s3://%s", source.Bucket)
+	}
+
+	if region == "" {
+		region = s3DefaultRegion
+	}
+
+	// S3 compatible storage is addressed with the bucket in the path, AWS with
+	// the bucket in the host name
+	pathStyle := endpoint != ""
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint '%s': %w", endpoint, err)
+	}
+
+	return &s3Client{
+		httpClient:      r.httpClient,
+		endpoint:        u,
+		pathStyle:       pathStyle,
+		region:          region,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		sessionToken:    sessionToken,
+		now:             time.Now,
+	}, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// walkObjects calls fn with the objects under the prefix, following the
+// continuation tokens. Only one page of the list is held at a time.
+func (c *s3Client) walkObjects(ctx context.Context, bucket, prefix string, fn func(s3Object) error) error {
+	var token string
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		result, err := c.listObjectsPage(ctx, bucket, query)
+		if err != nil {
+			return fmt.Errorf("failed to list objects in s3://%s/%s: %w", bucket, prefix, err)
+		}
+
+		for _, obj := range result.Contents {
+			if err := fn(obj); err != nil {
+				return err
+			}
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (c *s3Client) listObjectsPage(ctx context.Context, bucket string, query url.Values) (*s3ListBucketResult, error) {
+	body, err := c.do(ctx, bucket, "", query)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var result s3ListBucketResult
+	if err := xml.NewDecoder(body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode objects list: %w", err)
+	}
+
+	return &result, nil
+}
+
+func (c *s3Client) getObject(ctx context.Context, bucket, key string) ([]byte, error) {
+	body, err := c.do(ctx, bucket, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get s3://%s/%s: %w", bucket, key, err)
+	}
+	defer body.Close()
+
+	bts, err := readObject(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read s3://%s/%s: %w", bucket, key, err)
+	}
+	return bts, nil
+}
+
+// do sends the request and returns the body of the response, the caller has
+// to close it
+func (c *s3Client) do(ctx context.Context, bucket, key string, query url.Values) (io.ReadCloser, error) {
+	u := *c.endpoint
+
+	path := strings.TrimSuffix(u.Path, "/")
+	if c.pathStyle {
+		path += "/" + bucket
+	} else {
+		u.Host = bucket + "." + u.Host
+	}
+	path += "/" + key
+
+	u.Path = path
+	u.RawPath = s3URIEscape(path, false)
+	u.RawQuery = s3CanonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	c.sign(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+
+		var s3Err s3Error
+		if xml.NewDecoder(io.LimitReader(resp.Body, s3MaxErrorSize)).Decode(&s3Err) == nil && s3Err.Code != "" {
+			return nil, fmt.Errorf("%s (%d): %s", s3Err.Code, resp.StatusCode, s3Err.Message)
+		}
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return resp.Body, nil
+}
+
+// sign adds the AWS Signature Version 4 headers to the request
+func (c *s3Client) sign(req *http.Request) {
+	if c.accessKeyID == "" || c.secretAccessKey == "" {
+		return
+	}
+
+	now := c.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", s3EmptyPayloadHash)
+	if c.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.sessionToken)
+	}
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": s3EmptyPayloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if c.sessionToken != "" {
+		headers["x-amz-security-token"] = c.sessionToken
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		s3EmptyPayloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, c.region)
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.secretAccessKey), date)
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.accessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3CanonicalQuery encodes the query sorted by key as required for signing
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, s3URIEscape(k, true)+"="+s3URIEscape(v, true))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// s3URIEscape escapes everything except the unreserved characters, slashes
+// are kept in paths
+func s3URIEscape(s string, escapeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !escapeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
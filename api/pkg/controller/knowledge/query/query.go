@@ -112,6 +112,7 @@ func (q *Query) getDocuments(ctx context.Context, prompt string, knowledges []*t
 				DistanceThreshold: knowledge.RAGSettings.Threshold,
 				DistanceFunction:  knowledge.RAGSettings.DistanceFunction,
 				MaxResults:        knowledge.RAGSettings.ResultsCount,
+				SearchMode:        knowledge.RAGSettings.SearchMode,
 			})
 			if err != nil {
 				return nil, fmt.Errorf("error querying RAG: %w", err)
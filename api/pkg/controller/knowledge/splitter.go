@@ -2,6 +2,8 @@ package knowledge
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/helixml/helix/api/pkg/dataprep/text"
 	"github.com/helixml/helix/api/pkg/types"
@@ -28,7 +30,7 @@ func splitData(k *types.Knowledge, data []*indexerData) ([]*text.DataPrepTextSpl
 		}
 
 		for _, d := range data {
-			_, err := splitter.AddDocument(d.Source, string(d.Data), d.DocumentGroupID)
+			_, err := splitter.AddDocument(d.Source, string(d.Data), d.getDocumentGroupID())
 			if err != nil {
 				return nil, fmt.Errorf("failed to split %s, error %w", d.Source, err)
 			}
@@ -42,13 +44,28 @@ func splitData(k *types.Knowledge, data []*indexerData) ([]*text.DataPrepTextSpl
 			Int("chunk_overlap", k.RAGSettings.ChunkOverflow).
 			Msgf("splitting data with markdown text splitter")
 
-		splitter := textsplitter.NewMarkdownTextSplitter(
+		markdownSplitter := textsplitter.NewMarkdownTextSplitter(
 			textsplitter.WithChunkSize(k.RAGSettings.ChunkSize),
 			textsplitter.WithChunkOverlap(k.RAGSettings.ChunkOverflow),
 			textsplitter.WithCodeBlocks(true),
 		)
 
 		for _, d := range data {
+			var splitter textsplitter.TextSplitter = markdownSplitter
+
+			// Source code from repositories is split on declarations so that
+			// functions and types are kept together where possible
+			if k.Source.Github != nil {
+				if separators, ok := codeSeparators[strings.ToLower(filepath.Ext(d.Source))]; ok {
+					splitter = textsplitter.NewRecursiveCharacter(
+						textsplitter.WithChunkSize(k.RAGSettings.ChunkSize),
+						textsplitter.WithChunkOverlap(k.RAGSettings.ChunkOverflow),
+						textsplitter.WithSeparators(separators),
+						textsplitter.WithKeepSeparator(true),
+					)
+				}
+			}
+
 			parts, err := splitter.SplitText(string(d.Data))
 			if err != nil {
 				return nil, fmt.Errorf("failed to split %s, error %w", d.Source, err)
@@ -60,7 +77,7 @@ func splitData(k *types.Knowledge, data []*indexerData) ([]*text.DataPrepTextSpl
 					Index:           idx,
 					Text:            part,
 					DocumentID:      getDocumentID(d.Data),
-					DocumentGroupID: d.DocumentGroupID,
+					DocumentGroupID: d.getDocumentGroupID(),
 				})
 			}
 		}
@@ -68,3 +85,23 @@ func splitData(k *types.Knowledge, data []*indexerData) ([]*text.DataPrepTextSpl
 
 	return chunks, nil
 }
+
+// codeSeparators are the separators used to split source code files, by
+// extension. Declarations come first so chunks break between them.
+var codeSeparators = map[string][]string{
+	".go":   {"\nfunc ", "\ntype ", "\nvar ", "\nconst ", "\n\n", "\n", " ", ""},
+	".py":   {"\nclass ", "\ndef ", "\n\tdef ", "\n    def ", "\n\n", "\n", " ", ""},
+	".js":   {"\nfunction ", "\nclass ", "\nexport ", "\nconst ", "\n\n", "\n", " ", ""},
+	".jsx":  {"\nfunction ", "\nclass ", "\nexport ", "\nconst ", "\n\n", "\n", " ", ""},
+	".ts":   {"\nfunction ", "\nclass ", "\nexport ", "\ninterface ", "\ntype ", "\nconst ", "\n\n", "\n", " ", ""},
+	".tsx":  {"\nfunction ", "\nclass ", "\nexport ", "\ninterface ", "\ntype ", "\nconst ", "\n\n", "\n", " ", ""},
+	".java": {"\nclass ", "\ninterface ", "\n    public ", "\n    private ", "\n    protected ", "\n\n", "\n", " ", ""},
+	".rs":   {"\nfn ", "\npub fn ", "\nstruct ", "\npub struct ", "\nimpl ", "\nenum ", "\n\n", "\n", " ", ""},
+	".rb":   {"\nclass ", "\nmodule ", "\ndef ", "\n  def ", "\n\n", "\n", " ", ""},
+	".c":    {"\nstruct ", "\nstatic ", "\nvoid ", "\nint ", "\n\n", "\n", " ", ""},
+	".h":    {"\nstruct ", "\ntypedef ", "\n#define ", "\n\n", "\n", " ", ""},
+	".cpp":  {"\nclass ", "\nstruct ", "\nnamespace ", "\nvoid ", "\n\n", "\n", " ", ""},
+	".cs":   {"\nclass ", "\ninterface ", "\nnamespace ", "\n    public ", "\n    private ", "\n\n", "\n", " ", ""},
+	".php":  {"\nclass ", "\nfunction ", "\n    public function ", "\n\n", "\n", " ", ""},
+	".sh":   {"\nfunction ", "\n\n", "\n", " ", ""},
+}
@@ -29,3 +29,34 @@ func TestSplitData_Markdown(t *testing.T) {
 	assert.Contains(t, chunks[0].Text, "For example if the payload fragment looks like this:")
 	assert.Contains(t, chunks[0].Text, "local encoded_payload, err = json.encode(json_payload)")
 }
+
+func TestSplitData_GitCode(t *testing.T) {
+	contents := `package server
+
+func Start() {
+	// start the server
+}
+
+func Stop() {
+	// stop the server
+}
+`
+
+	k := &types.Knowledge{}
+	k.RAGSettings.ChunkSize = 50
+	k.RAGSettings.ChunkOverflow = 0
+	k.Source.Github = &types.KnowledgeSourceGithub{Owner: "helixml", Repository: "helix"}
+
+	chunks, err := splitData(k, []*indexerData{{
+		Source: "https://github.com/helixml/helix/blob/abc123/server.go",
+		Data:   []byte(contents),
+	}})
+	require.NoError(t, err)
+
+	require.Equal(t, 3, len(chunks))
+
+	assert.Equal(t, "package server", chunks[0].Text)
+	assert.Contains(t, chunks[1].Text, "func Start() {")
+	assert.Contains(t, chunks[1].Text, "// start the server")
+	assert.Contains(t, chunks[2].Text, "func Stop() {")
+}
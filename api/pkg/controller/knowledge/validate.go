@@ -1,8 +1,11 @@
 package knowledge
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -32,11 +35,55 @@ func Validate(cfg *config.ServerConfig, k *types.AssistantKnowledge) error {
 		}
 	}
 
+	switch k.RAGSettings.SearchMode {
+	case "", types.RAGSearchModeVector:
+	case types.RAGSearchModeHybrid:
+		if !supportsHybridSearch(cfg, &k.RAGSettings) {
+			return fmt.Errorf("hybrid search mode is not supported by the RAG provider, use %s", types.RAGSearchModeVector)
+		}
+	default:
+		return fmt.Errorf("invalid search mode '%s', must be one of: %s, %s", k.RAGSettings.SearchMode, types.RAGSearchModeVector, types.RAGSearchModeHybrid)
+	}
+
+	switch k.RAGSettings.Reranker.Type {
+	case types.RerankerTypeNone:
+	case types.RerankerTypeCrossEncoder, types.RerankerTypeLLM:
+		if k.RAGSettings.Reranker.Provider == "" || k.RAGSettings.Reranker.Model == "" {
+			return fmt.Errorf("reranker provider and model are required")
+		}
+		if k.RAGSettings.Reranker.Candidates < 0 {
+			return fmt.Errorf("reranker candidates must not be negative")
+		}
+	default:
+		return fmt.Errorf("invalid reranker type '%s', must be one of: %s, %s", k.RAGSettings.Reranker.Type, types.RerankerTypeCrossEncoder, types.RerankerTypeLLM)
+	}
+
 	// At least one knowledge source must be specified
-	if k.Source.Web == nil && k.Source.Filestore == nil && k.Source.Content == nil {
+	if k.Source.Web == nil && k.Source.Filestore == nil && k.Source.Content == nil && k.Source.Github == nil &&
+		k.Source.S3 == nil && k.Source.GCS == nil {
 		return fmt.Errorf("at least one knowledge source must be specified")
 	}
 
+	if k.Source.S3 != nil && k.Source.S3.Bucket == "" {
+		return fmt.Errorf("s3 source requires a bucket")
+	}
+
+	if k.Source.GCS != nil && k.Source.GCS.Bucket == "" {
+		return fmt.Errorf("gcs source requires a bucket")
+	}
+
+	if k.Source.Github != nil {
+		if k.Source.Github.URL == "" && (k.Source.Github.Owner == "" || k.Source.Github.Repository == "") {
+			return fmt.Errorf("github source requires either a url or an owner and repository")
+		}
+
+		if k.Source.Github.URL != "" {
+			if err := validateGitURL(cfg, k.Source.Github.URL); err != nil {
+				return err
+			}
+		}
+	}
+
 	if k.Source.Web != nil {
 		if len(k.Source.Web.URLs) == 0 {
 			return fmt.Errorf("at least one url is required")
@@ -71,3 +118,99 @@ func Validate(cfg *config.ServerConfig, k *types.AssistantKnowledge) error {
 
 	return nil
 }
+
+// supportsHybridSearch returns whether the knowledge is indexed by a provider
+// that can combine keyword and vector search, custom RAG servers and
+// llamaindex only run vector search
+func supportsHybridSearch(cfg *config.ServerConfig, settings *types.RAGSettings) bool {
+	if settings.IndexURL != "" && settings.QueryURL != "" {
+		return false
+	}
+
+	switch cfg.RAG.DefaultRagProvider {
+	case config.RAGProviderTypesense, config.RAGProviderPGVector:
+		return true
+	default:
+		return false
+	}
+}
+
+// validateGitURL only allows remote https and ssh repositories so that
+// knowledge can't read local paths of the server. Without allowed hosts the
+// repositories can't be on private or loopback addresses, so knowledge can't
+// reach the internal services of the server's network.
+func validateGitURL(cfg *config.ServerConfig, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid git url %s: %w", rawURL, err)
+	}
+
+	if u.Scheme != "https" && u.Scheme != "ssh" {
+		return fmt.Errorf("git url must start with https:// or ssh://")
+	}
+
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("git url %s has no host", rawURL)
+	}
+
+	allowed := cfg.RAG.Git.AllowedHosts
+	if len(allowed) > 0 {
+		if !slices.ContainsFunc(allowed, func(allowedHost string) bool {
+			return strings.EqualFold(strings.TrimSpace(allowedHost), host)
+		}) {
+			return fmt.Errorf("git host %s is not allowed", host)
+		}
+		return nil
+	}
+
+	lower := strings.ToLower(host)
+	if lower == "localhost" || strings.HasSuffix(lower, ".localhost") {
+		return fmt.Errorf("git host %s is not allowed", host)
+	}
+
+	if ip := net.ParseIP(host); ip != nil && !isPublicIP(ip) {
+		return fmt.Errorf("git host %s is not allowed", host)
+	}
+
+	return nil
+}
+
+// validateGitRemote validates the url of a repository before it's cloned,
+// the host has to resolve to public addresses unless it's an allowed host
+func validateGitRemote(ctx context.Context, cfg *config.ServerConfig, rawURL string) error {
+	if err := validateGitURL(cfg, rawURL); err != nil {
+		return err
+	}
+
+	if len(cfg.RAG.Git.AllowedHosts) > 0 {
+		return nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid git url %s: %w", rawURL, err)
+	}
+
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("failed to resolve git host %s: %w", u.Hostname(), err)
+	}
+
+	for _, ip := range ips {
+		if !isPublicIP(ip) {
+			return fmt.Errorf("git host %s resolves to the private address %s, add it to the allowed hosts to clone from it", u.Hostname(), ip)
+		}
+	}
+
+	return nil
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsUnspecified()
+}
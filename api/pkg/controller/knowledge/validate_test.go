@@ -159,11 +159,213 @@ func TestValidate(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "Hybrid search mode",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				RAGSettings: types.RAGSettings{
+					SearchMode: types.RAGSearchModeHybrid,
+				},
+				Source: types.KnowledgeSource{
+					Content: &basicContent,
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "Hybrid search mode with a custom RAG server",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				RAGSettings: types.RAGSettings{
+					SearchMode: types.RAGSearchModeHybrid,
+					IndexURL:   "http://rag:5000/api/v1/rag/chunk",
+					QueryURL:   "http://rag:5000/api/v1/rag/query",
+				},
+				Source: types.KnowledgeSource{
+					Content: &basicContent,
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "Invalid search mode",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				RAGSettings: types.RAGSettings{
+					SearchMode: "keyword",
+				},
+				Source: types.KnowledgeSource{
+					Content: &basicContent,
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "Valid reranker",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				RAGSettings: types.RAGSettings{
+					Reranker: types.RerankerSettings{
+						Type:     types.RerankerTypeCrossEncoder,
+						Provider: "vllm",
+						Model:    "BAAI/bge-reranker-v2-m3",
+					},
+				},
+				Source: types.KnowledgeSource{
+					Content: &basicContent,
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "Reranker without model",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				RAGSettings: types.RAGSettings{
+					Reranker: types.RerankerSettings{
+						Type:     types.RerankerTypeLLM,
+						Provider: "openai",
+					},
+				},
+				Source: types.KnowledgeSource{
+					Content: &basicContent,
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "Valid github source",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				Source: types.KnowledgeSource{
+					Github: &types.KnowledgeSourceGithub{
+						Owner:      "helixml",
+						Repository: "helix",
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "Github source without repository",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				Source: types.KnowledgeSource{
+					Github: &types.KnowledgeSourceGithub{
+						Owner: "helixml",
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "Github source with https url",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				Source: types.KnowledgeSource{
+					Github: &types.KnowledgeSourceGithub{
+						URL: "https://gitlab.com/org/repo.git",
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "Github source with ssh url",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				Source: types.KnowledgeSource{
+					Github: &types.KnowledgeSourceGithub{
+						URL: "ssh://git@gitlab.com/org/repo.git",
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "Github source with file url",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				Source: types.KnowledgeSource{
+					Github: &types.KnowledgeSourceGithub{
+						URL: "file:///etc",
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "Github source with local path",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				Source: types.KnowledgeSource{
+					Github: &types.KnowledgeSourceGithub{
+						URL: "/var/lib/helix",
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "Github source with scp-like url",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				Source: types.KnowledgeSource{
+					Github: &types.KnowledgeSourceGithub{
+						URL: "git@gitlab.com:org/repo.git",
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "Valid s3 source",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				Source: types.KnowledgeSource{
+					S3: &types.KnowledgeSourceS3{
+						Bucket: "docs",
+						Path:   "manuals/",
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "GCS source without bucket",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				Source: types.KnowledgeSource{
+					GCS: &types.KnowledgeSourceGCS{
+						Path: "manuals/",
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "Invalid reranker type",
+			knowledge: &types.AssistantKnowledge{
+				Name: "Test",
+				RAGSettings: types.RAGSettings{
+					Reranker: types.RerankerSettings{
+						Type:     "colbert",
+						Provider: "vllm",
+						Model:    "colbert-v2",
+					},
+				},
+				Source: types.KnowledgeSource{
+					Content: &basicContent,
+				},
+			},
+			expectError: true,
+		},
 	}
 
 	serverConfig := config.ServerConfig{}
 	serverConfig.RAG.Crawler.MaxFrequency = 10 * time.Minute
 	serverConfig.RAG.Crawler.MaxDepth = 30
+	serverConfig.RAG.DefaultRagProvider = config.RAGProviderTypesense
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -176,3 +378,56 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestValidate_HybridSearchLlamaindex(t *testing.T) {
+	content := "Hello, world!"
+
+	cfg := &config.ServerConfig{}
+	cfg.RAG.DefaultRagProvider = config.RAGProviderLlamaindex
+
+	err := Validate(cfg, &types.AssistantKnowledge{
+		Name: "Test",
+		RAGSettings: types.RAGSettings{
+			SearchMode: types.RAGSearchModeHybrid,
+		},
+		Source: types.KnowledgeSource{
+			Content: &content,
+		},
+	})
+	assert.Error(t, err)
+}
+
+func Test_validateGitURL_AllowedHosts(t *testing.T) {
+	cfg := &config.ServerConfig{}
+	cfg.RAG.Git.AllowedHosts = []string{"gitlab.example.com"}
+
+	assert.NoError(t, validateGitURL(cfg, "https://GitLab.example.com/org/repo.git"))
+	assert.NoError(t, validateGitURL(cfg, "ssh://git@gitlab.example.com:2222/org/repo.git"))
+	assert.Error(t, validateGitURL(cfg, "https://github.com/org/repo.git"))
+	assert.Error(t, validateGitURL(cfg, "https://gitlab.example.com.evil.com/org/repo.git"))
+
+	// Private hosts can be allowed explicitly
+	cfg.RAG.Git.AllowedHosts = []string{"10.0.0.5"}
+	assert.NoError(t, validateGitURL(cfg, "https://10.0.0.5/org/repo.git"))
+}
+
+func Test_validateGitURL_PrivateHosts(t *testing.T) {
+	cfg := &config.ServerConfig{}
+
+	for _, rawURL := range []string{
+		"https://localhost/org/repo.git",
+		"https://git.localhost/org/repo.git",
+		"https://127.0.0.1/org/repo.git",
+		"https://10.0.0.5/org/repo.git",
+		"https://192.168.1.10/org/repo.git",
+		"https://169.254.169.254/latest/meta-data",
+		"https://[::1]/org/repo.git",
+		"https://[fd00::1]/org/repo.git",
+		"ssh://git@0.0.0.0/org/repo.git",
+	} {
+		assert.Error(t, validateGitURL(cfg, rawURL), rawURL)
+	}
+
+	assert.NoError(t, validateGitURL(cfg, "https://gitlab.com/org/repo.git"))
+	assert.NoError(t, validateGitURL(cfg, "https://140.82.112.3/org/repo.git"))
+}
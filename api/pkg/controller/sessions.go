@@ -16,6 +16,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/sashabaranov/go-openai"
 
+	"github.com/helixml/helix/api/pkg/auth"
 	"github.com/helixml/helix/api/pkg/data"
 	"github.com/helixml/helix/api/pkg/notification"
 	"github.com/helixml/helix/api/pkg/prompts"
@@ -578,8 +579,13 @@ func (c *Controller) checkForActions(session *types.Session) (*types.Session, er
 		}
 
 		// if the tool exists but the user cannot access it - then something funky is being attempted and we should deny it
-		if (!app.Global && !app.Shared) && app.Owner != session.Owner {
-			return nil, system.NewHTTPError403(fmt.Sprintf("you do not have access to the app with the id: %s", app.ID))
+		sessionOwner := &types.User{ID: session.Owner, Type: session.OwnerType}
+		err = auth.NewAuthorizer(c.Options.Store).Authorize(ctx, sessionOwner, auth.AppResource(app), auth.ActionUse)
+		if err != nil {
+			if errors.Is(err, auth.ErrForbidden) {
+				return nil, system.NewHTTPError403(fmt.Sprintf("you do not have access to the app with the id: %s", app.ID))
+			}
+			return nil, fmt.Errorf("error checking app access: %w", err)
 		}
 
 		if len(app.Config.Helix.Assistants) > 0 {
@@ -604,8 +610,14 @@ func (c *Controller) checkForActions(session *types.Session) (*types.Session, er
 			}
 
 			// if the tool exists but the user cannot access it - then something funky is being attempted and we should deny it
-			if !tool.Global && tool.Owner != session.Owner {
-				return nil, system.NewHTTPError403(fmt.Sprintf("you do not have access to the tool with the id: %s", tool.ID))
+			if !tool.Global {
+				canUse, _, err := store.IsOwnerMember(ctx, c.Options.Store, session.Owner, tool.Owner, tool.OwnerType)
+				if err != nil {
+					return nil, fmt.Errorf("error checking tool access: %w", err)
+				}
+				if !canUse {
+					return nil, system.NewHTTPError403(fmt.Sprintf("you do not have access to the tool with the id: %s", tool.ID))
+				}
 			}
 
 			activeTools = append(activeTools, tool)
@@ -645,6 +657,10 @@ func (c *Controller) checkForActions(session *types.Session) (*types.Session, er
 	if assistant != nil && assistant.Model != "" {
 		options = append(options, tools.WithModel(assistant.Model))
 	}
+	if assistant != nil && assistant.NativeToolCalling {
+		options = append(options, tools.WithNativeToolCalling(true))
+	}
+	options = append(options, tools.WithOwner(session.Owner), tools.WithAppID(session.ParentApp))
 
 	isActionable, err := c.ToolsPlanner.IsActionable(ctx, session.ID, lastInteraction.ID, activeTools, messageHistory, options...)
 	if err != nil {
@@ -669,6 +685,15 @@ func (c *Controller) checkForActions(session *types.Session) (*types.Session, er
 	lastInteraction.Metadata["tool_action"] = isActionable.API
 	lastInteraction.Metadata["tool_action_justification"] = isActionable.Justification
 
+	// Keep the arguments of the function call for when the action runs
+	if isActionable.Arguments != nil {
+		arguments, err := json.Marshal(isActionable.Arguments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal tool action arguments: %w", err)
+		}
+		lastInteraction.Metadata["tool_action_arguments"] = string(arguments)
+	}
+
 	actionTool, ok := tools.GetToolFromAction(activeTools, isActionable.API)
 	if !ok {
 		return nil, fmt.Errorf("tool not found for action: %s", isActionable.API)
@@ -848,6 +873,12 @@ func (c *Controller) ErrorSession(ctx context.Context, session *types.Session, s
 // we mark the session as "preparing" here to give text fine tuning
 // a chance to sort itself out in the background
 func (c *Controller) AddSessionToQueue(session *types.Session) error {
+	runnerSelector, err := c.getSessionRunnerSelector(c.Ctx, session)
+	if err != nil {
+		return err
+	}
+	session.Metadata.RunnerSelector = runnerSelector
+
 	work, err := scheduler.NewSessionWorkload(session)
 	if err != nil {
 		return fmt.Errorf("error creating workload: %w", err)
@@ -885,6 +916,24 @@ func (c *Controller) AddSessionToQueue(session *types.Session) error {
 	return nil
 }
 
+// getSessionRunnerSelector returns the runner selector of the session's app,
+// the session itself can't set one
+func (c *Controller) getSessionRunnerSelector(ctx context.Context, session *types.Session) (*types.RunnerSelector, error) {
+	if session.ParentApp == "" {
+		return nil, nil
+	}
+
+	app, err := c.Options.Store.GetApp(ctx, session.ParentApp)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get app %s: %w", session.ParentApp, err)
+	}
+
+	return app.Config.Helix.RunnerSelector, nil
+}
+
 func (c *Controller) pubsubHandler(session *types.Session, payload []byte) error {
 	lastInteraction, err := data.GetLastInteraction(session)
 	if err != nil {
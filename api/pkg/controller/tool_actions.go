@@ -2,9 +2,11 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/helixml/helix/api/pkg/data"
+	"github.com/helixml/helix/api/pkg/tools"
 	"github.com/helixml/helix/api/pkg/types"
 	"github.com/rs/zerolog/log"
 )
@@ -17,8 +19,11 @@ func (c *Controller) runActionInteraction(ctx context.Context, session *types.Se
 		return nil, fmt.Errorf("action not found in interaction metadata")
 	}
 
-	var tool *types.Tool
-	var err error
+	var (
+		tool              *types.Tool
+		nativeToolCalling bool
+		err               error
+	)
 
 	toolID, ok := assistantInteraction.Metadata["tool_id"]
 	if !ok {
@@ -43,6 +48,8 @@ func (c *Controller) runActionInteraction(ctx context.Context, session *types.Se
 			return nil, fmt.Errorf("we could not find the assistant with the id: %s", assistantID)
 		}
 
+		nativeToolCalling = assistant.NativeToolCalling
+
 		for _, appTool := range assistant.Tools {
 			if appTool.ID == toolID {
 				tool = appTool
@@ -55,8 +62,20 @@ func (c *Controller) runActionInteraction(ctx context.Context, session *types.Se
 		}
 	}
 
+	if tool == nil {
+		return nil, fmt.Errorf("tool %s not found", toolID)
+	}
+
+	// Denied actions and the ones waiting for approval don't run
+	if updated, stop, err := c.checkActionPolicy(ctx, session, assistantInteraction, tool, action); err != nil || stop {
+		return updated, err
+	}
+
 	// Override query parameters if the user has specified them
 	for paramName, paramValue := range session.Metadata.AppQueryParams {
+		if tool.Config.API == nil {
+			break
+		}
 		for queryName, queryValue := range tool.Config.API.Query {
 			// If the request query params match something in the tool query params, override it
 			if queryName == paramName {
@@ -83,7 +102,20 @@ func (c *Controller) runActionInteraction(ctx context.Context, session *types.Se
 		Str("history", fmt.Sprintf("%+v", messageHistory)).
 		Msg("Running tool action")
 
-	resp, err := c.ToolsPlanner.RunAction(ctx, session.ID, assistantInteraction.ID, tool, messageHistory, action)
+	options := []tools.Option{
+		tools.WithOwner(session.Owner), tools.WithAppID(session.ParentApp), tools.WithNativeToolCalling(nativeToolCalling),
+	}
+
+	// The function call that picked the action has its arguments
+	if arguments, ok := assistantInteraction.Metadata["tool_action_arguments"]; ok {
+		var params map[string]any
+		if err := json.Unmarshal([]byte(arguments), &params); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal tool action arguments: %w", err)
+		}
+		options = append(options, tools.WithArguments(params))
+	}
+
+	resp, err := c.ToolsPlanner.RunAction(ctx, session.ID, assistantInteraction.ID, tool, messageHistory, action, options...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to perform action: %w", err)
 	}
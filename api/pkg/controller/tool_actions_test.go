@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/tools"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func Test_runActionInteraction_NativeToolCalling(t *testing.T) {
+	for _, nativeToolCalling := range []bool{false, true} {
+		test := newToolApprovalTest(t)
+		test.allowSessionUpdates()
+
+		session := actionSession("listOrders", nil)
+		session.ParentApp = "app_1"
+
+		test.store.EXPECT().GetAppWithTools(gomock.Any(), "app_1").Return(&types.App{
+			ID: "app_1",
+			Config: types.AppConfig{
+				Helix: types.AppHelixConfig{
+					Assistants: []types.AssistantConfig{
+						{NativeToolCalling: nativeToolCalling, Tools: []*types.Tool{ordersTool()}},
+					},
+				},
+			},
+		}, nil)
+
+		// The action runs with the assistant's tool calling, like the
+		// planning that picked it
+		var want tools.Options
+		for _, opt := range []tools.Option{tools.WithOwner("user_1"), tools.WithAppID("app_1"), tools.WithNativeToolCalling(nativeToolCalling)} {
+			require.NoError(t, opt(&want))
+		}
+
+		test.planner.EXPECT().RunAction(gomock.Any(), "ses_1", "i-assistant", gomock.Any(), gomock.Any(), "listOrders", gomock.Any()).
+			DoAndReturn(func(_ context.Context, _, _ string, _ *types.Tool, _ []*types.ToolHistoryMessage, _ string, opts ...tools.Option) (*tools.RunActionResponse, error) {
+				var got tools.Options
+				for _, opt := range opts {
+					require.NoError(t, opt(&got))
+				}
+				require.Equal(t, want, got)
+
+				return &tools.RunActionResponse{Message: "No orders"}, nil
+			})
+
+		updated, err := test.controller.runActionInteraction(context.Background(), session, session.Interactions[1])
+		require.NoError(t, err)
+		require.Equal(t, "No orders", updated.Interactions[1].Message)
+	}
+}
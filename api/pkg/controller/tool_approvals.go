@@ -0,0 +1,422 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/data"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/tools"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+const (
+	// defaultToolApprovalTimeout applies when the config doesn't set one
+	defaultToolApprovalTimeout = time.Hour
+
+	// toolApprovalSweepInterval is how often the expired approvals and the
+	// approved actions that didn't run are looked up in the store
+	toolApprovalSweepInterval = time.Minute
+
+	// toolApprovalResumeLease is how long an approved action that was picked
+	// up to run can still wait before it's resumed again, the process running
+	// it may have exited
+	toolApprovalResumeLease = 10 * time.Minute
+
+	// toolApprovalResumeWindow is how long after the decision the approved
+	// actions that didn't run are resumed
+	toolApprovalResumeWindow = 24 * time.Hour
+)
+
+var (
+	ErrToolApprovalForbidden = errors.New("you are not allowed to decide on this tool action")
+	ErrToolApprovalDecided   = errors.New("tool action is already decided")
+	ErrToolApprovalExpired   = errors.New("tool action approval has expired")
+)
+
+// checkActionPolicy applies the policy of the action before it runs. It
+// returns true with the updated session when the action must not run now:
+// it's denied or it waits for approval.
+func (c *Controller) checkActionPolicy(ctx context.Context, session *types.Session, assistantInteraction *types.Interaction, tool *types.Tool, action string) (*types.Session, bool, error) {
+	switch tools.GetActionPolicy(tool, action) {
+	case types.ToolActionPolicyDeny:
+		log.Info().
+			Str("session_id", session.ID).
+			Str("tool", tool.Name).
+			Str("action", action).
+			Msg("tool action denied by policy")
+
+		updated, err := c.completeActionInteraction(ctx, session, fmt.Sprintf("The action %s of the %s tool is not allowed to run.", action, tool.Name))
+		return updated, true, err
+	case types.ToolActionPolicyConfirm:
+		approvalID := assistantInteraction.Metadata["tool_approval_id"]
+		if approvalID != "" {
+			approval, err := c.Options.Store.GetToolApproval(ctx, approvalID)
+			if err != nil {
+				return nil, true, fmt.Errorf("failed to get tool approval %s: %w", approvalID, err)
+			}
+			if approval.Status == types.ToolApprovalStatusApproved && approval.Action == action {
+				return nil, false, nil
+			}
+		}
+
+		updated, err := c.requestToolApproval(ctx, session, assistantInteraction, tool, action)
+		return updated, true, err
+	default:
+		return nil, false, nil
+	}
+}
+
+// requestToolApproval pauses the action interaction until a user approves
+// the action or the approval expires
+func (c *Controller) requestToolApproval(ctx context.Context, session *types.Session, assistantInteraction *types.Interaction, tool *types.Tool, action string) (*types.Session, error) {
+	timeout := c.Options.Config.Tools.ApprovalTimeout
+	if timeout <= 0 {
+		timeout = defaultToolApprovalTimeout
+	}
+
+	approval := &types.ToolApproval{
+		SessionID:     session.ID,
+		InteractionID: assistantInteraction.ID,
+		Owner:         session.Owner,
+		OwnerType:     session.OwnerType,
+		AppID:         session.ParentApp,
+		ToolID:        tool.ID,
+		ToolName:      tool.Name,
+		Action:        action,
+		Justification: assistantInteraction.Metadata["tool_action_justification"],
+		Status:        types.ToolApprovalStatusPending,
+		ExpiresAt:     time.Now().Add(timeout),
+	}
+
+	if tool.Config.API != nil {
+		for _, a := range tool.Config.API.Actions {
+			if a.Name == action {
+				approval.Method = strings.ToUpper(a.Method)
+				approval.Path = a.Path
+			}
+		}
+	}
+
+	if config := tools.GetApprovalConfig(tool); config != nil {
+		approval.Approvers = config.Approvers
+	}
+
+	approval, err := c.Options.Store.CreateToolApproval(ctx, approval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tool approval: %w", err)
+	}
+
+	updated, err := data.UpdateAssistantInteraction(session, func(assistantInteraction *types.Interaction) (*types.Interaction, error) {
+		assistantInteraction.State = types.InteractionStateWaiting
+		assistantInteraction.Status = fmt.Sprintf("waiting for approval to run %s", action)
+		assistantInteraction.Metadata["tool_approval_id"] = approval.ID
+		return assistantInteraction, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update assistant interaction: %w", err)
+	}
+
+	if err := c.WriteSession(ctx, updated); err != nil {
+		// NOTE: we dont return here as this "only" emits WS events
+		log.Err(err).Msg("failed writing session")
+	}
+
+	log.Info().
+		Str("session_id", session.ID).
+		Str("approval_id", approval.ID).
+		Str("tool", tool.Name).
+		Str("action", action).
+		Time("expires_at", approval.ExpiresAt).
+		Msg("tool action waiting for approval")
+
+	c.publishToolApproval(ctx, approval)
+
+	return updated, nil
+}
+
+// GetToolApproval returns the approval if the user can decide on it
+func (c *Controller) GetToolApproval(ctx context.Context, user *types.User, id string) (*types.ToolApproval, error) {
+	approval, err := c.Options.Store.GetToolApproval(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canDecideToolApproval(user, approval) {
+		return nil, ErrToolApprovalForbidden
+	}
+
+	return approval, nil
+}
+
+// DecideToolApproval records the decision of the user on a pending tool
+// action. Approved actions resume in the background, the sweeper resumes them
+// if the process exits before they run. Denied ones complete the interaction.
+func (c *Controller) DecideToolApproval(ctx context.Context, user *types.User, id string, decision *types.ToolApprovalDecision) (*types.ToolApproval, error) {
+	approval, err := c.GetToolApproval(ctx, user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if approval.Status != types.ToolApprovalStatusPending {
+		return nil, fmt.Errorf("%w: %s", ErrToolApprovalDecided, approval.Status)
+	}
+
+	if time.Now().After(approval.ExpiresAt) {
+		if _, err := c.expireToolApproval(ctx, approval.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrToolApprovalExpired
+	}
+
+	now := time.Now()
+	approval.Status = types.ToolApprovalStatusDenied
+	if decision.Approved {
+		approval.Status = types.ToolApprovalStatusApproved
+	}
+	approval.DecidedBy = user.ID
+	approval.DecidedAt = &now
+	approval.Reason = decision.Reason
+
+	approval, err = c.Options.Store.UpdateToolApproval(ctx, approval)
+	if err != nil {
+		if errors.Is(err, store.ErrToolApprovalNotPending) {
+			// Another decision or the expiry got there first
+			return nil, ErrToolApprovalDecided
+		}
+		return nil, fmt.Errorf("failed to update tool approval: %w", err)
+	}
+
+	log.Info().
+		Str("approval_id", approval.ID).
+		Str("session_id", approval.SessionID).
+		Str("tool", approval.ToolName).
+		Str("action", approval.Action).
+		Str("status", string(approval.Status)).
+		Str("decided_by", approval.DecidedBy).
+		Str("reason", approval.Reason).
+		Msg("tool action decided")
+
+	c.publishToolApproval(ctx, approval)
+
+	if decision.Approved {
+		go func() {
+			if err := c.resumeToolApproval(c.Ctx, approval); err != nil {
+				log.Error().Err(err).Str("approval_id", approval.ID).Msg("failed to resume approved tool action")
+			}
+		}()
+		return approval, nil
+	}
+
+	session, ok, err := c.getToolApprovalSession(ctx, approval)
+	if err != nil || !ok {
+		return approval, err
+	}
+
+	message := fmt.Sprintf("The action %s of the %s tool was denied.", approval.Action, approval.ToolName)
+	if approval.Reason != "" {
+		message = fmt.Sprintf("%s Reason: %s", message, approval.Reason)
+	}
+	if _, err := c.completeActionInteraction(ctx, session, message); err != nil {
+		return nil, err
+	}
+
+	return approval, nil
+}
+
+// resumeToolApproval runs the approved action if its interaction still waits
+// on it. The approval is marked as resumed first so the action runs once.
+func (c *Controller) resumeToolApproval(ctx context.Context, approval *types.ToolApproval) error {
+	session, ok, err := c.getToolApprovalSession(ctx, approval)
+	if err != nil || !ok {
+		return err
+	}
+
+	lastInteraction, err := data.GetLastAssistantInteraction(session.Interactions)
+	if err != nil {
+		return fmt.Errorf("failed to get last assistant interaction: %w", err)
+	}
+	if lastInteraction.State != types.InteractionStateWaiting {
+		return nil
+	}
+
+	_, err = c.Options.Store.ResumeToolApproval(ctx, approval.ID, time.Now().Add(-toolApprovalResumeLease))
+	if err != nil {
+		if errors.Is(err, store.ErrToolApprovalResumed) {
+			// The action is already running
+			return nil
+		}
+		return fmt.Errorf("failed to resume tool approval: %w", err)
+	}
+
+	if _, err := c.runActionInteraction(ctx, session, lastInteraction); err != nil {
+		log.Error().Err(err).Str("session_id", session.ID).Msg("error running approved action interaction")
+		c.ErrorSession(ctx, session, err)
+	}
+
+	return nil
+}
+
+// expireToolApproval expires the approval if it's still pending, the
+// interaction completes without running the action
+func (c *Controller) expireToolApproval(ctx context.Context, id string) (*types.ToolApproval, error) {
+	approval, err := c.Options.Store.GetToolApproval(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if approval.Status != types.ToolApprovalStatusPending {
+		return approval, nil
+	}
+
+	approval.Status = types.ToolApprovalStatusExpired
+	approval, err = c.Options.Store.UpdateToolApproval(ctx, approval)
+	if err != nil {
+		if errors.Is(err, store.ErrToolApprovalNotPending) {
+			return c.Options.Store.GetToolApproval(ctx, id)
+		}
+		return nil, fmt.Errorf("failed to update tool approval: %w", err)
+	}
+
+	log.Info().
+		Str("approval_id", approval.ID).
+		Str("session_id", approval.SessionID).
+		Str("action", approval.Action).
+		Msg("tool action approval expired")
+
+	c.publishToolApproval(ctx, approval)
+
+	session, ok, err := c.getToolApprovalSession(ctx, approval)
+	if err != nil || !ok {
+		return approval, err
+	}
+
+	_, err = c.completeActionInteraction(ctx, session, fmt.Sprintf("The action %s of the %s tool was not approved in time.", approval.Action, approval.ToolName))
+	return approval, err
+}
+
+// RunToolApprovalSweeper expires the pending approvals past their deadline
+// and resumes the approved actions that didn't run until the context is done.
+// The approvals are kept in the store so they're still picked up after a
+// restart.
+func (c *Controller) RunToolApprovalSweeper(ctx context.Context) {
+	ticker := time.NewTicker(toolApprovalSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		c.expireToolApprovals(ctx)
+		c.resumeToolApprovals(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// expireToolApprovals expires the pending approvals past their deadline
+func (c *Controller) expireToolApprovals(ctx context.Context) {
+	approvals, err := c.Options.Store.ListToolApprovals(ctx, &store.ListToolApprovalsQuery{
+		Status:        types.ToolApprovalStatusPending,
+		ExpiresBefore: time.Now(),
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list expired tool approvals")
+		return
+	}
+
+	for _, approval := range approvals {
+		if _, err := c.expireToolApproval(ctx, approval.ID); err != nil {
+			log.Error().Err(err).Str("approval_id", approval.ID).Msg("failed to expire tool approval")
+		}
+	}
+}
+
+// resumeToolApprovals runs the approved actions whose interaction still waits
+// on them, the process that should have run them may have exited
+func (c *Controller) resumeToolApprovals(ctx context.Context) {
+	now := time.Now()
+
+	approvals, err := c.Options.Store.ListToolApprovals(ctx, &store.ListToolApprovalsQuery{
+		Status:        types.ToolApprovalStatusApproved,
+		DecidedAfter:  now.Add(-toolApprovalResumeWindow),
+		ResumedBefore: now.Add(-toolApprovalResumeLease),
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list approved tool approvals")
+		return
+	}
+
+	for _, approval := range approvals {
+		if err := c.resumeToolApproval(ctx, approval); err != nil {
+			log.Error().Err(err).Str("approval_id", approval.ID).Msg("failed to resume approved tool action")
+		}
+	}
+}
+
+// getToolApprovalSession returns the session of the approval if it's still
+// waiting on it, users may have moved on since
+func (c *Controller) getToolApprovalSession(ctx context.Context, approval *types.ToolApproval) (*types.Session, bool, error) {
+	session, err := c.Options.Store.GetSession(ctx, approval.SessionID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to get session %s: %w", approval.SessionID, err)
+	}
+
+	lastInteraction, err := data.GetLastAssistantInteraction(session.Interactions)
+	if err != nil || lastInteraction.ID != approval.InteractionID || lastInteraction.Metadata["tool_approval_id"] != approval.ID {
+		return nil, false, nil
+	}
+
+	return session, true, nil
+}
+
+// completeActionInteraction finishes the action interaction with a message
+// instead of the result of the action
+func (c *Controller) completeActionInteraction(ctx context.Context, session *types.Session, message string) (*types.Session, error) {
+	updated, err := data.UpdateAssistantInteraction(session, func(assistantInteraction *types.Interaction) (*types.Interaction, error) {
+		assistantInteraction.Finished = true
+		assistantInteraction.Message = message
+		assistantInteraction.Status = ""
+		assistantInteraction.State = types.InteractionStateComplete
+		assistantInteraction.Completed = time.Now()
+		return assistantInteraction, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update assistant interaction: %w", err)
+	}
+
+	if err := c.WriteSession(ctx, updated); err != nil {
+		// NOTE: we dont return here as this "only" emits WS events
+		log.Err(err).Msg("failed writing session")
+	}
+
+	return updated, nil
+}
+
+// publishToolApproval notifies the owner of the session and the approvers
+// watching it
+func (c *Controller) publishToolApproval(ctx context.Context, approval *types.ToolApproval) {
+	for _, owner := range append([]string{approval.Owner}, approval.Approvers...) {
+		_ = c.publishEvent(ctx, &types.WebsocketEvent{
+			Type:          types.WebsocketEventToolApproval,
+			SessionID:     approval.SessionID,
+			InteractionID: approval.InteractionID,
+			Owner:         owner,
+			ToolApproval:  approval,
+		})
+	}
+}
+
+func canDecideToolApproval(user *types.User, approval *types.ToolApproval) bool {
+	return user.Admin || user.ID == approval.Owner || slices.Contains(approval.Approvers, user.ID)
+}
@@ -0,0 +1,421 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/janitor"
+	"github.com/helixml/helix/api/pkg/pubsub"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/tools"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+type toolApprovalTest struct {
+	controller *Controller
+	store      *store.MockStore
+	planner    *tools.MockPlanner
+	pubsub     pubsub.PubSub
+}
+
+func newToolApprovalTest(t *testing.T) *toolApprovalTest {
+	ctrl := gomock.NewController(t)
+
+	ps, err := pubsub.New(&config.ServerConfig{
+		PubSub: config.PubSub{
+			Provider: string(pubsub.ProviderMemory),
+		},
+	})
+	require.NoError(t, err)
+
+	cfg := &config.ServerConfig{}
+	cfg.Tools.ApprovalTimeout = time.Hour
+
+	test := &toolApprovalTest{
+		store:   store.NewMockStore(ctrl),
+		planner: tools.NewMockPlanner(ctrl),
+		pubsub:  ps,
+	}
+	test.controller = &Controller{
+		Ctx: context.Background(),
+		Options: Options{
+			Config:  cfg,
+			Store:   test.store,
+			PubSub:  ps,
+			Janitor: janitor.NewJanitor(config.Janitor{}),
+		},
+		ToolsPlanner: test.planner,
+	}
+
+	return test
+}
+
+func (test *toolApprovalTest) allowSessionUpdates() {
+	test.store.EXPECT().UpdateSession(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, session types.Session) (*types.Session, error) {
+		return &session, nil
+	}).AnyTimes()
+}
+
+// subscribe returns the tool approval events the user receives for the
+// session
+func (test *toolApprovalTest) subscribe(t *testing.T, owner string) chan *types.WebsocketEvent {
+	events := make(chan *types.WebsocketEvent, 10)
+
+	sub, err := test.pubsub.Subscribe(context.Background(), pubsub.GetSessionQueue(owner, "ses_1"), func(payload []byte) error {
+		var event types.WebsocketEvent
+		require.NoError(t, json.Unmarshal(payload, &event))
+		if event.Type == types.WebsocketEventToolApproval {
+			events <- &event
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sub.Unsubscribe() })
+
+	return events
+}
+
+func receiveEvent(t *testing.T, events chan *types.WebsocketEvent) *types.WebsocketEvent {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the tool approval event")
+		return nil
+	}
+}
+
+func ordersTool() *types.Tool {
+	return &types.Tool{
+		ID:       "tool_orders",
+		Name:     "orders",
+		ToolType: types.ToolTypeAPI,
+		Config: types.ToolConfig{
+			API: &types.ToolAPIConfig{
+				URL: "https://example.com/api",
+				Actions: []*types.ToolAPIAction{
+					{Name: "listOrders", Method: "GET", Path: "/orders"},
+					{Name: "deleteOrder", Method: "DELETE", Path: "/orders/{id}"},
+					{Name: "purgeOrders", Method: "DELETE", Path: "/orders"},
+				},
+				Approval: &types.ToolApprovalConfig{
+					Mutating:  types.ToolActionPolicyConfirm,
+					Actions:   map[string]types.ToolActionPolicy{"purgeOrders": types.ToolActionPolicyDeny},
+					Approvers: []string{"approver_1"},
+				},
+			},
+		},
+	}
+}
+
+func actionSession(action string, metadata map[string]string) *types.Session {
+	interactionMetadata := map[string]string{
+		"tool_action":               action,
+		"tool_action_justification": "The user asked to delete the order",
+		"tool_id":                   "tool_orders",
+	}
+	for k, v := range metadata {
+		interactionMetadata[k] = v
+	}
+
+	return &types.Session{
+		ID:        "ses_1",
+		Owner:     "user_1",
+		OwnerType: types.OwnerTypeUser,
+		Interactions: []*types.Interaction{
+			{ID: "i-user", Creator: types.CreatorTypeUser, Message: "Delete order 42", Metadata: map[string]string{}},
+			{ID: "i-assistant", Creator: types.CreatorTypeAssistant, Mode: types.SessionModeAction, Metadata: interactionMetadata},
+		},
+	}
+}
+
+func Test_runActionInteraction_RequestsApproval(t *testing.T) {
+	test := newToolApprovalTest(t)
+	test.allowSessionUpdates()
+	ownerEvents := test.subscribe(t, "user_1")
+	approverEvents := test.subscribe(t, "approver_1")
+
+	session := actionSession("deleteOrder", nil)
+
+	test.store.EXPECT().GetTool(gomock.Any(), "tool_orders").Return(ordersTool(), nil)
+	test.store.EXPECT().CreateToolApproval(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, approval *types.ToolApproval) (*types.ToolApproval, error) {
+		require.Equal(t, "ses_1", approval.SessionID)
+		require.Equal(t, "i-assistant", approval.InteractionID)
+		require.Equal(t, "user_1", approval.Owner)
+		require.Equal(t, "deleteOrder", approval.Action)
+		require.Equal(t, "DELETE", approval.Method)
+		require.Equal(t, "/orders/{id}", approval.Path)
+		require.Equal(t, "The user asked to delete the order", approval.Justification)
+		require.Equal(t, []string{"approver_1"}, []string(approval.Approvers))
+		require.Equal(t, types.ToolApprovalStatusPending, approval.Status)
+		require.WithinDuration(t, time.Now().Add(time.Hour), approval.ExpiresAt, time.Minute)
+
+		approval.ID = "tap_1"
+		return approval, nil
+	})
+
+	updated, err := test.controller.runActionInteraction(context.Background(), session, session.Interactions[1])
+	require.NoError(t, err)
+
+	interaction := updated.Interactions[1]
+	require.Equal(t, types.InteractionStateWaiting, interaction.State)
+	require.False(t, interaction.Finished)
+	require.Equal(t, "tap_1", interaction.Metadata["tool_approval_id"])
+
+	for _, events := range []chan *types.WebsocketEvent{ownerEvents, approverEvents} {
+		event := receiveEvent(t, events)
+		require.Equal(t, "tap_1", event.ToolApproval.ID)
+		require.Equal(t, types.ToolApprovalStatusPending, event.ToolApproval.Status)
+	}
+}
+
+func Test_runActionInteraction_Denied(t *testing.T) {
+	test := newToolApprovalTest(t)
+	test.allowSessionUpdates()
+	session := actionSession("purgeOrders", nil)
+
+	test.store.EXPECT().GetTool(gomock.Any(), "tool_orders").Return(ordersTool(), nil)
+
+	updated, err := test.controller.runActionInteraction(context.Background(), session, session.Interactions[1])
+	require.NoError(t, err)
+
+	interaction := updated.Interactions[1]
+	require.Equal(t, types.InteractionStateComplete, interaction.State)
+	require.True(t, interaction.Finished)
+	require.Contains(t, interaction.Message, "not allowed")
+}
+
+func pendingApproval() *types.ToolApproval {
+	return &types.ToolApproval{
+		ID:            "tap_1",
+		SessionID:     "ses_1",
+		InteractionID: "i-assistant",
+		Owner:         "user_1",
+		ToolID:        "tool_orders",
+		ToolName:      "orders",
+		Action:        "deleteOrder",
+		Approvers:     []string{"approver_1"},
+		Status:        types.ToolApprovalStatusPending,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	}
+}
+
+// waitingSession is the session of an action waiting for the approval
+func waitingSession() *types.Session {
+	session := actionSession("deleteOrder", map[string]string{"tool_approval_id": "tap_1"})
+	session.Interactions[1].State = types.InteractionStateWaiting
+	return session
+}
+
+func Test_DecideToolApproval_Approve(t *testing.T) {
+	test := newToolApprovalTest(t)
+	session := waitingSession()
+
+	var stored *types.ToolApproval
+	test.store.EXPECT().GetToolApproval(gomock.Any(), "tap_1").DoAndReturn(func(_ context.Context, _ string) (*types.ToolApproval, error) {
+		if stored != nil {
+			return stored, nil
+		}
+		return pendingApproval(), nil
+	}).Times(2)
+	test.store.EXPECT().UpdateToolApproval(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, approval *types.ToolApproval) (*types.ToolApproval, error) {
+		stored = approval
+		return approval, nil
+	})
+	test.store.EXPECT().GetSession(gomock.Any(), "ses_1").Return(session, nil)
+	test.store.EXPECT().ResumeToolApproval(gomock.Any(), "tap_1", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, resumedBefore time.Time) (*types.ToolApproval, error) {
+		require.WithinDuration(t, time.Now().Add(-toolApprovalResumeLease), resumedBefore, time.Minute)
+		return stored, nil
+	})
+	test.store.EXPECT().GetTool(gomock.Any(), "tool_orders").Return(ordersTool(), nil)
+
+	test.planner.EXPECT().RunAction(gomock.Any(), "ses_1", "i-assistant", gomock.Any(), gomock.Any(), "deleteOrder", gomock.Any()).
+		Return(&tools.RunActionResponse{Message: "Order 42 deleted"}, nil)
+
+	// The session resumes with the result of the action
+	ran := make(chan struct{})
+	test.store.EXPECT().UpdateSession(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, updated types.Session) (*types.Session, error) {
+		defer close(ran)
+		require.True(t, updated.Interactions[1].Finished)
+		require.Equal(t, "Order 42 deleted", updated.Interactions[1].Message)
+		return &updated, nil
+	})
+
+	approval, err := test.controller.DecideToolApproval(context.Background(), &types.User{ID: "approver_1"}, "tap_1", &types.ToolApprovalDecision{
+		Approved: true,
+		Reason:   "Customer asked for it",
+	})
+	require.NoError(t, err)
+	require.Equal(t, types.ToolApprovalStatusApproved, approval.Status)
+	require.Equal(t, "approver_1", approval.DecidedBy)
+	require.NotNil(t, approval.DecidedAt)
+	require.Equal(t, "Customer asked for it", approval.Reason)
+
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fatal("approved action did not run")
+	}
+}
+
+func Test_DecideToolApproval_Deny(t *testing.T) {
+	test := newToolApprovalTest(t)
+	session := actionSession("deleteOrder", map[string]string{"tool_approval_id": "tap_1"})
+
+	test.store.EXPECT().GetToolApproval(gomock.Any(), "tap_1").Return(pendingApproval(), nil)
+	test.store.EXPECT().UpdateToolApproval(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, approval *types.ToolApproval) (*types.ToolApproval, error) {
+		return approval, nil
+	})
+	test.store.EXPECT().GetSession(gomock.Any(), "ses_1").Return(session, nil)
+	test.store.EXPECT().UpdateSession(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, updated types.Session) (*types.Session, error) {
+		interaction := updated.Interactions[1]
+		require.True(t, interaction.Finished)
+		require.Equal(t, "The action deleteOrder of the orders tool was denied. Reason: Wrong order", interaction.Message)
+		return &updated, nil
+	})
+
+	approval, err := test.controller.DecideToolApproval(context.Background(), &types.User{ID: "user_1"}, "tap_1", &types.ToolApprovalDecision{
+		Reason: "Wrong order",
+	})
+	require.NoError(t, err)
+	require.Equal(t, types.ToolApprovalStatusDenied, approval.Status)
+}
+
+func Test_DecideToolApproval_Errors(t *testing.T) {
+	decided := pendingApproval()
+	decided.Status = types.ToolApprovalStatusApproved
+
+	expired := pendingApproval()
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name     string
+		user     *types.User
+		approval *types.ToolApproval
+		err      error
+	}{
+		{name: "not an approver", user: &types.User{ID: "other_user"}, approval: pendingApproval(), err: ErrToolApprovalForbidden},
+		{name: "already decided", user: &types.User{ID: "user_1"}, approval: decided, err: ErrToolApprovalDecided},
+		{name: "expired", user: &types.User{ID: "user_1"}, approval: expired, err: ErrToolApprovalExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test := newToolApprovalTest(t)
+
+			test.store.EXPECT().GetToolApproval(gomock.Any(), "tap_1").Return(tt.approval, nil).AnyTimes()
+			test.store.EXPECT().UpdateToolApproval(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, approval *types.ToolApproval) (*types.ToolApproval, error) {
+				require.Equal(t, types.ToolApprovalStatusExpired, approval.Status)
+				return approval, nil
+			}).AnyTimes()
+			test.store.EXPECT().GetSession(gomock.Any(), "ses_1").Return(nil, store.ErrNotFound).AnyTimes()
+
+			_, err := test.controller.DecideToolApproval(context.Background(), tt.user, "tap_1", &types.ToolApprovalDecision{Approved: true})
+			require.True(t, errors.Is(err, tt.err), err)
+		})
+	}
+}
+
+func Test_DecideToolApproval_Concurrent(t *testing.T) {
+	test := newToolApprovalTest(t)
+
+	// Both decisions read the approval as pending, the store only lets the
+	// first one through
+	test.store.EXPECT().GetToolApproval(gomock.Any(), "tap_1").Return(pendingApproval(), nil)
+	test.store.EXPECT().UpdateToolApproval(gomock.Any(), gomock.Any()).Return(nil, store.ErrToolApprovalNotPending)
+
+	_, err := test.controller.DecideToolApproval(context.Background(), &types.User{ID: "user_1"}, "tap_1", &types.ToolApprovalDecision{Approved: true})
+	require.ErrorIs(t, err, ErrToolApprovalDecided)
+}
+
+func Test_expireToolApprovals(t *testing.T) {
+	test := newToolApprovalTest(t)
+	session := actionSession("deleteOrder", map[string]string{"tool_approval_id": "tap_1"})
+
+	expired := pendingApproval()
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+
+	test.store.EXPECT().ListToolApprovals(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, q *store.ListToolApprovalsQuery) ([]*types.ToolApproval, error) {
+		require.Equal(t, types.ToolApprovalStatusPending, q.Status)
+		require.WithinDuration(t, time.Now(), q.ExpiresBefore, time.Minute)
+		return []*types.ToolApproval{expired}, nil
+	})
+	test.store.EXPECT().GetToolApproval(gomock.Any(), "tap_1").Return(expired, nil)
+	test.store.EXPECT().UpdateToolApproval(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, approval *types.ToolApproval) (*types.ToolApproval, error) {
+		require.Equal(t, types.ToolApprovalStatusExpired, approval.Status)
+		return approval, nil
+	})
+	test.store.EXPECT().GetSession(gomock.Any(), "ses_1").Return(session, nil)
+	test.store.EXPECT().UpdateSession(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, updated types.Session) (*types.Session, error) {
+		require.True(t, updated.Interactions[1].Finished)
+		require.Equal(t, "The action deleteOrder of the orders tool was not approved in time.", updated.Interactions[1].Message)
+		return &updated, nil
+	})
+
+	test.controller.expireToolApprovals(context.Background())
+}
+
+func approvedApproval() *types.ToolApproval {
+	decided := time.Now().Add(-time.Hour)
+
+	approval := pendingApproval()
+	approval.Status = types.ToolApprovalStatusApproved
+	approval.DecidedBy = "approver_1"
+	approval.DecidedAt = &decided
+	return approval
+}
+
+func Test_resumeToolApprovals(t *testing.T) {
+	test := newToolApprovalTest(t)
+	approved := approvedApproval()
+
+	test.store.EXPECT().ListToolApprovals(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, q *store.ListToolApprovalsQuery) ([]*types.ToolApproval, error) {
+		require.Equal(t, types.ToolApprovalStatusApproved, q.Status)
+		require.WithinDuration(t, time.Now().Add(-toolApprovalResumeWindow), q.DecidedAfter, time.Minute)
+		require.WithinDuration(t, time.Now().Add(-toolApprovalResumeLease), q.ResumedBefore, time.Minute)
+		return []*types.ToolApproval{approved}, nil
+	})
+	test.store.EXPECT().GetSession(gomock.Any(), "ses_1").Return(waitingSession(), nil)
+	test.store.EXPECT().ResumeToolApproval(gomock.Any(), "tap_1", gomock.Any()).Return(approved, nil)
+	test.store.EXPECT().GetTool(gomock.Any(), "tool_orders").Return(ordersTool(), nil)
+	test.store.EXPECT().GetToolApproval(gomock.Any(), "tap_1").Return(approved, nil)
+
+	test.planner.EXPECT().RunAction(gomock.Any(), "ses_1", "i-assistant", gomock.Any(), gomock.Any(), "deleteOrder", gomock.Any()).
+		Return(&tools.RunActionResponse{Message: "Order 42 deleted"}, nil)
+
+	test.store.EXPECT().UpdateSession(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, updated types.Session) (*types.Session, error) {
+		require.True(t, updated.Interactions[1].Finished)
+		require.Equal(t, "Order 42 deleted", updated.Interactions[1].Message)
+		return &updated, nil
+	})
+
+	test.controller.resumeToolApprovals(context.Background())
+}
+
+func Test_resumeToolApproval_Skipped(t *testing.T) {
+	t.Run("interaction moved on", func(t *testing.T) {
+		test := newToolApprovalTest(t)
+
+		session := waitingSession()
+		session.Interactions[1].State = types.InteractionStateComplete
+		test.store.EXPECT().GetSession(gomock.Any(), "ses_1").Return(session, nil)
+
+		require.NoError(t, test.controller.resumeToolApproval(context.Background(), approvedApproval()))
+	})
+
+	t.Run("already resumed", func(t *testing.T) {
+		test := newToolApprovalTest(t)
+
+		test.store.EXPECT().GetSession(gomock.Any(), "ses_1").Return(waitingSession(), nil)
+		test.store.EXPECT().ResumeToolApproval(gomock.Any(), "tap_1", gomock.Any()).Return(nil, store.ErrToolApprovalResumed)
+
+		require.NoError(t, test.controller.resumeToolApproval(context.Background(), approvedApproval()))
+	})
+}
@@ -11,8 +11,8 @@ import (
 	"time"
 
 	"github.com/helixml/helix/api/pkg/openai"
-	"github.com/helixml/helix/api/pkg/tools"
 	"github.com/helixml/helix/api/pkg/types"
+	"github.com/helixml/helix/api/pkg/util/jsonutil"
 
 	"github.com/rs/zerolog/log"
 	ext_openai "github.com/sashabaranov/go-openai"
@@ -322,6 +322,7 @@ func chatWithModel(client openai.Client, ownerID, sessionID, model, system, user
 		SessionID:     sessionID,
 		InteractionID: "n/a",
 	})
+	ctx = openai.SetContextPriorityClass(ctx, types.WorkloadPriorityClassFinetune)
 
 	resp, err := client.CreateChatCompletion(ctx, req)
 	if err != nil {
@@ -333,7 +334,7 @@ func chatWithModel(client openai.Client, ownerID, sessionID, model, system, user
 	log.Printf("XXX Raw response (%s) to %s json=%t: %s\n", resp.ID, debug, jsonSchema != nil, answer)
 
 	if jsonSchema == nil {
-		answer = tools.AttemptFixJSON(answer)
+		answer = jsonutil.AttemptFixJSON(answer)
 	}
 
 	return TryVariousJSONFormats(answer, fmt.Sprintf("%s respID=%s", debug, resp.ID))
@@ -30,7 +30,7 @@ func CloneOrUpdateRepo(
 			_, err = git.PlainClone(repoPath, false, &git.CloneOptions{
 				URL:      fmt.Sprintf("git@github.com:%s.git", repo),
 				Progress: os.Stdout,
-				Auth:     makeAuth(keypair),
+				Auth:     MakeAuth(keypair),
 			})
 			if err != nil {
 				return fmt.Errorf("failed to clone repo: %v", err)
@@ -52,7 +52,7 @@ func CloneOrUpdateRepo(
 
 	err = worktree.Pull(&git.PullOptions{
 		RemoteName: "origin",
-		Auth:       makeAuth(keypair),
+		Auth:       MakeAuth(keypair),
 	})
 
 	if err != nil && err != git.NoErrAlreadyUpToDate {
@@ -105,7 +105,8 @@ func GetRepoHash(
 	return commit.Hash.String(), nil
 }
 
-func makeAuth(keypair types.KeyPair) transport.AuthMethod {
+// MakeAuth returns the SSH auth for cloning with a deploy key
+func MakeAuth(keypair types.KeyPair) transport.AuthMethod {
 	signer, err := ssh.NewPublicKeys("git", []byte(keypair.PrivateKey), keypair.PublicKey)
 	if err != nil {
 		fmt.Println("Failed to create signer:", err)
@@ -0,0 +1,23 @@
+package mcpserver
+
+import (
+	"context"
+
+	"github.com/helixml/helix/api/pkg/client"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+//go:generate mockgen -source $GOFILE -destination backend_mocks.go -package $GOPACKAGE
+
+// Backend runs the tools and searches the knowledge of the apps. The CLI
+// proxies them to the API with the Helix client, the API server runs them
+// in process.
+type Backend interface {
+	ListKnowledge(ctx context.Context, f *client.KnowledgeFilter) ([]*types.Knowledge, error)
+	GetKnowledge(ctx context.Context, id string) (*types.Knowledge, error)
+	SearchKnowledge(ctx context.Context, f *client.KnowledgeSearchQuery) ([]*types.KnowledgeSearchResult, error)
+	RunAPIAction(ctx context.Context, appID string, req *types.RunAPIActionRequest) (*types.RunAPIActionResponse, error)
+}
+
+// Static check
+var _ Backend = client.Client(nil)
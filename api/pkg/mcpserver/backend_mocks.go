@@ -0,0 +1,103 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: backend.go
+//
+// Generated by this command:
+//
+//	mockgen -source backend.go -destination backend_mocks.go -package mcpserver
+//
+
+// Package mcpserver is a generated GoMock package.
+package mcpserver
+
+import (
+	context "context"
+	reflect "reflect"
+
+	client "github.com/helixml/helix/api/pkg/client"
+	types "github.com/helixml/helix/api/pkg/types"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockBackend is a mock of Backend interface.
+type MockBackend struct {
+	ctrl     *gomock.Controller
+	recorder *MockBackendMockRecorder
+	isgomock struct{}
+}
+
+// MockBackendMockRecorder is the mock recorder for MockBackend.
+type MockBackendMockRecorder struct {
+	mock *MockBackend
+}
+
+// NewMockBackend creates a new mock instance.
+func NewMockBackend(ctrl *gomock.Controller) *MockBackend {
+	mock := &MockBackend{ctrl: ctrl}
+	mock.recorder = &MockBackendMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBackend) EXPECT() *MockBackendMockRecorder {
+	return m.recorder
+}
+
+// GetKnowledge mocks base method.
+func (m *MockBackend) GetKnowledge(ctx context.Context, id string) (*types.Knowledge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKnowledge", ctx, id)
+	ret0, _ := ret[0].(*types.Knowledge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKnowledge indicates an expected call of GetKnowledge.
+func (mr *MockBackendMockRecorder) GetKnowledge(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKnowledge", reflect.TypeOf((*MockBackend)(nil).GetKnowledge), ctx, id)
+}
+
+// ListKnowledge mocks base method.
+func (m *MockBackend) ListKnowledge(ctx context.Context, f *client.KnowledgeFilter) ([]*types.Knowledge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListKnowledge", ctx, f)
+	ret0, _ := ret[0].([]*types.Knowledge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListKnowledge indicates an expected call of ListKnowledge.
+func (mr *MockBackendMockRecorder) ListKnowledge(ctx, f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListKnowledge", reflect.TypeOf((*MockBackend)(nil).ListKnowledge), ctx, f)
+}
+
+// RunAPIAction mocks base method.
+func (m *MockBackend) RunAPIAction(ctx context.Context, appID string, req *types.RunAPIActionRequest) (*types.RunAPIActionResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunAPIAction", ctx, appID, req)
+	ret0, _ := ret[0].(*types.RunAPIActionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunAPIAction indicates an expected call of RunAPIAction.
+func (mr *MockBackendMockRecorder) RunAPIAction(ctx, appID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunAPIAction", reflect.TypeOf((*MockBackend)(nil).RunAPIAction), ctx, appID, req)
+}
+
+// SearchKnowledge mocks base method.
+func (m *MockBackend) SearchKnowledge(ctx context.Context, f *client.KnowledgeSearchQuery) ([]*types.KnowledgeSearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchKnowledge", ctx, f)
+	ret0, _ := ret[0].([]*types.KnowledgeSearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchKnowledge indicates an expected call of SearchKnowledge.
+func (mr *MockBackendMockRecorder) SearchKnowledge(ctx, f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchKnowledge", reflect.TypeOf((*MockBackend)(nil).SearchKnowledge), ctx, f)
+}
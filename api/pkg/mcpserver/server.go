@@ -0,0 +1,320 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/client"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/tools"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+const (
+	serverName    = "Helix ML"
+	serverVersion = "1.0.0"
+
+	// maxToolNameLength is the longest tool name MCP clients accept
+	maxToolNameLength = 64
+
+	knowledgeURIPrefix = "helix://knowledge/"
+)
+
+var invalidToolNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// NewServer returns the MCP server for the app. It exposes the API tools,
+// GPTScripts and Zapier integrations of all the assistants and a search tool
+// and a resource for each knowledge of the app.
+func NewServer(ctx context.Context, backend Backend, app *types.App) (*server.MCPServer, error) {
+	s := server.NewMCPServer(
+		serverName,
+		serverVersion,
+		server.WithResourceCapabilities(false, false),
+		server.WithLogging(),
+	)
+
+	names := toolNames{}
+
+	for i := range app.Config.Helix.Assistants {
+		assistant := &app.Config.Helix.Assistants[i]
+		// Assistants without IDs are selected by their index
+		assistantID := assistant.ID
+		if assistantID == "" {
+			assistantID = fmt.Sprint(i)
+		}
+
+		for _, t := range assistantTools(names, backend, app.ID, assistantID, assistant) {
+			s.AddTool(t.tool, t.handler)
+		}
+	}
+
+	knowledges, err := backend.ListKnowledge(ctx, &client.KnowledgeFilter{
+		AppID: app.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list knowledge: %w", err)
+	}
+
+	for _, knowledge := range knowledges {
+		t := knowledgeTool(names, backend, app.ID, knowledge)
+		s.AddTool(t.tool, t.handler)
+
+		s.AddResource(
+			mcp.NewResource(knowledgeURIPrefix+knowledge.ID, knowledge.Name,
+				mcp.WithResourceDescription(knowledge.Description),
+				mcp.WithMIMEType("application/json"),
+			),
+			knowledgeResourceHandler(backend, knowledge.ID),
+		)
+	}
+
+	return s, nil
+}
+
+type helixMCPTool struct {
+	tool    mcp.Tool
+	handler server.ToolHandlerFunc
+}
+
+func assistantTools(names toolNames, backend Backend, appID, assistantID string, assistant *types.AssistantConfig) []*helixMCPTool {
+	var mcpTools []*helixMCPTool
+
+	for _, api := range assistant.APIs {
+		tool, err := store.ConvertAPIToTool(api)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("tool", api.Name).
+				Msg("failed to convert api tool to mcp tool")
+			continue
+		}
+
+		// Each API tool has a list of actions, adding them separately
+		for _, action := range tool.Config.API.Actions {
+			parameters, err := tools.GetParametersFromSchema(tool.Config.API.Schema, action.Name)
+			if err != nil {
+				log.Error().
+					Err(err).
+					Str("tool", tool.Name).
+					Str("action", action.Name).
+					Msg("failed to get parameters from schema")
+				continue
+			}
+
+			options := []mcp.ToolOption{mcp.WithDescription(action.Description)}
+			for _, param := range parameters {
+				options = append(options, withParameter(param))
+			}
+
+			mcpTools = append(mcpTools, &helixMCPTool{
+				tool:    mcp.NewTool(names.add(assistant.Name, action.Name), options...),
+				handler: actionHandler(backend, appID, assistantID, action.Name, nil),
+			})
+		}
+	}
+
+	for _, script := range assistant.GPTScripts {
+		mcpTools = append(mcpTools, &helixMCPTool{
+			tool: mcp.NewTool(names.add(assistant.Name, script.Name),
+				mcp.WithDescription(script.Description),
+				mcp.WithString(tools.GPTScriptInputParameter,
+					mcp.Description("Input passed to the script"),
+				),
+			),
+			handler: actionHandler(backend, appID, assistantID, script.Name, []string{tools.GPTScriptInputParameter}),
+		})
+	}
+
+	for _, zapier := range assistant.Zapier {
+		mcpTools = append(mcpTools, &helixMCPTool{
+			tool: mcp.NewTool(names.add(assistant.Name, zapier.Name),
+				mcp.WithDescription(zapier.Description),
+				mcp.WithString(tools.ZapierPromptParameter,
+					mcp.Required(),
+					mcp.Description("Instructions for the Zapier actions to run"),
+				),
+			),
+			handler: actionHandler(backend, appID, assistantID, zapier.Name, []string{tools.ZapierPromptParameter}),
+		})
+	}
+
+	return mcpTools
+}
+
+// withParameter adds the parameter with its JSON schema so clients send the
+// values with their types
+func withParameter(param *tools.Parameter) mcp.ToolOption {
+	return func(t *mcp.Tool) {
+		property := map[string]any{}
+		for k, v := range param.Schema {
+			property[k] = v
+		}
+		if _, ok := property["type"]; !ok {
+			property["type"] = string(param.Type)
+		}
+		if param.Description != "" {
+			property["description"] = param.Description
+		}
+
+		t.InputSchema.Properties[param.Name] = property
+		if param.Required {
+			t.InputSchema.Required = append(t.InputSchema.Required, param.Name)
+		}
+	}
+}
+
+// actionHandler runs the action of the assistant. Only the listed arguments
+// are passed when set, API actions get all of them.
+func actionHandler(backend Backend, appID, assistantID, action string, arguments []string) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		log.Info().
+			Str("app_id", appID).
+			Str("assistant_id", assistantID).
+			Str("action", action).
+			Msg("running mcp tool")
+
+		parameters := request.Params.Arguments
+		if arguments != nil {
+			parameters = map[string]any{}
+			for _, name := range arguments {
+				if value, ok := request.Params.Arguments[name]; ok {
+					parameters[name] = value
+				}
+			}
+		}
+
+		// The values keep their types, they are checked against the schema
+		// of the action by the server
+		resp, err := backend.RunAPIAction(ctx, appID, &types.RunAPIActionRequest{
+			AssistantID: assistantID,
+			Action:      action,
+			Parameters:  parameters,
+		})
+		if err != nil {
+			log.Error().Err(err).Str("action", action).Msg("failed to run action")
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		if resp.Error != "" {
+			return mcp.NewToolResultError(resp.Error), nil
+		}
+
+		return mcp.NewToolResultText(resp.Response), nil
+	}
+}
+
+func knowledgeTool(names toolNames, backend Backend, appID string, knowledge *types.Knowledge) *helixMCPTool {
+	return &helixMCPTool{
+		tool: mcp.NewTool(names.add("knowledge", knowledge.Name),
+			mcp.WithDescription(fmt.Sprintf("Knowledge tool to search for: '%s'. Returns fragments from the database", knowledge.Description)),
+			mcp.WithString("prompt",
+				mcp.Required(),
+				mcp.Description("The prompt to search knowledge with, use concise, main keywords as the engine is performing both semantic and full text search"),
+			),
+		),
+		handler: knowledgeToolHandler(backend, appID, knowledge.ID),
+	}
+}
+
+func knowledgeToolHandler(backend Backend, appID, knowledgeID string) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		prompt, ok := request.Params.Arguments["prompt"]
+		if !ok {
+			return mcp.NewToolResultError("prompt is required"), nil
+		}
+
+		promptStr, ok := prompt.(string)
+		if !ok {
+			return mcp.NewToolResultError("prompt must be a string"), nil
+		}
+
+		results, err := backend.SearchKnowledge(ctx, &client.KnowledgeSearchQuery{
+			AppID:       appID,
+			KnowledgeID: knowledgeID,
+			Prompt:      promptStr,
+		})
+		if err != nil {
+			log.Error().Err(err).Msg("failed to search knowledge")
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		resultsJSON, err := json.Marshal(results)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to marshal knowledge search results")
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		return mcp.NewToolResultText(string(resultsJSON)), nil
+	}
+}
+
+// knowledgeResourceHandler returns the knowledge with its sources and state,
+// read when requested so clients see the latest version
+func knowledgeResourceHandler(backend Backend, knowledgeID string) server.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error) {
+		knowledge, err := backend.GetKnowledge(ctx, knowledgeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get knowledge %s: %w", knowledgeID, err)
+		}
+
+		knowledgeJSON, err := json.Marshal(knowledge)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal knowledge %s: %w", knowledgeID, err)
+		}
+
+		return []interface{}{
+			mcp.TextResourceContents{
+				ResourceContents: mcp.ResourceContents{
+					URI:      request.Params.URI,
+					MIMEType: "application/json",
+				},
+				Text: string(knowledgeJSON),
+			},
+		}, nil
+	}
+}
+
+// toolNames keeps the tool names unique across the assistants, clients only
+// accept letters, digits, underscores and dashes
+type toolNames map[string]bool
+
+func (n toolNames) add(prefix, name string) string {
+	candidates := []string{name, prefix + "_" + name}
+
+	for _, candidate := range candidates {
+		candidate = sanitizeToolName(candidate)
+		if candidate != "" && !n[candidate] {
+			n[candidate] = true
+			return candidate
+		}
+	}
+
+	base := sanitizeToolName(candidates[len(candidates)-1])
+	for i := 2; ; i++ {
+		suffix := fmt.Sprintf("_%d", i)
+		candidate := base
+		if len(candidate)+len(suffix) > maxToolNameLength {
+			candidate = candidate[:maxToolNameLength-len(suffix)]
+		}
+		candidate += suffix
+		if !n[candidate] {
+			n[candidate] = true
+			return candidate
+		}
+	}
+}
+
+func sanitizeToolName(name string) string {
+	name = strings.Trim(invalidToolNameChars.ReplaceAllString(name, "_"), "_")
+	if len(name) > maxToolNameLength {
+		name = name[:maxToolNameLength]
+	}
+	return name
+}
@@ -0,0 +1,316 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/client"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+const petsAPISpec = `openapi: "3.0.0"
+info:
+  version: 1.0.0
+  title: Pets
+paths:
+  /pets:
+    get:
+      summary: List all pets
+      operationId: listPets
+      parameters:
+        - name: limit
+          in: query
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: A list of pets
+`
+
+func TestServerSuite(t *testing.T) {
+	suite.Run(t, new(ServerSuite))
+}
+
+type ServerSuite struct {
+	suite.Suite
+
+	ctx     context.Context
+	backend *MockBackend
+	app     *types.App
+}
+
+func (suite *ServerSuite) SetupTest() {
+	ctrl := gomock.NewController(suite.T())
+
+	suite.ctx = context.Background()
+	suite.backend = NewMockBackend(ctrl)
+	suite.app = &types.App{
+		ID: "app_1",
+		Config: types.AppConfig{
+			Helix: types.AppHelixConfig{
+				Assistants: []types.AssistantConfig{
+					{
+						Name: "Pets",
+						APIs: []types.AssistantAPI{
+							{
+								Name:        "pets",
+								Description: "Pet store",
+								Schema:      petsAPISpec,
+								URL:         "http://pets.local",
+							},
+						},
+						GPTScripts: []types.AssistantGPTScript{
+							{
+								Name:        "summarize",
+								Description: "Summarizes the input",
+								Content:     "echo hi",
+							},
+						},
+					},
+					{
+						ID:   "assistant_zapier",
+						Name: "Zapier helper",
+						GPTScripts: []types.AssistantGPTScript{
+							{
+								Name:        "summarize",
+								Description: "Summarizes the input differently",
+								Content:     "echo hello",
+							},
+						},
+						Zapier: []types.AssistantZapier{
+							{
+								Name:        "send email",
+								Description: "Sends emails",
+								APIKey:      "key",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (suite *ServerSuite) newServer(knowledges ...*types.Knowledge) *rpcClient {
+	suite.backend.EXPECT().ListKnowledge(suite.ctx, &client.KnowledgeFilter{AppID: "app_1"}).Return(knowledges, nil)
+
+	s, err := NewServer(suite.ctx, suite.backend, suite.app)
+	suite.Require().NoError(err)
+
+	return &rpcClient{t: suite.T(), ctx: suite.ctx, handle: s.HandleMessage}
+}
+
+func (suite *ServerSuite) TestListTools() {
+	c := suite.newServer(&types.Knowledge{ID: "kno_1", Name: "docs", Description: "Product docs"})
+
+	var result struct {
+		Tools []struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			InputSchema struct {
+				Properties map[string]map[string]any `json:"properties"`
+				Required   []string                  `json:"required"`
+			} `json:"inputSchema"`
+		} `json:"tools"`
+	}
+	c.call("tools/list", nil, &result)
+
+	schemas := map[string][]string{}
+	for _, tool := range result.Tools {
+		schemas[tool.Name] = tool.InputSchema.Required
+	}
+
+	suite.Equal(map[string][]string{
+		"listPets":                {"limit"},
+		"summarize":               nil,
+		"Zapier_helper_summarize": nil,
+		"send_email":              {"prompt"},
+		"docs":                    {"prompt"},
+	}, schemas)
+
+	for _, tool := range result.Tools {
+		if tool.Name == "listPets" {
+			suite.Equal("integer", tool.InputSchema.Properties["limit"]["type"])
+		}
+	}
+}
+
+func (suite *ServerSuite) TestCallAPIAction() {
+	c := suite.newServer()
+
+	suite.backend.EXPECT().RunAPIAction(gomock.Any(), "app_1", &types.RunAPIActionRequest{
+		AssistantID: "0",
+		Action:      "listPets",
+		Parameters:  map[string]any{"limit": float64(2)},
+	}).Return(&types.RunAPIActionResponse{Response: `[{"name":"Rex"}]`}, nil)
+
+	var result callToolResult
+	c.call("tools/call", map[string]any{
+		"name":      "listPets",
+		"arguments": map[string]any{"limit": 2},
+	}, &result)
+
+	suite.False(result.IsError)
+	suite.Equal(`[{"name":"Rex"}]`, result.text())
+}
+
+func (suite *ServerSuite) TestCallGPTScriptOfSecondAssistant() {
+	c := suite.newServer()
+
+	suite.backend.EXPECT().RunAPIAction(gomock.Any(), "app_1", &types.RunAPIActionRequest{
+		AssistantID: "assistant_zapier",
+		Action:      "summarize",
+		Parameters:  map[string]any{"input": "long text"},
+	}).Return(&types.RunAPIActionResponse{Response: "short"}, nil)
+
+	var result callToolResult
+	c.call("tools/call", map[string]any{
+		"name":      "Zapier_helper_summarize",
+		"arguments": map[string]any{"input": "long text", "ignored": true},
+	}, &result)
+
+	suite.False(result.IsError)
+	suite.Equal("short", result.text())
+}
+
+func (suite *ServerSuite) TestCallActionError() {
+	c := suite.newServer()
+
+	suite.backend.EXPECT().RunAPIAction(gomock.Any(), "app_1", gomock.Any()).
+		Return(&types.RunAPIActionResponse{Error: "action denied"}, nil)
+
+	var result callToolResult
+	c.call("tools/call", map[string]any{
+		"name":      "send_email",
+		"arguments": map[string]any{"prompt": "email bob"},
+	}, &result)
+
+	suite.True(result.IsError)
+	suite.Equal("action denied", result.text())
+}
+
+func (suite *ServerSuite) TestSearchKnowledge() {
+	c := suite.newServer(&types.Knowledge{ID: "kno_1", Name: "docs"})
+
+	suite.backend.EXPECT().SearchKnowledge(gomock.Any(), &client.KnowledgeSearchQuery{
+		AppID:       "app_1",
+		KnowledgeID: "kno_1",
+		Prompt:      "pricing",
+	}).Return([]*types.KnowledgeSearchResult{
+		{Knowledge: &types.Knowledge{ID: "kno_1"}},
+	}, nil)
+
+	var result callToolResult
+	c.call("tools/call", map[string]any{
+		"name":      "docs",
+		"arguments": map[string]any{"prompt": "pricing"},
+	}, &result)
+
+	suite.False(result.IsError)
+	suite.Contains(result.text(), `"kno_1"`)
+}
+
+func (suite *ServerSuite) TestReadKnowledgeResource() {
+	c := suite.newServer(&types.Knowledge{ID: "kno_1", Name: "docs"})
+
+	var list struct {
+		Resources []struct {
+			URI  string `json:"uri"`
+			Name string `json:"name"`
+		} `json:"resources"`
+	}
+	c.call("resources/list", nil, &list)
+	suite.Require().Len(list.Resources, 1)
+	suite.Equal("helix://knowledge/kno_1", list.Resources[0].URI)
+	suite.Equal("docs", list.Resources[0].Name)
+
+	suite.backend.EXPECT().GetKnowledge(gomock.Any(), "kno_1").
+		Return(&types.Knowledge{ID: "kno_1", Name: "docs", State: types.KnowledgeStateReady}, nil)
+
+	var read struct {
+		Contents []struct {
+			URI  string `json:"uri"`
+			Text string `json:"text"`
+		} `json:"contents"`
+	}
+	c.call("resources/read", map[string]any{"uri": "helix://knowledge/kno_1"}, &read)
+	suite.Require().Len(read.Contents, 1)
+
+	var knowledge types.Knowledge
+	suite.Require().NoError(json.Unmarshal([]byte(read.Contents[0].Text), &knowledge))
+	suite.Equal(types.KnowledgeStateReady, knowledge.State)
+}
+
+func Test_toolNames(t *testing.T) {
+	names := toolNames{}
+
+	require.Equal(t, "list_orders", names.add("Shop", "list orders"))
+	require.Equal(t, "Shop_list_orders", names.add("Shop", "list orders"))
+	require.Equal(t, "Shop_list_orders_2", names.add("Shop", "list orders"))
+	require.Equal(t, "Shop_list_orders_3", names.add("Shop", "list/orders"))
+
+	long := strings.Repeat("a", 70)
+	require.Equal(t, long[:maxToolNameLength], names.add("assistant", long))
+	require.Equal(t, "assistant_"+long[:maxToolNameLength-10], names.add("assistant", long))
+	require.Equal(t, "assistant_"+long[:maxToolNameLength-12]+"_2", names.add("assistant", long))
+}
+
+// rpcClient sends JSON-RPC requests to the MCP server in process
+type rpcClient struct {
+	t      *testing.T
+	ctx    context.Context
+	handle func(ctx context.Context, message json.RawMessage) mcp.JSONRPCMessage
+	id     int
+}
+
+func (c *rpcClient) call(method string, params any, result any) {
+	c.id++
+
+	request := map[string]any{
+		"jsonrpc": "2.0",
+		"id":      c.id,
+		"method":  method,
+	}
+	if params != nil {
+		request["params"] = params
+	}
+
+	message, err := json.Marshal(request)
+	require.NoError(c.t, err)
+
+	data, err := json.Marshal(c.handle(c.ctx, message))
+	require.NoError(c.t, err)
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	require.NoError(c.t, json.Unmarshal(data, &response))
+	require.Nil(c.t, response.Error, "unexpected error for %s: %s", method, data)
+	require.NoError(c.t, json.Unmarshal(response.Result, result))
+}
+
+type callToolResult struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	IsError bool `json:"isError"`
+}
+
+func (r *callToolResult) text() string {
+	if len(r.Content) == 0 {
+		return ""
+	}
+	return r.Content[0].Text
+}
@@ -0,0 +1,190 @@
+package mcpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/system"
+)
+
+// sseKeepAliveInterval keeps idle streams open through proxies
+const sseKeepAliveInterval = 30 * time.Second
+
+// SSEServer serves MCP servers with the HTTP with SSE transport: clients
+// keep an event stream open for the responses and post their messages.
+// Unlike the SSE server of mcp-go it is mounted on the router of the API
+// server and each stream gets the MCP server of its user and app.
+type SSEServer struct {
+	messageURL string
+	sessions   sync.Map
+}
+
+type sseSession struct {
+	owner     string
+	mcpServer *server.MCPServer
+
+	mu      sync.Mutex
+	writer  http.ResponseWriter
+	flusher http.Flusher
+	done    chan struct{}
+}
+
+// NewSSEServer returns the SSE server, clients post their messages to the
+// message URL
+func NewSSEServer(messageURL string) *SSEServer {
+	return &SSEServer{
+		messageURL: messageURL,
+	}
+}
+
+// ServeSSE streams the responses of the MCP server to the client until it
+// disconnects. The first event is the endpoint for the messages.
+func (s *SSEServer) ServeSSE(w http.ResponseWriter, r *http.Request, owner string, mcpServer *server.MCPServer) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// The stream outlives the write timeout of the server
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		log.Debug().Err(err).Msg("failed to clear write deadline of mcp event stream")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	sessionID := system.GenerateUUID()
+	session := &sseSession{
+		owner:     owner,
+		mcpServer: mcpServer,
+		writer:    w,
+		flusher:   flusher,
+		done:      make(chan struct{}),
+	}
+
+	s.sessions.Store(sessionID, session)
+	defer s.sessions.Delete(sessionID)
+	defer session.close()
+
+	if err := session.write(fmt.Sprintf("event: endpoint\ndata: %s?sessionId=%s\n\n", s.messageURL, sessionID)); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(sseKeepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if err := session.write(": ping\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// ServeMessage handles a message the client posts for its session. The
+// response is sent on the event stream and in the response body.
+func (s *SSEServer) ServeMessage(w http.ResponseWriter, r *http.Request, owner string) {
+	value, ok := s.sessions.Load(r.URL.Query().Get("sessionId"))
+	if !ok {
+		writeJSONRPCError(w, http.StatusNotFound, mcp.INVALID_PARAMS, "Invalid session ID")
+		return
+	}
+	session := value.(*sseSession)
+
+	// Sessions are only reachable by their owner
+	if session.owner != owner {
+		writeJSONRPCError(w, http.StatusNotFound, mcp.INVALID_PARAMS, "Invalid session ID")
+		return
+	}
+
+	var message json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+		writeJSONRPCError(w, http.StatusBadRequest, mcp.PARSE_ERROR, "Parse error")
+		return
+	}
+
+	response := session.mcpServer.HandleMessage(r.Context(), message)
+	if response == nil {
+		// Notifications have no response
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		writeJSONRPCError(w, http.StatusInternalServerError, mcp.INTERNAL_ERROR, err.Error())
+		return
+	}
+
+	if err := session.write(fmt.Sprintf("event: message\ndata: %s\n\n", data)); err != nil {
+		log.Warn().Err(err).Msg("failed to send mcp response to the event stream")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = w.Write(data)
+}
+
+// ServeHTTP handles a message posted without an event stream, the response
+// is returned in the body
+func ServeHTTP(w http.ResponseWriter, r *http.Request, mcpServer *server.MCPServer) {
+	var message json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+		writeJSONRPCError(w, http.StatusBadRequest, mcp.PARSE_ERROR, "Parse error")
+		return
+	}
+
+	response := mcpServer.HandleMessage(r.Context(), message)
+	if response == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Warn().Err(err).Msg("failed to write mcp response")
+	}
+}
+
+func (s *sseSession) write(event string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return fmt.Errorf("session closed")
+	default:
+	}
+
+	if _, err := fmt.Fprint(s.writer, event); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// close stops the writes once the handler of the stream returns
+func (s *sseSession) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	close(s.done)
+}
+
+func writeJSONRPCError(w http.ResponseWriter, status, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(mcp.NewJSONRPCError(nil, code, message, nil))
+}
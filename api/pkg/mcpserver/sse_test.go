@@ -0,0 +1,116 @@
+package mcpserver
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func Test_SSEServer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	backend := NewMockBackend(ctrl)
+	backend.EXPECT().ListKnowledge(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+	mcpServer, err := NewServer(context.Background(), backend, &types.App{ID: "app_1"})
+	require.NoError(t, err)
+
+	sseServer := NewSSEServer("/message")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
+		sseServer.ServeSSE(w, r, r.Header.Get("X-Owner"), mcpServer)
+	})
+	mux.HandleFunc("/message", func(w http.ResponseWriter, r *http.Request) {
+		sseServer.ServeMessage(w, r, r.Header.Get("X-Owner"))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/sse", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Owner", "user_1")
+
+	stream, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer stream.Body.Close()
+	require.Equal(t, "text/event-stream", stream.Header.Get("Content-Type"))
+
+	events := bufio.NewReader(stream.Body)
+	readEvent := func() (string, string) {
+		var event, data string
+		for {
+			line, err := events.ReadString('\n')
+			require.NoError(t, err)
+			line = strings.TrimSuffix(line, "\n")
+			switch {
+			case line == "":
+				return event, data
+			case strings.HasPrefix(line, "event: "):
+				event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}
+
+	event, endpoint := readEvent()
+	require.Equal(t, "endpoint", event)
+	require.True(t, strings.HasPrefix(endpoint, "/message?sessionId="))
+
+	post := func(owner string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+endpoint,
+			strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+		require.NoError(t, err)
+		req.Header.Set("X-Owner", owner)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	t.Run("OtherOwner", func(t *testing.T) {
+		require.Equal(t, http.StatusNotFound, post("user_2").StatusCode)
+	})
+
+	t.Run("Message", func(t *testing.T) {
+		require.Equal(t, http.StatusAccepted, post("user_1").StatusCode)
+
+		event, data := readEvent()
+		require.Equal(t, "message", event)
+		require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"result":{}}`, data)
+	})
+}
+
+func Test_ServeHTTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	backend := NewMockBackend(ctrl)
+	backend.EXPECT().ListKnowledge(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+	mcpServer, err := NewServer(context.Background(), backend, &types.App{ID: "app_1"})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	ServeHTTP(rec, req, mcpServer)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"jsonrpc":"2.0","id":1,"result":{}}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{`))
+	ServeHTTP(rec, req, mcpServer)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -62,6 +62,15 @@ func (m Name) InferenceRuntime() types.InferenceRuntime {
 			return types.InferenceRuntimeDiffusers
 		}
 	}
+	vllmModels, err := GetDefaultVLLMModels()
+	if err != nil {
+		return types.InferenceRuntimeAxolotl
+	}
+	for _, model := range vllmModels {
+		if m.String() == model.ID {
+			return types.InferenceRuntimeVLLM
+		}
+	}
 
 	// misnamed: axolotl runtime handles axolotl and cog/sd-scripts
 	return types.InferenceRuntimeAxolotl
@@ -156,6 +165,13 @@ func GetModels() (map[string]Model, error) {
 	for _, model := range diffusersModels {
 		models[model.ID] = model
 	}
+	vllmModels, err := GetDefaultVLLMModels()
+	if err != nil {
+		return nil, err
+	}
+	for _, model := range vllmModels {
+		models[model.ID] = model
+	}
 	return models, nil
 }
 
@@ -195,6 +211,32 @@ func GetDefaultDiffusersModels() ([]*DiffusersGenericImage, error) {
 	}, nil
 }
 
+// GetDefaultVLLMModels returns the HuggingFace models served by the vLLM
+// runtime. They are hidden from the model picker since the runner image only
+// ships vLLM when it's built with GPU support.
+func GetDefaultVLLMModels() ([]*VLLMGenericText, error) {
+	return []*VLLMGenericText{
+		{
+			ID:            "Qwen/Qwen2.5-7B-Instruct", // https://huggingface.co/Qwen/Qwen2.5-7B-Instruct
+			Name:          "Qwen 2.5 7B (vLLM)",
+			Memory:        GB * 24,
+			ContextLength: 32768,
+			Description:   "Fast and good for everyday tasks, from Alibaba - 16bit, 32K context, continuous batching",
+			Hide:          true,
+			Args:          []string{"--enable-prefix-caching"},
+		},
+		{
+			ID:            "meta-llama/Llama-3.1-8B-Instruct", // https://huggingface.co/meta-llama/Llama-3.1-8B-Instruct
+			Name:          "Llama 3.1 8B (vLLM)",
+			Memory:        GB * 24,
+			ContextLength: 32768,
+			Description:   "Fast and good for everyday tasks, from Meta - 16bit, 32K context, continuous batching",
+			Hide:          true,
+			Args:          []string{"--enable-prefix-caching"},
+		},
+	}, nil
+}
+
 // See also types/models.go for model name constants
 func GetDefaultOllamaModels() ([]*OllamaGenericText, error) {
 	models := []*OllamaGenericText{
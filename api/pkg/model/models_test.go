@@ -107,3 +107,58 @@ func TestProcessModelName(t *testing.T) {
 		})
 	}
 }
+
+func TestInferenceRuntime(t *testing.T) {
+	tests := map[string]types.InferenceRuntime{
+		"llama3.1:8b-instruct-q8_0":        types.InferenceRuntimeOllama,
+		ModelDiffusersFluxdev:              types.InferenceRuntimeDiffusers,
+		"Qwen/Qwen2.5-7B-Instruct":         types.InferenceRuntimeVLLM,
+		"meta-llama/Llama-3.1-8B-Instruct": types.InferenceRuntimeVLLM,
+		ModelAxolotlMistral7b:              types.InferenceRuntimeAxolotl,
+	}
+
+	for name, want := range tests {
+		if got := NewModel(name).InferenceRuntime(); got != want {
+			t.Errorf("InferenceRuntime(%s) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestVLLMGenericText_GetContextLength(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+		want int64
+	}{
+		"default":      {args: []string{"--enable-prefix-caching"}, want: 32768},
+		"separate":     {args: []string{"--max-model-len", "8192"}, want: 8192},
+		"equals":       {args: []string{"--max-model-len=16384"}, want: 16384},
+		"invalid":      {args: []string{"--max-model-len", "auto"}, want: 32768},
+		"missing-last": {args: []string{"--max-model-len"}, want: 32768},
+	}
+
+	for name, tt := range tests {
+		m := &VLLMGenericText{ContextLength: 32768, Args: tt.args}
+		if got := m.GetContextLength(); got != tt.want {
+			t.Errorf("%s: GetContextLength() = %d, want %d", name, got, tt.want)
+		}
+	}
+}
+
+func TestVLLMGenericText_GetTensorParallelSize(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+		want int
+	}{
+		"default":  {args: []string{"--enable-prefix-caching"}, want: 1},
+		"separate": {args: []string{"--tensor-parallel-size", "2"}, want: 2},
+		"equals":   {args: []string{"--tensor-parallel-size=4"}, want: 4},
+		"invalid":  {args: []string{"--tensor-parallel-size", "0"}, want: 1},
+	}
+
+	for name, tt := range tests {
+		m := &VLLMGenericText{Args: tt.args}
+		if got := m.GetTensorParallelSize(); got != tt.want {
+			t.Errorf("%s: GetTensorParallelSize() = %d, want %d", name, got, tt.want)
+		}
+	}
+}
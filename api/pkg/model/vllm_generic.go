@@ -0,0 +1,112 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+var _ Model = &VLLMGenericText{}
+
+// VLLMMaxModelLenArg is the vllm serve argument setting the context length
+const VLLMMaxModelLenArg = "--max-model-len"
+
+// VLLMTensorParallelSizeArg is the vllm serve argument setting the number of
+// GPUs the model is split across
+const VLLMTensorParallelSizeArg = "--tensor-parallel-size"
+
+// VLLMGenericText is a HuggingFace format text model served by vLLM, one
+// vLLM process serves a single model with continuous batching
+type VLLMGenericText struct {
+	ID            string // e.g. "Qwen/Qwen2.5-7B-Instruct"
+	Name          string // e.g. "Qwen 2.5 7B"
+	Memory        uint64 // vLLM preallocates this much for the weights and the KV cache
+	ContextLength int64
+	Description   string
+	Hide          bool
+	Args          []string // Extra arguments for vllm serve, e.g. --enable-prefix-caching
+}
+
+func (i *VLLMGenericText) GetMemoryRequirements(_ types.SessionMode) uint64 {
+	return i.Memory
+}
+
+// GetContextLength returns the context length vLLM serves the model with, the
+// --max-model-len of the arguments if they set it
+func (i *VLLMGenericText) GetContextLength() int64 {
+	if contextLength, ok := i.intArg(VLLMMaxModelLenArg); ok {
+		return contextLength
+	}
+	return i.ContextLength
+}
+
+// GetTensorParallelSize returns the number of GPUs vLLM splits the model
+// across, the --tensor-parallel-size of the arguments or 1
+func (i *VLLMGenericText) GetTensorParallelSize() int {
+	if size, ok := i.intArg(VLLMTensorParallelSizeArg); ok && size > 0 {
+		return int(size)
+	}
+	return 1
+}
+
+// intArg returns the integer value of the argument, given as "name value" or
+// "name=value"
+func (i *VLLMGenericText) intArg(name string) (int64, bool) {
+	for j, arg := range i.Args {
+		value, ok := strings.CutPrefix(arg, name+"=")
+		if !ok && arg == name && j+1 < len(i.Args) {
+			value, ok = i.Args[j+1], true
+		}
+		if !ok {
+			continue
+		}
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return parsed, true
+		}
+	}
+	return 0, false
+}
+
+func (i *VLLMGenericText) GetType() types.SessionType {
+	return types.SessionTypeText
+}
+
+func (i *VLLMGenericText) GetID() string {
+	return i.ID
+}
+
+func (i *VLLMGenericText) ModelName() Name {
+	return NewModel(i.ID)
+}
+
+func (i *VLLMGenericText) GetTask(session *types.Session, _ SessionFileManager) (*types.RunnerTask, error) {
+	return getGenericTask(session)
+}
+
+func (i *VLLMGenericText) GetCommand(_ context.Context, _ types.SessionFilter, _ types.RunnerProcessConfig) (*exec.Cmd, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (i *VLLMGenericText) GetTextStreams(_ types.SessionMode, _ WorkerEventHandler) (*TextStream, *TextStream, error) {
+	return nil, nil, fmt.Errorf("not implemented")
+}
+
+func (i *VLLMGenericText) PrepareFiles(_ *types.Session, _ bool, _ SessionFileManager) (*types.Session, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (i *VLLMGenericText) GetDescription() string {
+	return i.Description
+}
+
+func (i *VLLMGenericText) GetHumanReadableName() string {
+	return i.Name
+}
+
+func (i *VLLMGenericText) GetHidden() bool {
+	return i.Hide
+}
@@ -0,0 +1,232 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/avast/retry-go/v4"
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/helixml/helix/api/pkg/model"
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/openai/transport"
+)
+
+const (
+	DefaultBaseURL = "https://api.anthropic.com/v1"
+
+	apiVersion = "2023-06-01"
+
+	// defaultMaxTokens is sent when the request doesn't limit the tokens, the
+	// Messages API requires a limit
+	defaultMaxTokens = 4096
+
+	retries             = 3
+	delayBetweenRetries = time.Second
+)
+
+var _ oai.Client = &Client{}
+
+// Client is an adapter translating the OpenAI chat completions to the Anthropic
+// Messages API
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+}
+
+func New(apiKey, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		httpClient: http.DefaultClient,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+	}
+}
+
+func (c *Client) APIKey() string {
+	return c.apiKey
+}
+
+func (c *Client) CreateChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (resp openai.ChatCompletionResponse, err error) {
+	body, err := convertRequest(&request)
+	if err != nil {
+		return resp, err
+	}
+
+	err = retry.Do(func() error {
+		httpResp, err := c.do(ctx, http.MethodPost, "/messages", body)
+		if err != nil {
+			if !retryable(err) {
+				return retry.Unrecoverable(err)
+			}
+			return err
+		}
+		defer httpResp.Body.Close()
+
+		var messagesResp messagesResponse
+		if err := json.NewDecoder(httpResp.Body).Decode(&messagesResp); err != nil {
+			return fmt.Errorf("failed to decode Anthropic response: %w", err)
+		}
+
+		resp = convertResponse(&messagesResp)
+		return nil
+	},
+		retry.Attempts(retries),
+		retry.Delay(delayBetweenRetries),
+		retry.Context(ctx),
+		retry.LastErrorOnly(true),
+	)
+
+	return resp, err
+}
+
+func (c *Client) CreateChatCompletionStream(ctx context.Context, request openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error) {
+	body, err := convertRequest(&request)
+	if err != nil {
+		return nil, err
+	}
+	body.Stream = true
+
+	httpResp, err := c.do(ctx, http.MethodPost, "/messages", body)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, writer, err := transport.NewOpenAIStreamingAdapter(request)
+	if err != nil {
+		httpResp.Body.Close()
+		return nil, fmt.Errorf("failed to create streaming adapter: %w", err)
+	}
+
+	go func() {
+		defer httpResp.Body.Close()
+
+		s := &streamState{
+			request:     &request,
+			writer:      writer,
+			toolIndexes: make(map[int]int),
+		}
+		err := transport.ReadServerSentEvents(httpResp.Body, s.handleEvent)
+		if err == nil {
+			err = s.writeUsage()
+		}
+		// Closing with a nil error ends the stream with io.EOF
+		_ = writer.CloseWithError(err)
+	}()
+
+	return stream, nil
+}
+
+func (c *Client) ListModels(ctx context.Context) ([]model.OpenAIModel, error) {
+	httpResp, err := c.do(ctx, http.MethodGet, "/models?limit=1000", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Anthropic models: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	var modelsResp struct {
+		Data []struct {
+			ID          string    `json:"id"`
+			DisplayName string    `json:"display_name"`
+			CreatedAt   time.Time `json:"created_at"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(&modelsResp); err != nil {
+		return nil, fmt.Errorf("failed to decode Anthropic models: %w", err)
+	}
+
+	models := make([]model.OpenAIModel, 0, len(modelsResp.Data))
+	for _, m := range modelsResp.Data {
+		models = append(models, model.OpenAIModel{
+			ID:        m.ID,
+			Object:    "model",
+			OwnedBy:   "anthropic",
+			Name:      m.DisplayName,
+			CreatedAt: m.CreatedAt.Unix(),
+			Type:      "chat",
+		})
+	}
+
+	return models, nil
+}
+
+func (c *Client) CreateEmbeddings(_ context.Context, _ openai.EmbeddingRequest) (openai.EmbeddingResponse, error) {
+	return openai.EmbeddingResponse{}, errors.New("embeddings are not supported by the Anthropic API")
+}
+
+func (c *Client) Rerank(_ context.Context, _ oai.RerankRequest) (oai.RerankResponse, error) {
+	return oai.RerankResponse{}, fmt.Errorf("%w by the Anthropic API", oai.ErrRerankNotSupported)
+}
+
+// do sends the request to the API, the errors it answers with are returned as
+// OpenAI API errors so they are handled like the ones of the other providers
+func (c *Client) do(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		bts, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal Anthropic request: %w", err)
+		}
+		reader = bytes.NewReader(bts)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Anthropic request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", c.apiKey)
+	req.Header.Set("anthropic-version", apiVersion)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to Anthropic: %w", err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		bts, _ := io.ReadAll(resp.Body)
+		return nil, apiError(resp.StatusCode, bts)
+	}
+
+	return resp, nil
+}
+
+func apiError(status int, body []byte) *openai.APIError {
+	apiErr := &openai.APIError{
+		HTTPStatusCode: status,
+		Message:        strings.TrimSpace(string(body)),
+	}
+
+	var errResp errorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Message != "" {
+		apiErr.Message = errResp.Error.Message
+		apiErr.Type = errResp.Error.Type
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(status)
+	}
+
+	return apiErr
+}
+
+// retryable returns whether the request may succeed when sent again, client
+// errors other than rate limits won't
+func retryable(err error) bool {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatusCode == http.StatusTooManyRequests || apiErr.HTTPStatusCode >= http.StatusInternalServerError
+	}
+	return true
+}
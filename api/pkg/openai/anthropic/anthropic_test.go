@@ -0,0 +1,254 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, handler func(t *testing.T, req *messagesRequest, w http.ResponseWriter)) *Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/messages", r.URL.Path)
+		require.Equal(t, "secret", r.Header.Get("x-api-key"))
+		require.Equal(t, apiVersion, r.Header.Get("anthropic-version"))
+
+		var req messagesRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		handler(t, &req, w)
+	}))
+	t.Cleanup(srv.Close)
+
+	return New("secret", srv.URL+"/v1")
+}
+
+func toolRequest() openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
+		Model:       "claude-3-5-sonnet-latest",
+		Temperature: 0.5,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: "You are a weather bot"},
+			{Role: openai.ChatMessageRoleUser, Content: "What's the weather in London and Paris?"},
+			{
+				Role: openai.ChatMessageRoleAssistant,
+				ToolCalls: []openai.ToolCall{
+					{ID: "toolu_1", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "get_weather", Arguments: `{"city":"London"}`}},
+					{ID: "toolu_2", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+				},
+			},
+			{Role: openai.ChatMessageRoleTool, ToolCallID: "toolu_1", Content: "rainy"},
+			{Role: openai.ChatMessageRoleTool, ToolCallID: "toolu_2", Content: "sunny"},
+		},
+		Tools: []openai.Tool{
+			{
+				Type: openai.ToolTypeFunction,
+				Function: &openai.FunctionDefinition{
+					Name:        "get_weather",
+					Description: "Get the weather of a city",
+					Parameters: map[string]any{
+						"type":       "object",
+						"properties": map[string]any{"city": map[string]any{"type": "string"}},
+					},
+				},
+			},
+		},
+		ToolChoice: "required",
+	}
+}
+
+func TestClient_CreateChatCompletion(t *testing.T) {
+	client := newTestServer(t, func(t *testing.T, req *messagesRequest, w http.ResponseWriter) {
+		require.Equal(t, "claude-3-5-sonnet-latest", req.Model)
+		require.Equal(t, defaultMaxTokens, req.MaxTokens)
+		require.Equal(t, "You are a weather bot", req.System)
+		require.Equal(t, float32(0.5), *req.Temperature)
+		require.Nil(t, req.TopP)
+		require.Equal(t, &toolChoice{Type: "any"}, req.ToolChoice)
+		require.Len(t, req.Tools, 1)
+		require.Equal(t, "get_weather", req.Tools[0].Name)
+
+		// Tool results are sent together in a user message
+		require.Len(t, req.Messages, 3)
+		require.Equal(t, roleUser, req.Messages[0].Role)
+		require.Equal(t, roleAssistant, req.Messages[1].Role)
+		require.Equal(t, "tool_use", req.Messages[1].Content[0].Type)
+		require.JSONEq(t, `{"city":"London"}`, string(req.Messages[1].Content[0].Input))
+		require.Equal(t, roleUser, req.Messages[2].Role)
+		require.Equal(t, []contentBlock{
+			{Type: "tool_result", ToolUseID: "toolu_1", Content: "rainy"},
+			{Type: "tool_result", ToolUseID: "toolu_2", Content: "sunny"},
+		}, req.Messages[2].Content)
+
+		_, _ = w.Write([]byte(`{
+			"id": "msg_1",
+			"type": "message",
+			"role": "assistant",
+			"model": "claude-3-5-sonnet-20241022",
+			"content": [
+				{"type": "text", "text": "Let me check the forecast."},
+				{"type": "tool_use", "id": "toolu_3", "name": "get_forecast", "input": {"city": "London"}}
+			],
+			"stop_reason": "tool_use",
+			"usage": {"input_tokens": 100, "output_tokens": 20}
+		}`))
+	})
+
+	resp, err := client.CreateChatCompletion(context.Background(), toolRequest())
+	require.NoError(t, err)
+	require.Equal(t, "msg_1", resp.ID)
+	require.Equal(t, "claude-3-5-sonnet-20241022", resp.Model)
+	require.Len(t, resp.Choices, 1)
+	require.Equal(t, openai.FinishReasonToolCalls, resp.Choices[0].FinishReason)
+
+	msg := resp.Choices[0].Message
+	require.Equal(t, openai.ChatMessageRoleAssistant, msg.Role)
+	require.Equal(t, "Let me check the forecast.", msg.Content)
+	require.Len(t, msg.ToolCalls, 1)
+	require.Equal(t, "toolu_3", msg.ToolCalls[0].ID)
+	require.Equal(t, "get_forecast", msg.ToolCalls[0].Function.Name)
+	require.JSONEq(t, `{"city":"London"}`, msg.ToolCalls[0].Function.Arguments)
+	require.Equal(t, openai.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120}, resp.Usage)
+}
+
+func TestClient_CreateChatCompletion_Error(t *testing.T) {
+	client := newTestServer(t, func(_ *testing.T, _ *messagesRequest, w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens is too large"}}`))
+	})
+
+	_, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:     "claude-3-5-sonnet-latest",
+		MaxTokens: 1000000,
+		Messages:  []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "hi"}},
+	})
+
+	var apiErr *openai.APIError
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusBadRequest, apiErr.HTTPStatusCode)
+	require.Equal(t, "invalid_request_error", apiErr.Type)
+	require.Equal(t, "max_tokens is too large", apiErr.Message)
+}
+
+func TestClient_CreateChatCompletionStream(t *testing.T) {
+	events := []string{
+		`{"type":"message_start","message":{"id":"msg_1","model":"claude-3-5-sonnet-20241022","usage":{"input_tokens":50,"output_tokens":1}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"ping"}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking"}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" now."}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":" \"London\"}"}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":30}}`,
+		`{"type":"message_stop"}`,
+	}
+
+	client := newTestServer(t, func(t *testing.T, req *messagesRequest, w http.ResponseWriter) {
+		require.True(t, req.Stream)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			var e struct {
+				Type string `json:"type"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(event), &e))
+			_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, event)
+		}
+	})
+
+	req := openai.ChatCompletionRequest{
+		Model:         "claude-3-5-sonnet-latest",
+		Messages:      []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "What's the weather in London?"}},
+		Stream:        true,
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
+	}
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), req)
+	require.NoError(t, err)
+	defer stream.Close()
+
+	var (
+		content      string
+		arguments    string
+		toolCallID   string
+		finishReason openai.FinishReason
+		usage        *openai.Usage
+	)
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		require.Equal(t, "msg_1", chunk.ID)
+
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		delta := chunk.Choices[0].Delta
+		content += delta.Content
+		for _, call := range delta.ToolCalls {
+			require.Equal(t, 0, *call.Index)
+			if call.ID != "" {
+				toolCallID = call.ID
+				require.Equal(t, "get_weather", call.Function.Name)
+			}
+			arguments += call.Function.Arguments
+		}
+		if chunk.Choices[0].FinishReason != "" {
+			finishReason = chunk.Choices[0].FinishReason
+		}
+	}
+
+	require.Equal(t, "Checking now.", content)
+	require.Equal(t, "toolu_1", toolCallID)
+	require.JSONEq(t, `{"city":"London"}`, arguments)
+	require.Equal(t, openai.FinishReasonToolCalls, finishReason)
+	require.Equal(t, &openai.Usage{PromptTokens: 50, CompletionTokens: 30, TotalTokens: 80}, usage)
+}
+
+func TestClient_CreateChatCompletionStream_Error(t *testing.T) {
+	client := newTestServer(t, func(_ *testing.T, _ *messagesRequest, w http.ResponseWriter) {
+		_, _ = fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\"}}\n\n")
+		_, _ = fmt.Fprint(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model:    "claude-3-5-sonnet-latest",
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "hi"}},
+	})
+	require.NoError(t, err)
+	defer stream.Close()
+
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	require.ErrorContains(t, err, "Overloaded")
+}
+
+func Test_convertImageURL(t *testing.T) {
+	source, err := convertImageURL("data:image/png;base64,iVBORw0KGgo=")
+	require.NoError(t, err)
+	require.Equal(t, &imageSource{Type: "base64", MediaType: "image/png", Data: "iVBORw0KGgo="}, source)
+
+	source, err = convertImageURL("https://example.com/cat.png")
+	require.NoError(t, err)
+	require.Equal(t, &imageSource{Type: "url", URL: "https://example.com/cat.png"}, source)
+
+	_, err = convertImageURL("data:text/plain,hello")
+	require.Error(t, err)
+}
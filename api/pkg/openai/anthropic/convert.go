@@ -0,0 +1,355 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+type messagesRequest struct {
+	Model         string      `json:"model"`
+	MaxTokens     int         `json:"max_tokens"`
+	System        string      `json:"system,omitempty"`
+	Messages      []message   `json:"messages"`
+	Temperature   *float32    `json:"temperature,omitempty"`
+	TopP          *float32    `json:"top_p,omitempty"`
+	StopSequences []string    `json:"stop_sequences,omitempty"`
+	Stream        bool        `json:"stream,omitempty"`
+	Tools         []tool      `json:"tools,omitempty"`
+	ToolChoice    *toolChoice `json:"tool_choice,omitempty"`
+	Metadata      *metadata   `json:"metadata,omitempty"`
+}
+
+type message struct {
+	Role    string         `json:"role"`
+	Content []contentBlock `json:"content"`
+}
+
+// contentBlock is a text, image, tool use or tool result block
+type contentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	Source    *imageSource    `json:"source,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+type imageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+type tool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema"`
+}
+
+type toolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+type metadata struct {
+	UserID string `json:"user_id,omitempty"`
+}
+
+type messagesResponse struct {
+	ID         string         `json:"id"`
+	Model      string         `json:"model"`
+	Content    []contentBlock `json:"content"`
+	StopReason string         `json:"stop_reason"`
+	Usage      usage          `json:"usage"`
+}
+
+type usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+const (
+	roleUser      = "user"
+	roleAssistant = "assistant"
+)
+
+func convertRequest(req *openai.ChatCompletionRequest) (*messagesRequest, error) {
+	system, messages, err := convertMessages(req.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	converted := &messagesRequest{
+		Model:         req.Model,
+		MaxTokens:     defaultMaxTokens,
+		System:        system,
+		Messages:      messages,
+		StopSequences: req.Stop,
+	}
+
+	if req.MaxTokens > 0 {
+		converted.MaxTokens = req.MaxTokens
+	}
+
+	// The OpenAI client can't tell an unset temperature from zero either
+	if req.Temperature != 0 {
+		temperature := req.Temperature
+		converted.Temperature = &temperature
+	}
+	if req.TopP != 0 {
+		topP := req.TopP
+		converted.TopP = &topP
+	}
+
+	if req.User != "" {
+		converted.Metadata = &metadata{UserID: req.User}
+	}
+
+	for _, t := range req.Tools {
+		if t.Function == nil {
+			continue
+		}
+		schema := t.Function.Parameters
+		if schema == nil {
+			schema = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		converted.Tools = append(converted.Tools, tool{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			InputSchema: schema,
+		})
+	}
+
+	converted.ToolChoice, err = convertToolChoice(req.ToolChoice)
+	if err != nil {
+		return nil, err
+	}
+
+	return converted, nil
+}
+
+// convertToolChoice converts the tool choice, which is either one of the
+// auto, required and none strings or the function to call
+func convertToolChoice(choice any) (*toolChoice, error) {
+	switch c := choice.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		switch c {
+		case "", "auto":
+			return nil, nil
+		case "required":
+			return &toolChoice{Type: "any"}, nil
+		case "none":
+			return &toolChoice{Type: "none"}, nil
+		default:
+			return nil, fmt.Errorf("unknown tool choice '%s'", c)
+		}
+	default:
+		bts, err := json.Marshal(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tool choice: %w", err)
+		}
+		var function openai.ToolChoice
+		if err := json.Unmarshal(bts, &function); err != nil || function.Function.Name == "" {
+			return nil, fmt.Errorf("invalid tool choice: %s", string(bts))
+		}
+		return &toolChoice{Type: "tool", Name: function.Function.Name}, nil
+	}
+}
+
+// convertMessages moves the system messages to the system prompt and the tool
+// results into user messages. Consecutive messages of a role are merged as
+// the Messages API expects the roles to alternate.
+func convertMessages(messages []openai.ChatCompletionMessage) (string, []message, error) {
+	var (
+		system    []string
+		converted []message
+	)
+
+	for _, m := range messages {
+		var (
+			role   string
+			blocks []contentBlock
+		)
+
+		switch m.Role {
+		case openai.ChatMessageRoleSystem, "developer":
+			system = append(system, messageText(&m))
+			continue
+		case openai.ChatMessageRoleUser:
+			role = roleUser
+			var err error
+			blocks, err = userContent(&m)
+			if err != nil {
+				return "", nil, err
+			}
+		case openai.ChatMessageRoleAssistant:
+			role = roleAssistant
+			if text := messageText(&m); text != "" {
+				blocks = append(blocks, contentBlock{Type: "text", Text: text})
+			}
+			for _, call := range m.ToolCalls {
+				input := json.RawMessage(call.Function.Arguments)
+				if len(input) == 0 {
+					input = json.RawMessage("{}")
+				}
+				if !json.Valid(input) {
+					return "", nil, fmt.Errorf("invalid arguments of tool call %s: %s", call.ID, call.Function.Arguments)
+				}
+				blocks = append(blocks, contentBlock{
+					Type:  "tool_use",
+					ID:    call.ID,
+					Name:  call.Function.Name,
+					Input: input,
+				})
+			}
+		case openai.ChatMessageRoleTool:
+			role = roleUser
+			blocks = []contentBlock{{
+				Type:      "tool_result",
+				ToolUseID: m.ToolCallID,
+				Content:   messageText(&m),
+			}}
+		default:
+			return "", nil, fmt.Errorf("unsupported message role '%s'", m.Role)
+		}
+
+		if len(blocks) == 0 {
+			continue
+		}
+
+		if n := len(converted); n > 0 && converted[n-1].Role == role {
+			converted[n-1].Content = append(converted[n-1].Content, blocks...)
+			continue
+		}
+		converted = append(converted, message{Role: role, Content: blocks})
+	}
+
+	return strings.Join(system, "\n\n"), converted, nil
+}
+
+func messageText(m *openai.ChatCompletionMessage) string {
+	if len(m.MultiContent) == 0 {
+		return m.Content
+	}
+
+	var parts []string
+	for _, part := range m.MultiContent {
+		if part.Type == openai.ChatMessagePartTypeText {
+			parts = append(parts, part.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func userContent(m *openai.ChatCompletionMessage) ([]contentBlock, error) {
+	if len(m.MultiContent) == 0 {
+		if m.Content == "" {
+			return nil, nil
+		}
+		return []contentBlock{{Type: "text", Text: m.Content}}, nil
+	}
+
+	blocks := make([]contentBlock, 0, len(m.MultiContent))
+	for _, part := range m.MultiContent {
+		switch part.Type {
+		case openai.ChatMessagePartTypeText:
+			blocks = append(blocks, contentBlock{Type: "text", Text: part.Text})
+		case openai.ChatMessagePartTypeImageURL:
+			if part.ImageURL == nil {
+				continue
+			}
+			source, err := convertImageURL(part.ImageURL.URL)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, contentBlock{Type: "image", Source: source})
+		default:
+			return nil, fmt.Errorf("unsupported content part type '%s'", part.Type)
+		}
+	}
+	return blocks, nil
+}
+
+// convertImageURL converts a URL or a base64 data URL like
+// data:image/png;base64,iVBORw0... to an image source
+func convertImageURL(url string) (*imageSource, error) {
+	data, ok := strings.CutPrefix(url, "data:")
+	if !ok {
+		return &imageSource{Type: "url", URL: url}, nil
+	}
+
+	mediaType, encoded, ok := strings.Cut(data, ";base64,")
+	if !ok {
+		return nil, fmt.Errorf("only base64 encoded data URLs are supported")
+	}
+	return &imageSource{Type: "base64", MediaType: mediaType, Data: encoded}, nil
+}
+
+func convertResponse(resp *messagesResponse) openai.ChatCompletionResponse {
+	msg := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}
+
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			msg.Content += block.Text
+		case "tool_use":
+			msg.ToolCalls = append(msg.ToolCalls, openai.ToolCall{
+				ID:   block.ID,
+				Type: openai.ToolTypeFunction,
+				Function: openai.FunctionCall{
+					Name:      block.Name,
+					Arguments: string(block.Input),
+				},
+			})
+		}
+	}
+
+	return openai.ChatCompletionResponse{
+		ID:      resp.ID,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   resp.Model,
+		Choices: []openai.ChatCompletionChoice{
+			{
+				Index:        0,
+				Message:      msg,
+				FinishReason: finishReason(resp.StopReason),
+			},
+		},
+		Usage: openai.Usage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		},
+	}
+}
+
+func finishReason(stopReason string) openai.FinishReason {
+	switch stopReason {
+	case "max_tokens":
+		return openai.FinishReasonLength
+	case "tool_use":
+		return openai.FinishReasonToolCalls
+	case "":
+		return ""
+	default:
+		// end_turn and stop_sequence
+		return openai.FinishReasonStop
+	}
+}
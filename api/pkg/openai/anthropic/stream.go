@@ -0,0 +1,163 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/helixml/helix/api/pkg/openai/transport"
+)
+
+// streamEvent is any of the events of a streamed message, only the fields of
+// the event type are set
+type streamEvent struct {
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+
+	// message_start
+	Message *messagesResponse `json:"message,omitempty"`
+	// content_block_start
+	ContentBlock *contentBlock `json:"content_block,omitempty"`
+	// content_block_delta and message_delta
+	Delta *struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta,omitempty"`
+	// message_delta
+	Usage *usage `json:"usage,omitempty"`
+	// error
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// streamState translates the events of a streamed message to OpenAI chunks
+type streamState struct {
+	request *openai.ChatCompletionRequest
+	writer  io.Writer
+
+	id      string
+	model   string
+	created int64
+	usage   usage
+	// toolIndexes maps the content blocks with tool uses to the indexes of the
+	// tool calls
+	toolIndexes map[int]int
+}
+
+func (s *streamState) handleEvent(data []byte) error {
+	var event streamEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return fmt.Errorf("failed to decode Anthropic stream event: %w", err)
+	}
+
+	switch event.Type {
+	case "message_start":
+		if event.Message != nil {
+			s.id = event.Message.ID
+			s.model = event.Message.Model
+			s.usage = event.Message.Usage
+		}
+		s.created = time.Now().Unix()
+		return s.write(openai.ChatCompletionStreamChoiceDelta{Role: openai.ChatMessageRoleAssistant}, "")
+
+	case "content_block_start":
+		if event.ContentBlock == nil || event.ContentBlock.Type != "tool_use" {
+			return nil
+		}
+		index := len(s.toolIndexes)
+		s.toolIndexes[event.Index] = index
+		return s.write(openai.ChatCompletionStreamChoiceDelta{
+			ToolCalls: []openai.ToolCall{{
+				Index: &index,
+				ID:    event.ContentBlock.ID,
+				Type:  openai.ToolTypeFunction,
+				Function: openai.FunctionCall{
+					Name: event.ContentBlock.Name,
+				},
+			}},
+		}, "")
+
+	case "content_block_delta":
+		if event.Delta == nil {
+			return nil
+		}
+		switch event.Delta.Type {
+		case "text_delta":
+			return s.write(openai.ChatCompletionStreamChoiceDelta{Content: event.Delta.Text}, "")
+		case "input_json_delta":
+			index, ok := s.toolIndexes[event.Index]
+			if !ok {
+				return nil
+			}
+			return s.write(openai.ChatCompletionStreamChoiceDelta{
+				ToolCalls: []openai.ToolCall{{
+					Index:    &index,
+					Function: openai.FunctionCall{Arguments: event.Delta.PartialJSON},
+				}},
+			}, "")
+		}
+
+	case "message_delta":
+		if event.Usage != nil {
+			s.usage.OutputTokens = event.Usage.OutputTokens
+		}
+		if event.Delta != nil && event.Delta.StopReason != "" {
+			return s.write(openai.ChatCompletionStreamChoiceDelta{}, finishReason(event.Delta.StopReason))
+		}
+
+	case "error":
+		apiErr := &openai.APIError{Message: "Anthropic stream failed"}
+		if event.Error != nil {
+			apiErr.Type = event.Error.Type
+			apiErr.Message = event.Error.Message
+		}
+		return apiErr
+	}
+
+	// ping, content_block_stop and message_stop don't have anything to send
+	return nil
+}
+
+func (s *streamState) write(delta openai.ChatCompletionStreamChoiceDelta, finishReason openai.FinishReason) error {
+	return transport.WriteChatCompletionStream(s.writer, &openai.ChatCompletionStreamResponse{
+		ID:      s.id,
+		Object:  "chat.completion.chunk",
+		Created: s.created,
+		Model:   s.model,
+		Choices: []openai.ChatCompletionStreamChoice{
+			{
+				Index:        0,
+				Delta:        delta,
+				FinishReason: finishReason,
+			},
+		},
+	})
+}
+
+// writeUsage sends the usage in a last chunk without choices, like OpenAI does
+// when the request asks for it
+func (s *streamState) writeUsage() error {
+	if s.request.StreamOptions == nil || !s.request.StreamOptions.IncludeUsage {
+		return nil
+	}
+
+	return transport.WriteChatCompletionStream(s.writer, &openai.ChatCompletionStreamResponse{
+		ID:      s.id,
+		Object:  "chat.completion.chunk",
+		Created: s.created,
+		Model:   s.model,
+		Choices: []openai.ChatCompletionStreamChoice{},
+		Usage: &openai.Usage{
+			PromptTokens:     s.usage.InputTokens,
+			CompletionTokens: s.usage.OutputTokens,
+			TotalTokens:      s.usage.InputTokens + s.usage.OutputTokens,
+		},
+	})
+}
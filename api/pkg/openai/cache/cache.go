@@ -0,0 +1,323 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/helixml/helix/api/pkg/config"
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// Header is set to "hit" on the responses served from the cache
+const Header = "X-Helix-Cache"
+
+// IsHit returns whether the response with the header was served from the cache
+func IsHit(header http.Header) bool {
+	return header.Get(Header) == "hit"
+}
+
+func hitHeader() http.Header {
+	header := http.Header{}
+	header.Set(Header, "hit")
+	return header
+}
+
+// ResponseCache holds chat completion responses in memory. Responses are found
+// by the hash of the request, or by the similarity of the prompt embeddings to
+// the ones of requests that only differ in the last user message.
+type ResponseCache struct {
+	cfg *config.ResponseCache
+
+	mu         sync.Mutex
+	embeddings oai.Client
+	entries    map[string]*entry
+	partitions map[string]map[string]*entry
+	// Least recently used entries are at the back
+	lru *list.List
+
+	now func() time.Time
+}
+
+type entry struct {
+	key       string
+	partition string
+	embedding []float32
+	response  openai.ChatCompletionResponse
+	expires   time.Time
+	element   *list.Element
+}
+
+func NewResponseCache(cfg *config.ResponseCache) *ResponseCache {
+	return &ResponseCache{
+		cfg:        cfg,
+		entries:    make(map[string]*entry),
+		partitions: make(map[string]map[string]*entry),
+		lru:        list.New(),
+		now:        time.Now,
+	}
+}
+
+// SetEmbeddingsClient sets the client prompts are embedded with, without one
+// only identical requests get cached responses
+func (c *ResponseCache) SetEmbeddingsClient(client oai.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.embeddings = client
+}
+
+// lookup identifies a request in the cache
+type lookup struct {
+	key       string
+	partition string
+	// prompt is embedded for similarity lookups, empty if the request can only
+	// match exactly
+	prompt    string
+	embedding []float32
+	threshold float64
+	ttl       time.Duration
+}
+
+// partitionRequest is hashed into the partition of a request, the streaming
+// options and the end user don't change the answer
+type partitionRequest struct {
+	Provider  types.Provider               `json:"provider"`
+	Scope     string                       `json:"scope"`
+	Knowledge []*types.Citation            `json:"knowledge"`
+	Request   openai.ChatCompletionRequest `json:"request"`
+}
+
+func (c *ResponseCache) newLookup(provider types.Provider, req openai.ChatCompletionRequest, opts *Options) (*lookup, error) {
+	l := &lookup{
+		threshold: c.cfg.SimilarityThreshold,
+		ttl:       c.cfg.TTL,
+	}
+	if opts.SimilarityThreshold > 0 {
+		l.threshold = opts.SimilarityThreshold
+	}
+	if opts.TTL > 0 {
+		l.ttl = opts.TTL
+	}
+
+	req.Stream = false
+	req.StreamOptions = nil
+	req.User = ""
+
+	var last openai.ChatCompletionMessage
+	if len(req.Messages) > 0 {
+		// Copy the messages so the last one can be cleared from the partition
+		req.Messages = append([]openai.ChatCompletionMessage(nil), req.Messages...)
+		last = req.Messages[len(req.Messages)-1]
+		req.Messages[len(req.Messages)-1] = openai.ChatCompletionMessage{Role: last.Role}
+	}
+
+	partition, err := hash(&partitionRequest{
+		Provider:  provider,
+		Scope:     opts.Scope,
+		Knowledge: opts.Knowledge,
+		Request:   req,
+	})
+	if err != nil {
+		return nil, err
+	}
+	l.partition = partition
+
+	key, err := hash(struct {
+		Partition string                       `json:"partition"`
+		Message   openai.ChatCompletionMessage `json:"message"`
+	}{partition, last})
+	if err != nil {
+		return nil, err
+	}
+	l.key = key
+
+	// Only plain questions are looked up by similarity
+	if last.Role == openai.ChatMessageRoleUser && len(last.MultiContent) == 0 && l.threshold < 1 {
+		l.prompt = opts.Prompt
+		if l.prompt == "" {
+			l.prompt = last.Content
+		}
+	}
+
+	return l, nil
+}
+
+func hash(v any) (string, error) {
+	bts, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(bts)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// get returns the cached response of the request, embedding the prompt if there
+// is no response for the exact request
+func (c *ResponseCache) get(ctx context.Context, l *lookup) (openai.ChatCompletionResponse, bool) {
+	c.mu.Lock()
+	e, ok := c.entries[l.key]
+	if ok && c.now().Before(e.expires) {
+		c.lru.MoveToFront(e.element)
+		resp := e.response
+		c.mu.Unlock()
+		resp.SetHeader(hitHeader())
+		return resp, true
+	}
+	embeddings := c.embeddings
+	c.mu.Unlock()
+
+	if l.prompt == "" || embeddings == nil {
+		return openai.ChatCompletionResponse{}, false
+	}
+
+	embedding, err := c.embed(ctx, embeddings, l.prompt)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to embed prompt for the response cache, only exact matches are used")
+		return openai.ChatCompletionResponse{}, false
+	}
+	l.embedding = embedding
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var (
+		best           *entry
+		bestSimilarity float64
+	)
+	now := c.now()
+	for _, e := range c.partitions[l.partition] {
+		if e.embedding == nil || !now.Before(e.expires) {
+			continue
+		}
+		similarity := cosineSimilarity(embedding, e.embedding)
+		if similarity >= l.threshold && similarity > bestSimilarity {
+			best, bestSimilarity = e, similarity
+		}
+	}
+	if best == nil {
+		return openai.ChatCompletionResponse{}, false
+	}
+
+	log.Debug().
+		Str("partition", l.partition).
+		Float64("similarity", bestSimilarity).
+		Msg("found cached response for similar prompt")
+
+	c.lru.MoveToFront(best.element)
+	resp := best.response
+	resp.SetHeader(hitHeader())
+	return resp, true
+}
+
+func (c *ResponseCache) embed(ctx context.Context, client oai.Client, prompt string) ([]float32, error) {
+	resp, err := client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Model: openai.EmbeddingModel(c.cfg.EmbeddingsModel),
+		Input: []string{prompt},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 {
+		return nil, errors.New("no embeddings returned")
+	}
+	return resp.Data[0].Embedding, nil
+}
+
+// set caches the response of the request, responses that were cut short or
+// have no choices aren't cached
+func (c *ResponseCache) set(l *lookup, resp openai.ChatCompletionResponse) {
+	if len(resp.Choices) == 0 || resp.Choices[0].FinishReason == openai.FinishReasonLength {
+		return
+	}
+	resp.SetHeader(nil)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if existing, ok := c.entries[l.key]; ok {
+		c.remove(existing)
+	}
+
+	e := &entry{
+		key:       l.key,
+		partition: l.partition,
+		embedding: l.embedding,
+		response:  resp,
+		expires:   c.now().Add(l.ttl),
+	}
+	e.element = c.lru.PushFront(e)
+	c.entries[l.key] = e
+	if c.partitions[l.partition] == nil {
+		c.partitions[l.partition] = make(map[string]*entry)
+	}
+	c.partitions[l.partition][l.key] = e
+
+	for c.cfg.MaxEntries > 0 && c.lru.Len() > c.cfg.MaxEntries {
+		c.remove(c.lru.Back().Value.(*entry))
+	}
+}
+
+func (c *ResponseCache) remove(e *entry) {
+	c.lru.Remove(e.element)
+	delete(c.entries, e.key)
+	delete(c.partitions[e.partition], e.key)
+	if len(c.partitions[e.partition]) == 0 {
+		delete(c.partitions, e.partition)
+	}
+}
+
+// removeExpired drops the expired entries so they don't hold memory until
+// they are evicted
+func (c *ResponseCache) removeExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for _, e := range c.entries {
+		if !now.Before(e.expires) {
+			c.remove(e)
+		}
+	}
+}
+
+// Run removes the expired entries every minute until the context is done
+func (c *ResponseCache) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.removeExpired()
+		}
+	}
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
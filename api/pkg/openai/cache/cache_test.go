@@ -0,0 +1,249 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/config"
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func newTestCache(t *testing.T) (*ResponseCache, *oai.MockClient, *time.Time) {
+	ctrl := gomock.NewController(t)
+	embeddings := oai.NewMockClient(ctrl)
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := NewResponseCache(&config.ResponseCache{
+		MaxEntries:          2,
+		TTL:                 time.Hour,
+		SimilarityThreshold: 0.9,
+		EmbeddingsModel:     "embed",
+	})
+	c.now = func() time.Time { return now }
+	c.SetEmbeddingsClient(embeddings)
+
+	return c, embeddings, &now
+}
+
+func question(content string) openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
+		Model: "gpt-4o",
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: "You are helpful"},
+			{Role: openai.ChatMessageRoleUser, Content: content},
+		},
+	}
+}
+
+func answer(content string) openai.ChatCompletionResponse {
+	return openai.ChatCompletionResponse{
+		ID:    "chatcmpl-" + content,
+		Model: "gpt-4o",
+		Choices: []openai.ChatCompletionChoice{
+			{
+				Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: content},
+				FinishReason: openai.FinishReasonStop,
+			},
+		},
+		Usage: openai.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+	}
+}
+
+func expectEmbedding(client *oai.MockClient, prompt string, embedding ...float32) {
+	client.EXPECT().CreateEmbeddings(gomock.Any(), openai.EmbeddingRequest{
+		Model: "embed",
+		Input: []string{prompt},
+	}).Return(openai.EmbeddingResponse{
+		Data: []openai.Embedding{{Embedding: embedding}},
+	}, nil)
+}
+
+func TestMiddleware_ExactMatch(t *testing.T) {
+	c, embeddings, now := newTestCache(t)
+	upstream := oai.NewMockClient(gomock.NewController(t))
+	m := Wrap(types.ProviderOpenAI, upstream, c)
+
+	ctx := SetContextOptions(context.Background(), &Options{Scope: "app", SimilarityThreshold: 1})
+
+	// Exact matches don't need the embeddings
+	embeddings.EXPECT().CreateEmbeddings(gomock.Any(), gomock.Any()).Times(0)
+	upstream.EXPECT().CreateChatCompletion(gomock.Any(), question("hi")).Return(answer("hello"), nil).Times(1)
+
+	resp, err := m.CreateChatCompletion(ctx, question("hi"))
+	require.NoError(t, err)
+	require.False(t, IsHit(resp.Header()))
+
+	// The end user doesn't change the answer
+	req := question("hi")
+	req.User = "bob"
+	resp, err = m.CreateChatCompletion(ctx, req)
+	require.NoError(t, err)
+	require.True(t, IsHit(resp.Header()))
+	require.Equal(t, "hello", resp.Choices[0].Message.Content)
+
+	// Other apps don't share the answers
+	upstream.EXPECT().CreateChatCompletion(gomock.Any(), question("hi")).Return(answer("hey"), nil).Times(1)
+	resp, err = m.CreateChatCompletion(SetContextOptions(context.Background(), &Options{Scope: "other", SimilarityThreshold: 1}), question("hi"))
+	require.NoError(t, err)
+	require.False(t, IsHit(resp.Header()))
+
+	// Expired answers aren't used
+	*now = now.Add(2 * time.Hour)
+	upstream.EXPECT().CreateChatCompletion(gomock.Any(), question("hi")).Return(answer("hello again"), nil).Times(1)
+	resp, err = m.CreateChatCompletion(ctx, question("hi"))
+	require.NoError(t, err)
+	require.Equal(t, "hello again", resp.Choices[0].Message.Content)
+
+	// Without the options the cache is bypassed
+	upstream.EXPECT().CreateChatCompletion(gomock.Any(), question("hi")).Return(answer("uncached"), nil).Times(1)
+	resp, err = m.CreateChatCompletion(context.Background(), question("hi"))
+	require.NoError(t, err)
+	require.Equal(t, "uncached", resp.Choices[0].Message.Content)
+}
+
+func TestMiddleware_SimilarPrompt(t *testing.T) {
+	c, embeddings, _ := newTestCache(t)
+	upstream := oai.NewMockClient(gomock.NewController(t))
+	m := Wrap(types.ProviderOpenAI, upstream, c)
+
+	knowledge := []*types.Citation{{DocumentID: "doc", Content: "opening hours are 9 to 5"}}
+	ctx := func(prompt string, knowledge []*types.Citation) context.Context {
+		return SetContextOptions(context.Background(), &Options{Scope: "app", Prompt: prompt, Knowledge: knowledge})
+	}
+
+	expectEmbedding(embeddings, "when are you open?", 1, 0)
+	upstream.EXPECT().CreateChatCompletion(gomock.Any(), question("when are you open? [knowledge]")).Return(answer("9 to 5"), nil)
+	_, err := m.CreateChatCompletion(ctx("when are you open?", knowledge), question("when are you open? [knowledge]"))
+	require.NoError(t, err)
+
+	// A similar question with the same knowledge gets the answer
+	expectEmbedding(embeddings, "what are your opening hours?", 0.95, 0.05)
+	resp, err := m.CreateChatCompletion(ctx("what are your opening hours?", knowledge), question("what are your opening hours? [knowledge]"))
+	require.NoError(t, err)
+	require.True(t, IsHit(resp.Header()))
+	require.Equal(t, "9 to 5", resp.Choices[0].Message.Content)
+
+	// The cache is bypassed when other knowledge was found
+	expectEmbedding(embeddings, "what are your opening hours?", 0.95, 0.05)
+	upstream.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(answer("10 to 6"), nil)
+	resp, err = m.CreateChatCompletion(ctx("what are your opening hours?", []*types.Citation{{DocumentID: "doc", Content: "opening hours are 10 to 6"}}), question("what are your opening hours? [knowledge]"))
+	require.NoError(t, err)
+	require.False(t, IsHit(resp.Header()))
+
+	// Or when the tools differ
+	req := question("what are your opening hours? [knowledge]")
+	req.Tools = []openai.Tool{{Type: openai.ToolTypeFunction, Function: &openai.FunctionDefinition{Name: "get_hours"}}}
+	expectEmbedding(embeddings, "what are your opening hours?", 0.95, 0.05)
+	upstream.EXPECT().CreateChatCompletion(gomock.Any(), req).Return(answer("let me check"), nil)
+	resp, err = m.CreateChatCompletion(ctx("what are your opening hours?", knowledge), req)
+	require.NoError(t, err)
+	require.False(t, IsHit(resp.Header()))
+
+	// Unrelated questions aren't answered from the cache
+	expectEmbedding(embeddings, "where are you?", 0, 1)
+	upstream.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(answer("London"), nil)
+	resp, err = m.CreateChatCompletion(ctx("where are you?", knowledge), question("where are you? [knowledge]"))
+	require.NoError(t, err)
+	require.False(t, IsHit(resp.Header()))
+}
+
+func TestMiddleware_EmbeddingsFailure(t *testing.T) {
+	c, embeddings, _ := newTestCache(t)
+	upstream := oai.NewMockClient(gomock.NewController(t))
+	m := Wrap(types.ProviderOpenAI, upstream, c)
+
+	ctx := SetContextOptions(context.Background(), &Options{Scope: "app"})
+
+	// Failing embeddings only disable the similarity lookup
+	embeddings.EXPECT().CreateEmbeddings(gomock.Any(), gomock.Any()).Return(openai.EmbeddingResponse{}, errors.New("unavailable"))
+	upstream.EXPECT().CreateChatCompletion(gomock.Any(), question("hi")).Return(answer("hello"), nil).Times(1)
+
+	_, err := m.CreateChatCompletion(ctx, question("hi"))
+	require.NoError(t, err)
+
+	resp, err := m.CreateChatCompletion(ctx, question("hi"))
+	require.NoError(t, err)
+	require.True(t, IsHit(resp.Header()))
+
+	expectEmbedding(embeddings, "hello?", 1)
+	upstream.EXPECT().CreateChatCompletion(gomock.Any(), question("hello?")).Return(answer("hi"), nil).Times(1)
+	_, err = m.CreateChatCompletion(ctx, question("hello?"))
+	require.NoError(t, err)
+}
+
+func TestResponseCache_Eviction(t *testing.T) {
+	c, _, _ := newTestCache(t)
+	opts := &Options{Scope: "app", SimilarityThreshold: 1}
+
+	lookups := make([]*lookup, 3)
+	for i, content := range []string{"a", "b", "c"} {
+		l, err := c.newLookup(types.ProviderOpenAI, question(content), opts)
+		require.NoError(t, err)
+		lookups[i] = l
+	}
+
+	c.set(lookups[0], answer("a"))
+	c.set(lookups[1], answer("b"))
+
+	// Using the first entry makes the second one the least recently used
+	_, ok := c.get(context.Background(), lookups[0])
+	require.True(t, ok)
+
+	c.set(lookups[2], answer("c"))
+	_, ok = c.get(context.Background(), lookups[1])
+	require.False(t, ok)
+	_, ok = c.get(context.Background(), lookups[0])
+	require.True(t, ok)
+
+	// Truncated answers aren't cached
+	truncated := answer("d")
+	truncated.Choices[0].FinishReason = openai.FinishReasonLength
+	l, err := c.newLookup(types.ProviderOpenAI, question("d"), opts)
+	require.NoError(t, err)
+	c.set(l, truncated)
+	_, ok = c.get(context.Background(), l)
+	require.False(t, ok)
+}
+
+func TestMiddleware_Stream(t *testing.T) {
+	c, _, _ := newTestCache(t)
+	upstream := oai.NewMockClient(gomock.NewController(t))
+	m := Wrap(types.ProviderOpenAI, upstream, c)
+
+	ctx := SetContextOptions(context.Background(), &Options{Scope: "app", SimilarityThreshold: 1})
+
+	req := question("hi")
+	req.Stream = true
+	req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+
+	// A cached answer is replayed as a stream
+	upstream.EXPECT().CreateChatCompletion(gomock.Any(), question("hi")).Return(answer("hello"), nil)
+	_, err := m.CreateChatCompletion(ctx, question("hi"))
+	require.NoError(t, err)
+
+	stream, err := m.CreateChatCompletionStream(ctx, req)
+	require.NoError(t, err)
+	defer stream.Close()
+	require.True(t, IsHit(stream.Header()))
+
+	chunk, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "hello", chunk.Choices[0].Delta.Content)
+	require.Equal(t, openai.FinishReasonStop, chunk.Choices[0].FinishReason)
+
+	chunk, err = stream.Recv()
+	require.NoError(t, err)
+	require.Empty(t, chunk.Choices)
+	require.Equal(t, 15, chunk.Usage.TotalTokens)
+
+	_, err = stream.Recv()
+	require.ErrorIs(t, err, io.EOF)
+}
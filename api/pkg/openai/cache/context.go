@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+type contextOptionsKeyType int
+
+const contextOptionsKey contextOptionsKeyType = iota
+
+// Options enable the response cache for the chat completions made with the
+// context
+type Options struct {
+	// Scope partitions the cache, answers are only shared within a scope such
+	// as an app
+	Scope string
+	// Prompt is the question asked, before it was enriched with knowledge. It's
+	// embedded to find the answers to similar questions.
+	Prompt string
+	// Knowledge are the knowledge results added to the prompt, answers are
+	// only reused when the same results were found
+	Knowledge []*types.Citation
+	// TTL and SimilarityThreshold override the server defaults when set
+	TTL                 time.Duration
+	SimilarityThreshold float64
+}
+
+func SetContextOptions(ctx context.Context, opts *Options) context.Context {
+	return context.WithValue(ctx, contextOptionsKey, opts)
+}
+
+func GetContextOptions(ctx context.Context) (*Options, bool) {
+	opts, ok := ctx.Value(contextOptionsKey).(*Options)
+	return opts, ok
+}
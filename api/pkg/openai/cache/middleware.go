@@ -0,0 +1,255 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime/debug"
+
+	"github.com/rs/zerolog/log"
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/helixml/helix/api/pkg/model"
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/openai/transport"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+var _ oai.Client = &Middleware{}
+
+// Middleware serves the chat completions from the response cache when the
+// context has cache options, the other requests are passed to the client
+type Middleware struct {
+	provider types.Provider
+	client   oai.Client
+	cache    *ResponseCache
+}
+
+func Wrap(provider types.Provider, client oai.Client, cache *ResponseCache) *Middleware {
+	return &Middleware{
+		provider: provider,
+		client:   client,
+		cache:    cache,
+	}
+}
+
+func (m *Middleware) APIKey() string {
+	return m.client.APIKey()
+}
+
+func (m *Middleware) ListModels(ctx context.Context) ([]model.OpenAIModel, error) {
+	return m.client.ListModels(ctx)
+}
+
+func (m *Middleware) lookup(ctx context.Context, request openai.ChatCompletionRequest) *lookup {
+	opts, ok := GetContextOptions(ctx)
+	if !ok {
+		return nil
+	}
+
+	l, err := m.cache.newLookup(m.provider, request, opts)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to hash request for the response cache, skipping it")
+		return nil
+	}
+	return l
+}
+
+func (m *Middleware) CreateChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	l := m.lookup(ctx, request)
+	if l == nil {
+		return m.client.CreateChatCompletion(ctx, request)
+	}
+
+	if resp, ok := m.cache.get(ctx, l); ok {
+		return resp, nil
+	}
+
+	resp, err := m.client.CreateChatCompletion(ctx, request)
+	if err != nil {
+		return resp, err
+	}
+
+	m.cache.set(l, resp)
+
+	return resp, nil
+}
+
+func (m *Middleware) CreateChatCompletionStream(ctx context.Context, request openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error) {
+	l := m.lookup(ctx, request)
+	if l == nil {
+		return m.client.CreateChatCompletionStream(ctx, request)
+	}
+
+	if resp, ok := m.cache.get(ctx, l); ok {
+		return replay(request, &resp)
+	}
+
+	upstream, err := m.client.CreateChatCompletionStream(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	downstream, downstreamWriter, err := transport.NewOpenAIStreamingAdapter(request)
+	if err != nil {
+		upstream.Close()
+		return nil, fmt.Errorf("failed to create streaming adapter: %w", err)
+	}
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error().Msgf("Recovered from panic: %v\n%s", r, debug.Stack())
+			}
+		}()
+
+		defer upstream.Close()
+		defer downstreamWriter.Close()
+
+		var (
+			resp      openai.ChatCompletionResponse
+			cacheable = true
+		)
+
+		for {
+			msg, err := upstream.Recv()
+			if err != nil {
+				if err != io.EOF {
+					log.Error().Err(err).Msg("failed to receive message from upstream stream")
+					cacheable = false
+				}
+				break
+			}
+
+			// Tool calls are streamed in fragments, the responses with them
+			// aren't put together so they aren't cached
+			if !appendChunk(&resp, &msg) {
+				cacheable = false
+			}
+
+			if err := transport.WriteChatCompletionStream(downstreamWriter, &msg); err != nil {
+				log.Error().Err(err).Msg("failed to write completion")
+			}
+		}
+
+		if cacheable {
+			m.cache.set(l, resp)
+		}
+	}()
+
+	return downstream, nil
+}
+
+// appendChunk adds the content of the chunk to the response, returning false
+// if the chunk has tool calls
+func appendChunk(resp *openai.ChatCompletionResponse, chunk *openai.ChatCompletionStreamResponse) bool {
+	if resp.ID == "" {
+		resp.ID = chunk.ID
+		resp.Object = "chat.completion"
+		resp.Created = chunk.Created
+		resp.Model = chunk.Model
+		resp.SystemFingerprint = chunk.SystemFingerprint
+	}
+
+	if chunk.Usage != nil {
+		resp.Usage = *chunk.Usage
+	}
+
+	if len(chunk.Choices) == 0 {
+		return true
+	}
+
+	if len(resp.Choices) == 0 {
+		resp.Choices = []openai.ChatCompletionChoice{
+			{Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}},
+		}
+	}
+
+	delta := chunk.Choices[0].Delta
+	resp.Choices[0].Message.Content += delta.Content
+	if chunk.Choices[0].FinishReason != "" {
+		resp.Choices[0].FinishReason = chunk.Choices[0].FinishReason
+	}
+
+	return len(delta.ToolCalls) == 0 && delta.FunctionCall == nil
+}
+
+// replay streams the cached response back in a single chunk
+func replay(request openai.ChatCompletionRequest, resp *openai.ChatCompletionResponse) (*openai.ChatCompletionStream, error) {
+	stream, writer, err := transport.NewOpenAIStreamingAdapter(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create streaming adapter: %w", err)
+	}
+	stream.SetHeader(resp.Header())
+
+	go func() {
+		defer writer.Close()
+
+		for _, chunk := range streamChunks(request, resp) {
+			if err := transport.WriteChatCompletionStream(writer, chunk); err != nil {
+				log.Error().Err(err).Msg("failed to write cached completion")
+				return
+			}
+		}
+	}()
+
+	return stream, nil
+}
+
+func streamChunks(request openai.ChatCompletionRequest, resp *openai.ChatCompletionResponse) []*openai.ChatCompletionStreamResponse {
+	chunk := &openai.ChatCompletionStreamResponse{
+		ID:                resp.ID,
+		Object:            "chat.completion.chunk",
+		Created:           resp.Created,
+		Model:             resp.Model,
+		SystemFingerprint: resp.SystemFingerprint,
+	}
+
+	for _, choice := range resp.Choices {
+		toolCalls := make([]openai.ToolCall, len(choice.Message.ToolCalls))
+		for i, toolCall := range choice.Message.ToolCalls {
+			index := i
+			toolCall.Index = &index
+			toolCalls[i] = toolCall
+		}
+
+		chunk.Choices = append(chunk.Choices, openai.ChatCompletionStreamChoice{
+			Index: choice.Index,
+			Delta: openai.ChatCompletionStreamChoiceDelta{
+				Role:         choice.Message.Role,
+				Content:      choice.Message.Content,
+				FunctionCall: choice.Message.FunctionCall,
+				ToolCalls:    toolCalls,
+			},
+			FinishReason: choice.FinishReason,
+		})
+	}
+
+	chunks := []*openai.ChatCompletionStreamResponse{chunk}
+
+	// Like the providers, the usage is sent in a last chunk without choices
+	// when the request asks for it
+	if request.StreamOptions != nil && request.StreamOptions.IncludeUsage {
+		usage := resp.Usage
+		chunks = append(chunks, &openai.ChatCompletionStreamResponse{
+			ID:      resp.ID,
+			Object:  "chat.completion.chunk",
+			Created: resp.Created,
+			Model:   resp.Model,
+			Choices: []openai.ChatCompletionStreamChoice{},
+			Usage:   &usage,
+		})
+	}
+
+	return chunks
+}
+
+// No-op, embeddings aren't cached
+func (m *Middleware) CreateEmbeddings(ctx context.Context, request openai.EmbeddingRequest) (openai.EmbeddingResponse, error) {
+	return m.client.CreateEmbeddings(ctx, request)
+}
+
+// No-op, rerank calls aren't cached
+func (m *Middleware) Rerank(ctx context.Context, request oai.RerankRequest) (oai.RerankResponse, error) {
+	return m.client.Rerank(ctx, request)
+}
@@ -7,15 +7,19 @@ import (
 )
 
 type (
-	contextValuesKeyType int
-	contextAppIDKeyType  int
-	stepKeyType          int
+	contextValuesKeyType         int
+	contextAppIDKeyType          int
+	stepKeyType                  int
+	contextPriorityClassKeyType  int
+	contextRunnerSelectorKeyType int
 )
 
 var (
-	contextValuesKey contextValuesKeyType
-	contextAppIDKey  contextAppIDKeyType
-	stepKey          stepKeyType
+	contextValuesKey         contextValuesKeyType
+	contextAppIDKey          contextAppIDKeyType
+	stepKey                  stepKeyType
+	contextPriorityClassKey  contextPriorityClassKeyType
+	contextRunnerSelectorKey contextRunnerSelectorKeyType
 )
 
 const (
@@ -46,6 +50,35 @@ func GetContextAppID(ctx context.Context) (string, bool) {
 	return appID, ok
 }
 
+// SetContextPriorityClass sets the scheduler priority class of the requests
+// made with this context
+func SetContextPriorityClass(ctx context.Context, class types.WorkloadPriorityClass) context.Context {
+	return context.WithValue(ctx, contextPriorityClassKey, class)
+}
+
+// GetContextPriorityClass returns the priority class of the context, API
+// requests by default
+func GetContextPriorityClass(ctx context.Context) types.WorkloadPriorityClass {
+	class, ok := ctx.Value(contextPriorityClassKey).(types.WorkloadPriorityClass)
+	if !ok || class == "" {
+		return types.WorkloadPriorityClassAPI
+	}
+	return class
+}
+
+// SetContextRunnerSelector restricts the runners the requests made with this
+// context are scheduled on
+func SetContextRunnerSelector(ctx context.Context, selector *types.RunnerSelector) context.Context {
+	return context.WithValue(ctx, contextRunnerSelectorKey, selector)
+}
+
+// GetContextRunnerSelector returns the runner selector of the context, nil
+// when the requests can run on any runner
+func GetContextRunnerSelector(ctx context.Context) *types.RunnerSelector {
+	selector, _ := ctx.Value(contextRunnerSelectorKey).(*types.RunnerSelector)
+	return selector
+}
+
 func SetContextValues(ctx context.Context, vals *ContextValues) context.Context {
 	// Check if the context already has values, if it does,
 	// preserve the OriginalRequest
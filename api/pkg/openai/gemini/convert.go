@@ -0,0 +1,507 @@
+package gemini
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+type generateContentRequest struct {
+	Contents          []content         `json:"contents"`
+	SystemInstruction *content          `json:"systemInstruction,omitempty"`
+	Tools             []tool            `json:"tools,omitempty"`
+	ToolConfig        *toolConfig       `json:"toolConfig,omitempty"`
+	GenerationConfig  *generationConfig `json:"generationConfig,omitempty"`
+}
+
+type content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []part `json:"parts"`
+}
+
+// part is a text, inline data, file, function call or function response part
+type part struct {
+	Text             string            `json:"text,omitempty"`
+	InlineData       *blob             `json:"inlineData,omitempty"`
+	FileData         *fileData         `json:"fileData,omitempty"`
+	FunctionCall     *functionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *functionResponse `json:"functionResponse,omitempty"`
+}
+
+type blob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+type fileData struct {
+	MimeType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
+type functionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type functionResponse struct {
+	ID       string          `json:"id,omitempty"`
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response"`
+}
+
+type tool struct {
+	FunctionDeclarations []functionDeclaration `json:"functionDeclarations"`
+}
+
+type functionDeclaration struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters,omitempty"`
+}
+
+type toolConfig struct {
+	FunctionCallingConfig functionCallingConfig `json:"functionCallingConfig"`
+}
+
+type functionCallingConfig struct {
+	Mode                 string   `json:"mode"`
+	AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+}
+
+type generationConfig struct {
+	Temperature      *float32 `json:"temperature,omitempty"`
+	TopP             *float32 `json:"topP,omitempty"`
+	MaxOutputTokens  int      `json:"maxOutputTokens,omitempty"`
+	StopSequences    []string `json:"stopSequences,omitempty"`
+	ResponseMimeType string   `json:"responseMimeType,omitempty"`
+}
+
+type generateContentResponse struct {
+	Candidates    []candidate    `json:"candidates"`
+	UsageMetadata *usageMetadata `json:"usageMetadata,omitempty"`
+	ModelVersion  string         `json:"modelVersion"`
+	ResponseID    string         `json:"responseId"`
+}
+
+type candidate struct {
+	Content      content `json:"content"`
+	FinishReason string  `json:"finishReason"`
+	Index        int     `json:"index"`
+}
+
+type usageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
+}
+
+type batchEmbedRequest struct {
+	Requests []embedRequest `json:"requests"`
+}
+
+type embedRequest struct {
+	Model                string  `json:"model"`
+	Content              content `json:"content"`
+	OutputDimensionality int     `json:"outputDimensionality,omitempty"`
+}
+
+type batchEmbedResponse struct {
+	Embeddings []struct {
+		Values []float32 `json:"values"`
+	} `json:"embeddings"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
+
+const (
+	roleUser  = "user"
+	roleModel = "model"
+)
+
+func convertRequest(req *openai.ChatCompletionRequest) (*generateContentRequest, error) {
+	system, contents, err := convertMessages(req.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &generationConfig{
+		MaxOutputTokens: req.MaxTokens,
+		StopSequences:   req.Stop,
+	}
+	converted := &generateContentRequest{
+		Contents:          contents,
+		SystemInstruction: system,
+		GenerationConfig:  config,
+	}
+
+	// The OpenAI client can't tell an unset temperature from zero either
+	if req.Temperature != 0 {
+		temperature := req.Temperature
+		config.Temperature = &temperature
+	}
+	if req.TopP != 0 {
+		topP := req.TopP
+		config.TopP = &topP
+	}
+	if req.ResponseFormat != nil && req.ResponseFormat.Type == openai.ChatCompletionResponseFormatTypeJSONObject {
+		config.ResponseMimeType = "application/json"
+	}
+
+	var declarations []functionDeclaration
+	for _, t := range req.Tools {
+		if t.Function == nil {
+			continue
+		}
+		parameters, err := convertSchema(t.Function.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameters of tool %s: %w", t.Function.Name, err)
+		}
+		declarations = append(declarations, functionDeclaration{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			Parameters:  parameters,
+		})
+	}
+	if len(declarations) > 0 {
+		converted.Tools = []tool{{FunctionDeclarations: declarations}}
+	}
+
+	converted.ToolConfig, err = convertToolChoice(req.ToolChoice)
+	if err != nil {
+		return nil, err
+	}
+
+	return converted, nil
+}
+
+// unsupportedSchemaKeys are the JSON schema keywords the Gemini API rejects
+var unsupportedSchemaKeys = []string{"$schema", "additionalProperties", "strict"}
+
+// convertSchema removes the JSON schema keywords Gemini doesn't support from
+// the function parameters
+func convertSchema(schema any) (any, error) {
+	if schema == nil {
+		return nil, nil
+	}
+
+	bts, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	var converted any
+	if err := json.Unmarshal(bts, &converted); err != nil {
+		return nil, err
+	}
+
+	var clean func(v any)
+	clean = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for _, key := range unsupportedSchemaKeys {
+				delete(v, key)
+			}
+			for _, value := range v {
+				clean(value)
+			}
+		case []any:
+			for _, value := range v {
+				clean(value)
+			}
+		}
+	}
+	clean(converted)
+
+	// Functions without parameters are declared without a schema
+	if m, ok := converted.(map[string]any); ok {
+		if properties, ok := m["properties"].(map[string]any); ok && len(properties) == 0 {
+			return nil, nil
+		}
+	}
+
+	return converted, nil
+}
+
+// convertToolChoice converts the tool choice, which is either one of the
+// auto, required and none strings or the function to call
+func convertToolChoice(choice any) (*toolConfig, error) {
+	switch c := choice.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		switch c {
+		case "", "auto":
+			return nil, nil
+		case "required":
+			return &toolConfig{FunctionCallingConfig: functionCallingConfig{Mode: "ANY"}}, nil
+		case "none":
+			return &toolConfig{FunctionCallingConfig: functionCallingConfig{Mode: "NONE"}}, nil
+		default:
+			return nil, fmt.Errorf("unknown tool choice '%s'", c)
+		}
+	default:
+		bts, err := json.Marshal(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tool choice: %w", err)
+		}
+		var function openai.ToolChoice
+		if err := json.Unmarshal(bts, &function); err != nil || function.Function.Name == "" {
+			return nil, fmt.Errorf("invalid tool choice: %s", string(bts))
+		}
+		return &toolConfig{FunctionCallingConfig: functionCallingConfig{
+			Mode:                 "ANY",
+			AllowedFunctionNames: []string{function.Function.Name},
+		}}, nil
+	}
+}
+
+// convertMessages moves the system messages to the system instruction and the
+// tool results into user contents. Function responses are matched to the
+// function calls by name so the names of the tool calls are looked up.
+func convertMessages(messages []openai.ChatCompletionMessage) (*content, []content, error) {
+	var (
+		system    []part
+		contents  []content
+		toolNames = make(map[string]string)
+	)
+
+	for _, m := range messages {
+		var (
+			role  string
+			parts []part
+		)
+
+		switch m.Role {
+		case openai.ChatMessageRoleSystem, "developer":
+			system = append(system, part{Text: messageText(&m)})
+			continue
+		case openai.ChatMessageRoleUser:
+			role = roleUser
+			var err error
+			parts, err = userParts(&m)
+			if err != nil {
+				return nil, nil, err
+			}
+		case openai.ChatMessageRoleAssistant:
+			role = roleModel
+			if text := messageText(&m); text != "" {
+				parts = append(parts, part{Text: text})
+			}
+			for _, call := range m.ToolCalls {
+				args := json.RawMessage(call.Function.Arguments)
+				if len(args) == 0 {
+					args = json.RawMessage("{}")
+				}
+				if !json.Valid(args) {
+					return nil, nil, fmt.Errorf("invalid arguments of tool call %s: %s", call.ID, call.Function.Arguments)
+				}
+				toolNames[call.ID] = call.Function.Name
+				parts = append(parts, part{FunctionCall: &functionCall{Name: call.Function.Name, Args: args}})
+			}
+		case openai.ChatMessageRoleTool:
+			role = roleUser
+			name, ok := toolNames[m.ToolCallID]
+			if !ok {
+				name = m.Name
+			}
+			if name == "" {
+				return nil, nil, fmt.Errorf("no tool call found for tool result %s", m.ToolCallID)
+			}
+			parts = []part{{FunctionResponse: &functionResponse{
+				Name:     name,
+				Response: toolResponse(messageText(&m)),
+			}}}
+		default:
+			return nil, nil, fmt.Errorf("unsupported message role '%s'", m.Role)
+		}
+
+		if len(parts) == 0 {
+			continue
+		}
+
+		// Consecutive contents of a role are merged, parallel function
+		// responses have to be sent together
+		if n := len(contents); n > 0 && contents[n-1].Role == role {
+			contents[n-1].Parts = append(contents[n-1].Parts, parts...)
+			continue
+		}
+		contents = append(contents, content{Role: role, Parts: parts})
+	}
+
+	if len(system) == 0 {
+		return nil, contents, nil
+	}
+	return &content{Parts: system}, contents, nil
+}
+
+// toolResponse returns the tool result as the object Gemini expects, results
+// that aren't JSON objects are wrapped
+func toolResponse(result string) json.RawMessage {
+	trimmed := strings.TrimSpace(result)
+	if strings.HasPrefix(trimmed, "{") && json.Valid([]byte(trimmed)) {
+		return json.RawMessage(trimmed)
+	}
+
+	bts, _ := json.Marshal(map[string]string{"content": result})
+	return bts
+}
+
+func messageText(m *openai.ChatCompletionMessage) string {
+	if len(m.MultiContent) == 0 {
+		return m.Content
+	}
+
+	var parts []string
+	for _, part := range m.MultiContent {
+		if part.Type == openai.ChatMessagePartTypeText {
+			parts = append(parts, part.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func userParts(m *openai.ChatCompletionMessage) ([]part, error) {
+	if len(m.MultiContent) == 0 {
+		if m.Content == "" {
+			return nil, nil
+		}
+		return []part{{Text: m.Content}}, nil
+	}
+
+	parts := make([]part, 0, len(m.MultiContent))
+	for _, p := range m.MultiContent {
+		switch p.Type {
+		case openai.ChatMessagePartTypeText:
+			parts = append(parts, part{Text: p.Text})
+		case openai.ChatMessagePartTypeImageURL:
+			if p.ImageURL == nil {
+				continue
+			}
+			converted, err := convertImageURL(p.ImageURL.URL)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, converted)
+		default:
+			return nil, fmt.Errorf("unsupported content part type '%s'", p.Type)
+		}
+	}
+	return parts, nil
+}
+
+// convertImageURL converts a URL or a base64 data URL like
+// data:image/png;base64,iVBORw0... to a part
+func convertImageURL(url string) (part, error) {
+	data, ok := strings.CutPrefix(url, "data:")
+	if !ok {
+		return part{FileData: &fileData{FileURI: url}}, nil
+	}
+
+	mimeType, encoded, ok := strings.Cut(data, ";base64,")
+	if !ok {
+		return part{}, fmt.Errorf("only base64 encoded data URLs are supported")
+	}
+	return part{InlineData: &blob{MimeType: mimeType, Data: encoded}}, nil
+}
+
+func convertResponse(model string, resp *generateContentResponse) openai.ChatCompletionResponse {
+	converted := openai.ChatCompletionResponse{
+		ID:      resp.ResponseID,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+	}
+	if converted.ID == "" {
+		converted.ID = newResponseID()
+	}
+	if resp.ModelVersion != "" {
+		converted.Model = resp.ModelVersion
+	}
+
+	for _, c := range resp.Candidates {
+		msg := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}
+		for _, p := range c.Content.Parts {
+			if p.FunctionCall != nil {
+				msg.ToolCalls = append(msg.ToolCalls, convertFunctionCall(p.FunctionCall, nil))
+				continue
+			}
+			msg.Content += p.Text
+		}
+
+		converted.Choices = append(converted.Choices, openai.ChatCompletionChoice{
+			Index:        c.Index,
+			Message:      msg,
+			FinishReason: finishReason(c.FinishReason, len(msg.ToolCalls) > 0),
+		})
+	}
+
+	if resp.UsageMetadata != nil {
+		converted.Usage = convertUsage(resp.UsageMetadata)
+	}
+
+	return converted
+}
+
+// convertFunctionCall converts a function call to a tool call, Gemini doesn't
+// always identify the calls so they get an ID for the tool results to refer to
+func convertFunctionCall(call *functionCall, index *int) openai.ToolCall {
+	id := call.ID
+	if id == "" {
+		id = "call_" + strings.ReplaceAll(uuid.New().String(), "-", "")
+	}
+
+	args := string(call.Args)
+	if args == "" {
+		args = "{}"
+	}
+
+	return openai.ToolCall{
+		Index: index,
+		ID:    id,
+		Type:  openai.ToolTypeFunction,
+		Function: openai.FunctionCall{
+			Name:      call.Name,
+			Arguments: args,
+		},
+	}
+}
+
+func convertUsage(usage *usageMetadata) openai.Usage {
+	return openai.Usage{
+		PromptTokens:     usage.PromptTokenCount,
+		CompletionTokens: usage.CandidatesTokenCount,
+		TotalTokens:      usage.TotalTokenCount,
+	}
+}
+
+func finishReason(reason string, toolCalls bool) openai.FinishReason {
+	switch reason {
+	case "":
+		return ""
+	case "STOP":
+		if toolCalls {
+			return openai.FinishReasonToolCalls
+		}
+		return openai.FinishReasonStop
+	case "MAX_TOKENS":
+		return openai.FinishReasonLength
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII", "IMAGE_SAFETY":
+		return openai.FinishReasonContentFilter
+	default:
+		return openai.FinishReasonStop
+	}
+}
+
+func newResponseID() string {
+	return "chatcmpl-" + strings.ReplaceAll(uuid.New().String(), "-", "")
+}
@@ -0,0 +1,343 @@
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/avast/retry-go/v4"
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/helixml/helix/api/pkg/model"
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/openai/transport"
+)
+
+const (
+	DefaultBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+	retries             = 3
+	delayBetweenRetries = time.Second
+)
+
+var _ oai.Client = &Client{}
+
+// Client is an adapter translating the OpenAI chat completions and embeddings
+// to the Gemini API
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+}
+
+func New(apiKey, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		httpClient: http.DefaultClient,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+	}
+}
+
+func (c *Client) APIKey() string {
+	return c.apiKey
+}
+
+// modelPath returns the path of the model, the models are named models/<model>
+// in the Gemini API but requests may leave the prefix out
+func modelPath(name string) string {
+	return "/models/" + url.PathEscape(strings.TrimPrefix(name, "models/"))
+}
+
+func (c *Client) CreateChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (resp openai.ChatCompletionResponse, err error) {
+	body, err := convertRequest(&request)
+	if err != nil {
+		return resp, err
+	}
+
+	err = retry.Do(func() error {
+		httpResp, err := c.do(ctx, modelPath(request.Model)+":generateContent", body)
+		if err != nil {
+			if !retryable(err) {
+				return retry.Unrecoverable(err)
+			}
+			return err
+		}
+		defer httpResp.Body.Close()
+
+		var generateResp generateContentResponse
+		if err := json.NewDecoder(httpResp.Body).Decode(&generateResp); err != nil {
+			return fmt.Errorf("failed to decode Gemini response: %w", err)
+		}
+
+		resp = convertResponse(request.Model, &generateResp)
+		return nil
+	},
+		retry.Attempts(retries),
+		retry.Delay(delayBetweenRetries),
+		retry.Context(ctx),
+		retry.LastErrorOnly(true),
+	)
+
+	return resp, err
+}
+
+func (c *Client) CreateChatCompletionStream(ctx context.Context, request openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error) {
+	body, err := convertRequest(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := c.do(ctx, modelPath(request.Model)+":streamGenerateContent?alt=sse", body)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, writer, err := transport.NewOpenAIStreamingAdapter(request)
+	if err != nil {
+		httpResp.Body.Close()
+		return nil, fmt.Errorf("failed to create streaming adapter: %w", err)
+	}
+
+	go func() {
+		defer httpResp.Body.Close()
+
+		s := &streamState{
+			request: &request,
+			writer:  writer,
+			id:      newResponseID(),
+			created: time.Now().Unix(),
+		}
+		err := transport.ReadServerSentEvents(httpResp.Body, s.handleEvent)
+		if err == nil {
+			err = s.writeUsage()
+		}
+		// Closing with a nil error ends the stream with io.EOF
+		_ = writer.CloseWithError(err)
+	}()
+
+	return stream, nil
+}
+
+func (c *Client) ListModels(ctx context.Context) ([]model.OpenAIModel, error) {
+	var models []model.OpenAIModel
+
+	pageToken := ""
+	for {
+		path := "/models?pageSize=1000"
+		if pageToken != "" {
+			path += "&pageToken=" + url.QueryEscape(pageToken)
+		}
+
+		modelsResp, err := c.listModels(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range modelsResp.Models {
+			if !supports(m.SupportedGenerationMethods, "generateContent") {
+				continue
+			}
+			models = append(models, model.OpenAIModel{
+				ID:          strings.TrimPrefix(m.Name, "models/"),
+				Object:      "model",
+				OwnedBy:     "google",
+				Name:        m.DisplayName,
+				Description: m.Description,
+				Type:        "chat",
+			})
+		}
+
+		if modelsResp.NextPageToken == "" {
+			return models, nil
+		}
+		pageToken = modelsResp.NextPageToken
+	}
+}
+
+type listModelsResponse struct {
+	Models []struct {
+		Name                       string   `json:"name"`
+		DisplayName                string   `json:"displayName"`
+		Description                string   `json:"description"`
+		SupportedGenerationMethods []string `json:"supportedGenerationMethods"`
+	} `json:"models"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+func (c *Client) listModels(ctx context.Context, path string) (*listModelsResponse, error) {
+	httpResp, err := c.doRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Gemini models: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	var modelsResp listModelsResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&modelsResp); err != nil {
+		return nil, fmt.Errorf("failed to decode Gemini models: %w", err)
+	}
+	return &modelsResp, nil
+}
+
+func supports(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) CreateEmbeddings(ctx context.Context, request openai.EmbeddingRequest) (openai.EmbeddingResponse, error) {
+	inputs, err := embeddingInputs(request.Input)
+	if err != nil {
+		return openai.EmbeddingResponse{}, err
+	}
+
+	modelName := "models/" + strings.TrimPrefix(string(request.Model), "models/")
+
+	body := batchEmbedRequest{}
+	for _, input := range inputs {
+		embedReq := embedRequest{
+			Model:   modelName,
+			Content: content{Parts: []part{{Text: input}}},
+		}
+		if request.Dimensions > 0 {
+			embedReq.OutputDimensionality = request.Dimensions
+		}
+		body.Requests = append(body.Requests, embedReq)
+	}
+
+	httpResp, err := c.do(ctx, modelPath(string(request.Model))+":batchEmbedContents", body)
+	if err != nil {
+		return openai.EmbeddingResponse{}, err
+	}
+	defer httpResp.Body.Close()
+
+	var embedResp batchEmbedResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&embedResp); err != nil {
+		return openai.EmbeddingResponse{}, fmt.Errorf("failed to decode Gemini embeddings: %w", err)
+	}
+
+	resp := openai.EmbeddingResponse{
+		Object: "list",
+		Model:  request.Model,
+		Data:   make([]openai.Embedding, 0, len(embedResp.Embeddings)),
+	}
+	for i, embedding := range embedResp.Embeddings {
+		resp.Data = append(resp.Data, openai.Embedding{
+			Object:    "embedding",
+			Embedding: embedding.Values,
+			Index:     i,
+		})
+	}
+
+	return resp, nil
+}
+
+// embeddingInputs returns the texts to embed, the input is either a string or
+// a list of them
+func embeddingInputs(input any) ([]string, error) {
+	switch v := input.(type) {
+	case string:
+		return []string{v}, nil
+	case []string:
+		return v, nil
+	case []any:
+		inputs := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("unsupported embeddings input of type %T", item)
+			}
+			inputs = append(inputs, s)
+		}
+		return inputs, nil
+	default:
+		return nil, fmt.Errorf("unsupported embeddings input of type %T", input)
+	}
+}
+
+func (c *Client) Rerank(_ context.Context, _ oai.RerankRequest) (oai.RerankResponse, error) {
+	return oai.RerankResponse{}, fmt.Errorf("%w by the Gemini API", oai.ErrRerankNotSupported)
+}
+
+func (c *Client) do(ctx context.Context, path string, body any) (*http.Response, error) {
+	bts, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Gemini request: %w", err)
+	}
+	return c.doRequest(ctx, http.MethodPost, path, bytes.NewReader(bts))
+}
+
+// doRequest sends the request to the API, the errors it answers with are
+// returned as OpenAI API errors so they are handled like the ones of the other
+// providers
+func (c *Client) doRequest(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Gemini request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to Gemini: %w", err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		bts, _ := io.ReadAll(resp.Body)
+		return nil, apiError(resp.StatusCode, bts)
+	}
+
+	return resp, nil
+}
+
+func apiError(status int, body []byte) *openai.APIError {
+	apiErr := &openai.APIError{
+		HTTPStatusCode: status,
+		Message:        strings.TrimSpace(string(body)),
+	}
+
+	// Errors are sometimes wrapped in a list
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var errResps []errorResponse
+		if err := json.Unmarshal(body, &errResps); err == nil && len(errResps) > 0 {
+			body, _ = json.Marshal(errResps[0])
+		}
+	}
+
+	var errResp errorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Message != "" {
+		apiErr.Message = errResp.Error.Message
+		apiErr.Type = errResp.Error.Status
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(status)
+	}
+
+	return apiErr
+}
+
+// retryable returns whether the request may succeed when sent again, client
+// errors other than rate limits won't
+func retryable(err error) bool {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatusCode == http.StatusTooManyRequests || apiErr.HTTPStatusCode >= http.StatusInternalServerError
+	}
+	return true
+}
@@ -0,0 +1,224 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, path string, handler func(t *testing.T, body []byte, w http.ResponseWriter)) *Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, path, r.URL.RequestURI())
+		require.Equal(t, "secret", r.Header.Get("x-goog-api-key"))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		handler(t, body, w)
+	}))
+	t.Cleanup(srv.Close)
+
+	return New("secret", srv.URL+"/v1beta")
+}
+
+func TestClient_CreateChatCompletion(t *testing.T) {
+	client := newTestServer(t, "/v1beta/models/gemini-2.0-flash:generateContent", func(t *testing.T, body []byte, w http.ResponseWriter) {
+		var req generateContentRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+
+		require.Equal(t, &content{Parts: []part{{Text: "You are a weather bot"}}}, req.SystemInstruction)
+		require.Equal(t, &toolConfig{FunctionCallingConfig: functionCallingConfig{
+			Mode:                 "ANY",
+			AllowedFunctionNames: []string{"get_weather"},
+		}}, req.ToolConfig)
+		require.Equal(t, 256, req.GenerationConfig.MaxOutputTokens)
+
+		// Unsupported schema keywords are removed
+		require.Len(t, req.Tools, 1)
+		require.Equal(t, map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"city": map[string]any{"type": "string"}},
+		}, req.Tools[0].FunctionDeclarations[0].Parameters)
+
+		// Function responses are named after the function calls
+		require.Len(t, req.Contents, 3)
+		require.Equal(t, roleModel, req.Contents[1].Role)
+		require.Equal(t, "get_weather", req.Contents[1].Parts[0].FunctionCall.Name)
+		require.Equal(t, roleUser, req.Contents[2].Role)
+		require.Equal(t, "get_weather", req.Contents[2].Parts[0].FunctionResponse.Name)
+		require.JSONEq(t, `{"content":"rainy"}`, string(req.Contents[2].Parts[0].FunctionResponse.Response))
+
+		_, _ = w.Write([]byte(`{
+			"candidates": [{
+				"content": {"role": "model", "parts": [{"functionCall": {"name": "get_forecast", "args": {"city": "London"}}}]},
+				"finishReason": "STOP",
+				"index": 0
+			}],
+			"usageMetadata": {"promptTokenCount": 100, "candidatesTokenCount": 20, "totalTokenCount": 120},
+			"modelVersion": "gemini-2.0-flash-001",
+			"responseId": "resp_1"
+		}`))
+	})
+
+	resp, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:     "gemini-2.0-flash",
+		MaxTokens: 256,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: "You are a weather bot"},
+			{Role: openai.ChatMessageRoleUser, Content: "What's the weather in London?"},
+			{
+				Role: openai.ChatMessageRoleAssistant,
+				ToolCalls: []openai.ToolCall{
+					{ID: "call_1", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "get_weather", Arguments: `{"city":"London"}`}},
+				},
+			},
+			{Role: openai.ChatMessageRoleTool, ToolCallID: "call_1", Content: "rainy"},
+		},
+		Tools: []openai.Tool{
+			{
+				Type: openai.ToolTypeFunction,
+				Function: &openai.FunctionDefinition{
+					Name: "get_weather",
+					Parameters: map[string]any{
+						"$schema":              "http://json-schema.org/draft-07/schema#",
+						"type":                 "object",
+						"properties":           map[string]any{"city": map[string]any{"type": "string"}},
+						"additionalProperties": false,
+					},
+				},
+			},
+		},
+		ToolChoice: openai.ToolChoice{Type: openai.ToolTypeFunction, Function: openai.ToolFunction{Name: "get_weather"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "resp_1", resp.ID)
+	require.Equal(t, "gemini-2.0-flash-001", resp.Model)
+	require.Len(t, resp.Choices, 1)
+	require.Equal(t, openai.FinishReasonToolCalls, resp.Choices[0].FinishReason)
+
+	toolCalls := resp.Choices[0].Message.ToolCalls
+	require.Len(t, toolCalls, 1)
+	require.NotEmpty(t, toolCalls[0].ID)
+	require.Equal(t, "get_forecast", toolCalls[0].Function.Name)
+	require.JSONEq(t, `{"city":"London"}`, toolCalls[0].Function.Arguments)
+	require.Equal(t, openai.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120}, resp.Usage)
+}
+
+func TestClient_CreateChatCompletion_Error(t *testing.T) {
+	client := newTestServer(t, "/v1beta/models/gemini-2.0-flash:generateContent", func(_ *testing.T, _ []byte, w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`[{"error":{"code":400,"message":"API key not valid","status":"INVALID_ARGUMENT"}}]`))
+	})
+
+	_, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:    "gemini-2.0-flash",
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "hi"}},
+	})
+
+	var apiErr *openai.APIError
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusBadRequest, apiErr.HTTPStatusCode)
+	require.Equal(t, "INVALID_ARGUMENT", apiErr.Type)
+	require.Equal(t, "API key not valid", apiErr.Message)
+}
+
+func TestClient_CreateChatCompletionStream(t *testing.T) {
+	responses := []string{
+		`{"candidates":[{"content":{"role":"model","parts":[{"text":"Checking"}]}}],"modelVersion":"gemini-2.0-flash-001"}`,
+		`{"candidates":[{"content":{"role":"model","parts":[{"text":" now."},{"functionCall":{"name":"get_weather","args":{"city":"London"}}}]}}]}`,
+		`{"candidates":[{"content":{"role":"model","parts":[{"text":""}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":50,"candidatesTokenCount":30,"totalTokenCount":80}}`,
+	}
+
+	client := newTestServer(t, "/v1beta/models/gemini-2.0-flash:streamGenerateContent?alt=sse", func(_ *testing.T, _ []byte, w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, resp := range responses {
+			_, _ = fmt.Fprintf(w, "data: %s\r\n\r\n", resp)
+		}
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model:         "gemini-2.0-flash",
+		Messages:      []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "What's the weather in London?"}},
+		Stream:        true,
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
+	})
+	require.NoError(t, err)
+	defer stream.Close()
+
+	var (
+		role         string
+		content      string
+		toolCalls    []openai.ToolCall
+		finishReason openai.FinishReason
+		usage        *openai.Usage
+	)
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		require.Equal(t, "gemini-2.0-flash-001", chunk.Model)
+
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		delta := chunk.Choices[0].Delta
+		role += delta.Role
+		content += delta.Content
+		toolCalls = append(toolCalls, delta.ToolCalls...)
+		if chunk.Choices[0].FinishReason != "" {
+			finishReason = chunk.Choices[0].FinishReason
+		}
+	}
+
+	require.Equal(t, openai.ChatMessageRoleAssistant, role)
+	require.Equal(t, "Checking now.", content)
+	require.Len(t, toolCalls, 1)
+	require.Equal(t, 0, *toolCalls[0].Index)
+	require.Equal(t, "get_weather", toolCalls[0].Function.Name)
+	require.JSONEq(t, `{"city":"London"}`, toolCalls[0].Function.Arguments)
+	require.Equal(t, openai.FinishReasonToolCalls, finishReason)
+	require.Equal(t, &openai.Usage{PromptTokens: 50, CompletionTokens: 30, TotalTokens: 80}, usage)
+}
+
+func TestClient_CreateEmbeddings(t *testing.T) {
+	client := newTestServer(t, "/v1beta/models/text-embedding-004:batchEmbedContents", func(t *testing.T, body []byte, w http.ResponseWriter) {
+		var req batchEmbedRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+		require.Len(t, req.Requests, 2)
+		require.Equal(t, "models/text-embedding-004", req.Requests[0].Model)
+		require.Equal(t, "hello", req.Requests[0].Content.Parts[0].Text)
+		require.Equal(t, "world", req.Requests[1].Content.Parts[0].Text)
+
+		_, _ = w.Write([]byte(`{"embeddings":[{"values":[0.1,0.2]},{"values":[0.3,0.4]}]}`))
+	})
+
+	resp, err := client.CreateEmbeddings(context.Background(), openai.EmbeddingRequest{
+		Model: "text-embedding-004",
+		Input: []string{"hello", "world"},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Data, 2)
+	require.Equal(t, []float32{0.3, 0.4}, resp.Data[1].Embedding)
+	require.Equal(t, 1, resp.Data[1].Index)
+}
+
+func Test_finishReason(t *testing.T) {
+	require.Equal(t, openai.FinishReasonStop, finishReason("STOP", false))
+	require.Equal(t, openai.FinishReasonToolCalls, finishReason("STOP", true))
+	require.Equal(t, openai.FinishReasonLength, finishReason("MAX_TOKENS", false))
+	require.Equal(t, openai.FinishReasonContentFilter, finishReason("SAFETY", false))
+	require.Equal(t, openai.FinishReason(""), finishReason("", false))
+}
@@ -0,0 +1,97 @@
+package gemini
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/helixml/helix/api/pkg/openai/transport"
+)
+
+// streamState translates the streamed responses to OpenAI chunks, each of them
+// is a complete response with the parts generated since the previous one
+type streamState struct {
+	request *openai.ChatCompletionRequest
+	writer  io.Writer
+
+	id        string
+	created   int64
+	model     string
+	started   bool
+	toolCalls int
+	usage     *usageMetadata
+}
+
+func (s *streamState) handleEvent(data []byte) error {
+	var resp generateContentResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("failed to decode Gemini stream response: %w", err)
+	}
+
+	if s.model == "" {
+		s.model = s.request.Model
+		if resp.ModelVersion != "" {
+			s.model = resp.ModelVersion
+		}
+	}
+	if resp.UsageMetadata != nil {
+		s.usage = resp.UsageMetadata
+	}
+
+	if len(resp.Candidates) == 0 {
+		return nil
+	}
+	c := resp.Candidates[0]
+
+	delta := openai.ChatCompletionStreamChoiceDelta{}
+	if !s.started {
+		delta.Role = openai.ChatMessageRoleAssistant
+		s.started = true
+	}
+
+	for _, p := range c.Content.Parts {
+		if p.FunctionCall != nil {
+			// Function calls aren't split across responses, they are sent
+			// whole in a single chunk
+			index := s.toolCalls
+			delta.ToolCalls = append(delta.ToolCalls, convertFunctionCall(p.FunctionCall, &index))
+			s.toolCalls++
+			continue
+		}
+		delta.Content += p.Text
+	}
+
+	return transport.WriteChatCompletionStream(s.writer, &openai.ChatCompletionStreamResponse{
+		ID:      s.id,
+		Object:  "chat.completion.chunk",
+		Created: s.created,
+		Model:   s.model,
+		Choices: []openai.ChatCompletionStreamChoice{
+			{
+				Index:        0,
+				Delta:        delta,
+				FinishReason: finishReason(c.FinishReason, s.toolCalls > 0),
+			},
+		},
+	})
+}
+
+// writeUsage sends the usage in a last chunk without choices, like OpenAI does
+// when the request asks for it
+func (s *streamState) writeUsage() error {
+	if s.request.StreamOptions == nil || !s.request.StreamOptions.IncludeUsage || s.usage == nil {
+		return nil
+	}
+
+	usage := convertUsage(s.usage)
+	return transport.WriteChatCompletionStream(s.writer, &openai.ChatCompletionStreamResponse{
+		ID:      s.id,
+		Object:  "chat.completion.chunk",
+		Created: s.created,
+		Model:   s.model,
+		Choices: []openai.ChatCompletionStreamChoice{},
+		Usage:   &usage,
+	})
+}
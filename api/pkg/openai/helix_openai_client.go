@@ -62,6 +62,22 @@ func ListModels(_ context.Context) ([]model.OpenAIModel, error) {
 		})
 	}
 
+	vllmModels, err := model.GetDefaultVLLMModels()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vLLM models: %w", err)
+	}
+	for _, m := range vllmModels {
+		helixModels = append(helixModels, model.OpenAIModel{
+			ID:          m.ModelName().String(),
+			Object:      "model",
+			OwnedBy:     "helix",
+			Name:        m.GetHumanReadableName(),
+			Description: m.GetDescription(),
+			Hide:        m.GetHidden(),
+			Type:        "text",
+		})
+	}
+
 	return helixModels, nil
 }
 
@@ -122,12 +138,14 @@ func (c *InternalHelixServer) CreateChatCompletion(requestCtx context.Context, r
 
 	// Enqueue the request, it will be picked up by the runner
 	err = c.enqueueRequest(&types.RunnerLLMInferenceRequest{
-		RequestID:     requestID,
-		CreatedAt:     time.Now(),
-		OwnerID:       vals.OwnerID,
-		SessionID:     vals.SessionID,
-		InteractionID: vals.InteractionID,
-		Request:       &request,
+		RequestID:      requestID,
+		CreatedAt:      time.Now(),
+		PriorityClass:  GetContextPriorityClass(ctx),
+		RunnerSelector: GetContextRunnerSelector(ctx),
+		OwnerID:        vals.OwnerID,
+		SessionID:      vals.SessionID,
+		InteractionID:  vals.InteractionID,
+		Request:        &request,
 	})
 	if err != nil {
 		return openai.ChatCompletionResponse{}, fmt.Errorf("error enqueuing request: %w", err)
@@ -242,12 +260,14 @@ func (c *InternalHelixServer) CreateChatCompletionStream(ctx context.Context, re
 
 	// Enqueue the request, it will be picked up by the runner
 	err = c.enqueueRequest(&types.RunnerLLMInferenceRequest{
-		RequestID:     requestID,
-		CreatedAt:     time.Now(),
-		OwnerID:       vals.OwnerID,
-		SessionID:     vals.SessionID,
-		InteractionID: vals.InteractionID,
-		Request:       &request,
+		RequestID:      requestID,
+		CreatedAt:      time.Now(),
+		PriorityClass:  GetContextPriorityClass(ctx),
+		RunnerSelector: GetContextRunnerSelector(ctx),
+		OwnerID:        vals.OwnerID,
+		SessionID:      vals.SessionID,
+		InteractionID:  vals.InteractionID,
+		Request:        &request,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error enqueuing request: %w", err)
@@ -49,6 +49,11 @@ func (c *InternalHelixServer) CreateEmbeddings(_ context.Context, _ openai.Embed
 	return openai.EmbeddingResponse{}, fmt.Errorf("not implemented")
 }
 
+func (c *InternalHelixServer) Rerank(_ context.Context, _ RerankRequest) (RerankResponse, error) {
+	// Runners only serve chat and embedding models
+	return RerankResponse{}, fmt.Errorf("%w by Helix runners", ErrRerankNotSupported)
+}
+
 func (c *InternalHelixServer) enqueueRequest(req *types.RunnerLLMInferenceRequest) error {
 	work, err := scheduler.NewLLMWorkload(req)
 	if err != nil {
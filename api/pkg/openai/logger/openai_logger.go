@@ -15,6 +15,7 @@ import (
 	"github.com/helixml/helix/api/pkg/config"
 	"github.com/helixml/helix/api/pkg/model"
 	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/openai/cache"
 	"github.com/helixml/helix/api/pkg/openai/transport"
 	"github.com/helixml/helix/api/pkg/types"
 )
@@ -79,7 +80,16 @@ func (m *LoggingMiddleware) CreateChatCompletion(ctx context.Context, request op
 }
 
 func (m *LoggingMiddleware) CreateChatCompletionStream(ctx context.Context, request openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error) {
-	upstream, err := m.client.CreateChatCompletionStream(ctx, request)
+	// Ask the providers that support it for the usage so streams count in the
+	// token budgets, it's only passed on to the client if it asked for it too.
+	// Other providers may reject the option, their usage is estimated.
+	includeUsage := request.StreamOptions != nil && request.StreamOptions.IncludeUsage
+	upstreamRequest := request
+	if supportsStreamUsage(m.provider) {
+		upstreamRequest.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	}
+
+	upstream, err := m.client.CreateChatCompletionStream(ctx, upstreamRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -88,6 +98,8 @@ func (m *LoggingMiddleware) CreateChatCompletionStream(ctx context.Context, requ
 	if err != nil {
 		return nil, fmt.Errorf("failed to create streaming adapter: %w", err)
 	}
+	// Keep the upstream headers, they tell if the response was cached
+	downstream.SetHeader(upstream.Header())
 
 	m.wg.Add(1)
 	go func() {
@@ -119,12 +131,27 @@ func (m *LoggingMiddleware) CreateChatCompletionStream(ctx context.Context, requ
 			// Add the message to the response
 			appendChunk(&resp, &msg)
 
+			if !includeUsage && msg.Usage != nil {
+				// The usage is sent in a last chunk without choices
+				if len(msg.Choices) == 0 {
+					continue
+				}
+				msg.Usage = nil
+			}
+
 			if err := transport.WriteChatCompletionStream(downstreamWriter, &msg); err != nil {
 				// TODO: should we return here? For now we just log and continue
 				log.Error().Err(err).Msg("failed to  write completion")
 			}
 		}
 
+		resp.SetHeader(upstream.Header())
+
+		if resp.Usage.TotalTokens == 0 {
+			// Not every provider supports the stream usage
+			resp.Usage = estimateUsage(&request, &resp)
+		}
+
 		// Once the stream is done, close the downstream writer
 		m.logLLMCall(ctx, &request, &resp, time.Since(start).Milliseconds())
 	}()
@@ -132,6 +159,16 @@ func (m *LoggingMiddleware) CreateChatCompletionStream(ctx context.Context, requ
 	return downstream, nil
 }
 
+// supportsStreamUsage reports whether the provider is known to accept the
+// stream_options of the requests
+func supportsStreamUsage(provider types.Provider) bool {
+	switch provider {
+	case types.ProviderOpenAI, types.ProviderTogetherAI, types.ProviderVLLM, types.ProviderHelix:
+		return true
+	}
+	return false
+}
+
 func appendChunk(resp *openai.ChatCompletionResponse, chunk *openai.ChatCompletionStreamResponse) {
 	if chunk == nil {
 		return
@@ -166,11 +203,38 @@ func appendChunk(resp *openai.ChatCompletionResponse, chunk *openai.ChatCompleti
 		}
 	}
 
-	// Append the usage
-	// TODO: need to update the fork
-	// resp.Usage.PromptTokens += chunk.Usage.PromptTokens
-	// resp.Usage.CompletionTokens += chunk.Usage.CompletionTokens
-	// resp.Usage.TotalTokens += chunk.Usage.TotalTokens
+	// The usage is only sent in the last chunk, when the request asks for it
+	if chunk.Usage != nil {
+		resp.Usage = *chunk.Usage
+	}
+}
+
+// estimateUsage approximates the usage of a stream when the provider didn't
+// return it, with about 4 characters per token
+func estimateUsage(req *openai.ChatCompletionRequest, resp *openai.ChatCompletionResponse) openai.Usage {
+	var promptChars, completionChars int
+
+	for _, message := range req.Messages {
+		promptChars += len(message.Content)
+		for _, part := range message.MultiContent {
+			promptChars += len(part.Text)
+		}
+	}
+
+	for _, choice := range resp.Choices {
+		completionChars += len(choice.Message.Content)
+		if choice.Message.FunctionCall != nil {
+			completionChars += len(choice.Message.FunctionCall.Arguments)
+		}
+	}
+
+	usage := openai.Usage{
+		PromptTokens:     (promptChars + 3) / 4,
+		CompletionTokens: (completionChars + 3) / 4,
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+
+	return usage
 }
 
 func (m *LoggingMiddleware) logLLMCall(ctx context.Context, req *openai.ChatCompletionRequest, resp *openai.ChatCompletionResponse, durationMs int64) {
@@ -205,15 +269,23 @@ func (m *LoggingMiddleware) logLLMCall(ctx context.Context, req *openai.ChatComp
 		log.Debug().Msg("failed to get app_id")
 	}
 
+	// Responses served from the cache don't use any tokens
+	usage := resp.Usage
+	cacheHit := cache.IsHit(resp.Header())
+	if cacheHit {
+		usage = openai.Usage{}
+	}
+
 	log.Debug().
 		Str("owner_id", vals.OwnerID).
 		Str("app_id", appID).
 		Str("model", req.Model).
 		Str("provider", string(m.provider)).
 		Str("step", string(step.Step)).
-		Int("prompt_tokens", resp.Usage.PromptTokens).
-		Int("completion_tokens", resp.Usage.CompletionTokens).
-		Int("total_tokens", resp.Usage.TotalTokens).
+		Int("prompt_tokens", usage.PromptTokens).
+		Int("completion_tokens", usage.CompletionTokens).
+		Int("total_tokens", usage.TotalTokens).
+		Bool("cache_hit", cacheHit).
 		Msg("logging LLM call")
 
 	llmCall := &types.LLMCall{
@@ -227,12 +299,15 @@ func (m *LoggingMiddleware) logLLMCall(ctx context.Context, req *openai.ChatComp
 		Response:         respBts,
 		Provider:         string(m.provider),
 		DurationMs:       durationMs,
-		PromptTokens:     int64(resp.Usage.PromptTokens),
-		CompletionTokens: int64(resp.Usage.CompletionTokens),
-		TotalTokens:      int64(resp.Usage.TotalTokens),
+		PromptTokens:     int64(usage.PromptTokens),
+		CompletionTokens: int64(usage.CompletionTokens),
+		TotalTokens:      int64(usage.TotalTokens),
 		UserID:           vals.OwnerID,
+		CacheHit:         cacheHit,
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), logCallTimeout)
+	// Keep the request values, log stores like the rate limiter use them, but
+	// not its cancellation as streams are logged once the request is done
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), logCallTimeout)
 	defer cancel()
 
 	for _, logStore := range m.logStores {
@@ -247,3 +322,8 @@ func (m *LoggingMiddleware) logLLMCall(ctx context.Context, req *openai.ChatComp
 func (m *LoggingMiddleware) CreateEmbeddings(ctx context.Context, request openai.EmbeddingRequest) (resp openai.EmbeddingResponse, err error) {
 	return m.client.CreateEmbeddings(ctx, request)
 }
+
+// No-op, not logging rerank calls
+func (m *LoggingMiddleware) Rerank(ctx context.Context, request oai.RerankRequest) (oai.RerankResponse, error) {
+	return m.client.Rerank(ctx, request)
+}
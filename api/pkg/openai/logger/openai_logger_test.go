@@ -0,0 +1,159 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/config"
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/openai/transport"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+type memoryLogStore struct {
+	mu    sync.Mutex
+	calls []*types.LLMCall
+}
+
+func (s *memoryLogStore) CreateLLMCall(_ context.Context, call *types.LLMCall) (*types.LLMCall, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, call)
+	return call, nil
+}
+
+// streamChunks returns a stream of the chunks, like a provider would
+func streamChunks(t *testing.T, req openai.ChatCompletionRequest, chunks ...openai.ChatCompletionStreamResponse) *openai.ChatCompletionStream {
+	stream, writer, err := transport.NewOpenAIStreamingAdapter(req)
+	require.NoError(t, err)
+
+	go func() {
+		defer writer.Close()
+		for _, chunk := range chunks {
+			if err := transport.WriteChatCompletionStream(writer, &chunk); err != nil {
+				return
+			}
+		}
+	}()
+
+	return stream
+}
+
+func readStream(t *testing.T, stream *openai.ChatCompletionStream) []openai.ChatCompletionStreamResponse {
+	var chunks []openai.ChatCompletionStreamResponse
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return chunks
+		}
+		require.NoError(t, err)
+		chunks = append(chunks, chunk)
+	}
+}
+
+func contentChunk(content string) openai.ChatCompletionStreamResponse {
+	return openai.ChatCompletionStreamResponse{
+		Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{Content: content}}},
+	}
+}
+
+func Test_CreateChatCompletionStream_Usage(t *testing.T) {
+	usage := openai.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}
+
+	tests := []struct {
+		name         string
+		provider     types.Provider
+		includeUsage bool
+	}{
+		{name: "client didn't ask for the usage", provider: types.ProviderOpenAI},
+		{name: "client asked for the usage", provider: types.ProviderOpenAI, includeUsage: true},
+		{name: "provider endpoint", provider: "my-endpoint", includeUsage: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			client := oai.NewMockClient(ctrl)
+			logStore := &memoryLogStore{}
+
+			m := Wrap(&config.ServerConfig{}, tt.provider, client, logStore)
+
+			req := openai.ChatCompletionRequest{Model: "gpt-4o", Stream: true}
+			if tt.includeUsage {
+				req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+			}
+
+			client.EXPECT().CreateChatCompletionStream(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, upstreamReq openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error) {
+					// Known providers are always asked for the usage,
+					// provider endpoints only get the client options
+					require.NotNil(t, upstreamReq.StreamOptions)
+					require.True(t, upstreamReq.StreamOptions.IncludeUsage)
+
+					return streamChunks(t, upstreamReq,
+						contentChunk("Hello"),
+						contentChunk(" world"),
+						openai.ChatCompletionStreamResponse{Usage: &usage},
+					), nil
+				})
+
+			stream, err := m.CreateChatCompletionStream(context.Background(), req)
+			require.NoError(t, err)
+
+			chunks := readStream(t, stream)
+			m.wg.Wait()
+
+			if tt.includeUsage {
+				require.Len(t, chunks, 3)
+				require.Equal(t, &usage, chunks[2].Usage)
+			} else {
+				require.Len(t, chunks, 2)
+				for _, chunk := range chunks {
+					require.Nil(t, chunk.Usage)
+				}
+			}
+
+			require.Len(t, logStore.calls, 1)
+			require.Equal(t, int64(15), logStore.calls[0].TotalTokens)
+		})
+	}
+}
+
+func Test_CreateChatCompletionStream_EstimatesMissingUsage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := oai.NewMockClient(ctrl)
+	logStore := &memoryLogStore{}
+
+	m := Wrap(&config.ServerConfig{}, "my-endpoint", client, logStore)
+
+	req := openai.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Stream:   true,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "What is the capital of France?"}},
+	}
+
+	client.EXPECT().CreateChatCompletionStream(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, upstreamReq openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error) {
+			// The provider endpoint may not support the stream options
+			require.Nil(t, upstreamReq.StreamOptions)
+			return streamChunks(t, upstreamReq, contentChunk("The capital of France is Paris.")), nil
+		})
+
+	stream, err := m.CreateChatCompletionStream(context.Background(), req)
+	require.NoError(t, err)
+
+	readStream(t, stream)
+	m.wg.Wait()
+
+	require.Len(t, logStore.calls, 1)
+	require.Equal(t, int64(8), logStore.calls[0].PromptTokens)
+	require.Equal(t, int64(8), logStore.calls[0].CompletionTokens)
+	require.Equal(t, int64(16), logStore.calls[0].TotalTokens)
+}
@@ -0,0 +1,76 @@
+package manager
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// circuitBreakerFailureThreshold is the number of consecutive failures after
+	// which a provider is skipped by the routing groups
+	circuitBreakerFailureThreshold = 5
+	// circuitBreakerCooldown is how long a provider is skipped before a single
+	// trial request is let through
+	circuitBreakerCooldown = 30 * time.Second
+)
+
+// circuitBreaker stops sending requests to a failing provider. It opens after
+// consecutive failures and lets one trial request through once the cooldown
+// has passed (half-open), a successful trial closes it again.
+type circuitBreaker struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+func newCircuitBreaker(now func() time.Time) *circuitBreaker {
+	return &circuitBreaker{now: now}
+}
+
+// allow reports whether a request can be sent to the provider, when half-open
+// it reserves the trial request
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < circuitBreakerFailureThreshold {
+		return true
+	}
+
+	if b.trial || b.now().Sub(b.openedAt) < circuitBreakerCooldown {
+		return false
+	}
+
+	b.trial = true
+	return true
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.trial = false
+}
+
+// release gives the trial request back without a result, e.g. when the
+// request was cancelled before the provider answered
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+}
+
+func (b *circuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.trial = false
+	if b.failures >= circuitBreakerFailureThreshold {
+		b.openedAt = b.now()
+	}
+}
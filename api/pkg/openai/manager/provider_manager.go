@@ -12,14 +12,19 @@ import (
 
 	"github.com/helixml/helix/api/pkg/config"
 	"github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/openai/anthropic"
+	"github.com/helixml/helix/api/pkg/openai/cache"
+	"github.com/helixml/helix/api/pkg/openai/gemini"
 	"github.com/helixml/helix/api/pkg/openai/logger"
+	"github.com/helixml/helix/api/pkg/ratelimit"
 	"github.com/helixml/helix/api/pkg/store"
 	"github.com/helixml/helix/api/pkg/types"
 )
 
 type GetClientRequest struct {
-	Provider string
-	Owner    string
+	Provider  string
+	Owner     string
+	OwnerType types.OwnerType // The owner is a user if not set
 }
 
 //go:generate mockgen -source $GOFILE -destination manager_mocks.go -package $GOPACKAGE
@@ -40,12 +45,17 @@ type MultiClientManager struct {
 	cfg             *config.ServerConfig
 	store           store.Store
 	logStores       []logger.LogStore
+	responseCache   *cache.ResponseCache
+	rateLimiter     *ratelimit.Limiter
 	globalClients   map[types.Provider]*providerClient
 	globalClientsMu *sync.RWMutex
+	routing         *routingState
 	wg              sync.WaitGroup
 }
 
-func NewProviderManager(cfg *config.ServerConfig, store store.Store, helixInference openai.Client, logStores ...logger.LogStore) *MultiClientManager {
+// NewProviderManager returns a manager of the clients of the providers, the
+// response cache and the rate limiter are optional
+func NewProviderManager(cfg *config.ServerConfig, store store.Store, helixInference openai.Client, responseCache *cache.ResponseCache, rateLimiter *ratelimit.Limiter, logStores ...logger.LogStore) *MultiClientManager {
 	clients := make(map[types.Provider]*providerClient)
 
 	if cfg.Providers.OpenAI.APIKey != "" {
@@ -57,7 +67,7 @@ func NewProviderManager(cfg *config.ServerConfig, store store.Store, helixInfere
 			cfg.Providers.OpenAI.APIKey,
 			cfg.Providers.OpenAI.BaseURL)
 
-		loggedClient := logger.Wrap(cfg, types.ProviderOpenAI, openaiClient, logStores...)
+		loggedClient := wrapClient(cfg, types.ProviderOpenAI, string(types.ProviderOpenAI), openaiClient, responseCache, rateLimiter, logStores)
 
 		clients[types.ProviderOpenAI] = &providerClient{client: loggedClient}
 	}
@@ -71,7 +81,7 @@ func NewProviderManager(cfg *config.ServerConfig, store store.Store, helixInfere
 			cfg.Providers.TogetherAI.APIKey,
 			cfg.Providers.TogetherAI.BaseURL)
 
-		loggedClient := logger.Wrap(cfg, types.ProviderTogetherAI, togetherAiClient, logStores...)
+		loggedClient := wrapClient(cfg, types.ProviderTogetherAI, string(types.ProviderTogetherAI), togetherAiClient, responseCache, rateLimiter, logStores)
 
 		clients[types.ProviderTogetherAI] = &providerClient{client: loggedClient}
 	}
@@ -86,14 +96,14 @@ func NewProviderManager(cfg *config.ServerConfig, store store.Store, helixInfere
 			cfg.Providers.VLLM.APIKey,
 			cfg.Providers.VLLM.BaseURL)
 
-		loggedClient := logger.Wrap(cfg, types.ProviderVLLM, vllmClient, logStores...)
+		loggedClient := wrapClient(cfg, types.ProviderVLLM, string(types.ProviderVLLM), vllmClient, responseCache, rateLimiter, logStores)
 
 		clients[types.ProviderVLLM] = &providerClient{client: loggedClient}
 	}
 
 	// Always configure Helix provider too
 
-	loggedClient := logger.Wrap(cfg, types.ProviderHelix, helixInference, logStores...)
+	loggedClient := wrapClient(cfg, types.ProviderHelix, string(types.ProviderHelix), helixInference, responseCache, rateLimiter, logStores)
 
 	clients[types.ProviderHelix] = &providerClient{client: loggedClient}
 
@@ -101,13 +111,32 @@ func NewProviderManager(cfg *config.ServerConfig, store store.Store, helixInfere
 		cfg:             cfg,
 		store:           store,
 		logStores:       logStores,
+		responseCache:   responseCache,
+		rateLimiter:     rateLimiter,
 		globalClients:   clients,
 		globalClientsMu: &sync.RWMutex{},
+		routing:         newRoutingState(time.Now),
 	}
 
 	return mcm
 }
 
+// wrapClient adds the response cache, the logging of the LLM calls and the
+// rate limit of the provider to the client. The cache is wrapped by the logger
+// so cache hits are logged too, and the logger by the rate limit so the logged
+// tokens are counted in the budget of the provider. The provider ID is the
+// endpoint ID, or the provider name for the global providers.
+func wrapClient(cfg *config.ServerConfig, provider types.Provider, providerID string, client openai.Client, responseCache *cache.ResponseCache, rateLimiter *ratelimit.Limiter, logStores []logger.LogStore) openai.Client {
+	if responseCache != nil {
+		client = cache.Wrap(provider, client, responseCache)
+	}
+	client = logger.Wrap(cfg, provider, client, logStores...)
+	if rateLimiter != nil {
+		client = ratelimit.Wrap(rateLimiter, providerID, client)
+	}
+	return client
+}
+
 func (m *MultiClientManager) StartRefresh(ctx context.Context) {
 	if m.cfg.Providers.OpenAI.APIKeyFromFile != "" {
 		err := m.watchAndUpdateClient(ctx, types.ProviderOpenAI, m.cfg.Providers.OpenAI.APIKeyRefreshInterval, m.cfg.Providers.OpenAI.BaseURL, m.cfg.Providers.OpenAI.APIKeyFromFile)
@@ -201,7 +230,7 @@ func (m *MultiClientManager) updateClientAPIKeyFromFile(provider types.Provider,
 	// Recreate the client with the new key
 	openaiClient := openai.New(newKey, baseURL)
 
-	loggedClient := logger.Wrap(m.cfg, provider, openaiClient, m.logStores...)
+	loggedClient := wrapClient(m.cfg, provider, string(provider), openaiClient, m.responseCache, m.rateLimiter, m.logStores)
 
 	m.globalClientsMu.Lock()
 	m.globalClients[provider] = &providerClient{client: loggedClient}
@@ -226,8 +255,9 @@ func (m *MultiClientManager) ListProviders(ctx context.Context, owner string) ([
 	}
 
 	userProviders, err := m.store.ListProviderEndpoints(ctx, &store.ListProviderEndpointsQuery{
-		Owner:      owner,
-		WithGlobal: true,
+		Owner:             owner,
+		WithGlobal:        true,
+		WithOrganizations: true,
 	})
 	if err != nil {
 		return nil, err
@@ -237,10 +267,23 @@ func (m *MultiClientManager) ListProviders(ctx context.Context, owner string) ([
 		providers = append(providers, types.Provider(provider.Name))
 	}
 
+	groups, err := m.store.ListProviderRoutingGroups(ctx, &store.ListProviderEndpointsQuery{
+		Owner:             owner,
+		WithGlobal:        true,
+		WithOrganizations: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range groups {
+		providers = append(providers, types.Provider(group.Name))
+	}
+
 	return providers, nil
 }
 
-func (m *MultiClientManager) GetClient(_ context.Context, req *GetClientRequest) (openai.Client, error) {
+func (m *MultiClientManager) GetClient(ctx context.Context, req *GetClientRequest) (openai.Client, error) {
 	m.globalClientsMu.RLock()
 	defer m.globalClientsMu.RUnlock()
 
@@ -249,9 +292,11 @@ func (m *MultiClientManager) GetClient(_ context.Context, req *GetClientRequest)
 		return client.client, nil
 	}
 
-	userProviders, err := m.store.ListProviderEndpoints(context.Background(), &store.ListProviderEndpointsQuery{
-		Owner:      req.Owner,
-		WithGlobal: true,
+	userProviders, err := m.store.ListProviderEndpoints(ctx, &store.ListProviderEndpointsQuery{
+		Owner:             req.Owner,
+		OwnerType:         req.OwnerType,
+		WithGlobal:        true,
+		WithOrganizations: true,
 	})
 	if err != nil {
 		return nil, err
@@ -263,6 +308,23 @@ func (m *MultiClientManager) GetClient(_ context.Context, req *GetClientRequest)
 		}
 	}
 
+	// Routing groups are checked last so they can't shadow a provider
+	groups, err := m.store.ListProviderRoutingGroups(ctx, &store.ListProviderEndpointsQuery{
+		Owner:             req.Owner,
+		OwnerType:         req.OwnerType,
+		WithGlobal:        true,
+		WithOrganizations: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range groups {
+		if group.Name == req.Provider || group.ID == req.Provider {
+			return m.initializeRoutingClient(group, userProviders)
+		}
+	}
+
 	// Check if the provider is a global one
 	availableProviders := make([]string, 0, len(m.globalClients))
 	for provider := range m.globalClients {
@@ -282,9 +344,77 @@ func (m *MultiClientManager) initializeClient(endpoint *types.ProviderEndpoint)
 		apiKey = strings.TrimSpace(string(bts))
 	}
 
-	openaiClient := openai.New(apiKey, endpoint.BaseURL)
+	client, err := newEndpointClient(endpoint.APIType, apiKey, endpoint.BaseURL)
+	if err != nil {
+		return nil, err
+	}
 
-	loggedClient := logger.Wrap(m.cfg, types.Provider(endpoint.Name), openaiClient, m.logStores...)
+	loggedClient := wrapClient(m.cfg, types.Provider(endpoint.Name), endpoint.ID, client, m.responseCache, m.rateLimiter, m.logStores)
 
 	return loggedClient, nil
 }
+
+// newEndpointClient returns the client for the API the endpoint serves, the
+// adapters of the other APIs translate the OpenAI requests to them
+func newEndpointClient(apiType types.ProviderAPIType, apiKey, baseURL string) (openai.Client, error) {
+	switch apiType {
+	case "", types.ProviderAPITypeOpenAI:
+		return openai.New(apiKey, baseURL), nil
+	case types.ProviderAPITypeAnthropic:
+		return anthropic.New(apiKey, baseURL), nil
+	case types.ProviderAPITypeGemini:
+		return gemini.New(apiKey, baseURL), nil
+	default:
+		return nil, fmt.Errorf("unknown provider API type '%s'", apiType)
+	}
+}
+
+// initializeRoutingClient resolves the providers of the group, groups can't be
+// nested so the members are only looked up in the global providers and the
+// provider endpoints
+func (m *MultiClientManager) initializeRoutingClient(group *types.ProviderRoutingGroup, endpoints []*types.ProviderEndpoint) (openai.Client, error) {
+	members := make([]*routingMember, 0, len(group.Members))
+
+	for _, member := range group.Members {
+		if client, ok := m.globalClients[types.Provider(member.Provider)]; ok {
+			members = append(members, &routingMember{
+				ProviderRoutingGroupMember: member,
+				key:                        member.Provider,
+				client:                     client.client,
+			})
+			continue
+		}
+
+		var endpoint *types.ProviderEndpoint
+		for _, e := range endpoints {
+			if e.Name == member.Provider || e.ID == member.Provider {
+				endpoint = e
+				break
+			}
+		}
+		if endpoint == nil {
+			log.Warn().
+				Str("routing_group", group.Name).
+				Str("provider", member.Provider).
+				Msg("provider of routing group not found, skipping it")
+			continue
+		}
+
+		client, err := m.initializeClient(endpoint)
+		if err != nil {
+			return nil, err
+		}
+
+		members = append(members, &routingMember{
+			ProviderRoutingGroupMember: member,
+			key:                        endpoint.ID,
+			client:                     client,
+		})
+	}
+
+	if len(members) == 0 {
+		return nil, fmt.Errorf("routing group %s has no available providers", group.Name)
+	}
+
+	return newRoutingClient(group, members, m.routing), nil
+}
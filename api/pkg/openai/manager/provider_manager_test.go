@@ -43,7 +43,7 @@ func (suite *MultiClientManagerTestSuite) SetupTest() {
 }
 
 func (suite *MultiClientManagerTestSuite) Test_VLLM() {
-	manager := NewProviderManager(suite.cfg, suite.store, nil)
+	manager := NewProviderManager(suite.cfg, suite.store, nil, nil, nil)
 	client, err := manager.GetClient(context.Background(), &GetClientRequest{Provider: string(types.ProviderVLLM)})
 	suite.NoError(err)
 	suite.NotNil(client)
@@ -57,7 +57,7 @@ func (suite *MultiClientManagerTestSuite) Test_WatchAndUpdateClient() {
 	suite.NoError(err)
 
 	// Create manager with initial key
-	manager := NewProviderManager(suite.cfg, suite.store, nil)
+	manager := NewProviderManager(suite.cfg, suite.store, nil, nil, nil)
 
 	// Create context with cancel
 	ctx, cancel := context.WithCancel(context.Background())
@@ -108,7 +108,7 @@ func (suite *MultiClientManagerTestSuite) Test_WatchAndUpdateClient_MissingFile(
 	}()
 
 	// Create manager with initial key
-	manager := NewProviderManager(suite.cfg, suite.store, nil)
+	manager := NewProviderManager(suite.cfg, suite.store, nil, nil, nil)
 
 	// Create context with cancel
 	ctx, cancel := context.WithCancel(context.Background())
@@ -138,3 +138,46 @@ func (suite *MultiClientManagerTestSuite) Test_WatchAndUpdateClient_MissingFile(
 
 	cancel()
 }
+
+func (suite *MultiClientManagerTestSuite) Test_RoutingGroup() {
+	endpoint := &types.ProviderEndpoint{
+		ID:      "pe_1",
+		Name:    "my-endpoint",
+		BaseURL: "http://endpoint:8000",
+		APIKey:  "endpoint-key",
+	}
+	group := &types.ProviderRoutingGroup{
+		ID:       "prg_1",
+		Name:     "my-group",
+		Strategy: types.ProviderRoutingStrategyPriority,
+		Members: types.ProviderRoutingGroupMembers{
+			{Provider: string(types.ProviderVLLM)},
+			{Provider: "my-endpoint", Priority: 1},
+			{Provider: "missing", Priority: 2},
+		},
+	}
+
+	suite.store.EXPECT().ListProviderEndpoints(gomock.Any(), gomock.Any()).Return([]*types.ProviderEndpoint{endpoint}, nil).Times(2)
+	suite.store.EXPECT().ListProviderRoutingGroups(gomock.Any(), &store.ListProviderEndpointsQuery{
+		Owner:             "user",
+		WithGlobal:        true,
+		WithOrganizations: true,
+	}).Return([]*types.ProviderRoutingGroup{group}, nil).Times(2)
+
+	manager := NewProviderManager(suite.cfg, suite.store, nil, nil, nil)
+
+	providers, err := manager.ListProviders(context.Background(), "user")
+	suite.NoError(err)
+	suite.Contains(providers, types.Provider("my-group"))
+
+	client, err := manager.GetClient(context.Background(), &GetClientRequest{Provider: "my-group", Owner: "user"})
+	suite.NoError(err)
+
+	routing, ok := client.(*routingClient)
+	suite.Require().True(ok)
+
+	// Missing providers are skipped
+	suite.Require().Len(routing.members, 2)
+	suite.Equal(string(types.ProviderVLLM), routing.members[0].key)
+	suite.Equal("pe_1", routing.members[1].key)
+}
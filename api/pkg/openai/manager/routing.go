@@ -0,0 +1,327 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/helixml/helix/api/pkg/model"
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/ratelimit"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// latencySmoothing is the weight of the latest request in the moving average
+// of the provider latencies used by the least latency strategy
+const latencySmoothing = 0.3
+
+// routingState is shared by the routing clients of all the groups so the
+// circuit breakers and latencies of a provider outlive single requests
+type routingState struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	breakers  map[string]*circuitBreaker
+	latencies map[string]time.Duration
+	// weights holds the current weights of the smooth weighted round-robin
+	// of each group
+	weights map[string][]int
+}
+
+func newRoutingState(now func() time.Time) *routingState {
+	return &routingState{
+		now:       now,
+		breakers:  make(map[string]*circuitBreaker),
+		latencies: make(map[string]time.Duration),
+		weights:   make(map[string][]int),
+	}
+}
+
+func (s *routingState) breaker(key string) *circuitBreaker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.breakers[key]
+	if !ok {
+		b = newCircuitBreaker(s.now)
+		s.breakers[key] = b
+	}
+	return b
+}
+
+func (s *routingState) observeLatency(key string, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.latencies[key]
+	if !ok {
+		s.latencies[key] = latency
+		return
+	}
+	s.latencies[key] = time.Duration(latencySmoothing*float64(latency) + (1-latencySmoothing)*float64(current))
+}
+
+func (s *routingState) latency(key string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.latencies[key]
+}
+
+// nextWeighted picks the index of the next member of the group with smooth
+// weighted round-robin, which interleaves the members instead of sending
+// bursts to the heaviest one
+func (s *routingState) nextWeighted(groupID string, weights []int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.weights[groupID]
+	if len(current) != len(weights) {
+		current = make([]int, len(weights))
+		s.weights[groupID] = current
+	}
+
+	total, best := 0, 0
+	for i, weight := range weights {
+		current[i] += weight
+		total += weight
+		if current[i] > current[best] {
+			best = i
+		}
+	}
+	current[best] -= total
+
+	return best
+}
+
+type routingMember struct {
+	types.ProviderRoutingGroupMember
+	// key identifies the provider in the routing state, the endpoint ID for
+	// provider endpoints and the provider name for global providers
+	key    string
+	client oai.Client
+}
+
+func (m *routingMember) weight() int {
+	if m.Weight <= 0 {
+		return 1
+	}
+	return m.Weight
+}
+
+// routingClient sends the requests to the providers of a routing group, it
+// falls back to the next provider on rate limits, server and network errors
+// and skips the providers whose circuit breaker is open
+type routingClient struct {
+	group   *types.ProviderRoutingGroup
+	members []*routingMember
+	state   *routingState
+}
+
+var _ oai.Client = &routingClient{}
+
+func newRoutingClient(group *types.ProviderRoutingGroup, members []*routingMember, state *routingState) *routingClient {
+	return &routingClient{
+		group:   group,
+		members: members,
+		state:   state,
+	}
+}
+
+// order returns the members in the order they are tried, the members that
+// aren't picked by the strategy are tried by priority
+func (c *routingClient) order() []*routingMember {
+	members := make([]*routingMember, len(c.members))
+	copy(members, c.members)
+
+	sort.SliceStable(members, func(i, j int) bool {
+		return members[i].Priority < members[j].Priority
+	})
+
+	switch c.group.Strategy {
+	case types.ProviderRoutingStrategyWeightedRoundRobin:
+		weights := make([]int, len(members))
+		for i, member := range members {
+			weights[i] = member.weight()
+		}
+		next := c.state.nextWeighted(c.group.ID, weights)
+		picked := members[next]
+		copy(members[1:next+1], members[:next])
+		members[0] = picked
+	case types.ProviderRoutingStrategyLeastLatency:
+		// Providers without latency yet come first so they get measured
+		latencies := make(map[string]time.Duration, len(members))
+		for _, member := range members {
+			latencies[member.key] = c.state.latency(member.key)
+		}
+		sort.SliceStable(members, func(i, j int) bool {
+			return latencies[members[i].key] < latencies[members[j].key]
+		})
+	}
+
+	return members
+}
+
+func (c *routingClient) route(ctx context.Context, do func(member *routingMember) error) error {
+	var errs []error
+
+	for _, member := range c.order() {
+		breaker := c.state.breaker(member.key)
+		if !breaker.allow() {
+			continue
+		}
+
+		start := c.state.now()
+		err := do(member)
+		if err == nil {
+			breaker.success()
+			c.state.observeLatency(member.key, c.state.now().Sub(start))
+			return nil
+		}
+
+		var exceeded *ratelimit.ExceededError
+		if errors.As(err, &exceeded) {
+			// The provider reached its rate limit, it isn't failing
+			breaker.release()
+			errs = append(errs, fmt.Errorf("%s: %w", member.Provider, err))
+			continue
+		}
+
+		if ctx.Err() != nil {
+			// The caller gave up, the provider's health is unknown
+			breaker.release()
+			return err
+		}
+
+		if !shouldFallback(ctx, err) {
+			// The provider answered, the request is at fault
+			breaker.success()
+			return err
+		}
+
+		breaker.failure()
+		errs = append(errs, fmt.Errorf("%s: %w", member.Provider, err))
+
+		log.Warn().
+			Err(err).
+			Str("routing_group", c.group.Name).
+			Str("provider", member.Provider).
+			Msg("provider request failed, falling back to the next provider")
+	}
+
+	if len(errs) == 0 {
+		return fmt.Errorf("no provider of routing group %s is available", c.group.Name)
+	}
+
+	return fmt.Errorf("all providers of routing group %s failed: %w", c.group.Name, errors.Join(errs...))
+}
+
+// shouldFallback reports whether the request can be retried on another
+// provider: rate limits, timeouts, server errors and errors without a status
+// code like network failures
+func shouldFallback(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var status int
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		status = reqErr.HTTPStatusCode
+	}
+
+	if status == 0 {
+		return true
+	}
+
+	return status == http.StatusRequestTimeout ||
+		status == http.StatusTooManyRequests ||
+		status >= http.StatusInternalServerError
+}
+
+func (c *routingClient) CreateChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	var resp openai.ChatCompletionResponse
+
+	err := c.route(ctx, func(member *routingMember) error {
+		req := request
+		if member.Model != "" {
+			req.Model = member.Model
+		}
+
+		var err error
+		resp, err = member.client.CreateChatCompletion(ctx, req)
+		return err
+	})
+
+	return resp, err
+}
+
+// CreateChatCompletionStream falls back only until a stream is opened, once
+// the provider started answering the stream is returned as is
+func (c *routingClient) CreateChatCompletionStream(ctx context.Context, request openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error) {
+	var stream *openai.ChatCompletionStream
+
+	err := c.route(ctx, func(member *routingMember) error {
+		req := request
+		if member.Model != "" {
+			req.Model = member.Model
+		}
+
+		var err error
+		stream, err = member.client.CreateChatCompletionStream(ctx, req)
+		return err
+	})
+
+	return stream, err
+}
+
+func (c *routingClient) ListModels(ctx context.Context) ([]model.OpenAIModel, error) {
+	var models []model.OpenAIModel
+
+	err := c.route(ctx, func(member *routingMember) error {
+		var err error
+		models, err = member.client.ListModels(ctx)
+		return err
+	})
+
+	return models, err
+}
+
+func (c *routingClient) CreateEmbeddings(ctx context.Context, request openai.EmbeddingRequest) (openai.EmbeddingResponse, error) {
+	var resp openai.EmbeddingResponse
+
+	err := c.route(ctx, func(member *routingMember) error {
+		var err error
+		resp, err = member.client.CreateEmbeddings(ctx, request)
+		return err
+	})
+
+	return resp, err
+}
+
+func (c *routingClient) Rerank(ctx context.Context, request oai.RerankRequest) (oai.RerankResponse, error) {
+	var resp oai.RerankResponse
+
+	err := c.route(ctx, func(member *routingMember) error {
+		var err error
+		resp, err = member.client.Rerank(ctx, request)
+		return err
+	})
+
+	return resp, err
+}
+
+// APIKey is empty, every provider of the group has its own key
+func (c *routingClient) APIKey() string {
+	return ""
+}
@@ -0,0 +1,246 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/ratelimit"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestRoutingClient(strategy types.ProviderRoutingStrategy, clock *fakeClock, members ...*routingMember) *routingClient {
+	group := &types.ProviderRoutingGroup{
+		ID:       "prg_test",
+		Name:     "test",
+		Strategy: strategy,
+	}
+	return newRoutingClient(group, members, newRoutingState(clock.Now))
+}
+
+func apiError(status int) error {
+	return &openai.APIError{HTTPStatusCode: status, Message: http.StatusText(status)}
+}
+
+func Test_routingClient_Fallback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	primary := oai.NewMockClient(ctrl)
+	secondary := oai.NewMockClient(ctrl)
+
+	client := newTestRoutingClient(types.ProviderRoutingStrategyPriority, &fakeClock{now: time.Now()},
+		&routingMember{ProviderRoutingGroupMember: types.ProviderRoutingGroupMember{Provider: "secondary", Priority: 2, Model: "other-model"}, key: "secondary", client: secondary},
+		&routingMember{ProviderRoutingGroupMember: types.ProviderRoutingGroupMember{Provider: "primary", Priority: 1}, key: "primary", client: primary},
+	)
+
+	request := openai.ChatCompletionRequest{Model: "model"}
+
+	// Rate limits fall back to the next provider with its model
+	primary.EXPECT().CreateChatCompletion(gomock.Any(), request).Return(openai.ChatCompletionResponse{}, apiError(http.StatusTooManyRequests))
+	secondary.EXPECT().CreateChatCompletion(gomock.Any(), openai.ChatCompletionRequest{Model: "other-model"}).Return(openai.ChatCompletionResponse{ID: "secondary"}, nil)
+
+	resp, err := client.CreateChatCompletion(context.Background(), request)
+	require.NoError(t, err)
+	require.Equal(t, "secondary", resp.ID)
+
+	// Bad requests are returned as they would fail everywhere
+	primary.EXPECT().CreateChatCompletion(gomock.Any(), request).Return(openai.ChatCompletionResponse{}, apiError(http.StatusBadRequest))
+
+	_, err = client.CreateChatCompletion(context.Background(), request)
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "all providers")
+
+	// Network errors fall back too
+	primary.EXPECT().CreateEmbeddings(gomock.Any(), gomock.Any()).Return(openai.EmbeddingResponse{}, errors.New("connection refused"))
+	secondary.EXPECT().CreateEmbeddings(gomock.Any(), gomock.Any()).Return(openai.EmbeddingResponse{}, apiError(http.StatusBadGateway))
+
+	_, err = client.CreateEmbeddings(context.Background(), openai.EmbeddingRequest{})
+	require.ErrorContains(t, err, "all providers of routing group test failed")
+	require.ErrorContains(t, err, "connection refused")
+}
+
+func Test_routingClient_CircuitBreaker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	primary := oai.NewMockClient(ctrl)
+	secondary := oai.NewMockClient(ctrl)
+
+	clock := &fakeClock{now: time.Now()}
+	client := newTestRoutingClient(types.ProviderRoutingStrategyPriority, clock,
+		&routingMember{ProviderRoutingGroupMember: types.ProviderRoutingGroupMember{Provider: "primary", Priority: 1}, key: "primary", client: primary},
+		&routingMember{ProviderRoutingGroupMember: types.ProviderRoutingGroupMember{Provider: "secondary", Priority: 2}, key: "secondary", client: secondary},
+	)
+
+	primary.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{}, apiError(http.StatusInternalServerError)).Times(circuitBreakerFailureThreshold)
+	secondary.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{ID: "secondary"}, nil).Times(circuitBreakerFailureThreshold + 2)
+
+	for i := 0; i < circuitBreakerFailureThreshold; i++ {
+		_, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+		require.NoError(t, err)
+	}
+
+	// The breaker is open, the primary isn't called anymore
+	_, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+	require.NoError(t, err)
+
+	// After the cooldown a trial request fails and opens the breaker again
+	clock.now = clock.now.Add(circuitBreakerCooldown)
+	primary.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{}, apiError(http.StatusServiceUnavailable))
+
+	_, err = client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+	require.NoError(t, err)
+
+	// A successful trial closes it
+	clock.now = clock.now.Add(circuitBreakerCooldown)
+	primary.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{ID: "primary"}, nil).Times(2)
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+		require.NoError(t, err)
+		require.Equal(t, "primary", resp.ID)
+	}
+}
+
+func Test_routingClient_CircuitBreaker_Cancelled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	primary := oai.NewMockClient(ctrl)
+	secondary := oai.NewMockClient(ctrl)
+
+	clock := &fakeClock{now: time.Now()}
+	client := newTestRoutingClient(types.ProviderRoutingStrategyPriority, clock,
+		&routingMember{ProviderRoutingGroupMember: types.ProviderRoutingGroupMember{Provider: "primary", Priority: 1}, key: "primary", client: primary},
+		&routingMember{ProviderRoutingGroupMember: types.ProviderRoutingGroupMember{Provider: "secondary", Priority: 2}, key: "secondary", client: secondary},
+	)
+
+	primary.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{}, apiError(http.StatusInternalServerError)).Times(circuitBreakerFailureThreshold)
+	secondary.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{ID: "secondary"}, nil).Times(circuitBreakerFailureThreshold + 1)
+
+	for i := 0; i < circuitBreakerFailureThreshold; i++ {
+		_, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+		require.NoError(t, err)
+	}
+
+	// The trial request is cancelled, the breaker stays open
+	clock.now = clock.now.Add(circuitBreakerCooldown)
+	ctx, cancel := context.WithCancel(context.Background())
+	primary.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+			cancel()
+			return openai.ChatCompletionResponse{}, context.Canceled
+		})
+
+	_, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{})
+	require.ErrorIs(t, err, context.Canceled)
+
+	breaker := client.state.breaker("primary")
+	require.Equal(t, circuitBreakerFailureThreshold, breaker.failures)
+
+	// The next request is the trial, a failure keeps the breaker open
+	primary.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{}, apiError(http.StatusServiceUnavailable))
+
+	resp, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+	require.NoError(t, err)
+	require.Equal(t, "secondary", resp.ID)
+}
+
+func Test_routingClient_RateLimitedProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	primary := oai.NewMockClient(ctrl)
+	secondary := oai.NewMockClient(ctrl)
+
+	client := newTestRoutingClient(types.ProviderRoutingStrategyPriority, &fakeClock{now: time.Now()},
+		&routingMember{ProviderRoutingGroupMember: types.ProviderRoutingGroupMember{Provider: "primary", Priority: 1}, key: "primary", client: primary},
+		&routingMember{ProviderRoutingGroupMember: types.ProviderRoutingGroupMember{Provider: "secondary", Priority: 2}, key: "secondary", client: secondary},
+	)
+
+	exceeded := &ratelimit.ExceededError{
+		Subject: ratelimit.Subject{Scope: types.RateLimitScopeProvider, ID: "primary"},
+		Limit:   1,
+		Period:  "minute",
+	}
+
+	// The limited provider is skipped without opening its breaker
+	primary.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{}, exceeded).Times(circuitBreakerFailureThreshold)
+	secondary.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{ID: "secondary"}, nil).Times(circuitBreakerFailureThreshold)
+
+	for i := 0; i < circuitBreakerFailureThreshold; i++ {
+		resp, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+		require.NoError(t, err)
+		require.Equal(t, "secondary", resp.ID)
+	}
+
+	primary.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{ID: "primary"}, nil)
+
+	resp, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+	require.NoError(t, err)
+	require.Equal(t, "primary", resp.ID)
+
+	// Once every provider is limited the limit is returned
+	primary.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{}, exceeded)
+	secondary.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{}, exceeded)
+
+	_, err = client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+	require.ErrorAs(t, err, &exceeded)
+}
+
+func Test_routingClient_WeightedRoundRobin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	heavy := oai.NewMockClient(ctrl)
+	light := oai.NewMockClient(ctrl)
+
+	client := newTestRoutingClient(types.ProviderRoutingStrategyWeightedRoundRobin, &fakeClock{now: time.Now()},
+		&routingMember{ProviderRoutingGroupMember: types.ProviderRoutingGroupMember{Provider: "heavy", Weight: 3}, key: "heavy", client: heavy},
+		&routingMember{ProviderRoutingGroupMember: types.ProviderRoutingGroupMember{Provider: "light"}, key: "light", client: light},
+	)
+
+	heavy.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{ID: "heavy"}, nil).AnyTimes()
+	light.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai.ChatCompletionResponse{ID: "light"}, nil).AnyTimes()
+
+	counts := map[string]int{}
+	var order []string
+	for i := 0; i < 8; i++ {
+		resp, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+		require.NoError(t, err)
+		counts[resp.ID]++
+		order = append(order, resp.ID)
+	}
+
+	require.Equal(t, map[string]int{"heavy": 6, "light": 2}, counts)
+	// Smooth round-robin interleaves the light provider
+	require.Equal(t, []string{"heavy", "heavy", "light", "heavy", "heavy", "heavy", "light", "heavy"}, order)
+}
+
+func Test_routingClient_LeastLatency(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	client := newTestRoutingClient(types.ProviderRoutingStrategyLeastLatency, clock,
+		&routingMember{ProviderRoutingGroupMember: types.ProviderRoutingGroupMember{Provider: "slow"}, key: "slow"},
+		&routingMember{ProviderRoutingGroupMember: types.ProviderRoutingGroupMember{Provider: "fast"}, key: "fast"},
+		&routingMember{ProviderRoutingGroupMember: types.ProviderRoutingGroupMember{Provider: "new"}, key: "new"},
+	)
+
+	client.state.observeLatency("slow", 2*time.Second)
+	client.state.observeLatency("fast", time.Second)
+
+	var providers []string
+	for _, member := range client.order() {
+		providers = append(providers, member.Provider)
+	}
+	require.Equal(t, []string{"new", "fast", "slow"}, providers)
+
+	// The latency is a moving average so a single fast answer doesn't win
+	client.state.observeLatency("slow", 500*time.Millisecond)
+	require.Equal(t, 1550*time.Millisecond, client.state.latency("slow"))
+}
@@ -1,8 +1,10 @@
 package openai
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -31,9 +33,34 @@ type Client interface {
 
 	CreateEmbeddings(ctx context.Context, request openai.EmbeddingRequest) (resp openai.EmbeddingResponse, err error)
 
+	// Rerank scores documents against a query using a cross-encoder model. This is
+	// not part of the OpenAI API but is served by vLLM, TEI, Jina, Cohere and others.
+	Rerank(ctx context.Context, request RerankRequest) (RerankResponse, error)
+
 	APIKey() string
 }
 
+// ErrRerankNotSupported is returned by the clients of providers that can't
+// rerank, the results are then left in their search order
+var ErrRerankNotSupported = errors.New("rerank is not supported")
+
+type RerankRequest struct {
+	Model     string   `json:"model"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n,omitempty"`
+}
+
+type RerankResponse struct {
+	Results []RerankResult `json:"results"`
+}
+
+type RerankResult struct {
+	// Index of the document in the request
+	Index          int     `json:"index"`
+	RelevanceScore float64 `json:"relevance_score"`
+}
+
 func New(apiKey string, baseURL string) *RetryableClient {
 	config := openai.DefaultConfig(apiKey)
 	config.BaseURL = baseURL
@@ -210,3 +237,53 @@ func (c *RetryableClient) CreateEmbeddings(ctx context.Context, request openai.E
 
 	return
 }
+
+func (c *RetryableClient) Rerank(ctx context.Context, request RerankRequest) (resp RerankResponse, err error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return resp, fmt.Errorf("failed to marshal rerank request: %w", err)
+	}
+
+	url := c.baseURL + "/rerank"
+
+	err = retry.Do(func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return retry.Unrecoverable(fmt.Errorf("failed to create request to provider's rerank endpoint: %w", err))
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+		httpResp, err := c.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to send request to provider's rerank endpoint: %w", err)
+		}
+		defer httpResp.Body.Close()
+
+		respBody, err := io.ReadAll(httpResp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read response from provider's rerank endpoint: %w", err)
+		}
+
+		if httpResp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("failed to rerank documents (%s): %s, %s", url, httpResp.Status, string(respBody))
+			if httpResp.StatusCode < http.StatusInternalServerError && httpResp.StatusCode != http.StatusTooManyRequests {
+				return retry.Unrecoverable(err)
+			}
+			return err
+		}
+
+		if err := json.Unmarshal(respBody, &resp); err != nil {
+			return retry.Unrecoverable(fmt.Errorf("failed to unmarshal response from provider's rerank endpoint: %w", err))
+		}
+
+		return nil
+	},
+		retry.Attempts(retries),
+		retry.Delay(delayBetweenRetries),
+		retry.Context(ctx),
+	)
+
+	return
+}
@@ -115,3 +115,18 @@ func (mr *MockClientMockRecorder) ListModels(ctx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListModels", reflect.TypeOf((*MockClient)(nil).ListModels), ctx)
 }
+
+// Rerank mocks base method.
+func (m *MockClient) Rerank(ctx context.Context, request RerankRequest) (RerankResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rerank", ctx, request)
+	ret0, _ := ret[0].(RerankResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rerank indicates an expected call of Rerank.
+func (mr *MockClientMockRecorder) Rerank(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rerank", reflect.TypeOf((*MockClient)(nil).Rerank), ctx, request)
+}
@@ -1,6 +1,8 @@
 package transport
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -10,6 +12,10 @@ import (
 	openai "github.com/sashabaranov/go-openai"
 )
 
+// maxEventSize is the largest server-sent event that can be read, tool calls
+// can be streamed in a single event
+const maxEventSize = 4 * 1024 * 1024
+
 // NewOpenAIStreamingAdapter returns a new OpenAI streaming adapter which allows
 // to write into the io.Writer and read from the stream directly
 func NewOpenAIStreamingAdapter(req openai.ChatCompletionRequest) (*openai.ChatCompletionStream, *io.PipeWriter, error) {
@@ -72,3 +78,30 @@ func WriteChunk(w io.Writer, chunk []byte) error {
 
 	return nil
 }
+
+// ReadServerSentEvents calls fn with the data of each event read from r until
+// r is done or fn returns an error. Events are expected to be single line, as
+// the providers send them.
+func ReadServerSentEvents(r io.Reader, fn func(data []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventSize)
+
+	for scanner.Scan() {
+		data, ok := bytes.CutPrefix(scanner.Bytes(), []byte("data:"))
+		if !ok {
+			// Event names, ids, comments and the blank lines between events
+			continue
+		}
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 || string(data) == "[DONE]" {
+			continue
+		}
+
+		if err := fn(data); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
@@ -9,15 +9,21 @@ import (
 )
 
 type RagContent struct {
-	DocumentID string
-	Content    string
+	DataEntityID  string
+	DocumentID    string
+	Source        string
+	ContentOffset int
+	Content       string
 }
 
 type BackgroundKnowledge struct {
-	Description string
-	Content     string
-	DocumentID  string
-	Source      string // source of the document (URL)
+	KnowledgeID      string
+	KnowledgeVersion string
+	Description      string
+	Content          string
+	DocumentID       string
+	Source           string // source of the document (URL)
+	ContentOffset    int
 }
 
 type Prompt struct {
@@ -0,0 +1,64 @@
+package rag
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// rrfK dampens the impact of the top ranked results, 60 is the value
+// used in the original paper and by most search engines
+const rrfK = 60
+
+// fuseResults combines keyword and vector search results using
+// reciprocal-rank fusion: each result scores 1/(k+rank) in every list it
+// appears in. Both lists must be ordered from best to worst. The lexical and
+// semantic scores of the inputs are kept on the fused results.
+func fuseResults(lexical, semantic []*types.SessionRAGResult, limit int) []*types.SessionRAGResult {
+	var (
+		fused []*types.SessionRAGResult
+		byKey = make(map[string]*types.SessionRAGResult)
+	)
+
+	add := func(results []*types.SessionRAGResult, isLexical bool) {
+		for rank, result := range results {
+			key := resultKey(result)
+
+			existing, ok := byKey[key]
+			if !ok {
+				copied := *result
+				existing = &copied
+				existing.Score = 0
+				byKey[key] = existing
+				fused = append(fused, existing)
+			}
+
+			existing.Score += 1 / float64(rrfK+rank+1)
+
+			if isLexical {
+				existing.LexicalScore = result.LexicalScore
+			} else {
+				existing.SemanticScore = result.SemanticScore
+				existing.Distance = result.Distance
+			}
+		}
+	}
+
+	add(lexical, true)
+	add(semantic, false)
+
+	sort.SliceStable(fused, func(i, j int) bool {
+		return fused[i].Score > fused[j].Score
+	})
+
+	if limit > 0 && len(fused) > limit {
+		fused = fused[:limit]
+	}
+
+	return fused
+}
+
+func resultKey(result *types.SessionRAGResult) string {
+	return fmt.Sprintf("%s/%s/%d", result.DocumentGroupID, result.DocumentID, result.ContentOffset)
+}
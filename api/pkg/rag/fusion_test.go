@@ -0,0 +1,50 @@
+package rag
+
+import (
+	"testing"
+
+	"github.com/helixml/helix/api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_fuseResults(t *testing.T) {
+	lexical := []*types.SessionRAGResult{
+		{DocumentID: "a", LexicalScore: 3},
+		{DocumentID: "b", LexicalScore: 2},
+	}
+	semantic := []*types.SessionRAGResult{
+		{DocumentID: "c", SemanticScore: 0.9},
+		{DocumentID: "b", SemanticScore: 0.8},
+		{DocumentID: "a", SemanticScore: 0.1},
+	}
+
+	fused := fuseResults(lexical, semantic, 0)
+	require.Len(t, fused, 3)
+
+	// a: 1/61 + 1/63, b: 1/62 + 1/62, c: 1/61
+	assert.Equal(t, "a", fused[0].DocumentID)
+	assert.Equal(t, "b", fused[1].DocumentID)
+	assert.Equal(t, "c", fused[2].DocumentID)
+
+	assert.Equal(t, 3.0, fused[0].LexicalScore)
+	assert.Equal(t, 0.1, fused[0].SemanticScore)
+	assert.InDelta(t, 1.0/61+1.0/63, fused[0].Score, 1e-9)
+
+	// Inputs are not modified
+	assert.Equal(t, 0.0, lexical[0].Score)
+	assert.Equal(t, 0.0, lexical[0].SemanticScore)
+}
+
+func Test_fuseResults_Limit(t *testing.T) {
+	lexical := []*types.SessionRAGResult{
+		{DocumentID: "a"},
+		{DocumentID: "b"},
+	}
+	semantic := []*types.SessionRAGResult{
+		{DocumentID: "c"},
+	}
+
+	fused := fuseResults(lexical, semantic, 2)
+	assert.Len(t, fused, 2)
+}
@@ -13,3 +13,14 @@ type RAG interface {
 	Query(ctx context.Context, q *types.SessionRAGQuery) ([]*types.SessionRAGResult, error)
 	Delete(ctx context.Context, req *types.DeleteIndexRequest) error
 }
+
+// Copier is implemented by the RAG backends that can copy indexed chunks
+// between data entities without calling the embeddings provider again
+type Copier interface {
+	Copy(ctx context.Context, req *types.CopyIndexRequest) error
+}
+
+// Reranker reorders search results by their relevance to the query
+type Reranker interface {
+	Rerank(ctx context.Context, query string, results []*types.SessionRAGResult) ([]*types.SessionRAGResult, error)
+}
@@ -88,3 +88,80 @@ func (mr *MockRAGMockRecorder) Query(ctx, q any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockRAG)(nil).Query), ctx, q)
 }
+
+// MockCopier is a mock of Copier interface.
+type MockCopier struct {
+	ctrl     *gomock.Controller
+	recorder *MockCopierMockRecorder
+	isgomock struct{}
+}
+
+// MockCopierMockRecorder is the mock recorder for MockCopier.
+type MockCopierMockRecorder struct {
+	mock *MockCopier
+}
+
+// NewMockCopier creates a new mock instance.
+func NewMockCopier(ctrl *gomock.Controller) *MockCopier {
+	mock := &MockCopier{ctrl: ctrl}
+	mock.recorder = &MockCopierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCopier) EXPECT() *MockCopierMockRecorder {
+	return m.recorder
+}
+
+// Copy mocks base method.
+func (m *MockCopier) Copy(ctx context.Context, req *types.CopyIndexRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Copy", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Copy indicates an expected call of Copy.
+func (mr *MockCopierMockRecorder) Copy(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Copy", reflect.TypeOf((*MockCopier)(nil).Copy), ctx, req)
+}
+
+// MockReranker is a mock of Reranker interface.
+type MockReranker struct {
+	ctrl     *gomock.Controller
+	recorder *MockRerankerMockRecorder
+	isgomock struct{}
+}
+
+// MockRerankerMockRecorder is the mock recorder for MockReranker.
+type MockRerankerMockRecorder struct {
+	mock *MockReranker
+}
+
+// NewMockReranker creates a new mock instance.
+func NewMockReranker(ctrl *gomock.Controller) *MockReranker {
+	mock := &MockReranker{ctrl: ctrl}
+	mock.recorder = &MockRerankerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReranker) EXPECT() *MockRerankerMockRecorder {
+	return m.recorder
+}
+
+// Rerank mocks base method.
+func (m *MockReranker) Rerank(ctx context.Context, query string, results []*types.SessionRAGResult) ([]*types.SessionRAGResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rerank", ctx, query, results)
+	ret0, _ := ret[0].([]*types.SessionRAGResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rerank indicates an expected call of Rerank.
+func (mr *MockRerankerMockRecorder) Rerank(ctx, query, results any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rerank", reflect.TypeOf((*MockReranker)(nil).Rerank), ctx, query, results)
+}
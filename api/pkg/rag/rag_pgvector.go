@@ -19,7 +19,10 @@ type PGVector struct {
 	store           store.EmbeddingsStore
 }
 
-var _ RAG = &PGVector{}
+var (
+	_ RAG    = &PGVector{}
+	_ Copier = &PGVector{}
+)
 
 func NewPGVector(cfg *config.ServerConfig, providerManager manager.ProviderManager, store store.EmbeddingsStore) *PGVector {
 	return &PGVector{
@@ -100,7 +103,24 @@ func (p *PGVector) getEmbeddings(ctx context.Context, indexReqs []*types.Session
 }
 
 func (p *PGVector) Query(ctx context.Context, q *types.SessionRAGQuery) ([]*types.SessionRAGResult, error) {
+	semantic, err := p.queryVector(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.SearchMode != types.RAGSearchModeHybrid {
+		return semantic, nil
+	}
+
+	lexical, err := p.queryFullText(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	return fuseResults(lexical, semantic, q.MaxResults), nil
+}
 
+func (p *PGVector) queryVector(ctx context.Context, q *types.SessionRAGQuery) ([]*types.SessionRAGResult, error) {
 	client, err := p.providerManager.GetClient(ctx, &manager.GetClientRequest{
 		Provider: p.cfg.RAG.PGVector.Provider,
 	})
@@ -154,12 +174,46 @@ func (p *PGVector) Query(ctx context.Context, q *types.SessionRAGQuery) ([]*type
 			Source:          embedding.Source,
 			Content:         embedding.Content,
 			ContentOffset:   embedding.ContentOffset,
+			Distance:        embedding.Distance,
+			// Turn the L2 distance into a similarity where higher is better
+			SemanticScore: 1 / (1 + embedding.Distance),
 		})
 	}
 
 	return results, nil
 }
 
+func (p *PGVector) queryFullText(ctx context.Context, q *types.SessionRAGQuery) ([]*types.SessionRAGResult, error) {
+	embeddings, err := p.store.QueryKnowledgeEmbeddings(ctx, &types.KnowledgeEmbeddingQuery{
+		DataEntityID: q.DataEntityID,
+		Content:      q.Prompt,
+		Limit:        q.MaxResults,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error querying full text: %w", err)
+	}
+
+	var results []*types.SessionRAGResult
+
+	for _, embedding := range embeddings {
+		results = append(results, &types.SessionRAGResult{
+			DocumentGroupID: embedding.DocumentGroupID,
+			DocumentID:      embedding.DocumentID,
+			Source:          embedding.Source,
+			Content:         embedding.Content,
+			ContentOffset:   embedding.ContentOffset,
+			LexicalScore:    embedding.Rank,
+		})
+	}
+
+	return results, nil
+}
+
+// Copy reuses the stored embeddings of the documents for another data entity
+func (p *PGVector) Copy(ctx context.Context, req *types.CopyIndexRequest) error {
+	return p.store.CopyKnowledgeEmbeddings(ctx, req)
+}
+
 func (p *PGVector) Delete(ctx context.Context, req *types.DeleteIndexRequest) error {
 	return p.store.DeleteKnowledgeEmbedding(ctx, req.DataEntityID)
 }
@@ -138,3 +138,55 @@ func (suite *PGVectorTestSuite) TestIndex_1536_text_embedding_3_small() {
 	})
 	suite.Require().NoError(err)
 }
+
+func (suite *PGVectorTestSuite) TestQuery_Hybrid() {
+	suite.mockProvider.EXPECT().GetClient(suite.ctx, &manager.GetClientRequest{
+		Provider: "vllm",
+	}).Return(suite.mockClient, nil)
+
+	suite.mockClient.EXPECT().CreateEmbeddings(suite.ctx, oai.EmbeddingRequest{
+		Model: "thenlper/gte-small",
+		Input: "error E-1234",
+	}).Return(oai.EmbeddingResponse{
+		Data: []oai.Embedding{
+			{
+				Embedding: []float32{0.1, 0.2, 0.3},
+			},
+		},
+	}, nil)
+
+	suite.mockEmbeddingsStore.EXPECT().QueryKnowledgeEmbeddings(suite.ctx, &types.KnowledgeEmbeddingQuery{
+		DataEntityID: "test-data-entity-id",
+		Embedding384: pgvector.NewVector([]float32{0.1, 0.2, 0.3}),
+		Limit:        2,
+	}).Return([]*types.KnowledgeEmbeddingItem{
+		{DocumentID: "doc-semantic", Content: "something about errors", Distance: 0.5},
+		{DocumentID: "doc-both", Content: "E-1234 means the disk is full", Distance: 1},
+	}, nil)
+
+	suite.mockEmbeddingsStore.EXPECT().QueryKnowledgeEmbeddings(suite.ctx, &types.KnowledgeEmbeddingQuery{
+		DataEntityID: "test-data-entity-id",
+		Content:      "error E-1234",
+		Limit:        2,
+	}).Return([]*types.KnowledgeEmbeddingItem{
+		{DocumentID: "doc-both", Content: "E-1234 means the disk is full", Rank: 0.8},
+	}, nil)
+
+	results, err := suite.pg.Query(suite.ctx, &types.SessionRAGQuery{
+		Prompt:       "error E-1234",
+		DataEntityID: "test-data-entity-id",
+		MaxResults:   2,
+		SearchMode:   types.RAGSearchModeHybrid,
+	})
+	suite.Require().NoError(err)
+	suite.Require().Len(results, 2)
+
+	// Found by both searches so it's ranked first
+	suite.Equal("doc-both", results[0].DocumentID)
+	suite.Equal(0.8, results[0].LexicalScore)
+	suite.Equal(0.5, results[0].SemanticScore)
+
+	suite.Equal("doc-semantic", results[1].DocumentID)
+	suite.Equal(0.0, results[1].LexicalScore)
+	suite.Greater(results[0].Score, results[1].Score)
+}
@@ -3,6 +3,7 @@ package rag
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/helixml/helix/api/pkg/types"
@@ -25,7 +26,10 @@ type Typesense struct {
 	ready      chan struct{}
 }
 
-var _ RAG = &Typesense{}
+var (
+	_ RAG    = &Typesense{}
+	_ Copier = &Typesense{}
+)
 
 func NewTypesense(settings *types.RAGSettings) (*Typesense, error) {
 	client := typesense.NewClient(
@@ -136,6 +140,10 @@ func (t *Typesense) Query(ctx context.Context, q *types.SessionRAGQuery) ([]*typ
 		return nil, err
 	}
 
+	if q.SearchMode == types.RAGSearchModeHybrid {
+		return t.queryHybrid(ctx, q)
+	}
+
 	searchParameters := &api.SearchCollectionParams{
 		Q:       pointer.String(q.Prompt),
 		QueryBy: pointer.String("content,embedding"),
@@ -159,6 +167,42 @@ func (t *Typesense) Query(ctx context.Context, q *types.SessionRAGQuery) ([]*typ
 		searchParameters.Limit = pointer.Int(q.MaxResults)
 	}
 
+	return t.search(ctx, searchParameters)
+}
+
+// queryHybrid runs separate keyword and vector searches and fuses them with
+// reciprocal-rank fusion so both scores are available on the results
+func (t *Typesense) queryHybrid(ctx context.Context, q *types.SessionRAGQuery) ([]*types.SessionRAGResult, error) {
+	params := func(queryBy, sortBy string) *api.SearchCollectionParams {
+		searchParameters := &api.SearchCollectionParams{
+			Q:                pointer.String(q.Prompt),
+			QueryBy:          pointer.String(queryBy),
+			FilterBy:         pointer.String("data_entity_id:" + q.DataEntityID),
+			SortBy:           pointer.String(sortBy),
+			ExhaustiveSearch: pointer.True(),
+			Prefix:           pointer.String("false"),
+			ExcludeFields:    pointer.String("embedding"),
+		}
+		if q.MaxResults > 0 {
+			searchParameters.Limit = pointer.Int(q.MaxResults)
+		}
+		return searchParameters
+	}
+
+	lexical, err := t.search(ctx, params("content", "_text_match:desc"))
+	if err != nil {
+		return nil, fmt.Errorf("error running keyword search: %w", err)
+	}
+
+	semantic, err := t.search(ctx, params("embedding", "_vector_distance:asc"))
+	if err != nil {
+		return nil, fmt.Errorf("error running vector search: %w", err)
+	}
+
+	return fuseResults(lexical, semantic, q.MaxResults), nil
+}
+
+func (t *Typesense) search(ctx context.Context, searchParameters *api.SearchCollectionParams) ([]*types.SessionRAGResult, error) {
 	results, err := t.client.Collection(t.collection).Documents().Search(ctx, searchParameters)
 	if err != nil {
 		return nil, err
@@ -176,6 +220,14 @@ func (t *Typesense) Query(ctx context.Context, q *types.SessionRAGQuery) ([]*typ
 			Content:         getStrVariable(&hit, "content"),
 			ContentOffset:   getIntVariable(&hit, "content_offset"),
 		}
+		if hit.TextMatch != nil {
+			ragResult.LexicalScore = float64(*hit.TextMatch)
+		}
+		if hit.VectorDistance != nil {
+			// Typesense uses cosine distance
+			ragResult.Distance = float64(*hit.VectorDistance)
+			ragResult.SemanticScore = 1 - ragResult.Distance
+		}
 		ragResults = append(ragResults, ragResult)
 	}
 
@@ -194,6 +246,69 @@ func (t *Typesense) Delete(ctx context.Context, r *types.DeleteIndexRequest) err
 	return err
 }
 
+const (
+	copyPageSize  = 250
+	copyBatchSize = 100
+)
+
+// Copy re-imports the chunks of the documents under another data entity.
+// Typesense embeds the content itself so nothing is sent to an external
+// embeddings provider.
+func (t *Typesense) Copy(ctx context.Context, req *types.CopyIndexRequest) error {
+	if err := t.ensureReady(ctx); err != nil {
+		return err
+	}
+
+	// Filter by batches of document groups to keep the filter short
+	for start := 0; start < len(req.DocumentGroupIDs); start += copyBatchSize {
+		end := min(start+copyBatchSize, len(req.DocumentGroupIDs))
+
+		filter := fmt.Sprintf("data_entity_id:=%s && document_group_id:=[%s]",
+			req.FromDataEntityID, strings.Join(req.DocumentGroupIDs[start:end], ","))
+
+		for page := 1; ; page++ {
+			results, err := t.client.Collection(t.collection).Documents().Search(ctx, &api.SearchCollectionParams{
+				Q:             pointer.String("*"),
+				QueryBy:       pointer.String("content"),
+				FilterBy:      pointer.String(filter),
+				ExcludeFields: pointer.String("embedding"),
+				PerPage:       pointer.Int(copyPageSize),
+				Page:          pointer.Int(page),
+			})
+			if err != nil {
+				return fmt.Errorf("error listing documents to copy: %w", err)
+			}
+
+			if results.Hits == nil || len(*results.Hits) == 0 {
+				break
+			}
+
+			chunks := make([]*types.SessionRAGIndexChunk, 0, len(*results.Hits))
+			for _, hit := range *results.Hits {
+				chunks = append(chunks, &types.SessionRAGIndexChunk{
+					DataEntityID:    req.ToDataEntityID,
+					Source:          getStrVariable(&hit, "source"),
+					Filename:        getStrVariable(&hit, "filename"),
+					DocumentID:      getStrVariable(&hit, "document_id"),
+					DocumentGroupID: getStrVariable(&hit, "document_group_id"),
+					ContentOffset:   getIntVariable(&hit, "content_offset"),
+					Content:         getStrVariable(&hit, "content"),
+				})
+			}
+
+			if err := t.index(ctx, chunks...); err != nil {
+				return fmt.Errorf("error copying documents: %w", err)
+			}
+
+			if len(*results.Hits) < copyPageSize {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
 func getStrVariable(hit *api.SearchResultHit, key string) string {
 	val, ok := (*hit.Document)[key]
 	if !ok {
@@ -0,0 +1,198 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/openai/manager"
+	"github.com/helixml/helix/api/pkg/types"
+	"github.com/helixml/helix/api/pkg/util/jsonutil"
+
+	"github.com/rs/zerolog/log"
+	oai "github.com/sashabaranov/go-openai"
+)
+
+const DefaultRerankCandidates = 20
+
+// NewReranker returns the reranker configured in the settings, nil if reranking
+// is disabled
+func NewReranker(ctx context.Context, providerManager manager.ProviderManager, owner string, ownerType types.OwnerType, settings *types.RerankerSettings) (Reranker, error) {
+	if settings.Type == types.RerankerTypeNone {
+		return nil, nil
+	}
+
+	client, err := providerManager.GetClient(ctx, &manager.GetClientRequest{
+		Provider:  settings.Provider,
+		Owner:     owner,
+		OwnerType: ownerType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reranker client for provider '%s': %w", settings.Provider, err)
+	}
+
+	switch settings.Type {
+	case types.RerankerTypeCrossEncoder:
+		return NewCrossEncoderReranker(client, settings.Model), nil
+	case types.RerankerTypeLLM:
+		return NewLLMReranker(client, settings.Model), nil
+	default:
+		return nil, fmt.Errorf("unknown reranker type '%s'", settings.Type)
+	}
+}
+
+// Static check
+var _ Reranker = &CrossEncoderReranker{}
+
+// CrossEncoderReranker scores results with a cross-encoder model, for example
+// BAAI/bge-reranker-v2-m3 served by vLLM or TEI
+type CrossEncoderReranker struct {
+	client openai.Client
+	model  string
+}
+
+func NewCrossEncoderReranker(client openai.Client, model string) *CrossEncoderReranker {
+	return &CrossEncoderReranker{
+		client: client,
+		model:  model,
+	}
+}
+
+func (r *CrossEncoderReranker) Rerank(ctx context.Context, query string, results []*types.SessionRAGResult) ([]*types.SessionRAGResult, error) {
+	if len(results) == 0 {
+		return results, nil
+	}
+
+	documents := make([]string, 0, len(results))
+	for _, result := range results {
+		documents = append(documents, result.Content)
+	}
+
+	resp, err := r.client.Rerank(ctx, openai.RerankRequest{
+		Model:     r.model,
+		Query:     query,
+		Documents: documents,
+	})
+	if errors.Is(err, openai.ErrRerankNotSupported) {
+		log.Debug().Err(err).Str("model", r.model).Msg("skipping reranking")
+		return results, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to rerank results: %w", err)
+	}
+
+	scores := make(map[int]float64, len(resp.Results))
+	for _, result := range resp.Results {
+		scores[result.Index] = result.RelevanceScore
+	}
+
+	return sortByRerankScore(results, scores), nil
+}
+
+// Static check
+var _ Reranker = &LLMReranker{}
+
+// LLMReranker asks a chat model to judge how relevant each result is to the query
+type LLMReranker struct {
+	client openai.Client
+	model  string
+}
+
+func NewLLMReranker(client openai.Client, model string) *LLMReranker {
+	return &LLMReranker{
+		client: client,
+		model:  model,
+	}
+}
+
+const llmRerankSystemPrompt = `You are a search relevance judge. You will be given a query and a numbered list of passages.
+Score how useful each passage is for answering the query from 0 (irrelevant) to 10 (answers the query).
+Respond only with a JSON array in the form [{"index": 0, "score": 7}], one entry per passage.`
+
+type llmRerankScore struct {
+	Index int     `json:"index"`
+	Score float64 `json:"score"`
+}
+
+func (r *LLMReranker) Rerank(ctx context.Context, query string, results []*types.SessionRAGResult) ([]*types.SessionRAGResult, error) {
+	if len(results) == 0 {
+		return results, nil
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Query: %s\n\nPassages:\n", query)
+	for i, result := range results {
+		fmt.Fprintf(&prompt, "[%d] %s\n\n", i, result.Content)
+	}
+
+	resp, err := r.client.CreateChatCompletion(ctx, oai.ChatCompletionRequest{
+		Model: r.model,
+		Messages: []oai.ChatCompletionMessage{
+			{
+				Role:    oai.ChatMessageRoleSystem,
+				Content: llmRerankSystemPrompt,
+			},
+			{
+				Role:    oai.ChatMessageRoleUser,
+				Content: prompt.String(),
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to rerank results: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from reranker model")
+	}
+
+	var judged []llmRerankScore
+	err = json.Unmarshal([]byte(jsonutil.AttemptFixJSON(resp.Choices[0].Message.Content)), &judged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse reranker response: %w", err)
+	}
+
+	scores := make(map[int]float64, len(judged))
+	for _, score := range judged {
+		scores[score.Index] = score.Score
+	}
+
+	return sortByRerankScore(results, scores), nil
+}
+
+// sortByRerankScore returns a copy of the results ordered by the scores given
+// to them by the reranker. Results without a score keep their relative order
+// after the scored ones.
+func sortByRerankScore(results []*types.SessionRAGResult, scores map[int]float64) []*types.SessionRAGResult {
+	type scored struct {
+		result *types.SessionRAGResult
+		score  float64
+		ok     bool
+	}
+
+	ranked := make([]scored, 0, len(results))
+	for i, result := range results {
+		copied := *result
+		score, ok := scores[i]
+		copied.RerankScore = score
+		ranked = append(ranked, scored{result: &copied, score: score, ok: ok})
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].ok != ranked[j].ok {
+			return ranked[i].ok
+		}
+		return ranked[i].score > ranked[j].score
+	})
+
+	reranked := make([]*types.SessionRAGResult, 0, len(ranked))
+	for _, r := range ranked {
+		reranked = append(reranked, r.result)
+	}
+
+	return reranked
+}
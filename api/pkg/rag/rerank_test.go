@@ -0,0 +1,128 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/types"
+
+	oai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/suite"
+	gomock "go.uber.org/mock/gomock"
+)
+
+type RerankTestSuite struct {
+	suite.Suite
+	ctx context.Context
+
+	mockClient *openai.MockClient
+	results    []*types.SessionRAGResult
+}
+
+func TestRerankTestSuite(t *testing.T) {
+	suite.Run(t, new(RerankTestSuite))
+}
+
+func (suite *RerankTestSuite) SetupTest() {
+	suite.ctx = context.Background()
+
+	ctrl := gomock.NewController(suite.T())
+	suite.mockClient = openai.NewMockClient(ctrl)
+
+	suite.results = []*types.SessionRAGResult{
+		{DocumentID: "doc_1", Content: "the cat sat on the mat"},
+		{DocumentID: "doc_2", Content: "error E1234 means the disk is full"},
+		{DocumentID: "doc_3", Content: "disk usage can be checked with df"},
+	}
+}
+
+func (suite *RerankTestSuite) TestCrossEncoder() {
+	suite.mockClient.EXPECT().Rerank(suite.ctx, openai.RerankRequest{
+		Model:     "BAAI/bge-reranker-v2-m3",
+		Query:     "what is E1234?",
+		Documents: []string{suite.results[0].Content, suite.results[1].Content, suite.results[2].Content},
+	}).Return(openai.RerankResponse{
+		Results: []openai.RerankResult{
+			{Index: 1, RelevanceScore: 0.98},
+			{Index: 2, RelevanceScore: 0.4},
+			{Index: 0, RelevanceScore: 0.01},
+		},
+	}, nil)
+
+	reranker := NewCrossEncoderReranker(suite.mockClient, "BAAI/bge-reranker-v2-m3")
+
+	reranked, err := reranker.Rerank(suite.ctx, "what is E1234?", suite.results)
+	suite.Require().NoError(err)
+	suite.Require().Len(reranked, 3)
+
+	suite.Equal("doc_2", reranked[0].DocumentID)
+	suite.Equal(0.98, reranked[0].RerankScore)
+	suite.Equal("doc_3", reranked[1].DocumentID)
+	suite.Equal("doc_1", reranked[2].DocumentID)
+
+	// Inputs are not modified
+	suite.Equal(float64(0), suite.results[1].RerankScore)
+}
+
+func (suite *RerankTestSuite) TestCrossEncoder_Error() {
+	suite.mockClient.EXPECT().Rerank(suite.ctx, gomock.Any()).Return(openai.RerankResponse{}, fmt.Errorf("404 Not Found"))
+
+	reranker := NewCrossEncoderReranker(suite.mockClient, "BAAI/bge-reranker-v2-m3")
+
+	_, err := reranker.Rerank(suite.ctx, "what is E1234?", suite.results)
+	suite.Error(err)
+}
+
+func (suite *RerankTestSuite) TestCrossEncoder_NotSupported() {
+	suite.mockClient.EXPECT().Rerank(suite.ctx, gomock.Any()).
+		Return(openai.RerankResponse{}, fmt.Errorf("%w by Helix runners", openai.ErrRerankNotSupported))
+
+	reranker := NewCrossEncoderReranker(suite.mockClient, "BAAI/bge-reranker-v2-m3")
+
+	// The results keep their search order
+	reranked, err := reranker.Rerank(suite.ctx, "what is E1234?", suite.results)
+	suite.Require().NoError(err)
+	suite.Equal(suite.results, reranked)
+}
+
+func (suite *RerankTestSuite) TestLLM() {
+	suite.mockClient.EXPECT().CreateChatCompletion(suite.ctx, gomock.Any()).Return(oai.ChatCompletionResponse{
+		Choices: []oai.ChatCompletionChoice{
+			{
+				Message: oai.ChatCompletionMessage{
+					Content: "```json\n[{\"index\": 0, \"score\": 0}, {\"index\": 2, \"score\": 6}]\n```",
+				},
+			},
+		},
+	}, nil)
+
+	reranker := NewLLMReranker(suite.mockClient, "gpt-4o-mini")
+
+	reranked, err := reranker.Rerank(suite.ctx, "how do I check disk usage?", suite.results)
+	suite.Require().NoError(err)
+	suite.Require().Len(reranked, 3)
+
+	suite.Equal("doc_3", reranked[0].DocumentID)
+	suite.Equal("doc_1", reranked[1].DocumentID)
+	// Results the model didn't score go last
+	suite.Equal("doc_2", reranked[2].DocumentID)
+}
+
+func (suite *RerankTestSuite) TestLLM_InvalidResponse() {
+	suite.mockClient.EXPECT().CreateChatCompletion(suite.ctx, gomock.Any()).Return(oai.ChatCompletionResponse{
+		Choices: []oai.ChatCompletionChoice{
+			{
+				Message: oai.ChatCompletionMessage{
+					Content: "The second passage is the most relevant.",
+				},
+			},
+		},
+	}, nil)
+
+	reranker := NewLLMReranker(suite.mockClient, "gpt-4o-mini")
+
+	_, err := reranker.Rerank(suite.ctx, "what is E1234?", suite.results)
+	suite.Error(err)
+}
@@ -0,0 +1,33 @@
+package ratelimit
+
+import "context"
+
+type contextSubjectsKeyType int
+
+const contextSubjectsKey contextSubjectsKeyType = iota
+
+// SetContextSubjects sets the subjects the LLM calls made with the context are
+// accounted to
+func SetContextSubjects(ctx context.Context, subjects []Subject) context.Context {
+	return context.WithValue(ctx, contextSubjectsKey, subjects)
+}
+
+func GetContextSubjects(ctx context.Context) ([]Subject, bool) {
+	subjects, ok := ctx.Value(contextSubjectsKey).([]Subject)
+	return subjects, ok
+}
+
+type contextProviderKeyType int
+
+const contextProviderKey contextProviderKeyType = iota
+
+// SetContextProvider sets the provider the LLM calls made with the context are
+// accounted to, it's set by the middleware of the provider that served them
+func SetContextProvider(ctx context.Context, subject Subject) context.Context {
+	return context.WithValue(ctx, contextProviderKey, subject)
+}
+
+func GetContextProvider(ctx context.Context) (Subject, bool) {
+	subject, ok := ctx.Value(contextProviderKey).(Subject)
+	return subject, ok
+}
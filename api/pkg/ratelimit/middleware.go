@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/helixml/helix/api/pkg/model"
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+var _ oai.Client = &Middleware{}
+
+// Middleware enforces the rate limit of the provider that serves the chat
+// completions. It wraps the client of each provider, so the requests of a
+// routing group are charged to the provider they are sent to and a limited
+// provider is skipped like a failing one.
+type Middleware struct {
+	limiter *Limiter
+	subject Subject
+	client  oai.Client
+}
+
+// Wrap limits the client of a provider, the provider ID is the ID of the
+// provider endpoint or the name of the global provider
+func Wrap(limiter *Limiter, providerID string, client oai.Client) *Middleware {
+	return &Middleware{
+		limiter: limiter,
+		subject: Subject{Scope: types.RateLimitScopeProvider, ID: providerID},
+		client:  client,
+	}
+}
+
+func (m *Middleware) APIKey() string {
+	return m.client.APIKey()
+}
+
+func (m *Middleware) ListModels(ctx context.Context) ([]model.OpenAIModel, error) {
+	return m.client.ListModels(ctx)
+}
+
+// allow counts the request in the limit of the provider, the returned context
+// accounts the tokens of the call to it
+func (m *Middleware) allow(ctx context.Context) (context.Context, error) {
+	if _, err := m.limiter.Allow(ctx, []Subject{m.subject}); err != nil {
+		return ctx, err
+	}
+	return SetContextProvider(ctx, m.subject), nil
+}
+
+func (m *Middleware) CreateChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	ctx, err := m.allow(ctx)
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	return m.client.CreateChatCompletion(ctx, request)
+}
+
+func (m *Middleware) CreateChatCompletionStream(ctx context.Context, request openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error) {
+	ctx, err := m.allow(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.client.CreateChatCompletionStream(ctx, request)
+}
+
+// Not limited, embeddings and rerank calls don't count in the rate limits
+func (m *Middleware) CreateEmbeddings(ctx context.Context, request openai.EmbeddingRequest) (openai.EmbeddingResponse, error) {
+	return m.client.CreateEmbeddings(ctx, request)
+}
+
+func (m *Middleware) Rerank(ctx context.Context, request oai.RerankRequest) (oai.RerankResponse, error) {
+	return m.client.Rerank(ctx, request)
+}
@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/config"
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func TestMiddleware(t *testing.T) {
+	l, mockStore, _ := newTestLimiter(t, config.RateLimits{})
+	client := oai.NewMockClient(gomock.NewController(t))
+
+	provider := Subject{Scope: types.RateLimitScopeProvider, ID: "pe_1"}
+
+	// The limit is looked up by the ID of the provider endpoint
+	mockStore.EXPECT().GetRateLimit(gomock.Any(), &store.GetRateLimitQuery{Scope: provider.Scope, ScopeID: provider.ID}).
+		Return(&types.RateLimit{Scope: provider.Scope, ScopeID: provider.ID, RequestsPerMinute: 1}, nil)
+
+	m := Wrap(l, "pe_1", client)
+
+	// The calls of the provider are accounted to it
+	client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+			subject, ok := GetContextProvider(ctx)
+			require.True(t, ok)
+			require.Equal(t, provider, subject)
+			return openai.ChatCompletionResponse{ID: "resp"}, nil
+		})
+
+	resp, err := m.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+	require.NoError(t, err)
+	require.Equal(t, "resp", resp.ID)
+
+	// Requests over the limit aren't sent to the provider
+	_, err = m.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{})
+	var exceeded *ExceededError
+	require.ErrorAs(t, err, &exceeded)
+	require.Equal(t, provider, exceeded.Subject)
+}
@@ -0,0 +1,393 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// limitsCacheTTL is how long the limits of a subject are cached, changes made
+// by admins take effect after it
+const limitsCacheTTL = 30 * time.Second
+
+// APIKeyHashPrefix starts the scope IDs of API keys, they are hashes of the keys
+// so the keys aren't stored with the limits and usage
+const APIKeyHashPrefix = "sha256:"
+
+// APIKeyScopeID returns the scope ID of an API key, keys that are already
+// hashed are returned as they are
+func APIKeyScopeID(key string) string {
+	if strings.HasPrefix(key, APIKeyHashPrefix) {
+		return key
+	}
+	hash := sha256.Sum256([]byte(key))
+	return APIKeyHashPrefix + hex.EncodeToString(hash[:])
+}
+
+// Subject is who or what a request is accounted to
+type Subject struct {
+	Scope types.RateLimitScope
+	ID    string
+}
+
+func (s Subject) String() string {
+	// API keys are secrets, only say that the key is limited
+	if s.Scope == types.RateLimitScopeAPIKey {
+		return "API key"
+	}
+	return fmt.Sprintf("%s %s", s.Scope, s.ID)
+}
+
+// Subjects returns the subjects of a chat completion: the user, the API key
+// used and the app. The provider is limited by the Middleware once the
+// provider serving the request is known.
+func Subjects(user *types.User, appID string) []Subject {
+	var subjects []Subject
+
+	if user != nil && user.TokenType != types.TokenTypeRunner {
+		if user.ID != "" {
+			subjects = append(subjects, Subject{Scope: types.RateLimitScopeUser, ID: user.ID})
+		}
+		if user.TokenType == types.TokenTypeAPIKey && user.Token != "" {
+			subjects = append(subjects, Subject{Scope: types.RateLimitScopeAPIKey, ID: APIKeyScopeID(user.Token)})
+		}
+	}
+
+	if appID != "" {
+		subjects = append(subjects, Subject{Scope: types.RateLimitScopeApp, ID: appID})
+	}
+
+	return subjects
+}
+
+// Status is the state of the most constrained request and token limits of a
+// request, it's returned to the clients in the x-ratelimit headers
+type Status struct {
+	LimitRequests     int64
+	RemainingRequests int64
+	ResetRequests     time.Duration
+	LimitTokens       int64
+	RemainingTokens   int64
+	ResetTokens       time.Duration
+}
+
+func (s *Status) observeRequests(limit, remaining int64, reset time.Duration) {
+	if s.LimitRequests == 0 || remaining < s.RemainingRequests {
+		s.LimitRequests = limit
+		s.RemainingRequests = remaining
+		s.ResetRequests = reset
+	}
+}
+
+func (s *Status) observeTokens(limit, remaining int64, reset time.Duration) {
+	if s.LimitTokens == 0 || remaining < s.RemainingTokens {
+		s.LimitTokens = limit
+		s.RemainingTokens = remaining
+		s.ResetTokens = reset
+	}
+}
+
+// ExceededError is returned when a request goes over a limit of one of its
+// subjects
+type ExceededError struct {
+	Subject Subject
+	Limit   int64
+	// Period of the limit, a minute for requests and a day or month for tokens
+	Period     string
+	Tokens     bool
+	RetryAfter time.Duration
+	Status     *Status
+}
+
+func (e *ExceededError) Error() string {
+	unit := "requests"
+	if e.Tokens {
+		unit = "tokens"
+	}
+	return fmt.Sprintf("rate limit reached for %s: %d %s per %s, retry in %s",
+		e.Subject, e.Limit, unit, e.Period, e.RetryAfter.Round(time.Second))
+}
+
+type cachedLimit struct {
+	// limit is nil when the subject isn't limited
+	limit   *types.RateLimit
+	expires time.Time
+}
+
+// Limiter enforces the request rates and token budgets of the rate limits.
+// Requests are counted in memory over a sliding minute and tokens in the store
+// per day and month, the tokens are counted once the calls are logged.
+//
+// The request windows aren't shared between the API servers, with several
+// replicas each of them allows the requests per minute of a limit. The token
+// budgets are shared through the store.
+type Limiter struct {
+	cfg   config.RateLimits
+	store store.Store
+	now   func() time.Time
+
+	mu       sync.Mutex
+	requests map[Subject][]time.Time
+	limits   map[Subject]cachedLimit
+}
+
+func NewLimiter(cfg *config.ServerConfig, store store.Store) *Limiter {
+	return &Limiter{
+		cfg:      cfg.RateLimits,
+		store:    store,
+		now:      time.Now,
+		requests: make(map[Subject][]time.Time),
+		limits:   make(map[Subject]cachedLimit),
+	}
+}
+
+// Allow checks the limits of the subjects and counts the request, it returns
+// an *ExceededError when a limit has been reached. The status is returned
+// either way.
+func (l *Limiter) Allow(ctx context.Context, subjects []Subject) (*Status, error) {
+	now := l.now()
+	status := &Status{}
+
+	var limited []Subject
+	limits := make(map[Subject]*types.RateLimit, len(subjects))
+	for _, subject := range subjects {
+		limit := l.limit(ctx, subject, now)
+		if limit == nil {
+			continue
+		}
+		limited = append(limited, subject)
+		limits[subject] = limit
+	}
+
+	for _, subject := range limited {
+		if err := l.checkTokens(ctx, subject, limits[subject], now, status); err != nil {
+			return status, err
+		}
+	}
+
+	// Requests are checked and counted under the same lock so concurrent
+	// requests can't all take the last one
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, subject := range limited {
+		limit := limits[subject].RequestsPerMinute
+		if limit <= 0 {
+			continue
+		}
+
+		window := l.window(subject, now)
+		reset := time.Minute
+		if len(window) > 0 {
+			reset = window[0].Add(time.Minute).Sub(now)
+		}
+
+		if int64(len(window)) >= limit {
+			status.observeRequests(limit, 0, reset)
+			return status, &ExceededError{
+				Subject:    subject,
+				Limit:      limit,
+				Period:     "minute",
+				RetryAfter: reset,
+				Status:     status,
+			}
+		}
+
+		status.observeRequests(limit, limit-int64(len(window))-1, reset)
+	}
+
+	for _, subject := range limited {
+		if limits[subject].RequestsPerMinute > 0 {
+			l.requests[subject] = append(l.requests[subject], now)
+		}
+	}
+
+	return status, nil
+}
+
+// window returns the requests of the subject in the last minute, the lock
+// must be held
+func (l *Limiter) window(subject Subject, now time.Time) []time.Time {
+	window := l.requests[subject]
+
+	start := 0
+	for start < len(window) && !window[start].After(now.Add(-time.Minute)) {
+		start++
+	}
+	window = window[start:]
+
+	if len(window) == 0 {
+		delete(l.requests, subject)
+	} else {
+		l.requests[subject] = window
+	}
+
+	return window
+}
+
+func (l *Limiter) checkTokens(ctx context.Context, subject Subject, limit *types.RateLimit, now time.Time, status *Status) error {
+	budgets := []struct {
+		period types.RateLimitPeriod
+		limit  int64
+	}{
+		{period: types.RateLimitPeriodDay, limit: limit.TokensPerDay},
+		{period: types.RateLimitPeriodMonth, limit: limit.TokensPerMonth},
+	}
+
+	for _, budget := range budgets {
+		if budget.limit <= 0 {
+			continue
+		}
+
+		start, end := periodBounds(budget.period, now)
+
+		var used int64
+		usage, err := l.store.GetTokenUsage(ctx, &store.GetTokenUsageQuery{
+			Scope:       subject.Scope,
+			ScopeID:     subject.ID,
+			Period:      budget.period,
+			PeriodStart: start,
+		})
+		switch {
+		case err == nil:
+			used = usage.Tokens
+		case errors.Is(err, store.ErrNotFound):
+		default:
+			// Don't fail the requests when the budget can't be checked
+			log.Warn().Err(err).Str("scope", string(subject.Scope)).Msg("failed to get token usage, not enforcing the token budget")
+			continue
+		}
+
+		remaining := max(budget.limit-used, 0)
+		status.observeTokens(budget.limit, remaining, end.Sub(now))
+
+		if remaining == 0 {
+			return &ExceededError{
+				Subject:    subject,
+				Limit:      budget.limit,
+				Period:     string(budget.period),
+				Tokens:     true,
+				RetryAfter: end.Sub(now),
+				Status:     status,
+			}
+		}
+	}
+
+	return nil
+}
+
+// limit returns the limits of the subject, nil if it isn't limited
+func (l *Limiter) limit(ctx context.Context, subject Subject, now time.Time) *types.RateLimit {
+	l.mu.Lock()
+	cached, ok := l.limits[subject]
+	l.mu.Unlock()
+
+	if ok && now.Before(cached.expires) {
+		return cached.limit
+	}
+
+	limit, err := l.store.GetRateLimit(ctx, &store.GetRateLimitQuery{
+		Scope:   subject.Scope,
+		ScopeID: subject.ID,
+	})
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		limit = l.defaultLimit(subject)
+	case err != nil:
+		log.Warn().Err(err).Str("scope", string(subject.Scope)).Msg("failed to get rate limit, using the default")
+		return l.defaultLimit(subject)
+	}
+
+	if limit != nil && limit.RequestsPerMinute <= 0 && limit.TokensPerDay <= 0 && limit.TokensPerMonth <= 0 {
+		limit = nil
+	}
+
+	l.mu.Lock()
+	l.limits[subject] = cachedLimit{limit: limit, expires: now.Add(limitsCacheTTL)}
+	l.mu.Unlock()
+
+	return limit
+}
+
+func (l *Limiter) defaultLimit(subject Subject) *types.RateLimit {
+	switch subject.Scope {
+	case types.RateLimitScopeUser:
+		return &types.RateLimit{
+			Scope:             subject.Scope,
+			ScopeID:           subject.ID,
+			RequestsPerMinute: l.cfg.UserRequestsPerMinute,
+			TokensPerDay:      l.cfg.UserTokensPerDay,
+			TokensPerMonth:    l.cfg.UserTokensPerMonth,
+		}
+	case types.RateLimitScopeAPIKey:
+		return &types.RateLimit{
+			Scope:             subject.Scope,
+			ScopeID:           subject.ID,
+			RequestsPerMinute: l.cfg.APIKeyRequestsPerMinute,
+			TokensPerDay:      l.cfg.APIKeyTokensPerDay,
+			TokensPerMonth:    l.cfg.APIKeyTokensPerMonth,
+		}
+	}
+	return nil
+}
+
+// CreateLLMCall counts the tokens of the call in the budgets of the subjects
+// of the request. The limiter is one of the LLM call log stores so it sees the
+// calls of every provider, including the streamed ones.
+func (l *Limiter) CreateLLMCall(ctx context.Context, call *types.LLMCall) (*types.LLMCall, error) {
+	if call.TotalTokens <= 0 {
+		return call, nil
+	}
+
+	subjects, ok := GetContextSubjects(ctx)
+	if !ok {
+		// Calls made outside of the chat completions API, e.g. by sessions
+		subjects = Subjects(&types.User{ID: call.UserID}, call.AppID)
+	}
+	if provider, ok := GetContextProvider(ctx); ok {
+		subjects = append(slices.Clone(subjects), provider)
+	}
+
+	now := l.now()
+	for _, subject := range subjects {
+		for _, period := range []types.RateLimitPeriod{types.RateLimitPeriodDay, types.RateLimitPeriodMonth} {
+			start, _ := periodBounds(period, now)
+
+			err := l.store.IncrementTokenUsage(ctx, &types.TokenUsage{
+				Scope:       subject.Scope,
+				ScopeID:     subject.ID,
+				Period:      period,
+				PeriodStart: start,
+				Tokens:      call.TotalTokens,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to count tokens for %s: %w", subject, err)
+			}
+		}
+	}
+
+	return call, nil
+}
+
+// periodBounds returns the start and end of the day or month of now, in UTC
+func periodBounds(period types.RateLimitPeriod, now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	if period == types.RateLimitPeriodMonth {
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	}
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 0, 1)
+}
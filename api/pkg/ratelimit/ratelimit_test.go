@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+var testNow = time.Date(2025, 3, 14, 15, 0, 0, 0, time.UTC)
+
+func newTestLimiter(t *testing.T, limits config.RateLimits) (*Limiter, *store.MockStore, *time.Time) {
+	ctrl := gomock.NewController(t)
+	mockStore := store.NewMockStore(ctrl)
+
+	now := testNow
+	l := NewLimiter(&config.ServerConfig{RateLimits: limits}, mockStore)
+	l.now = func() time.Time { return now }
+
+	return l, mockStore, &now
+}
+
+func TestSubjects(t *testing.T) {
+	require.Empty(t, Subjects(&types.User{ID: "runner", TokenType: types.TokenTypeRunner}, ""))
+
+	require.Equal(t, []Subject{
+		{Scope: types.RateLimitScopeUser, ID: "user"},
+		{Scope: types.RateLimitScopeAPIKey, ID: "sha256:713defaae8e2dd63c390c6abd16adc7fc7bb883cb5f28effe6d52a9a0fbcf56c"},
+		{Scope: types.RateLimitScopeApp, ID: "app"},
+	}, Subjects(&types.User{ID: "user", TokenType: types.TokenTypeAPIKey, Token: "hl-key"}, "app"))
+
+	// API keys are only kept hashed
+	require.Equal(t, APIKeyScopeID("hl-key"), APIKeyScopeID(APIKeyScopeID("hl-key")))
+
+	// The API key isn't shown in errors
+	require.Equal(t, "API key", Subject{Scope: types.RateLimitScopeAPIKey, ID: "hl-key"}.String())
+}
+
+func TestLimiter_RequestsPerMinute(t *testing.T) {
+	l, mockStore, now := newTestLimiter(t, config.RateLimits{UserRequestsPerMinute: 2})
+
+	// The default applies to users without limits, the limits are looked up
+	// again once the cache expires after 30s
+	mockStore.EXPECT().GetRateLimit(gomock.Any(), gomock.Any()).Return(nil, store.ErrNotFound).Times(4)
+
+	subjects := []Subject{
+		{Scope: types.RateLimitScopeUser, ID: "user"},
+		{Scope: types.RateLimitScopeApp, ID: "app"},
+	}
+
+	status, err := l.Allow(context.Background(), subjects)
+	require.NoError(t, err)
+	require.Equal(t, &Status{LimitRequests: 2, RemainingRequests: 1, ResetRequests: time.Minute}, status)
+
+	*now = now.Add(20 * time.Second)
+	status, err = l.Allow(context.Background(), subjects)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), status.RemainingRequests)
+	require.Equal(t, 40*time.Second, status.ResetRequests)
+
+	*now = now.Add(20 * time.Second)
+	_, err = l.Allow(context.Background(), subjects)
+	var exceeded *ExceededError
+	require.ErrorAs(t, err, &exceeded)
+	require.False(t, exceeded.Tokens)
+	require.Equal(t, subjects[0], exceeded.Subject)
+	require.Equal(t, 20*time.Second, exceeded.RetryAfter)
+	require.Equal(t, "rate limit reached for user user: 2 requests per minute, retry in 20s", exceeded.Error())
+
+	// The first request leaves the window
+	*now = now.Add(20 * time.Second)
+	_, err = l.Allow(context.Background(), subjects)
+	require.NoError(t, err)
+}
+
+func TestLimiter_TokenBudgets(t *testing.T) {
+	l, mockStore, _ := newTestLimiter(t, config.RateLimits{})
+
+	app := Subject{Scope: types.RateLimitScopeApp, ID: "app"}
+
+	mockStore.EXPECT().GetRateLimit(gomock.Any(), &store.GetRateLimitQuery{Scope: app.Scope, ScopeID: app.ID}).
+		Return(&types.RateLimit{Scope: app.Scope, ScopeID: app.ID, TokensPerDay: 1000, TokensPerMonth: 10000}, nil)
+	mockStore.EXPECT().GetTokenUsage(gomock.Any(), &store.GetTokenUsageQuery{
+		Scope:       app.Scope,
+		ScopeID:     app.ID,
+		Period:      types.RateLimitPeriodDay,
+		PeriodStart: time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC),
+	}).Return(&types.TokenUsage{Tokens: 400}, nil)
+	mockStore.EXPECT().GetTokenUsage(gomock.Any(), &store.GetTokenUsageQuery{
+		Scope:       app.Scope,
+		ScopeID:     app.ID,
+		Period:      types.RateLimitPeriodMonth,
+		PeriodStart: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+	}).Return(&types.TokenUsage{Tokens: 9900}, nil)
+
+	// The month budget is the most constrained
+	status, err := l.Allow(context.Background(), []Subject{app})
+	require.NoError(t, err)
+	require.Equal(t, &Status{
+		LimitTokens:     10000,
+		RemainingTokens: 100,
+		ResetTokens:     time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC).Sub(testNow),
+	}, status)
+
+	mockStore.EXPECT().GetTokenUsage(gomock.Any(), gomock.Any()).Return(&types.TokenUsage{Tokens: 1200}, nil)
+
+	_, err = l.Allow(context.Background(), []Subject{app})
+	var exceeded *ExceededError
+	require.ErrorAs(t, err, &exceeded)
+	require.True(t, exceeded.Tokens)
+	require.Equal(t, "day", exceeded.Period)
+	require.Equal(t, 9*time.Hour, exceeded.RetryAfter)
+	require.Equal(t, int64(0), exceeded.Status.RemainingTokens)
+}
+
+func TestLimiter_CreateLLMCall(t *testing.T) {
+	l, mockStore, _ := newTestLimiter(t, config.RateLimits{})
+
+	day := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)
+	month := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// The subjects of the request are used when set
+	ctx := SetContextSubjects(context.Background(), []Subject{{Scope: types.RateLimitScopeAPIKey, ID: "hl-key"}})
+	mockStore.EXPECT().IncrementTokenUsage(gomock.Any(), &types.TokenUsage{
+		Scope: types.RateLimitScopeAPIKey, ScopeID: "hl-key", Period: types.RateLimitPeriodDay, PeriodStart: day, Tokens: 42,
+	})
+	mockStore.EXPECT().IncrementTokenUsage(gomock.Any(), &types.TokenUsage{
+		Scope: types.RateLimitScopeAPIKey, ScopeID: "hl-key", Period: types.RateLimitPeriodMonth, PeriodStart: month, Tokens: 42,
+	})
+
+	_, err := l.CreateLLMCall(ctx, &types.LLMCall{UserID: "user", TotalTokens: 42})
+	require.NoError(t, err)
+
+	// Otherwise the call's user and app, and the provider that served it
+	ctx = SetContextProvider(context.Background(), Subject{Scope: types.RateLimitScopeProvider, ID: "pe_1"})
+	var counted []Subject
+	mockStore.EXPECT().IncrementTokenUsage(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, usage *types.TokenUsage) error {
+		if usage.Period == types.RateLimitPeriodDay {
+			counted = append(counted, Subject{Scope: usage.Scope, ID: usage.ScopeID})
+		}
+		return nil
+	}).Times(6)
+
+	_, err = l.CreateLLMCall(ctx, &types.LLMCall{UserID: "user", AppID: "app", Provider: "my-openai", TotalTokens: 42})
+	require.NoError(t, err)
+	require.Equal(t, []Subject{
+		{Scope: types.RateLimitScopeUser, ID: "user"},
+		{Scope: types.RateLimitScopeApp, ID: "app"},
+		{Scope: types.RateLimitScopeProvider, ID: "pe_1"},
+	}, counted)
+
+	// Calls without usage aren't counted
+	_, err = l.CreateLLMCall(context.Background(), &types.LLMCall{UserID: "user"})
+	require.NoError(t, err)
+}
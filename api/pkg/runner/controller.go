@@ -60,6 +60,8 @@ type Options struct {
 	MemoryString string
 
 	Labels map[string]string
+	// Taints keep workloads off this runner unless they tolerate them
+	Taints map[string]string
 
 	SchedulingDecisionBufferSize int
 	JobHistoryBufferSize         int
@@ -2,6 +2,7 @@ package runner
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"os/exec"
@@ -18,6 +19,7 @@ import (
 type GPUManager struct {
 	hasGPU        bool
 	gpuMemory     uint64
+	gpuMemories   []uint64 // Memory of each GPU
 	freeMemory    uint64
 	runnerOptions *Options
 }
@@ -30,6 +32,7 @@ func NewGPUManager(ctx context.Context, runnerOptions *Options) *GPUManager {
 	// These are slow, but run on startup so it's probably fine
 	g.hasGPU = g.detectGPU()
 	g.gpuMemory = g.fetchTotalMemory()
+	g.gpuMemories = g.fetchGPUMemories()
 
 	// Start a background goroutine to refresh the free memory. We need to do this because it takes
 	// about 8 seconds to query nvidia-smi, so on hot paths that's just too long.
@@ -126,6 +129,51 @@ func (g *GPUManager) GetTotalMemory() uint64 {
 	return g.gpuMemory
 }
 
+// GetGPUMemories returns the memory of each GPU in the order of the devices,
+// runtimes that split a model across GPUs use the first ones
+func (g *GPUManager) GetGPUMemories() []uint64 {
+	return g.gpuMemories
+}
+
+func (g *GPUManager) fetchGPUMemories() []uint64 {
+	if !g.hasGPU {
+		return nil
+	}
+
+	if runtime.GOOS == "linux" {
+		cmd := exec.Command("nvidia-smi", "--query-gpu=memory.total", "--format=csv,noheader,nounits")
+		connectCmdStdErrToLogger(cmd)
+		output, err := cmd.Output()
+		if err != nil {
+			log.Error().Err(err).Msg("failed to list the GPUs")
+			return nil
+		}
+		return parseGPUMemories(output)
+	}
+
+	// Apple Silicon has a single GPU with unified memory
+	if g.gpuMemory > 0 {
+		return []uint64{g.gpuMemory}
+	}
+	return nil
+}
+
+// parseGPUMemories parses the nvidia-smi total memory output, one line in MiB
+// per GPU
+func parseGPUMemories(output []byte) []uint64 {
+	var memories []uint64
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		total, err := strconv.ParseUint(strings.TrimSpace(scanner.Text()), 10, 64)
+		if err != nil {
+			continue
+		}
+		memories = append(memories, total*1024*1024) // Convert MiB to bytes
+	}
+	return memories
+}
+
 func (g *GPUManager) fetchTotalMemory() uint64 {
 	totalMemory := g.getActualTotalMemory()
 
@@ -25,8 +25,9 @@ import (
 )
 
 var (
-	ollamaCommander Commander = &RealCommander{}
-	_               Runtime   = &OllamaRuntime{}
+	ollamaCommander Commander      = &RealCommander{}
+	_               Runtime        = &OllamaRuntime{}
+	_               MemoryReporter = &OllamaRuntime{}
 )
 
 type OllamaRuntime struct {
@@ -244,6 +245,20 @@ func (i *OllamaRuntime) Status(ctx context.Context) string {
 	return buf.String()
 }
 
+// MeasuredMemory returns the GPU memory of the models loaded by Ollama, as
+// reported by ollama ps
+func (i *OllamaRuntime) MeasuredMemory(ctx context.Context) (uint64, error) {
+	ps, err := i.ollamaClient.ListRunning(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error listing running models: %w", err)
+	}
+	var total uint64
+	for _, m := range ps.Models {
+		total += uint64(m.SizeVRAM)
+	}
+	return total, nil
+}
+
 func (i *OllamaRuntime) waitUntilOllamaIsReady(ctx context.Context, startTimeout time.Duration) error {
 	startCtx, cancel := context.WithTimeout(ctx, startTimeout)
 	defer cancel()
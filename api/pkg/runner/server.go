@@ -114,7 +114,7 @@ func (apiServer *HelixRunnerAPIServer) healthz(w http.ResponseWriter, _ *http.Re
 	}
 }
 
-func (apiServer *HelixRunnerAPIServer) status(w http.ResponseWriter, _ *http.Request) {
+func (apiServer *HelixRunnerAPIServer) status(w http.ResponseWriter, r *http.Request) {
 	status := &types.RunnerStatus{
 		ID:          apiServer.runnerOptions.ID,
 		Created:     startTime,
@@ -123,7 +123,19 @@ func (apiServer *HelixRunnerAPIServer) status(w http.ResponseWriter, _ *http.Req
 		TotalMemory: apiServer.gpuManager.GetTotalMemory(),
 		FreeMemory:  apiServer.gpuManager.GetFreeMemory(),
 		Labels:      apiServer.runnerOptions.Labels,
+		Taints:      apiServer.runnerOptions.Taints,
 	}
+	apiServer.slots.Range(func(id uuid.UUID, slot *Slot) bool {
+		if memory := slot.MeasuredMemory(r.Context()); memory > 0 {
+			status.SlotMemory = append(status.SlotMemory, &types.RunnerSlotMemory{
+				ID:      id,
+				Runtime: slot.Runtime(),
+				Model:   slot.Model,
+				Memory:  memory,
+			})
+		}
+		return true
+	})
 	err := json.NewEncoder(w).Encode(status)
 	if err != nil {
 		log.Error().Err(err).Msg("error encoding status response")
@@ -160,6 +172,7 @@ func (apiServer *HelixRunnerAPIServer) createSlot(w http.ResponseWriter, r *http
 		ID:            slotRequest.ID,
 		Runtime:       slotRequest.Attributes.Runtime,
 		Model:         slotRequest.Attributes.Model,
+		GPUMemories:   apiServer.gpuManager.GetGPUMemories(),
 	})
 	apiServer.slots.Store(slotRequest.ID, s)
 
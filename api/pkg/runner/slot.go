@@ -3,9 +3,12 @@ package runner
 import (
 	"context"
 	"fmt"
+	"math"
+	"slices"
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/helixml/helix/api/pkg/model"
 	"github.com/helixml/helix/api/pkg/types"
 	"github.com/rs/zerolog/log"
 )
@@ -23,6 +26,7 @@ type Slot struct {
 	Ready           bool // True if the slot is ready to be used
 	runnerOptions   *Options
 	runningRuntime  Runtime
+	gpuMemories     []uint64
 }
 
 type PullProgress struct {
@@ -42,11 +46,18 @@ type Runtime interface {
 	URL() string
 }
 
+// MemoryReporter is implemented by runtimes that can measure the GPU memory
+// their models use
+type MemoryReporter interface {
+	MeasuredMemory(ctx context.Context) (uint64, error)
+}
+
 type CreateSlotParams struct {
 	RunnerOptions *Options
 	ID            uuid.UUID
 	Runtime       types.Runtime
 	Model         string
+	GPUMemories   []uint64 // Memory of each GPU, used to size runtimes that preallocate memory
 }
 
 func NewEmptySlot(params CreateSlotParams) *Slot {
@@ -59,6 +70,7 @@ func NewEmptySlot(params CreateSlotParams) *Slot {
 		Ready:           false,
 		runnerOptions:   params.RunnerOptions,
 		runningRuntime:  nil, // This is set during creation
+		gpuMemories:     params.GPUMemories,
 	}
 }
 
@@ -94,6 +106,22 @@ func (s *Slot) Create(ctx context.Context) (err error) {
 		if err != nil {
 			return
 		}
+	case types.RuntimeVLLM:
+		var m *model.VLLMGenericText
+		m, err = getVLLMModel(s.Model)
+		if err != nil {
+			return
+		}
+		s.runningRuntime, err = NewVLLMRuntime(ctx, VLLMRuntimeParams{
+			Model:                s.Model,
+			Args:                 m.Args,
+			GPUMemoryUtilization: gpuMemoryUtilization(m.Memory, assignedGPUs(s.gpuMemories, m.GetTensorParallelSize())),
+			ContextLength:        m.GetContextLength(),
+			CacheDir:             &s.runnerOptions.CacheDir,
+		})
+		if err != nil {
+			return
+		}
 	case types.RuntimeAxolotl:
 		s.runningRuntime, err = NewAxolotlRuntime(ctx, AxolotlRuntimeParams{
 			RunnerOptions: s.runnerOptions,
@@ -177,9 +205,62 @@ func (s *Slot) Status(ctx context.Context) string {
 	return "unknown"
 }
 
+// MeasuredMemory returns the GPU memory used by the slot, 0 when the runtime
+// can't measure it
+func (s *Slot) MeasuredMemory(ctx context.Context) uint64 {
+	reporter, ok := s.runningRuntime.(MemoryReporter)
+	if !ok || !s.Ready {
+		return 0
+	}
+	memory, err := reporter.MeasuredMemory(ctx)
+	if err != nil {
+		log.Warn().Err(err).Str("model", s.Model).Msg("error measuring slot memory")
+		return 0
+	}
+	return memory
+}
+
 func (s *Slot) URL() string {
 	if s.runningRuntime != nil {
 		return s.runningRuntime.URL()
 	}
 	return ""
 }
+
+func getVLLMModel(name string) (*model.VLLMGenericText, error) {
+	m, err := model.GetModel(name)
+	if err != nil {
+		return nil, err
+	}
+	vllmModel, ok := m.(*model.VLLMGenericText)
+	if !ok {
+		return nil, fmt.Errorf("model %s is not a vllm model", name)
+	}
+	return vllmModel, nil
+}
+
+// assignedGPUs returns the memory of the GPUs vLLM runs the model on, the
+// first ones of the runner. Nil if the runner doesn't have enough GPUs.
+func assignedGPUs(gpuMemories []uint64, tensorParallelSize int) []uint64 {
+	if tensorParallelSize > len(gpuMemories) {
+		return nil
+	}
+	return gpuMemories[:tensorParallelSize]
+}
+
+// gpuMemoryUtilization returns the fraction of each GPU the model may use, so
+// vLLM doesn't preallocate the memory of the other slots on the runner. The
+// model is split evenly across the GPUs, the smallest one limits the fraction.
+func gpuMemoryUtilization(required uint64, gpuMemories []uint64) float64 {
+	if required == 0 || len(gpuMemories) == 0 {
+		return 0
+	}
+
+	perGPU := (required + uint64(len(gpuMemories)) - 1) / uint64(len(gpuMemories))
+	smallest := slices.Min(gpuMemories)
+	if smallest == 0 || perGPU >= smallest {
+		return 0
+	}
+	// Round up so the model always fits in the fraction passed to vLLM
+	return math.Ceil(float64(perGPU)/float64(smallest)*100) / 100
+}
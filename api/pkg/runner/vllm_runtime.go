@@ -0,0 +1,450 @@
+//go:build !windows
+// +build !windows
+
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/helixml/helix/api/pkg/freeport"
+	"github.com/helixml/helix/api/pkg/model"
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+	"github.com/rs/zerolog/log"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+var (
+	vllmCommander Commander      = &RealCommander{}
+	_             Runtime        = &VLLMRuntime{}
+	_             MemoryReporter = &VLLMRuntime{}
+)
+
+// VLLMRuntime serves a single HuggingFace model with vLLM's OpenAI compatible
+// server. Unlike Ollama the model is loaded when the process starts.
+type VLLMRuntime struct {
+	version              string
+	model                string
+	args                 []string
+	gpuMemoryUtilization float64
+	contextLength        int64
+	cacheDir             string
+	port                 int
+	startTimeout         time.Duration
+	httpClient           *http.Client
+	cmd                  *exec.Cmd
+	exited               <-chan error
+	cancel               context.CancelFunc
+}
+
+type VLLMRuntimeParams struct {
+	Model                string         // The HuggingFace model to serve
+	Args                 []string       // Extra arguments for vllm serve
+	GPUMemoryUtilization float64        // Fraction of the GPU memory vLLM preallocates, vLLM's default if 0
+	ContextLength        int64          // Context length to serve, the model's maximum if 0
+	CacheDir             *string        // Where to store the models
+	Port                 *int           // If nil, will be assigned a random port
+	StartTimeout         *time.Duration // How long to wait for vLLM to download and load the model
+}
+
+func NewVLLMRuntime(_ context.Context, params VLLMRuntimeParams) (*VLLMRuntime, error) {
+	if params.Model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+
+	defaultCacheDir := os.TempDir()
+	if params.CacheDir == nil {
+		params.CacheDir = &defaultCacheDir
+	}
+
+	// Large models take a while to download and load into the GPU
+	defaultStartTimeout := 30 * time.Minute
+	if params.StartTimeout == nil {
+		params.StartTimeout = &defaultStartTimeout
+	}
+	if params.Port == nil {
+		port, err := freeport.GetFreePort()
+		if err != nil {
+			return nil, fmt.Errorf("error getting free port: %s", err.Error())
+		}
+		params.Port = &port
+		log.Debug().Int("port", *params.Port).Msg("Found free port")
+	}
+
+	return &VLLMRuntime{
+		version:              "unknown",
+		model:                params.Model,
+		args:                 params.Args,
+		gpuMemoryUtilization: params.GPUMemoryUtilization,
+		contextLength:        params.ContextLength,
+		cacheDir:             *params.CacheDir,
+		port:                 *params.Port,
+		startTimeout:         *params.StartTimeout,
+		httpClient:           http.DefaultClient,
+	}, nil
+}
+
+func (v *VLLMRuntime) Start(ctx context.Context) error {
+	log.Debug().Str("model", v.model).Msg("Starting vLLM runtime")
+
+	// Make sure the port is not already in use
+	if isPortInUse(v.port) {
+		return fmt.Errorf("port %d is already in use", v.port)
+	}
+
+	// Check if the cache dir exists, if not create it
+	if _, err := os.Stat(v.cacheDir); os.IsNotExist(err) {
+		if err := os.MkdirAll(v.cacheDir, 0755); err != nil {
+			return fmt.Errorf("error creating cache dir: %s", err.Error())
+		}
+	}
+
+	// Prepare vllm cmd context (a cancel context)
+	ctx, cancel := context.WithCancel(ctx)
+	v.cancel = cancel
+	var err error
+	defer func() {
+		// If there is an error at any point after this, cancel the context to cancel the cmd
+		if err != nil {
+			v.cancel()
+		}
+	}()
+
+	cmd, exited, err := startVLLMCmd(ctx, vllmCommander, v.vllmArgs(), v.cacheDir)
+	if err != nil {
+		return fmt.Errorf("error building vllm cmd: %w", err)
+	}
+	v.cmd = cmd
+	v.exited = exited
+
+	// Wait for vLLM to download and load the model
+	log.Debug().Str("url", v.URL()).Dur("timeout", v.startTimeout).Msg("Waiting for vLLM to start")
+	err = v.waitUntilVLLMIsReady(ctx)
+	if err != nil {
+		return fmt.Errorf("error waiting for vLLM to start: %s", err.Error())
+	}
+	log.Info().Str("model", v.model).Msg("vLLM has started")
+
+	version, err := v.getVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting vllm version: %w", err)
+	}
+	v.version = version
+
+	return nil
+}
+
+// vllmArgs returns the arguments of vllm serve, the model is served under its
+// HuggingFace name so requests don't need rewriting
+func (v *VLLMRuntime) vllmArgs() []string {
+	args := []string{
+		"serve", v.model,
+		"--host", "127.0.0.1",
+		"--port", strconv.Itoa(v.port),
+		"--served-model-name", v.model,
+		"--download-dir", v.cacheDir,
+	}
+	if v.gpuMemoryUtilization > 0 {
+		args = append(args, "--gpu-memory-utilization", strconv.FormatFloat(v.gpuMemoryUtilization, 'f', 2, 64))
+	}
+	// The scheduler sizes the slot for the context length of the model, extra
+	// arguments setting it win
+	if v.contextLength > 0 && !slices.ContainsFunc(v.args, isMaxModelLenArg) {
+		args = append(args, model.VLLMMaxModelLenArg, strconv.FormatInt(v.contextLength, 10))
+	}
+	return append(args, v.args...)
+}
+
+func isMaxModelLenArg(arg string) bool {
+	return arg == model.VLLMMaxModelLenArg || strings.HasPrefix(arg, model.VLLMMaxModelLenArg+"=")
+}
+
+func (v *VLLMRuntime) URL() string {
+	return fmt.Sprintf("http://localhost:%d", v.port)
+}
+
+func (v *VLLMRuntime) Stop() error {
+	if v.cancel != nil {
+		defer v.cancel() // Cancel the context no matter what
+	}
+
+	if v.cmd == nil {
+		return nil
+	}
+	log.Info().Int("pid", v.cmd.Process.Pid).Msg("Stopping vLLM runtime")
+	if err := killProcessTree(v.cmd.Process.Pid); err != nil {
+		log.Error().Msgf("error stopping vLLM model process: %s", err.Error())
+		return err
+	}
+	log.Info().Msg("vLLM runtime stopped")
+	return nil
+}
+
+// PullModel is a no-op, vLLM downloads the model it serves when it starts
+func (v *VLLMRuntime) PullModel(_ context.Context, modelName string, _ func(progress PullProgress) error) error {
+	if modelName != v.model {
+		return fmt.Errorf("vllm runtime serves %s, can't pull %s", v.model, modelName)
+	}
+	return nil
+}
+
+func (v *VLLMRuntime) Warm(ctx context.Context, model string) error {
+	client, err := CreateOpenaiClient(ctx, fmt.Sprintf("%s/v1", v.URL()))
+	if err != nil {
+		return err
+	}
+
+	_, err = client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: "Say the word 'warm'.",
+			},
+		},
+		MaxTokens: 8,
+	})
+	return err
+}
+
+func (v *VLLMRuntime) Runtime() types.Runtime {
+	return types.RuntimeVLLM
+}
+
+func (v *VLLMRuntime) Version() string {
+	return v.version
+}
+
+// MeasuredMemory returns the GPU memory used by the vLLM process and its
+// workers, as reported by nvidia-smi
+func (v *VLLMRuntime) MeasuredMemory(ctx context.Context) (uint64, error) {
+	if v.cmd == nil || v.cmd.Process == nil {
+		return 0, fmt.Errorf("vllm is not running")
+	}
+
+	// Tensor parallel workers are child processes, each with its own memory
+	pids, err := getAllDescendants(v.cmd.Process.Pid)
+	if err != nil {
+		return 0, fmt.Errorf("error listing vllm processes: %w", err)
+	}
+	pids = append(pids, v.cmd.Process.Pid)
+
+	cmd := vllmCommander.CommandContext(ctx, "nvidia-smi", "--query-compute-apps=pid,used_memory", "--format=csv,noheader,nounits")
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("error querying gpu processes: %w", err)
+	}
+
+	return processesGPUMemory(output, pids)
+}
+
+// processesGPUMemory sums the memory of the processes in the nvidia-smi
+// compute apps output, one "pid, used memory in MiB" line per process and GPU
+func processesGPUMemory(output []byte, pids []int) (uint64, error) {
+	var (
+		total uint64
+		found bool
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ",")
+		if len(fields) != 2 {
+			continue
+		}
+
+		pid, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+		if err != nil || !slices.Contains(pids, pid) {
+			continue
+		}
+
+		used, err := strconv.ParseUint(strings.TrimSpace(fields[1]), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		total += used * 1024 * 1024 // Convert MiB to bytes
+		found = true
+	}
+
+	if !found {
+		return 0, fmt.Errorf("no gpu memory used by the vllm processes")
+	}
+	return total, nil
+}
+
+// Status reports the requests vLLM is batching from its Prometheus metrics
+func (v *VLLMRuntime) Status(ctx context.Context) string {
+	body, err := v.get(ctx, "/metrics")
+	if err != nil {
+		return fmt.Sprintf("error getting vllm status: %s", err.Error())
+	}
+
+	metrics := parseVLLMMetrics(body)
+
+	return fmt.Sprintf(" %s %d running, %d waiting, %.0f%% GPU KV cache\n",
+		v.model,
+		int(metrics["vllm:num_requests_running"]),
+		int(metrics["vllm:num_requests_waiting"]),
+		metrics["vllm:gpu_cache_usage_perc"]*100,
+	)
+}
+
+// parseVLLMMetrics sums the Prometheus samples by metric name, vLLM labels
+// every sample with the model name
+func parseVLLMMetrics(body []byte) map[string]float64 {
+	metrics := make(map[string]float64)
+
+	scanner := bufio.NewScanner(strings.NewReader(string(body)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		name := fields[0]
+		if i := strings.Index(name, "{"); i >= 0 {
+			name = name[:i]
+		}
+
+		value, err := strconv.ParseFloat(fields[len(fields)-1], 64)
+		if err != nil {
+			continue
+		}
+		metrics[name] += value
+	}
+
+	return metrics
+}
+
+func (v *VLLMRuntime) getVersion(ctx context.Context) (string, error) {
+	body, err := v.get(ctx, "/version")
+	if err != nil {
+		return "", err
+	}
+
+	var version struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(body, &version); err != nil {
+		return "", fmt.Errorf("error decoding version: %w", err)
+	}
+	return version.Version, nil
+}
+
+func (v *VLLMRuntime) get(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.URL()+path, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+func (v *VLLMRuntime) waitUntilVLLMIsReady(ctx context.Context) error {
+	startCtx, cancel := context.WithTimeout(ctx, v.startTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-startCtx.Done():
+			return startCtx.Err()
+		case err := <-v.exited:
+			// vLLM failed to load the model, e.g. it doesn't fit in the GPU
+			return fmt.Errorf("vllm exited before it was ready: %w", err)
+		case <-ticker.C:
+			// The health endpoint only responds once the model is loaded
+			_, err := v.get(startCtx, "/health")
+			if err != nil {
+				continue
+			}
+			return nil
+		}
+	}
+}
+
+// startVLLMCmd starts vllm serve, the returned channel receives the error of
+// the process when it exits
+func startVLLMCmd(ctx context.Context, commander Commander, args []string, cacheDir string) (*exec.Cmd, <-chan error, error) {
+	// Find vllm on the path
+	vllmPath, err := commander.LookPath("vllm")
+	if err != nil {
+		return nil, nil, fmt.Errorf("vllm not found in PATH")
+	}
+	log.Debug().Str("vllm_path", vllmPath).Msg("Found vllm")
+
+	log.Debug().Strs("args", args).Msg("Preparing vllm serve command")
+	cmd := commander.CommandContext(ctx, vllmPath, args...)
+	cmd.Env = append(cmd.Env,
+		"HOME="+os.Getenv("HOME"),
+		"PATH="+os.Getenv("PATH"),
+		"HTTP_PROXY="+os.Getenv("HTTP_PROXY"),
+		"HTTPS_PROXY="+os.Getenv("HTTPS_PROXY"),
+		"HF_TOKEN="+os.Getenv("HF_TOKEN"), // Required for gated models
+		"HF_HOME="+cacheDir,
+		"CUDA_VISIBLE_DEVICES="+os.Getenv("CUDA_VISIBLE_DEVICES"),
+		"PYTHONUNBUFFERED=1", // Get logs in real time
+	)
+
+	// Prepare stdout and stderr
+	cmd.Stdout = os.Stdout
+	// this buffer is so we can keep the last 10kb of stderr so if
+	// there is an error we can send it to the api
+	stderrBuf := system.NewLimitedBuffer(1024 * 10)
+	// Wait copies all of stderr before it returns, so the error of an early
+	// exit has the output
+	cmd.Stderr = io.MultiWriter(os.Stderr, stderrBuf)
+
+	log.Debug().Msg("Starting vllm serve")
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("error starting vLLM model instance: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			errMsg := string(stderrBuf.Bytes())
+			log.Error().Err(err).Str("stderr", errMsg).Int("exit_code", cmd.ProcessState.ExitCode()).Msg("vLLM exited with error")
+
+			exited <- fmt.Errorf("%w: %s", err, errMsg)
+			return
+		}
+		exited <- fmt.Errorf("exit code %d", cmd.ProcessState.ExitCode())
+	}()
+
+	return cmd, exited, nil
+}
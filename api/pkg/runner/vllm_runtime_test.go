@@ -0,0 +1,275 @@
+//go:build !windows
+// +build !windows
+
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/freeport"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+const testVLLMModel = "Qwen/Qwen2.5-7B-Instruct"
+
+// TestVLLMHelperProcess is not a real test, it's started by the mocked
+// Commander in place of vllm and serves a fake OpenAI compatible server on the
+// port from the arguments
+func TestVLLMHelperProcess(_ *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	if os.Getenv("GO_HELPER_PROCESS_CRASH") == "1" {
+		fmt.Fprint(os.Stderr, "torch.OutOfMemoryError: CUDA out of memory")
+		os.Exit(1)
+	}
+
+	args := os.Args[slices.Index(os.Args, "--")+1:]
+	port := args[slices.Index(args, "--port")+1]
+	model := args[slices.Index(args, "--served-model-name")+1]
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/version", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"version":"0.6.6"}`)
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, "# HELP vllm:num_requests_running Number of requests running.\n"+
+			"vllm:num_requests_running{model_name=%q} 3.0\n"+
+			"vllm:num_requests_waiting{model_name=%q} 1.0\n"+
+			"vllm:gpu_cache_usage_perc{model_name=%q} 0.25\n", model, model, model)
+	})
+	mux.HandleFunc("/v1/models", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, `{"object":"list","data":[{"id":%q,"object":"model"}]}`, model)
+	})
+	mux.HandleFunc("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string `json:"model"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != model {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"error":{"message":"model %s does not exist"}}`, req.Model)
+			return
+		}
+		fmt.Fprintf(w, `{"id":"1","object":"chat.completion","model":%q,"choices":[{"index":0,"message":{"role":"assistant","content":"warm"}}]}`, model)
+	})
+
+	_ = http.ListenAndServe("127.0.0.1:"+port, mux)
+	os.Exit(0)
+}
+
+func newTestVLLMRuntime(t *testing.T) (*VLLMRuntime, *[]string) {
+	ctrl := gomock.NewController(t)
+	commander := NewMockCommander(ctrl)
+
+	var args []string
+	commander.EXPECT().LookPath("vllm").Return("/usr/bin/vllm", nil)
+	commander.EXPECT().CommandContext(gomock.Any(), "/usr/bin/vllm", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ string, arg ...string) *exec.Cmd {
+			args = arg
+			cmd := exec.CommandContext(ctx, os.Args[0], append([]string{"-test.run=TestVLLMHelperProcess", "--"}, arg...)...)
+			cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+			return cmd
+		},
+	)
+
+	var runtime *VLLMRuntime
+
+	// nvidia-smi lists the vLLM process and one of another program
+	commander.EXPECT().CommandContext(gomock.Any(), "nvidia-smi", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ string, _ ...string) *exec.Cmd {
+			return exec.CommandContext(ctx, "echo", fmt.Sprintf("1, 1024\n%d, 20480", runtime.cmd.Process.Pid))
+		},
+	).AnyTimes()
+
+	previous := vllmCommander
+	vllmCommander = commander
+	t.Cleanup(func() { vllmCommander = previous })
+
+	port, err := freeport.GetFreePort()
+	require.NoError(t, err)
+
+	cacheDir := t.TempDir()
+	startTimeout := 10 * time.Second
+
+	runtime, err = NewVLLMRuntime(context.Background(), VLLMRuntimeParams{
+		Model:                testVLLMModel,
+		Args:                 []string{"--enable-prefix-caching"},
+		GPUMemoryUtilization: 0.3,
+		ContextLength:        32768,
+		CacheDir:             &cacheDir,
+		Port:                 &port,
+		StartTimeout:         &startTimeout,
+	})
+	require.NoError(t, err)
+
+	return runtime, &args
+}
+
+func TestVLLMRuntime(t *testing.T) {
+	runtime, args := newTestVLLMRuntime(t)
+
+	ctx := context.Background()
+
+	require.NoError(t, runtime.Start(ctx))
+	defer func() {
+		require.NoError(t, runtime.Stop())
+	}()
+
+	require.Equal(t, []string{
+		"serve", testVLLMModel,
+		"--host", "127.0.0.1",
+		"--port", fmt.Sprint(runtime.port),
+		"--served-model-name", testVLLMModel,
+		"--download-dir", runtime.cacheDir,
+		"--gpu-memory-utilization", "0.30",
+		"--max-model-len", "32768",
+		"--enable-prefix-caching",
+	}, *args)
+
+	require.Equal(t, "0.6.6", runtime.Version())
+	require.Equal(t, types.RuntimeVLLM, runtime.Runtime())
+
+	require.NoError(t, runtime.PullModel(ctx, testVLLMModel, nil))
+	require.Error(t, runtime.PullModel(ctx, "other/model", nil))
+
+	require.NoError(t, runtime.Warm(ctx, testVLLMModel))
+	require.Error(t, runtime.Warm(ctx, "other/model"))
+
+	require.Equal(t, " Qwen/Qwen2.5-7B-Instruct 3 running, 1 waiting, 25% GPU KV cache\n", runtime.Status(ctx))
+
+	memory, err := runtime.MeasuredMemory(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(20480*1024*1024), memory)
+}
+
+func Test_processesGPUMemory(t *testing.T) {
+	output := []byte("100, 20480\n101, 1024\n102, 2048\n")
+
+	// The memory of the tensor parallel workers adds up
+	memory, err := processesGPUMemory(output, []int{100, 102})
+	require.NoError(t, err)
+	require.Equal(t, uint64(22528*1024*1024), memory)
+
+	_, err = processesGPUMemory(output, []int{200})
+	require.Error(t, err)
+
+	_, err = processesGPUMemory([]byte("No running processes found\n"), []int{100})
+	require.Error(t, err)
+}
+
+func TestVLLMRuntime_NotInstalled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	commander := NewMockCommander(ctrl)
+	commander.EXPECT().LookPath("vllm").Return("", exec.ErrNotFound)
+
+	previous := vllmCommander
+	vllmCommander = commander
+	t.Cleanup(func() { vllmCommander = previous })
+
+	cacheDir := t.TempDir()
+	runtime, err := NewVLLMRuntime(context.Background(), VLLMRuntimeParams{
+		Model:    testVLLMModel,
+		CacheDir: &cacheDir,
+	})
+	require.NoError(t, err)
+
+	err = runtime.Start(context.Background())
+	require.ErrorContains(t, err, "vllm not found in PATH")
+}
+
+func TestVLLMRuntime_ExitedOnStart(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	commander := NewMockCommander(ctrl)
+	commander.EXPECT().LookPath("vllm").Return("/usr/bin/vllm", nil)
+	commander.EXPECT().CommandContext(gomock.Any(), "/usr/bin/vllm", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ string, arg ...string) *exec.Cmd {
+			cmd := exec.CommandContext(ctx, os.Args[0], append([]string{"-test.run=TestVLLMHelperProcess", "--"}, arg...)...)
+			cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1", "GO_HELPER_PROCESS_CRASH=1"}
+			return cmd
+		},
+	)
+
+	previous := vllmCommander
+	vllmCommander = commander
+	t.Cleanup(func() { vllmCommander = previous })
+
+	cacheDir := t.TempDir()
+	startTimeout := time.Hour
+	runtime, err := NewVLLMRuntime(context.Background(), VLLMRuntimeParams{
+		Model:        testVLLMModel,
+		CacheDir:     &cacheDir,
+		StartTimeout: &startTimeout,
+	})
+	require.NoError(t, err)
+
+	// Start returns when vLLM exits instead of waiting for the start timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	err = runtime.Start(ctx)
+	require.ErrorContains(t, err, "vllm exited before it was ready")
+	require.ErrorContains(t, err, "CUDA out of memory")
+	require.NoError(t, ctx.Err())
+}
+
+func TestVLLMRuntime_vllmArgs_MaxModelLen(t *testing.T) {
+	runtime := &VLLMRuntime{
+		model:         testVLLMModel,
+		port:          8000,
+		cacheDir:      "/cache",
+		contextLength: 32768,
+		args:          []string{"--max-model-len=8192"},
+	}
+
+	// The context length set in the arguments of the model wins
+	require.Equal(t, []string{
+		"serve", testVLLMModel,
+		"--host", "127.0.0.1",
+		"--port", "8000",
+		"--served-model-name", testVLLMModel,
+		"--download-dir", "/cache",
+		"--max-model-len=8192",
+	}, runtime.vllmArgs())
+}
+
+func Test_gpuMemoryUtilization(t *testing.T) {
+	require.Equal(t, 0.25, gpuMemoryUtilization(20, []uint64{80}))
+	require.Equal(t, 0.34, gpuMemoryUtilization(1, []uint64{3}))
+	require.Equal(t, 0.0, gpuMemoryUtilization(80, []uint64{80}))
+	require.Equal(t, 0.0, gpuMemoryUtilization(20, []uint64{0}))
+	require.Equal(t, 0.0, gpuMemoryUtilization(20, nil))
+
+	// The model is split across the GPUs, the smallest limits the fraction
+	require.Equal(t, 0.25, gpuMemoryUtilization(40, []uint64{80, 80}))
+	require.Equal(t, 0.5, gpuMemoryUtilization(40, []uint64{80, 40}))
+}
+
+func Test_assignedGPUs(t *testing.T) {
+	gpus := []uint64{80, 40, 24}
+
+	// A single GPU model only counts the memory of the first GPU, not the
+	// whole runner
+	require.Equal(t, []uint64{80}, assignedGPUs(gpus, 1))
+	require.Equal(t, []uint64{80, 40}, assignedGPUs(gpus, 2))
+	require.Nil(t, assignedGPUs(gpus, 4))
+}
+
+func Test_parseGPUMemories(t *testing.T) {
+	require.Equal(t, []uint64{81920 * 1024 * 1024, 24576 * 1024 * 1024}, parseGPUMemories([]byte("81920\n24576\n")))
+	require.Empty(t, parseGPUMemories([]byte("No devices were found\n")))
+}
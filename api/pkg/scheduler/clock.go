@@ -0,0 +1,15 @@
+package scheduler
+
+import "time"
+
+// Clock tells the scheduler the time, the simulator replaces it to replay
+// traces faster than real time
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
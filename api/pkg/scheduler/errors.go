@@ -11,7 +11,9 @@ var (
 	ErrRunnersAreFull     = errors.New("runner is full and no slots are stale")
 	ErrNoRunnersAvailable = errors.New("no runners available")
 	ErrModelWontFit       = errors.New("model won't fit in any runner")
+	ErrNoMatchingRunners  = errors.New("no runner matches the runner selectors")
 	ErrPendingSlotsFull   = errors.New("pending slots are full")
+	ErrSchedulerRestarted = errors.New("the scheduler restarted before the request was scheduled, please retry")
 )
 
 // ErrorHandlingStrategy is a function that handles errors returned by the scheduler.
@@ -42,6 +44,12 @@ func ErrorHandlingStrategy(schedulerError error, work *Workload) (bool, error) {
 		return false, fmt.Errorf("no runners available to schedule work: %w", schedulerError)
 	}
 
+	// If no runner matches the selectors, fail the request.
+	if errors.Is(schedulerError, ErrNoMatchingRunners) {
+		l.Warn().Err(schedulerError).Msgf("no runner matches the runner selectors of the work")
+		return false, fmt.Errorf("no runner matches the runner selectors: %w", schedulerError)
+	}
+
 	// If the model won't fit in any available runner, fail the request.
 	if errors.Is(schedulerError, ErrModelWontFit) {
 		l.Warn().Err(schedulerError).Msgf("model won't fit in any runner, please add a bigger runner")
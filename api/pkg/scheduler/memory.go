@@ -0,0 +1,136 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// footprintKey identifies models that use the same amount of GPU memory, the
+// KV cache grows with the context length so it's part of the key
+type footprintKey struct {
+	runtime       types.Runtime
+	model         string
+	contextLength int64
+}
+
+// footprintTTL is how long a footprint is used without being measured again,
+// models measure differently once the runners upgrade their runtimes
+const footprintTTL = time.Hour
+
+type footprint struct {
+	memory   uint64
+	observed time.Time
+}
+
+// memoryFootprints holds the GPU memory measured by the runners for each model,
+// it's used instead of the static model estimates once a model has been loaded
+type memoryFootprints struct {
+	mu         sync.RWMutex
+	footprints map[footprintKey]footprint
+}
+
+func newMemoryFootprints() *memoryFootprints {
+	return &memoryFootprints{
+		footprints: make(map[footprintKey]footprint),
+	}
+}
+
+// observe records a measurement, the largest one is kept so packing stays on
+// the safe side when the same model measures differently across runners. The
+// largest measurement is replaced once it hasn't been measured for the TTL, so
+// the footprint follows the model when it shrinks.
+func (m *memoryFootprints) observe(key footprintKey, memory uint64, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	existing, ok := m.footprints[key]
+	if !ok || memory >= existing.memory || now.Sub(existing.observed) > footprintTTL {
+		m.footprints[key] = footprint{memory: memory, observed: now}
+	}
+}
+
+// get returns the footprint of the model, expired footprints aren't used
+func (m *memoryFootprints) get(key footprintKey, now time.Time) (uint64, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	existing, ok := m.footprints[key]
+	if !ok || now.Sub(existing.observed) > footprintTTL {
+		return 0, false
+	}
+	return existing.memory, true
+}
+
+type contextLengthGetter interface {
+	GetContextLength() int64
+}
+
+// modelContextLength returns the context length the model is served with, 0
+// if the model doesn't have one
+func modelContextLength(work *Workload) int64 {
+	if m, ok := work.Model().(contextLengthGetter); ok {
+		return m.GetContextLength()
+	}
+	return 0
+}
+
+// workFootprintKey returns the key of the footprint the work will have once a
+// slot is started for it
+func workFootprintKey(work *Workload) footprintKey {
+	return footprintKey{
+		runtime:       work.Runtime(),
+		model:         work.ModelName().String(),
+		contextLength: modelContextLength(work),
+	}
+}
+
+// slotFootprintKey returns the key of the footprint measured on the slot, with
+// the context length the slot was started with
+func slotFootprintKey(slot *Slot) footprintKey {
+	return footprintKey{
+		runtime:       slot.InitialWork().Runtime(),
+		model:         slot.InitialWork().ModelName().String(),
+		contextLength: slot.ContextLength(),
+	}
+}
+
+// requiredMemory returns the GPU memory the workload needs, the measured
+// footprint of its model if a runner reported one, the model estimate otherwise
+func (s *Scheduler) requiredMemory(work *Workload) uint64 {
+	if memory, ok := s.footprints.get(workFootprintKey(work), s.clock.Now()); ok {
+		return memory
+	}
+	return work.Model().GetMemoryRequirements(work.Mode())
+}
+
+// slotMemory returns the GPU memory allocated to the slot, measured by the
+// runner if it reported it
+func (s *Scheduler) slotMemory(slot *Slot) uint64 {
+	if memory := slot.MeasuredMemory(); memory > 0 {
+		return memory
+	}
+	return s.requiredMemory(slot.InitialWork())
+}
+
+// collectMeasuredMemory updates the slots and the model footprints with the
+// memory reported in the runner statuses
+func (s *Scheduler) collectMeasuredMemory() {
+	for _, runnerID := range s.controller.RunnerIDs() {
+		status, err := s.controller.GetStatus(runnerID)
+		if err != nil {
+			log.Debug().Err(err).Str("runner_id", runnerID).Msg("failed to get runner status for memory measurements")
+			continue
+		}
+
+		for _, measured := range status.SlotMemory {
+			slot, ok := s.slots.Load(measured.ID)
+			if !ok || slot.RunnerID != runnerID {
+				continue
+			}
+			slot.SetMeasuredMemory(measured.Memory)
+			s.footprints.observe(slotFootprintKey(slot), measured.Memory, s.clock.Now())
+		}
+	}
+}
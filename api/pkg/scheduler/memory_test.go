@@ -0,0 +1,130 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func TestScheduler_MeasuredMemory(t *testing.T) {
+	work := newTestLLMWorkload(t, "work", "alice", types.WorkloadPriorityClassAPI)
+	estimate := work.Model().GetMemoryRequirements(work.Mode())
+	measured := estimate / 4
+
+	slotID := uuid.New()
+	s := newTestSchedulerWithRunners(t, &config.ServerConfig{},
+		&types.RunnerStatus{
+			ID:          "runner",
+			TotalMemory: estimate,
+			SlotMemory: []*types.RunnerSlotMemory{
+				{ID: slotID, Runtime: types.RuntimeOllama, Model: testModel, Memory: measured},
+				// Slots the scheduler doesn't know about are ignored
+				{ID: uuid.New(), Runtime: types.RuntimeOllama, Model: "other", Memory: 1},
+			},
+		},
+	)
+
+	// The estimate is used until the model has been measured
+	require.Equal(t, estimate, s.requiredMemory(work))
+
+	slot := NewSlot("runner", work, s.modelStaleFunc, s.slotTimeoutFunc)
+	slot.ID = slotID
+	slot.SetRunning()
+	s.storeSlot(slot)
+	require.Equal(t, estimate, s.slotMemory(slot))
+
+	// With the estimate a second slot of the model doesn't fit next to the first one
+	next := newTestLLMWorkload(t, "next", "bob", types.WorkloadPriorityClassAPI)
+	require.ErrorIs(t, s.deleteMostStaleStrategy("runner", next), ErrRunnersAreFull)
+
+	s.collectMeasuredMemory()
+	require.Equal(t, measured, slot.MeasuredMemory())
+	require.Equal(t, measured, slot.Memory())
+	require.Equal(t, measured, s.slotMemory(slot))
+
+	// The footprint is learned for the model so new slots use it too
+	require.Equal(t, measured, s.requiredMemory(next))
+	require.NoError(t, s.deleteMostStaleStrategy("runner", next))
+	require.Equal(t, 1, s.slots.Size())
+}
+
+func TestScheduler_MeasuredMemory_PicksRunner(t *testing.T) {
+	work := newTestLLMWorkload(t, "work", "alice", types.WorkloadPriorityClassAPI)
+	estimate := work.Model().GetMemoryRequirements(work.Mode())
+
+	s := newTestSchedulerWithRunners(t, &config.ServerConfig{},
+		&types.RunnerStatus{ID: "small", TotalMemory: estimate / 2},
+	)
+
+	_, err := s.pickBestRunner(work)
+	require.ErrorIs(t, err, ErrModelWontFit)
+
+	// Once measured smaller than its estimate the model fits on the runner
+	s.footprints.observe(workFootprintKey(work), estimate/4, time.Now())
+	runnerID, err := s.pickBestRunner(work)
+	require.NoError(t, err)
+	require.Equal(t, "small", runnerID)
+}
+
+func Test_memoryFootprints(t *testing.T) {
+	m := newMemoryFootprints()
+	key := footprintKey{runtime: types.RuntimeOllama, model: testModel, contextLength: 32768}
+	now := time.Now()
+
+	_, ok := m.get(key, now)
+	require.False(t, ok)
+
+	m.observe(key, 10, now)
+	m.observe(key, 20, now)
+	m.observe(key, 15, now)
+	memory, ok := m.get(key, now)
+	require.True(t, ok)
+	require.Equal(t, uint64(20), memory)
+
+	// The context length changes the KV cache so it's a different footprint
+	_, ok = m.get(footprintKey{runtime: types.RuntimeOllama, model: testModel, contextLength: 8192}, now)
+	require.False(t, ok)
+}
+
+func Test_memoryFootprints_TTL(t *testing.T) {
+	m := newMemoryFootprints()
+	key := footprintKey{runtime: types.RuntimeOllama, model: testModel, contextLength: 32768}
+	now := time.Now()
+
+	m.observe(key, 20, now)
+
+	// Smaller measurements don't replace the largest one while it's measured
+	m.observe(key, 15, now.Add(footprintTTL/2))
+	m.observe(key, 20, now.Add(footprintTTL))
+	memory, ok := m.get(key, now.Add(footprintTTL+time.Minute))
+	require.True(t, ok)
+	require.Equal(t, uint64(20), memory)
+
+	// Once the largest one hasn't been measured for the TTL the next one wins
+	m.observe(key, 15, now.Add(2*footprintTTL+time.Minute))
+	memory, ok = m.get(key, now.Add(2*footprintTTL+time.Minute))
+	require.True(t, ok)
+	require.Equal(t, uint64(15), memory)
+
+	// Footprints that aren't measured anymore expire
+	_, ok = m.get(key, now.Add(4*footprintTTL))
+	require.False(t, ok)
+}
+
+func TestScheduler_MeasuredMemory_SlotContextLength(t *testing.T) {
+	work := newTestLLMWorkload(t, "work", "alice", types.WorkloadPriorityClassAPI)
+
+	slot := NewSlot("runner", work, nil, nil)
+	require.Equal(t, modelContextLength(work), slot.ContextLength())
+
+	// The measurement is keyed by the context length the slot was started with
+	slot.contextLength = 8192
+	key := slotFootprintKey(slot)
+	require.Equal(t, int64(8192), key.contextLength)
+	require.NotEqual(t, workFootprintKey(work), key)
+}
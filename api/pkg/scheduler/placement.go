@@ -0,0 +1,105 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// runnerSelectors returns the selectors of the workload and its model, nil
+// selectors are skipped
+func (s *Scheduler) runnerSelectors(work *Workload) []*types.RunnerSelector {
+	var selectors []*types.RunnerSelector
+	if sel := work.RunnerSelector(); sel != nil {
+		selectors = append(selectors, sel)
+	}
+	if sel := s.modelSelectors[work.ModelName().String()]; sel != nil {
+		selectors = append(selectors, sel)
+	}
+	return selectors
+}
+
+// runnerMatchesWork returns whether the workload can be placed on the runner
+func (s *Scheduler) runnerMatchesWork(runnerID string, work *Workload) bool {
+	selectors := s.runnerSelectors(work)
+
+	status, err := s.controller.GetStatus(runnerID)
+	if err != nil {
+		// Without a status the runner can only take unrestricted work
+		return len(selectors) == 0
+	}
+
+	return runnerMatches(status.Labels, status.Taints, selectors...)
+}
+
+// runnerMatches returns whether the runner labels satisfy all the selectors and
+// every runner taint is tolerated by one of the selectors
+func runnerMatches(labels, taints map[string]string, selectors ...*types.RunnerSelector) bool {
+	var tolerations []types.RunnerToleration
+
+	for _, sel := range selectors {
+		for k, v := range sel.NodeSelector {
+			if labels[k] != v {
+				return false
+			}
+		}
+
+		for _, req := range sel.Affinity {
+			if !labelRequirementMatches(labels, req) {
+				return false
+			}
+		}
+
+		tolerations = append(tolerations, sel.Tolerations...)
+	}
+
+	for k, v := range taints {
+		if !slices.ContainsFunc(tolerations, func(t types.RunnerToleration) bool {
+			return tolerates(t, k, v)
+		}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func labelRequirementMatches(labels map[string]string, req types.RunnerLabelRequirement) bool {
+	value, exists := labels[req.Key]
+
+	switch req.Operator {
+	case types.RunnerLabelOperatorIn:
+		return exists && slices.Contains(req.Values, value)
+	case types.RunnerLabelOperatorNotIn:
+		return !exists || !slices.Contains(req.Values, value)
+	case types.RunnerLabelOperatorExists:
+		return exists
+	case types.RunnerLabelOperatorDoesNotExist:
+		return !exists
+	}
+
+	// Unknown operators never match so a typo doesn't place work anywhere
+	return false
+}
+
+func tolerates(t types.RunnerToleration, key, value string) bool {
+	if t.Operator == types.RunnerTolerationOperatorExists {
+		return t.Key == "" || t.Key == key
+	}
+	return t.Key == key && t.Value == value
+}
+
+// placementKey identifies the runners a workload can be placed on, workloads
+// with different selectors need different slots
+func placementKey(sel *types.RunnerSelector) string {
+	if sel == nil {
+		return ""
+	}
+	// Maps are encoded with sorted keys so the key is stable
+	bts, err := json.Marshal(sel)
+	if err != nil {
+		return ""
+	}
+	return string(bts)
+}
@@ -0,0 +1,255 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/pubsub"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func Test_runnerMatches(t *testing.T) {
+	labels := map[string]string{"gpu": "a100", "region": "eu"}
+
+	tests := []struct {
+		name      string
+		labels    map[string]string
+		taints    map[string]string
+		selectors []*types.RunnerSelector
+		want      bool
+	}{
+		{
+			name:   "no selectors",
+			labels: labels,
+			want:   true,
+		},
+		{
+			name:   "node selector matches",
+			labels: labels,
+			selectors: []*types.RunnerSelector{
+				{NodeSelector: map[string]string{"gpu": "a100"}},
+			},
+			want: true,
+		},
+		{
+			name:   "node selector mismatch",
+			labels: labels,
+			selectors: []*types.RunnerSelector{
+				{NodeSelector: map[string]string{"gpu": "h100"}},
+			},
+			want: false,
+		},
+		{
+			name:   "all selectors must match",
+			labels: labels,
+			selectors: []*types.RunnerSelector{
+				{NodeSelector: map[string]string{"gpu": "a100"}},
+				{NodeSelector: map[string]string{"region": "us"}},
+			},
+			want: false,
+		},
+		{
+			name:   "affinity in",
+			labels: labels,
+			selectors: []*types.RunnerSelector{
+				{Affinity: []types.RunnerLabelRequirement{
+					{Key: "region", Operator: types.RunnerLabelOperatorIn, Values: []string{"eu", "uk"}},
+				}},
+			},
+			want: true,
+		},
+		{
+			name:   "affinity not in",
+			labels: labels,
+			selectors: []*types.RunnerSelector{
+				{Affinity: []types.RunnerLabelRequirement{
+					{Key: "region", Operator: types.RunnerLabelOperatorNotIn, Values: []string{"eu"}},
+				}},
+			},
+			want: false,
+		},
+		{
+			name:   "affinity exists and does not exist",
+			labels: labels,
+			selectors: []*types.RunnerSelector{
+				{Affinity: []types.RunnerLabelRequirement{
+					{Key: "gpu", Operator: types.RunnerLabelOperatorExists},
+					{Key: "tenant", Operator: types.RunnerLabelOperatorDoesNotExist},
+				}},
+			},
+			want: true,
+		},
+		{
+			name:   "unknown operator",
+			labels: labels,
+			selectors: []*types.RunnerSelector{
+				{Affinity: []types.RunnerLabelRequirement{
+					{Key: "gpu", Operator: "Gt", Values: []string{"1"}},
+				}},
+			},
+			want: false,
+		},
+		{
+			name:   "taint not tolerated",
+			labels: labels,
+			taints: map[string]string{"tenant": "acme"},
+			want:   false,
+		},
+		{
+			name:   "taint tolerated",
+			labels: labels,
+			taints: map[string]string{"tenant": "acme"},
+			selectors: []*types.RunnerSelector{
+				{Tolerations: []types.RunnerToleration{{Key: "tenant", Value: "acme"}}},
+			},
+			want: true,
+		},
+		{
+			name:   "taint tolerated with another value",
+			labels: labels,
+			taints: map[string]string{"tenant": "acme"},
+			selectors: []*types.RunnerSelector{
+				{Tolerations: []types.RunnerToleration{{Key: "tenant", Value: "globex"}}},
+			},
+			want: false,
+		},
+		{
+			name:   "taint tolerated by exists",
+			labels: labels,
+			taints: map[string]string{"tenant": "acme", "maintenance": "true"},
+			selectors: []*types.RunnerSelector{
+				{Tolerations: []types.RunnerToleration{{Operator: types.RunnerTolerationOperatorExists}}},
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, runnerMatches(tt.labels, tt.taints, tt.selectors...))
+		})
+	}
+}
+
+func newTestSchedulerWithRunners(t *testing.T, cfg *config.ServerConfig, runners ...*types.RunnerStatus) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	ps, err := pubsub.NewInMemoryNats()
+	require.NoError(t, err)
+
+	ctrl, err := NewRunnerController(ctx, &RunnerControllerConfig{
+		PubSub: ps,
+	})
+	require.NoError(t, err)
+
+	for _, status := range runners {
+		status := status
+		sub, err := ps.SubscribeWithCtx(ctx, pubsub.GetRunnerQueue(status.ID), func(_ context.Context, msg *nats.Msg) error {
+			body, err := json.Marshal(status)
+			require.NoError(t, err)
+			resp, err := json.Marshal(&types.Response{StatusCode: 200, Body: body})
+			require.NoError(t, err)
+			return msg.Respond(resp)
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = sub.Unsubscribe() })
+
+		ctrl.OnConnectedHandler(status.ID)
+	}
+
+	s, err := NewScheduler(ctx, cfg, &Params{
+		RunnerController: ctrl,
+		OnSchedulingErr:  func(*Workload, error) {},
+	})
+	require.NoError(t, err)
+	return s
+}
+
+func TestScheduler_PickBestRunner_Placement(t *testing.T) {
+	cfg := &config.ServerConfig{}
+	cfg.Providers.Helix.ModelRunnerSelectors = config.ModelRunnerSelectors{
+		testModel: {NodeSelector: map[string]string{"region": "eu"}},
+	}
+
+	s := newTestSchedulerWithRunners(t, cfg,
+		&types.RunnerStatus{ID: "us", TotalMemory: 100e9, Labels: map[string]string{"region": "us"}},
+		&types.RunnerStatus{ID: "eu", TotalMemory: 100e9, Labels: map[string]string{"region": "eu"}},
+		&types.RunnerStatus{ID: "acme", TotalMemory: 100e9, Labels: map[string]string{"region": "eu"}, Taints: map[string]string{"tenant": "acme"}},
+	)
+
+	// The model selector keeps the work in the eu, the taint off the acme runner
+	work := newTestLLMWorkload(t, "work", "alice", types.WorkloadPriorityClassAPI)
+	runnerID, err := s.pickBestRunner(work)
+	require.NoError(t, err)
+	require.Equal(t, "eu", runnerID)
+
+	// The dedicated runner only takes work tolerating its taint
+	acme := newTestLLMWorkload(t, "acme", "bob", types.WorkloadPriorityClassAPI)
+	acme.LLMInferenceRequest().RunnerSelector = &types.RunnerSelector{
+		NodeSelector: map[string]string{"region": "eu"},
+		Affinity: []types.RunnerLabelRequirement{
+			{Key: "region", Operator: types.RunnerLabelOperatorIn, Values: []string{"eu"}},
+		},
+		Tolerations: []types.RunnerToleration{{Key: "tenant", Value: "acme"}},
+	}
+	s.storeSlot(NewSlot("eu", work, s.modelStaleFunc, s.slotTimeoutFunc))
+
+	runnerID, err = s.pickBestRunner(acme)
+	require.NoError(t, err)
+	require.Equal(t, "acme", runnerID)
+
+	// No runner matches
+	gpu := newTestLLMWorkload(t, "gpu", "carol", types.WorkloadPriorityClassAPI)
+	gpu.LLMInferenceRequest().RunnerSelector = &types.RunnerSelector{
+		NodeSelector: map[string]string{"gpu": "h100"},
+	}
+	_, err = s.pickBestRunner(gpu)
+	require.ErrorIs(t, err, ErrNoMatchingRunners)
+}
+
+func TestScheduler_WarmSlots_Placement(t *testing.T) {
+	s := newTestSchedulerWithRunners(t, &config.ServerConfig{},
+		&types.RunnerStatus{ID: "shared", TotalMemory: 100e9},
+		&types.RunnerStatus{ID: "acme", TotalMemory: 100e9, Taints: map[string]string{"tenant": "acme"}},
+	)
+
+	work := newTestLLMWorkload(t, "work", "alice", types.WorkloadPriorityClassAPI)
+
+	acmeSlot := NewSlot("acme", work, s.modelStaleFunc, s.slotTimeoutFunc)
+	acmeSlot.SetRunning()
+	s.storeSlot(acmeSlot)
+
+	// Work without the toleration can't use the warm slot of the dedicated runner
+	require.Empty(t, s.warmSlots(work))
+
+	acme := newTestLLMWorkload(t, "acme", "bob", types.WorkloadPriorityClassAPI)
+	acme.LLMInferenceRequest().RunnerSelector = &types.RunnerSelector{
+		Tolerations: []types.RunnerToleration{{Key: "tenant", Value: "acme"}},
+	}
+	require.Equal(t, []*Slot{acmeSlot}, s.warmSlots(acme))
+}
+
+func TestWorkQueue_GetRequiredSlots_Placement(t *testing.T) {
+	q := NewWorkQueue(10, 0)
+
+	require.NoError(t, q.Add(newTestLLMWorkload(t, "a", "alice", types.WorkloadPriorityClassAPI)))
+	require.NoError(t, q.Add(newTestLLMWorkload(t, "b", "alice", types.WorkloadPriorityClassAPI)))
+
+	acme := newTestLLMWorkload(t, "c", "bob", types.WorkloadPriorityClassAPI)
+	acme.LLMInferenceRequest().RunnerSelector = &types.RunnerSelector{
+		NodeSelector: map[string]string{"tenant": "acme"},
+	}
+	require.NoError(t, q.Add(acme))
+
+	reqs := q.GetRequiredSlots()
+	require.Len(t, reqs, 2)
+	require.Equal(t, 2, reqs[0].Count)
+	require.Equal(t, 1, reqs[1].Count)
+	require.Equal(t, "c", reqs[1].ExampleWorkload.ID())
+}
@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"cmp"
 	"fmt"
 	"slices"
 	"sync"
@@ -18,16 +19,42 @@ type SlotRequirement struct {
 	ExampleWorkload *Workload
 }
 
+// WorkQueue orders the workloads by their priority class and shares each class
+// fairly between the owners. Every workload gets a turn within its class: an
+// owner's workloads take consecutive turns, starting at the turn that is being
+// scheduled when the owner has nothing queued. A single owner enqueueing many
+// workloads therefore can't starve the others.
 type WorkQueue struct {
-	items    []*Workload
-	capacity int
-	mu       sync.RWMutex
+	items         []*Workload
+	capacity      int
+	ownerCapacity int // Maximum number of workloads per owner, 0 for no limit
+
+	entries    map[string]queueEntry // By workload ID
+	ownerTurns map[queueOwner]uint64 // Next turn of the owners with queued workloads
+	classTurns map[types.WorkloadPriorityClass]uint64
+	nextSeq    uint64
+
+	mu sync.RWMutex
+}
+
+type queueEntry struct {
+	seq  uint64 // Order in which the workloads were added
+	turn uint64
 }
 
-func NewWorkQueue(capacity int) *WorkQueue {
+type queueOwner struct {
+	class types.WorkloadPriorityClass
+	owner string
+}
+
+func NewWorkQueue(capacity, ownerCapacity int) *WorkQueue {
 	return &WorkQueue{
-		items:    make([]*Workload, 0, capacity),
-		capacity: capacity,
+		items:         make([]*Workload, 0, capacity),
+		capacity:      capacity,
+		ownerCapacity: ownerCapacity,
+		entries:       make(map[string]queueEntry, capacity),
+		ownerTurns:    make(map[queueOwner]uint64),
+		classTurns:    make(map[types.WorkloadPriorityClass]uint64),
 	}
 }
 
@@ -38,36 +65,92 @@ func (q *WorkQueue) Add(work *Workload) error {
 	defer q.mu.Unlock()
 
 	// Check if the work is already in the queue
-	for _, w := range q.items {
-		if w.ID() == work.ID() {
-			return fmt.Errorf("work already in queue")
-		}
+	if _, ok := q.entries[work.ID()]; ok {
+		return fmt.Errorf("work already in queue")
 	}
 
 	if len(q.items) >= q.capacity {
 		return fmt.Errorf("queue is full")
 	}
 
-	withWorkContext(&log.Logger, work).Trace().Msg("adding work item to queue")
-
-	// Add with priority if requested
-	priority := false
-	if work.WorkloadType == WorkloadTypeSession {
-		if work.Session().Metadata.Priority {
-			priority = true
+	if q.ownerCapacity > 0 {
+		owned := 0
+		for _, w := range q.items {
+			if w.OwnerID() == work.OwnerID() {
+				owned++
+			}
+		}
+		if owned >= q.ownerCapacity {
+			return fmt.Errorf("queue is full for owner %s (%d workloads queued)", work.OwnerID(), owned)
 		}
 	}
-	if priority {
-		q.items = append([]*Workload{work}, q.items...)
-	} else {
-		q.items = append(q.items, work)
-	}
+
+	withWorkContext(&log.Logger, work).Trace().
+		Str("priority_class", string(work.PriorityClass())).
+		Msg("adding work item to queue")
+
+	class := work.PriorityClass()
+	owner := queueOwner{class: class, owner: work.OwnerID()}
+
+	turn := max(q.ownerTurns[owner], q.classTurns[class])
+	q.ownerTurns[owner] = turn + 1
+
+	q.entries[work.ID()] = queueEntry{seq: q.nextSeq, turn: turn}
+	q.nextSeq++
+
+	q.items = append(q.items, work)
+	q.sort()
 
 	return nil
 }
 
-// Queue returns a copy of the current queue, because the original queue might be modified after
-// this call
+// remove removes the workload at the index. Scheduled workloads advance the
+// turn of their class. Must be called with the lock held.
+func (q *WorkQueue) remove(i int, scheduled bool) {
+	work := q.items[i]
+	entry := q.entries[work.ID()]
+	class := work.PriorityClass()
+
+	q.items = append(q.items[:i], q.items[i+1:]...)
+	delete(q.entries, work.ID())
+
+	if scheduled {
+		q.classTurns[class] = max(q.classTurns[class], entry.turn)
+	}
+
+	// Owners without queued workloads start again at the current turn
+	owner := queueOwner{class: class, owner: work.OwnerID()}
+	if !slices.ContainsFunc(q.items, func(w *Workload) bool {
+		return w.PriorityClass() == class && w.OwnerID() == owner.owner
+	}) {
+		delete(q.ownerTurns, owner)
+	}
+}
+
+// sort orders the queue by priority class, priority flag, turn and finally the
+// order in which the workloads were added. Must be called with the lock held.
+func (q *WorkQueue) sort() {
+	slices.SortFunc(q.items, func(a, b *Workload) int {
+		if c := cmp.Compare(a.PriorityClass().Rank(), b.PriorityClass().Rank()); c != 0 {
+			return c
+		}
+		if a.Priority() != b.Priority() {
+			if a.Priority() {
+				return -1
+			}
+			return 1
+		}
+
+		ea, eb := q.entries[a.ID()], q.entries[b.ID()]
+		if c := cmp.Compare(ea.turn, eb.turn); c != 0 {
+			return c
+		}
+		return cmp.Compare(ea.seq, eb.seq)
+	})
+}
+
+// Queue returns a copy of the current queue in scheduling order, because the
+// original queue might be modified after this call
 func (q *WorkQueue) Queue() []*Workload {
 	q.mu.RLock()
 	defer q.mu.RUnlock()
@@ -88,7 +171,7 @@ func (q *WorkQueue) TakeNext(hasWarmSlot func(*Workload) bool) *Workload {
 			// Verify the queue hasn't changed and the item is still at same position
 			if i < len(q.items) && q.items[i].ID() == work.ID() {
 				// Remove the item from the queue
-				q.items = append(q.items[:i], q.items[i+1:]...)
+				q.remove(i, true)
 				q.mu.Unlock()
 				return work
 			}
@@ -105,20 +188,24 @@ func (q *WorkQueue) GetRequiredSlots() []SlotRequirement {
 	// Get a copy of the copy of the queue with an RLock to reduce contention
 	items := q.Queue()
 
-	// Map to accumulate requirements
+	// Map to accumulate requirements, keys in the order of the queue so the
+	// slots for the workloads at the front are created first
 	reqMap := make(map[string]*SlotRequirement)
+	var keys []string
 
 	for _, work := range items {
 		// Create a key that uniquely identifies this slot type
-		key := fmt.Sprintf("%s:%s:%s",
+		key := fmt.Sprintf("%s:%s:%s:%s",
 			work.Runtime(),
 			work.ModelName(),
 			work.LoraDir(),
+			placementKey(work.RunnerSelector()),
 		)
 
 		if req, exists := reqMap[key]; exists {
 			req.Count++
 		} else {
+			keys = append(keys, key)
 			reqMap[key] = &SlotRequirement{
 				Runtime:         work.Runtime(),
 				Model:           work.ModelName(),
@@ -131,8 +218,8 @@ func (q *WorkQueue) GetRequiredSlots() []SlotRequirement {
 
 	// Convert map to slice
 	requirements := make([]SlotRequirement, 0, len(reqMap))
-	for _, req := range reqMap {
-		requirements = append(requirements, *req)
+	for _, key := range keys {
+		requirements = append(requirements, *reqMap[key])
 	}
 
 	return requirements
@@ -141,7 +228,10 @@ func (q *WorkQueue) GetRequiredSlots() []SlotRequirement {
 func (q *WorkQueue) Remove(work *Workload) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
-	q.items = slices.DeleteFunc(q.items, func(w *Workload) bool {
+	i := slices.IndexFunc(q.items, func(w *Workload) bool {
 		return w.ID() == work.ID()
 	})
+	if i >= 0 {
+		q.remove(i, false)
+	}
 }
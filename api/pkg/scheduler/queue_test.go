@@ -0,0 +1,166 @@
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+const testModel = "llama3.1:8b-instruct-q8_0"
+
+func newTestLLMWorkload(t *testing.T, id, owner string, class types.WorkloadPriorityClass) *Workload {
+	work, err := NewLLMWorkload(&types.RunnerLLMInferenceRequest{
+		RequestID:     id,
+		CreatedAt:     time.Now(),
+		OwnerID:       owner,
+		PriorityClass: class,
+		Request: &openai.ChatCompletionRequest{
+			Model: testModel,
+			Messages: []openai.ChatCompletionMessage{
+				{Role: openai.ChatMessageRoleUser, Content: "hello"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	return work
+}
+
+func queueIDs(q *WorkQueue) []string {
+	var ids []string
+	for _, w := range q.Queue() {
+		ids = append(ids, w.ID())
+	}
+	return ids
+}
+
+func TestWorkQueue_PriorityClasses(t *testing.T) {
+	q := NewWorkQueue(10, 0)
+
+	require.NoError(t, q.Add(newTestLLMWorkload(t, "cron", "alice", types.WorkloadPriorityClassCron)))
+	require.NoError(t, q.Add(newTestLLMWorkload(t, "finetune", "alice", types.WorkloadPriorityClassFinetune)))
+	require.NoError(t, q.Add(newTestLLMWorkload(t, "api", "alice", types.WorkloadPriorityClassAPI)))
+	require.NoError(t, q.Add(newTestLLMWorkload(t, "chat", "alice", types.WorkloadPriorityClassInteractive)))
+
+	require.Equal(t, []string{"chat", "api", "finetune", "cron"}, queueIDs(q))
+}
+
+func TestWorkQueue_FairShare(t *testing.T) {
+	q := NewWorkQueue(100, 0)
+
+	// Alice enqueues a batch before Bob and Carol send their requests
+	for i := 0; i < 5; i++ {
+		require.NoError(t, q.Add(newTestLLMWorkload(t, fmt.Sprintf("alice-%d", i), "alice", types.WorkloadPriorityClassAPI)))
+	}
+	require.NoError(t, q.Add(newTestLLMWorkload(t, "bob-0", "bob", types.WorkloadPriorityClassAPI)))
+	require.NoError(t, q.Add(newTestLLMWorkload(t, "bob-1", "bob", types.WorkloadPriorityClassAPI)))
+	require.NoError(t, q.Add(newTestLLMWorkload(t, "carol-0", "carol", types.WorkloadPriorityClassAPI)))
+
+	require.Equal(t, []string{
+		"alice-0", "bob-0", "carol-0",
+		"alice-1", "bob-1",
+		"alice-2", "alice-3", "alice-4",
+	}, queueIDs(q))
+
+	// Taking the next workloads keeps the turns
+	work := q.TakeNext(func(*Workload) bool { return true })
+	require.Equal(t, "alice-0", work.ID())
+	work = q.TakeNext(func(*Workload) bool { return true })
+	require.Equal(t, "bob-0", work.ID())
+
+	// Dave joins at the turn being scheduled, ahead of the second turns
+	require.NoError(t, q.Add(newTestLLMWorkload(t, "dave-0", "dave", types.WorkloadPriorityClassAPI)))
+	require.Equal(t, []string{
+		"carol-0", "dave-0",
+		"alice-1", "bob-1",
+		"alice-2", "alice-3", "alice-4",
+	}, queueIDs(q))
+}
+
+func TestWorkQueue_PriorityFlag(t *testing.T) {
+	q := NewWorkQueue(10, 0)
+
+	require.NoError(t, q.Add(newTestLLMWorkload(t, "normal", "alice", types.WorkloadPriorityClassAPI)))
+
+	priority := newTestLLMWorkload(t, "priority", "bob", types.WorkloadPriorityClassAPI)
+	priority.LLMInferenceRequest().Priority = true
+	require.NoError(t, q.Add(priority))
+
+	require.NoError(t, q.Add(newTestLLMWorkload(t, "chat", "carol", types.WorkloadPriorityClassInteractive)))
+
+	require.Equal(t, []string{"chat", "priority", "normal"}, queueIDs(q))
+}
+
+func TestWorkQueue_OwnerCapacity(t *testing.T) {
+	q := NewWorkQueue(10, 2)
+
+	require.NoError(t, q.Add(newTestLLMWorkload(t, "alice-0", "alice", types.WorkloadPriorityClassAPI)))
+	require.NoError(t, q.Add(newTestLLMWorkload(t, "alice-1", "alice", types.WorkloadPriorityClassAPI)))
+	require.Error(t, q.Add(newTestLLMWorkload(t, "alice-2", "alice", types.WorkloadPriorityClassAPI)))
+
+	// Other owners can still enqueue
+	require.NoError(t, q.Add(newTestLLMWorkload(t, "bob-0", "bob", types.WorkloadPriorityClassAPI)))
+
+	// Duplicates are rejected
+	require.Error(t, q.Add(newTestLLMWorkload(t, "bob-0", "bob", types.WorkloadPriorityClassAPI)))
+
+	q.Remove(newTestLLMWorkload(t, "alice-0", "alice", types.WorkloadPriorityClassAPI))
+	require.NoError(t, q.Add(newTestLLMWorkload(t, "alice-2", "alice", types.WorkloadPriorityClassAPI)))
+}
+
+func TestWorkQueue_TakeNextWarmSlot(t *testing.T) {
+	q := NewWorkQueue(10, 0)
+
+	require.NoError(t, q.Add(newTestLLMWorkload(t, "chat", "alice", types.WorkloadPriorityClassInteractive)))
+	require.NoError(t, q.Add(newTestLLMWorkload(t, "api", "bob", types.WorkloadPriorityClassAPI)))
+
+	// Lower classes are scheduled when the higher ones have no warm slot
+	work := q.TakeNext(func(w *Workload) bool { return w.ID() == "api" })
+	require.Equal(t, "api", work.ID())
+	require.Equal(t, []string{"chat"}, queueIDs(q))
+
+	require.Nil(t, q.TakeNext(func(*Workload) bool { return false }))
+}
+
+func TestWorkload_PriorityClass(t *testing.T) {
+	work := newTestLLMWorkload(t, "id", "alice", "")
+	require.Equal(t, types.WorkloadPriorityClassAPI, work.PriorityClass())
+
+	session, err := NewSessionWorkload(&types.Session{
+		ID:        "session",
+		Owner:     "alice",
+		ModelName: testModel,
+		Mode:      types.SessionModeFinetune,
+		Type:      types.SessionTypeText,
+	})
+	require.NoError(t, err)
+	require.Equal(t, types.WorkloadPriorityClassFinetune, session.PriorityClass())
+}
+
+func TestWorkload_RunnerSelector_Session(t *testing.T) {
+	selector := &types.RunnerSelector{NodeSelector: map[string]string{"gpu": "a100"}}
+
+	session, err := NewSessionWorkload(&types.Session{
+		ID:        "session",
+		Owner:     "alice",
+		ModelName: testModel,
+		Mode:      types.SessionModeInference,
+		Type:      types.SessionTypeText,
+		Metadata:  types.SessionMetadata{RunnerSelector: selector},
+	})
+	require.NoError(t, err)
+	require.Equal(t, selector, session.RunnerSelector())
+}
+
+func TestWorkload_Runtime(t *testing.T) {
+	work := newTestLLMWorkload(t, "ollama", "alice", types.WorkloadPriorityClassAPI)
+	require.Equal(t, types.RuntimeOllama, work.Runtime())
+
+	work = newTestLLMWorkload(t, "vllm", "alice", types.WorkloadPriorityClassAPI)
+	work.LLMInferenceRequest().Request.Model = "Qwen/Qwen2.5-7B-Instruct"
+	require.Equal(t, types.RuntimeVLLM, work.Runtime())
+}
@@ -1,9 +1,13 @@
 package scheduler
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"io"
 	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,8 +31,12 @@ const (
 type TimeoutFunc func(runnerID string, lastActivityTime time.Time) bool
 
 func NewTimeoutFunc(ttl time.Duration) TimeoutFunc {
+	return newClockTimeoutFunc(realClock{}, ttl)
+}
+
+func newClockTimeoutFunc(clock Clock, ttl time.Duration) TimeoutFunc {
 	return func(_ string, lastActivityTime time.Time) bool {
-		return lastActivityTime.Add(ttl).Before(time.Now())
+		return lastActivityTime.Add(ttl).Before(clock.Now())
 	}
 }
 
@@ -40,6 +48,14 @@ type Scheduler struct {
 	slots           *xsync.MapOf[uuid.UUID, *Slot]
 	modelStaleFunc  TimeoutFunc // Function to check if models are stale
 	slotTimeoutFunc TimeoutFunc // Function to check if slots have timed out due to error
+	state           StateStore  // Persists the queue and slots, nil keeps the state in memory only
+	modelSelectors  map[string]*types.RunnerSelector
+	footprints      *memoryFootprints // GPU memory measured by the runners for each model
+	clock           Clock
+	newSlotID       func() uuid.UUID
+	trace           *traceRecorder // Records the enqueued workloads for the simulator, nil to disable
+	randMu          sync.Mutex
+	rand            *rand.Rand // Breaks the ties between runners and slots
 }
 
 type Params struct {
@@ -47,9 +63,36 @@ type Params struct {
 	QueueSize         int
 	OnSchedulingErr   func(work *Workload, err error)
 	OnResponseHandler func(ctx context.Context, resp *types.RunnerLLMInferenceResponse) error
+	StateStore        StateStore
+	Clock             Clock      // Defaults to the real time
+	Trace             io.Writer  // Records the enqueued workloads as JSON lines for the simulator
+	Rand              *rand.Rand // Breaks the ties between runners and slots, randomly seeded if nil
 }
 
 func NewScheduler(ctx context.Context, serverConfig *config.ServerConfig, params *Params) (*Scheduler, error) {
+	s, err := newScheduler(ctx, serverConfig, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start the queue processor
+	go s.processQueue(ctx)
+
+	// Start the slot reconciler
+	go s.reconcileSlots(ctx)
+
+	// Start the activity reconciler
+	go s.reconcileActivity(ctx)
+
+	// Start the runner reconciler
+	go s.reconcileRunners(ctx)
+
+	return s, nil
+}
+
+// newScheduler creates the scheduler without starting the reconcilers, the
+// simulator runs them itself
+func newScheduler(ctx context.Context, serverConfig *config.ServerConfig, params *Params) (*Scheduler, error) {
 	if params == nil {
 		params = &Params{}
 	}
@@ -68,42 +111,59 @@ func NewScheduler(ctx context.Context, serverConfig *config.ServerConfig, params
 	if params.QueueSize > 0 {
 		queueSize = params.QueueSize
 	}
+	clock := params.Clock
+	if clock == nil {
+		clock = realClock{}
+	}
+	random := params.Rand
+	if random == nil {
+		random = rand.New(rand.NewSource(uint64(time.Now().UnixNano())))
+	}
 
 	log.Info().Dur("model_stale_time", modelTTL).Dur("slot_timeout", slotTTL).Msg("slot timeouts")
 
 	s := &Scheduler{
 		ctx:             ctx,
 		controller:      params.RunnerController,
-		queue:           NewWorkQueue(queueSize),
+		queue:           NewWorkQueue(queueSize, serverConfig.Providers.Helix.OwnerQueueSize),
 		onSchedulingErr: params.OnSchedulingErr,
 		slots:           xsync.NewMapOf[uuid.UUID, *Slot](),
-		modelStaleFunc:  NewTimeoutFunc(modelTTL),
-		slotTimeoutFunc: NewTimeoutFunc(slotTTL),
+		modelStaleFunc:  newClockTimeoutFunc(clock, modelTTL),
+		slotTimeoutFunc: newClockTimeoutFunc(clock, slotTTL),
+		state:           params.StateStore,
+		modelSelectors:  serverConfig.Providers.Helix.ModelRunnerSelectors,
+		footprints:      newMemoryFootprints(),
+		clock:           clock,
+		newSlotID:       uuid.New,
+		rand:            random,
+	}
+	if params.Trace != nil {
+		s.trace = newTraceRecorder(params.Trace)
 	}
 
-	// Start the queue processor
-	go s.processQueue(ctx)
-
-	// Start the slot reconciler
-	go s.reconcileSlots(ctx)
-
-	// Start the activity reconciler
-	go s.reconcileActivity(ctx)
-
-	// Start the runner reconciler
-	go s.reconcileRunners(ctx)
+	// Restore the queue and slots from before the restart
+	err := s.restoreState(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	return s, nil
 }
 
 func (s *Scheduler) Enqueue(work *Workload) error {
-	return s.queue.Add(work)
+	err := s.queue.Add(work)
+	if err != nil {
+		return err
+	}
+	s.persistWorkload(work)
+	s.trace.record(work)
+	return nil
 }
 
 func (s *Scheduler) Queue() ([]*types.WorkloadSummary, error) {
 	currentQueue := s.queue.Queue()
 	queue := make([]*types.WorkloadSummary, 0, len(currentQueue))
-	for _, w := range currentQueue {
+	for i, w := range currentQueue {
 		summary := ""
 		switch w.WorkloadType {
 		case WorkloadTypeLLMInferenceRequest:
@@ -125,6 +185,10 @@ func (s *Scheduler) Queue() ([]*types.WorkloadSummary, error) {
 			Runtime:   string(w.Runtime()),
 			LoraDir:   w.LoraDir(),
 			Summary:   summary,
+			OwnerID:   w.OwnerID(),
+
+			PriorityClass: w.PriorityClass(),
+			QueuePosition: i + 1,
 		})
 	}
 	return queue, nil
@@ -213,9 +277,11 @@ func (s *Scheduler) reconcileActivityOnce() {
 			remoteSlot, err := s.controller.fetchSlot(slot.RunnerID, slotID)
 			if err != nil {
 				withSlotContext(&log.Logger, slot).Error().Err(err).Msg("failed to get slot, assuming it's finished")
+				s.trace.finished(slotID, s.clock.Now())
 				slot.Release()
 			} else if !remoteSlot.Active {
 				withSlotContext(&log.Logger, slot).Debug().Msg("slot is not active, releasing")
+				s.trace.finished(slotID, s.clock.Now())
 				slot.Release()
 			}
 		}
@@ -251,21 +317,23 @@ func (s *Scheduler) reconcileRunnersOnce() {
 			s.deleteRunnerSlots(runnerID)
 		}
 	}
+
+	s.collectMeasuredMemory()
 }
 
 func (s *Scheduler) deleteRunnerSlots(runnerID string) {
 	// First collect the slots to delete
-	var slotsToDelete []uuid.UUID
+	var slotsToDelete []*Slot
 	s.slots.Range(func(_ uuid.UUID, slot *Slot) bool {
 		if slot.RunnerID == runnerID {
-			slotsToDelete = append(slotsToDelete, slot.ID)
+			slotsToDelete = append(slotsToDelete, slot)
 		}
 		return true
 	})
 
 	// Then delete them after the range is complete
-	for _, slotID := range slotsToDelete {
-		s.slots.Delete(slotID)
+	for _, slot := range slotsToDelete {
+		s.deleteSlot(slot)
 	}
 }
 
@@ -287,7 +355,8 @@ func (s *Scheduler) reconcileSlotsOnce(ctx context.Context) {
 			if slot.InitialWork().ModelName() == req.Model &&
 				slot.InitialWork().Runtime() == req.Runtime &&
 				slot.InitialWork().LoraDir() == req.LoraDir &&
-				!slot.IsActive() {
+				!slot.IsActive() &&
+				s.runnerMatchesWork(slot.RunnerID, req.ExampleWorkload) {
 				existingCount++
 			}
 			return true
@@ -330,7 +399,19 @@ func (s *Scheduler) reconcileSlotsOnce(ctx context.Context) {
 
 	// Clean up scheduler slots that don't exist on any runner
 	s.slots.Range(func(slotID uuid.UUID, slot *Slot) bool {
-		if runnerID, exists := allActualSlots[slotID]; !exists {
+		runnerID, exists := allActualSlots[slotID]
+		if !exists && !slot.restoredAt.IsZero() {
+			// Restored slots are adopted once their runner reconnects, they
+			// are not recreated since the runner might still be starting
+			if s.clock.Now().Sub(slot.restoredAt) > slotRestoreGracePeriod {
+				withSlotContext(&log.Logger, slot).Info().Msg("restored slot not found on any runner, deleting...")
+				s.deleteSlot(slot)
+			}
+			return true
+		}
+		slot.restoredAt = time.Time{}
+
+		if !exists {
 			log.Info().
 				Str("runner_id", slot.RunnerID).
 				Str("slot_id", slotID.String()).
@@ -346,10 +427,10 @@ func (s *Scheduler) reconcileSlotsOnce(ctx context.Context) {
 					withWorkContext(&log.Logger, slot.InitialWork()).Warn().Err(err).Msg("failed to create slot, calling error handler")
 
 					// First remove that slot, since it was never created
-					s.slots.Delete(slot.ID)
+					s.deleteSlot(slot)
 
 					// Then remove the work from the queue if it exists
-					s.queue.Remove(slot.InitialWork())
+					s.removeWork(slot.InitialWork())
 
 					// Then notify the error handler
 					s.onSchedulingErr(slot.InitialWork(), err)
@@ -363,6 +444,7 @@ func (s *Scheduler) reconcileSlotsOnce(ctx context.Context) {
 				Str("slot_id", slotID.String()).
 				Msg("slot exists on different runner than expected, updating runner ID")
 			slot.RunnerID = runnerID
+			s.persistSlot(slot)
 		}
 		return true
 	})
@@ -394,7 +476,7 @@ func (s *Scheduler) ensureSlots(req SlotRequirement, count int) {
 			}
 			log.Warn().Err(err).Interface("requirement", req).Msg("failed to pick best runner for requirement, skipping...")
 			s.onSchedulingErr(req.ExampleWorkload, err)
-			s.queue.Remove(req.ExampleWorkload) // This only removes the one workload from the slot requirement, not the entire queue full of them. It should clean up on the next time around.
+			s.removeWork(req.ExampleWorkload) // This only removes the one workload from the slot requirement, not the entire queue full of them. It should clean up on the next time around.
 			return
 		}
 
@@ -408,15 +490,15 @@ func (s *Scheduler) ensureSlots(req SlotRequirement, count int) {
 			}
 			log.Warn().Err(err).Interface("requirement", req).Msg("failed to delete any stale slots, skipping...")
 			s.onSchedulingErr(req.ExampleWorkload, err)
-			s.queue.Remove(req.ExampleWorkload) // This only removes the one workload from the slit requirement, not the entire queue full of them. It should clean up on the next time around.
+			s.removeWork(req.ExampleWorkload) // This only removes the one workload from the slit requirement, not the entire queue full of them. It should clean up on the next time around.
 			return
 		}
 
 		// Create the control plane view of the slot
-		slot := NewSlot(runnerID, req.ExampleWorkload, s.modelStaleFunc, s.slotTimeoutFunc)
+		slot := newSlot(s.newSlotID(), runnerID, req.ExampleWorkload, s.modelStaleFunc, s.slotTimeoutFunc, s.clock)
 
 		// Store the slot
-		s.slots.Store(slot.ID, slot)
+		s.storeSlot(slot)
 	}
 }
 
@@ -430,6 +512,7 @@ func (s *Scheduler) processQueueOnce() {
 	if work == nil {
 		return // Nothing can be scheduled right now
 	}
+	s.forgetWorkload(work)
 
 	// We know we have a warm slot, so schedule the work
 	warmSlots := s.warmSlots(work)
@@ -442,7 +525,9 @@ func (s *Scheduler) processQueueOnce() {
 		if err != nil {
 			log.Warn().Err(err).Msg("failed to add work back to queue")
 			s.onSchedulingErr(work, err)
+			return
 		}
+		s.persistWorkload(work)
 	}
 }
 
@@ -451,6 +536,16 @@ func (s *Scheduler) pickBestRunner(work *Workload) (string, error) {
 	// First get a list of all runners
 	allRunners := s.controller.RunnerIDs()
 
+	// Only keep the runners matching the labels and tolerating the taints
+	if len(allRunners) > 0 {
+		allRunners = Filter(allRunners, func(runnerID string) bool {
+			return s.runnerMatchesWork(runnerID, work)
+		})
+		if len(allRunners) == 0 {
+			return "", ErrNoMatchingRunners
+		}
+	}
+
 	// Reach out to each runner and get their total memory
 	runnerMemory := make(map[string]uint64)
 	for _, runnerID := range allRunners {
@@ -461,9 +556,10 @@ func (s *Scheduler) pickBestRunner(work *Workload) (string, error) {
 	// Filter out runners that don't have enough memory to allocate the new workload
 	numRunnersWithNotEnoughTotalMemory := 0
 	largestRunnerMemory := uint64(0)
-	requiredMemory := work.Model().GetMemoryRequirements(work.Mode())
+	requiredMemory := s.requiredMemory(work)
 	filteredRunners := make([]string, 0)
-	for runnerID, memory := range runnerMemory {
+	for _, runnerID := range allRunners {
+		memory := runnerMemory[runnerID]
 		if memory >= requiredMemory {
 			filteredRunners = append(filteredRunners, runnerID)
 		} else {
@@ -486,15 +582,15 @@ func (s *Scheduler) pickBestRunner(work *Workload) (string, error) {
 	}
 
 	// Calculate the scheduled load on each runner according to their slots
-	// Note: We discussed using real free memory by pinging the runner, but decided to use the
-	// control-plane's view of free memory to avoid the overhead of pinging the runner. This also
-	// has the added benefit of being able to over-commit memory slightly.
+	// Note: We use the control-plane's view of the memory to avoid pinging the runner on every
+	// scheduling decision. The slots use the memory measured by the runners when they reported it,
+	// which is collected in the background from the cached runner statuses.
 	runnerLoad := make(map[string]uint64)
 	for _, runnerID := range filteredRunners {
 		// Sum up all scheduled slots on the runner
 		s.slots.Range(func(_ uuid.UUID, slot *Slot) bool {
 			if slot.RunnerID == runnerID {
-				runnerLoad[runnerID] += slot.Memory()
+				runnerLoad[runnerID] += s.slotMemory(slot)
 			}
 			return true
 		})
@@ -502,11 +598,12 @@ func (s *Scheduler) pickBestRunner(work *Workload) (string, error) {
 	withWorkContext(&log.Logger, work).Debug().Interface("runner_load", runnerLoad).Msg("runner load")
 
 	// Sort the runners by load, increasing, with a random shuffle for ties
-	slices.SortFunc(filteredRunners, func(a, b string) int {
-		if runnerLoad[a] != runnerLoad[b] {
-			return int(runnerLoad[a] - runnerLoad[b])
-		}
-		return rand.Intn(3) - 1 // Introduces random shuffle for true ties
+	slices.Sort(filteredRunners)
+	s.shuffle(len(filteredRunners), func(i, j int) {
+		filteredRunners[i], filteredRunners[j] = filteredRunners[j], filteredRunners[i]
+	})
+	slices.SortStableFunc(filteredRunners, func(a, b string) int {
+		return cmp.Compare(runnerLoad[a], runnerLoad[b])
 	})
 	withWorkContext(&log.Logger, work).Debug().Interface("sorted_runners", filteredRunners).Msg("sorted runners")
 
@@ -528,12 +625,12 @@ func (s *Scheduler) deleteMostStaleStrategy(runnerID string, work *Workload) err
 		s.slots.Range(func(_ uuid.UUID, slot *Slot) bool {
 			if slot.RunnerID == runnerID {
 				allSlots = append(allSlots, slot)
-				allocatedMem += slot.Memory()
+				allocatedMem += s.slotMemory(slot)
 			}
 			return true
 		})
 
-		requiredMem := work.Model().GetMemoryRequirements(work.Mode())
+		requiredMem := s.requiredMemory(work)
 		freeMem := int64(totalMem) - int64(allocatedMem) - int64(requiredMem)
 		log.Trace().Interface("slots", allSlots).Int64("freeMem", freeMem).Msg("checking if we can allocate")
 		// If there is enough free space on the runner, break out of the loop.
@@ -554,9 +651,12 @@ func (s *Scheduler) deleteMostStaleStrategy(runnerID string, work *Workload) err
 			return slot.IsStale()
 		})
 
-		// Sort the slots by last activity time
+		// Sort the slots by last activity time, by ID for ties so evictions are reproducible
 		slices.SortFunc(staleSlots, func(i, j *Slot) int {
-			return int(i.LastActivityTime.Sub(j.LastActivityTime))
+			if !i.LastActivityTime.Equal(j.LastActivityTime) {
+				return int(i.LastActivityTime.Sub(j.LastActivityTime))
+			}
+			return strings.Compare(i.ID.String(), j.ID.String())
 		})
 		log.Trace().Interface("stale_slots", staleSlots).Msg("stale slots")
 		if len(staleSlots) == 0 {
@@ -564,7 +664,7 @@ func (s *Scheduler) deleteMostStaleStrategy(runnerID string, work *Workload) err
 		}
 		// Then delete the most stale slot, allow the reconciler to mop up
 		withSlotContext(&log.Logger, staleSlots[0]).Info().Msg("deleting stale slot")
-		s.slots.Delete(staleSlots[0].ID)
+		s.deleteSlot(staleSlots[0])
 	}
 	return nil
 }
@@ -590,6 +690,12 @@ func (s *Scheduler) warmSlots(req *Workload) []*Slot {
 			return true
 		}
 
+		// If the runner doesn't match the placement of the work, skip
+		if !s.runnerMatchesWork(slot.RunnerID, req) {
+			withSlotContext(&log.Logger, slot).Trace().Msg("skipping warm slot, runner selector mismatch")
+			return true
+		}
+
 		// If the slot is already running another job, skip
 		if slot.IsActive() {
 			withSlotContext(&log.Logger, slot).Trace().Msg("skipping warm slot, already active")
@@ -630,28 +736,39 @@ func (s *Scheduler) pickBestWarmSlot(warmSlots []*Slot) *Slot {
 		return true
 	})
 
+	// Start from a stable order so the tie-breaks only depend on the random source
+	slices.SortFunc(warmSlots, func(i, j *Slot) int {
+		return strings.Compare(i.ID.String(), j.ID.String())
+	})
+	s.shuffle(len(warmSlots), func(i, j int) {
+		warmSlots[i], warmSlots[j] = warmSlots[j], warmSlots[i]
+	})
+
 	// Sort slots considering:
 	// 1. Runner load (prefer less loaded runners)
 	// 2. Last activity time (prefer more recently used slots)
-	// 3. Random factor for tie-breaking
-	slices.SortFunc(warmSlots, func(i, j *Slot) int {
+	// 3. The random order for tie-breaking
+	slices.SortStableFunc(warmSlots, func(i, j *Slot) int {
 		// First compare runner load
 		if activeSlots[i.RunnerID] != activeSlots[j.RunnerID] {
 			return activeSlots[i.RunnerID] - activeSlots[j.RunnerID]
 		}
 
 		// Then prefer more recently used slots (reverse of current order)
-		if !i.LastActivityTime.Equal(j.LastActivityTime) {
-			return int(j.LastActivityTime.Sub(i.LastActivityTime))
-		}
-
-		// Random tie-breaker
-		return rand.Intn(3) - 1
+		return j.LastActivityTime.Compare(i.LastActivityTime)
 	})
 
 	return warmSlots[0]
 }
 
+// shuffle randomizes the order of equally good runners and slots before they
+// are sorted. The simulator seeds the random source so replays are identical.
+func (s *Scheduler) shuffle(n int, swap func(i, j int)) {
+	s.randMu.Lock()
+	defer s.randMu.Unlock()
+	s.rand.Shuffle(n, swap)
+}
+
 // AllocateSlot assigns a workload to a specific slot, validating the model and slot before scheduling.
 func (s *Scheduler) allocateSlot(slotID uuid.UUID, req *Workload) error {
 	// Validate slot
@@ -668,6 +785,7 @@ func (s *Scheduler) allocateSlot(slotID uuid.UUID, req *Workload) error {
 	// Marks the slot as locally active. This is reset in the reconciliation process.
 	withSlotAndWorkContext(&log.Logger, slot, req).Trace().Msg("starting slot")
 	slot.Start()
+	s.trace.started(slot.ID, req, s.clock.Now())
 
 	// Can do the rest in a goroutine, no need to wait for it to submit
 	go func() {
@@ -746,19 +864,19 @@ func (s *Scheduler) createNewSlot(ctx context.Context, slot *Slot) error {
 		return err
 	}
 
-	// Wait for the slot to be ready
-	slotReady := make(chan bool)
+	// Wait for the slot to be ready, checking straight away since some runtimes are ready as soon
+	// as the slot is created
+	slotReady := make(chan bool, 1)
 	go func() {
 		for {
+			if s, err := s.controller.fetchSlot(slot.RunnerID, slot.ID); err == nil && s.Ready {
+				slotReady <- true
+				return
+			}
 			select {
 			case <-ctx.Done():
 				return
 			case <-time.After(500 * time.Millisecond):
-				if s, err := s.controller.fetchSlot(slot.RunnerID, slot.ID); err == nil {
-					if s.Ready {
-						slotReady <- true
-					}
-				}
 			}
 		}
 	}()
@@ -770,6 +888,7 @@ func (s *Scheduler) createNewSlot(ctx context.Context, slot *Slot) error {
 
 	// Mark the slot as running
 	slot.SetRunning()
+	s.persistSlot(slot)
 	withSlotContext(&log.Logger, slot).Info().Msg("slot created on runner")
 
 	return nil
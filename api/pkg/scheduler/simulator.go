@@ -0,0 +1,580 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	openai "github.com/sashabaranov/go-openai"
+	"golang.org/x/exp/rand"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/pubsub"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+const (
+	defaultSimulationColdStart = 30 * time.Second
+	defaultSimulationDuration  = 10 * time.Second
+	defaultSimulationTimeout   = 24 * time.Hour
+	// simulationSubmitTimeout is how long, in real time, the simulator waits
+	// for the fake runners to receive the work the scheduler submitted
+	simulationSubmitTimeout = 10 * time.Second
+)
+
+// SimulatedClock is a Clock that only moves when the simulator advances it
+type SimulatedClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewSimulatedClock(start time.Time) *SimulatedClock {
+	return &SimulatedClock{now: start}
+}
+
+func (c *SimulatedClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *SimulatedClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// SimulatedRunner is a fake runner of the simulation
+type SimulatedRunner struct {
+	ID     string            `json:"id"`
+	Memory uint64            `json:"memory"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Taints map[string]string `json:"taints,omitempty"`
+}
+
+type SimulationConfig struct {
+	Runners        []*SimulatedRunner
+	ModelTTL       time.Duration // How long models are kept warm, the server default if 0
+	SlotTTL        time.Duration // How long work can run before the slot is considered dead, the server default if 0
+	QueueSize      int
+	OwnerQueueSize int
+	// ModelRunnerSelectors restricts the runners the models are placed on
+	ModelRunnerSelectors config.ModelRunnerSelectors
+	// ColdStart is how long a runner takes to load a model into a new slot
+	ColdStart time.Duration
+	// Duration is how long work runs when the trace doesn't say
+	Duration time.Duration
+	// Timeout stops the simulation, in simulated time from the first workload
+	Timeout time.Duration
+	// Seed of the random tie-breaks between equally good runners and slots,
+	// replays with the same seed are identical
+	Seed uint64
+}
+
+// SimulatedWorkload is the outcome of a workload of the trace
+type SimulatedWorkload struct {
+	ID            string                      `json:"id"`
+	Model         string                      `json:"model"`
+	OwnerID       string                      `json:"owner_id"`
+	PriorityClass types.WorkloadPriorityClass `json:"priority_class"`
+	Arrived       time.Time                   `json:"arrived"`
+	Started       time.Time                   `json:"started,omitempty"`
+	// Wait is the time from the arrival until the work started, including
+	// the time to load the model on a cold start
+	Wait      time.Duration `json:"wait"`
+	ColdStart bool          `json:"cold_start"`
+	RunnerID  string        `json:"runner_id,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// SimulatedEviction is a slot deleted from a runner to make room for other work
+type SimulatedEviction struct {
+	Time     time.Time `json:"time"`
+	RunnerID string    `json:"runner_id"`
+	Model    string    `json:"model"`
+}
+
+type SimulationReport struct {
+	Start      time.Time            `json:"start"`
+	End        time.Time            `json:"end"`
+	Workloads  []*SimulatedWorkload `json:"workloads"`
+	Evictions  []*SimulatedEviction `json:"evictions"`
+	ColdStarts int                  `json:"cold_starts"`
+}
+
+// WaitPercentile returns the wait of the started workloads at the percentile,
+// between 0 and 100
+func (r *SimulationReport) WaitPercentile(p float64) time.Duration {
+	var waits []time.Duration
+	for _, w := range r.Workloads {
+		if !w.Started.IsZero() {
+			waits = append(waits, w.Wait)
+		}
+	}
+	if len(waits) == 0 {
+		return 0
+	}
+	slices.Sort(waits)
+	i := int(p / 100 * float64(len(waits)-1))
+	return waits[i]
+}
+
+// Simulate replays a trace of workloads through the scheduler against fake
+// runners. The scheduler runs on a simulated clock, its reconcilers are
+// stepped by the simulator and its ties are broken with the seed of the config
+// so a replay is deterministic and much faster than real time.
+func Simulate(ctx context.Context, cfg *SimulationConfig, trace []*types.SchedulerTraceEntry) (*SimulationReport, error) {
+	if len(cfg.Runners) == 0 {
+		return nil, fmt.Errorf("at least one runner is required")
+	}
+	if len(trace) == 0 {
+		return nil, fmt.Errorf("the trace is empty")
+	}
+	if cfg.ColdStart == 0 {
+		cfg.ColdStart = defaultSimulationColdStart
+	}
+	if cfg.Duration == 0 {
+		cfg.Duration = defaultSimulationDuration
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultSimulationTimeout
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	trace = mergeTrace(trace)
+	slices.SortStableFunc(trace, func(a, b *types.SchedulerTraceEntry) int {
+		return a.Created.Compare(b.Created)
+	})
+
+	sim := &simulation{
+		cfg:       cfg,
+		clock:     NewSimulatedClock(trace[0].Created),
+		workloads: make(map[string]*SimulatedWorkload),
+		durations: make(map[string]time.Duration),
+		received:  make(chan struct{}, len(trace)),
+		report:    &SimulationReport{Start: trace[0].Created},
+	}
+
+	ps, err := pubsub.NewInMemoryNats()
+	if err != nil {
+		return nil, fmt.Errorf("error starting pubsub: %w", err)
+	}
+	controller, err := NewRunnerController(ctx, &RunnerControllerConfig{
+		PubSub: ps,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range cfg.Runners {
+		runner := &simulatedRunner{
+			sim:    sim,
+			runner: r,
+			slots:  make(map[uuid.UUID]*simulatedSlot),
+		}
+		sub, err := ps.SubscribeWithCtx(ctx, pubsub.GetRunnerQueue(r.ID), runner.handle)
+		if err != nil {
+			return nil, fmt.Errorf("error subscribing runner %s: %w", r.ID, err)
+		}
+		defer func() { _ = sub.Unsubscribe() }()
+		controller.OnConnectedHandler(r.ID)
+	}
+
+	serverConfig := &config.ServerConfig{}
+	serverConfig.Providers.Helix.ModelTTL = cfg.ModelTTL
+	serverConfig.Providers.Helix.SlotTTL = cfg.SlotTTL
+	serverConfig.Providers.Helix.OwnerQueueSize = cfg.OwnerQueueSize
+	serverConfig.Providers.Helix.ModelRunnerSelectors = cfg.ModelRunnerSelectors
+
+	s, err := newScheduler(ctx, serverConfig, &Params{
+		RunnerController: controller,
+		QueueSize:        cfg.QueueSize,
+		Clock:            sim.clock,
+		OnSchedulingErr:  sim.onSchedulingErr,
+		Rand:             rand.New(rand.NewSource(cfg.Seed)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.newSlotID = sim.newSlotID
+
+	return sim.run(ctx, s, trace)
+}
+
+// mergeTrace merges the entries recorded when the work was enqueued with the
+// ones recorded with its duration once it was done
+func mergeTrace(trace []*types.SchedulerTraceEntry) []*types.SchedulerTraceEntry {
+	merged := make([]*types.SchedulerTraceEntry, 0, len(trace))
+	byID := make(map[string]*types.SchedulerTraceEntry, len(trace))
+	for _, entry := range trace {
+		if entry.ID == "" {
+			merged = append(merged, entry)
+			continue
+		}
+		if first, ok := byID[entry.ID]; ok {
+			if entry.Duration > 0 {
+				first.Duration = entry.Duration
+			}
+			continue
+		}
+		entry := *entry
+		byID[entry.ID] = &entry
+		merged = append(merged, &entry)
+	}
+	return merged
+}
+
+type simulation struct {
+	cfg   *SimulationConfig
+	clock *SimulatedClock
+
+	mu        sync.Mutex
+	workloads map[string]*SimulatedWorkload
+	durations map[string]time.Duration
+	report    *SimulationReport
+	slotCount uint64
+
+	// received is signalled by the fake runners for every submitted work
+	received chan struct{}
+}
+
+func (sim *simulation) run(ctx context.Context, s *Scheduler, trace []*types.SchedulerTraceEntry) (*SimulationReport, error) {
+	start := sim.clock.Now()
+	nextEntry := 0
+	nextSlotReconcile := start
+
+	for {
+		now := sim.clock.Now()
+		if now.Sub(start) > sim.cfg.Timeout {
+			break
+		}
+
+		for nextEntry < len(trace) && !trace[nextEntry].Created.After(now) {
+			sim.enqueue(s, trace[nextEntry], nextEntry)
+			nextEntry++
+		}
+
+		s.reconcileActivityOnce()
+
+		submitted := 0
+		for {
+			queued := len(s.queue.Queue())
+			s.processQueueOnce()
+			if len(s.queue.Queue()) >= queued {
+				break
+			}
+			submitted++
+		}
+		err := sim.waitForSubmissions(ctx, submitted)
+		if err != nil {
+			return nil, err
+		}
+
+		if !now.Before(nextSlotReconcile) {
+			s.reconcileRunnersOnce()
+			s.reconcileSlotsOnce(ctx)
+			nextSlotReconcile = now.Add(runnerReconcileInterval)
+		}
+
+		if nextEntry == len(trace) && sim.settled() {
+			break
+		}
+
+		sim.clock.Advance(queueReconcileInterval)
+	}
+
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	sim.report.End = sim.clock.Now()
+	for _, w := range sim.report.Workloads {
+		if w.Started.IsZero() && w.Error == "" {
+			w.Error = "not started before the end of the simulation"
+		}
+	}
+	return sim.report, nil
+}
+
+func (sim *simulation) enqueue(s *Scheduler, entry *types.SchedulerTraceEntry, index int) {
+	id := entry.ID
+	if id == "" {
+		id = fmt.Sprintf("trace-%d", index)
+	}
+
+	result := &SimulatedWorkload{
+		ID:            id,
+		Model:         entry.Model,
+		OwnerID:       entry.OwnerID,
+		PriorityClass: entry.PriorityClass,
+		Arrived:       entry.Created,
+	}
+	if result.PriorityClass == "" {
+		result.PriorityClass = types.WorkloadPriorityClassAPI
+	}
+	duration := entry.Duration
+	if duration == 0 {
+		duration = sim.cfg.Duration
+	}
+
+	sim.mu.Lock()
+	sim.report.Workloads = append(sim.report.Workloads, result)
+	sim.workloads[id] = result
+	sim.durations[id] = duration
+	sim.mu.Unlock()
+
+	err := sim.enqueueWork(s, id, entry)
+	if err != nil {
+		sim.mu.Lock()
+		result.Error = err.Error()
+		sim.mu.Unlock()
+	}
+}
+
+func (sim *simulation) enqueueWork(s *Scheduler, id string, entry *types.SchedulerTraceEntry) error {
+	// Only inference requests are replayed, sessions are recorded as the
+	// inference requests they are converted to
+	if entry.Mode != "" && entry.Mode != types.SessionModeInference {
+		return fmt.Errorf("%s workloads can't be simulated", entry.Mode)
+	}
+	switch entry.Runtime {
+	case "", types.RuntimeOllama, types.RuntimeVLLM:
+	default:
+		return fmt.Errorf("%s workloads can't be simulated", entry.Runtime)
+	}
+
+	work, err := NewLLMWorkload(&types.RunnerLLMInferenceRequest{
+		RequestID:      id,
+		CreatedAt:      entry.Created,
+		OwnerID:        entry.OwnerID,
+		PriorityClass:  entry.PriorityClass,
+		Priority:       entry.Priority,
+		RunnerSelector: entry.RunnerSelector,
+		Request: &openai.ChatCompletionRequest{
+			Model: entry.Model,
+			Messages: []openai.ChatCompletionMessage{
+				{Role: openai.ChatMessageRoleUser, Content: "simulated"},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if entry.Runtime != "" && work.Runtime() != entry.Runtime {
+		return fmt.Errorf("model %s runs on %s, not %s", entry.Model, work.Runtime(), entry.Runtime)
+	}
+
+	return s.Enqueue(work)
+}
+
+func (sim *simulation) onSchedulingErr(work *Workload, err error) {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	if w, ok := sim.workloads[work.ID()]; ok && w.Started.IsZero() {
+		w.Error = err.Error()
+	}
+}
+
+// waitForSubmissions waits for the fake runners to receive the work the
+// scheduler submitted in the background, so it's accounted for before the
+// clock moves
+func (sim *simulation) waitForSubmissions(ctx context.Context, count int) error {
+	for i := 0; i < count; i++ {
+		select {
+		case <-sim.received:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(simulationSubmitTimeout):
+			return fmt.Errorf("timed out waiting for the runners to receive the submitted work")
+		}
+	}
+	return nil
+}
+
+// settled returns whether every workload started or failed
+func (sim *simulation) settled() bool {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	for _, w := range sim.report.Workloads {
+		if w.Started.IsZero() && w.Error == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// newSlotID returns sequential slot IDs so replays are reproducible
+func (sim *simulation) newSlotID() uuid.UUID {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	sim.slotCount++
+	var id uuid.UUID
+	binary.BigEndian.PutUint64(id[8:], sim.slotCount)
+	return id
+}
+
+type simulatedSlot struct {
+	model   string
+	runtime types.Runtime
+	// readyAt is when the model is loaded. Slots are reported ready straight
+	// away so the scheduler doesn't wait in real time, work submitted before
+	// the model is loaded starts once it is.
+	readyAt   time.Time
+	busyUntil time.Time
+	served    int
+}
+
+type simulatedRunner struct {
+	sim    *simulation
+	runner *SimulatedRunner
+
+	mu    sync.Mutex
+	slots map[uuid.UUID]*simulatedSlot
+}
+
+func (r *simulatedRunner) handle(_ context.Context, msg *nats.Msg) error {
+	var req types.Request
+	if err := json.Unmarshal(msg.Data, &req); err != nil {
+		return err
+	}
+
+	status, body := r.serve(&req)
+	bts, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp, err := json.Marshal(&types.Response{StatusCode: status, Body: bts})
+	if err != nil {
+		return err
+	}
+	return msg.Respond(resp)
+}
+
+func (r *simulatedRunner) serve(req *types.Request) (int, any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.sim.clock.Now()
+	path := strings.Split(strings.TrimPrefix(req.URL, "/api/v1/"), "/")
+
+	switch {
+	case req.Method == http.MethodGet && req.URL == "/api/v1/healthz":
+		return http.StatusOK, "ok"
+	case req.Method == http.MethodGet && req.URL == "/api/v1/status":
+		return http.StatusOK, &types.RunnerStatus{
+			ID:          r.runner.ID,
+			TotalMemory: r.runner.Memory,
+			Labels:      r.runner.Labels,
+			Taints:      r.runner.Taints,
+		}
+	case req.Method == http.MethodGet && req.URL == "/api/v1/slots":
+		resp := &types.ListRunnerSlotsResponse{}
+		for id, slot := range r.slots {
+			resp.Slots = append(resp.Slots, slot.toRunnerSlot(id, now))
+		}
+		return http.StatusOK, resp
+	case req.Method == http.MethodPost && req.URL == "/api/v1/slots":
+		var create types.CreateRunnerSlotRequest
+		if err := json.Unmarshal(req.Body, &create); err != nil {
+			return http.StatusBadRequest, err.Error()
+		}
+		r.slots[create.ID] = &simulatedSlot{
+			model:   create.Attributes.Model,
+			runtime: create.Attributes.Runtime,
+			readyAt: now.Add(r.sim.cfg.ColdStart),
+		}
+		r.sim.coldStart()
+		return http.StatusCreated, nil
+	case len(path) < 2 || path[0] != "slots":
+		return http.StatusNotFound, "not found"
+	}
+
+	id, err := uuid.Parse(path[1])
+	if err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+	slot, ok := r.slots[id]
+
+	switch {
+	case req.Method == http.MethodGet && len(path) == 2:
+		if !ok {
+			return http.StatusNotFound, "slot not found"
+		}
+		return http.StatusOK, slot.toRunnerSlot(id, now)
+	case req.Method == http.MethodDelete && len(path) == 2:
+		if ok {
+			delete(r.slots, id)
+			r.sim.evicted(r.runner.ID, slot.model, now)
+		}
+		return http.StatusNoContent, nil
+	case req.Method == http.MethodPost:
+		// Everything else posted to a slot is work
+		defer func() { r.sim.received <- struct{}{} }()
+		if !ok {
+			return http.StatusNotFound, "slot not found"
+		}
+		var work types.RunnerNatsReplyRequest
+		if err := json.Unmarshal(req.Body, &work); err != nil {
+			return http.StatusBadRequest, err.Error()
+		}
+		started := now
+		if started.Before(slot.readyAt) {
+			started = slot.readyAt
+		}
+		slot.busyUntil = started.Add(r.sim.started(work.RequestID, r.runner.ID, started, slot.served == 0))
+		slot.served++
+		return http.StatusOK, nil
+	}
+
+	return http.StatusNotFound, "not found"
+}
+
+func (s *simulatedSlot) toRunnerSlot(id uuid.UUID, now time.Time) *types.RunnerSlot {
+	return &types.RunnerSlot{
+		ID:      id,
+		Runtime: s.runtime,
+		Model:   s.model,
+		Active:  now.Before(s.busyUntil),
+		Ready:   true,
+	}
+}
+
+func (sim *simulation) coldStart() {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	sim.report.ColdStarts++
+}
+
+func (sim *simulation) evicted(runnerID, model string, now time.Time) {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	sim.report.Evictions = append(sim.report.Evictions, &SimulatedEviction{
+		Time:     now,
+		RunnerID: runnerID,
+		Model:    model,
+	})
+}
+
+// started records the start of the work and returns how long it runs
+func (sim *simulation) started(id, runnerID string, started time.Time, coldStart bool) time.Duration {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	w, ok := sim.workloads[id]
+	if !ok {
+		return sim.cfg.Duration
+	}
+	w.Started = started
+	w.Wait = started.Sub(w.Arrived)
+	w.ColdStart = coldStart
+	w.RunnerID = runnerID
+	w.Error = ""
+	return sim.durations[id]
+}
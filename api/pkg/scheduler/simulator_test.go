@@ -0,0 +1,188 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/model"
+	"github.com/helixml/helix/api/pkg/pubsub"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+const testLargeModel = "llama3.3:70b-instruct-q4_K_M"
+
+func TestSimulate_WarmSlotReused(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	report, err := Simulate(context.Background(), &SimulationConfig{
+		Runners:   []*SimulatedRunner{{ID: "runner", Memory: 50 * model.GB}},
+		ColdStart: 20 * time.Second,
+		Duration:  5 * time.Second,
+	}, []*types.SchedulerTraceEntry{
+		{ID: "first", Created: start, OwnerID: "alice", Model: testModel},
+		{ID: "second", Created: start.Add(time.Second), OwnerID: "bob", Model: testModel},
+	})
+	require.NoError(t, err)
+	require.Len(t, report.Workloads, 2)
+
+	first, second := report.Workloads[0], report.Workloads[1]
+	require.Empty(t, first.Error)
+	require.True(t, first.ColdStart)
+	require.Equal(t, "runner", first.RunnerID)
+	require.GreaterOrEqual(t, first.Wait, 20*time.Second)
+
+	// The second workload fits in a slot of its own next to the first one
+	require.Empty(t, second.Error)
+	require.True(t, second.ColdStart)
+	require.Equal(t, 2, report.ColdStarts)
+	require.Empty(t, report.Evictions)
+	require.Equal(t, first.Wait, report.WaitPercentile(0))
+}
+
+func TestSimulate_StaleSlotEvicted(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &SimulationConfig{
+		Runners:   []*SimulatedRunner{{ID: "runner", Memory: 50 * model.GB}},
+		ModelTTL:  10 * time.Second,
+		ColdStart: 20 * time.Second,
+		Duration:  5 * time.Second,
+	}
+	trace := []*types.SchedulerTraceEntry{
+		{ID: "small", Created: start, OwnerID: "alice", Model: testModel},
+		{ID: "warm", Created: start.Add(30 * time.Second), OwnerID: "alice", Model: testModel},
+		{ID: "large", Created: start.Add(2 * time.Minute), OwnerID: "bob", Model: testLargeModel, Duration: time.Minute},
+		{ID: "finetune", Created: start.Add(2 * time.Minute), OwnerID: "bob", Model: testModel, Mode: types.SessionModeFinetune},
+	}
+
+	report, err := Simulate(context.Background(), cfg, trace)
+	require.NoError(t, err)
+	require.Len(t, report.Workloads, 4)
+
+	byID := map[string]*SimulatedWorkload{}
+	for _, w := range report.Workloads {
+		byID[w.ID] = w
+	}
+
+	// The warm slot is reused while the model is still loaded
+	require.True(t, byID["small"].ColdStart)
+	require.False(t, byID["warm"].ColdStart)
+	require.Less(t, byID["warm"].Wait, time.Second)
+
+	// The large model only fits once the stale small model is evicted
+	require.True(t, byID["large"].ColdStart)
+	require.Empty(t, byID["large"].Error)
+	require.Len(t, report.Evictions, 1)
+	require.Equal(t, testModel, report.Evictions[0].Model)
+
+	// Finetuning isn't simulated
+	require.Contains(t, byID["finetune"].Error, "can't be simulated")
+
+	// Replays are deterministic
+	again, err := Simulate(context.Background(), cfg, trace)
+	require.NoError(t, err)
+	require.Equal(t, report, again)
+}
+
+func TestSimulate_DeterministicTieBreaks(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &SimulationConfig{
+		// Identical runners, every placement is a tie
+		Runners: []*SimulatedRunner{
+			{ID: "runner-1", Memory: 50 * model.GB},
+			{ID: "runner-2", Memory: 50 * model.GB},
+		},
+		ColdStart: 10 * time.Second,
+		Duration:  5 * time.Second,
+		Seed:      42,
+	}
+
+	var trace []*types.SchedulerTraceEntry
+	for i := 0; i < 8; i++ {
+		trace = append(trace, &types.SchedulerTraceEntry{
+			ID:      fmt.Sprintf("work-%d", i),
+			Created: start.Add(time.Duration(i) * 3 * time.Second),
+			OwnerID: "alice",
+			Model:   testModel,
+		})
+	}
+	// The entry recorded once the work was done sets its duration
+	trace = append(trace, &types.SchedulerTraceEntry{ID: "work-0", Created: start, OwnerID: "alice", Model: testModel, Duration: time.Minute})
+
+	report, err := Simulate(context.Background(), cfg, trace)
+	require.NoError(t, err)
+	require.Len(t, report.Workloads, 8)
+	for _, w := range report.Workloads {
+		require.Empty(t, w.Error)
+	}
+
+	for i := 0; i < 2; i++ {
+		again, err := Simulate(context.Background(), cfg, trace)
+		require.NoError(t, err)
+		require.Equal(t, report, again)
+	}
+}
+
+func TestSimulate_ModelWontFit(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	report, err := Simulate(context.Background(), &SimulationConfig{
+		Runners: []*SimulatedRunner{{ID: "runner", Memory: 20 * model.GB}},
+	}, []*types.SchedulerTraceEntry{
+		{ID: "large", Created: start, OwnerID: "alice", Model: testLargeModel},
+	})
+	require.NoError(t, err)
+	require.Contains(t, report.Workloads[0].Error, "won't fit")
+	require.Zero(t, report.ColdStarts)
+}
+
+func TestScheduler_RecordTrace(t *testing.T) {
+	ps, err := pubsub.NewInMemoryNats()
+	require.NoError(t, err)
+	ctrl, err := NewRunnerController(context.Background(), &RunnerControllerConfig{PubSub: ps})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	s, err := newScheduler(context.Background(), &config.ServerConfig{}, &Params{
+		RunnerController: ctrl,
+		Trace:            &buf,
+	})
+	require.NoError(t, err)
+
+	work := newTestLLMWorkload(t, "work", "alice", types.WorkloadPriorityClassInteractive)
+	selector := &types.RunnerSelector{NodeSelector: map[string]string{"gpu": "a100"}}
+	work.llmInferenceRequest.RunnerSelector = selector
+	require.NoError(t, s.Enqueue(work))
+
+	dec := json.NewDecoder(&buf)
+	var entry types.SchedulerTraceEntry
+	require.NoError(t, dec.Decode(&entry))
+	require.Equal(t, "work", entry.ID)
+	require.Equal(t, "alice", entry.OwnerID)
+	require.Equal(t, types.WorkloadPriorityClassInteractive, entry.PriorityClass)
+	require.Equal(t, testModel, entry.Model)
+	require.Equal(t, types.SessionModeInference, entry.Mode)
+	require.Equal(t, types.RuntimeOllama, entry.Runtime)
+	require.Equal(t, selector, entry.RunnerSelector)
+	require.True(t, entry.Created.Equal(work.Created()))
+	require.Zero(t, entry.Duration)
+
+	// The work is recorded again with its duration once done
+	slotID := uuid.New()
+	started := time.Now()
+	s.trace.started(slotID, work, started)
+	s.trace.finished(slotID, started.Add(42*time.Second))
+
+	var done types.SchedulerTraceEntry
+	require.NoError(t, dec.Decode(&done))
+	require.Equal(t, "work", done.ID)
+	require.Equal(t, selector, done.RunnerSelector)
+	require.Equal(t, 42*time.Second, done.Duration)
+}
@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,21 +17,31 @@ type Slot struct {
 	isStaleFunc      TimeoutFunc
 	isErrorFunc      TimeoutFunc
 	isRunning        bool
+	restoredAt       time.Time // Set when the slot was restored from the persisted state
+	measuredMemory   atomic.Uint64
+	contextLength    int64 // The context length the runtime was started with
+	clock            Clock
 }
 
 // NewSlot creates a new slot with the given runnerID and work
 // staleTimeout is a function that determines if a slot is stale
 // errorTimeout is a function that determines if a slot has errored
 func NewSlot(runnerID string, work *Workload, staleTimeout TimeoutFunc, errorTimeout TimeoutFunc) *Slot {
+	return newSlot(uuid.New(), runnerID, work, staleTimeout, errorTimeout, realClock{})
+}
+
+func newSlot(id uuid.UUID, runnerID string, work *Workload, staleTimeout TimeoutFunc, errorTimeout TimeoutFunc, clock Clock) *Slot {
 	return &Slot{
-		ID:               uuid.New(),
+		ID:               id,
 		RunnerID:         runnerID,
 		initialWork:      work,
-		LastActivityTime: time.Now(),
+		LastActivityTime: clock.Now(),
 		isActive:         false,
 		isStaleFunc:      staleTimeout,
 		isErrorFunc:      errorTimeout,
 		isRunning:        false,
+		contextLength:    modelContextLength(work),
+		clock:            clock,
 	}
 }
 
@@ -70,12 +81,12 @@ func (s *Slot) IsActive() bool {
 // Sets a slot as no longer active
 func (s *Slot) Release() {
 	s.isActive = false
-	s.LastActivityTime = time.Now()
+	s.LastActivityTime = s.clock.Now()
 }
 
 // Marks the work as started
 func (s *Slot) Start() {
-	s.LastActivityTime = time.Now()
+	s.LastActivityTime = s.clock.Now()
 	s.isActive = true
 }
 
@@ -87,10 +98,30 @@ func (s *Slot) SetRunning() {
 	s.isRunning = true
 }
 
+// Memory returns the GPU memory used by the slot, as measured by the runner or
+// estimated from the model until it's been measured
 func (s *Slot) Memory() uint64 {
+	if memory := s.MeasuredMemory(); memory > 0 {
+		return memory
+	}
 	return s.initialWork.Model().GetMemoryRequirements(s.initialWork.Mode())
 }
 
+// MeasuredMemory returns the GPU memory reported by the runner, 0 if unknown
+func (s *Slot) MeasuredMemory() uint64 {
+	return s.measuredMemory.Load()
+}
+
+func (s *Slot) SetMeasuredMemory(memory uint64) {
+	s.measuredMemory.Store(memory)
+}
+
+// ContextLength returns the context length the slot was started with, the KV
+// cache and so the memory of the slot depend on it
+func (s *Slot) ContextLength() int64 {
+	return s.contextLength
+}
+
 func (s *Slot) InitialWork() *Workload {
 	return s.initialWork
 }
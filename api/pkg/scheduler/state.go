@@ -0,0 +1,210 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// slotRestoreGracePeriod is how long restored slots are kept while their
+// runners reconnect after an API restart
+const slotRestoreGracePeriod = 2 * time.Minute
+
+// StateStore persists the queue and the slots so the scheduler can rebuild its
+// state when the API restarts, it is implemented by the store
+type StateStore interface {
+	UpsertSchedulerWorkload(ctx context.Context, workload *types.SchedulerWorkload) error
+	ListSchedulerWorkloads(ctx context.Context) ([]*types.SchedulerWorkload, error)
+	DeleteSchedulerWorkload(ctx context.Context, id string) error
+
+	UpsertSchedulerSlot(ctx context.Context, slot *types.SchedulerSlot) error
+	ListSchedulerSlots(ctx context.Context) ([]*types.SchedulerSlot, error)
+	DeleteSchedulerSlot(ctx context.Context, id string) error
+}
+
+// persistWorkload stores a queued workload, errors are logged since the
+// scheduler keeps working from memory
+func (s *Scheduler) persistWorkload(work *Workload) {
+	if s.state == nil {
+		return
+	}
+
+	bts, err := json.Marshal(work.ToRunnerWorkload())
+	if err != nil {
+		withWorkContext(&log.Logger, work).Warn().Err(err).Msg("failed to marshal workload")
+		return
+	}
+
+	err = s.state.UpsertSchedulerWorkload(s.ctx, &types.SchedulerWorkload{
+		ID:       work.ID(),
+		Created:  work.Created(),
+		Workload: bts,
+	})
+	if err != nil {
+		withWorkContext(&log.Logger, work).Warn().Err(err).Msg("failed to persist workload")
+	}
+}
+
+func (s *Scheduler) forgetWorkload(work *Workload) {
+	if s.state == nil {
+		return
+	}
+
+	err := s.state.DeleteSchedulerWorkload(s.ctx, work.ID())
+	if err != nil {
+		withWorkContext(&log.Logger, work).Warn().Err(err).Msg("failed to delete persisted workload")
+	}
+}
+
+// removeWork removes the work from the queue and from the persisted state
+func (s *Scheduler) removeWork(work *Workload) {
+	s.queue.Remove(work)
+	s.forgetWorkload(work)
+}
+
+// storeSlot adds the slot to the scheduler and persists it
+func (s *Scheduler) storeSlot(slot *Slot) {
+	s.slots.Store(slot.ID, slot)
+	s.persistSlot(slot)
+}
+
+func (s *Scheduler) persistSlot(slot *Slot) {
+	if s.state == nil {
+		return
+	}
+
+	bts, err := json.Marshal(slot.InitialWork().ToRunnerWorkload())
+	if err != nil {
+		withSlotContext(&log.Logger, slot).Warn().Err(err).Msg("failed to marshal slot workload")
+		return
+	}
+
+	err = s.state.UpsertSchedulerSlot(s.ctx, &types.SchedulerSlot{
+		ID:       slot.ID.String(),
+		RunnerID: slot.RunnerID,
+		Running:  slot.IsRunning(),
+		Workload: bts,
+	})
+	if err != nil {
+		withSlotContext(&log.Logger, slot).Warn().Err(err).Msg("failed to persist slot")
+	}
+}
+
+// deleteSlot removes the slot from the scheduler and from the persisted state
+func (s *Scheduler) deleteSlot(slot *Slot) {
+	s.slots.Delete(slot.ID)
+	s.trace.forget(slot.ID)
+
+	if s.state == nil {
+		return
+	}
+
+	err := s.state.DeleteSchedulerSlot(s.ctx, slot.ID.String())
+	if err != nil {
+		withSlotContext(&log.Logger, slot).Warn().Err(err).Msg("failed to delete persisted slot")
+	}
+}
+
+// restoreState rebuilds the queue and the slots from the persisted state, it
+// is called before the reconcilers start
+func (s *Scheduler) restoreState(ctx context.Context) error {
+	if s.state == nil {
+		return nil
+	}
+
+	slots, err := s.state.ListSchedulerSlots(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list scheduler slots: %w", err)
+	}
+
+	for _, stored := range slots {
+		slot, err := s.restoreSlot(stored)
+		if err != nil {
+			log.Warn().Err(err).Str("slot_id", stored.ID).Msg("failed to restore slot, deleting...")
+			if err := s.state.DeleteSchedulerSlot(ctx, stored.ID); err != nil {
+				log.Warn().Err(err).Str("slot_id", stored.ID).Msg("failed to delete persisted slot")
+			}
+			continue
+		}
+		s.slots.Store(slot.ID, slot)
+	}
+
+	workloads, err := s.state.ListSchedulerWorkloads(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list scheduler workloads: %w", err)
+	}
+
+	// Workloads are listed in the order they were created, so re-adding them
+	// rebuilds the same queue
+	for _, stored := range workloads {
+		work, err := decodeWorkload(stored.Workload)
+		if err == nil && work.WorkloadType == WorkloadTypeLLMInferenceRequest {
+			// The request was made to the API before it restarted, running it
+			// would waste the GPUs. The callers waiting for the response get
+			// an error so they can retry.
+			if s.onSchedulingErr != nil {
+				s.onSchedulingErr(work, ErrSchedulerRestarted)
+			}
+			err = ErrSchedulerRestarted
+		}
+		if err == nil {
+			err = s.queue.Add(work)
+		}
+		if err != nil {
+			log.Warn().Err(err).Str("work_id", stored.ID).Msg("failed to restore workload, deleting...")
+			if err := s.state.DeleteSchedulerWorkload(ctx, stored.ID); err != nil {
+				log.Warn().Err(err).Str("work_id", stored.ID).Msg("failed to delete persisted workload")
+			}
+		}
+	}
+
+	log.Info().
+		Int("slots", s.slots.Size()).
+		Int("workloads", len(s.queue.Queue())).
+		Msg("restored scheduler state")
+
+	return nil
+}
+
+func (s *Scheduler) restoreSlot(stored *types.SchedulerSlot) (*Slot, error) {
+	id, err := uuid.Parse(stored.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid slot id: %w", err)
+	}
+
+	work, err := decodeWorkload(stored.Workload)
+	if err != nil {
+		return nil, err
+	}
+
+	slot := newSlot(id, stored.RunnerID, work, s.modelStaleFunc, s.slotTimeoutFunc, s.clock)
+	slot.isRunning = stored.Running
+	// The slot might still be working on a request, the activity reconciler
+	// releases it once the runner reports it as idle
+	slot.isActive = stored.Running
+	slot.restoredAt = s.clock.Now()
+
+	return slot, nil
+}
+
+func decodeWorkload(bts []byte) (*Workload, error) {
+	var work types.RunnerWorkload
+	if err := json.Unmarshal(bts, &work); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal workload: %w", err)
+	}
+
+	switch {
+	case work.LLMInferenceRequest != nil:
+		return NewLLMWorkload(work.LLMInferenceRequest)
+	case work.Session != nil:
+		return NewSessionWorkload(work.Session)
+	}
+
+	return nil, fmt.Errorf("empty workload")
+}
@@ -0,0 +1,211 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/pubsub"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+type memoryStateStore struct {
+	mu        sync.Mutex
+	workloads map[string]*types.SchedulerWorkload
+	slots     map[string]*types.SchedulerSlot
+}
+
+func newMemoryStateStore() *memoryStateStore {
+	return &memoryStateStore{
+		workloads: make(map[string]*types.SchedulerWorkload),
+		slots:     make(map[string]*types.SchedulerSlot),
+	}
+}
+
+func (m *memoryStateStore) UpsertSchedulerWorkload(_ context.Context, workload *types.SchedulerWorkload) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.workloads[workload.ID] = workload
+	return nil
+}
+
+func (m *memoryStateStore) ListSchedulerWorkloads(_ context.Context) ([]*types.SchedulerWorkload, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*types.SchedulerWorkload
+	for _, w := range m.workloads {
+		result = append(result, w)
+	}
+	// Mimic the store ordering
+	for i := 1; i < len(result); i++ {
+		for j := i; j > 0 && result[j].Created.Before(result[j-1].Created); j-- {
+			result[j], result[j-1] = result[j-1], result[j]
+		}
+	}
+	return result, nil
+}
+
+func (m *memoryStateStore) DeleteSchedulerWorkload(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.workloads, id)
+	return nil
+}
+
+func (m *memoryStateStore) UpsertSchedulerSlot(_ context.Context, slot *types.SchedulerSlot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.slots[slot.ID] = slot
+	return nil
+}
+
+func (m *memoryStateStore) ListSchedulerSlots(_ context.Context) ([]*types.SchedulerSlot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*types.SchedulerSlot
+	for _, s := range m.slots {
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+func (m *memoryStateStore) DeleteSchedulerSlot(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.slots, id)
+	return nil
+}
+
+func newTestScheduler(t *testing.T, state StateStore) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	ps, err := pubsub.NewInMemoryNats()
+	require.NoError(t, err)
+
+	ctrl, err := NewRunnerController(ctx, &RunnerControllerConfig{
+		PubSub: ps,
+	})
+	require.NoError(t, err)
+
+	s, err := NewScheduler(ctx, &config.ServerConfig{}, &Params{
+		RunnerController: ctrl,
+		StateStore:       state,
+		OnSchedulingErr:  func(*Workload, error) {},
+	})
+	require.NoError(t, err)
+	return s
+}
+
+func newTestSessionWorkload(t *testing.T, id, owner string, created time.Time) *Workload {
+	work, err := NewSessionWorkload(&types.Session{
+		ID:        id,
+		Owner:     owner,
+		Created:   created,
+		ModelName: testModel,
+		Mode:      types.SessionModeInference,
+		Type:      types.SessionTypeText,
+	})
+	require.NoError(t, err)
+	return work
+}
+
+func TestScheduler_RestoreQueue(t *testing.T) {
+	state := newMemoryStateStore()
+	s := newTestScheduler(t, state)
+
+	first := newTestSessionWorkload(t, "first", "alice", time.Now())
+	second := newTestSessionWorkload(t, "second", "bob", first.Created().Add(time.Second))
+
+	require.NoError(t, s.Enqueue(second))
+	require.NoError(t, s.Enqueue(first))
+	require.Len(t, state.workloads, 2)
+
+	// A new scheduler picks up the queue where the previous one stopped
+	restored := newTestScheduler(t, state)
+	require.Equal(t, []string{"first", "second"}, queueIDs(restored.queue))
+
+	work := restored.queue.Queue()[0]
+	require.Equal(t, "alice", work.OwnerID())
+	require.Equal(t, types.WorkloadPriorityClassInteractive, work.PriorityClass())
+
+	// Removed work is not restored again
+	restored.removeWork(work)
+	require.Len(t, state.workloads, 1)
+}
+
+func TestScheduler_RestoreQueue_FailsLLMInferenceRequests(t *testing.T) {
+	state := newMemoryStateStore()
+	s := newTestScheduler(t, state)
+
+	request := newTestLLMWorkload(t, "request", "alice", types.WorkloadPriorityClassAPI)
+	require.NoError(t, s.Enqueue(request))
+	require.NoError(t, s.Enqueue(newTestSessionWorkload(t, "session", "bob", request.Created().Add(time.Second))))
+	require.Len(t, state.workloads, 2)
+
+	ps, err := pubsub.NewInMemoryNats()
+	require.NoError(t, err)
+	ctrl, err := NewRunnerController(context.Background(), &RunnerControllerConfig{PubSub: ps})
+	require.NoError(t, err)
+
+	failed := map[string]error{}
+	restored, err := newScheduler(context.Background(), &config.ServerConfig{}, &Params{
+		RunnerController: ctrl,
+		StateStore:       state,
+		OnSchedulingErr: func(work *Workload, err error) {
+			failed[work.ID()] = err
+		},
+	})
+	require.NoError(t, err)
+
+	// The caller of the queued request is told to retry instead of waiting
+	// for a response that won't come, the session is queued again
+	require.Equal(t, map[string]error{"request": ErrSchedulerRestarted}, failed)
+	require.Equal(t, []string{"session"}, queueIDs(restored.queue))
+	require.Len(t, state.workloads, 1)
+	require.Contains(t, state.workloads, "session")
+}
+
+func TestScheduler_RestoreSlots(t *testing.T) {
+	state := newMemoryStateStore()
+	s := newTestScheduler(t, state)
+
+	slot := NewSlot("runner", newTestLLMWorkload(t, "work", "alice", types.WorkloadPriorityClassAPI), s.modelStaleFunc, s.slotTimeoutFunc)
+	slot.SetRunning()
+	s.storeSlot(slot)
+
+	// Slots that can't be decoded are dropped
+	state.slots["invalid"] = &types.SchedulerSlot{ID: "invalid", Workload: []byte(`{}`)}
+
+	restored := newTestScheduler(t, state)
+	require.Equal(t, 1, restored.slots.Size())
+	require.NotContains(t, state.slots, "invalid")
+
+	restoredSlot, ok := restored.slots.Load(slot.ID)
+	require.True(t, ok)
+	require.Equal(t, "runner", restoredSlot.RunnerID)
+	require.Equal(t, "work", restoredSlot.InitialWork().ID())
+	require.True(t, restoredSlot.IsRunning())
+
+	// The slot is kept while the runner reconnects
+	restored.reconcileSlotsOnce(context.Background())
+	require.Equal(t, 1, restored.slots.Size())
+
+	// And deleted once the runner didn't come back
+	restoredSlot.restoredAt = time.Now().Add(-slotRestoreGracePeriod - time.Second)
+	restored.reconcileSlotsOnce(context.Background())
+	require.Equal(t, 0, restored.slots.Size())
+	require.Empty(t, state.slots)
+}
+
+func TestScheduler_NoStateStore(t *testing.T) {
+	s := newTestScheduler(t, nil)
+	require.NoError(t, s.Enqueue(newTestLLMWorkload(t, "work", "alice", types.WorkloadPriorityClassAPI)))
+
+	s.storeSlot(NewSlot("runner", newTestLLMWorkload(t, "slot", "alice", types.WorkloadPriorityClassAPI), s.modelStaleFunc, s.slotTimeoutFunc))
+	require.Equal(t, 1, s.slots.Size())
+}
@@ -0,0 +1,107 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// traceRecorder writes the enqueued workloads as JSON lines, the traces are
+// replayed by the simulator. Work is recorded again with its duration once
+// it's done, the simulator merges the entries of a workload.
+type traceRecorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	// running is the work started on each slot until it's done
+	running map[uuid.UUID]runningWork
+}
+
+type runningWork struct {
+	work    *Workload
+	started time.Time
+}
+
+func newTraceRecorder(w io.Writer) *traceRecorder {
+	return &traceRecorder{
+		enc:     json.NewEncoder(w),
+		running: make(map[uuid.UUID]runningWork),
+	}
+}
+
+func (t *traceRecorder) record(work *Workload) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.encode(work, 0)
+}
+
+// started remembers the work submitted to the slot to time it
+func (t *traceRecorder) started(slotID uuid.UUID, work *Workload, now time.Time) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.running[slotID] = runningWork{work: work, started: now}
+}
+
+// finished records the work of the slot with its duration
+func (t *traceRecorder) finished(slotID uuid.UUID, now time.Time) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	running, ok := t.running[slotID]
+	if !ok {
+		return
+	}
+	delete(t.running, slotID)
+
+	t.encode(running.work, now.Sub(running.started))
+}
+
+// forget drops the work of a deleted slot, it didn't finish
+func (t *traceRecorder) forget(slotID uuid.UUID) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.running, slotID)
+}
+
+// encode writes the entry of the work, the lock must be held
+func (t *traceRecorder) encode(work *Workload, duration time.Duration) {
+	err := t.enc.Encode(&types.SchedulerTraceEntry{
+		ID:             work.ID(),
+		Created:        work.Created(),
+		OwnerID:        work.OwnerID(),
+		PriorityClass:  work.PriorityClass(),
+		Priority:       work.Priority(),
+		Model:          work.ModelName().String(),
+		Mode:           work.Mode(),
+		Runtime:        work.Runtime(),
+		RunnerSelector: work.RunnerSelector(),
+		Duration:       duration,
+	})
+	if err != nil {
+		withWorkContext(&log.Logger, work).Warn().Err(err).Msg("failed to record workload trace")
+	}
+}
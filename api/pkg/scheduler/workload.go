@@ -94,7 +94,7 @@ func (w *Workload) Mode() types.SessionMode {
 func (w *Workload) Runtime() types.Runtime {
 	switch w.WorkloadType {
 	case WorkloadTypeLLMInferenceRequest:
-		return types.RuntimeOllama
+		return w.textRuntime()
 	case WorkloadTypeSession:
 		switch w.Mode() {
 		case types.SessionModeInference:
@@ -103,7 +103,7 @@ func (w *Workload) Runtime() types.Runtime {
 				if w.session.LoraDir != "" {
 					return types.RuntimeAxolotl
 				}
-				return types.RuntimeOllama
+				return w.textRuntime()
 			case types.SessionTypeImage:
 				return types.RuntimeDiffusers
 			default:
@@ -124,6 +124,64 @@ func (w *Workload) Runtime() types.Runtime {
 	}
 }
 
+// textRuntime returns the runtime serving the text model, HuggingFace models
+// are served by vLLM and the rest by Ollama
+func (w *Workload) textRuntime() types.Runtime {
+	if _, ok := w.Model().(*model.VLLMGenericText); ok {
+		return types.RuntimeVLLM
+	}
+	return types.RuntimeOllama
+}
+
+// PriorityClass of the workload, sessions are interactive unless they are fine-tuning
+func (w *Workload) PriorityClass() types.WorkloadPriorityClass {
+	switch w.WorkloadType {
+	case WorkloadTypeLLMInferenceRequest:
+		if w.llmInferenceRequest.PriorityClass == "" {
+			return types.WorkloadPriorityClassAPI
+		}
+		return w.llmInferenceRequest.PriorityClass
+	case WorkloadTypeSession:
+		if w.session.Mode == types.SessionModeFinetune {
+			return types.WorkloadPriorityClassFinetune
+		}
+		return types.WorkloadPriorityClassInteractive
+	}
+	panic(fmt.Sprintf("unknown workload type: %s", w.WorkloadType))
+}
+
+// Priority workloads go before the other workloads of their class
+func (w *Workload) Priority() bool {
+	switch w.WorkloadType {
+	case WorkloadTypeLLMInferenceRequest:
+		return w.llmInferenceRequest.Priority
+	case WorkloadTypeSession:
+		return w.session.Metadata.Priority
+	}
+	panic(fmt.Sprintf("unknown workload type: %s", w.WorkloadType))
+}
+
+func (w *Workload) OwnerID() string {
+	switch w.WorkloadType {
+	case WorkloadTypeLLMInferenceRequest:
+		return w.llmInferenceRequest.OwnerID
+	case WorkloadTypeSession:
+		return w.session.Owner
+	}
+	panic(fmt.Sprintf("unknown workload type: %s", w.WorkloadType))
+}
+
+// RunnerSelector restricts the runners the workload is placed on, nil for any runner
+func (w *Workload) RunnerSelector() *types.RunnerSelector {
+	switch w.WorkloadType {
+	case WorkloadTypeLLMInferenceRequest:
+		return w.llmInferenceRequest.RunnerSelector
+	case WorkloadTypeSession:
+		return w.session.Metadata.RunnerSelector
+	}
+	panic(fmt.Sprintf("unknown workload type: %s", w.WorkloadType))
+}
+
 func (w *Workload) LLMInferenceRequest() *types.RunnerLLMInferenceRequest {
 	if w.WorkloadType != WorkloadTypeLLMInferenceRequest {
 		panic(fmt.Sprintf("workload is not  an LLM inference request: %#v", w))
@@ -203,10 +261,11 @@ func (w *Workload) ToLLMInferenceRequest() *types.RunnerLLMInferenceRequest {
 	}
 
 	convertedRequest := types.RunnerLLMInferenceRequest{
-		RequestID: lastInteraction.ID,
-		CreatedAt: time.Now(),
-		Priority:  w.Session().Metadata.Priority,
-		OwnerID:   w.Session().Owner,
+		RequestID:     lastInteraction.ID,
+		CreatedAt:     time.Now(),
+		Priority:      w.Session().Metadata.Priority,
+		PriorityClass: w.PriorityClass(),
+		OwnerID:       w.Session().Owner,
 		Request: &openai.ChatCompletionRequest{
 			Model:    string(w.ModelName()),
 			Messages: chatCompletionMessages,
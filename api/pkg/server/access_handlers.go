@@ -0,0 +1,243 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/helixml/helix/api/pkg/auth"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// listAccessGrants godoc
+// @Summary List access grants
+// @Description List users, teams and organizations that were given access to the resource.
+// @Description The same endpoint is available for apps, knowledge and sessions.
+// @Tags    access
+// @Success 200 {array} types.AccessGrant
+// @Param id path string true "Resource ID"
+// @Router /api/v1/apps/{id}/access [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) listAccessGrants(resourceType types.ResourceType) func(http.ResponseWriter, *http.Request) ([]*types.AccessGrant, *system.HTTPError) {
+	return func(_ http.ResponseWriter, r *http.Request) ([]*types.AccessGrant, *system.HTTPError) {
+		user := getRequestUser(r)
+
+		resource, httpErr := s.getAccessResource(r.Context(), resourceType, getID(r))
+		if httpErr != nil {
+			return nil, httpErr
+		}
+
+		if httpErr := s.authorizeResource(r.Context(), user, resource, auth.ActionRead); httpErr != nil {
+			return nil, httpErr
+		}
+
+		grants, err := s.Store.ListAccessGrants(r.Context(), &store.ListAccessGrantsQuery{
+			ResourceType: resource.Type,
+			ResourceID:   resource.ID,
+		})
+		if err != nil {
+			return nil, system.NewHTTPError500(err.Error())
+		}
+
+		return grants, nil
+	}
+}
+
+// createAccessGrant godoc
+// @Summary Grant access
+// @Description Give a user, team or organization the viewer, editor or owner role on the resource.
+// @Description If the grantee already has access, their role is updated. Only resource owners can manage access.
+// @Tags    access
+// @Success 200 {object} types.AccessGrant
+// @Param id path string true "Resource ID"
+// @Param request body types.CreateAccessGrantRequest true "Request body with grantee and role."
+// @Router /api/v1/apps/{id}/access [post]
+// @Security BearerAuth
+func (s *HelixAPIServer) createAccessGrant(resourceType types.ResourceType) func(http.ResponseWriter, *http.Request) (*types.AccessGrant, *system.HTTPError) {
+	return func(_ http.ResponseWriter, r *http.Request) (*types.AccessGrant, *system.HTTPError) {
+		ctx := r.Context()
+		user := getRequestUser(r)
+
+		resource, httpErr := s.getAccessResource(ctx, resourceType, getID(r))
+		if httpErr != nil {
+			return nil, httpErr
+		}
+
+		if httpErr := s.authorizeResource(ctx, user, resource, auth.ActionManageAccess); httpErr != nil {
+			return nil, httpErr
+		}
+
+		var req types.CreateAccessGrantRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, system.NewHTTPError400(err.Error())
+		}
+
+		if req.GranteeType == "" {
+			req.GranteeType = types.OwnerTypeUser
+		}
+
+		if httpErr := s.validateAccessGrantRequest(ctx, &req); httpErr != nil {
+			return nil, httpErr
+		}
+
+		existing, err := s.Store.ListAccessGrants(ctx, &store.ListAccessGrantsQuery{
+			ResourceType: resource.Type,
+			ResourceID:   resource.ID,
+			Grantee:      req.Grantee,
+			GranteeType:  req.GranteeType,
+		})
+		if err != nil {
+			return nil, system.NewHTTPError500(err.Error())
+		}
+
+		if len(existing) > 0 {
+			grant := existing[0]
+			grant.Role = req.Role
+			grant.GrantedBy = user.ID
+
+			updated, err := s.Store.UpdateAccessGrant(ctx, grant)
+			if err != nil {
+				return nil, system.NewHTTPError500(err.Error())
+			}
+			return updated, nil
+		}
+
+		created, err := s.Store.CreateAccessGrant(ctx, &types.AccessGrant{
+			ResourceType: resource.Type,
+			ResourceID:   resource.ID,
+			Grantee:      req.Grantee,
+			GranteeType:  req.GranteeType,
+			Role:         req.Role,
+			GrantedBy:    user.ID,
+		})
+		if err != nil {
+			return nil, system.NewHTTPError500(err.Error())
+		}
+
+		return created, nil
+	}
+}
+
+// deleteAccessGrant godoc
+// @Summary Revoke access
+// @Description Revoke an access grant. Resource owners can revoke any grant, users can remove their own access.
+// @Tags    access
+// @Success 200 {object} types.AccessGrant
+// @Param id path string true "Resource ID"
+// @Param grant_id path string true "Access grant ID"
+// @Router /api/v1/apps/{id}/access/{grant_id} [delete]
+// @Security BearerAuth
+func (s *HelixAPIServer) deleteAccessGrant(resourceType types.ResourceType) func(http.ResponseWriter, *http.Request) (*types.AccessGrant, *system.HTTPError) {
+	return func(_ http.ResponseWriter, r *http.Request) (*types.AccessGrant, *system.HTTPError) {
+		ctx := r.Context()
+		user := getRequestUser(r)
+
+		resource, httpErr := s.getAccessResource(ctx, resourceType, getID(r))
+		if httpErr != nil {
+			return nil, httpErr
+		}
+
+		grant, err := s.Store.GetAccessGrant(ctx, mux.Vars(r)["grant_id"])
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return nil, system.NewHTTPError404(store.ErrNotFound.Error())
+			}
+			return nil, system.NewHTTPError500(err.Error())
+		}
+
+		if grant.ResourceType != resource.Type || grant.ResourceID != resource.ID {
+			return nil, system.NewHTTPError404(store.ErrNotFound.Error())
+		}
+
+		// Users can always give up access that was granted to them
+		ownGrant := grant.GranteeType == types.OwnerTypeUser && grant.Grantee == user.ID
+		if !ownGrant {
+			if httpErr := s.authorizeResource(ctx, user, resource, auth.ActionManageAccess); httpErr != nil {
+				return nil, httpErr
+			}
+		}
+
+		err = s.Store.DeleteAccessGrant(ctx, grant.ID)
+		if err != nil {
+			return nil, system.NewHTTPError500(err.Error())
+		}
+
+		return grant, nil
+	}
+}
+
+// getAccessResource loads the resource that access is being managed for
+func (s *HelixAPIServer) getAccessResource(ctx context.Context, resourceType types.ResourceType, id string) (auth.Resource, *system.HTTPError) {
+	var (
+		resource auth.Resource
+		err      error
+	)
+
+	switch resourceType {
+	case types.ResourceTypeApp:
+		var app *types.App
+		app, err = s.Store.GetApp(ctx, id)
+		if err == nil {
+			resource = auth.AppResource(app)
+		}
+	case types.ResourceTypeKnowledge:
+		var knowledge *types.Knowledge
+		knowledge, err = s.Store.GetKnowledge(ctx, id)
+		if err == nil {
+			resource = auth.KnowledgeResource(knowledge)
+		}
+	case types.ResourceTypeSession:
+		var session *types.Session
+		session, err = s.Store.GetSession(ctx, id)
+		if err == nil {
+			resource = auth.SessionResource(session)
+		}
+	default:
+		return auth.Resource{}, system.NewHTTPError400("access cannot be granted to " + string(resourceType))
+	}
+
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return auth.Resource{}, system.NewHTTPError404(store.ErrNotFound.Error())
+		}
+		return auth.Resource{}, system.NewHTTPError500(err.Error())
+	}
+
+	return resource, nil
+}
+
+func (s *HelixAPIServer) validateAccessGrantRequest(ctx context.Context, req *types.CreateAccessGrantRequest) *system.HTTPError {
+	if req.Grantee == "" {
+		return system.NewHTTPError400("grantee is required")
+	}
+
+	if !req.Role.IsValid() {
+		return system.NewHTTPError400("role must be one of viewer, editor or owner")
+	}
+
+	var err error
+
+	switch req.GranteeType {
+	case types.OwnerTypeUser:
+		return nil
+	case types.OwnerTypeOrg:
+		_, err = s.Store.GetOrganization(ctx, &store.GetOrganizationQuery{ID: req.Grantee})
+	case types.OwnerTypeTeam:
+		_, err = s.Store.GetTeam(ctx, &store.GetTeamQuery{ID: req.Grantee})
+	default:
+		return system.NewHTTPError400("grantee_type must be one of user, team or org")
+	}
+
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return system.NewHTTPError400("grantee " + req.Grantee + " not found")
+		}
+		return system.NewHTTPError500(err.Error())
+	}
+
+	return nil
+}
@@ -12,7 +12,9 @@ import (
 	"time"
 
 	"github.com/helixml/helix/api/pkg/apps"
+	"github.com/helixml/helix/api/pkg/auth"
 	"github.com/helixml/helix/api/pkg/controller/knowledge"
+	"github.com/helixml/helix/api/pkg/data"
 	"github.com/helixml/helix/api/pkg/store"
 	"github.com/helixml/helix/api/pkg/system"
 	"github.com/helixml/helix/api/pkg/tools"
@@ -34,8 +36,10 @@ func (s *HelixAPIServer) listApps(_ http.ResponseWriter, r *http.Request) ([]*ty
 	user := getRequestUser(r)
 
 	userApps, err := s.Store.ListApps(ctx, &store.ListAppsQuery{
-		Owner:     user.ID,
-		OwnerType: user.Type,
+		Owner:             user.ID,
+		OwnerType:         user.Type,
+		WithOrganizations: true,
+		WithAccessGrants:  true,
 	})
 	if err != nil {
 		return nil, system.NewHTTPError500(err.Error())
@@ -104,18 +108,24 @@ func (s *HelixAPIServer) createApp(_ http.ResponseWriter, r *http.Request) (*typ
 	user := getRequestUser(r)
 	ctx := r.Context()
 
-	// Getting existing tools for the user
+	// Apps are owned by the user unless an organization or team is requested
+	owner, ownerType, httpErr := s.resolveResourceOwner(ctx, user, app.Owner, app.OwnerType)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	// Getting existing apps for the owner
 	existingApps, err := s.Store.ListApps(ctx, &store.ListAppsQuery{
-		Owner:     user.ID,
-		OwnerType: user.Type,
+		Owner:     owner,
+		OwnerType: ownerType,
 	})
 	if err != nil {
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
 	app.ID = system.GenerateAppID()
-	app.Owner = user.ID
-	app.OwnerType = user.Type
+	app.Owner = owner
+	app.OwnerType = ownerType
 	app.Updated = time.Now()
 
 	err = s.validateProviderAndModel(ctx, user, &app)
@@ -147,6 +157,16 @@ func (s *HelixAPIServer) createApp(_ http.ResponseWriter, r *http.Request) (*typ
 			return nil, system.NewHTTPError400(err.Error())
 		}
 
+		err = validateResponseCache(app.Config.Helix.ResponseCache)
+		if err != nil {
+			return nil, system.NewHTTPError400(err.Error())
+		}
+
+		err = validateRunnerSelector(user, app.Config.Helix.RunnerSelector, nil)
+		if err != nil {
+			return nil, system.NewHTTPError403(err.Error())
+		}
+
 		// Validate and default tools
 		for idx := range app.Config.Helix.Assistants {
 			assistant := &app.Config.Helix.Assistants[idx]
@@ -289,6 +309,48 @@ func (s *HelixAPIServer) validateTriggers(triggers []types.Trigger) error {
 	return nil
 }
 
+func validateResponseCache(cfg *types.ResponseCacheConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	if cfg.TTL != "" {
+		ttl, err := time.ParseDuration(cfg.TTL)
+		if err != nil {
+			return fmt.Errorf("invalid response cache TTL: %w", err)
+		}
+		if ttl <= 0 {
+			return fmt.Errorf("response cache TTL must be positive")
+		}
+	}
+	if cfg.SimilarityThreshold < 0 || cfg.SimilarityThreshold > 1 {
+		return fmt.Errorf("response cache similarity threshold must be between 0 and 1")
+	}
+	return nil
+}
+
+// validateRunnerSelector only lets admins set the runner tolerations of the
+// app, they place the app on runners tainted as dedicated. Other users can
+// set node selectors and affinity and keep the tolerations an admin set.
+func validateRunnerSelector(user *types.User, selector, existing *types.RunnerSelector) error {
+	if isAdmin(user) {
+		return nil
+	}
+
+	var tolerations, existingTolerations []types.RunnerToleration
+	if selector != nil {
+		tolerations = selector.Tolerations
+	}
+	if existing != nil {
+		existingTolerations = existing.Tolerations
+	}
+
+	if !slices.Equal(tolerations, existingTolerations) {
+		return fmt.Errorf("runner tolerations can only be set by admins")
+	}
+
+	return nil
+}
+
 // ensureKnowledge creates or updates knowledge config in the database
 func (s *HelixAPIServer) ensureKnowledge(ctx context.Context, app *types.App) error {
 	var knowledge []*types.AssistantKnowledge
@@ -402,8 +464,11 @@ func (s *HelixAPIServer) getApp(_ http.ResponseWriter, r *http.Request) (*types.
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
-	if (!app.Global && !app.Shared) && app.Owner != user.ID {
-		return nil, system.NewHTTPError404(store.ErrNotFound.Error())
+	if httpErr := s.authorizeResource(r.Context(), user, auth.AppResource(app), auth.ActionUse); httpErr != nil {
+		if httpErr.StatusCode == http.StatusForbidden {
+			return nil, system.NewHTTPError404(store.ErrNotFound.Error())
+		}
+		return nil, httpErr
 	}
 	return app, nil
 }
@@ -440,14 +505,12 @@ func (s *HelixAPIServer) updateApp(_ http.ResponseWriter, r *http.Request) (*typ
 		return nil, system.NewHTTPError404(store.ErrNotFound.Error())
 	}
 
-	if existing.Global {
-		if !isAdmin(user) {
-			return nil, system.NewHTTPError403("only admin users can update global apps")
-		}
-	} else {
-		if existing.Owner != user.ID {
-			return nil, system.NewHTTPError403("you do not have permission to update this app")
-		}
+	if existing.Global && !isAdmin(user) {
+		return nil, system.NewHTTPError403("only admin users can update global apps")
+	}
+
+	if httpErr := s.authorizeResource(r.Context(), user, auth.AppResource(existing), auth.ActionUpdate); httpErr != nil {
+		return nil, httpErr
 	}
 
 	err = s.validateProviderAndModel(r.Context(), user, &update)
@@ -460,7 +523,20 @@ func (s *HelixAPIServer) updateApp(_ http.ResponseWriter, r *http.Request) (*typ
 		return nil, system.NewHTTPError400(err.Error())
 	}
 
+	err = validateResponseCache(update.Config.Helix.ResponseCache)
+	if err != nil {
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	err = validateRunnerSelector(user, update.Config.Helix.RunnerSelector, existing.Config.Helix.RunnerSelector)
+	if err != nil {
+		return nil, system.NewHTTPError403(err.Error())
+	}
+
 	update.Updated = time.Now()
+	// Ownership can't be changed through the update
+	update.Owner = existing.Owner
+	update.OwnerType = existing.OwnerType
 
 	// Validate and default tools
 	for idx := range update.Config.Helix.Assistants {
@@ -541,17 +617,21 @@ func (s *HelixAPIServer) updateGithubApp(_ http.ResponseWriter, r *http.Request)
 		return nil, system.NewHTTPError404(store.ErrNotFound.Error())
 	}
 
-	if appUpdate.Global {
-		if !isAdmin(user) {
-			return nil, system.NewHTTPError403("only admin users can update global apps")
-		}
-	} else {
-		if existing.Owner != user.ID {
-			return nil, system.NewHTTPError403("you do not have permission to update this app")
-		}
+	if appUpdate.Global && !isAdmin(user) {
+		return nil, system.NewHTTPError403("only admin users can update global apps")
+	}
+
+	if httpErr := s.authorizeResource(r.Context(), user, auth.AppResource(existing), auth.ActionUpdate); httpErr != nil {
+		return nil, httpErr
 	}
 
 	if existing.AppSource == types.AppSourceGithub {
+		// The synced config is checked before it's stored
+		var (
+			existingSelector = existing.Config.Helix.RunnerSelector
+			selectorErr      error
+		)
+
 		client, err := s.getGithubClientFromRequest(r)
 		if err != nil {
 			return nil, system.NewHTTPError500(err.Error())
@@ -562,6 +642,9 @@ func (s *HelixAPIServer) updateGithubApp(_ http.ResponseWriter, r *http.Request)
 			App:          existing,
 			ToolsPlanner: s.Controller.ToolsPlanner,
 			UpdateApp: func(app *types.App) (*types.App, error) {
+				if selectorErr = validateRunnerSelector(user, app.Config.Helix.RunnerSelector, existingSelector); selectorErr != nil {
+					return nil, selectorErr
+				}
 				return s.Store.UpdateApp(r.Context(), app)
 			},
 		})
@@ -571,6 +654,9 @@ func (s *HelixAPIServer) updateGithubApp(_ http.ResponseWriter, r *http.Request)
 
 		existing, err = githubApp.Update()
 		if err != nil {
+			if selectorErr != nil {
+				return nil, system.NewHTTPError403(selectorErr.Error())
+			}
 			return nil, system.NewHTTPError500(err.Error())
 		}
 	}
@@ -614,14 +700,12 @@ func (s *HelixAPIServer) deleteApp(_ http.ResponseWriter, r *http.Request) (*typ
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
-	if existing.Global {
-		if !isAdmin(user) {
-			return nil, system.NewHTTPError403("only admin users can delete global apps")
-		}
-	} else {
-		if existing.Owner != user.ID {
-			return nil, system.NewHTTPError403("you do not have permission to delete this app")
-		}
+	if existing.Global && !isAdmin(user) {
+		return nil, system.NewHTTPError403("only admin users can delete global apps")
+	}
+
+	if httpErr := s.authorizeResource(r.Context(), user, auth.AppResource(existing), auth.ActionDelete); httpErr != nil {
+		return nil, httpErr
 	}
 
 	if !keepKnowledge {
@@ -771,10 +855,21 @@ func (s *HelixAPIServer) appRunScript(w http.ResponseWriter, r *http.Request) (*
 // @Router /api/v1/apps/{id}/api-actions [post]
 // @Security BearerAuth
 func (s *HelixAPIServer) appRunAPIAction(_ http.ResponseWriter, r *http.Request) (*types.RunAPIActionResponse, *system.HTTPError) {
-	user := getRequestUser(r)
-	id := getID(r)
+	// load the body of the request
+	var req types.RunAPIActionRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return nil, system.NewHTTPError400(fmt.Sprintf("failed to decode request body 4, error: %s", err))
+	}
 
-	app, err := s.Store.GetAppWithTools(r.Context(), id)
+	return s.runAppAction(r.Context(), getRequestUser(r), getID(r), &req)
+}
+
+// runAppAction runs the action of the assistant with the parameters, API
+// actions call the API straight away and GPTScript and Zapier tools get the
+// input parameter as the message
+func (s *HelixAPIServer) runAppAction(ctx context.Context, user *types.User, appID string, req *types.RunAPIActionRequest) (*types.RunAPIActionResponse, *system.HTTPError) {
+	app, err := s.Store.GetAppWithTools(ctx, appID)
 	if err != nil {
 		if errors.Is(err, store.ErrNotFound) {
 			return nil, system.NewHTTPError404("app not found")
@@ -782,15 +877,8 @@ func (s *HelixAPIServer) appRunAPIAction(_ http.ResponseWriter, r *http.Request)
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
-	if user.ID != app.Owner && !app.Global {
-		return nil, system.NewHTTPError403("you do not have permission to run this action")
-	}
-
-	// load the body of the request
-	var req types.RunAPIActionRequest
-	err = json.NewDecoder(r.Body).Decode(&req)
-	if err != nil {
-		return nil, system.NewHTTPError400(fmt.Sprintf("failed to decode request body 4, error: %s", err))
+	if httpErr := s.authorizeResource(ctx, user, auth.AppResource(app), auth.ActionUse); httpErr != nil {
+		return nil, httpErr
 	}
 
 	if req.Action == "" {
@@ -798,20 +886,36 @@ func (s *HelixAPIServer) appRunAPIAction(_ http.ResponseWriter, r *http.Request)
 	}
 
 	if len(app.Config.Helix.Assistants) == 0 {
-		return nil, system.NewHTTPError400("action is required")
+		return nil, system.NewHTTPError400("app has no assistants")
 	}
 
-	// Validate whether action is valid
+	assistant := data.GetAssistant(app, req.AssistantID)
+	if assistant == nil {
+		return nil, system.NewHTTPError400(fmt.Sprintf("assistant %s not found", req.AssistantID))
+	}
 
-	tool, ok := tools.GetToolFromAction(app.Config.Helix.Assistants[0].Tools, req.Action)
+	// Validate whether action is valid
+	tool, ok := tools.GetToolFromAction(assistant.Tools, req.Action)
 	if !ok {
 		return nil, system.NewHTTPError400(fmt.Sprintf("action %s not found in the assistant tools", req.Action))
 	}
 
+	// Actions needing approval only run in sessions, there is nothing to
+	// resume here once they are approved
+	switch tools.GetActionPolicy(tool, req.Action) {
+	case types.ToolActionPolicyDeny:
+		return nil, system.NewHTTPError403(fmt.Sprintf("action %s is not allowed to run", req.Action))
+	case types.ToolActionPolicyConfirm:
+		return nil, system.NewHTTPError403(fmt.Sprintf("action %s needs approval, run it in a session", req.Action))
+	}
+
 	req.Tool = tool
 
-	response, err := s.Controller.ToolsPlanner.RunAPIActionWithParameters(r.Context(), &req)
+	response, err := s.Controller.ToolsPlanner.RunAPIActionWithParameters(ctx, req, tools.WithOwner(user.ID), tools.WithAppID(app.ID))
 	if err != nil {
+		if errors.Is(err, tools.ErrInvalidParameters) || errors.Is(err, tools.ErrMissingCredentials) {
+			return nil, system.NewHTTPError400(err.Error())
+		}
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func Test_validateRunnerSelector(t *testing.T) {
+	admin := &types.User{ID: "admin", Token: "token", Admin: true}
+	user := &types.User{ID: "user", Token: "token"}
+
+	dedicated := &types.RunnerSelector{
+		NodeSelector: map[string]string{"pool": "acme"},
+		Tolerations:  []types.RunnerToleration{{Key: "dedicated", Value: "acme"}},
+	}
+	tolerateAll := &types.RunnerSelector{
+		Tolerations: []types.RunnerToleration{{Operator: types.RunnerTolerationOperatorExists}},
+	}
+
+	tests := []struct {
+		name     string
+		user     *types.User
+		selector *types.RunnerSelector
+		existing *types.RunnerSelector
+		wantErr  bool
+	}{
+		{name: "no selector", user: user},
+		{name: "node selector", user: user, selector: &types.RunnerSelector{NodeSelector: map[string]string{"gpu": "a100"}}},
+		{name: "user tolerations", user: user, selector: tolerateAll, wantErr: true},
+		{name: "user changes tolerations", user: user, selector: tolerateAll, existing: dedicated, wantErr: true},
+		{name: "user keeps admin tolerations", user: user, selector: &types.RunnerSelector{
+			NodeSelector: map[string]string{"gpu": "a100"},
+			Tolerations:  dedicated.Tolerations,
+		}, existing: dedicated},
+		{name: "admin tolerations", user: admin, selector: dedicated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRunnerSelector(tt.user, tt.selector, tt.existing)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
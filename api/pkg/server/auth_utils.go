@@ -2,9 +2,14 @@ package server
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
+	"github.com/helixml/helix/api/pkg/auth"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/system"
 	"github.com/helixml/helix/api/pkg/types"
 )
 
@@ -138,23 +143,48 @@ func doesOwnSession(user *types.User, session *types.Session) bool {
 	return session.OwnerType == user.Type && session.Owner == user.ID
 }
 
-func canSeeSession(user *types.User, session *types.Session) bool {
-	canEdit := canEditSession(user, session)
-	if canEdit {
-		return true
-	}
-	if session.Metadata.Shared {
-		return true
+// authorizeResource checks with the authorizer whether the user can perform
+// the action on the resource. All handlers should go through this (or
+// authorizeResourceRole) instead of comparing owners directly.
+func (apiServer *HelixAPIServer) authorizeResource(ctx context.Context, user *types.User, resource auth.Resource, action auth.Action) *system.HTTPError {
+	err := apiServer.authorizer.Authorize(ctx, user, resource, action)
+	if err != nil {
+		if errors.Is(err, auth.ErrForbidden) {
+			return system.NewHTTPError403(fmt.Sprintf("you do not have %s access to %s %s", action, resource.Type, resource.ID))
+		}
+		return system.NewHTTPError500(err.Error())
 	}
-	return false
+	return nil
 }
 
-func canEditSession(user *types.User, session *types.Session) bool {
-	if session.OwnerType == user.Type && session.Owner == user.ID {
-		return true
-	}
-	if user.Admin {
-		return true
+// isOwnerMember checks whether the user owns the owner directly or is a
+// member of the organization or team
+func (apiServer *HelixAPIServer) isOwnerMember(ctx context.Context, user *types.User, owner string, ownerType types.OwnerType) (bool, error) {
+	isMember, _, err := store.IsOwnerMember(ctx, apiServer.Store, user.ID, owner, ownerType)
+	return isMember, err
+}
+
+// resolveResourceOwner returns the owner for a resource that is being created.
+// Resources are owned by the user unless an organization or team the user is
+// a member of is requested.
+func (apiServer *HelixAPIServer) resolveResourceOwner(ctx context.Context, user *types.User, owner string, ownerType types.OwnerType) (string, types.OwnerType, *system.HTTPError) {
+	switch ownerType {
+	case types.OwnerTypeOrg, types.OwnerTypeTeam:
+		if owner == "" {
+			return "", "", system.NewHTTPError400("owner must be set when creating resources for an organization or team")
+		}
+
+		isMember, err := apiServer.isOwnerMember(ctx, user, owner, ownerType)
+		if err != nil {
+			return "", "", system.NewHTTPError500(err.Error())
+		}
+
+		if !isMember {
+			return "", "", system.NewHTTPError403("you are not a member of the " + string(ownerType) + " " + owner)
+		}
+
+		return owner, ownerType, nil
+	default:
+		return user.ID, user.Type, nil
 	}
-	return false
 }
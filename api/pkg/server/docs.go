@@ -285,6 +285,88 @@ const docTemplate = `{
                 }
             }
         },
+        "/api/v1/mcp": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sends a JSON-RPC message to the MCP server of the app without an event stream, the response is returned in the body.",
+                "tags": [
+                    "mcp"
+                ],
+                "summary": "Send a Model Context Protocol message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "App ID, defaults to the app of the API key",
+                        "name": "app_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "App name, used when the app ID is not set",
+                        "name": "app",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/mcp/message": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Posts a JSON-RPC message for the event stream of the session. The response is sent on the stream and in the response body.",
+                "tags": [
+                    "mcp"
+                ],
+                "summary": "Post a Model Context Protocol message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID from the endpoint event",
+                        "name": "sessionId",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/v1/mcp/sse": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Opens the event stream of the MCP server of the app. The tools of all the assistants and the knowledge of the app are exposed. The first event is the endpoint to post the messages to.",
+                "tags": [
+                    "mcp"
+                ],
+                "summary": "Model Context Protocol event stream",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "App ID, defaults to the app of the API key",
+                        "name": "app_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "App name, used when the app ID is not set",
+                        "name": "app",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
         "/api/v1/providers": {
             "get": {
                 "security": [
@@ -550,6 +632,116 @@ const docTemplate = `{
                 }
             }
         },
+        "/api/v1/tool-approvals": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the tool actions of the user's sessions and of the tools they approve, pending and decided.",
+                "tags": [
+                    "tool-approvals"
+                ],
+                "summary": "List tool approvals",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only list the approvals of this session",
+                        "name": "session_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only list the approvals with this status (pending, approved, denied, expired)",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/types.ToolApproval"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tool-approvals/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a tool action waiting for approval or decided.",
+                "tags": [
+                    "tool-approvals"
+                ],
+                "summary": "Get a tool approval",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tool approval ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.ToolApproval"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tool-approvals/{id}/decision": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Approve or deny a tool action waiting for approval. Approved actions run and the session resumes, denied ones complete without running.",
+                "tags": [
+                    "tool-approvals"
+                ],
+                "summary": "Approve or deny a tool action",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tool approval ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Decision",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.ToolApprovalDecision"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/types.ToolApproval"
+                        }
+                    }
+                }
+            }
+        },
         "/v1/chat/completions": {
             "post": {
                 "security": [
@@ -664,12 +856,14 @@ const docTemplate = `{
             "enum": [
                 "api",
                 "gptscript",
-                "zapier"
+                "zapier",
+                "mcp"
             ],
             "x-enum-varnames": [
                 "ToolTypeAPI",
                 "ToolTypeGPTScript",
-                "ToolTypeZapier"
+                "ToolTypeZapier",
+                "ToolTypeMCP"
             ]
         },
         "github_com_helixml_helix_api_pkg_types.Usage": {
@@ -1394,6 +1588,12 @@ const docTemplate = `{
         "types.AssistantAPI": {
             "type": "object",
             "properties": {
+                "approval": {
+                    "$ref": "#/definitions/types.ToolApprovalConfig"
+                },
+                "auth": {
+                    "$ref": "#/definitions/types.ToolAPIAuth"
+                },
                 "description": {
                     "type": "string"
                 },
@@ -1468,6 +1668,12 @@ const docTemplate = `{
                 "lora_id": {
                     "type": "string"
                 },
+                "mcps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.AssistantMCP"
+                    }
+                },
                 "model": {
                     "type": "string"
                 },
@@ -1572,6 +1778,44 @@ const docTemplate = `{
                 }
             }
         },
+        "types.AssistantMCP": {
+            "type": "object",
+            "properties": {
+                "approval": {
+                    "$ref": "#/definitions/types.ToolApprovalConfig"
+                },
+                "args": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "command": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "env": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "secrets": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
         "types.AssistantZapier": {
             "type": "object",
             "properties": {
@@ -2407,11 +2651,13 @@ const docTemplate = `{
                 "action": {
                     "type": "string"
                 },
+                "assistant_id": {
+                    "description": "AssistantID selects the assistant by ID or index, defaults to the\nfirst one",
+                    "type": "string"
+                },
                 "parameters": {
                     "type": "object",
-                    "additionalProperties": {
-                        "type": "string"
-                    }
+                    "additionalProperties": {}
                 }
             }
         },
@@ -2906,6 +3152,38 @@ const docTemplate = `{
                 }
             }
         },
+        "types.ToolAPIAuth": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "description": "ClientID for OAuth2",
+                    "type": "string"
+                },
+                "scheme": {
+                    "description": "Scheme is the name of the security scheme to use, defaults to the first\none the operation requires",
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "Scopes requested for OAuth2 tokens, defaults to the ones the operation\nrequires",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "description": "Secret holds the API key, the bearer token, the basic auth password or\nthe OAuth2 client secret. It is read from the secrets of the app owner.",
+                    "type": "string"
+                },
+                "user_secret": {
+                    "description": "UserSecret is the secret of each end user holding their OAuth2 token\nfor the authorization code flow. The value is either the access token\nor the JSON token with its refresh token and expiry.",
+                    "type": "string"
+                },
+                "username": {
+                    "description": "Username for basic auth",
+                    "type": "string"
+                }
+            }
+        },
         "types.ToolAPIConfig": {
             "type": "object",
             "properties": {
@@ -2916,6 +3194,22 @@ const docTemplate = `{
                         "$ref": "#/definitions/types.ToolAPIAction"
                     }
                 },
+                "approval": {
+                    "description": "Actions that need approval before running",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.ToolApprovalConfig"
+                        }
+                    ]
+                },
+                "auth": {
+                    "description": "Credentials for the security schemes of the spec",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.ToolAPIAuth"
+                        }
+                    ]
+                },
                 "headers": {
                     "description": "Headers (authentication, etc)",
                     "type": "object",
@@ -2954,6 +3248,153 @@ const docTemplate = `{
                 }
             }
         },
+        "types.ToolActionPolicy": {
+            "type": "string",
+            "enum": [
+                "auto",
+                "confirm",
+                "deny"
+            ],
+            "x-enum-varnames": [
+                "ToolActionPolicyAuto",
+                "ToolActionPolicyConfirm",
+                "ToolActionPolicyDeny"
+            ]
+        },
+        "types.ToolApproval": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "app_id": {
+                    "type": "string"
+                },
+                "approvers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "created": {
+                    "type": "string"
+                },
+                "decided_at": {
+                    "type": "string"
+                },
+                "decided_by": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "interaction_id": {
+                    "type": "string"
+                },
+                "justification": {
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "owner": {
+                    "description": "Owner of the session the action runs in",
+                    "type": "string"
+                },
+                "owner_type": {
+                    "$ref": "#/definitions/types.OwnerType"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "resumed_at": {
+                    "description": "ResumedAt is when the approved action was last picked up to run",
+                    "type": "string"
+                },
+                "session_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/types.ToolApprovalStatus"
+                },
+                "tool_id": {
+                    "type": "string"
+                },
+                "tool_name": {
+                    "type": "string"
+                },
+                "updated": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ToolApprovalConfig": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "description": "Actions sets the policies of single actions, keyed by action name",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/types.ToolActionPolicy"
+                    }
+                },
+                "approvers": {
+                    "description": "Approvers are the users that can decide on the actions besides the\nowner of the session",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "mutating": {
+                    "description": "Mutating applies to the actions with POST, PUT, PATCH and DELETE methods\nand to all the tools of MCP servers",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.ToolActionPolicy"
+                        }
+                    ]
+                },
+                "policy": {
+                    "description": "Policy applies to all the actions, defaults to auto",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.ToolActionPolicy"
+                        }
+                    ]
+                }
+            }
+        },
+        "types.ToolApprovalDecision": {
+            "type": "object",
+            "properties": {
+                "approved": {
+                    "type": "boolean"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ToolApprovalStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "approved",
+                "denied",
+                "expired"
+            ],
+            "x-enum-varnames": [
+                "ToolApprovalStatusPending",
+                "ToolApprovalStatusApproved",
+                "ToolApprovalStatusDenied",
+                "ToolApprovalStatusExpired"
+            ]
+        },
         "types.ToolConfig": {
             "type": "object",
             "properties": {
@@ -2963,6 +3404,9 @@ const docTemplate = `{
                 "gptscript": {
                     "$ref": "#/definitions/types.ToolGPTScriptConfig"
                 },
+                "mcp": {
+                    "$ref": "#/definitions/types.ToolMCPConfig"
+                },
                 "zapier": {
                     "$ref": "#/definitions/types.ToolZapierConfig"
                 }
@@ -2981,6 +3425,70 @@ const docTemplate = `{
                 }
             }
         },
+        "types.ToolMCPConfig": {
+            "type": "object",
+            "properties": {
+                "approval": {
+                    "description": "Tools that need approval before running",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.ToolApprovalConfig"
+                        }
+                    ]
+                },
+                "args": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "command": {
+                    "description": "Command starting the server, e.g. npx",
+                    "type": "string"
+                },
+                "env": {
+                    "description": "Environment variables of the command",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "secrets": {
+                    "description": "Secrets sets environment variables of the command from secrets, keyed\nby variable with the secret name as the value. Only the names are kept\nhere, the values are read from the secrets of the app owner when the\nserver starts.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "tools": {
+                    "description": "Read-only, listed from the server",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ToolMCPTool"
+                    }
+                },
+                "url": {
+                    "description": "SSE endpoint of a running server",
+                    "type": "string"
+                }
+            }
+        },
+        "types.ToolMCPTool": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "input_schema": {
+                    "description": "JSON schema of the arguments",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
         "types.ToolZapierConfig": {
             "type": "object",
             "properties": {
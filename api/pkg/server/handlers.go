@@ -15,6 +15,7 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/helixml/helix/api/pkg/auth"
 	"github.com/helixml/helix/api/pkg/controller"
 	"github.com/helixml/helix/api/pkg/data"
 	"github.com/helixml/helix/api/pkg/filestore"
@@ -39,16 +40,13 @@ func (apiServer *HelixAPIServer) sessionLoaderWithID(req *http.Request, id strin
 		return nil, system.NewHTTPError404(fmt.Sprintf("no session found with id %s", id))
 	}
 
-	canSee := false
-
+	action := auth.ActionRead
 	if writeMode {
-		canSee = canEditSession(user, session)
-	} else {
-		canSee = canSeeSession(user, session)
+		action = auth.ActionUpdate
 	}
 
-	if !canSee {
-		return nil, system.NewHTTPError403(fmt.Sprintf("access denied for session id %s", id))
+	if httpErr := apiServer.authorizeResource(ctx, user, auth.SessionResource(session), action); httpErr != nil {
+		return nil, httpErr
 	}
 	return session, nil
 }
@@ -713,6 +711,12 @@ func (apiServer *HelixAPIServer) deleteSession(_ http.ResponseWriter, req *http.
 		return nil, httpError
 	}
 
+	// editors can change the session but only its owners can delete it
+	httpError = apiServer.authorizeResource(req.Context(), getRequestUser(req), auth.SessionResource(session), auth.ActionDelete)
+	if httpError != nil {
+		return nil, httpError
+	}
+
 	return system.DefaultController(apiServer.Store.DeleteSession(req.Context(), session.ID))
 }
 
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/helixml/helix/api/pkg/auth"
 	"github.com/helixml/helix/api/pkg/store"
 	"github.com/helixml/helix/api/pkg/system"
 	"github.com/helixml/helix/api/pkg/types"
@@ -18,9 +19,11 @@ func (s *HelixAPIServer) listKnowledge(_ http.ResponseWriter, r *http.Request) (
 	appID := r.URL.Query().Get("app_id")
 
 	knowledges, err := s.Store.ListKnowledge(ctx, &store.ListKnowledgeQuery{
-		Owner:     user.ID,
-		OwnerType: user.Type,
-		AppID:     appID,
+		Owner:             user.ID,
+		OwnerType:         user.Type,
+		AppID:             appID,
+		WithOrganizations: true,
+		WithAccessGrants:  true,
 	})
 	if err != nil {
 		return nil, system.NewHTTPError500(err.Error())
@@ -53,8 +56,8 @@ func (s *HelixAPIServer) getKnowledge(_ http.ResponseWriter, r *http.Request) (*
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
-	if existing.Owner != user.ID {
-		return nil, system.NewHTTPError403("you do not have permission to view this knowledge")
+	if httpErr := s.authorizeResource(r.Context(), user, auth.KnowledgeResource(existing), auth.ActionRead); httpErr != nil {
+		return nil, httpErr
 	}
 
 	// Ephemeral progress from the knowledge manager
@@ -75,8 +78,8 @@ func (s *HelixAPIServer) listKnowledgeVersions(_ http.ResponseWriter, r *http.Re
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
-	if existing.Owner != user.ID {
-		return nil, system.NewHTTPError403("you do not have permission to delete this knowledge")
+	if httpErr := s.authorizeResource(r.Context(), user, auth.KnowledgeResource(existing), auth.ActionRead); httpErr != nil {
+		return nil, httpErr
 	}
 
 	versions, err := s.Store.ListKnowledgeVersions(r.Context(), &store.ListKnowledgeVersionQuery{
@@ -101,8 +104,8 @@ func (s *HelixAPIServer) deleteKnowledge(_ http.ResponseWriter, r *http.Request)
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
-	if existing.Owner != user.ID {
-		return nil, system.NewHTTPError403("you do not have permission to delete this knowledge")
+	if httpErr := s.authorizeResource(r.Context(), user, auth.KnowledgeResource(existing), auth.ActionDelete); httpErr != nil {
+		return nil, httpErr
 	}
 
 	err = s.deleteKnowledgeAndVersions(existing)
@@ -174,8 +177,8 @@ func (s *HelixAPIServer) refreshKnowledge(_ http.ResponseWriter, r *http.Request
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
-	if existing.Owner != user.ID {
-		return nil, system.NewHTTPError403("you do not have permission to refresh this knowledge")
+	if httpErr := s.authorizeResource(r.Context(), user, auth.KnowledgeResource(existing), auth.ActionUpdate); httpErr != nil {
+		return nil, httpErr
 	}
 
 	switch existing.State {
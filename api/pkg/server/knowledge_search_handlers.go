@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"sync"
@@ -17,17 +18,22 @@ import (
 )
 
 func (s *HelixAPIServer) knowledgeSearch(_ http.ResponseWriter, r *http.Request) ([]*types.KnowledgeSearchResult, *system.HTTPError) {
-	ctx := r.Context()
-	user := getRequestUser(r)
-
 	appID := r.URL.Query().Get("app_id")             // Required (for now, we can relax this later)
 	knowledgeID := r.URL.Query().Get("knowledge_id") // Optional knowledge ID to search within
 	prompt := r.URL.Query().Get("prompt")            // Search query
 
+	return s.searchKnowledge(r.Context(), getRequestUser(r), appID, knowledgeID, prompt)
+}
+
+// searchKnowledge queries the knowledge of the app the user can access, all
+// of it unless the knowledge ID is set
+func (s *HelixAPIServer) searchKnowledge(ctx context.Context, user *types.User, appID, knowledgeID, prompt string) ([]*types.KnowledgeSearchResult, *system.HTTPError) {
 	knowledges, err := s.Controller.Options.Store.ListKnowledge(ctx, &store.ListKnowledgeQuery{
-		AppID: appID,
-		Owner: user.ID,
-		ID:    knowledgeID,
+		AppID:             appID,
+		Owner:             user.ID,
+		ID:                knowledgeID,
+		WithOrganizations: true,
+		WithAccessGrants:  true,
 	})
 	if err != nil {
 		log.Error().Err(err).Msgf("error listing knowledges for app %s", appID)
@@ -66,6 +72,7 @@ func (s *HelixAPIServer) knowledgeSearch(_ http.ResponseWriter, r *http.Request)
 				DistanceThreshold: knowledge.RAGSettings.Threshold,
 				DistanceFunction:  knowledge.RAGSettings.DistanceFunction,
 				MaxResults:        knowledge.RAGSettings.ResultsCount,
+				SearchMode:        knowledge.RAGSettings.SearchMode,
 			})
 			if err != nil {
 				log.Error().Err(err).Msgf("error querying RAG for knowledge %s", knowledge.ID)
@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 
+	"github.com/helixml/helix/api/pkg/auth"
 	"github.com/helixml/helix/api/pkg/model"
 	"github.com/helixml/helix/api/pkg/system"
 	"github.com/helixml/helix/api/pkg/types"
@@ -55,8 +56,8 @@ func (s *HelixAPIServer) startLearnSessionHandler(rw http.ResponseWriter, req *h
 		return
 	}
 
-	if dataEntity.Owner != user.ID {
-		http.Error(rw, "you must own the data entity", http.StatusBadRequest)
+	if httpErr := s.authorizeResource(ctx, user, auth.DataEntityResource(dataEntity), auth.ActionUse); httpErr != nil {
+		http.Error(rw, httpErr.Message, httpErr.StatusCode)
 		return
 	}
 
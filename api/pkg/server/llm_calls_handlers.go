@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/helixml/helix/api/pkg/auth"
 	"github.com/helixml/helix/api/pkg/store"
 	"github.com/helixml/helix/api/pkg/system"
 	"github.com/helixml/helix/api/pkg/types"
@@ -63,7 +64,7 @@ func (s *HelixAPIServer) listLLMCalls(_ http.ResponseWriter, r *http.Request) (*
 
 // listAppLLMCalls godoc
 // @Summary List LLM calls
-// @Description List user's LLM calls with pagination and optional session filtering for a specific app
+// @Description List LLM calls for a specific app with pagination and optional session filtering. Requires at least the viewer role on the app.
 // @Tags    llm_calls
 // @Produce json
 // @Param   page          query    int     false  "Page number"
@@ -84,7 +85,8 @@ func (s *HelixAPIServer) listAppLLMCalls(_ http.ResponseWriter, r *http.Request)
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
-	if app.Owner != user.ID && !isAdmin(user) {
+	// Anyone with at least the viewer role on the app can see its LLM calls
+	if httpErr := s.authorizeResource(r.Context(), user, auth.AppResource(app), auth.ActionRead); httpErr != nil {
 		return nil, system.NewHTTPError403("you do not have permission to view this app's LLM calls")
 	}
 
@@ -107,7 +109,6 @@ func (s *HelixAPIServer) listAppLLMCalls(_ http.ResponseWriter, r *http.Request)
 		PerPage:       pageSize,
 		SessionFilter: sessionFilter,
 		AppID:         appID,
-		UserID:        user.ID,
 	})
 	if err != nil {
 		return nil, system.NewHTTPError500(err.Error())
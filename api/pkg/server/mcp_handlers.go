@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/helixml/helix/api/pkg/auth"
+	"github.com/helixml/helix/api/pkg/client"
+	"github.com/helixml/helix/api/pkg/mcpserver"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// mcpMessagePath is where the clients of the event streams post their
+// messages, relative so it works behind proxies
+const mcpMessagePath = "/api/v1/mcp/message"
+
+// mcpSSE godoc
+// @Summary Model Context Protocol event stream
+// @Description Opens the event stream of the MCP server of the app. The tools of all the assistants and the knowledge of the app are exposed. The first event is the endpoint to post the messages to.
+// @Tags    mcp
+// @Param app_id query string false "App ID, defaults to the app of the API key"
+// @Param app    query string false "App name, used when the app ID is not set"
+// @Router /api/v1/mcp/sse [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) mcpSSE(rw http.ResponseWriter, r *http.Request) {
+	user := getRequestUser(r)
+
+	mcpServer, httpErr := s.getMCPServer(r.Context(), user, r)
+	if httpErr != nil {
+		http.Error(rw, httpErr.Message, httpErr.StatusCode)
+		return
+	}
+
+	s.mcpSSEServer.ServeSSE(rw, r, user.ID, mcpServer)
+}
+
+// mcpMessage godoc
+// @Summary Post a Model Context Protocol message
+// @Description Posts a JSON-RPC message for the event stream of the session. The response is sent on the stream and in the response body.
+// @Tags    mcp
+// @Param sessionId query string true "Session ID from the endpoint event"
+// @Router /api/v1/mcp/message [post]
+// @Security BearerAuth
+func (s *HelixAPIServer) mcpMessage(rw http.ResponseWriter, r *http.Request) {
+	s.mcpSSEServer.ServeMessage(rw, r, getRequestUser(r).ID)
+}
+
+// mcpHTTP godoc
+// @Summary Send a Model Context Protocol message
+// @Description Sends a JSON-RPC message to the MCP server of the app without an event stream, the response is returned in the body.
+// @Tags    mcp
+// @Param app_id query string false "App ID, defaults to the app of the API key"
+// @Param app    query string false "App name, used when the app ID is not set"
+// @Router /api/v1/mcp [post]
+// @Security BearerAuth
+func (s *HelixAPIServer) mcpHTTP(rw http.ResponseWriter, r *http.Request) {
+	mcpServer, httpErr := s.getMCPServer(r.Context(), getRequestUser(r), r)
+	if httpErr != nil {
+		http.Error(rw, httpErr.Message, httpErr.StatusCode)
+		return
+	}
+
+	mcpserver.ServeHTTP(rw, r, mcpServer)
+}
+
+// getMCPServer returns the MCP server of the app selected by ID or name,
+// API keys created for an app select it
+func (s *HelixAPIServer) getMCPServer(ctx context.Context, user *types.User, r *http.Request) (*server.MCPServer, *system.HTTPError) {
+	app, httpErr := s.getMCPApp(ctx, user, r.URL.Query().Get("app_id"), r.URL.Query().Get("app"))
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	if httpErr := s.authorizeResource(ctx, user, auth.AppResource(app), auth.ActionUse); httpErr != nil {
+		return nil, httpErr
+	}
+
+	mcpServer, err := mcpserver.NewServer(ctx, &mcpBackend{server: s, user: user}, app)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return mcpServer, nil
+}
+
+func (s *HelixAPIServer) getMCPApp(ctx context.Context, user *types.User, appID, name string) (*types.App, *system.HTTPError) {
+	if appID == "" && name == "" {
+		appID = user.AppID
+	}
+
+	if appID != "" {
+		app, err := s.Store.GetApp(ctx, appID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return nil, system.NewHTTPError404("app not found")
+			}
+			return nil, system.NewHTTPError500(err.Error())
+		}
+		return app, nil
+	}
+
+	if name == "" {
+		return nil, system.NewHTTPError400("app_id or app is required")
+	}
+
+	apps, err := s.Store.ListApps(ctx, &store.ListAppsQuery{
+		Owner:             user.ID,
+		OwnerType:         user.Type,
+		WithOrganizations: true,
+		WithAccessGrants:  true,
+	})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	for _, app := range apps {
+		if app.Config.Helix.Name == name {
+			return app, nil
+		}
+	}
+
+	return nil, system.NewHTTPError404(fmt.Sprintf("app %s not found", name))
+}
+
+// mcpBackend runs the MCP tools in process for the user, with the same
+// checks as the API endpoints the CLI calls
+type mcpBackend struct {
+	server *HelixAPIServer
+	user   *types.User
+}
+
+var _ mcpserver.Backend = &mcpBackend{}
+
+func (b *mcpBackend) ListKnowledge(ctx context.Context, f *client.KnowledgeFilter) ([]*types.Knowledge, error) {
+	return b.server.Store.ListKnowledge(ctx, &store.ListKnowledgeQuery{
+		Owner:             b.user.ID,
+		OwnerType:         b.user.Type,
+		AppID:             f.AppID,
+		WithOrganizations: true,
+		WithAccessGrants:  true,
+	})
+}
+
+func (b *mcpBackend) GetKnowledge(ctx context.Context, id string) (*types.Knowledge, error) {
+	knowledge, err := b.server.Store.GetKnowledge(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if httpErr := b.server.authorizeResource(ctx, b.user, auth.KnowledgeResource(knowledge), auth.ActionRead); httpErr != nil {
+		return nil, httpErr
+	}
+
+	// Ephemeral progress from the knowledge manager
+	knowledge.Progress = b.server.knowledgeManager.GetStatus(id)
+
+	return knowledge, nil
+}
+
+func (b *mcpBackend) SearchKnowledge(ctx context.Context, f *client.KnowledgeSearchQuery) ([]*types.KnowledgeSearchResult, error) {
+	results, httpErr := b.server.searchKnowledge(ctx, b.user, f.AppID, f.KnowledgeID, f.Prompt)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+	return results, nil
+}
+
+func (b *mcpBackend) RunAPIAction(ctx context.Context, appID string, req *types.RunAPIActionRequest) (*types.RunAPIActionResponse, error) {
+	resp, httpErr := b.server.runAppAction(ctx, b.user, appID, req)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+	return resp, nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/helixml/helix/api/pkg/controller"
 	"github.com/helixml/helix/api/pkg/model"
 	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/openai/cache"
 	"github.com/helixml/helix/api/pkg/system"
 	"github.com/helixml/helix/api/pkg/types"
 
@@ -92,6 +93,7 @@ func (s *HelixAPIServer) createChatCompletion(rw http.ResponseWriter, r *http.Re
 		InteractionID:   "n/a",
 		OriginalRequest: body,
 	})
+	ctx = oai.SetContextPriorityClass(ctx, types.WorkloadPriorityClassAPI)
 
 	options := &controller.ChatCompletionOptions{
 		AppID:       r.URL.Query().Get("app_id"),
@@ -169,16 +171,28 @@ func (s *HelixAPIServer) createChatCompletion(rw http.ResponseWriter, r *http.Re
 	if !chatCompletionRequest.Stream {
 		resp, _, err := s.Controller.ChatCompletion(ctx, user, chatCompletionRequest, options)
 		if err != nil {
+			if writeRateLimitError(rw, err) {
+				return
+			}
 			log.Error().Err(err).Msg("error creating chat completion")
 			http.Error(rw, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
+		setRateLimitHeaders(rw, options.RateLimit)
+		setCacheHeader(rw, resp.Header())
 		rw.Header().Set("Content-Type", "application/json")
 
+		resp.ID = responseID
+
+		citedResp := &types.ChatCompletionResponse{
+			ChatCompletionResponse: *resp,
+			Citations:              options.Citations,
+		}
+
 		if r.URL.Query().Get("pretty") == "true" {
 			// Pretty print the response with indentation
-			bts, err := json.MarshalIndent(resp, "", "  ")
+			bts, err := json.MarshalIndent(citedResp, "", "  ")
 			if err != nil {
 				log.Error().Err(err).Msg("error marshalling response")
 				http.Error(rw, err.Error(), http.StatusInternalServerError)
@@ -189,9 +203,7 @@ func (s *HelixAPIServer) createChatCompletion(rw http.ResponseWriter, r *http.Re
 			return
 		}
 
-		resp.ID = responseID
-
-		err = json.NewEncoder(rw).Encode(resp)
+		err = json.NewEncoder(rw).Encode(citedResp)
 		if err != nil {
 			log.Error().Err(err).Msg("error writing response")
 		}
@@ -201,11 +213,16 @@ func (s *HelixAPIServer) createChatCompletion(rw http.ResponseWriter, r *http.Re
 	// Streaming request, receive and write the stream in chunks
 	stream, _, err := s.Controller.ChatCompletionStream(ctx, user, chatCompletionRequest, options)
 	if err != nil {
+		if writeRateLimitError(rw, err) {
+			return
+		}
 		http.Error(rw, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	defer stream.Close()
 
+	setRateLimitHeaders(rw, options.RateLimit)
+	setCacheHeader(rw, stream.Header())
 	rw.Header().Set("Content-Type", "text/event-stream")
 	rw.Header().Set("Cache-Control", "no-cache")
 	rw.Header().Set("Connection", "keep-alive")
@@ -233,6 +250,29 @@ func (s *HelixAPIServer) createChatCompletion(rw http.ResponseWriter, r *http.Re
 			log.Error().Msgf("failed to write completion chunk: %v", err)
 		}
 	}
+
+	// Send the citations in the last chunk, once the whole answer has been written.
+	// The chunk has an empty list of choices, like the usage chunk of OpenAI, so
+	// the clients that read the choices of every chunk can parse it
+	if len(options.Citations) > 0 {
+		bts, err := json.Marshal(&types.ChatCompletionStreamResponse{
+			ChatCompletionStreamResponse: openai.ChatCompletionStreamResponse{
+				ID:      responseID,
+				Object:  "chat.completion.chunk",
+				Model:   chatCompletionRequest.Model,
+				Choices: []openai.ChatCompletionStreamChoice{},
+			},
+			Citations: options.Citations,
+		})
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if err := writeChunk(rw, bts); err != nil {
+			log.Error().Msgf("failed to write completion chunk: %v", err)
+		}
+	}
 }
 
 func (s *HelixAPIServer) getAppLoraAssistant(ctx context.Context, appID string) (*types.AssistantConfig, error) {
@@ -253,3 +293,11 @@ func (s *HelixAPIServer) getAppLoraAssistant(ctx context.Context, appID string)
 
 	return assistant, nil
 }
+
+// setCacheHeader tells the client that the response was served from the
+// response cache
+func setCacheHeader(rw http.ResponseWriter, header http.Header) {
+	if cache.IsHit(header) {
+		rw.Header().Set(cache.Header, "hit")
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -770,6 +772,128 @@ func (suite *OpenAIChatSuite) TestChatCompletions_AppRag_Blocking() {
 	suite.Equal("**model-result**", resp.Choices[0].Message.Content)
 }
 
+// TestChatCompletions_AppRag_Streaming reads the stream with the citations
+// using the OpenAI client
+func (suite *OpenAIChatSuite) TestChatCompletions_AppRag_Streaming() {
+
+	const (
+		ragSourceID = "rag-source-id"
+	)
+
+	app := &types.App{
+		Global: true,
+		Config: types.AppConfig{
+			Helix: types.AppHelixConfig{
+				Assistants: []types.AssistantConfig{
+					{
+						SystemPrompt: "you are very custom assistant",
+						RAGSourceID:  ragSourceID,
+					},
+				},
+			},
+		},
+	}
+
+	suite.store.EXPECT().GetAppWithTools(gomock.Any(), "app123").Return(app, nil).Times(1)
+	suite.store.EXPECT().ListSecrets(gomock.Any(), &store.ListSecretsQuery{
+		Owner: suite.userID,
+	}).Return([]*types.Secret{}, nil)
+
+	suite.store.EXPECT().GetDataEntity(gomock.Any(), ragSourceID).Return(&types.DataEntity{
+		Owner: suite.userID,
+		ID:    ragSourceID,
+		Config: types.DataEntityConfig{
+			RAGSettings: types.RAGSettings{
+				Threshold:        40,
+				DistanceFunction: "cosine",
+				ResultsCount:     2,
+			},
+		},
+	}, nil).Times(1)
+
+	suite.rag.EXPECT().Query(gomock.Any(), gomock.Any()).Return([]*types.SessionRAGResult{
+		{
+			Content: "This is a test RAG source 1",
+		},
+	}, nil)
+
+	stream, writer, err := openai.NewOpenAIStreamingAdapter(oai.ChatCompletionRequest{})
+	suite.Require().NoError(err)
+
+	suite.openAiClient.EXPECT().CreateChatCompletionStream(gomock.Any(), gomock.Any()).Return(stream, nil)
+
+	go func() {
+		for i := 0; i < 2; i++ {
+			bts, err := json.Marshal(oai.ChatCompletionStreamResponse{
+				Object: "chat.completion.chunk",
+				Model:  "meta-llama/Meta-Llama-3.1-8B-Instruct-Turbo",
+				Choices: []oai.ChatCompletionStreamChoice{
+					{
+						Delta: oai.ChatCompletionStreamChoiceDelta{
+							Content: fmt.Sprintf("msg-%d", i),
+						},
+					},
+				},
+			})
+			suite.NoError(err)
+
+			suite.NoError(writeChunk(writer, bts))
+		}
+
+		_, err = writer.Write([]byte("[DONE]"))
+		suite.NoError(err)
+
+		writer.Close()
+	}()
+
+	// The OpenAI client reads the stream from the server
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		r.URL.RawQuery = "app_id=app123"
+		suite.server.createChatCompletion(rw, r.WithContext(suite.authCtx))
+	}))
+	defer srv.Close()
+
+	clientConfig := oai.DefaultConfig("")
+	clientConfig.BaseURL = srv.URL + "/v1"
+
+	resp, err := oai.NewClientWithConfig(clientConfig).CreateChatCompletionStream(context.Background(), oai.ChatCompletionRequest{
+		Model: "meta-llama/Meta-Llama-3.1-8B-Instruct-Turbo",
+		Messages: []oai.ChatCompletionMessage{
+			{
+				Role:    "user",
+				Content: "tell me about oceans!",
+			},
+		},
+	})
+	suite.Require().NoError(err)
+	defer resp.Close()
+
+	var (
+		fullResp string
+		chunks   []oai.ChatCompletionStreamResponse
+	)
+
+	for {
+		chunk, err := resp.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		suite.Require().NoError(err)
+
+		chunks = append(chunks, chunk)
+		for _, choice := range chunk.Choices {
+			fullResp += choice.Delta.Content
+		}
+	}
+
+	suite.Equal("msg-0msg-1", fullResp)
+
+	// The last chunk has the citations and no choices
+	suite.Require().Len(chunks, 3)
+	suite.NotNil(chunks[2].Choices)
+	suite.Empty(chunks[2].Choices)
+}
+
 // TestChatCompletions_AppFromAuth_Blocking test that simulates app id coming
 // from the auth context
 func (suite *OpenAIChatSuite) TestChatCompletions_AppFromAuth_Blocking() {
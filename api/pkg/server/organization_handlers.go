@@ -0,0 +1,594 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// listOrganizations godoc
+// @Summary List organizations
+// @Description List organizations the user is a member of.
+// @Tags    organizations
+// @Success 200 {array} types.Organization
+// @Router /api/v1/organizations [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) listOrganizations(_ http.ResponseWriter, r *http.Request) ([]*types.Organization, *system.HTTPError) {
+	user := getRequestUser(r)
+
+	orgs, err := s.Store.ListOrganizations(r.Context(), &store.ListOrganizationsQuery{
+		UserID: user.ID,
+	})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return orgs, nil
+}
+
+// createOrganization godoc
+// @Summary Create new organization
+// @Description Create a new organization, the user becomes its owner.
+// @Tags    organizations
+// @Success 200 {object} types.Organization
+// @Param request body types.Organization true "Request body with organization configuration."
+// @Router /api/v1/organizations [post]
+// @Security BearerAuth
+func (s *HelixAPIServer) createOrganization(_ http.ResponseWriter, r *http.Request) (*types.Organization, *system.HTTPError) {
+	user := getRequestUser(r)
+
+	var org types.Organization
+	if err := json.NewDecoder(r.Body).Decode(&org); err != nil {
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	if org.Name == "" {
+		return nil, system.NewHTTPError400("name is required")
+	}
+
+	created, err := s.Store.CreateOrganization(r.Context(), &types.Organization{
+		Name:        org.Name,
+		DisplayName: org.DisplayName,
+		Owner:       user.ID,
+	})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return created, nil
+}
+
+// getOrganization godoc
+// @Summary Get organization
+// @Description Get organization by ID, only available to its members.
+// @Tags    organizations
+// @Success 200 {object} types.Organization
+// @Param id path string true "Organization ID"
+// @Router /api/v1/organizations/{id} [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) getOrganization(_ http.ResponseWriter, r *http.Request) (*types.Organization, *system.HTTPError) {
+	org, _, httpErr := s.authorizeOrganization(r.Context(), getRequestUser(r), getID(r), types.OrganizationRoleMember)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	return org, nil
+}
+
+// updateOrganization godoc
+// @Summary Update organization
+// @Description Update organization display name, only available to the organization owners.
+// @Tags    organizations
+// @Success 200 {object} types.Organization
+// @Param request body types.Organization true "Request body with organization configuration."
+// @Param id path string true "Organization ID"
+// @Router /api/v1/organizations/{id} [put]
+// @Security BearerAuth
+func (s *HelixAPIServer) updateOrganization(_ http.ResponseWriter, r *http.Request) (*types.Organization, *system.HTTPError) {
+	existing, _, httpErr := s.authorizeOrganization(r.Context(), getRequestUser(r), getID(r), types.OrganizationRoleOwner)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	var update types.Organization
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	existing.DisplayName = update.DisplayName
+
+	updated, err := s.Store.UpdateOrganization(r.Context(), existing)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return updated, nil
+}
+
+// deleteOrganization godoc
+// @Summary Delete organization
+// @Description Delete organization with its teams and memberships, only available to the organization owners.
+// @Tags    organizations
+// @Success 200 {object} types.Organization
+// @Param id path string true "Organization ID"
+// @Router /api/v1/organizations/{id} [delete]
+// @Security BearerAuth
+func (s *HelixAPIServer) deleteOrganization(_ http.ResponseWriter, r *http.Request) (*types.Organization, *system.HTTPError) {
+	existing, _, httpErr := s.authorizeOrganization(r.Context(), getRequestUser(r), getID(r), types.OrganizationRoleOwner)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	err := s.Store.DeleteOrganization(r.Context(), existing.ID)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return existing, nil
+}
+
+// listOrganizationMembers godoc
+// @Summary List organization members
+// @Description List organization members and their roles.
+// @Tags    organizations
+// @Success 200 {array} types.OrganizationMembership
+// @Param id path string true "Organization ID"
+// @Router /api/v1/organizations/{id}/members [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) listOrganizationMembers(_ http.ResponseWriter, r *http.Request) ([]*types.OrganizationMembership, *system.HTTPError) {
+	org, _, httpErr := s.authorizeOrganization(r.Context(), getRequestUser(r), getID(r), types.OrganizationRoleMember)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	memberships, err := s.Store.ListOrganizationMemberships(r.Context(), &store.ListOrganizationMembershipsQuery{
+		OrganizationID: org.ID,
+	})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return memberships, nil
+}
+
+// addOrganizationMember godoc
+// @Summary Add organization member
+// @Description Add a user to the organization, only available to the organization owners.
+// @Tags    organizations
+// @Success 200 {object} types.OrganizationMembership
+// @Param request body types.CreateOrganizationMembershipRequest true "Request body with user ID and role."
+// @Param id path string true "Organization ID"
+// @Router /api/v1/organizations/{id}/members [post]
+// @Security BearerAuth
+func (s *HelixAPIServer) addOrganizationMember(_ http.ResponseWriter, r *http.Request) (*types.OrganizationMembership, *system.HTTPError) {
+	org, _, httpErr := s.authorizeOrganization(r.Context(), getRequestUser(r), getID(r), types.OrganizationRoleOwner)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	var req types.CreateOrganizationMembershipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	if req.UserID == "" {
+		return nil, system.NewHTTPError400("user_id is required")
+	}
+
+	if req.Role == "" {
+		req.Role = types.OrganizationRoleMember
+	}
+
+	if err := validateOrganizationRole(req.Role); err != nil {
+		return nil, err
+	}
+
+	membership, err := s.Store.CreateOrganizationMembership(r.Context(), &types.OrganizationMembership{
+		OrganizationID: org.ID,
+		UserID:         req.UserID,
+		Role:           req.Role,
+	})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return membership, nil
+}
+
+// updateOrganizationMember godoc
+// @Summary Update organization member
+// @Description Change the role of an organization member, only available to the organization owners.
+// @Tags    organizations
+// @Success 200 {object} types.OrganizationMembership
+// @Param request body types.UpdateOrganizationMembershipRequest true "Request body with the new role."
+// @Param id path string true "Organization ID"
+// @Param user_id path string true "User ID"
+// @Router /api/v1/organizations/{id}/members/{user_id} [put]
+// @Security BearerAuth
+func (s *HelixAPIServer) updateOrganizationMember(_ http.ResponseWriter, r *http.Request) (*types.OrganizationMembership, *system.HTTPError) {
+	ctx := r.Context()
+
+	org, _, httpErr := s.authorizeOrganization(ctx, getRequestUser(r), getID(r), types.OrganizationRoleOwner)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	var req types.UpdateOrganizationMembershipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	if err := validateOrganizationRole(req.Role); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.Store.GetOrganizationMembership(ctx, org.ID, mux.Vars(r)["user_id"])
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, system.NewHTTPError404("member not found")
+		}
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	if existing.Role == types.OrganizationRoleOwner && req.Role != types.OrganizationRoleOwner {
+		if httpErr := s.ensureAnotherOrganizationOwner(ctx, org.ID, existing.UserID); httpErr != nil {
+			return nil, httpErr
+		}
+	}
+
+	existing.Role = req.Role
+
+	updated, err := s.Store.UpdateOrganizationMembership(ctx, existing)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return updated, nil
+}
+
+// removeOrganizationMember godoc
+// @Summary Remove organization member
+// @Description Remove a user from the organization and its teams. Owners can remove anyone, members can only leave.
+// @Tags    organizations
+// @Success 200 {object} types.OrganizationMembership
+// @Param id path string true "Organization ID"
+// @Param user_id path string true "User ID"
+// @Router /api/v1/organizations/{id}/members/{user_id} [delete]
+// @Security BearerAuth
+func (s *HelixAPIServer) removeOrganizationMember(_ http.ResponseWriter, r *http.Request) (*types.OrganizationMembership, *system.HTTPError) {
+	ctx := r.Context()
+	user := getRequestUser(r)
+	userID := mux.Vars(r)["user_id"]
+
+	requiredRole := types.OrganizationRoleOwner
+	if userID == user.ID {
+		// Anyone can leave the organization
+		requiredRole = types.OrganizationRoleMember
+	}
+
+	org, _, httpErr := s.authorizeOrganization(ctx, user, getID(r), requiredRole)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	existing, err := s.Store.GetOrganizationMembership(ctx, org.ID, userID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, system.NewHTTPError404("member not found")
+		}
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	if existing.Role == types.OrganizationRoleOwner {
+		if httpErr := s.ensureAnotherOrganizationOwner(ctx, org.ID, existing.UserID); httpErr != nil {
+			return nil, httpErr
+		}
+	}
+
+	err = s.Store.DeleteOrganizationMembership(ctx, org.ID, userID)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return existing, nil
+}
+
+// listTeams godoc
+// @Summary List teams
+// @Description List teams of the organization.
+// @Tags    organizations
+// @Success 200 {array} types.Team
+// @Param id path string true "Organization ID"
+// @Router /api/v1/organizations/{id}/teams [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) listTeams(_ http.ResponseWriter, r *http.Request) ([]*types.Team, *system.HTTPError) {
+	org, _, httpErr := s.authorizeOrganization(r.Context(), getRequestUser(r), getID(r), types.OrganizationRoleMember)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	teams, err := s.Store.ListTeams(r.Context(), &store.ListTeamsQuery{
+		OrganizationID: org.ID,
+	})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return teams, nil
+}
+
+// createTeam godoc
+// @Summary Create team
+// @Description Create a new team in the organization, only available to the organization owners.
+// @Tags    organizations
+// @Success 200 {object} types.Team
+// @Param request body types.Team true "Request body with team configuration."
+// @Param id path string true "Organization ID"
+// @Router /api/v1/organizations/{id}/teams [post]
+// @Security BearerAuth
+func (s *HelixAPIServer) createTeam(_ http.ResponseWriter, r *http.Request) (*types.Team, *system.HTTPError) {
+	org, _, httpErr := s.authorizeOrganization(r.Context(), getRequestUser(r), getID(r), types.OrganizationRoleOwner)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	var team types.Team
+	if err := json.NewDecoder(r.Body).Decode(&team); err != nil {
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	if team.Name == "" {
+		return nil, system.NewHTTPError400("name is required")
+	}
+
+	created, err := s.Store.CreateTeam(r.Context(), &types.Team{
+		OrganizationID: org.ID,
+		Name:           team.Name,
+	})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return created, nil
+}
+
+// updateTeam godoc
+// @Summary Update team
+// @Description Rename a team, only available to the organization owners.
+// @Tags    organizations
+// @Success 200 {object} types.Team
+// @Param request body types.Team true "Request body with team configuration."
+// @Param id path string true "Organization ID"
+// @Param team_id path string true "Team ID"
+// @Router /api/v1/organizations/{id}/teams/{team_id} [put]
+// @Security BearerAuth
+func (s *HelixAPIServer) updateTeam(_ http.ResponseWriter, r *http.Request) (*types.Team, *system.HTTPError) {
+	existing, httpErr := s.authorizeTeam(r, types.OrganizationRoleOwner)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	var update types.Team
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	if update.Name == "" {
+		return nil, system.NewHTTPError400("name is required")
+	}
+
+	existing.Name = update.Name
+
+	updated, err := s.Store.UpdateTeam(r.Context(), existing)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return updated, nil
+}
+
+// deleteTeam godoc
+// @Summary Delete team
+// @Description Delete a team and its memberships, only available to the organization owners.
+// @Tags    organizations
+// @Success 200 {object} types.Team
+// @Param id path string true "Organization ID"
+// @Param team_id path string true "Team ID"
+// @Router /api/v1/organizations/{id}/teams/{team_id} [delete]
+// @Security BearerAuth
+func (s *HelixAPIServer) deleteTeam(_ http.ResponseWriter, r *http.Request) (*types.Team, *system.HTTPError) {
+	existing, httpErr := s.authorizeTeam(r, types.OrganizationRoleOwner)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	err := s.Store.DeleteTeam(r.Context(), existing.ID)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return existing, nil
+}
+
+// listTeamMembers godoc
+// @Summary List team members
+// @Description List members of a team.
+// @Tags    organizations
+// @Success 200 {array} types.TeamMembership
+// @Param id path string true "Organization ID"
+// @Param team_id path string true "Team ID"
+// @Router /api/v1/organizations/{id}/teams/{team_id}/members [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) listTeamMembers(_ http.ResponseWriter, r *http.Request) ([]*types.TeamMembership, *system.HTTPError) {
+	team, httpErr := s.authorizeTeam(r, types.OrganizationRoleMember)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	memberships, err := s.Store.ListTeamMemberships(r.Context(), &store.ListTeamMembershipsQuery{
+		TeamID: team.ID,
+	})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return memberships, nil
+}
+
+// addTeamMember godoc
+// @Summary Add team member
+// @Description Add an organization member to the team, only available to the organization owners.
+// @Tags    organizations
+// @Success 200 {object} types.TeamMembership
+// @Param request body types.CreateTeamMembershipRequest true "Request body with user ID."
+// @Param id path string true "Organization ID"
+// @Param team_id path string true "Team ID"
+// @Router /api/v1/organizations/{id}/teams/{team_id}/members [post]
+// @Security BearerAuth
+func (s *HelixAPIServer) addTeamMember(_ http.ResponseWriter, r *http.Request) (*types.TeamMembership, *system.HTTPError) {
+	team, httpErr := s.authorizeTeam(r, types.OrganizationRoleOwner)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	var req types.CreateTeamMembershipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	if req.UserID == "" {
+		return nil, system.NewHTTPError400("user_id is required")
+	}
+
+	membership, err := s.Store.CreateTeamMembership(r.Context(), &types.TeamMembership{
+		TeamID: team.ID,
+		UserID: req.UserID,
+	})
+	if err != nil {
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	return membership, nil
+}
+
+// removeTeamMember godoc
+// @Summary Remove team member
+// @Description Remove a member from the team, only available to the organization owners.
+// @Tags    organizations
+// @Success 200 {object} types.TeamMembership
+// @Param id path string true "Organization ID"
+// @Param team_id path string true "Team ID"
+// @Param user_id path string true "User ID"
+// @Router /api/v1/organizations/{id}/teams/{team_id}/members/{user_id} [delete]
+// @Security BearerAuth
+func (s *HelixAPIServer) removeTeamMember(_ http.ResponseWriter, r *http.Request) (*types.TeamMembership, *system.HTTPError) {
+	team, httpErr := s.authorizeTeam(r, types.OrganizationRoleOwner)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	existing, err := s.Store.GetTeamMembership(r.Context(), team.ID, mux.Vars(r)["user_id"])
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, system.NewHTTPError404("member not found")
+		}
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	err = s.Store.DeleteTeamMembership(r.Context(), team.ID, existing.UserID)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return existing, nil
+}
+
+// authorizeOrganization loads the organization and checks that the user has at
+// least the required role in it. Admins are treated as organization owners.
+func (s *HelixAPIServer) authorizeOrganization(ctx context.Context, user *types.User, orgID string, role types.OrganizationRole) (*types.Organization, *types.OrganizationMembership, *system.HTTPError) {
+	org, err := s.Store.GetOrganization(ctx, &store.GetOrganizationQuery{ID: orgID})
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, nil, system.NewHTTPError404("organization not found")
+		}
+		return nil, nil, system.NewHTTPError500(err.Error())
+	}
+
+	membership, err := s.Store.GetOrganizationMembership(ctx, org.ID, user.ID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, nil, system.NewHTTPError500(err.Error())
+	}
+
+	if isAdmin(user) {
+		return org, membership, nil
+	}
+
+	if membership == nil {
+		// Don't leak the existence of organizations to non-members
+		return nil, nil, system.NewHTTPError404("organization not found")
+	}
+
+	if role == types.OrganizationRoleOwner && membership.Role != types.OrganizationRoleOwner {
+		return nil, nil, system.NewHTTPError403("only organization owners can perform this action")
+	}
+
+	return org, membership, nil
+}
+
+// authorizeTeam loads the team from the {team_id} path variable, making sure it
+// belongs to the {id} organization the user has the required role in
+func (s *HelixAPIServer) authorizeTeam(r *http.Request, role types.OrganizationRole) (*types.Team, *system.HTTPError) {
+	ctx := r.Context()
+
+	org, _, httpErr := s.authorizeOrganization(ctx, getRequestUser(r), getID(r), role)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	team, err := s.Store.GetTeam(ctx, &store.GetTeamQuery{
+		ID:             mux.Vars(r)["team_id"],
+		OrganizationID: org.ID,
+	})
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, system.NewHTTPError404("team not found")
+		}
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return team, nil
+}
+
+// ensureAnotherOrganizationOwner prevents organizations from ending up
+// without an owner when a member is demoted or removed
+func (s *HelixAPIServer) ensureAnotherOrganizationOwner(ctx context.Context, orgID, userID string) *system.HTTPError {
+	memberships, err := s.Store.ListOrganizationMemberships(ctx, &store.ListOrganizationMembershipsQuery{
+		OrganizationID: orgID,
+	})
+	if err != nil {
+		return system.NewHTTPError500(err.Error())
+	}
+
+	for _, m := range memberships {
+		if m.UserID != userID && m.Role == types.OrganizationRoleOwner {
+			return nil
+		}
+	}
+
+	return system.NewHTTPError400("organization must have at least one owner")
+}
+
+func validateOrganizationRole(role types.OrganizationRole) *system.HTTPError {
+	switch role {
+	case types.OrganizationRoleOwner, types.OrganizationRoleMember:
+		return nil
+	default:
+		return system.NewHTTPError400("role must be either 'owner' or 'member'")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"sort"
 
 	"github.com/gorilla/mux"
+	"github.com/helixml/helix/api/pkg/auth"
 	"github.com/helixml/helix/api/pkg/store"
 	"github.com/helixml/helix/api/pkg/types"
 	"github.com/rs/zerolog/log"
@@ -54,9 +55,10 @@ func (apiServer *HelixAPIServer) listProviderEndpoints(rw http.ResponseWriter, r
 	user := getRequestUser(r)
 
 	providerEndpoints, err := apiServer.Store.ListProviderEndpoints(ctx, &store.ListProviderEndpointsQuery{
-		Owner:      user.ID,
-		OwnerType:  user.Type,
-		WithGlobal: true,
+		Owner:             user.ID,
+		OwnerType:         user.Type,
+		WithGlobal:        true,
+		WithOrganizations: true,
 	})
 	if err != nil {
 		log.Err(err).Msg("error listing provider endpoints")
@@ -164,15 +166,40 @@ func (apiServer *HelixAPIServer) createProviderEndpoint(rw http.ResponseWriter,
 		}
 	}
 
-	// Set owner information
-	endpoint.Owner = user.ID
-	endpoint.OwnerType = user.Type
-
 	// Default to user endpoint type if not specified
 	if endpoint.EndpointType == "" {
 		endpoint.EndpointType = types.ProviderEndpointTypeUser
 	}
 
+	// Default to OpenAI compatible endpoints
+	if endpoint.APIType == "" {
+		endpoint.APIType = types.ProviderAPITypeOpenAI
+	}
+	if err := validateProviderAPIType(endpoint.APIType); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Set owner information, organization and team endpoints are owned by the
+	// organization or team and can only be added by the users who can manage it
+	switch endpoint.EndpointType {
+	case types.ProviderEndpointTypeOrg:
+		endpoint.OwnerType = types.OwnerTypeOrg
+		if httpErr := apiServer.authorizeResource(ctx, user, auth.ProviderEndpointResource(&endpoint), auth.ActionUpdate); httpErr != nil {
+			http.Error(rw, "Only organization owners can add organization endpoints", httpErr.StatusCode)
+			return
+		}
+	case types.ProviderEndpointTypeTeam:
+		endpoint.OwnerType = types.OwnerTypeTeam
+		if httpErr := apiServer.authorizeResource(ctx, user, auth.ProviderEndpointResource(&endpoint), auth.ActionUpdate); httpErr != nil {
+			http.Error(rw, "Only team members can add team endpoints", httpErr.StatusCode)
+			return
+		}
+	default:
+		endpoint.Owner = user.ID
+		endpoint.OwnerType = user.Type
+	}
+
 	// Only admins can add global endpoints
 	if endpoint.EndpointType == types.ProviderEndpointTypeGlobal && !isAdmin {
 		http.Error(rw, "Only admins can add global endpoints", http.StatusForbidden)
@@ -234,8 +261,8 @@ func (apiServer *HelixAPIServer) updateProviderEndpoint(rw http.ResponseWriter,
 	}
 
 	// Check ownership - only allow updates to owned endpoints or if user is admin
-	if existingEndpoint.Owner != user.ID && !user.Admin {
-		http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+	if httpErr := apiServer.authorizeResource(ctx, user, auth.ProviderEndpointResource(existingEndpoint), auth.ActionUpdate); httpErr != nil {
+		http.Error(rw, httpErr.Message, httpErr.StatusCode)
 		return
 	}
 
@@ -258,6 +285,14 @@ func (apiServer *HelixAPIServer) updateProviderEndpoint(rw http.ResponseWriter,
 		return
 	}
 
+	if updatedEndpoint.APIType != "" {
+		if err := validateProviderAPIType(updatedEndpoint.APIType); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		existingEndpoint.APIType = updatedEndpoint.APIType
+	}
+
 	// Preserve ID and ownership information
 	existingEndpoint.Description = updatedEndpoint.Description
 	existingEndpoint.Models = updatedEndpoint.Models
@@ -332,8 +367,8 @@ func (apiServer *HelixAPIServer) deleteProviderEndpoint(rw http.ResponseWriter,
 	}
 
 	// Check ownership - only allow deletion of owned endpoints or if user is admin
-	if existingEndpoint.Owner != user.ID && !user.Admin {
-		http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+	if httpErr := apiServer.authorizeResource(ctx, user, auth.ProviderEndpointResource(existingEndpoint), auth.ActionDelete); httpErr != nil {
+		http.Error(rw, httpErr.Message, httpErr.StatusCode)
 		return
 	}
 
@@ -345,3 +380,12 @@ func (apiServer *HelixAPIServer) deleteProviderEndpoint(rw http.ResponseWriter,
 
 	rw.WriteHeader(http.StatusOK)
 }
+
+func validateProviderAPIType(apiType types.ProviderAPIType) error {
+	switch apiType {
+	case types.ProviderAPITypeOpenAI, types.ProviderAPITypeAnthropic, types.ProviderAPITypeGemini:
+		return nil
+	default:
+		return fmt.Errorf("unknown API type '%s', must be one of openai, anthropic or gemini", apiType)
+	}
+}
@@ -0,0 +1,306 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/helixml/helix/api/pkg/auth"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+	"github.com/rs/zerolog/log"
+)
+
+// listProviderRoutingGroups godoc
+// @Summary List provider routing groups
+// @Description List the provider routing groups of the user, their organizations and the global ones
+// @Tags    providers
+
+// @Success 200 {array} types.ProviderRoutingGroup
+// @Router /api/v1/provider-routing-groups [get]
+// @Security BearerAuth
+func (apiServer *HelixAPIServer) listProviderRoutingGroups(rw http.ResponseWriter, r *http.Request) {
+	user := getRequestUser(r)
+
+	groups, err := apiServer.Store.ListProviderRoutingGroups(r.Context(), &store.ListProviderEndpointsQuery{
+		Owner:             user.ID,
+		OwnerType:         user.Type,
+		WithGlobal:        true,
+		WithOrganizations: true,
+	})
+	if err != nil {
+		log.Err(err).Msg("error listing provider routing groups")
+		http.Error(rw, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(rw).Encode(groups)
+	if err != nil {
+		log.Err(err).Msg("error writing response")
+		http.Error(rw, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// createProviderRoutingGroup godoc
+// @Summary Create a provider routing group
+// @Description Create a group of providers that can be used as a provider, requests are routed with the group strategy and fall back to the other providers on errors
+// @Tags    providers
+
+// @Success 200 {object} types.ProviderRoutingGroup
+// @Param request    body types.ProviderRoutingGroup true "Request body with the routing group"
+// @Router /api/v1/provider-routing-groups [post]
+// @Security BearerAuth
+func (apiServer *HelixAPIServer) createProviderRoutingGroup(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getRequestUser(r)
+
+	isAdmin := apiServer.isAdmin(r)
+
+	if !isAdmin && !apiServer.Cfg.Providers.EnableCustomUserProviders {
+		http.Error(rw, "Custom user providers are not enabled", http.StatusForbidden)
+		return
+	}
+
+	var group types.ProviderRoutingGroup
+	if err := json.NewDecoder(r.Body).Decode(&group); err != nil {
+		log.Err(err).Msg("error decoding request body")
+		http.Error(rw, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateProviderRoutingGroup(&group); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Groups are used by name like providers so the names can't collide
+	existingProviders, err := apiServer.providerManager.ListProviders(ctx, user.ID)
+	if err != nil {
+		log.Err(err).Msg("error listing providers")
+		http.Error(rw, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, provider := range existingProviders {
+		if string(provider) == group.Name {
+			http.Error(rw, fmt.Sprintf("Provider with name '%s' already exists", group.Name), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Default to user group type if not specified
+	if group.EndpointType == "" {
+		group.EndpointType = types.ProviderEndpointTypeUser
+	}
+
+	// Organization and team groups are owned by the organization or team and
+	// can only be added by the users who can manage it
+	switch group.EndpointType {
+	case types.ProviderEndpointTypeOrg:
+		group.OwnerType = types.OwnerTypeOrg
+		if httpErr := apiServer.authorizeResource(ctx, user, auth.ProviderRoutingGroupResource(&group), auth.ActionUpdate); httpErr != nil {
+			http.Error(rw, "Only organization owners can add organization routing groups", httpErr.StatusCode)
+			return
+		}
+	case types.ProviderEndpointTypeTeam:
+		group.OwnerType = types.OwnerTypeTeam
+		if httpErr := apiServer.authorizeResource(ctx, user, auth.ProviderRoutingGroupResource(&group), auth.ActionUpdate); httpErr != nil {
+			http.Error(rw, "Only team members can add team routing groups", httpErr.StatusCode)
+			return
+		}
+	default:
+		group.Owner = user.ID
+		group.OwnerType = user.Type
+	}
+
+	// Only admins can add global groups
+	if group.EndpointType == types.ProviderEndpointTypeGlobal && !isAdmin {
+		http.Error(rw, "Only admins can add global routing groups", http.StatusForbidden)
+		return
+	}
+
+	createdGroup, err := apiServer.Store.CreateProviderRoutingGroup(ctx, &group)
+	if err != nil {
+		log.Err(err).Msg("error creating provider routing group")
+		http.Error(rw, "Error creating provider routing group: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(createdGroup); err != nil {
+		log.Err(err).Msg("error writing response")
+		http.Error(rw, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// updateProviderRoutingGroup godoc
+// @Summary Update a provider routing group
+// @Description Update the name, description, strategy and providers of a routing group. Global groups can only be updated by admins.
+// @Tags    providers
+
+// @Success 200 {object} types.ProviderRoutingGroup
+// @Param request    body types.ProviderRoutingGroup true "Request body with the routing group"
+// @Param id path string true "Routing group ID"
+// @Router /api/v1/provider-routing-groups/{id} [put]
+// @Security BearerAuth
+func (apiServer *HelixAPIServer) updateProviderRoutingGroup(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getRequestUser(r)
+	groupID := mux.Vars(r)["id"]
+
+	if !user.Admin && !apiServer.Cfg.Providers.EnableCustomUserProviders {
+		http.Error(rw, "Custom user providers are not enabled", http.StatusForbidden)
+		return
+	}
+
+	existingGroup, err := apiServer.Store.GetProviderRoutingGroup(ctx, &store.GetProviderRoutingGroupQuery{ID: groupID})
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(rw, "Provider routing group not found", http.StatusNotFound)
+			return
+		}
+		log.Err(err).Msg("error getting provider routing group")
+		http.Error(rw, "Error getting provider routing group: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if httpErr := apiServer.authorizeResource(ctx, user, auth.ProviderRoutingGroupResource(existingGroup), auth.ActionUpdate); httpErr != nil {
+		http.Error(rw, httpErr.Message, httpErr.StatusCode)
+		return
+	}
+
+	if existingGroup.EndpointType == types.ProviderEndpointTypeGlobal && !user.Admin {
+		http.Error(rw, "Only admins can update global routing groups", http.StatusForbidden)
+		return
+	}
+
+	var updatedGroup types.ProviderRoutingGroup
+	if err := json.NewDecoder(r.Body).Decode(&updatedGroup); err != nil {
+		log.Err(err).Msg("error decoding request body")
+		http.Error(rw, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateProviderRoutingGroup(&updatedGroup); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if updatedGroup.Name != existingGroup.Name {
+		existingProviders, err := apiServer.providerManager.ListProviders(ctx, user.ID)
+		if err != nil {
+			log.Err(err).Msg("error listing providers")
+			http.Error(rw, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		for _, provider := range existingProviders {
+			if string(provider) == updatedGroup.Name {
+				http.Error(rw, fmt.Sprintf("Provider with name '%s' already exists", updatedGroup.Name), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	// Preserve ID and ownership information
+	existingGroup.Name = updatedGroup.Name
+	existingGroup.Description = updatedGroup.Description
+	existingGroup.Strategy = updatedGroup.Strategy
+	existingGroup.Members = updatedGroup.Members
+
+	savedGroup, err := apiServer.Store.UpdateProviderRoutingGroup(ctx, existingGroup)
+	if err != nil {
+		log.Err(err).Msg("error updating provider routing group")
+		http.Error(rw, "Error updating provider routing group: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(savedGroup); err != nil {
+		log.Err(err).Msg("error writing response")
+		http.Error(rw, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// deleteProviderRoutingGroup godoc
+// @Summary Delete a provider routing group
+// @Description Delete a provider routing group. Global groups can only be deleted by admins.
+// @Tags    providers
+
+// @Success 200
+// @Param id path string true "Routing group ID"
+// @Router /api/v1/provider-routing-groups/{id} [delete]
+// @Security BearerAuth
+func (apiServer *HelixAPIServer) deleteProviderRoutingGroup(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getRequestUser(r)
+	groupID := mux.Vars(r)["id"]
+
+	existingGroup, err := apiServer.Store.GetProviderRoutingGroup(ctx, &store.GetProviderRoutingGroupQuery{ID: groupID})
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(rw, "Provider routing group not found", http.StatusNotFound)
+			return
+		}
+		log.Err(err).Msg("error getting provider routing group")
+		http.Error(rw, "Error getting provider routing group: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if existingGroup.EndpointType == types.ProviderEndpointTypeGlobal && !apiServer.isAdmin(r) {
+		http.Error(rw, "Global routing groups can only be deleted by admins", http.StatusForbidden)
+		return
+	}
+
+	if httpErr := apiServer.authorizeResource(ctx, user, auth.ProviderRoutingGroupResource(existingGroup), auth.ActionDelete); httpErr != nil {
+		http.Error(rw, httpErr.Message, httpErr.StatusCode)
+		return
+	}
+
+	if err := apiServer.Store.DeleteProviderRoutingGroup(ctx, groupID); err != nil {
+		log.Err(err).Msg("error deleting provider routing group")
+		http.Error(rw, "Error deleting provider routing group: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+// validateProviderRoutingGroup checks the group and defaults its strategy to
+// priority
+func validateProviderRoutingGroup(group *types.ProviderRoutingGroup) error {
+	if group.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	switch group.Strategy {
+	case "":
+		group.Strategy = types.ProviderRoutingStrategyPriority
+	case types.ProviderRoutingStrategyPriority,
+		types.ProviderRoutingStrategyWeightedRoundRobin,
+		types.ProviderRoutingStrategyLeastLatency:
+	default:
+		return fmt.Errorf("unknown routing strategy '%s'", group.Strategy)
+	}
+
+	if len(group.Members) == 0 {
+		return fmt.Errorf("at least one provider is required")
+	}
+
+	for _, member := range group.Members {
+		if member.Provider == "" {
+			return fmt.Errorf("provider is required for every member")
+		}
+		if member.Weight < 0 {
+			return fmt.Errorf("weight of provider '%s' can't be negative", member.Provider)
+		}
+	}
+
+	return nil
+}
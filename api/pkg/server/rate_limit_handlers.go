@@ -0,0 +1,214 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/helixml/helix/api/pkg/ratelimit"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// setRateLimitHeaders sets the OpenAI x-ratelimit headers of the limits the
+// request is subject to
+func setRateLimitHeaders(rw http.ResponseWriter, status *ratelimit.Status) {
+	if status == nil {
+		return
+	}
+
+	if status.LimitRequests > 0 {
+		rw.Header().Set("x-ratelimit-limit-requests", strconv.FormatInt(status.LimitRequests, 10))
+		rw.Header().Set("x-ratelimit-remaining-requests", strconv.FormatInt(status.RemainingRequests, 10))
+		rw.Header().Set("x-ratelimit-reset-requests", status.ResetRequests.Round(time.Millisecond).String())
+	}
+
+	if status.LimitTokens > 0 {
+		rw.Header().Set("x-ratelimit-limit-tokens", strconv.FormatInt(status.LimitTokens, 10))
+		rw.Header().Set("x-ratelimit-remaining-tokens", strconv.FormatInt(status.RemainingTokens, 10))
+		rw.Header().Set("x-ratelimit-reset-tokens", status.ResetTokens.Round(time.Millisecond).String())
+	}
+}
+
+// writeRateLimitError writes an OpenAI style 429 response when the error is a
+// rate limit error, it returns false for other errors
+func writeRateLimitError(rw http.ResponseWriter, err error) bool {
+	var exceeded *ratelimit.ExceededError
+	if !errors.As(err, &exceeded) {
+		return false
+	}
+
+	setRateLimitHeaders(rw, exceeded.Status)
+
+	errorType := "requests"
+	if exceeded.Tokens {
+		errorType = "tokens"
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(exceeded.RetryAfter.Seconds()))))
+	rw.WriteHeader(http.StatusTooManyRequests)
+
+	err = json.NewEncoder(rw).Encode(&openai.ErrorResponse{
+		Error: &openai.APIError{
+			Code:    "rate_limit_exceeded",
+			Message: exceeded.Error(),
+			Type:    errorType,
+		},
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("error writing rate limit response")
+	}
+
+	return true
+}
+
+// listRateLimits godoc
+// @Summary List rate limits
+// @Description List the rate limits set for users, API keys, apps and providers. Admin only.
+// @Tags    rate-limits
+// @Success 200 {array} types.RateLimit
+// @Param scope query string false "Only list the limits of this scope"
+// @Router /api/v1/rate-limits [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) listRateLimits(_ http.ResponseWriter, r *http.Request) ([]*types.RateLimit, *system.HTTPError) {
+	limits, err := s.Store.ListRateLimits(r.Context(), &store.ListRateLimitsQuery{
+		Scope: types.RateLimitScope(r.URL.Query().Get("scope")),
+	})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return limits, nil
+}
+
+// createRateLimit godoc
+// @Summary Create a rate limit
+// @Description Limit the requests per minute and tokens per day and month of a user, API key, app or provider. Admin only.
+// @Tags    rate-limits
+// @Success 200 {object} types.RateLimit
+// @Param request    body types.RateLimit true "Request body with the rate limit"
+// @Router /api/v1/rate-limits [post]
+// @Security BearerAuth
+func (s *HelixAPIServer) createRateLimit(_ http.ResponseWriter, r *http.Request) (*types.RateLimit, *system.HTTPError) {
+	var limit types.RateLimit
+	if err := json.NewDecoder(r.Body).Decode(&limit); err != nil {
+		return nil, system.NewHTTPError400("invalid request body: " + err.Error())
+	}
+
+	if err := validateRateLimit(&limit); err != nil {
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	// Only the hash of API keys is stored
+	if limit.Scope == types.RateLimitScopeAPIKey {
+		limit.ScopeID = ratelimit.APIKeyScopeID(limit.ScopeID)
+	}
+
+	_, err := s.Store.GetRateLimit(r.Context(), &store.GetRateLimitQuery{Scope: limit.Scope, ScopeID: limit.ScopeID})
+	if err == nil {
+		return nil, system.NewHTTPError400(fmt.Sprintf("rate limit for %s %s already exists", limit.Scope, limit.ScopeID))
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	limit.ID = ""
+	created, err := s.Store.CreateRateLimit(r.Context(), &limit)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return created, nil
+}
+
+// updateRateLimit godoc
+// @Summary Update a rate limit
+// @Description Update the limits of a rate limit. Admin only.
+// @Tags    rate-limits
+// @Success 200 {object} types.RateLimit
+// @Param request    body types.RateLimit true "Request body with the rate limit"
+// @Param id path string true "Rate limit ID"
+// @Router /api/v1/rate-limits/{id} [put]
+// @Security BearerAuth
+func (s *HelixAPIServer) updateRateLimit(_ http.ResponseWriter, r *http.Request) (*types.RateLimit, *system.HTTPError) {
+	existing, err := s.Store.GetRateLimit(r.Context(), &store.GetRateLimitQuery{ID: mux.Vars(r)["id"]})
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, system.NewHTTPError404("rate limit not found")
+		}
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	var limit types.RateLimit
+	if err := json.NewDecoder(r.Body).Decode(&limit); err != nil {
+		return nil, system.NewHTTPError400("invalid request body: " + err.Error())
+	}
+
+	// The scope can't be changed
+	existing.RequestsPerMinute = limit.RequestsPerMinute
+	existing.TokensPerDay = limit.TokensPerDay
+	existing.TokensPerMonth = limit.TokensPerMonth
+
+	if err := validateRateLimit(existing); err != nil {
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	updated, err := s.Store.UpdateRateLimit(r.Context(), existing)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return updated, nil
+}
+
+// deleteRateLimit godoc
+// @Summary Delete a rate limit
+// @Description Delete a rate limit, the defaults apply again to users and API keys. Admin only.
+// @Tags    rate-limits
+// @Success 200 {object} types.RateLimit
+// @Param id path string true "Rate limit ID"
+// @Router /api/v1/rate-limits/{id} [delete]
+// @Security BearerAuth
+func (s *HelixAPIServer) deleteRateLimit(_ http.ResponseWriter, r *http.Request) (*types.RateLimit, *system.HTTPError) {
+	existing, err := s.Store.GetRateLimit(r.Context(), &store.GetRateLimitQuery{ID: mux.Vars(r)["id"]})
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, system.NewHTTPError404("rate limit not found")
+		}
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	if err := s.Store.DeleteRateLimit(r.Context(), existing.ID); err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return existing, nil
+}
+
+func validateRateLimit(limit *types.RateLimit) error {
+	switch limit.Scope {
+	case types.RateLimitScopeUser, types.RateLimitScopeAPIKey, types.RateLimitScopeApp, types.RateLimitScopeProvider:
+	default:
+		return fmt.Errorf("unknown scope '%s'", limit.Scope)
+	}
+
+	if limit.ScopeID == "" {
+		return fmt.Errorf("scope_id is required")
+	}
+
+	if limit.RequestsPerMinute < 0 || limit.TokensPerDay < 0 || limit.TokensPerMonth < 0 {
+		return fmt.Errorf("limits can't be negative")
+	}
+
+	return nil
+}
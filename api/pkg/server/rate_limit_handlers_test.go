@@ -0,0 +1,52 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+
+	"github.com/helixml/helix/api/pkg/ratelimit"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func Test_writeRateLimitError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	require.False(t, writeRateLimitError(rec, errors.New("other error")))
+
+	err := &ratelimit.ExceededError{
+		Subject:    ratelimit.Subject{Scope: types.RateLimitScopeUser, ID: "user"},
+		Limit:      1000,
+		Period:     "day",
+		Tokens:     true,
+		RetryAfter: 90*time.Minute + 500*time.Millisecond,
+		Status: &ratelimit.Status{
+			LimitRequests:     60,
+			RemainingRequests: 59,
+			ResetRequests:     time.Second,
+			LimitTokens:       1000,
+			ResetTokens:       90*time.Minute + 500*time.Millisecond,
+		},
+	}
+
+	require.True(t, writeRateLimitError(rec, err))
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "5401", rec.Header().Get("Retry-After"))
+	require.Equal(t, "60", rec.Header().Get("x-ratelimit-limit-requests"))
+	require.Equal(t, "59", rec.Header().Get("x-ratelimit-remaining-requests"))
+	require.Equal(t, "1s", rec.Header().Get("x-ratelimit-reset-requests"))
+	require.Equal(t, "1000", rec.Header().Get("x-ratelimit-limit-tokens"))
+	require.Equal(t, "0", rec.Header().Get("x-ratelimit-remaining-tokens"))
+	require.Equal(t, "1h30m0.5s", rec.Header().Get("x-ratelimit-reset-tokens"))
+
+	var resp openai.ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, "tokens", resp.Error.Type)
+	require.Equal(t, "rate_limit_exceeded", resp.Error.Code)
+	require.Contains(t, resp.Error.Message, "1000 tokens per day")
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/helixml/helix/api/pkg/auth"
 	"github.com/helixml/helix/api/pkg/store"
 	"github.com/helixml/helix/api/pkg/system"
 	"github.com/helixml/helix/api/pkg/types"
@@ -25,8 +26,9 @@ func (s *HelixAPIServer) listSecrets(_ http.ResponseWriter, r *http.Request) ([]
 	}
 
 	query := &store.ListSecretsQuery{
-		Owner:     user.ID,
-		OwnerType: types.OwnerTypeUser,
+		Owner:             user.ID,
+		OwnerType:         types.OwnerTypeUser,
+		WithOrganizations: true,
 	}
 
 	secrets, err := s.Store.ListSecrets(ctx, query)
@@ -63,12 +65,17 @@ func (s *HelixAPIServer) createSecret(_ http.ResponseWriter, r *http.Request) (*
 		return nil, system.NewHTTPError400(err.Error())
 	}
 
+	owner, ownerType, httpErr := s.resolveResourceOwner(ctx, user, secretReq.Owner, secretReq.OwnerType)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
 	secret := &types.Secret{
 		Name:  secretReq.Name,
 		Value: []byte(secretReq.Value),
 	}
-	secret.Owner = user.ID
-	secret.OwnerType = types.OwnerTypeUser
+	secret.Owner = owner
+	secret.OwnerType = ownerType
 
 	createdSecret, err := s.Store.CreateSecret(ctx, secret)
 	if err != nil {
@@ -104,9 +111,21 @@ func (s *HelixAPIServer) updateSecret(_ http.ResponseWriter, r *http.Request) (*
 		return nil, system.NewHTTPError400(err.Error())
 	}
 
+	existing, err := s.Store.GetSecret(ctx, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, system.NewHTTPError404("Secret not found")
+		}
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	if httpErr := s.authorizeResource(ctx, user, auth.SecretResource(existing), auth.ActionUpdate); httpErr != nil {
+		return nil, httpErr
+	}
+
 	secret.ID = id
-	secret.Owner = user.ID
-	secret.OwnerType = types.OwnerTypeUser
+	secret.Owner = existing.Owner
+	secret.OwnerType = existing.OwnerType
 
 	updatedSecret, err := s.Store.UpdateSecret(ctx, &secret)
 	if err != nil {
@@ -147,8 +166,8 @@ func (s *HelixAPIServer) deleteSecret(_ http.ResponseWriter, r *http.Request) (*
 		return nil, system.NewHTTPError500(err.Error())
 	}
 
-	if existing.Owner != user.ID {
-		return nil, system.NewHTTPError403("Secret not found")
+	if httpErr := s.authorizeResource(ctx, user, auth.SecretResource(existing), auth.ActionDelete); httpErr != nil {
+		return nil, httpErr
 	}
 
 	err = s.Store.DeleteSecret(ctx, id)
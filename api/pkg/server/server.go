@@ -21,6 +21,7 @@ import (
 	"github.com/helixml/helix/api/pkg/controller/knowledge"
 	"github.com/helixml/helix/api/pkg/gptscript"
 	"github.com/helixml/helix/api/pkg/janitor"
+	"github.com/helixml/helix/api/pkg/mcpserver"
 	"github.com/helixml/helix/api/pkg/openai"
 	"github.com/helixml/helix/api/pkg/openai/manager"
 	"github.com/helixml/helix/api/pkg/pubsub"
@@ -29,6 +30,7 @@ import (
 	"github.com/helixml/helix/api/pkg/store"
 	"github.com/helixml/helix/api/pkg/stripe"
 	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
 	"github.com/helixml/helix/api/pkg/version"
 
 	"crypto/tls"
@@ -68,6 +70,7 @@ type HelixAPIServer struct {
 	Controller        *controller.Controller
 	Janitor           *janitor.Janitor
 	authMiddleware    *authMiddleware
+	authorizer        auth.Authorizer
 	pubsub            pubsub.PubSub
 	providerManager   manager.ProviderManager
 	gptScriptExecutor gptscript.Executor
@@ -76,6 +79,7 @@ type HelixAPIServer struct {
 	router            *mux.Router
 	scheduler         *scheduler.Scheduler
 	pingService       *version.PingService
+	mcpSSEServer      *mcpserver.SSEServer
 }
 
 type AuthConfig struct {
@@ -129,11 +133,13 @@ func NewServer(
 			authConfig.ApiKeyAuth,
 			store,
 		),
+		authorizer:       auth.NewAuthorizer(store),
 		providerManager:  providerManager,
 		pubsub:           ps,
 		knowledgeManager: knowledgeManager,
 		scheduler:        scheduler,
 		pingService:      pingService,
+		mcpSSEServer:     mcpserver.NewSSEServer(mcpMessagePath),
 	}, nil
 }
 
@@ -302,6 +308,21 @@ func (apiServer *HelixAPIServer) registerRoutes(_ context.Context) (*mux.Router,
 	authRouter.HandleFunc("/provider-endpoints", apiServer.createProviderEndpoint).Methods(http.MethodPost)
 	authRouter.HandleFunc("/provider-endpoints/{id}", apiServer.updateProviderEndpoint).Methods(http.MethodPut)
 	authRouter.HandleFunc("/provider-endpoints/{id}", apiServer.deleteProviderEndpoint).Methods(http.MethodDelete)
+	authRouter.HandleFunc("/provider-routing-groups", apiServer.listProviderRoutingGroups).Methods(http.MethodGet)
+	authRouter.HandleFunc("/provider-routing-groups", apiServer.createProviderRoutingGroup).Methods(http.MethodPost)
+	authRouter.HandleFunc("/provider-routing-groups/{id}", apiServer.updateProviderRoutingGroup).Methods(http.MethodPut)
+	authRouter.HandleFunc("/provider-routing-groups/{id}", apiServer.deleteProviderRoutingGroup).Methods(http.MethodDelete)
+
+	authRouter.HandleFunc("/usage", apiServer.getUsage).Methods(http.MethodGet)
+
+	authRouter.HandleFunc("/tool-approvals", system.Wrapper(apiServer.listToolApprovals)).Methods(http.MethodGet)
+	authRouter.HandleFunc("/tool-approvals/{id}", system.Wrapper(apiServer.getToolApproval)).Methods(http.MethodGet)
+	authRouter.HandleFunc("/tool-approvals/{id}/decision", system.Wrapper(apiServer.decideToolApproval)).Methods(http.MethodPost)
+
+	// Model Context Protocol server for the apps
+	authRouter.HandleFunc("/mcp", apiServer.mcpHTTP).Methods(http.MethodPost)
+	authRouter.HandleFunc("/mcp/sse", apiServer.mcpSSE).Methods(http.MethodGet)
+	authRouter.HandleFunc("/mcp/message", apiServer.mcpMessage).Methods(http.MethodPost)
 
 	// Helix inference route
 	authRouter.HandleFunc("/sessions/chat", apiServer.startChatSessionHandler).Methods(http.MethodPost)
@@ -320,6 +341,9 @@ func (apiServer *HelixAPIServer) registerRoutes(_ context.Context) (*mux.Router,
 	authRouter.HandleFunc("/sessions/{id}/config", system.Wrapper(apiServer.updateSessionConfig)).Methods(http.MethodPut)
 
 	authRouter.HandleFunc("/sessions/{id}/meta", system.Wrapper(apiServer.updateSessionMeta)).Methods(http.MethodPut)
+	authRouter.HandleFunc("/sessions/{id}/access", system.Wrapper(apiServer.listAccessGrants(types.ResourceTypeSession))).Methods(http.MethodGet)
+	authRouter.HandleFunc("/sessions/{id}/access", system.Wrapper(apiServer.createAccessGrant(types.ResourceTypeSession))).Methods(http.MethodPost)
+	authRouter.HandleFunc("/sessions/{id}/access/{grant_id}", system.Wrapper(apiServer.deleteAccessGrant(types.ResourceTypeSession))).Methods(http.MethodDelete)
 	authRouter.HandleFunc("/sessions/{id}/finetune/start", system.Wrapper(apiServer.startSessionFinetune)).Methods(http.MethodPost)
 	authRouter.HandleFunc("/sessions/{id}/finetune/documents", system.Wrapper(apiServer.finetuneAddDocuments)).Methods(http.MethodPut)
 	authRouter.HandleFunc("/sessions/{id}/finetune/clone/{interaction}/{mode}", system.Wrapper(apiServer.cloneFinetuneInteraction)).Methods(http.MethodPost)
@@ -340,6 +364,26 @@ func (apiServer *HelixAPIServer) registerRoutes(_ context.Context) (*mux.Router,
 	authRouter.HandleFunc("/apps/{id}", system.Wrapper(apiServer.deleteApp)).Methods(http.MethodDelete)
 	authRouter.HandleFunc("/apps/{id}/llm-calls", system.Wrapper(apiServer.listAppLLMCalls)).Methods(http.MethodGet)
 	authRouter.HandleFunc("/apps/{id}/api-actions", system.Wrapper(apiServer.appRunAPIAction)).Methods(http.MethodPost)
+	authRouter.HandleFunc("/apps/{id}/access", system.Wrapper(apiServer.listAccessGrants(types.ResourceTypeApp))).Methods(http.MethodGet)
+	authRouter.HandleFunc("/apps/{id}/access", system.Wrapper(apiServer.createAccessGrant(types.ResourceTypeApp))).Methods(http.MethodPost)
+	authRouter.HandleFunc("/apps/{id}/access/{grant_id}", system.Wrapper(apiServer.deleteAccessGrant(types.ResourceTypeApp))).Methods(http.MethodDelete)
+
+	authRouter.HandleFunc("/organizations", system.Wrapper(apiServer.listOrganizations)).Methods(http.MethodGet)
+	authRouter.HandleFunc("/organizations", system.Wrapper(apiServer.createOrganization)).Methods(http.MethodPost)
+	authRouter.HandleFunc("/organizations/{id}", system.Wrapper(apiServer.getOrganization)).Methods(http.MethodGet)
+	authRouter.HandleFunc("/organizations/{id}", system.Wrapper(apiServer.updateOrganization)).Methods(http.MethodPut)
+	authRouter.HandleFunc("/organizations/{id}", system.Wrapper(apiServer.deleteOrganization)).Methods(http.MethodDelete)
+	authRouter.HandleFunc("/organizations/{id}/members", system.Wrapper(apiServer.listOrganizationMembers)).Methods(http.MethodGet)
+	authRouter.HandleFunc("/organizations/{id}/members", system.Wrapper(apiServer.addOrganizationMember)).Methods(http.MethodPost)
+	authRouter.HandleFunc("/organizations/{id}/members/{user_id}", system.Wrapper(apiServer.updateOrganizationMember)).Methods(http.MethodPut)
+	authRouter.HandleFunc("/organizations/{id}/members/{user_id}", system.Wrapper(apiServer.removeOrganizationMember)).Methods(http.MethodDelete)
+	authRouter.HandleFunc("/organizations/{id}/teams", system.Wrapper(apiServer.listTeams)).Methods(http.MethodGet)
+	authRouter.HandleFunc("/organizations/{id}/teams", system.Wrapper(apiServer.createTeam)).Methods(http.MethodPost)
+	authRouter.HandleFunc("/organizations/{id}/teams/{team_id}", system.Wrapper(apiServer.updateTeam)).Methods(http.MethodPut)
+	authRouter.HandleFunc("/organizations/{id}/teams/{team_id}", system.Wrapper(apiServer.deleteTeam)).Methods(http.MethodDelete)
+	authRouter.HandleFunc("/organizations/{id}/teams/{team_id}/members", system.Wrapper(apiServer.listTeamMembers)).Methods(http.MethodGet)
+	authRouter.HandleFunc("/organizations/{id}/teams/{team_id}/members", system.Wrapper(apiServer.addTeamMember)).Methods(http.MethodPost)
+	authRouter.HandleFunc("/organizations/{id}/teams/{team_id}/members/{user_id}", system.Wrapper(apiServer.removeTeamMember)).Methods(http.MethodDelete)
 
 	authRouter.HandleFunc("/search", system.Wrapper(apiServer.knowledgeSearch)).Methods(http.MethodGet)
 
@@ -348,6 +392,9 @@ func (apiServer *HelixAPIServer) registerRoutes(_ context.Context) (*mux.Router,
 	authRouter.HandleFunc("/knowledge/{id}", system.Wrapper(apiServer.deleteKnowledge)).Methods(http.MethodDelete)
 	authRouter.HandleFunc("/knowledge/{id}/refresh", system.Wrapper(apiServer.refreshKnowledge)).Methods(http.MethodPost)
 	authRouter.HandleFunc("/knowledge/{id}/versions", system.Wrapper(apiServer.listKnowledgeVersions)).Methods(http.MethodGet)
+	authRouter.HandleFunc("/knowledge/{id}/access", system.Wrapper(apiServer.listAccessGrants(types.ResourceTypeKnowledge))).Methods(http.MethodGet)
+	authRouter.HandleFunc("/knowledge/{id}/access", system.Wrapper(apiServer.createAccessGrant(types.ResourceTypeKnowledge))).Methods(http.MethodPost)
+	authRouter.HandleFunc("/knowledge/{id}/access/{grant_id}", system.Wrapper(apiServer.deleteAccessGrant(types.ResourceTypeKnowledge))).Methods(http.MethodDelete)
 
 	// we know which app this is by the token that is used (which is linked to the app)
 	// this is so frontend devs don't need anything other than their access token
@@ -356,6 +403,14 @@ func (apiServer *HelixAPIServer) registerRoutes(_ context.Context) (*mux.Router,
 	authRouter.HandleFunc("/apps/script", system.Wrapper(apiServer.appRunScript)).Methods(http.MethodPost, http.MethodOptions)
 	adminRouter.HandleFunc("/dashboard", system.DefaultWrapper(apiServer.dashboard)).Methods(http.MethodGet)
 	adminRouter.HandleFunc("/llm_calls", system.Wrapper(apiServer.listLLMCalls)).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/rate-limits", system.Wrapper(apiServer.listRateLimits)).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/rate-limits", system.Wrapper(apiServer.createRateLimit)).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/rate-limits/{id}", system.Wrapper(apiServer.updateRateLimit)).Methods(http.MethodPut)
+	adminRouter.HandleFunc("/rate-limits/{id}", system.Wrapper(apiServer.deleteRateLimit)).Methods(http.MethodDelete)
+	adminRouter.HandleFunc("/model-prices", system.Wrapper(apiServer.listModelPrices)).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/model-prices", system.Wrapper(apiServer.createModelPrice)).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/model-prices/{id}", system.Wrapper(apiServer.updateModelPrice)).Methods(http.MethodPut)
+	adminRouter.HandleFunc("/model-prices/{id}", system.Wrapper(apiServer.deleteModelPrice)).Methods(http.MethodDelete)
 
 	// all these routes are secured via runner tokens
 	insecureRouter.HandleFunc("/runner/{runner_id}/ws", func(w http.ResponseWriter, r *http.Request) {
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/helixml/helix/api/pkg/auth"
 	"github.com/helixml/helix/api/pkg/controller"
 	"github.com/helixml/helix/api/pkg/data"
 	"github.com/helixml/helix/api/pkg/model"
@@ -62,6 +63,7 @@ func (s *HelixAPIServer) startChatSessionHandler(rw http.ResponseWriter, req *ht
 	}
 
 	ctx = oai.SetContextAppID(ctx, startReq.AppID)
+	ctx = oai.SetContextPriorityClass(ctx, types.WorkloadPriorityClassInteractive)
 
 	if ragSourceID := req.URL.Query().Get("rag_source_id"); ragSourceID != "" {
 		startReq.RAGSourceID = ragSourceID
@@ -148,8 +150,8 @@ func (s *HelixAPIServer) startChatSessionHandler(rw http.ResponseWriter, req *ht
 			return
 		}
 
-		if session.Owner != user.ID {
-			http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		if httpErr := s.authorizeResource(ctx, user, auth.SessionResource(session), auth.ActionUpdate); httpErr != nil {
+			http.Error(rw, httpErr.Message, httpErr.StatusCode)
 			return
 		}
 		// If the session has an AppID, use it as the next interaction
@@ -357,6 +359,7 @@ func (s *HelixAPIServer) restartChatSessionHandler(rw http.ResponseWriter, req *
 	}
 
 	ctx = oai.SetContextAppID(ctx, session.ParentApp)
+	ctx = oai.SetContextPriorityClass(ctx, types.WorkloadPriorityClassInteractive)
 
 	ownerID := user.ID
 	if user.TokenType == types.TokenTypeRunner {
@@ -419,6 +422,8 @@ func (s *HelixAPIServer) generateSessionName(user *types.User, sessionID, model,
 		InteractionID: "n/a",
 	})
 
+	ctx = oai.SetContextPriorityClass(ctx, types.WorkloadPriorityClassInteractive)
+
 	ctx = oai.SetStep(ctx, &oai.Step{
 		Step: types.LLMCallStepGenerateTitle,
 	})
@@ -486,6 +491,7 @@ func (s *HelixAPIServer) handleBlockingSession(
 
 	// Update the session with the response
 	session.Interactions[len(session.Interactions)-1].Message = chatCompletionResponse.Choices[0].Message.Content
+	session.Interactions[len(session.Interactions)-1].Citations = options.Citations
 	session.Interactions[len(session.Interactions)-1].Completed = time.Now()
 	session.Interactions[len(session.Interactions)-1].State = types.InteractionStateComplete
 	session.Interactions[len(session.Interactions)-1].Finished = true
@@ -499,7 +505,10 @@ func (s *HelixAPIServer) handleBlockingSession(
 
 	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(rw).Encode(chatCompletionResponse)
+	err = json.NewEncoder(rw).Encode(&types.ChatCompletionResponse{
+		ChatCompletionResponse: *chatCompletionResponse,
+		Citations:              options.Citations,
+	})
 	if err != nil {
 		log.Err(err).Msg("error writing response")
 	}
@@ -579,8 +588,32 @@ func (s *HelixAPIServer) handleStreamingSession(ctx context.Context, user *types
 		}
 	}
 
+	// Send the citations in the last chunk, once the whole answer has been written.
+	// The chunk has an empty list of choices, like the usage chunk of OpenAI, so
+	// the clients that read the choices of every chunk can parse it
+	if len(options.Citations) > 0 {
+		bts, err := json.Marshal(&types.ChatCompletionStreamResponse{
+			ChatCompletionStreamResponse: openai.ChatCompletionStreamResponse{
+				Object:  "chat.completion.chunk",
+				ID:      session.ID,
+				Model:   chatCompletionRequest.Model,
+				Choices: []openai.ChatCompletionStreamChoice{},
+			},
+			Citations: options.Citations,
+		})
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return err
+		}
+
+		if err := writeChunk(rw, bts); err != nil {
+			log.Error().Err(err).Msg("failed to write chunk")
+		}
+	}
+
 	// Update last interaction
 	session.Interactions[len(session.Interactions)-1].Message = fullResponse
+	session.Interactions[len(session.Interactions)-1].Citations = options.Citations
 	session.Interactions[len(session.Interactions)-1].Completed = time.Now()
 	session.Interactions[len(session.Interactions)-1].State = types.InteractionStateComplete
 	session.Interactions[len(session.Interactions)-1].Finished = true
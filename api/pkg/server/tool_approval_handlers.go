@@ -0,0 +1,91 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/helixml/helix/api/pkg/controller"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// listToolApprovals godoc
+// @Summary List tool approvals
+// @Description List the tool actions of the user's sessions and of the tools they approve, pending and decided.
+// @Tags    tool-approvals
+// @Success 200 {array} types.ToolApproval
+// @Param session_id query string false "Only list the approvals of this session"
+// @Param status     query string false "Only list the approvals with this status (pending, approved, denied, expired)"
+// @Router /api/v1/tool-approvals [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) listToolApprovals(_ http.ResponseWriter, r *http.Request) ([]*types.ToolApproval, *system.HTTPError) {
+	user := getRequestUser(r)
+
+	approvals, err := s.Store.ListToolApprovals(r.Context(), &store.ListToolApprovalsQuery{
+		User:      user.ID,
+		SessionID: r.URL.Query().Get("session_id"),
+		Status:    types.ToolApprovalStatus(r.URL.Query().Get("status")),
+	})
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return approvals, nil
+}
+
+// getToolApproval godoc
+// @Summary Get a tool approval
+// @Description Get a tool action waiting for approval or decided.
+// @Tags    tool-approvals
+// @Success 200 {object} types.ToolApproval
+// @Param id path string true "Tool approval ID"
+// @Router /api/v1/tool-approvals/{id} [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) getToolApproval(_ http.ResponseWriter, r *http.Request) (*types.ToolApproval, *system.HTTPError) {
+	approval, err := s.Controller.GetToolApproval(r.Context(), getRequestUser(r), mux.Vars(r)["id"])
+	if err != nil {
+		return nil, toolApprovalHTTPError(err)
+	}
+
+	return approval, nil
+}
+
+// decideToolApproval godoc
+// @Summary Approve or deny a tool action
+// @Description Approve or deny a tool action waiting for approval. Approved actions run and the session resumes, denied ones complete without running.
+// @Tags    tool-approvals
+// @Success 200 {object} types.ToolApproval
+// @Param id      path string                     true "Tool approval ID"
+// @Param request body types.ToolApprovalDecision true "Decision"
+// @Router /api/v1/tool-approvals/{id}/decision [post]
+// @Security BearerAuth
+func (s *HelixAPIServer) decideToolApproval(_ http.ResponseWriter, r *http.Request) (*types.ToolApproval, *system.HTTPError) {
+	var decision types.ToolApprovalDecision
+	if err := json.NewDecoder(r.Body).Decode(&decision); err != nil {
+		return nil, system.NewHTTPError400("invalid request body: " + err.Error())
+	}
+
+	approval, err := s.Controller.DecideToolApproval(r.Context(), getRequestUser(r), mux.Vars(r)["id"], &decision)
+	if err != nil {
+		return nil, toolApprovalHTTPError(err)
+	}
+
+	return approval, nil
+}
+
+func toolApprovalHTTPError(err error) *system.HTTPError {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return system.NewHTTPError404("tool approval not found")
+	case errors.Is(err, controller.ErrToolApprovalForbidden):
+		return system.NewHTTPError403(err.Error())
+	case errors.Is(err, controller.ErrToolApprovalDecided), errors.Is(err, controller.ErrToolApprovalExpired):
+		return system.NewHTTPError400(err.Error())
+	default:
+		return system.NewHTTPError500(err.Error())
+	}
+}
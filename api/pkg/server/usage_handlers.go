@@ -0,0 +1,275 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+	"github.com/helixml/helix/api/pkg/usage"
+)
+
+// getUsage godoc
+// @Summary Get usage and cost
+// @Description Get the tokens and estimated cost of the LLM calls, grouped by user, app, model, provider and day. Users only see their own usage, admins see everyone's.
+// @Tags    usage
+// @Success 200 {object} types.UsageReport
+// @Param from query string false "Start date (2006-01-02) or time (RFC3339), defaults to the start of the month"
+// @Param to query string false "End date, inclusive, or time, defaults to now"
+// @Param group_by query string false "Comma separated dimensions: user, app, model, provider, day. Defaults to day"
+// @Param user_id query string false "Filter by user, admin only"
+// @Param app_id query string false "Filter by app"
+// @Param model query string false "Filter by model"
+// @Param provider query string false "Filter by provider"
+// @Param format query string false "json or csv"
+// @Router /api/v1/usage [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) getUsage(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := getRequestUser(r)
+	query := r.URL.Query()
+
+	from, to, err := parseUsageRange(query.Get("from"), query.Get("to"), time.Now())
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	groupByParam := query.Get("group_by")
+	if groupByParam == "" {
+		groupByParam = string(types.UsageGroupByDay)
+	}
+	groupBy, err := usage.ParseGroupBy(groupByParam)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID := query.Get("user_id")
+	if !user.Admin {
+		if userID != "" && userID != user.ID {
+			http.Error(rw, "Only admins can get the usage of other users", http.StatusForbidden)
+			return
+		}
+		userID = user.ID
+	}
+
+	rows, err := s.Store.ListLLMCallUsage(ctx, &store.ListLLMCallUsageQuery{
+		From:     from,
+		To:       to,
+		UserID:   userID,
+		AppID:    query.Get("app_id"),
+		Provider: query.Get("provider"),
+		Model:    query.Get("model"),
+	})
+	if err != nil {
+		log.Err(err).Msg("error listing LLM call usage")
+		http.Error(rw, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	prices, err := s.Store.ListModelPrices(ctx)
+	if err != nil {
+		log.Err(err).Msg("error listing model prices")
+		http.Error(rw, "Internal server error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	report := usage.NewReport(from, to, groupBy, rows, usage.NewPrices(prices))
+
+	switch query.Get("format") {
+	case "csv":
+		rw.Header().Set("Content-Type", "text/csv")
+		rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"usage-%s-%s.csv\"", from.Format(time.DateOnly), to.Format(time.DateOnly)))
+		if err := usage.WriteCSV(rw, report); err != nil {
+			log.Err(err).Msg("error writing usage CSV")
+		}
+	case "", "json":
+		rw.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(rw).Encode(report); err != nil {
+			log.Err(err).Msg("error writing response")
+		}
+	default:
+		http.Error(rw, "format must be json or csv", http.StatusBadRequest)
+	}
+}
+
+// parseUsageRange parses the range of a usage report, dates without a time
+// are whole UTC days so the end date is included
+func parseUsageRange(fromParam, toParam string, now time.Time) (time.Time, time.Time, error) {
+	now = now.UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := now
+
+	if fromParam != "" {
+		t, _, err := parseUsageTime(fromParam)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %w", err)
+		}
+		from = t
+	}
+
+	if toParam != "" {
+		t, dateOnly, err := parseUsageTime(toParam)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %w", err)
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		to = t
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must be before to")
+	}
+
+	return from, to, nil
+}
+
+func parseUsageTime(s string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("'%s' is neither a date (2006-01-02) nor a time (RFC3339)", s)
+	}
+	return t, false, nil
+}
+
+// listModelPrices godoc
+// @Summary List model prices
+// @Description List the prices used to estimate the cost of the LLM calls. Admin only.
+// @Tags    usage
+// @Success 200 {array} types.ModelPrice
+// @Router /api/v1/model-prices [get]
+// @Security BearerAuth
+func (s *HelixAPIServer) listModelPrices(_ http.ResponseWriter, r *http.Request) ([]*types.ModelPrice, *system.HTTPError) {
+	prices, err := s.Store.ListModelPrices(r.Context())
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+	return prices, nil
+}
+
+// createModelPrice godoc
+// @Summary Create a model price
+// @Description Set the price of a model of a provider in USD per million tokens, use * as the model to price every model of the provider. Admin only.
+// @Tags    usage
+// @Success 200 {object} types.ModelPrice
+// @Param request    body types.ModelPrice true "Request body with the price"
+// @Router /api/v1/model-prices [post]
+// @Security BearerAuth
+func (s *HelixAPIServer) createModelPrice(_ http.ResponseWriter, r *http.Request) (*types.ModelPrice, *system.HTTPError) {
+	var price types.ModelPrice
+	if err := json.NewDecoder(r.Body).Decode(&price); err != nil {
+		return nil, system.NewHTTPError400("invalid request body: " + err.Error())
+	}
+
+	if err := validateModelPrice(&price); err != nil {
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	_, err := s.Store.GetModelPrice(r.Context(), &store.GetModelPriceQuery{Provider: price.Provider, Model: price.Model})
+	if err == nil {
+		return nil, system.NewHTTPError400(fmt.Sprintf("price of model %s of provider %s already exists", price.Model, price.Provider))
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	price.ID = ""
+	created, err := s.Store.CreateModelPrice(r.Context(), &price)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return created, nil
+}
+
+// updateModelPrice godoc
+// @Summary Update a model price
+// @Description Update the prices of a model. Admin only.
+// @Tags    usage
+// @Success 200 {object} types.ModelPrice
+// @Param request    body types.ModelPrice true "Request body with the price"
+// @Param id path string true "Model price ID"
+// @Router /api/v1/model-prices/{id} [put]
+// @Security BearerAuth
+func (s *HelixAPIServer) updateModelPrice(_ http.ResponseWriter, r *http.Request) (*types.ModelPrice, *system.HTTPError) {
+	existing, err := s.Store.GetModelPrice(r.Context(), &store.GetModelPriceQuery{ID: mux.Vars(r)["id"]})
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, system.NewHTTPError404("model price not found")
+		}
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	var price types.ModelPrice
+	if err := json.NewDecoder(r.Body).Decode(&price); err != nil {
+		return nil, system.NewHTTPError400("invalid request body: " + err.Error())
+	}
+
+	// The provider and model can't be changed
+	existing.PromptTokenPrice = price.PromptTokenPrice
+	existing.CompletionTokenPrice = price.CompletionTokenPrice
+
+	if err := validateModelPrice(existing); err != nil {
+		return nil, system.NewHTTPError400(err.Error())
+	}
+
+	updated, err := s.Store.UpdateModelPrice(r.Context(), existing)
+	if err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return updated, nil
+}
+
+// deleteModelPrice godoc
+// @Summary Delete a model price
+// @Description Delete a model price. Admin only.
+// @Tags    usage
+// @Success 200 {object} types.ModelPrice
+// @Param id path string true "Model price ID"
+// @Router /api/v1/model-prices/{id} [delete]
+// @Security BearerAuth
+func (s *HelixAPIServer) deleteModelPrice(_ http.ResponseWriter, r *http.Request) (*types.ModelPrice, *system.HTTPError) {
+	existing, err := s.Store.GetModelPrice(r.Context(), &store.GetModelPriceQuery{ID: mux.Vars(r)["id"]})
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, system.NewHTTPError404("model price not found")
+		}
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	if err := s.Store.DeleteModelPrice(r.Context(), existing.ID); err != nil {
+		return nil, system.NewHTTPError500(err.Error())
+	}
+
+	return existing, nil
+}
+
+func validateModelPrice(price *types.ModelPrice) error {
+	if price.Provider == "" {
+		return fmt.Errorf("provider is required")
+	}
+
+	if price.Model == "" {
+		return fmt.Errorf("model is required, use * for every model of the provider")
+	}
+
+	if price.PromptTokenPrice < 0 || price.CompletionTokenPrice < 0 {
+		return fmt.Errorf("prices can't be negative")
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseUsageRange(t *testing.T) {
+	now := time.Date(2025, 3, 14, 15, 9, 26, 0, time.UTC)
+
+	// Defaults to the month so far
+	from, to, err := parseUsageRange("", "", now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), from)
+	require.Equal(t, now, to)
+
+	// End dates are inclusive
+	from, to, err = parseUsageRange("2025-01-01", "2025-01-31", now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), from)
+	require.Equal(t, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), to)
+
+	from, to, err = parseUsageRange("2025-01-01T10:00:00Z", "2025-01-01T12:00:00Z", now)
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Hour, to.Sub(from))
+
+	_, _, err = parseUsageRange("yesterday", "", now)
+	require.ErrorContains(t, err, "invalid from")
+
+	_, _, err = parseUsageRange("2025-02-01", "2025-01-01", now)
+	require.ErrorContains(t, err, "from must be before to")
+}
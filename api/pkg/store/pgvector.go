@@ -88,6 +88,11 @@ func (s *PGVectorStore) autoMigratePGVector() error {
 	// Note: column cannot have more than 2000 dimensions for
 	// hnsw index hence skipping index creation for 3584
 
+	err = s.createFullTextIndex()
+	if err != nil {
+		return fmt.Errorf("failed to create full text index: %w", err)
+	}
+
 	return nil
 }
 
@@ -105,3 +110,18 @@ func (s *PGVectorStore) createIndex(columnName, _ string) error {
 
 	return nil
 }
+
+// createFullTextIndex creates the index used by the keyword part of the hybrid search
+func (s *PGVectorStore) createFullTextIndex() error {
+	schemaName := "public"
+	if cfg := s.cfg; cfg.Schema != "" {
+		schemaName = cfg.Schema
+	}
+
+	err := s.gdb.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS knowledge_embedding_items_content_fts_index ON %s.knowledge_embedding_items USING gin (to_tsvector('simple', content))", schemaName)).Error
+	if err != nil {
+		return fmt.Errorf("failed to create gin index: %w", err)
+	}
+
+	return nil
+}
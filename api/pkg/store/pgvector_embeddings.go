@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/helixml/helix/api/pkg/types"
+	"github.com/pgvector/pgvector-go"
 	"gorm.io/gorm/clause"
 )
 
@@ -60,6 +61,41 @@ func (s *PGVectorStore) DeleteKnowledgeEmbedding(ctx context.Context, dataEntity
 	return nil
 }
 
+// CopyKnowledgeEmbeddings copies the embeddings of the document groups into another
+// data entity, reusing the stored vectors
+func (s *PGVectorStore) CopyKnowledgeEmbeddings(ctx context.Context, req *types.CopyIndexRequest) error {
+	if req.FromDataEntityID == "" || req.ToDataEntityID == "" {
+		return fmt.Errorf("source and destination data entity IDs are required")
+	}
+
+	if len(req.DocumentGroupIDs) == 0 {
+		return nil
+	}
+
+	err := s.gdb.WithContext(ctx).Exec(`
+INSERT INTO knowledge_embedding_items (
+	created_at, updated_at, data_entity_id, document_group_id, document_id, source,
+	embedding384, embedding512, embedding1024, embedding1536, embedding3584,
+	content, content_offset, embeddings_model
+)
+SELECT
+	NOW(), NOW(), ?, document_group_id, document_id, source,
+	embedding384, embedding512, embedding1024, embedding1536, embedding3584,
+	content, content_offset, embeddings_model
+FROM knowledge_embedding_items
+WHERE data_entity_id = ? AND document_group_id IN ? AND deleted_at IS NULL`,
+		req.ToDataEntityID, req.FromDataEntityID, req.DocumentGroupIDs,
+	).Error
+	if err != nil {
+		return fmt.Errorf("failed to copy embeddings: %w", err)
+	}
+
+	return nil
+}
+
+// QueryKnowledgeEmbeddings returns the closest embeddings to the query vector.
+// If Content is set instead of a vector, a full text search is done and the
+// results are ordered by their rank.
 func (s *PGVectorStore) QueryKnowledgeEmbeddings(ctx context.Context, q *types.KnowledgeEmbeddingQuery) ([]*types.KnowledgeEmbeddingItem, error) {
 	if q.DataEntityID == "" {
 		return nil, fmt.Errorf("data entity ID is required")
@@ -73,27 +109,38 @@ func (s *PGVectorStore) QueryKnowledgeEmbeddings(ctx context.Context, q *types.K
 
 	query := s.gdb.WithContext(ctx).Where("data_entity_id = ?", q.DataEntityID)
 
+	var (
+		column string
+		vector pgvector.Vector
+	)
+
 	switch {
 	case len(q.Embedding384.Slice()) > 0:
-		query = query.Clauses(clause.OrderBy{
-			Expression: clause.Expr{SQL: "embedding384 <-> ?", Vars: []interface{}{q.Embedding384}},
-		})
+		column, vector = "embedding384", q.Embedding384
 	case len(q.Embedding512.Slice()) > 0:
-		query = query.Clauses(clause.OrderBy{
-			Expression: clause.Expr{SQL: "embedding512 <-> ?", Vars: []interface{}{q.Embedding512}},
-		})
+		column, vector = "embedding512", q.Embedding512
 	case len(q.Embedding1024.Slice()) > 0:
-		query = query.Clauses(clause.OrderBy{
-			Expression: clause.Expr{SQL: "embedding1024 <-> ?", Vars: []interface{}{q.Embedding1024}},
-		})
+		column, vector = "embedding1024", q.Embedding1024
 	case len(q.Embedding1536.Slice()) > 0:
-		query = query.Clauses(clause.OrderBy{
-			Expression: clause.Expr{SQL: "embedding1536 <-> ?", Vars: []interface{}{q.Embedding1536}},
-		})
+		column, vector = "embedding1536", q.Embedding1536
 	case len(q.Embedding3584.Slice()) > 0:
-		query = query.Clauses(clause.OrderBy{
-			Expression: clause.Expr{SQL: "embedding3584 <-> ?", Vars: []interface{}{q.Embedding3584}},
-		})
+		column, vector = "embedding3584", q.Embedding3584
+	}
+
+	switch {
+	case column != "":
+		query = query.
+			Select("*, "+column+" <-> ? AS distance", vector).
+			Clauses(clause.OrderBy{
+				Expression: clause.Expr{SQL: column + " <-> ?", Vars: []interface{}{vector}},
+			})
+	case q.Content != "":
+		// 'simple' configuration doesn't stem or drop words so that
+		// product codes and error messages are matched exactly
+		query = query.
+			Select("*, ts_rank_cd(to_tsvector('simple', content), websearch_to_tsquery('simple', ?)) AS rank", q.Content).
+			Where("to_tsvector('simple', content) @@ websearch_to_tsquery('simple', ?)", q.Content).
+			Order("rank DESC")
 	default:
 		// No query, will fetch all
 	}
@@ -58,3 +58,43 @@ func (suite *PGVectorStoreTestSuite) TestCreateKnowledgeEmbedding() {
 	suite.NoError(err)
 	suite.Equal(1, len(items))
 }
+
+func (suite *PGVectorStoreTestSuite) TestCopyKnowledgeEmbeddings() {
+	fromID := system.GenerateUUID()
+	toID := system.GenerateUUID()
+
+	embedding384 := pgvector.NewVector(make([]float32, 384))
+
+	err := suite.db.CreateKnowledgeEmbedding(suite.ctx,
+		&types.KnowledgeEmbeddingItem{
+			DataEntityID:    fromID,
+			DocumentGroupID: "unchanged-group-id",
+			DocumentID:      "unchanged-document-id",
+			Content:         "unchanged",
+			Embedding384:    &embedding384,
+		},
+		&types.KnowledgeEmbeddingItem{
+			DataEntityID:    fromID,
+			DocumentGroupID: "changed-group-id",
+			DocumentID:      "changed-document-id",
+			Content:         "changed",
+			Embedding384:    &embedding384,
+		},
+	)
+	suite.NoError(err)
+
+	err = suite.db.CopyKnowledgeEmbeddings(suite.ctx, &types.CopyIndexRequest{
+		FromDataEntityID: fromID,
+		ToDataEntityID:   toID,
+		DocumentGroupIDs: []string{"unchanged-group-id"},
+	})
+	suite.NoError(err)
+
+	items, err := suite.db.QueryKnowledgeEmbeddings(suite.ctx, &types.KnowledgeEmbeddingQuery{
+		DataEntityID: toID,
+	})
+	suite.NoError(err)
+	suite.Require().Equal(1, len(items))
+	suite.Equal("unchanged-document-id", items[0].DocumentID)
+	suite.Equal("unchanged", items[0].Content)
+}
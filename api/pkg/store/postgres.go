@@ -104,6 +104,18 @@ func (s *PostgresStore) autoMigrate() error {
 		&types.Secret{},
 		&types.LicenseKey{},
 		&types.ProviderEndpoint{},
+		&types.ProviderRoutingGroup{},
+		&types.RateLimit{},
+		&types.TokenUsage{},
+		&types.ModelPrice{},
+		&types.Organization{},
+		&types.OrganizationMembership{},
+		&types.Team{},
+		&types.TeamMembership{},
+		&types.AccessGrant{},
+		&types.SchedulerWorkload{},
+		&types.SchedulerSlot{},
+		&types.ToolApproval{},
 	)
 	if err != nil {
 		return err
@@ -121,6 +133,18 @@ func (s *PostgresStore) autoMigrate() error {
 		log.Err(err).Msg("failed to add DB FK")
 	}
 
+	if err := createFK(s.gdb, types.OrganizationMembership{}, types.Organization{}, "organization_id", "id", "CASCADE", "CASCADE"); err != nil {
+		log.Err(err).Msg("failed to add DB FK")
+	}
+
+	if err := createFK(s.gdb, types.Team{}, types.Organization{}, "organization_id", "id", "CASCADE", "CASCADE"); err != nil {
+		log.Err(err).Msg("failed to add DB FK")
+	}
+
+	if err := createFK(s.gdb, types.TeamMembership{}, types.Team{}, "team_id", "id", "CASCADE", "CASCADE"); err != nil {
+		log.Err(err).Msg("failed to add DB FK")
+	}
+
 	return nil
 }
 
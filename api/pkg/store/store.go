@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/helixml/helix/api/pkg/license"
 	"github.com/helixml/helix/api/pkg/types"
@@ -37,17 +38,32 @@ type ListToolsQuery struct {
 	Owner     string          `json:"owner"`
 	OwnerType types.OwnerType `json:"owner_type"`
 	Global    bool            `json:"global"`
+
+	// WithOrganizations also returns tools owned by the organizations
+	// and teams the owner is a member of
+	WithOrganizations bool `json:"with_organizations"`
 }
 
 type ListSecretsQuery struct {
 	Owner     string          `json:"owner"`
 	OwnerType types.OwnerType `json:"owner_type"`
+
+	// WithOrganizations also returns secrets owned by the organizations
+	// and teams the owner is a member of
+	WithOrganizations bool `json:"with_organizations"`
 }
 
 type ListAppsQuery struct {
 	Owner     string          `json:"owner"`
 	OwnerType types.OwnerType `json:"owner_type"`
 	Global    bool            `json:"global"`
+
+	// WithOrganizations also returns apps owned by the organizations
+	// and teams the owner is a member of
+	WithOrganizations bool `json:"with_organizations"`
+	// WithAccessGrants also returns apps shared with the owner
+	// through access grants
+	WithAccessGrants bool `json:"with_access_grants"`
 }
 
 type ListDataEntitiesQuery struct {
@@ -59,7 +75,8 @@ type ListProviderEndpointsQuery struct {
 	Owner     string
 	OwnerType types.OwnerType
 
-	WithGlobal bool
+	WithGlobal        bool
+	WithOrganizations bool
 }
 
 type GetProviderEndpointsQuery struct {
@@ -69,6 +86,49 @@ type GetProviderEndpointsQuery struct {
 	Name      string
 }
 
+type GetProviderRoutingGroupQuery struct {
+	Owner string
+	ID    string
+	Name  string
+}
+
+type GetRateLimitQuery struct {
+	ID      string
+	Scope   types.RateLimitScope
+	ScopeID string
+}
+
+type ListRateLimitsQuery struct {
+	Scope types.RateLimitScope
+}
+
+type ListToolApprovalsQuery struct {
+	// User returns the approvals of the sessions of the user and the ones
+	// they are an approver of
+	User      string
+	SessionID string
+	Status    types.ToolApprovalStatus
+	// ExpiresBefore returns the approvals expiring before the time
+	ExpiresBefore time.Time
+	// DecidedAfter returns the approvals decided after the time
+	DecidedAfter time.Time
+	// ResumedBefore returns the approvals that weren't resumed since the time
+	ResumedBefore time.Time
+}
+
+type GetTokenUsageQuery struct {
+	Scope       types.RateLimitScope
+	ScopeID     string
+	Period      types.RateLimitPeriod
+	PeriodStart time.Time
+}
+
+type GetModelPriceQuery struct {
+	ID       string
+	Provider string
+	Model    string
+}
+
 //go:generate mockgen -source $GOFILE -destination store_mocks.go -package $GOPACKAGE
 
 type Store interface {
@@ -108,6 +168,27 @@ type Store interface {
 	ListProviderEndpoints(ctx context.Context, q *ListProviderEndpointsQuery) ([]*types.ProviderEndpoint, error)
 	DeleteProviderEndpoint(ctx context.Context, id string) error
 
+	CreateProviderRoutingGroup(ctx context.Context, group *types.ProviderRoutingGroup) (*types.ProviderRoutingGroup, error)
+	UpdateProviderRoutingGroup(ctx context.Context, group *types.ProviderRoutingGroup) (*types.ProviderRoutingGroup, error)
+	GetProviderRoutingGroup(ctx context.Context, q *GetProviderRoutingGroupQuery) (*types.ProviderRoutingGroup, error)
+	ListProviderRoutingGroups(ctx context.Context, q *ListProviderEndpointsQuery) ([]*types.ProviderRoutingGroup, error)
+	DeleteProviderRoutingGroup(ctx context.Context, id string) error
+
+	CreateRateLimit(ctx context.Context, limit *types.RateLimit) (*types.RateLimit, error)
+	UpdateRateLimit(ctx context.Context, limit *types.RateLimit) (*types.RateLimit, error)
+	GetRateLimit(ctx context.Context, q *GetRateLimitQuery) (*types.RateLimit, error)
+	ListRateLimits(ctx context.Context, q *ListRateLimitsQuery) ([]*types.RateLimit, error)
+	DeleteRateLimit(ctx context.Context, id string) error
+
+	IncrementTokenUsage(ctx context.Context, usage *types.TokenUsage) error
+	GetTokenUsage(ctx context.Context, q *GetTokenUsageQuery) (*types.TokenUsage, error)
+
+	CreateToolApproval(ctx context.Context, approval *types.ToolApproval) (*types.ToolApproval, error)
+	UpdateToolApproval(ctx context.Context, approval *types.ToolApproval) (*types.ToolApproval, error)
+	ResumeToolApproval(ctx context.Context, id string, resumedBefore time.Time) (*types.ToolApproval, error)
+	GetToolApproval(ctx context.Context, id string) (*types.ToolApproval, error)
+	ListToolApprovals(ctx context.Context, q *ListToolApprovalsQuery) ([]*types.ToolApproval, error)
+
 	CreateSecret(ctx context.Context, secret *types.Secret) (*types.Secret, error)
 	UpdateSecret(ctx context.Context, secret *types.Secret) (*types.Secret, error)
 	GetSecret(ctx context.Context, id string) (*types.Secret, error)
@@ -150,17 +231,66 @@ type Store interface {
 
 	CreateLLMCall(ctx context.Context, call *types.LLMCall) (*types.LLMCall, error)
 	ListLLMCalls(ctx context.Context, q *ListLLMCallsQuery) ([]*types.LLMCall, int64, error)
+	ListLLMCallUsage(ctx context.Context, q *ListLLMCallUsageQuery) ([]*types.UsageEntry, error)
+
+	CreateModelPrice(ctx context.Context, price *types.ModelPrice) (*types.ModelPrice, error)
+	UpdateModelPrice(ctx context.Context, price *types.ModelPrice) (*types.ModelPrice, error)
+	GetModelPrice(ctx context.Context, q *GetModelPriceQuery) (*types.ModelPrice, error)
+	ListModelPrices(ctx context.Context) ([]*types.ModelPrice, error)
+	DeleteModelPrice(ctx context.Context, id string) error
 
 	GetLicenseKey(ctx context.Context) (*types.LicenseKey, error)
 	SetLicenseKey(ctx context.Context, licenseKey string) error
 
 	GetDecodedLicense(ctx context.Context) (*license.License, error)
+
+	// organizations
+	CreateOrganization(ctx context.Context, org *types.Organization) (*types.Organization, error)
+	UpdateOrganization(ctx context.Context, org *types.Organization) (*types.Organization, error)
+	GetOrganization(ctx context.Context, q *GetOrganizationQuery) (*types.Organization, error)
+	ListOrganizations(ctx context.Context, q *ListOrganizationsQuery) ([]*types.Organization, error)
+	DeleteOrganization(ctx context.Context, id string) error
+
+	CreateOrganizationMembership(ctx context.Context, membership *types.OrganizationMembership) (*types.OrganizationMembership, error)
+	UpdateOrganizationMembership(ctx context.Context, membership *types.OrganizationMembership) (*types.OrganizationMembership, error)
+	GetOrganizationMembership(ctx context.Context, organizationID, userID string) (*types.OrganizationMembership, error)
+	ListOrganizationMemberships(ctx context.Context, q *ListOrganizationMembershipsQuery) ([]*types.OrganizationMembership, error)
+	DeleteOrganizationMembership(ctx context.Context, organizationID, userID string) error
+
+	// teams
+	CreateTeam(ctx context.Context, team *types.Team) (*types.Team, error)
+	UpdateTeam(ctx context.Context, team *types.Team) (*types.Team, error)
+	GetTeam(ctx context.Context, q *GetTeamQuery) (*types.Team, error)
+	ListTeams(ctx context.Context, q *ListTeamsQuery) ([]*types.Team, error)
+	DeleteTeam(ctx context.Context, id string) error
+
+	CreateTeamMembership(ctx context.Context, membership *types.TeamMembership) (*types.TeamMembership, error)
+	GetTeamMembership(ctx context.Context, teamID, userID string) (*types.TeamMembership, error)
+	ListTeamMemberships(ctx context.Context, q *ListTeamMembershipsQuery) ([]*types.TeamMembership, error)
+	DeleteTeamMembership(ctx context.Context, teamID, userID string) error
+
+	// access grants
+	CreateAccessGrant(ctx context.Context, grant *types.AccessGrant) (*types.AccessGrant, error)
+	UpdateAccessGrant(ctx context.Context, grant *types.AccessGrant) (*types.AccessGrant, error)
+	GetAccessGrant(ctx context.Context, id string) (*types.AccessGrant, error)
+	ListAccessGrants(ctx context.Context, q *ListAccessGrantsQuery) ([]*types.AccessGrant, error)
+	DeleteAccessGrant(ctx context.Context, id string) error
+
+	// scheduler state
+	UpsertSchedulerWorkload(ctx context.Context, workload *types.SchedulerWorkload) error
+	ListSchedulerWorkloads(ctx context.Context) ([]*types.SchedulerWorkload, error)
+	DeleteSchedulerWorkload(ctx context.Context, id string) error
+
+	UpsertSchedulerSlot(ctx context.Context, slot *types.SchedulerSlot) error
+	ListSchedulerSlots(ctx context.Context) ([]*types.SchedulerSlot, error)
+	DeleteSchedulerSlot(ctx context.Context, id string) error
 }
 
 type EmbeddingsStore interface {
 	CreateKnowledgeEmbedding(ctx context.Context, embeddings ...*types.KnowledgeEmbeddingItem) error
 	DeleteKnowledgeEmbedding(ctx context.Context, knowledgeID string) error
 	QueryKnowledgeEmbeddings(ctx context.Context, q *types.KnowledgeEmbeddingQuery) ([]*types.KnowledgeEmbeddingItem, error)
+	CopyKnowledgeEmbeddings(ctx context.Context, req *types.CopyIndexRequest) error
 }
 
 var ErrNotFound = errors.New("not found")
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+	"gorm.io/gorm"
+)
+
+type ListAccessGrantsQuery struct {
+	ResourceType types.ResourceType
+	ResourceID   string
+	Grantee      string
+	GranteeType  types.OwnerType
+}
+
+func (s *PostgresStore) CreateAccessGrant(ctx context.Context, grant *types.AccessGrant) (*types.AccessGrant, error) {
+	if grant.ID == "" {
+		grant.ID = system.GenerateAccessGrantID()
+	}
+
+	if grant.ResourceType == "" || grant.ResourceID == "" {
+		return nil, fmt.Errorf("resource_type and resource_id must be specified")
+	}
+
+	if grant.Grantee == "" {
+		return nil, fmt.Errorf("grantee not specified")
+	}
+
+	if !grant.Role.IsValid() {
+		return nil, fmt.Errorf("invalid role '%s'", grant.Role)
+	}
+
+	grant.Created = time.Now()
+	grant.Updated = grant.Created
+
+	err := s.gdb.WithContext(ctx).Create(grant).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetAccessGrant(ctx, grant.ID)
+}
+
+func (s *PostgresStore) UpdateAccessGrant(ctx context.Context, grant *types.AccessGrant) (*types.AccessGrant, error) {
+	if grant.ID == "" {
+		return nil, fmt.Errorf("id not specified")
+	}
+
+	if !grant.Role.IsValid() {
+		return nil, fmt.Errorf("invalid role '%s'", grant.Role)
+	}
+
+	grant.Updated = time.Now()
+
+	err := s.gdb.WithContext(ctx).Save(grant).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetAccessGrant(ctx, grant.ID)
+}
+
+func (s *PostgresStore) GetAccessGrant(ctx context.Context, id string) (*types.AccessGrant, error) {
+	if id == "" {
+		return nil, fmt.Errorf("id not specified")
+	}
+
+	var grant types.AccessGrant
+	err := s.gdb.WithContext(ctx).Where("id = ?", id).First(&grant).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &grant, nil
+}
+
+func (s *PostgresStore) ListAccessGrants(ctx context.Context, q *ListAccessGrantsQuery) ([]*types.AccessGrant, error) {
+	var grants []*types.AccessGrant
+	err := s.gdb.WithContext(ctx).Where(&types.AccessGrant{
+		ResourceType: q.ResourceType,
+		ResourceID:   q.ResourceID,
+		Grantee:      q.Grantee,
+		GranteeType:  q.GranteeType,
+	}).Order("created ASC").Find(&grants).Error
+	if err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+func (s *PostgresStore) DeleteAccessGrant(ctx context.Context, id string) error {
+	if id == "" {
+		return fmt.Errorf("id not specified")
+	}
+
+	return s.gdb.WithContext(ctx).Delete(&types.AccessGrant{ID: id}).Error
+}
+
+// grantCondition matches resources of the given type that were shared with the
+// user directly or with any organization or team the user is a member of
+func (s *PostgresStore) grantCondition(resourceType types.ResourceType, userID string) *gorm.DB {
+	grantees := s.gdb.Where("grantee_type = ? AND grantee = ?", types.OwnerTypeUser, userID).
+		Or("grantee_type = ? AND grantee IN (?)", types.OwnerTypeOrg, s.gdb.Model(&types.OrganizationMembership{}).
+			Select("organization_id").
+			Where("user_id = ?", userID)).
+		Or("grantee_type = ? AND grantee IN (?)", types.OwnerTypeTeam, s.gdb.Model(&types.TeamMembership{}).
+			Select("team_id").
+			Where("user_id = ?", userID))
+
+	return s.gdb.Where("id IN (?)", s.gdb.Model(&types.AccessGrant{}).
+		Select("resource_id").
+		Where("resource_type = ?", resourceType).
+		Where(grantees))
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *PostgresStoreTestSuite) TestAccessGrantCRUD() {
+	appID := system.GenerateAppID()
+
+	grant, err := suite.db.CreateAccessGrant(suite.ctx, &types.AccessGrant{
+		ResourceType: types.ResourceTypeApp,
+		ResourceID:   appID,
+		Grantee:      "test-user-" + system.GenerateUUID(),
+		GranteeType:  types.OwnerTypeUser,
+		Role:         types.ResourceRoleViewer,
+	})
+	require.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), grant.ID)
+
+	grant.Role = types.ResourceRoleEditor
+	updated, err := suite.db.UpdateAccessGrant(suite.ctx, grant)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), types.ResourceRoleEditor, updated.Role)
+
+	grants, err := suite.db.ListAccessGrants(suite.ctx, &ListAccessGrantsQuery{
+		ResourceType: types.ResourceTypeApp,
+		ResourceID:   appID,
+	})
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), grants, 1)
+
+	err = suite.db.DeleteAccessGrant(suite.ctx, grant.ID)
+	require.NoError(suite.T(), err)
+
+	_, err = suite.db.GetAccessGrant(suite.ctx, grant.ID)
+	assert.ErrorIs(suite.T(), err, ErrNotFound)
+
+	// Only viewer, editor and owner can be granted
+	_, err = suite.db.CreateAccessGrant(suite.ctx, &types.AccessGrant{
+		ResourceType: types.ResourceTypeApp,
+		ResourceID:   appID,
+		Grantee:      "test-user",
+		Role:         "admin",
+	})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PostgresStoreTestSuite) TestListAppsWithAccessGrants() {
+	owner := "test-owner-" + system.GenerateUUID()
+	viewer := "test-viewer-" + system.GenerateUUID()
+
+	app, err := suite.db.CreateApp(suite.ctx, &types.App{
+		Owner:     owner,
+		OwnerType: types.OwnerTypeUser,
+	})
+	require.NoError(suite.T(), err)
+
+	grant, err := suite.db.CreateAccessGrant(suite.ctx, &types.AccessGrant{
+		ResourceType: types.ResourceTypeApp,
+		ResourceID:   app.ID,
+		Grantee:      viewer,
+		GranteeType:  types.OwnerTypeUser,
+		Role:         types.ResourceRoleViewer,
+	})
+	require.NoError(suite.T(), err)
+
+	suite.T().Cleanup(func() {
+		assert.NoError(suite.T(), suite.db.DeleteApp(suite.ctx, app.ID))
+		assert.NoError(suite.T(), suite.db.DeleteAccessGrant(suite.ctx, grant.ID))
+	})
+
+	apps, err := suite.db.ListApps(suite.ctx, &ListAppsQuery{
+		Owner:     viewer,
+		OwnerType: types.OwnerTypeUser,
+	})
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), apps, 0)
+
+	apps, err = suite.db.ListApps(suite.ctx, &ListAppsQuery{
+		Owner:            viewer,
+		OwnerType:        types.OwnerTypeUser,
+		WithAccessGrants: true,
+	})
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), apps, 1)
+	assert.Equal(suite.T(), app.ID, apps[0].ID)
+}
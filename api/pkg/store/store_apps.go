@@ -19,7 +19,7 @@ import (
 //
 // This function:
 //  1. Processes any tools found in the deprecated Tools field and converts them
-//     to their appropriate specific fields (APIs, GPTScripts, Zapier)
+//     to their appropriate specific fields (APIs, GPTScripts, Zapier, MCPs)
 //  2. Handles deduplication by name - if a tool already exists in a specific
 //     field (e.g., in APIs), it won't be duplicated from the Tools field
 //  3. Gives precedence to tools defined in their specific fields over those in
@@ -37,6 +37,7 @@ func RectifyApp(app *types.App) {
 		existingAPIs := make(map[string]bool)
 		existingGPTScripts := make(map[string]bool)
 		existingZapier := make(map[string]bool)
+		existingMCPs := make(map[string]bool)
 
 		// First mark all existing non-Tools items
 		for _, api := range assistant.APIs {
@@ -48,6 +49,9 @@ func RectifyApp(app *types.App) {
 		for _, zapier := range assistant.Zapier {
 			existingZapier[zapier.Name] = true
 		}
+		for _, mcp := range assistant.MCPs {
+			existingMCPs[mcp.Name] = true
+		}
 
 		// Convert tools to their appropriate fields
 		// but only if they don't already exist in the non-Tools fields
@@ -88,6 +92,20 @@ func RectifyApp(app *types.App) {
 					})
 					existingZapier[tool.Name] = true
 				}
+			case types.ToolTypeMCP:
+				if !existingMCPs[tool.Name] && tool.Config.MCP != nil {
+					assistant.MCPs = append(assistant.MCPs, types.AssistantMCP{
+						Name:        tool.Name,
+						Description: tool.Description,
+						Command:     tool.Config.MCP.Command,
+						Args:        tool.Config.MCP.Args,
+						Env:         tool.Config.MCP.Env,
+						Secrets:     tool.Config.MCP.Secrets,
+						URL:         tool.Config.MCP.URL,
+						Approval:    tool.Config.MCP.Approval,
+					})
+					existingMCPs[tool.Name] = true
+				}
 			}
 		}
 
@@ -217,6 +235,8 @@ func ConvertAPIToTool(api types.AssistantAPI) (*types.Tool, error) {
 				Schema:                  api.Schema,
 				Headers:                 api.Headers,
 				Query:                   api.Query,
+				Auth:                    api.Auth,
+				Approval:                api.Approval,
 				RequestPrepTemplate:     api.RequestPrepTemplate,
 				ResponseSuccessTemplate: api.ResponseSuccessTemplate,
 				ResponseErrorTemplate:   api.ResponseErrorTemplate,
@@ -285,24 +305,57 @@ func (s *PostgresStore) GetAppWithTools(ctx context.Context, id string) (*types.
 			})
 		}
 
+		// Convert MCP servers to Tools, their actions are listed when they
+		// are used
+		for _, mcp := range assistant.MCPs {
+			tools = append(tools, &types.Tool{
+				Name:        mcp.Name,
+				Description: mcp.Description,
+				ToolType:    types.ToolTypeMCP,
+				Config: types.ToolConfig{
+					MCP: &types.ToolMCPConfig{
+						Command:  mcp.Command,
+						Args:     mcp.Args,
+						Env:      mcp.Env,
+						Secrets:  mcp.Secrets,
+						URL:      mcp.URL,
+						Approval: mcp.Approval,
+					},
+				},
+			})
+		}
+
 		assistant.Tools = tools
 		// empty out the canonical fields to avoid confusion. Callers of this
 		// function should ONLY use the internal Tools field
 		assistant.APIs = nil
 		assistant.GPTScripts = nil
 		assistant.Zapier = nil
+		assistant.MCPs = nil
 	}
 
 	return app, nil
 }
 
 func (s *PostgresStore) ListApps(ctx context.Context, q *ListAppsQuery) ([]*types.App, error) {
+	query := s.gdb.WithContext(ctx)
+
+	if q.Owner != "" {
+		condition := s.ownerCondition(q.Owner, q.OwnerType, q.WithOrganizations)
+		if q.WithAccessGrants {
+			condition = s.gdb.Where(condition).Or(s.grantCondition(types.ResourceTypeApp, q.Owner))
+		}
+		query = query.Where(condition)
+	} else if q.OwnerType != "" {
+		query = query.Where("owner_type = ?", q.OwnerType)
+	}
+
+	if q.Global {
+		query = query.Where("global = ?", true)
+	}
+
 	var apps []*types.App
-	err := s.gdb.WithContext(ctx).Where(&types.App{
-		Owner:     q.Owner,
-		OwnerType: q.OwnerType,
-		Global:    q.Global,
-	}).Order("id DESC").Find(&apps).Error
+	err := query.Order("id DESC").Find(&apps).Error
 	if err != nil {
 		return nil, err
 	}
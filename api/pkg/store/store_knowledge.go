@@ -135,15 +135,25 @@ type ListKnowledgeQuery struct {
 	State     types.KnowledgeState
 	ID        string // Knowledge ID to search for
 	AppID     string
+
+	// WithOrganizations also returns knowledge owned by the organizations
+	// and teams the owner is a member of
+	WithOrganizations bool
+	// WithAccessGrants also returns knowledge shared with the owner
+	// through access grants
+	WithAccessGrants bool
 }
 
 func (s *PostgresStore) ListKnowledge(ctx context.Context, q *ListKnowledgeQuery) ([]*types.Knowledge, error) {
 	query := s.gdb.WithContext(ctx)
 
 	if q.Owner != "" {
-		query = query.Where("owner = ?", q.Owner)
-	}
-	if q.OwnerType != "" {
+		condition := s.ownerCondition(q.Owner, q.OwnerType, q.WithOrganizations)
+		if q.WithAccessGrants {
+			condition = s.gdb.Where(condition).Or(s.grantCondition(types.ResourceTypeKnowledge, q.Owner))
+		}
+		query = query.Where(condition)
+	} else if q.OwnerType != "" {
 		query = query.Where("owner_type = ?", q.OwnerType)
 	}
 	if q.State != "" {
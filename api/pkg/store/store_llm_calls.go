@@ -68,3 +68,53 @@ func (s *PostgresStore) ListLLMCalls(ctx context.Context, q *ListLLMCallsQuery)
 
 	return calls, totalCount, nil
 }
+
+type ListLLMCallUsageQuery struct {
+	From     time.Time
+	To       time.Time
+	UserID   string
+	AppID    string
+	Provider string
+	Model    string
+}
+
+// ListLLMCallUsage sums the tokens of the LLM calls made between from and to,
+// grouped by UTC day, user, app, provider and model
+func (s *PostgresStore) ListLLMCallUsage(ctx context.Context, q *ListLLMCallUsageQuery) ([]*types.UsageEntry, error) {
+	var entries []*types.UsageEntry
+
+	query := s.gdb.WithContext(ctx).Model(&types.LLMCall{}).
+		Select(`to_char(created AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day,
+			user_id, app_id, provider, model,
+			COUNT(*) AS requests,
+			COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens,
+			COALESCE(SUM(completion_tokens), 0) AS completion_tokens,
+			COALESCE(SUM(total_tokens), 0) AS total_tokens`).
+		Where("created >= ? AND created < ?", q.From, q.To)
+
+	if q.UserID != "" {
+		query = query.Where("user_id = ?", q.UserID)
+	}
+
+	if q.AppID != "" {
+		query = query.Where("app_id = ?", q.AppID)
+	}
+
+	if q.Provider != "" {
+		query = query.Where("provider = ?", q.Provider)
+	}
+
+	if q.Model != "" {
+		query = query.Where("model = ?", q.Model)
+	}
+
+	err := query.
+		Group("day, user_id, app_id, provider, model").
+		Order("day, user_id, app_id, provider, model").
+		Scan(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -12,6 +12,7 @@ package store
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	license "github.com/helixml/helix/api/pkg/license"
 	types "github.com/helixml/helix/api/pkg/types"
@@ -57,6 +58,21 @@ func (mr *MockStoreMockRecorder) CreateAPIKey(ctx, apiKey any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockStore)(nil).CreateAPIKey), ctx, apiKey)
 }
 
+// CreateAccessGrant mocks base method.
+func (m *MockStore) CreateAccessGrant(ctx context.Context, grant *types.AccessGrant) (*types.AccessGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccessGrant", ctx, grant)
+	ret0, _ := ret[0].(*types.AccessGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccessGrant indicates an expected call of CreateAccessGrant.
+func (mr *MockStoreMockRecorder) CreateAccessGrant(ctx, grant any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccessGrant", reflect.TypeOf((*MockStore)(nil).CreateAccessGrant), ctx, grant)
+}
+
 // CreateApp mocks base method.
 func (m *MockStore) CreateApp(ctx context.Context, tool *types.App) (*types.App, error) {
 	m.ctrl.T.Helper()
@@ -132,6 +148,51 @@ func (mr *MockStoreMockRecorder) CreateLLMCall(ctx, call any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLLMCall", reflect.TypeOf((*MockStore)(nil).CreateLLMCall), ctx, call)
 }
 
+// CreateModelPrice mocks base method.
+func (m *MockStore) CreateModelPrice(ctx context.Context, price *types.ModelPrice) (*types.ModelPrice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateModelPrice", ctx, price)
+	ret0, _ := ret[0].(*types.ModelPrice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateModelPrice indicates an expected call of CreateModelPrice.
+func (mr *MockStoreMockRecorder) CreateModelPrice(ctx, price any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateModelPrice", reflect.TypeOf((*MockStore)(nil).CreateModelPrice), ctx, price)
+}
+
+// CreateOrganization mocks base method.
+func (m *MockStore) CreateOrganization(ctx context.Context, org *types.Organization) (*types.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrganization", ctx, org)
+	ret0, _ := ret[0].(*types.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrganization indicates an expected call of CreateOrganization.
+func (mr *MockStoreMockRecorder) CreateOrganization(ctx, org any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrganization", reflect.TypeOf((*MockStore)(nil).CreateOrganization), ctx, org)
+}
+
+// CreateOrganizationMembership mocks base method.
+func (m *MockStore) CreateOrganizationMembership(ctx context.Context, membership *types.OrganizationMembership) (*types.OrganizationMembership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrganizationMembership", ctx, membership)
+	ret0, _ := ret[0].(*types.OrganizationMembership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrganizationMembership indicates an expected call of CreateOrganizationMembership.
+func (mr *MockStoreMockRecorder) CreateOrganizationMembership(ctx, membership any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrganizationMembership", reflect.TypeOf((*MockStore)(nil).CreateOrganizationMembership), ctx, membership)
+}
+
 // CreateProviderEndpoint mocks base method.
 func (m *MockStore) CreateProviderEndpoint(ctx context.Context, providerEndpoint *types.ProviderEndpoint) (*types.ProviderEndpoint, error) {
 	m.ctrl.T.Helper()
@@ -147,6 +208,36 @@ func (mr *MockStoreMockRecorder) CreateProviderEndpoint(ctx, providerEndpoint an
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProviderEndpoint", reflect.TypeOf((*MockStore)(nil).CreateProviderEndpoint), ctx, providerEndpoint)
 }
 
+// CreateProviderRoutingGroup mocks base method.
+func (m *MockStore) CreateProviderRoutingGroup(ctx context.Context, group *types.ProviderRoutingGroup) (*types.ProviderRoutingGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateProviderRoutingGroup", ctx, group)
+	ret0, _ := ret[0].(*types.ProviderRoutingGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateProviderRoutingGroup indicates an expected call of CreateProviderRoutingGroup.
+func (mr *MockStoreMockRecorder) CreateProviderRoutingGroup(ctx, group any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProviderRoutingGroup", reflect.TypeOf((*MockStore)(nil).CreateProviderRoutingGroup), ctx, group)
+}
+
+// CreateRateLimit mocks base method.
+func (m *MockStore) CreateRateLimit(ctx context.Context, limit *types.RateLimit) (*types.RateLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRateLimit", ctx, limit)
+	ret0, _ := ret[0].(*types.RateLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRateLimit indicates an expected call of CreateRateLimit.
+func (mr *MockStoreMockRecorder) CreateRateLimit(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRateLimit", reflect.TypeOf((*MockStore)(nil).CreateRateLimit), ctx, limit)
+}
+
 // CreateScriptRun mocks base method.
 func (m *MockStore) CreateScriptRun(ctx context.Context, task *types.ScriptRun) (*types.ScriptRun, error) {
 	m.ctrl.T.Helper()
@@ -192,6 +283,36 @@ func (mr *MockStoreMockRecorder) CreateSession(ctx, session any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStore)(nil).CreateSession), ctx, session)
 }
 
+// CreateTeam mocks base method.
+func (m *MockStore) CreateTeam(ctx context.Context, team *types.Team) (*types.Team, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTeam", ctx, team)
+	ret0, _ := ret[0].(*types.Team)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTeam indicates an expected call of CreateTeam.
+func (mr *MockStoreMockRecorder) CreateTeam(ctx, team any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTeam", reflect.TypeOf((*MockStore)(nil).CreateTeam), ctx, team)
+}
+
+// CreateTeamMembership mocks base method.
+func (m *MockStore) CreateTeamMembership(ctx context.Context, membership *types.TeamMembership) (*types.TeamMembership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTeamMembership", ctx, membership)
+	ret0, _ := ret[0].(*types.TeamMembership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTeamMembership indicates an expected call of CreateTeamMembership.
+func (mr *MockStoreMockRecorder) CreateTeamMembership(ctx, membership any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTeamMembership", reflect.TypeOf((*MockStore)(nil).CreateTeamMembership), ctx, membership)
+}
+
 // CreateTool mocks base method.
 func (m *MockStore) CreateTool(ctx context.Context, tool *types.Tool) (*types.Tool, error) {
 	m.ctrl.T.Helper()
@@ -207,6 +328,21 @@ func (mr *MockStoreMockRecorder) CreateTool(ctx, tool any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTool", reflect.TypeOf((*MockStore)(nil).CreateTool), ctx, tool)
 }
 
+// CreateToolApproval mocks base method.
+func (m *MockStore) CreateToolApproval(ctx context.Context, approval *types.ToolApproval) (*types.ToolApproval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateToolApproval", ctx, approval)
+	ret0, _ := ret[0].(*types.ToolApproval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateToolApproval indicates an expected call of CreateToolApproval.
+func (mr *MockStoreMockRecorder) CreateToolApproval(ctx, approval any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateToolApproval", reflect.TypeOf((*MockStore)(nil).CreateToolApproval), ctx, approval)
+}
+
 // CreateUserMeta mocks base method.
 func (m *MockStore) CreateUserMeta(ctx context.Context, UserMeta types.UserMeta) (*types.UserMeta, error) {
 	m.ctrl.T.Helper()
@@ -236,6 +372,20 @@ func (mr *MockStoreMockRecorder) DeleteAPIKey(ctx, apiKey any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAPIKey", reflect.TypeOf((*MockStore)(nil).DeleteAPIKey), ctx, apiKey)
 }
 
+// DeleteAccessGrant mocks base method.
+func (m *MockStore) DeleteAccessGrant(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccessGrant", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccessGrant indicates an expected call of DeleteAccessGrant.
+func (mr *MockStoreMockRecorder) DeleteAccessGrant(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccessGrant", reflect.TypeOf((*MockStore)(nil).DeleteAccessGrant), ctx, id)
+}
+
 // DeleteApp mocks base method.
 func (m *MockStore) DeleteApp(ctx context.Context, id string) error {
 	m.ctrl.T.Helper()
@@ -292,6 +442,48 @@ func (mr *MockStoreMockRecorder) DeleteKnowledgeVersion(ctx, id any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteKnowledgeVersion", reflect.TypeOf((*MockStore)(nil).DeleteKnowledgeVersion), ctx, id)
 }
 
+// DeleteModelPrice mocks base method.
+func (m *MockStore) DeleteModelPrice(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteModelPrice", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteModelPrice indicates an expected call of DeleteModelPrice.
+func (mr *MockStoreMockRecorder) DeleteModelPrice(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteModelPrice", reflect.TypeOf((*MockStore)(nil).DeleteModelPrice), ctx, id)
+}
+
+// DeleteOrganization mocks base method.
+func (m *MockStore) DeleteOrganization(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOrganization", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOrganization indicates an expected call of DeleteOrganization.
+func (mr *MockStoreMockRecorder) DeleteOrganization(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrganization", reflect.TypeOf((*MockStore)(nil).DeleteOrganization), ctx, id)
+}
+
+// DeleteOrganizationMembership mocks base method.
+func (m *MockStore) DeleteOrganizationMembership(ctx context.Context, organizationID, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOrganizationMembership", ctx, organizationID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOrganizationMembership indicates an expected call of DeleteOrganizationMembership.
+func (mr *MockStoreMockRecorder) DeleteOrganizationMembership(ctx, organizationID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrganizationMembership", reflect.TypeOf((*MockStore)(nil).DeleteOrganizationMembership), ctx, organizationID, userID)
+}
+
 // DeleteProviderEndpoint mocks base method.
 func (m *MockStore) DeleteProviderEndpoint(ctx context.Context, id string) error {
 	m.ctrl.T.Helper()
@@ -306,6 +498,62 @@ func (mr *MockStoreMockRecorder) DeleteProviderEndpoint(ctx, id any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProviderEndpoint", reflect.TypeOf((*MockStore)(nil).DeleteProviderEndpoint), ctx, id)
 }
 
+// DeleteProviderRoutingGroup mocks base method.
+func (m *MockStore) DeleteProviderRoutingGroup(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteProviderRoutingGroup", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteProviderRoutingGroup indicates an expected call of DeleteProviderRoutingGroup.
+func (mr *MockStoreMockRecorder) DeleteProviderRoutingGroup(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProviderRoutingGroup", reflect.TypeOf((*MockStore)(nil).DeleteProviderRoutingGroup), ctx, id)
+}
+
+// DeleteRateLimit mocks base method.
+func (m *MockStore) DeleteRateLimit(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRateLimit", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRateLimit indicates an expected call of DeleteRateLimit.
+func (mr *MockStoreMockRecorder) DeleteRateLimit(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRateLimit", reflect.TypeOf((*MockStore)(nil).DeleteRateLimit), ctx, id)
+}
+
+// DeleteSchedulerSlot mocks base method.
+func (m *MockStore) DeleteSchedulerSlot(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSchedulerSlot", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSchedulerSlot indicates an expected call of DeleteSchedulerSlot.
+func (mr *MockStoreMockRecorder) DeleteSchedulerSlot(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSchedulerSlot", reflect.TypeOf((*MockStore)(nil).DeleteSchedulerSlot), ctx, id)
+}
+
+// DeleteSchedulerWorkload mocks base method.
+func (m *MockStore) DeleteSchedulerWorkload(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSchedulerWorkload", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSchedulerWorkload indicates an expected call of DeleteSchedulerWorkload.
+func (mr *MockStoreMockRecorder) DeleteSchedulerWorkload(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSchedulerWorkload", reflect.TypeOf((*MockStore)(nil).DeleteSchedulerWorkload), ctx, id)
+}
+
 // DeleteScriptRun mocks base method.
 func (m *MockStore) DeleteScriptRun(ctx context.Context, id string) error {
 	m.ctrl.T.Helper()
@@ -349,6 +597,34 @@ func (mr *MockStoreMockRecorder) DeleteSession(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockStore)(nil).DeleteSession), ctx, id)
 }
 
+// DeleteTeam mocks base method.
+func (m *MockStore) DeleteTeam(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTeam", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTeam indicates an expected call of DeleteTeam.
+func (mr *MockStoreMockRecorder) DeleteTeam(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTeam", reflect.TypeOf((*MockStore)(nil).DeleteTeam), ctx, id)
+}
+
+// DeleteTeamMembership mocks base method.
+func (m *MockStore) DeleteTeamMembership(ctx context.Context, teamID, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTeamMembership", ctx, teamID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTeamMembership indicates an expected call of DeleteTeamMembership.
+func (mr *MockStoreMockRecorder) DeleteTeamMembership(ctx, teamID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTeamMembership", reflect.TypeOf((*MockStore)(nil).DeleteTeamMembership), ctx, teamID, userID)
+}
+
 // DeleteTool mocks base method.
 func (m *MockStore) DeleteTool(ctx context.Context, id string) error {
 	m.ctrl.T.Helper()
@@ -393,6 +669,21 @@ func (mr *MockStoreMockRecorder) GetAPIKey(ctx, apiKey any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKey", reflect.TypeOf((*MockStore)(nil).GetAPIKey), ctx, apiKey)
 }
 
+// GetAccessGrant mocks base method.
+func (m *MockStore) GetAccessGrant(ctx context.Context, id string) (*types.AccessGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccessGrant", ctx, id)
+	ret0, _ := ret[0].(*types.AccessGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccessGrant indicates an expected call of GetAccessGrant.
+func (mr *MockStoreMockRecorder) GetAccessGrant(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessGrant", reflect.TypeOf((*MockStore)(nil).GetAccessGrant), ctx, id)
+}
+
 // GetApp mocks base method.
 func (m *MockStore) GetApp(ctx context.Context, id string) (*types.App, error) {
 	m.ctrl.T.Helper()
@@ -498,6 +789,51 @@ func (mr *MockStoreMockRecorder) GetLicenseKey(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLicenseKey", reflect.TypeOf((*MockStore)(nil).GetLicenseKey), ctx)
 }
 
+// GetModelPrice mocks base method.
+func (m *MockStore) GetModelPrice(ctx context.Context, q *GetModelPriceQuery) (*types.ModelPrice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetModelPrice", ctx, q)
+	ret0, _ := ret[0].(*types.ModelPrice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetModelPrice indicates an expected call of GetModelPrice.
+func (mr *MockStoreMockRecorder) GetModelPrice(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetModelPrice", reflect.TypeOf((*MockStore)(nil).GetModelPrice), ctx, q)
+}
+
+// GetOrganization mocks base method.
+func (m *MockStore) GetOrganization(ctx context.Context, q *GetOrganizationQuery) (*types.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrganization", ctx, q)
+	ret0, _ := ret[0].(*types.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrganization indicates an expected call of GetOrganization.
+func (mr *MockStoreMockRecorder) GetOrganization(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganization", reflect.TypeOf((*MockStore)(nil).GetOrganization), ctx, q)
+}
+
+// GetOrganizationMembership mocks base method.
+func (m *MockStore) GetOrganizationMembership(ctx context.Context, organizationID, userID string) (*types.OrganizationMembership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrganizationMembership", ctx, organizationID, userID)
+	ret0, _ := ret[0].(*types.OrganizationMembership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrganizationMembership indicates an expected call of GetOrganizationMembership.
+func (mr *MockStoreMockRecorder) GetOrganizationMembership(ctx, organizationID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganizationMembership", reflect.TypeOf((*MockStore)(nil).GetOrganizationMembership), ctx, organizationID, userID)
+}
+
 // GetProviderEndpoint mocks base method.
 func (m *MockStore) GetProviderEndpoint(ctx context.Context, q *GetProviderEndpointsQuery) (*types.ProviderEndpoint, error) {
 	m.ctrl.T.Helper()
@@ -513,6 +849,36 @@ func (mr *MockStoreMockRecorder) GetProviderEndpoint(ctx, q any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProviderEndpoint", reflect.TypeOf((*MockStore)(nil).GetProviderEndpoint), ctx, q)
 }
 
+// GetProviderRoutingGroup mocks base method.
+func (m *MockStore) GetProviderRoutingGroup(ctx context.Context, q *GetProviderRoutingGroupQuery) (*types.ProviderRoutingGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProviderRoutingGroup", ctx, q)
+	ret0, _ := ret[0].(*types.ProviderRoutingGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProviderRoutingGroup indicates an expected call of GetProviderRoutingGroup.
+func (mr *MockStoreMockRecorder) GetProviderRoutingGroup(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProviderRoutingGroup", reflect.TypeOf((*MockStore)(nil).GetProviderRoutingGroup), ctx, q)
+}
+
+// GetRateLimit mocks base method.
+func (m *MockStore) GetRateLimit(ctx context.Context, q *GetRateLimitQuery) (*types.RateLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRateLimit", ctx, q)
+	ret0, _ := ret[0].(*types.RateLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRateLimit indicates an expected call of GetRateLimit.
+func (mr *MockStoreMockRecorder) GetRateLimit(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRateLimit", reflect.TypeOf((*MockStore)(nil).GetRateLimit), ctx, q)
+}
+
 // GetSecret mocks base method.
 func (m *MockStore) GetSecret(ctx context.Context, id string) (*types.Secret, error) {
 	m.ctrl.T.Helper()
@@ -573,6 +939,51 @@ func (mr *MockStoreMockRecorder) GetSessionsCounter(ctx, query any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionsCounter", reflect.TypeOf((*MockStore)(nil).GetSessionsCounter), ctx, query)
 }
 
+// GetTeam mocks base method.
+func (m *MockStore) GetTeam(ctx context.Context, q *GetTeamQuery) (*types.Team, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTeam", ctx, q)
+	ret0, _ := ret[0].(*types.Team)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTeam indicates an expected call of GetTeam.
+func (mr *MockStoreMockRecorder) GetTeam(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTeam", reflect.TypeOf((*MockStore)(nil).GetTeam), ctx, q)
+}
+
+// GetTeamMembership mocks base method.
+func (m *MockStore) GetTeamMembership(ctx context.Context, teamID, userID string) (*types.TeamMembership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTeamMembership", ctx, teamID, userID)
+	ret0, _ := ret[0].(*types.TeamMembership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTeamMembership indicates an expected call of GetTeamMembership.
+func (mr *MockStoreMockRecorder) GetTeamMembership(ctx, teamID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTeamMembership", reflect.TypeOf((*MockStore)(nil).GetTeamMembership), ctx, teamID, userID)
+}
+
+// GetTokenUsage mocks base method.
+func (m *MockStore) GetTokenUsage(ctx context.Context, q *GetTokenUsageQuery) (*types.TokenUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokenUsage", ctx, q)
+	ret0, _ := ret[0].(*types.TokenUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokenUsage indicates an expected call of GetTokenUsage.
+func (mr *MockStoreMockRecorder) GetTokenUsage(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenUsage", reflect.TypeOf((*MockStore)(nil).GetTokenUsage), ctx, q)
+}
+
 // GetTool mocks base method.
 func (m *MockStore) GetTool(ctx context.Context, id string) (*types.Tool, error) {
 	m.ctrl.T.Helper()
@@ -588,6 +999,21 @@ func (mr *MockStoreMockRecorder) GetTool(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTool", reflect.TypeOf((*MockStore)(nil).GetTool), ctx, id)
 }
 
+// GetToolApproval mocks base method.
+func (m *MockStore) GetToolApproval(ctx context.Context, id string) (*types.ToolApproval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetToolApproval", ctx, id)
+	ret0, _ := ret[0].(*types.ToolApproval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetToolApproval indicates an expected call of GetToolApproval.
+func (mr *MockStoreMockRecorder) GetToolApproval(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetToolApproval", reflect.TypeOf((*MockStore)(nil).GetToolApproval), ctx, id)
+}
+
 // GetUserMeta mocks base method.
 func (m *MockStore) GetUserMeta(ctx context.Context, id string) (*types.UserMeta, error) {
 	m.ctrl.T.Helper()
@@ -603,6 +1029,20 @@ func (mr *MockStoreMockRecorder) GetUserMeta(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserMeta", reflect.TypeOf((*MockStore)(nil).GetUserMeta), ctx, id)
 }
 
+// IncrementTokenUsage mocks base method.
+func (m *MockStore) IncrementTokenUsage(ctx context.Context, usage *types.TokenUsage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementTokenUsage", ctx, usage)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrementTokenUsage indicates an expected call of IncrementTokenUsage.
+func (mr *MockStoreMockRecorder) IncrementTokenUsage(ctx, usage any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementTokenUsage", reflect.TypeOf((*MockStore)(nil).IncrementTokenUsage), ctx, usage)
+}
+
 // ListAPIKeys mocks base method.
 func (m *MockStore) ListAPIKeys(ctx context.Context, query *ListAPIKeysQuery) ([]*types.ApiKey, error) {
 	m.ctrl.T.Helper()
@@ -618,6 +1058,21 @@ func (mr *MockStoreMockRecorder) ListAPIKeys(ctx, query any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPIKeys", reflect.TypeOf((*MockStore)(nil).ListAPIKeys), ctx, query)
 }
 
+// ListAccessGrants mocks base method.
+func (m *MockStore) ListAccessGrants(ctx context.Context, q *ListAccessGrantsQuery) ([]*types.AccessGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccessGrants", ctx, q)
+	ret0, _ := ret[0].([]*types.AccessGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccessGrants indicates an expected call of ListAccessGrants.
+func (mr *MockStoreMockRecorder) ListAccessGrants(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccessGrants", reflect.TypeOf((*MockStore)(nil).ListAccessGrants), ctx, q)
+}
+
 // ListApps mocks base method.
 func (m *MockStore) ListApps(ctx context.Context, q *ListAppsQuery) ([]*types.App, error) {
 	m.ctrl.T.Helper()
@@ -678,6 +1133,21 @@ func (mr *MockStoreMockRecorder) ListKnowledgeVersions(ctx, q any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListKnowledgeVersions", reflect.TypeOf((*MockStore)(nil).ListKnowledgeVersions), ctx, q)
 }
 
+// ListLLMCallUsage mocks base method.
+func (m *MockStore) ListLLMCallUsage(ctx context.Context, q *ListLLMCallUsageQuery) ([]*types.UsageEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLLMCallUsage", ctx, q)
+	ret0, _ := ret[0].([]*types.UsageEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLLMCallUsage indicates an expected call of ListLLMCallUsage.
+func (mr *MockStoreMockRecorder) ListLLMCallUsage(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLLMCallUsage", reflect.TypeOf((*MockStore)(nil).ListLLMCallUsage), ctx, q)
+}
+
 // ListLLMCalls mocks base method.
 func (m *MockStore) ListLLMCalls(ctx context.Context, q *ListLLMCallsQuery) ([]*types.LLMCall, int64, error) {
 	m.ctrl.T.Helper()
@@ -694,6 +1164,51 @@ func (mr *MockStoreMockRecorder) ListLLMCalls(ctx, q any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLLMCalls", reflect.TypeOf((*MockStore)(nil).ListLLMCalls), ctx, q)
 }
 
+// ListModelPrices mocks base method.
+func (m *MockStore) ListModelPrices(ctx context.Context) ([]*types.ModelPrice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListModelPrices", ctx)
+	ret0, _ := ret[0].([]*types.ModelPrice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListModelPrices indicates an expected call of ListModelPrices.
+func (mr *MockStoreMockRecorder) ListModelPrices(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListModelPrices", reflect.TypeOf((*MockStore)(nil).ListModelPrices), ctx)
+}
+
+// ListOrganizationMemberships mocks base method.
+func (m *MockStore) ListOrganizationMemberships(ctx context.Context, q *ListOrganizationMembershipsQuery) ([]*types.OrganizationMembership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrganizationMemberships", ctx, q)
+	ret0, _ := ret[0].([]*types.OrganizationMembership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrganizationMemberships indicates an expected call of ListOrganizationMemberships.
+func (mr *MockStoreMockRecorder) ListOrganizationMemberships(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrganizationMemberships", reflect.TypeOf((*MockStore)(nil).ListOrganizationMemberships), ctx, q)
+}
+
+// ListOrganizations mocks base method.
+func (m *MockStore) ListOrganizations(ctx context.Context, q *ListOrganizationsQuery) ([]*types.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrganizations", ctx, q)
+	ret0, _ := ret[0].([]*types.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrganizations indicates an expected call of ListOrganizations.
+func (mr *MockStoreMockRecorder) ListOrganizations(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrganizations", reflect.TypeOf((*MockStore)(nil).ListOrganizations), ctx, q)
+}
+
 // ListProviderEndpoints mocks base method.
 func (m *MockStore) ListProviderEndpoints(ctx context.Context, q *ListProviderEndpointsQuery) ([]*types.ProviderEndpoint, error) {
 	m.ctrl.T.Helper()
@@ -709,6 +1224,66 @@ func (mr *MockStoreMockRecorder) ListProviderEndpoints(ctx, q any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListProviderEndpoints", reflect.TypeOf((*MockStore)(nil).ListProviderEndpoints), ctx, q)
 }
 
+// ListProviderRoutingGroups mocks base method.
+func (m *MockStore) ListProviderRoutingGroups(ctx context.Context, q *ListProviderEndpointsQuery) ([]*types.ProviderRoutingGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListProviderRoutingGroups", ctx, q)
+	ret0, _ := ret[0].([]*types.ProviderRoutingGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListProviderRoutingGroups indicates an expected call of ListProviderRoutingGroups.
+func (mr *MockStoreMockRecorder) ListProviderRoutingGroups(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListProviderRoutingGroups", reflect.TypeOf((*MockStore)(nil).ListProviderRoutingGroups), ctx, q)
+}
+
+// ListRateLimits mocks base method.
+func (m *MockStore) ListRateLimits(ctx context.Context, q *ListRateLimitsQuery) ([]*types.RateLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRateLimits", ctx, q)
+	ret0, _ := ret[0].([]*types.RateLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRateLimits indicates an expected call of ListRateLimits.
+func (mr *MockStoreMockRecorder) ListRateLimits(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRateLimits", reflect.TypeOf((*MockStore)(nil).ListRateLimits), ctx, q)
+}
+
+// ListSchedulerSlots mocks base method.
+func (m *MockStore) ListSchedulerSlots(ctx context.Context) ([]*types.SchedulerSlot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSchedulerSlots", ctx)
+	ret0, _ := ret[0].([]*types.SchedulerSlot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSchedulerSlots indicates an expected call of ListSchedulerSlots.
+func (mr *MockStoreMockRecorder) ListSchedulerSlots(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSchedulerSlots", reflect.TypeOf((*MockStore)(nil).ListSchedulerSlots), ctx)
+}
+
+// ListSchedulerWorkloads mocks base method.
+func (m *MockStore) ListSchedulerWorkloads(ctx context.Context) ([]*types.SchedulerWorkload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSchedulerWorkloads", ctx)
+	ret0, _ := ret[0].([]*types.SchedulerWorkload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSchedulerWorkloads indicates an expected call of ListSchedulerWorkloads.
+func (mr *MockStoreMockRecorder) ListSchedulerWorkloads(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSchedulerWorkloads", reflect.TypeOf((*MockStore)(nil).ListSchedulerWorkloads), ctx)
+}
+
 // ListScriptRuns mocks base method.
 func (m *MockStore) ListScriptRuns(ctx context.Context, q *types.GptScriptRunsQuery) ([]*types.ScriptRun, error) {
 	m.ctrl.T.Helper()
@@ -739,6 +1314,51 @@ func (mr *MockStoreMockRecorder) ListSecrets(ctx, q any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSecrets", reflect.TypeOf((*MockStore)(nil).ListSecrets), ctx, q)
 }
 
+// ListTeamMemberships mocks base method.
+func (m *MockStore) ListTeamMemberships(ctx context.Context, q *ListTeamMembershipsQuery) ([]*types.TeamMembership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTeamMemberships", ctx, q)
+	ret0, _ := ret[0].([]*types.TeamMembership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTeamMemberships indicates an expected call of ListTeamMemberships.
+func (mr *MockStoreMockRecorder) ListTeamMemberships(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTeamMemberships", reflect.TypeOf((*MockStore)(nil).ListTeamMemberships), ctx, q)
+}
+
+// ListTeams mocks base method.
+func (m *MockStore) ListTeams(ctx context.Context, q *ListTeamsQuery) ([]*types.Team, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTeams", ctx, q)
+	ret0, _ := ret[0].([]*types.Team)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTeams indicates an expected call of ListTeams.
+func (mr *MockStoreMockRecorder) ListTeams(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTeams", reflect.TypeOf((*MockStore)(nil).ListTeams), ctx, q)
+}
+
+// ListToolApprovals mocks base method.
+func (m *MockStore) ListToolApprovals(ctx context.Context, q *ListToolApprovalsQuery) ([]*types.ToolApproval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListToolApprovals", ctx, q)
+	ret0, _ := ret[0].([]*types.ToolApproval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListToolApprovals indicates an expected call of ListToolApprovals.
+func (mr *MockStoreMockRecorder) ListToolApprovals(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListToolApprovals", reflect.TypeOf((*MockStore)(nil).ListToolApprovals), ctx, q)
+}
+
 // ListTools mocks base method.
 func (m *MockStore) ListTools(ctx context.Context, q *ListToolsQuery) ([]*types.Tool, error) {
 	m.ctrl.T.Helper()
@@ -769,6 +1389,21 @@ func (mr *MockStoreMockRecorder) LookupKnowledge(ctx, q any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LookupKnowledge", reflect.TypeOf((*MockStore)(nil).LookupKnowledge), ctx, q)
 }
 
+// ResumeToolApproval mocks base method.
+func (m *MockStore) ResumeToolApproval(ctx context.Context, id string, resumedBefore time.Time) (*types.ToolApproval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResumeToolApproval", ctx, id, resumedBefore)
+	ret0, _ := ret[0].(*types.ToolApproval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResumeToolApproval indicates an expected call of ResumeToolApproval.
+func (mr *MockStoreMockRecorder) ResumeToolApproval(ctx, id, resumedBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumeToolApproval", reflect.TypeOf((*MockStore)(nil).ResumeToolApproval), ctx, id, resumedBefore)
+}
+
 // SetLicenseKey mocks base method.
 func (m *MockStore) SetLicenseKey(ctx context.Context, licenseKey string) error {
 	m.ctrl.T.Helper()
@@ -783,6 +1418,21 @@ func (mr *MockStoreMockRecorder) SetLicenseKey(ctx, licenseKey any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLicenseKey", reflect.TypeOf((*MockStore)(nil).SetLicenseKey), ctx, licenseKey)
 }
 
+// UpdateAccessGrant mocks base method.
+func (m *MockStore) UpdateAccessGrant(ctx context.Context, grant *types.AccessGrant) (*types.AccessGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccessGrant", ctx, grant)
+	ret0, _ := ret[0].(*types.AccessGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAccessGrant indicates an expected call of UpdateAccessGrant.
+func (mr *MockStoreMockRecorder) UpdateAccessGrant(ctx, grant any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccessGrant", reflect.TypeOf((*MockStore)(nil).UpdateAccessGrant), ctx, grant)
+}
+
 // UpdateApp mocks base method.
 func (m *MockStore) UpdateApp(ctx context.Context, tool *types.App) (*types.App, error) {
 	m.ctrl.T.Helper()
@@ -842,6 +1492,51 @@ func (mr *MockStoreMockRecorder) UpdateKnowledgeState(ctx, id, state, message an
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateKnowledgeState", reflect.TypeOf((*MockStore)(nil).UpdateKnowledgeState), ctx, id, state, message)
 }
 
+// UpdateModelPrice mocks base method.
+func (m *MockStore) UpdateModelPrice(ctx context.Context, price *types.ModelPrice) (*types.ModelPrice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateModelPrice", ctx, price)
+	ret0, _ := ret[0].(*types.ModelPrice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateModelPrice indicates an expected call of UpdateModelPrice.
+func (mr *MockStoreMockRecorder) UpdateModelPrice(ctx, price any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateModelPrice", reflect.TypeOf((*MockStore)(nil).UpdateModelPrice), ctx, price)
+}
+
+// UpdateOrganization mocks base method.
+func (m *MockStore) UpdateOrganization(ctx context.Context, org *types.Organization) (*types.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrganization", ctx, org)
+	ret0, _ := ret[0].(*types.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateOrganization indicates an expected call of UpdateOrganization.
+func (mr *MockStoreMockRecorder) UpdateOrganization(ctx, org any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrganization", reflect.TypeOf((*MockStore)(nil).UpdateOrganization), ctx, org)
+}
+
+// UpdateOrganizationMembership mocks base method.
+func (m *MockStore) UpdateOrganizationMembership(ctx context.Context, membership *types.OrganizationMembership) (*types.OrganizationMembership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrganizationMembership", ctx, membership)
+	ret0, _ := ret[0].(*types.OrganizationMembership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateOrganizationMembership indicates an expected call of UpdateOrganizationMembership.
+func (mr *MockStoreMockRecorder) UpdateOrganizationMembership(ctx, membership any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrganizationMembership", reflect.TypeOf((*MockStore)(nil).UpdateOrganizationMembership), ctx, membership)
+}
+
 // UpdateProviderEndpoint mocks base method.
 func (m *MockStore) UpdateProviderEndpoint(ctx context.Context, providerEndpoint *types.ProviderEndpoint) (*types.ProviderEndpoint, error) {
 	m.ctrl.T.Helper()
@@ -857,6 +1552,36 @@ func (mr *MockStoreMockRecorder) UpdateProviderEndpoint(ctx, providerEndpoint an
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProviderEndpoint", reflect.TypeOf((*MockStore)(nil).UpdateProviderEndpoint), ctx, providerEndpoint)
 }
 
+// UpdateProviderRoutingGroup mocks base method.
+func (m *MockStore) UpdateProviderRoutingGroup(ctx context.Context, group *types.ProviderRoutingGroup) (*types.ProviderRoutingGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProviderRoutingGroup", ctx, group)
+	ret0, _ := ret[0].(*types.ProviderRoutingGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProviderRoutingGroup indicates an expected call of UpdateProviderRoutingGroup.
+func (mr *MockStoreMockRecorder) UpdateProviderRoutingGroup(ctx, group any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProviderRoutingGroup", reflect.TypeOf((*MockStore)(nil).UpdateProviderRoutingGroup), ctx, group)
+}
+
+// UpdateRateLimit mocks base method.
+func (m *MockStore) UpdateRateLimit(ctx context.Context, limit *types.RateLimit) (*types.RateLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRateLimit", ctx, limit)
+	ret0, _ := ret[0].(*types.RateLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateRateLimit indicates an expected call of UpdateRateLimit.
+func (mr *MockStoreMockRecorder) UpdateRateLimit(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRateLimit", reflect.TypeOf((*MockStore)(nil).UpdateRateLimit), ctx, limit)
+}
+
 // UpdateSecret mocks base method.
 func (m *MockStore) UpdateSecret(ctx context.Context, secret *types.Secret) (*types.Secret, error) {
 	m.ctrl.T.Helper()
@@ -916,6 +1641,21 @@ func (mr *MockStoreMockRecorder) UpdateSessionName(ctx, sessionID, name any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSessionName", reflect.TypeOf((*MockStore)(nil).UpdateSessionName), ctx, sessionID, name)
 }
 
+// UpdateTeam mocks base method.
+func (m *MockStore) UpdateTeam(ctx context.Context, team *types.Team) (*types.Team, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTeam", ctx, team)
+	ret0, _ := ret[0].(*types.Team)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTeam indicates an expected call of UpdateTeam.
+func (mr *MockStoreMockRecorder) UpdateTeam(ctx, team any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTeam", reflect.TypeOf((*MockStore)(nil).UpdateTeam), ctx, team)
+}
+
 // UpdateTool mocks base method.
 func (m *MockStore) UpdateTool(ctx context.Context, tool *types.Tool) (*types.Tool, error) {
 	m.ctrl.T.Helper()
@@ -931,6 +1671,21 @@ func (mr *MockStoreMockRecorder) UpdateTool(ctx, tool any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTool", reflect.TypeOf((*MockStore)(nil).UpdateTool), ctx, tool)
 }
 
+// UpdateToolApproval mocks base method.
+func (m *MockStore) UpdateToolApproval(ctx context.Context, approval *types.ToolApproval) (*types.ToolApproval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateToolApproval", ctx, approval)
+	ret0, _ := ret[0].(*types.ToolApproval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateToolApproval indicates an expected call of UpdateToolApproval.
+func (mr *MockStoreMockRecorder) UpdateToolApproval(ctx, approval any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateToolApproval", reflect.TypeOf((*MockStore)(nil).UpdateToolApproval), ctx, approval)
+}
+
 // UpdateUserMeta mocks base method.
 func (m *MockStore) UpdateUserMeta(ctx context.Context, UserMeta types.UserMeta) (*types.UserMeta, error) {
 	m.ctrl.T.Helper()
@@ -946,6 +1701,34 @@ func (mr *MockStoreMockRecorder) UpdateUserMeta(ctx, UserMeta any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserMeta", reflect.TypeOf((*MockStore)(nil).UpdateUserMeta), ctx, UserMeta)
 }
 
+// UpsertSchedulerSlot mocks base method.
+func (m *MockStore) UpsertSchedulerSlot(ctx context.Context, slot *types.SchedulerSlot) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertSchedulerSlot", ctx, slot)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertSchedulerSlot indicates an expected call of UpsertSchedulerSlot.
+func (mr *MockStoreMockRecorder) UpsertSchedulerSlot(ctx, slot any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertSchedulerSlot", reflect.TypeOf((*MockStore)(nil).UpsertSchedulerSlot), ctx, slot)
+}
+
+// UpsertSchedulerWorkload mocks base method.
+func (m *MockStore) UpsertSchedulerWorkload(ctx context.Context, workload *types.SchedulerWorkload) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertSchedulerWorkload", ctx, workload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertSchedulerWorkload indicates an expected call of UpsertSchedulerWorkload.
+func (mr *MockStoreMockRecorder) UpsertSchedulerWorkload(ctx, workload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertSchedulerWorkload", reflect.TypeOf((*MockStore)(nil).UpsertSchedulerWorkload), ctx, workload)
+}
+
 // MockEmbeddingsStore is a mock of EmbeddingsStore interface.
 type MockEmbeddingsStore struct {
 	ctrl     *gomock.Controller
@@ -970,6 +1753,20 @@ func (m *MockEmbeddingsStore) EXPECT() *MockEmbeddingsStoreMockRecorder {
 	return m.recorder
 }
 
+// CopyKnowledgeEmbeddings mocks base method.
+func (m *MockEmbeddingsStore) CopyKnowledgeEmbeddings(ctx context.Context, req *types.CopyIndexRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CopyKnowledgeEmbeddings", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CopyKnowledgeEmbeddings indicates an expected call of CopyKnowledgeEmbeddings.
+func (mr *MockEmbeddingsStoreMockRecorder) CopyKnowledgeEmbeddings(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyKnowledgeEmbeddings", reflect.TypeOf((*MockEmbeddingsStore)(nil).CopyKnowledgeEmbeddings), ctx, req)
+}
+
 // CreateKnowledgeEmbedding mocks base method.
 func (m *MockEmbeddingsStore) CreateKnowledgeEmbedding(ctx context.Context, embeddings ...*types.KnowledgeEmbeddingItem) error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func (s *PostgresStore) CreateModelPrice(ctx context.Context, price *types.ModelPrice) (*types.ModelPrice, error) {
+	if price.ID == "" {
+		price.ID = system.GenerateModelPriceID()
+	}
+
+	if price.Provider == "" {
+		return nil, fmt.Errorf("provider not specified")
+	}
+
+	if price.Model == "" {
+		return nil, fmt.Errorf("model not specified")
+	}
+
+	price.Created = time.Now()
+
+	err := s.gdb.WithContext(ctx).Create(price).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetModelPrice(ctx, &GetModelPriceQuery{ID: price.ID})
+}
+
+func (s *PostgresStore) UpdateModelPrice(ctx context.Context, price *types.ModelPrice) (*types.ModelPrice, error) {
+	if price.ID == "" {
+		return nil, fmt.Errorf("id not specified")
+	}
+
+	if price.Provider == "" {
+		return nil, fmt.Errorf("provider not specified")
+	}
+
+	if price.Model == "" {
+		return nil, fmt.Errorf("model not specified")
+	}
+
+	price.Updated = time.Now()
+
+	err := s.gdb.WithContext(ctx).Save(price).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetModelPrice(ctx, &GetModelPriceQuery{ID: price.ID})
+}
+
+func (s *PostgresStore) GetModelPrice(ctx context.Context, q *GetModelPriceQuery) (*types.ModelPrice, error) {
+	var price types.ModelPrice
+	query := s.gdb.WithContext(ctx)
+
+	if q.ID != "" {
+		query = query.Where("id = ?", q.ID)
+	}
+
+	if q.Provider != "" {
+		query = query.Where("provider = ?", q.Provider)
+	}
+
+	if q.Model != "" {
+		query = query.Where("model = ?", q.Model)
+	}
+
+	err := query.First(&price).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &price, nil
+}
+
+func (s *PostgresStore) ListModelPrices(ctx context.Context) ([]*types.ModelPrice, error) {
+	var prices []*types.ModelPrice
+
+	err := s.gdb.WithContext(ctx).Order("provider, model").Find(&prices).Error
+	if err != nil {
+		return nil, err
+	}
+	return prices, nil
+}
+
+func (s *PostgresStore) DeleteModelPrice(ctx context.Context, id string) error {
+	if id == "" {
+		return fmt.Errorf("id not specified")
+	}
+
+	return s.gdb.WithContext(ctx).Delete(&types.ModelPrice{ID: id}).Error
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"time"
+
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *PostgresStoreTestSuite) TestModelPriceCRUD() {
+	provider := "provider-" + system.GenerateUUID()
+
+	created, err := suite.db.CreateModelPrice(suite.ctx, &types.ModelPrice{
+		Provider:             provider,
+		Model:                "gpt-4o",
+		PromptTokenPrice:     2.5,
+		CompletionTokenPrice: 10,
+	})
+	require.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), created.ID)
+
+	suite.T().Cleanup(func() {
+		_ = suite.db.DeleteModelPrice(suite.ctx, created.ID)
+	})
+
+	fetched, err := suite.db.GetModelPrice(suite.ctx, &GetModelPriceQuery{Provider: provider, Model: "gpt-4o"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), created.ID, fetched.ID)
+
+	fetched.CompletionTokenPrice = 8
+	updated, err := suite.db.UpdateModelPrice(suite.ctx, fetched)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 8.0, updated.CompletionTokenPrice)
+
+	// Only one price per model of a provider
+	_, err = suite.db.CreateModelPrice(suite.ctx, &types.ModelPrice{Provider: provider, Model: "gpt-4o"})
+	assert.Error(suite.T(), err)
+
+	err = suite.db.DeleteModelPrice(suite.ctx, created.ID)
+	require.NoError(suite.T(), err)
+
+	_, err = suite.db.GetModelPrice(suite.ctx, &GetModelPriceQuery{ID: created.ID})
+	assert.ErrorIs(suite.T(), err, ErrNotFound)
+}
+
+func (suite *PostgresStoreTestSuite) TestListLLMCallUsage() {
+	userID := "user-" + system.GenerateUUID()
+	start := time.Now()
+
+	for _, call := range []*types.LLMCall{
+		{UserID: userID, AppID: "app", Provider: "openai", Model: "gpt-4o", PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		{UserID: userID, AppID: "app", Provider: "openai", Model: "gpt-4o", PromptTokens: 20, CompletionTokens: 10, TotalTokens: 30},
+		{UserID: userID, Provider: "helix", Model: "llama3", PromptTokens: 1, CompletionTokens: 2, TotalTokens: 3},
+	} {
+		_, err := suite.db.CreateLLMCall(suite.ctx, call)
+		require.NoError(suite.T(), err)
+	}
+
+	entries, err := suite.db.ListLLMCallUsage(suite.ctx, &ListLLMCallUsageQuery{
+		From:   start.Add(-time.Minute),
+		To:     time.Now().Add(time.Minute),
+		UserID: userID,
+	})
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), entries, 2)
+
+	// Ordered by day, user, app, provider and model
+	assert.Equal(suite.T(), "helix", entries[0].Provider)
+	assert.Equal(suite.T(), int64(1), entries[0].Requests)
+	assert.Equal(suite.T(), start.UTC().Format(time.DateOnly), entries[0].Day)
+
+	assert.Equal(suite.T(), "app", entries[1].AppID)
+	assert.Equal(suite.T(), int64(2), entries[1].Requests)
+	assert.Equal(suite.T(), int64(30), entries[1].PromptTokens)
+	assert.Equal(suite.T(), int64(15), entries[1].CompletionTokens)
+	assert.Equal(suite.T(), int64(45), entries[1].TotalTokens)
+}
@@ -0,0 +1,532 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+	"gorm.io/gorm"
+)
+
+type GetOrganizationQuery struct {
+	ID   string
+	Name string
+}
+
+type ListOrganizationsQuery struct {
+	// UserID, if set, only returns organizations the user is a member of
+	UserID string
+}
+
+type ListOrganizationMembershipsQuery struct {
+	OrganizationID string
+	UserID         string
+}
+
+type GetTeamQuery struct {
+	ID             string
+	OrganizationID string
+	Name           string
+}
+
+type ListTeamsQuery struct {
+	OrganizationID string
+	// UserID, if set, only returns teams the user is a member of
+	UserID string
+}
+
+type ListTeamMembershipsQuery struct {
+	TeamID         string
+	OrganizationID string
+	UserID         string
+}
+
+// CreateOrganization creates the organization and makes its owner
+// the first member with the owner role
+func (s *PostgresStore) CreateOrganization(ctx context.Context, org *types.Organization) (*types.Organization, error) {
+	if org.ID == "" {
+		org.ID = system.GenerateOrganizationID()
+	}
+
+	if org.Name == "" {
+		return nil, fmt.Errorf("name not specified")
+	}
+
+	if org.Owner == "" {
+		return nil, fmt.Errorf("owner not specified")
+	}
+
+	org.Created = time.Now()
+	org.Updated = org.Created
+
+	err := s.gdb.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing types.Organization
+		if err := tx.Where("name = ?", org.Name).First(&existing).Error; err == nil {
+			return fmt.Errorf("an organization with the name '%s' already exists", org.Name)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&types.OrganizationMembership{
+			OrganizationID: org.ID,
+			UserID:         org.Owner,
+			Role:           types.OrganizationRoleOwner,
+			Created:        org.Created,
+			Updated:        org.Created,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetOrganization(ctx, &GetOrganizationQuery{ID: org.ID})
+}
+
+func (s *PostgresStore) UpdateOrganization(ctx context.Context, org *types.Organization) (*types.Organization, error) {
+	if org.ID == "" {
+		return nil, fmt.Errorf("id not specified")
+	}
+
+	if org.Name == "" {
+		return nil, fmt.Errorf("name not specified")
+	}
+
+	org.Updated = time.Now()
+
+	err := s.gdb.WithContext(ctx).Save(org).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetOrganization(ctx, &GetOrganizationQuery{ID: org.ID})
+}
+
+func (s *PostgresStore) GetOrganization(ctx context.Context, q *GetOrganizationQuery) (*types.Organization, error) {
+	if q.ID == "" && q.Name == "" {
+		return nil, fmt.Errorf("id or name not specified")
+	}
+
+	query := s.gdb.WithContext(ctx)
+
+	if q.ID != "" {
+		query = query.Where("id = ?", q.ID)
+	}
+
+	if q.Name != "" {
+		query = query.Where("name = ?", q.Name)
+	}
+
+	var org types.Organization
+	err := query.First(&org).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (s *PostgresStore) ListOrganizations(ctx context.Context, q *ListOrganizationsQuery) ([]*types.Organization, error) {
+	query := s.gdb.WithContext(ctx)
+
+	if q != nil && q.UserID != "" {
+		query = query.Where("id IN (?)", s.gdb.Model(&types.OrganizationMembership{}).
+			Select("organization_id").
+			Where("user_id = ?", q.UserID))
+	}
+
+	var orgs []*types.Organization
+	err := query.Order("name ASC").Find(&orgs).Error
+	if err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// DeleteOrganization deletes the organization together with its teams and
+// memberships. Resources owned by the organization are not deleted.
+func (s *PostgresStore) DeleteOrganization(ctx context.Context, id string) error {
+	if id == "" {
+		return fmt.Errorf("id not specified")
+	}
+
+	return s.gdb.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("organization_id = ?", id).Delete(&types.TeamMembership{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("organization_id = ?", id).Delete(&types.Team{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("organization_id = ?", id).Delete(&types.OrganizationMembership{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&types.Organization{ID: id}).Error
+	})
+}
+
+func (s *PostgresStore) CreateOrganizationMembership(ctx context.Context, membership *types.OrganizationMembership) (*types.OrganizationMembership, error) {
+	if membership.OrganizationID == "" {
+		return nil, fmt.Errorf("organization_id not specified")
+	}
+
+	if membership.UserID == "" {
+		return nil, fmt.Errorf("user_id not specified")
+	}
+
+	if membership.Role == "" {
+		membership.Role = types.OrganizationRoleMember
+	}
+
+	membership.Created = time.Now()
+	membership.Updated = membership.Created
+
+	err := s.gdb.WithContext(ctx).Create(membership).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetOrganizationMembership(ctx, membership.OrganizationID, membership.UserID)
+}
+
+func (s *PostgresStore) UpdateOrganizationMembership(ctx context.Context, membership *types.OrganizationMembership) (*types.OrganizationMembership, error) {
+	if membership.OrganizationID == "" {
+		return nil, fmt.Errorf("organization_id not specified")
+	}
+
+	if membership.UserID == "" {
+		return nil, fmt.Errorf("user_id not specified")
+	}
+
+	membership.Updated = time.Now()
+
+	err := s.gdb.WithContext(ctx).Save(membership).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetOrganizationMembership(ctx, membership.OrganizationID, membership.UserID)
+}
+
+func (s *PostgresStore) GetOrganizationMembership(ctx context.Context, organizationID, userID string) (*types.OrganizationMembership, error) {
+	if organizationID == "" || userID == "" {
+		return nil, fmt.Errorf("organization_id and user_id must be specified")
+	}
+
+	var membership types.OrganizationMembership
+	err := s.gdb.WithContext(ctx).Where("organization_id = ? AND user_id = ?", organizationID, userID).First(&membership).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &membership, nil
+}
+
+func (s *PostgresStore) ListOrganizationMemberships(ctx context.Context, q *ListOrganizationMembershipsQuery) ([]*types.OrganizationMembership, error) {
+	var memberships []*types.OrganizationMembership
+	err := s.gdb.WithContext(ctx).Where(&types.OrganizationMembership{
+		OrganizationID: q.OrganizationID,
+		UserID:         q.UserID,
+	}).Order("created ASC").Find(&memberships).Error
+	if err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+// DeleteOrganizationMembership removes the user from the organization
+// and from all of its teams
+func (s *PostgresStore) DeleteOrganizationMembership(ctx context.Context, organizationID, userID string) error {
+	if organizationID == "" || userID == "" {
+		return fmt.Errorf("organization_id and user_id must be specified")
+	}
+
+	return s.gdb.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("organization_id = ? AND user_id = ?", organizationID, userID).Delete(&types.TeamMembership{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("organization_id = ? AND user_id = ?", organizationID, userID).Delete(&types.OrganizationMembership{}).Error
+	})
+}
+
+func (s *PostgresStore) CreateTeam(ctx context.Context, team *types.Team) (*types.Team, error) {
+	if team.ID == "" {
+		team.ID = system.GenerateTeamID()
+	}
+
+	if team.OrganizationID == "" {
+		return nil, fmt.Errorf("organization_id not specified")
+	}
+
+	if team.Name == "" {
+		return nil, fmt.Errorf("name not specified")
+	}
+
+	team.Created = time.Now()
+	team.Updated = team.Created
+
+	err := s.gdb.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing types.Team
+		if err := tx.Where("organization_id = ? AND name = ?", team.OrganizationID, team.Name).First(&existing).Error; err == nil {
+			return fmt.Errorf("a team with the name '%s' already exists in this organization", team.Name)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		return tx.Create(team).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetTeam(ctx, &GetTeamQuery{ID: team.ID})
+}
+
+func (s *PostgresStore) UpdateTeam(ctx context.Context, team *types.Team) (*types.Team, error) {
+	if team.ID == "" {
+		return nil, fmt.Errorf("id not specified")
+	}
+
+	if team.OrganizationID == "" {
+		return nil, fmt.Errorf("organization_id not specified")
+	}
+
+	team.Updated = time.Now()
+
+	err := s.gdb.WithContext(ctx).Save(team).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetTeam(ctx, &GetTeamQuery{ID: team.ID})
+}
+
+func (s *PostgresStore) GetTeam(ctx context.Context, q *GetTeamQuery) (*types.Team, error) {
+	if q.ID == "" && q.Name == "" {
+		return nil, fmt.Errorf("id or name not specified")
+	}
+
+	var team types.Team
+	err := s.gdb.WithContext(ctx).Where(&types.Team{
+		ID:             q.ID,
+		OrganizationID: q.OrganizationID,
+		Name:           q.Name,
+	}).First(&team).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &team, nil
+}
+
+func (s *PostgresStore) ListTeams(ctx context.Context, q *ListTeamsQuery) ([]*types.Team, error) {
+	query := s.gdb.WithContext(ctx)
+
+	if q.OrganizationID != "" {
+		query = query.Where("organization_id = ?", q.OrganizationID)
+	}
+
+	if q.UserID != "" {
+		query = query.Where("id IN (?)", s.gdb.Model(&types.TeamMembership{}).
+			Select("team_id").
+			Where("user_id = ?", q.UserID))
+	}
+
+	var teams []*types.Team
+	err := query.Order("name ASC").Find(&teams).Error
+	if err != nil {
+		return nil, err
+	}
+	return teams, nil
+}
+
+func (s *PostgresStore) DeleteTeam(ctx context.Context, id string) error {
+	if id == "" {
+		return fmt.Errorf("id not specified")
+	}
+
+	return s.gdb.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_id = ?", id).Delete(&types.TeamMembership{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&types.Team{ID: id}).Error
+	})
+}
+
+func (s *PostgresStore) CreateTeamMembership(ctx context.Context, membership *types.TeamMembership) (*types.TeamMembership, error) {
+	if membership.TeamID == "" {
+		return nil, fmt.Errorf("team_id not specified")
+	}
+
+	if membership.UserID == "" {
+		return nil, fmt.Errorf("user_id not specified")
+	}
+
+	team, err := s.GetTeam(ctx, &GetTeamQuery{ID: membership.TeamID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team: %w", err)
+	}
+
+	// Only organization members can join the organization's teams
+	_, err = s.GetOrganizationMembership(ctx, team.OrganizationID, membership.UserID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("user '%s' is not a member of the organization", membership.UserID)
+		}
+		return nil, err
+	}
+
+	membership.OrganizationID = team.OrganizationID
+	membership.Created = time.Now()
+	membership.Updated = membership.Created
+
+	err = s.gdb.WithContext(ctx).Create(membership).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetTeamMembership(ctx, membership.TeamID, membership.UserID)
+}
+
+func (s *PostgresStore) GetTeamMembership(ctx context.Context, teamID, userID string) (*types.TeamMembership, error) {
+	if teamID == "" || userID == "" {
+		return nil, fmt.Errorf("team_id and user_id must be specified")
+	}
+
+	var membership types.TeamMembership
+	err := s.gdb.WithContext(ctx).Where("team_id = ? AND user_id = ?", teamID, userID).First(&membership).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &membership, nil
+}
+
+func (s *PostgresStore) ListTeamMemberships(ctx context.Context, q *ListTeamMembershipsQuery) ([]*types.TeamMembership, error) {
+	var memberships []*types.TeamMembership
+	err := s.gdb.WithContext(ctx).Where(&types.TeamMembership{
+		TeamID:         q.TeamID,
+		OrganizationID: q.OrganizationID,
+		UserID:         q.UserID,
+	}).Order("created ASC").Find(&memberships).Error
+	if err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+func (s *PostgresStore) DeleteTeamMembership(ctx context.Context, teamID, userID string) error {
+	if teamID == "" || userID == "" {
+		return fmt.Errorf("team_id and user_id must be specified")
+	}
+
+	return s.gdb.WithContext(ctx).Where("team_id = ? AND user_id = ?", teamID, userID).Delete(&types.TeamMembership{}).Error
+}
+
+// ownerCondition builds the ownership filter used by the list queries. It matches
+// resources owned directly by the owner and, when withOrganizations is set, also
+// resources owned by any organization or team the owner is a member of. It
+// follows the same rule as IsOwnerMember.
+func (s *PostgresStore) ownerCondition(owner string, ownerType types.OwnerType, withOrganizations bool) *gorm.DB {
+	direct := s.gdb.Where("owner = ?", owner)
+	if ownerType != "" {
+		direct = direct.Where("owner_type = ?", ownerType)
+	}
+
+	if !withOrganizations {
+		return direct
+	}
+
+	return s.gdb.Where(direct).
+		Or("owner_type = ? AND owner IN (?)", types.OwnerTypeOrg, s.memberOrganizations(owner)).
+		Or("owner_type = ? AND owner IN (?)", types.OwnerTypeTeam, s.memberTeams(owner))
+}
+
+// memberOrganizations selects the IDs of the organizations the user is a member of
+func (s *PostgresStore) memberOrganizations(userID string) *gorm.DB {
+	return s.gdb.Model(&types.OrganizationMembership{}).
+		Select("organization_id").
+		Where("user_id = ?", userID)
+}
+
+// memberTeams selects the IDs of the teams whose resources the user can see,
+// like IsOwnerMember: the teams the user is a member of in their
+// organizations, and every team of the organizations the user owns
+func (s *PostgresStore) memberTeams(userID string) *gorm.DB {
+	ownedOrganizations := s.gdb.Model(&types.OrganizationMembership{}).
+		Select("organization_id").
+		Where("user_id = ? AND role = ?", userID, types.OrganizationRoleOwner)
+
+	memberships := s.gdb.Model(&types.TeamMembership{}).
+		Select("team_id").
+		Where("user_id = ? AND organization_id IN (?)", userID, s.memberOrganizations(userID))
+
+	return s.gdb.Model(&types.Team{}).
+		Select("id").
+		Where("id IN (?)", memberships).
+		Or("organization_id IN (?)", ownedOrganizations)
+}
+
+// IsOwnerMember reports whether the user owns a resource directly or belongs
+// to the organization or team that owns it. Returned role is the user's
+// organization role, empty for directly owned resources.
+func IsOwnerMember(ctx context.Context, s Store, userID, owner string, ownerType types.OwnerType) (bool, types.OrganizationRole, error) {
+	if userID == "" || owner == "" {
+		return false, "", nil
+	}
+
+	switch ownerType {
+	case types.OwnerTypeOrg:
+		membership, err := s.GetOrganizationMembership(ctx, owner, userID)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return false, "", nil
+			}
+			return false, "", err
+		}
+		return true, membership.Role, nil
+	case types.OwnerTypeTeam:
+		team, err := s.GetTeam(ctx, &GetTeamQuery{ID: owner})
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return false, "", nil
+			}
+			return false, "", err
+		}
+
+		orgMembership, err := s.GetOrganizationMembership(ctx, team.OrganizationID, userID)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return false, "", nil
+			}
+			return false, "", err
+		}
+
+		// Organization owners can see all teams' resources
+		if orgMembership.Role == types.OrganizationRoleOwner {
+			return true, orgMembership.Role, nil
+		}
+
+		_, err = s.GetTeamMembership(ctx, owner, userID)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return false, "", nil
+			}
+			return false, "", err
+		}
+		return true, orgMembership.Role, nil
+	default:
+		return owner == userID, "", nil
+	}
+}
@@ -0,0 +1,271 @@
+package store
+
+import (
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *PostgresStoreTestSuite) createTestOrganization(owner string) *types.Organization {
+	org, err := suite.db.CreateOrganization(suite.ctx, &types.Organization{
+		Name:  "test-org-" + system.GenerateUUID(),
+		Owner: owner,
+	})
+	require.NoError(suite.T(), err)
+
+	suite.T().Cleanup(func() {
+		err := suite.db.DeleteOrganization(suite.ctx, org.ID)
+		assert.NoError(suite.T(), err)
+	})
+
+	return org
+}
+
+func (suite *PostgresStoreTestSuite) TestOrganizationCreate() {
+	owner := "test-owner-" + system.GenerateUUID()
+
+	org := suite.createTestOrganization(owner)
+	assert.NotEmpty(suite.T(), org.ID)
+	assert.Equal(suite.T(), owner, org.Owner)
+
+	// Owner should automatically become a member with the owner role
+	membership, err := suite.db.GetOrganizationMembership(suite.ctx, org.ID, owner)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), types.OrganizationRoleOwner, membership.Role)
+
+	// Names are unique
+	_, err = suite.db.CreateOrganization(suite.ctx, &types.Organization{
+		Name:  org.Name,
+		Owner: owner,
+	})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *PostgresStoreTestSuite) TestOrganizationListByMember() {
+	owner := "test-owner-" + system.GenerateUUID()
+	member := "test-member-" + system.GenerateUUID()
+
+	org := suite.createTestOrganization(owner)
+	_ = suite.createTestOrganization(owner)
+
+	_, err := suite.db.CreateOrganizationMembership(suite.ctx, &types.OrganizationMembership{
+		OrganizationID: org.ID,
+		UserID:         member,
+	})
+	require.NoError(suite.T(), err)
+
+	orgs, err := suite.db.ListOrganizations(suite.ctx, &ListOrganizationsQuery{UserID: owner})
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), orgs, 2)
+
+	orgs, err = suite.db.ListOrganizations(suite.ctx, &ListOrganizationsQuery{UserID: member})
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), orgs, 1)
+	assert.Equal(suite.T(), org.ID, orgs[0].ID)
+
+	err = suite.db.DeleteOrganizationMembership(suite.ctx, org.ID, member)
+	require.NoError(suite.T(), err)
+
+	orgs, err = suite.db.ListOrganizations(suite.ctx, &ListOrganizationsQuery{UserID: member})
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), orgs, 0)
+}
+
+func (suite *PostgresStoreTestSuite) TestTeamMembershipRequiresOrganizationMembership() {
+	owner := "test-owner-" + system.GenerateUUID()
+	outsider := "test-outsider-" + system.GenerateUUID()
+
+	org := suite.createTestOrganization(owner)
+
+	team, err := suite.db.CreateTeam(suite.ctx, &types.Team{
+		OrganizationID: org.ID,
+		Name:           "engineering",
+	})
+	require.NoError(suite.T(), err)
+
+	_, err = suite.db.CreateTeamMembership(suite.ctx, &types.TeamMembership{
+		TeamID: team.ID,
+		UserID: outsider,
+	})
+	assert.Error(suite.T(), err)
+
+	membership, err := suite.db.CreateTeamMembership(suite.ctx, &types.TeamMembership{
+		TeamID: team.ID,
+		UserID: owner,
+	})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), org.ID, membership.OrganizationID)
+
+	teams, err := suite.db.ListTeams(suite.ctx, &ListTeamsQuery{UserID: owner})
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), teams, 1)
+	assert.Equal(suite.T(), team.ID, teams[0].ID)
+}
+
+func (suite *PostgresStoreTestSuite) TestListWithOrganizations() {
+	owner := "test-owner-" + system.GenerateUUID()
+	member := "test-member-" + system.GenerateUUID()
+
+	org := suite.createTestOrganization(owner)
+
+	_, err := suite.db.CreateOrganizationMembership(suite.ctx, &types.OrganizationMembership{
+		OrganizationID: org.ID,
+		UserID:         member,
+	})
+	require.NoError(suite.T(), err)
+
+	orgApp, err := suite.db.CreateApp(suite.ctx, &types.App{
+		Owner:     org.ID,
+		OwnerType: types.OwnerTypeOrg,
+	})
+	require.NoError(suite.T(), err)
+
+	memberApp, err := suite.db.CreateApp(suite.ctx, &types.App{
+		Owner:     member,
+		OwnerType: types.OwnerTypeUser,
+	})
+	require.NoError(suite.T(), err)
+
+	orgSecret, err := suite.db.CreateSecret(suite.ctx, &types.Secret{
+		Name:      "org-secret",
+		Owner:     org.ID,
+		OwnerType: types.OwnerTypeOrg,
+		Value:     []byte("value"),
+	})
+	require.NoError(suite.T(), err)
+
+	suite.T().Cleanup(func() {
+		assert.NoError(suite.T(), suite.db.DeleteApp(suite.ctx, orgApp.ID))
+		assert.NoError(suite.T(), suite.db.DeleteApp(suite.ctx, memberApp.ID))
+		assert.NoError(suite.T(), suite.db.DeleteSecret(suite.ctx, orgSecret.ID))
+	})
+
+	// Without organizations only the personal app is returned
+	apps, err := suite.db.ListApps(suite.ctx, &ListAppsQuery{
+		Owner:     member,
+		OwnerType: types.OwnerTypeUser,
+	})
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), apps, 1)
+	assert.Equal(suite.T(), memberApp.ID, apps[0].ID)
+
+	apps, err = suite.db.ListApps(suite.ctx, &ListAppsQuery{
+		Owner:             member,
+		OwnerType:         types.OwnerTypeUser,
+		WithOrganizations: true,
+	})
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), apps, 2)
+
+	secrets, err := suite.db.ListSecrets(suite.ctx, &ListSecretsQuery{
+		Owner:             member,
+		OwnerType:         types.OwnerTypeUser,
+		WithOrganizations: true,
+	})
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), secrets, 1)
+	assert.Equal(suite.T(), orgSecret.ID, secrets[0].ID)
+
+	isMember, role, err := IsOwnerMember(suite.ctx, suite.db, member, org.ID, types.OwnerTypeOrg)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), isMember)
+	assert.Equal(suite.T(), types.OrganizationRoleMember, role)
+}
+
+func (suite *PostgresStoreTestSuite) TestListWithOrganizations_TeamResources() {
+	owner := "test-owner-" + system.GenerateUUID()
+	member := "test-member-" + system.GenerateUUID()
+	teamMember := "test-team-member-" + system.GenerateUUID()
+
+	org := suite.createTestOrganization(owner)
+
+	for _, userID := range []string{member, teamMember} {
+		_, err := suite.db.CreateOrganizationMembership(suite.ctx, &types.OrganizationMembership{
+			OrganizationID: org.ID,
+			UserID:         userID,
+		})
+		require.NoError(suite.T(), err)
+	}
+
+	team, err := suite.db.CreateTeam(suite.ctx, &types.Team{
+		OrganizationID: org.ID,
+		Name:           "engineering",
+	})
+	require.NoError(suite.T(), err)
+
+	_, err = suite.db.CreateTeamMembership(suite.ctx, &types.TeamMembership{
+		TeamID: team.ID,
+		UserID: teamMember,
+	})
+	require.NoError(suite.T(), err)
+
+	teamApp, err := suite.db.CreateApp(suite.ctx, &types.App{
+		Owner:     team.ID,
+		OwnerType: types.OwnerTypeTeam,
+	})
+	require.NoError(suite.T(), err)
+
+	teamEndpoint, err := suite.db.CreateProviderEndpoint(suite.ctx, &types.ProviderEndpoint{
+		Name:         "team-endpoint-" + system.GenerateUUID(),
+		BaseURL:      "https://api.example.com",
+		EndpointType: types.ProviderEndpointTypeTeam,
+		Owner:        team.ID,
+		OwnerType:    types.OwnerTypeTeam,
+	})
+	require.NoError(suite.T(), err)
+
+	suite.T().Cleanup(func() {
+		assert.NoError(suite.T(), suite.db.DeleteApp(suite.ctx, teamApp.ID))
+		assert.NoError(suite.T(), suite.db.DeleteProviderEndpoint(suite.ctx, teamEndpoint.ID))
+	})
+
+	// The lists and IsOwnerMember agree: team members and organization owners
+	// see the team resources, other organization members don't
+	for _, tc := range []struct {
+		userID  string
+		visible bool
+	}{
+		{userID: owner, visible: true},
+		{userID: teamMember, visible: true},
+		{userID: member, visible: false},
+	} {
+		apps, err := suite.db.ListApps(suite.ctx, &ListAppsQuery{
+			Owner:             tc.userID,
+			OwnerType:         types.OwnerTypeUser,
+			WithOrganizations: true,
+		})
+		require.NoError(suite.T(), err)
+		assert.Equal(suite.T(), tc.visible, containsApp(apps, teamApp.ID), tc.userID)
+
+		endpoints, err := suite.db.ListProviderEndpoints(suite.ctx, &ListProviderEndpointsQuery{
+			Owner:             tc.userID,
+			OwnerType:         types.OwnerTypeUser,
+			WithOrganizations: true,
+		})
+		require.NoError(suite.T(), err)
+		assert.Equal(suite.T(), tc.visible, containsEndpoint(endpoints, teamEndpoint.ID), tc.userID)
+
+		isMember, _, err := IsOwnerMember(suite.ctx, suite.db, tc.userID, team.ID, types.OwnerTypeTeam)
+		require.NoError(suite.T(), err)
+		assert.Equal(suite.T(), tc.visible, isMember, tc.userID)
+	}
+}
+
+func containsApp(apps []*types.App, id string) bool {
+	for _, app := range apps {
+		if app.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func containsEndpoint(endpoints []*types.ProviderEndpoint, id string) bool {
+	for _, endpoint := range endpoints {
+		if endpoint.ID == id {
+			return true
+		}
+	}
+	return false
+}
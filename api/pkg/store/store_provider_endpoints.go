@@ -89,7 +89,13 @@ func (s *PostgresStore) ListProviderEndpoints(ctx context.Context, q *ListProvid
 	var providerEndpoints []*types.ProviderEndpoint
 	query := s.gdb.Debug().WithContext(ctx)
 
-	query = query.Where("owner = ? AND endpoint_type = ?", q.Owner, types.ProviderEndpointTypeUser)
+	query = query.Where("owner = ? AND endpoint_type = ?", q.Owner, ownerEndpointType(q.OwnerType))
+
+	if q.WithOrganizations {
+		query = query.
+			Or("endpoint_type = ? AND owner IN (?)", types.ProviderEndpointTypeOrg, s.memberOrganizations(q.Owner)).
+			Or("endpoint_type = ? AND owner IN (?)", types.ProviderEndpointTypeTeam, s.memberTeams(q.Owner))
+	}
 
 	if q.WithGlobal {
 		query = query.Or("endpoint_type = ?", types.ProviderEndpointTypeGlobal)
@@ -102,6 +108,19 @@ func (s *PostgresStore) ListProviderEndpoints(ctx context.Context, q *ListProvid
 	return providerEndpoints, nil
 }
 
+// ownerEndpointType returns the type of the endpoints the owner has, the
+// owner is a user unless it's an organization or a team
+func ownerEndpointType(ownerType types.OwnerType) types.ProviderEndpointType {
+	switch ownerType {
+	case types.OwnerTypeOrg:
+		return types.ProviderEndpointTypeOrg
+	case types.OwnerTypeTeam:
+		return types.ProviderEndpointTypeTeam
+	default:
+		return types.ProviderEndpointTypeUser
+	}
+}
+
 func (s *PostgresStore) DeleteProviderEndpoint(ctx context.Context, id string) error {
 	err := s.gdb.WithContext(ctx).Delete(&types.ProviderEndpoint{
 		ID: id,
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+	"gorm.io/gorm"
+)
+
+func (s *PostgresStore) CreateProviderRoutingGroup(ctx context.Context, group *types.ProviderRoutingGroup) (*types.ProviderRoutingGroup, error) {
+	if group.ID == "" {
+		group.ID = system.GenerateProviderRoutingGroupID()
+	}
+
+	if group.Owner == "" {
+		return nil, fmt.Errorf("owner not specified")
+	}
+
+	if group.EndpointType == "" {
+		return nil, fmt.Errorf("endpoint type not specified")
+	}
+
+	group.Created = time.Now()
+
+	err := s.gdb.WithContext(ctx).Create(group).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetProviderRoutingGroup(ctx, &GetProviderRoutingGroupQuery{ID: group.ID})
+}
+
+func (s *PostgresStore) UpdateProviderRoutingGroup(ctx context.Context, group *types.ProviderRoutingGroup) (*types.ProviderRoutingGroup, error) {
+	if group.ID == "" {
+		return nil, fmt.Errorf("id not specified")
+	}
+
+	if group.Owner == "" {
+		return nil, fmt.Errorf("owner not specified")
+	}
+
+	if group.EndpointType == "" {
+		return nil, fmt.Errorf("endpoint type not specified")
+	}
+
+	group.Updated = time.Now()
+
+	err := s.gdb.WithContext(ctx).Save(group).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetProviderRoutingGroup(ctx, &GetProviderRoutingGroupQuery{ID: group.ID})
+}
+
+func (s *PostgresStore) GetProviderRoutingGroup(ctx context.Context, q *GetProviderRoutingGroupQuery) (*types.ProviderRoutingGroup, error) {
+	var group types.ProviderRoutingGroup
+	query := s.gdb.WithContext(ctx)
+
+	if q.ID != "" {
+		query = query.Where("id = ?", q.ID)
+	}
+
+	if q.Name != "" {
+		query = query.Where("name = ?", q.Name)
+	}
+
+	if q.Owner != "" {
+		query = query.Where("owner = ?", q.Owner)
+	}
+
+	err := query.First(&group).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+// ListProviderRoutingGroups returns the groups of the owner, like
+// ListProviderEndpoints it can include the organization and global groups
+func (s *PostgresStore) ListProviderRoutingGroups(ctx context.Context, q *ListProviderEndpointsQuery) ([]*types.ProviderRoutingGroup, error) {
+	var groups []*types.ProviderRoutingGroup
+	query := s.gdb.WithContext(ctx)
+
+	query = query.Where("owner = ? AND endpoint_type = ?", q.Owner, ownerEndpointType(q.OwnerType))
+
+	if q.WithOrganizations {
+		query = query.
+			Or("endpoint_type = ? AND owner IN (?)", types.ProviderEndpointTypeOrg, s.memberOrganizations(q.Owner)).
+			Or("endpoint_type = ? AND owner IN (?)", types.ProviderEndpointTypeTeam, s.memberTeams(q.Owner))
+	}
+
+	if q.WithGlobal {
+		query = query.Or("endpoint_type = ?", types.ProviderEndpointTypeGlobal)
+	}
+
+	err := query.Order("name").Find(&groups).Error
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (s *PostgresStore) DeleteProviderRoutingGroup(ctx context.Context, id string) error {
+	err := s.gdb.WithContext(ctx).Delete(&types.ProviderRoutingGroup{
+		ID: id,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *PostgresStoreTestSuite) TestProviderRoutingGroupCRUD() {
+	owner := "test-owner-" + system.GenerateUUID()
+
+	group := &types.ProviderRoutingGroup{
+		Name:         "group-" + system.GenerateUUID(),
+		Owner:        owner,
+		EndpointType: types.ProviderEndpointTypeUser,
+		Strategy:     types.ProviderRoutingStrategyPriority,
+		Members: types.ProviderRoutingGroupMembers{
+			{Provider: "openai", Priority: 1},
+			{Provider: "togetherai", Priority: 2},
+		},
+	}
+
+	created, err := suite.db.CreateProviderRoutingGroup(suite.ctx, group)
+	require.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), created.ID)
+	assert.NotZero(suite.T(), created.Created)
+	assert.Equal(suite.T(), group.Members, created.Members)
+
+	suite.T().Cleanup(func() {
+		err := suite.db.DeleteProviderRoutingGroup(suite.ctx, created.ID)
+		assert.NoError(suite.T(), err)
+	})
+
+	fetched, err := suite.db.GetProviderRoutingGroup(suite.ctx, &GetProviderRoutingGroupQuery{Name: group.Name, Owner: owner})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), created.ID, fetched.ID)
+
+	fetched.Strategy = types.ProviderRoutingStrategyLeastLatency
+	fetched.Members = append(fetched.Members, types.ProviderRoutingGroupMember{Provider: "vllm"})
+	updated, err := suite.db.UpdateProviderRoutingGroup(suite.ctx, fetched)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), types.ProviderRoutingStrategyLeastLatency, updated.Strategy)
+	assert.Len(suite.T(), updated.Members, 3)
+
+	groups, err := suite.db.ListProviderRoutingGroups(suite.ctx, &ListProviderEndpointsQuery{Owner: owner})
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), groups, 1)
+	assert.Equal(suite.T(), created.ID, groups[0].ID)
+
+	others, err := suite.db.ListProviderRoutingGroups(suite.ctx, &ListProviderEndpointsQuery{Owner: "another-owner-" + system.GenerateUUID()})
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), others)
+
+	_, err = suite.db.GetProviderRoutingGroup(suite.ctx, &GetProviderRoutingGroupQuery{ID: "prg_missing"})
+	assert.ErrorIs(suite.T(), err, ErrNotFound)
+}
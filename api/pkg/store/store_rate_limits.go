@@ -0,0 +1,138 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func (s *PostgresStore) CreateRateLimit(ctx context.Context, limit *types.RateLimit) (*types.RateLimit, error) {
+	if limit.ID == "" {
+		limit.ID = system.GenerateRateLimitID()
+	}
+
+	if limit.Scope == "" {
+		return nil, fmt.Errorf("scope not specified")
+	}
+
+	if limit.ScopeID == "" {
+		return nil, fmt.Errorf("scope id not specified")
+	}
+
+	limit.Created = time.Now()
+
+	err := s.gdb.WithContext(ctx).Create(limit).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetRateLimit(ctx, &GetRateLimitQuery{ID: limit.ID})
+}
+
+func (s *PostgresStore) UpdateRateLimit(ctx context.Context, limit *types.RateLimit) (*types.RateLimit, error) {
+	if limit.ID == "" {
+		return nil, fmt.Errorf("id not specified")
+	}
+
+	if limit.Scope == "" {
+		return nil, fmt.Errorf("scope not specified")
+	}
+
+	if limit.ScopeID == "" {
+		return nil, fmt.Errorf("scope id not specified")
+	}
+
+	limit.Updated = time.Now()
+
+	err := s.gdb.WithContext(ctx).Save(limit).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetRateLimit(ctx, &GetRateLimitQuery{ID: limit.ID})
+}
+
+func (s *PostgresStore) GetRateLimit(ctx context.Context, q *GetRateLimitQuery) (*types.RateLimit, error) {
+	var limit types.RateLimit
+	query := s.gdb.WithContext(ctx)
+
+	if q.ID != "" {
+		query = query.Where("id = ?", q.ID)
+	}
+
+	if q.Scope != "" {
+		query = query.Where("scope = ?", q.Scope)
+	}
+
+	if q.ScopeID != "" {
+		query = query.Where("scope_id = ?", q.ScopeID)
+	}
+
+	err := query.First(&limit).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &limit, nil
+}
+
+func (s *PostgresStore) ListRateLimits(ctx context.Context, q *ListRateLimitsQuery) ([]*types.RateLimit, error) {
+	var limits []*types.RateLimit
+	query := s.gdb.WithContext(ctx)
+
+	if q != nil && q.Scope != "" {
+		query = query.Where("scope = ?", q.Scope)
+	}
+
+	err := query.Order("scope, scope_id").Find(&limits).Error
+	if err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+func (s *PostgresStore) DeleteRateLimit(ctx context.Context, id string) error {
+	if id == "" {
+		return fmt.Errorf("id not specified")
+	}
+
+	return s.gdb.WithContext(ctx).Delete(&types.RateLimit{ID: id}).Error
+}
+
+// IncrementTokenUsage adds the tokens of the usage to the counter of its scope
+// and period, creating it if needed
+func (s *PostgresStore) IncrementTokenUsage(ctx context.Context, usage *types.TokenUsage) error {
+	usage.Updated = time.Now()
+
+	return s.gdb.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "scope"}, {Name: "scope_id"}, {Name: "period"}, {Name: "period_start"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"tokens":  gorm.Expr("token_usages.tokens + EXCLUDED.tokens"),
+				"updated": usage.Updated,
+			}),
+		}).
+		Create(usage).Error
+}
+
+func (s *PostgresStore) GetTokenUsage(ctx context.Context, q *GetTokenUsageQuery) (*types.TokenUsage, error) {
+	var usage types.TokenUsage
+
+	err := s.gdb.WithContext(ctx).
+		Where("scope = ? AND scope_id = ? AND period = ? AND period_start = ?", q.Scope, q.ScopeID, q.Period, q.PeriodStart).
+		First(&usage).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &usage, nil
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"time"
+
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *PostgresStoreTestSuite) TestRateLimitCRUD() {
+	limit := &types.RateLimit{
+		Scope:             types.RateLimitScopeUser,
+		ScopeID:           "user-" + system.GenerateUUID(),
+		RequestsPerMinute: 10,
+		TokensPerDay:      1000,
+	}
+
+	created, err := suite.db.CreateRateLimit(suite.ctx, limit)
+	require.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), created.ID)
+	assert.NotZero(suite.T(), created.Created)
+
+	suite.T().Cleanup(func() {
+		err := suite.db.DeleteRateLimit(suite.ctx, created.ID)
+		assert.NoError(suite.T(), err)
+	})
+
+	fetched, err := suite.db.GetRateLimit(suite.ctx, &GetRateLimitQuery{Scope: types.RateLimitScopeUser, ScopeID: limit.ScopeID})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), created.ID, fetched.ID)
+
+	fetched.TokensPerMonth = 10000
+	updated, err := suite.db.UpdateRateLimit(suite.ctx, fetched)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(10000), updated.TokensPerMonth)
+	assert.Equal(suite.T(), int64(10), updated.RequestsPerMinute)
+
+	// Only one limit per scope
+	_, err = suite.db.CreateRateLimit(suite.ctx, &types.RateLimit{Scope: types.RateLimitScopeUser, ScopeID: limit.ScopeID})
+	assert.Error(suite.T(), err)
+
+	limits, err := suite.db.ListRateLimits(suite.ctx, &ListRateLimitsQuery{Scope: types.RateLimitScopeUser})
+	require.NoError(suite.T(), err)
+	found := false
+	for _, l := range limits {
+		assert.Equal(suite.T(), types.RateLimitScopeUser, l.Scope)
+		if l.ID == created.ID {
+			found = true
+		}
+	}
+	assert.True(suite.T(), found)
+
+	err = suite.db.DeleteRateLimit(suite.ctx, created.ID)
+	require.NoError(suite.T(), err)
+
+	_, err = suite.db.GetRateLimit(suite.ctx, &GetRateLimitQuery{ID: created.ID})
+	assert.ErrorIs(suite.T(), err, ErrNotFound)
+}
+
+func (suite *PostgresStoreTestSuite) TestTokenUsageIncrement() {
+	day := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	scopeID := "app-" + system.GenerateUUID()
+
+	_, err := suite.db.GetTokenUsage(suite.ctx, &GetTokenUsageQuery{
+		Scope:       types.RateLimitScopeApp,
+		ScopeID:     scopeID,
+		Period:      types.RateLimitPeriodDay,
+		PeriodStart: day,
+	})
+	assert.ErrorIs(suite.T(), err, ErrNotFound)
+
+	for _, tokens := range []int64{100, 250} {
+		err := suite.db.IncrementTokenUsage(suite.ctx, &types.TokenUsage{
+			Scope:       types.RateLimitScopeApp,
+			ScopeID:     scopeID,
+			Period:      types.RateLimitPeriodDay,
+			PeriodStart: day,
+			Tokens:      tokens,
+		})
+		require.NoError(suite.T(), err)
+	}
+
+	usage, err := suite.db.GetTokenUsage(suite.ctx, &GetTokenUsageQuery{
+		Scope:       types.RateLimitScopeApp,
+		ScopeID:     scopeID,
+		Period:      types.RateLimitPeriodDay,
+		PeriodStart: day,
+	})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(350), usage.Tokens)
+
+	// The next day is counted separately
+	_, err = suite.db.GetTokenUsage(suite.ctx, &GetTokenUsageQuery{
+		Scope:       types.RateLimitScopeApp,
+		ScopeID:     scopeID,
+		Period:      types.RateLimitPeriodDay,
+		PeriodStart: day.AddDate(0, 0, 1),
+	})
+	assert.ErrorIs(suite.T(), err, ErrNotFound)
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func (s *PostgresStore) UpsertSchedulerWorkload(ctx context.Context, workload *types.SchedulerWorkload) error {
+	if workload.ID == "" {
+		return fmt.Errorf("id not specified")
+	}
+
+	if workload.Created.IsZero() {
+		workload.Created = time.Now()
+	}
+
+	return s.gdb.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(workload).Error
+}
+
+func (s *PostgresStore) ListSchedulerWorkloads(ctx context.Context) ([]*types.SchedulerWorkload, error) {
+	var workloads []*types.SchedulerWorkload
+	err := s.gdb.WithContext(ctx).
+		Order("created ASC").
+		Find(&workloads).Error
+	if err != nil {
+		return nil, err
+	}
+	return workloads, nil
+}
+
+func (s *PostgresStore) DeleteSchedulerWorkload(ctx context.Context, id string) error {
+	if id == "" {
+		return fmt.Errorf("id not specified")
+	}
+
+	return s.gdb.WithContext(ctx).Delete(&types.SchedulerWorkload{ID: id}).Error
+}
+
+func (s *PostgresStore) UpsertSchedulerSlot(ctx context.Context, slot *types.SchedulerSlot) error {
+	if slot.ID == "" {
+		return fmt.Errorf("id not specified")
+	}
+
+	if slot.Created.IsZero() {
+		slot.Created = time.Now()
+	}
+	slot.Updated = time.Now()
+
+	return s.gdb.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(slot).Error
+}
+
+func (s *PostgresStore) ListSchedulerSlots(ctx context.Context) ([]*types.SchedulerSlot, error) {
+	var slots []*types.SchedulerSlot
+	err := s.gdb.WithContext(ctx).
+		Order("created ASC").
+		Find(&slots).Error
+	if err != nil {
+		return nil, err
+	}
+	return slots, nil
+}
+
+func (s *PostgresStore) DeleteSchedulerSlot(ctx context.Context, id string) error {
+	if id == "" {
+		return fmt.Errorf("id not specified")
+	}
+
+	return s.gdb.WithContext(ctx).Delete(&types.SchedulerSlot{ID: id}).Error
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"github.com/google/uuid"
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *PostgresStoreTestSuite) TestSchedulerWorkloadCRUD() {
+	id := "req_" + system.GenerateUUID()
+
+	err := suite.db.UpsertSchedulerWorkload(suite.ctx, &types.SchedulerWorkload{
+		ID:       id,
+		Workload: []byte(`{"Session":null}`),
+	})
+	require.NoError(suite.T(), err)
+
+	// Upserting again updates the existing row
+	err = suite.db.UpsertSchedulerWorkload(suite.ctx, &types.SchedulerWorkload{
+		ID:       id,
+		Workload: []byte(`{"Session":{"id":"ses_1"}}`),
+	})
+	require.NoError(suite.T(), err)
+
+	workloads, err := suite.db.ListSchedulerWorkloads(suite.ctx)
+	require.NoError(suite.T(), err)
+
+	var found *types.SchedulerWorkload
+	for _, w := range workloads {
+		if w.ID == id {
+			found = w
+		}
+	}
+	require.NotNil(suite.T(), found)
+	assert.JSONEq(suite.T(), `{"Session":{"id":"ses_1"}}`, string(found.Workload))
+
+	err = suite.db.DeleteSchedulerWorkload(suite.ctx, id)
+	require.NoError(suite.T(), err)
+
+	workloads, err = suite.db.ListSchedulerWorkloads(suite.ctx)
+	require.NoError(suite.T(), err)
+	for _, w := range workloads {
+		assert.NotEqual(suite.T(), id, w.ID)
+	}
+}
+
+func (suite *PostgresStoreTestSuite) TestSchedulerSlotCRUD() {
+	id := uuid.New().String()
+
+	err := suite.db.UpsertSchedulerSlot(suite.ctx, &types.SchedulerSlot{
+		ID:       id,
+		RunnerID: "runner",
+		Workload: []byte(`{}`),
+	})
+	require.NoError(suite.T(), err)
+
+	err = suite.db.UpsertSchedulerSlot(suite.ctx, &types.SchedulerSlot{
+		ID:       id,
+		RunnerID: "runner",
+		Running:  true,
+		Workload: []byte(`{}`),
+	})
+	require.NoError(suite.T(), err)
+
+	slots, err := suite.db.ListSchedulerSlots(suite.ctx)
+	require.NoError(suite.T(), err)
+
+	var found *types.SchedulerSlot
+	for _, s := range slots {
+		if s.ID == id {
+			found = s
+		}
+	}
+	require.NotNil(suite.T(), found)
+	assert.True(suite.T(), found.Running)
+
+	err = suite.db.DeleteSchedulerSlot(suite.ctx, id)
+	require.NoError(suite.T(), err)
+}
@@ -81,10 +81,9 @@ func (s *PostgresStore) ListSecrets(ctx context.Context, q *ListSecretsQuery) ([
 	}
 
 	var secrets []*types.Secret
-	err := s.gdb.WithContext(ctx).Where(&types.Secret{
-		Owner:     q.Owner,
-		OwnerType: q.OwnerType,
-	}).Find(&secrets).Error
+	err := s.gdb.WithContext(ctx).
+		Where(s.ownerCondition(q.Owner, q.OwnerType, q.WithOrganizations)).
+		Find(&secrets).Error
 	if err != nil {
 		return nil, err
 	}
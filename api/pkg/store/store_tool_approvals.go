@@ -0,0 +1,151 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func (s *PostgresStore) CreateToolApproval(ctx context.Context, approval *types.ToolApproval) (*types.ToolApproval, error) {
+	if approval.ID == "" {
+		approval.ID = system.GenerateToolApprovalID()
+	}
+
+	if approval.SessionID == "" {
+		return nil, fmt.Errorf("session id not specified")
+	}
+
+	if approval.Owner == "" {
+		return nil, fmt.Errorf("owner not specified")
+	}
+
+	approval.Created = time.Now()
+	approval.Updated = approval.Created
+
+	err := s.gdb.WithContext(ctx).Create(approval).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetToolApproval(ctx, approval.ID)
+}
+
+// ErrToolApprovalNotPending is returned when the approval was decided or
+// expired before the update
+var ErrToolApprovalNotPending = errors.New("tool approval is not pending")
+
+// UpdateToolApproval records the decision or expiry of a pending approval.
+// The update only applies while the approval is still pending so concurrent
+// decisions can't both go through.
+func (s *PostgresStore) UpdateToolApproval(ctx context.Context, approval *types.ToolApproval) (*types.ToolApproval, error) {
+	if approval.ID == "" {
+		return nil, fmt.Errorf("id not specified")
+	}
+
+	approval.Updated = time.Now()
+
+	res := s.gdb.WithContext(ctx).
+		Model(&types.ToolApproval{}).
+		Where("id = ? AND status = ?", approval.ID, types.ToolApprovalStatusPending).
+		Updates(map[string]any{
+			"updated":    approval.Updated,
+			"status":     approval.Status,
+			"decided_by": approval.DecidedBy,
+			"decided_at": approval.DecidedAt,
+			"reason":     approval.Reason,
+		})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrToolApprovalNotPending
+	}
+	return s.GetToolApproval(ctx, approval.ID)
+}
+
+// ErrToolApprovalResumed is returned when the approved action was picked up
+// to run by someone else
+var ErrToolApprovalResumed = errors.New("tool approval is already resumed")
+
+// ResumeToolApproval marks the approved action as picked up to run. It only
+// applies if the action wasn't resumed since resumedBefore so the action runs
+// once, unless the process running it went away.
+func (s *PostgresStore) ResumeToolApproval(ctx context.Context, id string, resumedBefore time.Time) (*types.ToolApproval, error) {
+	if id == "" {
+		return nil, fmt.Errorf("id not specified")
+	}
+
+	now := time.Now()
+
+	res := s.gdb.WithContext(ctx).
+		Model(&types.ToolApproval{}).
+		Where("id = ? AND status = ?", id, types.ToolApprovalStatusApproved).
+		Where("resumed_at IS NULL OR resumed_at < ?", resumedBefore).
+		Updates(map[string]any{
+			"updated":    now,
+			"resumed_at": now,
+		})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrToolApprovalResumed
+	}
+	return s.GetToolApproval(ctx, id)
+}
+
+func (s *PostgresStore) GetToolApproval(ctx context.Context, id string) (*types.ToolApproval, error) {
+	if id == "" {
+		return nil, fmt.Errorf("id not specified")
+	}
+
+	var approval types.ToolApproval
+	err := s.gdb.WithContext(ctx).Where("id = ?", id).First(&approval).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &approval, nil
+}
+
+func (s *PostgresStore) ListToolApprovals(ctx context.Context, q *ListToolApprovalsQuery) ([]*types.ToolApproval, error) {
+	var approvals []*types.ToolApproval
+	query := s.gdb.WithContext(ctx)
+
+	if q != nil && q.User != "" {
+		query = query.Where("owner = ? OR ? = ANY(approvers)", q.User, q.User)
+	}
+
+	if q != nil && q.SessionID != "" {
+		query = query.Where("session_id = ?", q.SessionID)
+	}
+
+	if q != nil && q.Status != "" {
+		query = query.Where("status = ?", q.Status)
+	}
+
+	if q != nil && !q.ExpiresBefore.IsZero() {
+		query = query.Where("expires_at < ?", q.ExpiresBefore)
+	}
+
+	if q != nil && !q.DecidedAfter.IsZero() {
+		query = query.Where("decided_at > ?", q.DecidedAfter)
+	}
+
+	if q != nil && !q.ResumedBefore.IsZero() {
+		query = query.Where("resumed_at IS NULL OR resumed_at < ?", q.ResumedBefore)
+	}
+
+	err := query.Order("created DESC").Find(&approvals).Error
+	if err != nil {
+		return nil, err
+	}
+	return approvals, nil
+}
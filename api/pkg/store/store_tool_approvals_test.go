@@ -0,0 +1,123 @@
+package store
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/helixml/helix/api/pkg/system"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func (suite *PostgresStoreTestSuite) TestToolApprovalCRUD() {
+	sessionID := system.GenerateSessionID()
+
+	approval := &types.ToolApproval{
+		SessionID: sessionID,
+		Owner:     "user-" + system.GenerateUUID(),
+		ToolName:  "orders",
+		Action:    "deleteOrder",
+		Method:    "DELETE",
+		Approvers: []string{"approver-1"},
+		Status:    types.ToolApprovalStatusPending,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	created, err := suite.db.CreateToolApproval(suite.ctx, approval)
+	require.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), created.ID)
+	assert.NotZero(suite.T(), created.Created)
+	assert.Equal(suite.T(), []string{"approver-1"}, []string(created.Approvers))
+
+	pending, err := suite.db.ListToolApprovals(suite.ctx, &ListToolApprovalsQuery{
+		SessionID: sessionID,
+		Status:    types.ToolApprovalStatusPending,
+	})
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), pending, 1)
+	assert.Equal(suite.T(), created.ID, pending[0].ID)
+
+	// Approvers see the approvals of sessions they don't own
+	approverApprovals, err := suite.db.ListToolApprovals(suite.ctx, &ListToolApprovalsQuery{
+		User:      "approver-1",
+		SessionID: sessionID,
+	})
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), approverApprovals, 1)
+
+	decided := time.Now()
+	created.Status = types.ToolApprovalStatusApproved
+	created.DecidedBy = "approver-1"
+	created.DecidedAt = &decided
+
+	updated, err := suite.db.UpdateToolApproval(suite.ctx, created)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), types.ToolApprovalStatusApproved, updated.Status)
+	assert.Equal(suite.T(), "approver-1", updated.DecidedBy)
+	assert.NotNil(suite.T(), updated.DecidedAt)
+
+	// A concurrent decision doesn't override the first one
+	created.Status = types.ToolApprovalStatusDenied
+	created.DecidedBy = "user-2"
+	_, err = suite.db.UpdateToolApproval(suite.ctx, created)
+	assert.ErrorIs(suite.T(), err, ErrToolApprovalNotPending)
+
+	stored, err := suite.db.GetToolApproval(suite.ctx, created.ID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), types.ToolApprovalStatusApproved, stored.Status)
+	assert.Equal(suite.T(), "approver-1", stored.DecidedBy)
+
+	pending, err = suite.db.ListToolApprovals(suite.ctx, &ListToolApprovalsQuery{
+		SessionID: sessionID,
+		Status:    types.ToolApprovalStatusPending,
+	})
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), pending)
+
+	// The approved action is resumed once until the resume goes stale
+	resumed, err := suite.db.ResumeToolApproval(suite.ctx, created.ID, time.Now().Add(-time.Minute))
+	require.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), resumed.ResumedAt)
+
+	_, err = suite.db.ResumeToolApproval(suite.ctx, created.ID, time.Now().Add(-time.Minute))
+	assert.ErrorIs(suite.T(), err, ErrToolApprovalResumed)
+
+	resumable, err := suite.db.ListToolApprovals(suite.ctx, &ListToolApprovalsQuery{
+		SessionID:     sessionID,
+		Status:        types.ToolApprovalStatusApproved,
+		ResumedBefore: time.Now().Add(-time.Minute),
+	})
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), resumable)
+
+	_, err = suite.db.ResumeToolApproval(suite.ctx, created.ID, time.Now().Add(time.Minute))
+	require.NoError(suite.T(), err)
+
+	_, err = suite.db.GetToolApproval(suite.ctx, "tap_missing")
+	assert.ErrorIs(suite.T(), err, ErrNotFound)
+}
+
+func (suite *PostgresStoreTestSuite) TestListToolApprovals_ExpiresBefore() {
+	sessionID := system.GenerateSessionID()
+
+	for _, expiresAt := range []time.Time{time.Now().Add(-time.Minute), time.Now().Add(time.Hour)} {
+		_, err := suite.db.CreateToolApproval(suite.ctx, &types.ToolApproval{
+			SessionID: sessionID,
+			Owner:     "user-" + system.GenerateUUID(),
+			Action:    "deleteOrder",
+			Status:    types.ToolApprovalStatusPending,
+			ExpiresAt: expiresAt,
+		})
+		require.NoError(suite.T(), err)
+	}
+
+	expired, err := suite.db.ListToolApprovals(suite.ctx, &ListToolApprovalsQuery{
+		SessionID:     sessionID,
+		Status:        types.ToolApprovalStatusPending,
+		ExpiresBefore: time.Now(),
+	})
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), expired, 1)
+	assert.True(suite.T(), expired[0].ExpiresAt.Before(time.Now()))
+}
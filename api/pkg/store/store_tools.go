@@ -65,12 +65,20 @@ func (s *PostgresStore) GetTool(ctx context.Context, id string) (*types.Tool, er
 }
 
 func (s *PostgresStore) ListTools(ctx context.Context, q *ListToolsQuery) ([]*types.Tool, error) {
+	query := s.gdb.WithContext(ctx)
+
+	if q.Owner != "" {
+		query = query.Where(s.ownerCondition(q.Owner, q.OwnerType, q.WithOrganizations))
+	} else if q.OwnerType != "" {
+		query = query.Where("owner_type = ?", q.OwnerType)
+	}
+
+	if q.Global {
+		query = query.Where("global = ?", true)
+	}
+
 	var tools []*types.Tool
-	err := s.gdb.WithContext(ctx).Where(&types.Tool{
-		Owner:     q.Owner,
-		OwnerType: q.OwnerType,
-		Global:    q.Global,
-	}).Find(&tools).Error
+	err := query.Find(&tools).Error
 	if err != nil {
 		return nil, err
 	}
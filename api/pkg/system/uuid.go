@@ -10,19 +10,26 @@ import (
 )
 
 const (
-	ToolPrefix                = "tool_"
-	SessionPrefix             = "ses_"
-	AppPrefix                 = "app_"
-	GptScriptRunnerTaskPrefix = "gst_"
-	RequestPrefix             = "req_"
-	DataEntityPrefix          = "dent_"
-	LLMCallPrefix             = "llmc_"
-	KnowledgePrefix           = "kno_"
-	KnowledgeVersionPrefix    = "knov_"
-	SecretPrefix              = "sec_"
-	TestRunPrefix             = "testrun_"
-	OpenAIResponsePrefix      = "oai_"
-	ProviderEndpointPrefix    = "pe_"
+	ToolPrefix                 = "tool_"
+	SessionPrefix              = "ses_"
+	AppPrefix                  = "app_"
+	GptScriptRunnerTaskPrefix  = "gst_"
+	RequestPrefix              = "req_"
+	DataEntityPrefix           = "dent_"
+	LLMCallPrefix              = "llmc_"
+	KnowledgePrefix            = "kno_"
+	KnowledgeVersionPrefix     = "knov_"
+	SecretPrefix               = "sec_"
+	TestRunPrefix              = "testrun_"
+	OpenAIResponsePrefix       = "oai_"
+	ProviderEndpointPrefix     = "pe_"
+	ProviderRoutingGroupPrefix = "prg_"
+	OrganizationPrefix         = "org_"
+	TeamPrefix                 = "team_"
+	AccessGrantPrefix          = "acg_"
+	RateLimitPrefix            = "rl_"
+	ModelPricePrefix           = "mp_"
+	ToolApprovalPrefix         = "tap_"
 )
 
 func GenerateUUID() string {
@@ -95,3 +102,31 @@ func GenerateOpenAIResponseID() string {
 func GenerateProviderEndpointID() string {
 	return fmt.Sprintf("%s%s", ProviderEndpointPrefix, newID())
 }
+
+func GenerateProviderRoutingGroupID() string {
+	return fmt.Sprintf("%s%s", ProviderRoutingGroupPrefix, newID())
+}
+
+func GenerateOrganizationID() string {
+	return fmt.Sprintf("%s%s", OrganizationPrefix, newID())
+}
+
+func GenerateTeamID() string {
+	return fmt.Sprintf("%s%s", TeamPrefix, newID())
+}
+
+func GenerateAccessGrantID() string {
+	return fmt.Sprintf("%s%s", AccessGrantPrefix, newID())
+}
+
+func GenerateRateLimitID() string {
+	return fmt.Sprintf("%s%s", RateLimitPrefix, newID())
+}
+
+func GenerateModelPriceID() string {
+	return fmt.Sprintf("%s%s", ModelPricePrefix, newID())
+}
+
+func GenerateToolApprovalID() string {
+	return fmt.Sprintf("%s%s", ToolApprovalPrefix, newID())
+}
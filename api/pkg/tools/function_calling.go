@@ -0,0 +1,425 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/rs/zerolog/log"
+	openai "github.com/sashabaranov/go-openai"
+
+	oai "github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// Native function calling: instead of asking the model to answer with JSON
+// following our prompts, the tools are declared as functions with typed JSON
+// schemas and the model picks the action and its parameters with tool calls.
+// The prompt chain is used when the model or provider can't do tool calls.
+
+const (
+	// bodyParameter is the name of the function parameter holding the request
+	// body of the operation
+	bodyParameter = "body"
+
+	// maxSchemaDepth limits the nesting of the converted schemas, recursive
+	// schemas would never end otherwise
+	maxSchemaDepth = 8
+)
+
+const functionCallingSystemPrompt = `You are an AI assistant that can use the tools provided to fulfil the user's request. Only call a tool if the last user message asks for something one of the tools can do or look up, NEVER invent tools. If no tool matches, answer without calling any tool and explain why.`
+
+const functionParametersSystemPrompt = `You are an intelligent machine learning model that calls REST APIs. Call the function with the parameters for the last user message, taken from the conversation. Leave out optional parameters the user didn't mention and use sensible defaults for the required ones.`
+
+// invalidFunctionNameChars matches the characters that aren't allowed in the
+// names of the functions
+var invalidFunctionNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+func functionName(name string) string {
+	name = invalidFunctionNameChars.ReplaceAllString(name, "_")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// toolFunctions converts the tools into function definitions, API tools are
+// declared with one function per action. It returns the definitions and the
+// action each function name maps to. Actions whose function names collide
+// can't be told apart from the call, they are rejected and the prompts are
+// used instead.
+func toolFunctions(tools []*types.Tool) ([]openai.Tool, map[string]string, error) {
+	var (
+		functions []openai.Tool
+		actions   = make(map[string]string)
+	)
+
+	add := func(action string, definition *openai.FunctionDefinition) error {
+		definition.Name = functionName(action)
+		if existing, ok := actions[definition.Name]; ok {
+			return fmt.Errorf("actions %s and %s have the same function name %s", existing, action, definition.Name)
+		}
+		actions[definition.Name] = action
+		functions = append(functions, openai.Tool{
+			Type:     openai.ToolTypeFunction,
+			Function: definition,
+		})
+		return nil
+	}
+
+	for _, tool := range tools {
+		switch tool.ToolType {
+		case types.ToolTypeAPI:
+			definitions, err := apiFunctions(tool)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to convert tool %s: %w", tool.Name, err)
+			}
+			for _, action := range tool.Config.API.Actions {
+				if err := add(action.Name, definitions[action.Name]); err != nil {
+					return nil, nil, err
+				}
+			}
+		case types.ToolTypeGPTScript, types.ToolTypeZapier:
+			// These tools take the conversation as their input
+			err := add(tool.Name, &openai.FunctionDefinition{
+				Description: tool.Description,
+				Parameters:  emptyParameters(),
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+		case types.ToolTypeMCP:
+			// The tools listed by the MCP server
+			if tool.Config.MCP == nil {
+				continue
+			}
+			for _, mcpTool := range tool.Config.MCP.Tools {
+				if err := add(mcpTool.Name, mcpFunction(mcpTool)); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+
+	return functions, actions, nil
+}
+
+// apiFunctions returns the function definitions of the actions of the API
+// tool, keyed by action
+func apiFunctions(tool *types.Tool) (map[string]*openai.FunctionDefinition, error) {
+	if tool.Config.API == nil {
+		return nil, fmt.Errorf("tool does not have an API config")
+	}
+
+	schema, err := openapi3.NewLoader().LoadFromData([]byte(tool.Config.API.Schema))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load openapi spec: %w", err)
+	}
+
+	definitions := make(map[string]*openai.FunctionDefinition)
+
+	for _, action := range tool.Config.API.Actions {
+		operation := findOperation(schema, action.Name)
+		if operation == nil {
+			return nil, fmt.Errorf("failed to find operation for action %s", action.Name)
+		}
+
+		definitions[action.Name] = &openai.FunctionDefinition{
+			Name:        functionName(action.Name),
+			Description: action.Description,
+			Parameters:  functionParameters(operation),
+		}
+	}
+
+	return definitions, nil
+}
+
+func emptyParameters() map[string]any {
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{},
+	}
+}
+
+// functionParameters returns the JSON schema of the function parameters of
+// the operation: its path, query and header parameters and the request body
+func functionParameters(operation *apiOperation) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+
+	for _, param := range operation.parameters {
+		if param.Value == nil || param.Value.In == openapi3.ParameterInCookie {
+			continue
+		}
+
+		property := jsonSchema(param.Value.Schema, 0)
+		if param.Value.Description != "" {
+			property["description"] = param.Value.Description
+		}
+		properties[param.Value.Name] = property
+
+		if param.Value.Required {
+			required = append(required, param.Value.Name)
+		}
+	}
+
+	if body, _, media := operation.requestBody(); body != nil {
+		property := jsonSchema(media.Schema, 0)
+		if body.Description != "" {
+			property["description"] = body.Description
+		}
+		properties[bodyParameter] = property
+
+		if body.Required {
+			required = append(required, bodyParameter)
+		}
+	}
+
+	parameters := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		parameters["required"] = required
+	}
+	return parameters
+}
+
+// jsonSchema converts the OpenAPI schema to a plain JSON schema, the
+// references are resolved as the model doesn't see the components
+func jsonSchema(ref *openapi3.SchemaRef, depth int) map[string]any {
+	if ref == nil || ref.Value == nil {
+		return map[string]any{"type": "string"}
+	}
+	if depth > maxSchemaDepth {
+		return map[string]any{"type": "object"}
+	}
+
+	s := ref.Value
+	converted := make(map[string]any)
+
+	switch schemaTypes := s.Type.Slice(); len(schemaTypes) {
+	case 0:
+	case 1:
+		converted["type"] = schemaTypes[0]
+	default:
+		converted["type"] = schemaTypes
+	}
+
+	if s.Description != "" {
+		converted["description"] = s.Description
+	}
+	if s.Format != "" {
+		converted["format"] = s.Format
+	}
+	if len(s.Enum) > 0 {
+		converted["enum"] = s.Enum
+	}
+	if s.Default != nil {
+		converted["default"] = s.Default
+	}
+	if s.Min != nil {
+		converted["minimum"] = *s.Min
+	}
+	if s.Max != nil {
+		converted["maximum"] = *s.Max
+	}
+	if s.Items != nil {
+		converted["items"] = jsonSchema(s.Items, depth+1)
+	}
+
+	properties := make(map[string]any)
+	required := append([]string{}, s.Required...)
+	for name, property := range s.Properties {
+		properties[name] = jsonSchema(property, depth+1)
+	}
+	// The properties of all the schemas apply
+	for _, all := range s.AllOf {
+		merged := jsonSchema(all, depth+1)
+		if mergedProperties, ok := merged["properties"].(map[string]any); ok {
+			for name, property := range mergedProperties {
+				properties[name] = property
+			}
+		}
+		if mergedRequired, ok := merged["required"].([]string); ok {
+			required = append(required, mergedRequired...)
+		}
+	}
+	if len(properties) > 0 {
+		converted["properties"] = properties
+		if _, ok := converted["type"]; !ok {
+			converted["type"] = "object"
+		}
+	}
+	if len(required) > 0 {
+		converted["required"] = required
+	}
+
+	var anyOf []any
+	for _, one := range append(s.OneOf, s.AnyOf...) {
+		anyOf = append(anyOf, jsonSchema(one, depth+1))
+	}
+	if len(anyOf) > 0 {
+		converted["anyOf"] = anyOf
+	}
+
+	return converted
+}
+
+// isActionableFunctionCalling lets the model pick the action by calling one
+// of the functions the tools are declared as
+func (c *ChainStrategy) isActionableFunctionCalling(ctx context.Context, client oai.Client, model string, tools []*types.Tool, history []*types.ToolHistoryMessage) (*IsActionableResponse, error) {
+	functions, actions, err := toolFunctions(tools)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: functionCallingSystemPrompt,
+		},
+	}
+	for _, msg := range history {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    msg.Role,
+			Content: msg.Content,
+		})
+	}
+
+	resp, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:      model,
+		Messages:   messages,
+		Tools:      functions,
+		ToolChoice: "auto",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get response from inference API: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from inference API")
+	}
+	msg := resp.Choices[0].Message
+
+	if len(msg.ToolCalls) == 0 {
+		return &IsActionableResponse{
+			NeedsTool:     NeedsToolNo,
+			Justification: msg.Content,
+		}, nil
+	}
+
+	call := msg.ToolCalls[0]
+	action, ok := actions[call.Function.Name]
+	if !ok {
+		return nil, fmt.Errorf("model called unknown function %s", call.Function.Name)
+	}
+
+	// The arguments of the call are the parameters of the action
+	arguments, err := paramsFromArguments(call.Function.Arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	justification := msg.Content
+	if justification == "" {
+		justification = fmt.Sprintf("The model called the %s function", action)
+	}
+
+	return &IsActionableResponse{
+		NeedsTool:     NeedsToolYes,
+		API:           action,
+		Justification: justification,
+		Arguments:     arguments,
+	}, nil
+}
+
+// getAPIRequestParametersFunctionCalling makes the model call the function of
+// the action, the parameters are the arguments of the call
+func (c *ChainStrategy) getAPIRequestParametersFunctionCalling(ctx context.Context, client oai.Client, model string, tool *types.Tool, history []*types.ToolHistoryMessage, action string) (map[string]any, error) {
+	definitions, err := apiFunctions(tool)
+	if err != nil {
+		return nil, err
+	}
+	definition, ok := definitions[action]
+	if !ok {
+		return nil, fmt.Errorf("action %s is not found in the tool %s", action, tool.Name)
+	}
+
+	return c.getFunctionArguments(ctx, client, model, definition, history)
+}
+
+// getFunctionArguments makes the model call the function, the arguments are
+// taken from the conversation
+func (c *ChainStrategy) getFunctionArguments(ctx context.Context, client oai.Client, model string, definition *openai.FunctionDefinition, history []*types.ToolHistoryMessage) (map[string]any, error) {
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: functionParametersSystemPrompt,
+		},
+	}
+	for _, msg := range history {
+		if msg.Role != openai.ChatMessageRoleSystem {
+			messages = append(messages, openai.ChatCompletionMessage{
+				Role:    msg.Role,
+				Content: msg.Content,
+			})
+		}
+	}
+
+	resp, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:    model,
+		Messages: messages,
+		Tools: []openai.Tool{
+			{Type: openai.ToolTypeFunction, Function: definition},
+		},
+		ToolChoice: openai.ToolChoice{
+			Type:     openai.ToolTypeFunction,
+			Function: openai.ToolFunction{Name: definition.Name},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get response from inference API: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from inference API")
+	}
+
+	for _, call := range resp.Choices[0].Message.ToolCalls {
+		if call.Function.Name == definition.Name {
+			return paramsFromArguments(call.Function.Arguments)
+		}
+	}
+
+	return nil, fmt.Errorf("model did not call the %s function", definition.Name)
+}
+
+// paramsFromArguments parses the arguments of a function call into request
+// parameters
+func paramsFromArguments(arguments string) (map[string]any, error) {
+	params := make(map[string]any)
+	if strings.TrimSpace(arguments) == "" {
+		return params, nil
+	}
+
+	if err := json.Unmarshal([]byte(arguments), &params); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal function arguments: %w (%s)", err, arguments)
+	}
+
+	return params, nil
+}
+
+// logFunctionCallingFallback logs why the prompt chain is used instead of the
+// function calling
+func logFunctionCallingFallback(err error, step types.LLMCallStep, started time.Time) {
+	log.Warn().
+		Err(err).
+		Str("step", string(step)).
+		Dur("time_taken", time.Since(started)).
+		Msg("function calling failed, falling back to prompt based tool use")
+}
@@ -0,0 +1,261 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	openai_ext "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func petStoreTool(t *testing.T) *types.Tool {
+	actions, err := GetActionsFromSchema(petStoreAPISpec)
+	require.NoError(t, err)
+
+	return &types.Tool{
+		Name:     "petStore",
+		ToolType: types.ToolTypeAPI,
+		Config: types.ToolConfig{
+			API: &types.ToolAPIConfig{
+				URL:     "http://petstore.swagger.io/v1",
+				Schema:  petStoreAPISpec,
+				Actions: actions,
+			},
+		},
+	}
+}
+
+func newFunctionCallingStrategy(t *testing.T, client openai.Client) *ChainStrategy {
+	cfg := &config.ServerConfig{}
+	cfg.Tools.Model = "gpt-4o"
+	cfg.Tools.NativeToolCalling = true
+
+	strategy, err := NewChainStrategy(cfg, nil, nil, client)
+	require.NoError(t, err)
+	return strategy
+}
+
+func Test_apiFunctions(t *testing.T) {
+	definitions, err := apiFunctions(petStoreTool(t))
+	require.NoError(t, err)
+	require.Len(t, definitions, 3)
+
+	require.Equal(t, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"limit": map[string]any{
+				"type":        "integer",
+				"format":      "int32",
+				"maximum":     float64(100),
+				"description": "How many items to return at one time (max 100)",
+			},
+		},
+	}, definitions["listPets"].Parameters)
+
+	require.Equal(t, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"petId": map[string]any{
+				"type":        "string",
+				"description": "The id of the pet to retrieve",
+			},
+		},
+		"required": []string{"petId"},
+	}, definitions["showPetById"].Parameters)
+
+	// The request body references are resolved
+	require.Equal(t, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"body": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"id":   map[string]any{"type": "integer", "format": "int64"},
+					"name": map[string]any{"type": "string"},
+					"tag":  map[string]any{"type": "string"},
+				},
+				"required": []string{"id", "name"},
+			},
+		},
+		"required": []string{"body"},
+	}, definitions["createPets"].Parameters)
+}
+
+func Test_toolFunctions(t *testing.T) {
+	functions, actions, err := toolFunctions([]*types.Tool{
+		petStoreTool(t),
+		{
+			Name:        "echo.gpt",
+			Description: "Echoes the input",
+			ToolType:    types.ToolTypeGPTScript,
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, functions, 4)
+	require.Equal(t, "echo.gpt", actions["echo_gpt"])
+	require.Equal(t, "showPetById", actions["showPetById"])
+}
+
+func Test_toolFunctions_Collision(t *testing.T) {
+	// Both actions are declared as the list_pets function
+	_, _, err := toolFunctions([]*types.Tool{
+		{Name: "list.pets", ToolType: types.ToolTypeGPTScript},
+		{Name: "list pets", ToolType: types.ToolTypeZapier},
+	})
+	require.ErrorContains(t, err, "list_pets")
+}
+
+func Test_paramsFromArguments(t *testing.T) {
+	params, err := paramsFromArguments(`{"petId": "55443", "limit": 10, "verbose": true, "tags": ["a", "b"]}`)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"petId":   "55443",
+		"limit":   float64(10),
+		"verbose": true,
+		"tags":    []any{"a", "b"},
+	}, params)
+
+	_, err = paramsFromArguments(`not json`)
+	require.Error(t, err)
+}
+
+func Test_IsActionable_FunctionCalling(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := openai.NewMockClient(ctrl)
+	strategy := newFunctionCallingStrategy(t, client)
+
+	client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, req openai_ext.ChatCompletionRequest) (openai_ext.ChatCompletionResponse, error) {
+			require.Equal(t, "gpt-4o", req.Model)
+			require.Len(t, req.Tools, 3)
+			require.Equal(t, "auto", req.ToolChoice)
+			require.Equal(t, "Can you please give me the details for pet 55443?", req.Messages[len(req.Messages)-1].Content)
+
+			return openai_ext.ChatCompletionResponse{
+				Choices: []openai_ext.ChatCompletionChoice{
+					{
+						Message: openai_ext.ChatCompletionMessage{
+							ToolCalls: []openai_ext.ToolCall{
+								{ID: "call_1", Function: openai_ext.FunctionCall{Name: "showPetById", Arguments: `{"petId":"55443"}`}},
+							},
+						},
+					},
+				},
+			}, nil
+		})
+
+	resp, err := strategy.IsActionable(context.Background(), "session-123", "i-123", []*types.Tool{petStoreTool(t)}, []*types.ToolHistoryMessage{
+		{Role: openai_ext.ChatMessageRoleUser, Content: "Can you please give me the details for pet 55443?"},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Actionable())
+	require.Equal(t, "showPetById", resp.API)
+	require.Equal(t, map[string]any{"petId": "55443"}, resp.Arguments)
+}
+
+func Test_IsActionable_FunctionCalling_NotActionable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := openai.NewMockClient(ctrl)
+	strategy := newFunctionCallingStrategy(t, client)
+
+	client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(openai_ext.ChatCompletionResponse{
+		Choices: []openai_ext.ChatCompletionChoice{
+			{Message: openai_ext.ChatCompletionMessage{Content: "None of the tools can tell jokes"}},
+		},
+	}, nil)
+
+	resp, err := strategy.IsActionable(context.Background(), "session-123", "i-123", []*types.Tool{petStoreTool(t)}, []*types.ToolHistoryMessage{
+		{Role: openai_ext.ChatMessageRoleUser, Content: "Tell me a joke"},
+	})
+	require.NoError(t, err)
+	require.False(t, resp.Actionable())
+	require.Equal(t, "None of the tools can tell jokes", resp.Justification)
+}
+
+func Test_IsActionable_FunctionCalling_Fallback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := openai.NewMockClient(ctrl)
+	strategy := newFunctionCallingStrategy(t, client)
+
+	// The model doesn't support tools, the prompt is used instead
+	gomock.InOrder(
+		client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, req openai_ext.ChatCompletionRequest) (openai_ext.ChatCompletionResponse, error) {
+				require.NotEmpty(t, req.Tools)
+				return openai_ext.ChatCompletionResponse{}, errors.New("model does not support tools")
+			}),
+		client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, req openai_ext.ChatCompletionRequest) (openai_ext.ChatCompletionResponse, error) {
+				require.Empty(t, req.Tools)
+				return openai_ext.ChatCompletionResponse{
+					Choices: []openai_ext.ChatCompletionChoice{
+						{Message: openai_ext.ChatCompletionMessage{Content: `{"needs_tool": "yes", "api": "listPets", "justification": "listing pets"}`}},
+					},
+				}, nil
+			}),
+	)
+
+	resp, err := strategy.IsActionable(context.Background(), "session-123", "i-123", []*types.Tool{petStoreTool(t)}, []*types.ToolHistoryMessage{
+		{Role: openai_ext.ChatMessageRoleUser, Content: "List all the pets"},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Actionable())
+	require.Equal(t, "listPets", resp.API)
+}
+
+func Test_getAPIRequestParameters_FunctionCalling(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := openai.NewMockClient(ctrl)
+	strategy := newFunctionCallingStrategy(t, client)
+
+	client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, req openai_ext.ChatCompletionRequest) (openai_ext.ChatCompletionResponse, error) {
+			require.Len(t, req.Tools, 1)
+			require.Equal(t, "listPets", req.Tools[0].Function.Name)
+			require.Equal(t, openai_ext.ToolChoice{
+				Type:     openai_ext.ToolTypeFunction,
+				Function: openai_ext.ToolFunction{Name: "listPets"},
+			}, req.ToolChoice)
+
+			return openai_ext.ChatCompletionResponse{
+				Choices: []openai_ext.ChatCompletionChoice{
+					{
+						Message: openai_ext.ChatCompletionMessage{
+							ToolCalls: []openai_ext.ToolCall{
+								{ID: "call_1", Function: openai_ext.FunctionCall{Name: "listPets", Arguments: `{"limit": 5}`}},
+							},
+						},
+					},
+				},
+			}, nil
+		})
+
+	params, err := strategy.getAPIRequestParameters(context.Background(), strategy.getDefaultOptions(), "session-123", "i-123", petStoreTool(t), []*types.ToolHistoryMessage{
+		{Role: openai_ext.ChatMessageRoleUser, Content: "List 5 pets"},
+	}, "listPets")
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"limit": float64(5)}, params)
+}
+
+func Test_getAPIRequestParameters_Arguments(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := openai.NewMockClient(ctrl)
+	strategy := newFunctionCallingStrategy(t, client)
+
+	// The arguments of the call that picked the action are used, the model
+	// isn't called again
+	opts := strategy.getDefaultOptions()
+	require.NoError(t, WithArguments(map[string]any{"limit": float64(5)})(&opts))
+
+	params, err := strategy.getAPIRequestParameters(context.Background(), opts, "session-123", "i-123", petStoreTool(t), []*types.ToolHistoryMessage{
+		{Role: openai_ext.ChatMessageRoleUser, Content: "List 5 pets"},
+	}, "listPets")
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"limit": float64(5)}, params)
+}
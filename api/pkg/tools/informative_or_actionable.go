@@ -24,6 +24,9 @@ type IsActionableResponse struct {
 	NeedsTool     string `json:"needs_tool"`
 	API           string `json:"api"`
 	Justification string `json:"justification"`
+	// Arguments are the arguments of the function call when the action was
+	// picked with function calling, the action runs with them
+	Arguments map[string]any `json:"-"`
 }
 
 func (i *IsActionableResponse) Actionable() bool {
@@ -53,6 +56,7 @@ func (c *ChainStrategy) getDefaultOptions() Options {
 	return Options{
 		isActionableTemplate: c.isActionableTemplate,
 		client:               c.apiClient,
+		nativeToolCalling:    c.cfg.Tools.NativeToolCalling,
 	}
 }
 
@@ -76,6 +80,9 @@ func (c *ChainStrategy) isActionable(ctx context.Context, sessionID, interaction
 
 	started := time.Now()
 
+	// The actions of the MCP tools are the tools their servers list
+	c.loadMCPTools(ctx, opts, tools)
+
 	systemPrompt, err := c.getActionableSystemPrompt(tools, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare system prompt: %w", err)
@@ -135,6 +142,22 @@ func (c *ChainStrategy) isActionable(ctx context.Context, sessionID, interaction
 		Step: types.LLMCallStepIsActionable,
 	})
 
+	if opts.nativeToolCalling {
+		actionableResponse, err := c.isActionableFunctionCalling(ctx, opts.client, req.Model, tools, history)
+		if err == nil {
+			log.Info().
+				Str("history", fmt.Sprintf("%+v", history)).
+				Str("justification", actionableResponse.Justification).
+				Str("needs_tool", actionableResponse.NeedsTool).
+				Str("chosen_tool", actionableResponse.API).
+				Dur("time_taken", time.Since(started)).
+				Msg("is_actionable")
+
+			return actionableResponse, nil
+		}
+		logFunctionCallingFallback(err, types.LLMCallStepIsActionable, started)
+	}
+
 	resp, err := opts.client.CreateChatCompletion(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get response from inference API: %w", err)
@@ -197,6 +220,18 @@ func (c *ChainStrategy) getActionableSystemPrompt(tools []*types.Tool, options O
 				Description: tool.Description,
 				ToolType:    string(tool.ToolType),
 			})
+		case types.ToolTypeMCP:
+			// Each tool of the MCP server is an action
+			if tool.Config.MCP == nil {
+				continue
+			}
+			for _, mcpTool := range tool.Config.MCP.Tools {
+				modelTools = append(modelTools, &modelTool{
+					Name:        mcpTool.Name,
+					Description: mcpTool.Description,
+					ToolType:    string(tool.ToolType),
+				})
+			}
 		}
 
 	}
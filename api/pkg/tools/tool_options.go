@@ -10,7 +10,10 @@ type Options struct {
 	isActionableTemplate string
 	model                string
 	client               openai.Client
-	//owner               string // For later
+	nativeToolCalling    bool
+	owner                string
+	appID                string
+	arguments            map[string]any
 }
 
 func WithIsActionableTemplate(isActionableTemplate string) Option {
@@ -33,3 +36,39 @@ func WithClient(client openai.Client) Option {
 		return nil
 	}
 }
+
+// WithNativeToolCalling uses the native function calling of the model to pick
+// the action and its parameters instead of the prompts
+func WithNativeToolCalling(nativeToolCalling bool) Option {
+	return func(o *Options) error {
+		o.nativeToolCalling = nativeToolCalling
+		return nil
+	}
+}
+
+// WithOwner sets the user the actions run for, their secrets only hold the
+// OAuth tokens of their own accounts
+func WithOwner(owner string) Option {
+	return func(o *Options) error {
+		o.owner = owner
+		return nil
+	}
+}
+
+// WithAppID sets the app the tools belong to, the shared credentials of the
+// tools are read from the secrets of the app owner
+func WithAppID(appID string) Option {
+	return func(o *Options) error {
+		o.appID = appID
+		return nil
+	}
+}
+
+// WithArguments sets the arguments the action runs with, they are the ones of
+// the function call that picked the action so the model isn't asked again
+func WithArguments(arguments map[string]any) Option {
+	return func(o *Options) error {
+		o.arguments = arguments
+		return nil
+	}
+}
@@ -18,6 +18,8 @@ import (
 	"github.com/helixml/helix/api/pkg/types"
 )
 
+//go:generate mockgen -source $GOFILE -destination tools_mocks.go -package $GOPACKAGE
+
 // TODO: probably move planner into a separate package so we can decide when we want to call APIs, when to go with RAG, etc.
 type Planner interface {
 	IsActionable(ctx context.Context, sessionID, interactionID string, tools []*types.Tool, history []*types.ToolHistoryMessage, options ...Option) (*IsActionableResponse, error)
@@ -27,9 +29,9 @@ type Planner interface {
 	// Validation and defaulting
 	ValidateAndDefault(ctx context.Context, tool *types.Tool) (*types.Tool, error)
 
-	// Low level methods for Model Context Protocol (MCP)
+	// Low level methods for Model Context Protocol (MCP), runs the API,
+	// GPTScript, Zapier and MCP tools
 	RunAPIActionWithParameters(ctx context.Context, req *types.RunAPIActionRequest, options ...Option) (*types.RunAPIActionResponse, error)
-	// TODO: GPTScript, Zapier.
 }
 
 // Static check
@@ -44,6 +46,18 @@ type ChainStrategy struct {
 	gptScriptExecutor    gptscript.Executor
 	isActionableTemplate string
 	wg                   sync.WaitGroup
+
+	tokenSourcesMu sync.Mutex
+	tokenSources   map[string]*tokenSourceCacheEntry // OAuth2 client credentials, keyed by client
+
+	secretLocksMu sync.Mutex
+	secretLocks   map[string]*secretLock // Serializes the refresh of user tokens, keyed by secret ID
+
+	mcpToolsMu sync.Mutex
+	mcpTools   map[string]*mcpToolsCacheEntry // Tools listed by the MCP servers, keyed by server
+
+	mcpSessionsMu sync.Mutex
+	mcpSessions   map[string]*mcpPooledSession // Open sessions with the MCP servers, keyed by server
 }
 
 func NewChainStrategy(cfg *config.ServerConfig, store store.Store, gptScriptExecutor gptscript.Executor, client openai.Client) (*ChainStrategy, error) {
@@ -62,6 +76,10 @@ func NewChainStrategy(cfg *config.ServerConfig, store store.Store, gptScriptExec
 		gptScriptExecutor:    gptScriptExecutor,
 		httpClient:           retryClient.StandardClient(),
 		isActionableTemplate: isActionableTemplate,
+		tokenSources:         make(map[string]*tokenSourceCacheEntry),
+		secretLocks:          make(map[string]*secretLock),
+		mcpTools:             make(map[string]*mcpToolsCacheEntry),
+		mcpSessions:          make(map[string]*mcpPooledSession),
 	}, nil
 }
 
@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	oai "github.com/helixml/helix/api/pkg/openai"
@@ -16,7 +18,7 @@ import (
 	openai "github.com/sashabaranov/go-openai"
 )
 
-func (c *ChainStrategy) prepareRequest(ctx context.Context, tool *types.Tool, action string, params map[string]string) (*http.Request, error) {
+func (c *ChainStrategy) prepareRequest(ctx context.Context, tool *types.Tool, action string, params map[string]any) (*http.Request, error) {
 	loader := openapi3.NewLoader()
 
 	schema, err := loader.LoadFromData([]byte(tool.Config.API.Schema))
@@ -25,38 +27,30 @@ func (c *ChainStrategy) prepareRequest(ctx context.Context, tool *types.Tool, ac
 	}
 
 	// Based on the operationId get the path and method
-	var path, method string
-
-	queryParams := make(map[string]bool)
-	pathParams := make(map[string]bool)
-
-	for p, pathItem := range schema.Paths.Map() {
-		for m, operation := range pathItem.Operations() {
-			if operation.OperationID == action {
-				path = p
-				method = m
-
-				for _, param := range operation.Parameters {
-
-					switch param.Value.In {
-					case "query":
-						queryParams[param.Value.Name] = true
-					case "path":
-						pathParams[param.Value.Name] = true
-					}
-				}
-
-				break
-			}
-		}
+	operation := findOperation(schema, action)
+	if operation == nil {
+		return nil, fmt.Errorf("failed to find path and method for action %s", action)
 	}
 
-	if path == "" || method == "" {
-		return nil, fmt.Errorf("failed to find path and method for action %s", action)
+	params, err = validateParameters(operation, tool.Config.API, params)
+	if err != nil {
+		return nil, fmt.Errorf("%w for action %s: %w", ErrInvalidParameters, action, err)
+	}
+
+	var (
+		body        io.Reader
+		contentType string
+	)
+	if value, ok := params[bodyParameter]; ok {
+		_, contentType, _ = operation.requestBody()
+		body, err = encodeRequestBody(contentType, value)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Prepare request
-	req, err := http.NewRequestWithContext(ctx, method, tool.Config.API.URL+path, nil)
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(operation.method), tool.Config.API.URL+operation.path, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -65,16 +59,30 @@ func (c *ChainStrategy) prepareRequest(ctx context.Context, tool *types.Tool, ac
 		req.Header.Set(k, v)
 	}
 
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
 	q := req.URL.Query()
 
-	// Add path params
-	for k, v := range params {
-		if pathParams[k] {
-			req.URL.Path = strings.Replace(req.URL.Path, "{"+k+"}", v, -1)
+	// Add path, query and header params
+	for _, param := range operation.parameters {
+		if param.Value == nil {
+			continue
 		}
 
-		if queryParams[k] {
-			q.Add(k, v)
+		v, ok := params[param.Value.Name]
+		if !ok {
+			continue
+		}
+
+		switch param.Value.In {
+		case openapi3.ParameterInPath:
+			req.URL.Path = strings.Replace(req.URL.Path, "{"+param.Value.Name+"}", formatValue(v), -1)
+		case openapi3.ParameterInQuery:
+			addQueryValue(q, param.Value.Name, v)
+		case openapi3.ParameterInHeader:
+			req.Header.Set(param.Value.Name, formatValue(v))
 		}
 	}
 
@@ -93,12 +101,10 @@ func (c *ChainStrategy) prepareRequest(ctx context.Context, tool *types.Tool, ac
 	req.Header.Set("X-Helix-Tool-Id", tool.ID)
 	req.Header.Set("X-Helix-Action-Id", action)
 
-	// TODO: Add body
-
 	return req, nil
 }
 
-func (c *ChainStrategy) getAPIRequestParameters(ctx context.Context, client oai.Client, sessionID, interactionID string, tool *types.Tool, history []*types.ToolHistoryMessage, action string) (map[string]string, error) {
+func (c *ChainStrategy) getAPIRequestParameters(ctx context.Context, opts Options, sessionID, interactionID string, tool *types.Tool, history []*types.ToolHistoryMessage, action string) (map[string]any, error) {
 	systemPrompt, err := c.getAPISystemPrompt(tool, action)
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare system prompt: %w", err)
@@ -152,7 +158,21 @@ func (c *ChainStrategy) getAPIRequestParameters(ctx context.Context, client oai.
 		Step: types.LLMCallStepPrepareAPIRequest,
 	})
 
-	resp, err := client.CreateChatCompletion(ctx, req)
+	// The function call that picked the action already has the parameters
+	if opts.arguments != nil {
+		return opts.arguments, nil
+	}
+
+	if opts.nativeToolCalling {
+		started := time.Now()
+		params, err := c.getAPIRequestParametersFunctionCalling(ctx, opts.client, req.Model, tool, history, action)
+		if err == nil {
+			return params, nil
+		}
+		logFunctionCallingFallback(err, types.LLMCallStepPrepareAPIRequest, started)
+	}
+
+	resp, err := opts.client.CreateChatCompletion(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get response from inference API: %w", err)
 	}
@@ -163,7 +183,6 @@ func (c *ChainStrategy) getAPIRequestParameters(ctx context.Context, client oai.
 
 	answer := resp.Choices[0].Message.Content
 
-	// var params map[string]string
 	params, err := unmarshalParams(answer)
 	if err != nil {
 		return nil, err
@@ -172,24 +191,16 @@ func (c *ChainStrategy) getAPIRequestParameters(ctx context.Context, client oai.
 	return params, nil
 }
 
-func unmarshalParams(data string) (map[string]string, error) {
-	var initial map[string]interface{}
-	err := unmarshalJSON(data, &initial)
+// unmarshalParams parses the parameters the model answered with, the values
+// keep their JSON types and are checked against the schema when the request is
+// prepared
+func unmarshalParams(data string) (map[string]any, error) {
+	params := make(map[string]any)
+	err := unmarshalJSON(data, &params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal response from inference API: %w (%s)", err, data)
 	}
 
-	params := make(map[string]string)
-
-	for k, v := range initial {
-		// Convert any type of value to string
-		if v == nil {
-			params[k] = "" // Set empty string if value is nil
-		} else {
-			params[k] = fmt.Sprintf("%v", v)
-		}
-	}
-
 	return params, nil
 }
 
@@ -307,7 +318,7 @@ OpenAPI schema:
 
 ===END OPENAPI SCHEMA===
 
-Based on conversation below, construct a valid JSON object. In cases where user input does not contain information for a query, DO NOT add that specific query parameter to the output. If a user doesn't provide a required parameter, use sensible defaults for required params, and leave optional params out. Do not pass parameters as null, instead just don't include them.
+Based on conversation below, construct a valid JSON object. If the operation has a request body, put it under the "body" key. In cases where user input does not contain information for a query, DO NOT add that specific query parameter to the output. If a user doesn't provide a required parameter, use sensible defaults for required params, and leave optional params out. Do not pass parameters as null, instead just don't include them.
 ONLY use search parameters from the user messages below - do NOT use search parameters provided in the examples.
 `
 
@@ -337,6 +348,15 @@ func filterOpenAPISchema(tool *types.Tool, operationID string) (string, error) {
 				// filtered.addOperation(path, method, operation)
 				filtered.AddOperation(path, method, operation)
 
+				if operation.RequestBody != nil && operation.RequestBody.Value != nil {
+					for _, media := range operation.RequestBody.Value.Content {
+						if media.Schema != nil && media.Schema.Ref != "" {
+							parts := strings.Split(media.Schema.Ref, "/")
+							usedRefs = append(usedRefs, parts[len(parts)-1])
+						}
+					}
+				}
+
 				for _, resp := range operation.Responses.Map() {
 					jsonBody, ok := resp.Value.Content["application/json"]
 					if !ok {
@@ -413,6 +433,8 @@ type Parameter struct {
 	Required    bool
 	Type        ParameterType
 	Description string
+	// Schema is the JSON schema of the parameter values
+	Schema map[string]any
 }
 
 type ParameterType string
@@ -420,11 +442,14 @@ type ParameterType string
 const (
 	ParameterTypeString  ParameterType = "string"
 	ParameterTypeInteger ParameterType = "integer"
+	ParameterTypeNumber  ParameterType = "number"
 	ParameterTypeBoolean ParameterType = "boolean"
 	ParameterTypeArray   ParameterType = "array"
 	ParameterTypeObject  ParameterType = "object"
 )
 
+// GetParametersFromSchema returns the parameters of the action, the request
+// body is returned as the body parameter
 func GetParametersFromSchema(spec string, action string) ([]*Parameter, error) {
 	loader := openapi3.NewLoader()
 
@@ -433,33 +458,42 @@ func GetParametersFromSchema(spec string, action string) ([]*Parameter, error) {
 		return nil, fmt.Errorf("failed to load openapi spec: %w", err)
 	}
 
-	var parameters []*Parameter
+	operation := findOperation(schema, action)
+	if operation == nil {
+		return nil, nil
+	}
 
-	for _, pathItem := range schema.Paths.Map() {
-		for _, operation := range pathItem.Operations() {
-			if operation.OperationID == action {
-				for _, param := range operation.Parameters {
-					parameters = append(parameters, &Parameter{
-						Name:        param.Value.Name,
-						Required:    param.Value.Required,
-						Type:        getParameterType(param.Value.Schema),
-						Description: param.Value.Description,
-					})
-				}
+	var parameters []*Parameter
 
-				// if operation.RequestBody != nil {
-				// 	// TODO: Add body parameter. Need to lookup the schema in components
-				// 	// and then add it to the parameters list all items.
-				// }
-			}
+	for _, param := range operation.parameters {
+		if param.Value == nil || param.Value.In == openapi3.ParameterInCookie {
+			continue
 		}
+
+		parameters = append(parameters, &Parameter{
+			Name:        param.Value.Name,
+			Required:    param.Value.Required,
+			Type:        getParameterType(param.Value.Schema),
+			Description: param.Value.Description,
+			Schema:      jsonSchema(param.Value.Schema, 0),
+		})
+	}
+
+	if body, _, media := operation.requestBody(); body != nil {
+		parameters = append(parameters, &Parameter{
+			Name:        bodyParameter,
+			Required:    body.Required,
+			Type:        getParameterType(media.Schema),
+			Description: body.Description,
+			Schema:      jsonSchema(media.Schema, 0),
+		})
 	}
 
 	return parameters, nil
 }
 
 func getParameterType(schema *openapi3.SchemaRef) ParameterType {
-	if len(schema.Value.Type.Slice()) > 0 {
+	if schema != nil && schema.Value != nil && len(schema.Value.Type.Slice()) > 0 {
 		return ParameterType(schema.Value.Type.Slice()[0])
 	}
 
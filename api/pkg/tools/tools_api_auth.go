@@ -0,0 +1,446 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// ErrMissingCredentials is returned when the secrets an API tool needs to
+// authenticate are not set
+var ErrMissingCredentials = errors.New("missing credentials")
+
+const (
+	securitySchemeAPIKey        = "apiKey"
+	securitySchemeHTTP          = "http"
+	securitySchemeOAuth2        = "oauth2"
+	securitySchemeOpenIDConnect = "openIdConnect"
+)
+
+// tokenSourceIdleTimeout is how long the client credentials token sources are
+// kept without being used
+const tokenSourceIdleTimeout = time.Hour
+
+type tokenSourceCacheEntry struct {
+	source   oauth2.TokenSource
+	lastUsed time.Time
+}
+
+type secretLock struct {
+	mu      sync.Mutex
+	waiters int
+}
+
+// authenticateRequest adds the credentials for the security scheme of the
+// action to the request. The secrets are resolved when the action runs so
+// rotated credentials apply without updating the app.
+func (c *ChainStrategy) authenticateRequest(ctx context.Context, opts Options, tool *types.Tool, action string, req *http.Request) error {
+	auth := tool.Config.API.Auth
+	if auth == nil {
+		return nil
+	}
+
+	schema, err := openapi3.NewLoader().LoadFromData([]byte(tool.Config.API.Schema))
+	if err != nil {
+		return fmt.Errorf("failed to load openapi spec: %w", err)
+	}
+
+	operation := findOperation(schema, action)
+	if operation == nil {
+		return fmt.Errorf("failed to find operation for action %s", action)
+	}
+
+	name, scopes, ok := selectSecurityScheme(schema, operation.operation, auth.Scheme)
+	if !ok {
+		// The operation doesn't need credentials
+		return nil
+	}
+
+	if schema.Components == nil || schema.Components.SecuritySchemes[name] == nil || schema.Components.SecuritySchemes[name].Value == nil {
+		return fmt.Errorf("security scheme %s is not found in the spec", name)
+	}
+	scheme := schema.Components.SecuritySchemes[name].Value
+
+	if len(auth.Scopes) > 0 {
+		scopes = auth.Scopes
+	}
+
+	switch scheme.Type {
+	case securitySchemeAPIKey:
+		value, err := c.secretValue(ctx, opts, tool, auth.Secret)
+		if err != nil {
+			return err
+		}
+
+		switch scheme.In {
+		case openapi3.ParameterInHeader:
+			req.Header.Set(scheme.Name, value)
+		case openapi3.ParameterInQuery:
+			q := req.URL.Query()
+			q.Set(scheme.Name, value)
+			req.URL.RawQuery = q.Encode()
+		case openapi3.ParameterInCookie:
+			req.AddCookie(&http.Cookie{Name: scheme.Name, Value: value})
+		default:
+			return fmt.Errorf("unsupported location %q for the API key of security scheme %s", scheme.In, name)
+		}
+	case securitySchemeHTTP:
+		value, err := c.secretValue(ctx, opts, tool, auth.Secret)
+		if err != nil {
+			return err
+		}
+
+		switch strings.ToLower(scheme.Scheme) {
+		case "bearer":
+			req.Header.Set("Authorization", "Bearer "+value)
+		case "basic":
+			req.SetBasicAuth(auth.Username, value)
+		default:
+			return fmt.Errorf("unsupported http scheme %q of security scheme %s", scheme.Scheme, name)
+		}
+	case securitySchemeOAuth2:
+		token, err := c.oauth2Token(ctx, opts, tool, scheme, scopes)
+		if err != nil {
+			return err
+		}
+		token.SetAuthHeader(req)
+	case securitySchemeOpenIDConnect:
+		// We can't sign the user in, the token has to be stored in their
+		// secrets
+		token, err := c.userToken(ctx, opts, tool, "")
+		if err != nil {
+			return err
+		}
+		token.SetAuthHeader(req)
+	default:
+		return fmt.Errorf("unsupported type %q of security scheme %s", scheme.Type, name)
+	}
+
+	return nil
+}
+
+// selectSecurityScheme returns the security scheme the operation requires
+// and its scopes, preferring the configured one. Specs declaring a single
+// scheme without requiring it anywhere get that scheme.
+func selectSecurityScheme(schema *openapi3.T, operation *openapi3.Operation, configured string) (string, []string, bool) {
+	requirements := schema.Security
+	if operation.Security != nil {
+		// An empty list removes the top level requirements
+		if len(*operation.Security) == 0 {
+			return "", nil, false
+		}
+		requirements = *operation.Security
+	}
+
+	for _, requirement := range requirements {
+		names := make([]string, 0, len(requirement))
+		for name := range requirement {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if configured == "" || configured == name {
+				return name, requirement[name], true
+			}
+		}
+	}
+
+	if configured != "" {
+		return configured, nil, true
+	}
+
+	if len(requirements) == 0 && schema.Components != nil && len(schema.Components.SecuritySchemes) == 1 {
+		for name := range schema.Components.SecuritySchemes {
+			return name, nil, true
+		}
+	}
+
+	return "", nil, false
+}
+
+// oauth2Token returns the token for the OAuth2 flow of the scheme, client
+// credentials are used when the spec supports them and no user token is
+// configured
+func (c *ChainStrategy) oauth2Token(ctx context.Context, opts Options, tool *types.Tool, scheme *openapi3.SecurityScheme, scopes []string) (*oauth2.Token, error) {
+	auth := tool.Config.API.Auth
+
+	if scheme.Flows == nil {
+		return nil, fmt.Errorf("oauth2 security scheme has no flows")
+	}
+
+	if auth.UserSecret == "" && scheme.Flows.ClientCredentials != nil {
+		return c.clientCredentialsToken(ctx, opts, tool, scheme.Flows.ClientCredentials, scopes)
+	}
+
+	var tokenURL string
+	for _, flow := range []*openapi3.OAuthFlow{scheme.Flows.AuthorizationCode, scheme.Flows.Implicit, scheme.Flows.Password} {
+		if flow == nil {
+			continue
+		}
+		tokenURL = flow.RefreshURL
+		if tokenURL == "" {
+			tokenURL = flow.TokenURL
+		}
+		break
+	}
+
+	return c.userToken(ctx, opts, tool, tokenURL)
+}
+
+// clientCredentialsToken returns the token of the client credentials flow.
+// The token sources are cached so the tokens are reused until they expire
+// and fetched again after.
+func (c *ChainStrategy) clientCredentialsToken(ctx context.Context, opts Options, tool *types.Tool, flow *openapi3.OAuthFlow, scopes []string) (*oauth2.Token, error) {
+	auth := tool.Config.API.Auth
+
+	if auth.ClientID == "" {
+		return nil, fmt.Errorf("%w: client ID is required for the client credentials flow", ErrMissingCredentials)
+	}
+
+	clientSecret, err := c.secretValue(ctx, opts, tool, auth.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &clientcredentials.Config{
+		ClientID:     auth.ClientID,
+		ClientSecret: clientSecret,
+		TokenURL:     flow.TokenURL,
+		Scopes:       scopes,
+	}
+
+	// Secrets are part of the key so rotated ones don't reuse the old tokens
+	hash := sha256.Sum256([]byte(strings.Join(append([]string{config.TokenURL, config.ClientID, config.ClientSecret}, scopes...), "\n")))
+	key := hex.EncodeToString(hash[:])
+
+	now := time.Now()
+
+	c.tokenSourcesMu.Lock()
+	entry, ok := c.tokenSources[key]
+	if !ok {
+		c.evictTokenSources(now)
+
+		// The source outlives the request, it fetches new tokens with its
+		// own context
+		entry = &tokenSourceCacheEntry{
+			source: config.TokenSource(context.WithValue(context.Background(), oauth2.HTTPClient, c.httpClient)),
+		}
+		c.tokenSources[key] = entry
+	}
+	entry.lastUsed = now
+	c.tokenSourcesMu.Unlock()
+
+	token, err := entry.source.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client credentials token: %w", err)
+	}
+
+	return token, nil
+}
+
+// evictTokenSources drops the token sources that weren't used for a while,
+// e.g. the ones of rotated secrets. The lock must be held.
+func (c *ChainStrategy) evictTokenSources(now time.Time) {
+	for key, entry := range c.tokenSources {
+		if now.Sub(entry.lastUsed) > tokenSourceIdleTimeout {
+			delete(c.tokenSources, key)
+		}
+	}
+}
+
+// lockSecret serializes the updates of the secret, the returned function
+// releases it
+func (c *ChainStrategy) lockSecret(id string) func() {
+	c.secretLocksMu.Lock()
+	lock, ok := c.secretLocks[id]
+	if !ok {
+		lock = &secretLock{}
+		c.secretLocks[id] = lock
+	}
+	lock.waiters++
+	c.secretLocksMu.Unlock()
+
+	lock.mu.Lock()
+
+	return func() {
+		lock.mu.Unlock()
+
+		c.secretLocksMu.Lock()
+		lock.waiters--
+		if lock.waiters == 0 {
+			delete(c.secretLocks, id)
+		}
+		c.secretLocksMu.Unlock()
+	}
+}
+
+// parseUserToken reads the OAuth2 token stored in the secret, plain access
+// tokens are accepted as well
+func parseUserToken(secret *types.Secret) *oauth2.Token {
+	token := &oauth2.Token{}
+	if err := json.Unmarshal(secret.Value, token); err != nil || token.AccessToken == "" {
+		token = &oauth2.Token{AccessToken: strings.TrimSpace(string(secret.Value))}
+	}
+	return token
+}
+
+// userToken returns the OAuth2 token of the end user from their secrets. It
+// is refreshed and stored back when it has expired, one refresh at a time so
+// rotated refresh tokens aren't overwritten by a concurrent refresh.
+func (c *ChainStrategy) userToken(ctx context.Context, opts Options, tool *types.Tool, tokenURL string) (*oauth2.Token, error) {
+	auth := tool.Config.API.Auth
+
+	if auth.UserSecret == "" {
+		return nil, fmt.Errorf("%w: user secret is required for user tokens", ErrMissingCredentials)
+	}
+	if opts.owner == "" {
+		return nil, fmt.Errorf("%w: user tokens need the user the action runs for", ErrMissingCredentials)
+	}
+	if c.store == nil {
+		return nil, fmt.Errorf("store is required to read secrets")
+	}
+
+	secrets, err := c.store.ListSecrets(ctx, &store.ListSecretsQuery{
+		Owner: opts.owner,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	secret := findSecret(secrets, opts.appID, auth.UserSecret)
+	if secret == nil {
+		return nil, fmt.Errorf("%w: secret %s is not set, connect your account first", ErrMissingCredentials, auth.UserSecret)
+	}
+
+	token := parseUserToken(secret)
+	if token.Valid() {
+		return token, nil
+	}
+
+	unlock := c.lockSecret(secret.ID)
+	defer unlock()
+
+	// Another action may have refreshed the token while we waited
+	secret, err = c.store.GetSecret(ctx, secret.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret %s: %w", auth.UserSecret, err)
+	}
+
+	token = parseUserToken(secret)
+	if token.Valid() {
+		return token, nil
+	}
+
+	if token.RefreshToken == "" || tokenURL == "" {
+		return nil, fmt.Errorf("%w: token in secret %s has expired, connect your account again", ErrMissingCredentials, auth.UserSecret)
+	}
+
+	config := &oauth2.Config{
+		ClientID: auth.ClientID,
+		Endpoint: oauth2.Endpoint{TokenURL: tokenURL},
+	}
+	if auth.Secret != "" {
+		config.ClientSecret, err = c.secretValue(ctx, opts, tool, auth.Secret)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	refreshed, err := config.TokenSource(context.WithValue(ctx, oauth2.HTTPClient, c.httpClient), token).Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh token in secret %s: %w", auth.UserSecret, err)
+	}
+
+	// Providers that don't rotate refresh tokens leave them out
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = token.RefreshToken
+	}
+
+	secret.Value, err = json.Marshal(refreshed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal token: %w", err)
+	}
+	if _, err := c.store.UpdateSecret(ctx, secret); err != nil {
+		return nil, fmt.Errorf("failed to update secret %s: %w", auth.UserSecret, err)
+	}
+
+	return refreshed, nil
+}
+
+// secretValue returns the value of the shared secret with the name, e.g. an
+// API key or a client secret. Shared secrets are read from the app owner (the
+// user, organization or team) or from the owner of the tool when it doesn't
+// belong to an app, never from the user running it: the author of the tool
+// picks the secret name and where its value is sent to.
+func (c *ChainStrategy) secretValue(ctx context.Context, opts Options, tool *types.Tool, name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("%w: secret name is required", ErrMissingCredentials)
+	}
+	if c.store == nil {
+		return "", fmt.Errorf("store is required to read secrets")
+	}
+
+	owner, ownerType := tool.Owner, tool.OwnerType
+	if opts.appID != "" {
+		app, err := c.store.GetApp(ctx, opts.appID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get app %s: %w", opts.appID, err)
+		}
+		owner, ownerType = app.Owner, app.OwnerType
+	}
+	if owner == "" {
+		return "", fmt.Errorf("%w: secret %s needs the app or owner of the tool", ErrMissingCredentials, name)
+	}
+
+	secrets, err := c.store.ListSecrets(ctx, &store.ListSecretsQuery{
+		Owner:     owner,
+		OwnerType: ownerType,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	secret := findSecret(secrets, opts.appID, name)
+	if secret == nil {
+		return "", fmt.Errorf("%w: secret %s is not set", ErrMissingCredentials, name)
+	}
+
+	return string(secret.Value), nil
+}
+
+// findSecret returns the first secret with the name available to the app,
+// secrets restricted to the app win over the shared ones
+func findSecret(secrets []*types.Secret, appID, name string) *types.Secret {
+	var found *types.Secret
+	for _, secret := range secrets {
+		if secret.Name != name {
+			continue
+		}
+		if secret.AppID != "" && secret.AppID != appID {
+			continue
+		}
+		if secret.AppID != "" {
+			return secret
+		}
+		if found == nil {
+			found = secret
+		}
+	}
+	return found
+}
@@ -0,0 +1,387 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/oauth2"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+const securedAPISpec = `openapi: "3.0.0"
+info:
+  version: 1.0.0
+  title: Secured
+security:
+  - apiKeyHeader: []
+paths:
+  /items:
+    get:
+      operationId: listItems
+      responses:
+        '200':
+          description: Items
+  /public:
+    get:
+      operationId: getPublic
+      security: []
+      responses:
+        '200':
+          description: Public
+  /reports:
+    get:
+      operationId: listReports
+      security:
+        - oauth: [reports.read]
+      responses:
+        '200':
+          description: Reports
+components:
+  securitySchemes:
+    apiKeyHeader:
+      type: apiKey
+      in: header
+      name: X-API-Key
+    apiKeyQuery:
+      type: apiKey
+      in: query
+      name: api_key
+    bearer:
+      type: http
+      scheme: bearer
+    basic:
+      type: http
+      scheme: basic
+    oauth:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: %[1]s/token
+          scopes:
+            reports.read: Read reports
+        authorizationCode:
+          authorizationUrl: %[1]s/authorize
+          tokenUrl: %[1]s/token
+          scopes:
+            reports.read: Read reports
+`
+
+func securedTool(tokenServerURL string, auth *types.ToolAPIAuth) *types.Tool {
+	return &types.Tool{
+		Name:      "secured",
+		Owner:     "author-1",
+		OwnerType: types.OwnerTypeUser,
+		ToolType:  types.ToolTypeAPI,
+		Config: types.ToolConfig{
+			API: &types.ToolAPIConfig{
+				URL:    "https://example.com/api",
+				Schema: fmt.Sprintf(securedAPISpec, tokenServerURL),
+				Auth:   auth,
+			},
+		},
+	}
+}
+
+// newAuthTestStrategy serves the secrets by owner, the ones without an owner
+// belong to author-1 who owns the tool. The actions run for user-1.
+func newAuthTestStrategy(t *testing.T, secrets ...*types.Secret) (*ChainStrategy, *store.MockStore) {
+	ctrl := gomock.NewController(t)
+	st := store.NewMockStore(ctrl)
+	st.EXPECT().ListSecrets(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, q *store.ListSecretsQuery) ([]*types.Secret, error) {
+		var result []*types.Secret
+		for _, secret := range secrets {
+			owner := secret.Owner
+			if owner == "" {
+				owner = "author-1"
+			}
+			if owner == q.Owner {
+				result = append(result, secret)
+			}
+		}
+		return result, nil
+	}).AnyTimes()
+
+	strategy, err := NewChainStrategy(&config.ServerConfig{}, st, nil, nil)
+	require.NoError(t, err)
+	return strategy, st
+}
+
+func authenticatedRequest(t *testing.T, strategy *ChainStrategy, tool *types.Tool, action string, options ...Option) (*http.Request, error) {
+	req, err := strategy.prepareRequest(context.Background(), tool, action, map[string]any{})
+	require.NoError(t, err)
+
+	opts := strategy.getDefaultOptions()
+	for _, opt := range append([]Option{WithOwner("user-1")}, options...) {
+		require.NoError(t, opt(&opts))
+	}
+
+	return req, strategy.authenticateRequest(context.Background(), opts, tool, action, req)
+}
+
+func Test_authenticateRequest_Static(t *testing.T) {
+	strategy, _ := newAuthTestStrategy(t,
+		&types.Secret{Name: "API_KEY", Value: []byte("key-123")},
+		&types.Secret{Name: "API_KEY", Value: []byte("other-app-key"), AppID: "app-2"},
+	)
+
+	tests := []struct {
+		name   string
+		auth   *types.ToolAPIAuth
+		action string
+		check  func(t *testing.T, req *http.Request)
+	}{
+		{
+			name:   "api key header from top level security",
+			auth:   &types.ToolAPIAuth{Secret: "API_KEY"},
+			action: "listItems",
+			check: func(t *testing.T, req *http.Request) {
+				require.Equal(t, "key-123", req.Header.Get("X-API-Key"))
+			},
+		},
+		{
+			name:   "api key query",
+			auth:   &types.ToolAPIAuth{Scheme: "apiKeyQuery", Secret: "API_KEY"},
+			action: "listItems",
+			check: func(t *testing.T, req *http.Request) {
+				require.Equal(t, "key-123", req.URL.Query().Get("api_key"))
+				require.Empty(t, req.Header.Get("X-API-Key"))
+			},
+		},
+		{
+			name:   "bearer",
+			auth:   &types.ToolAPIAuth{Scheme: "bearer", Secret: "API_KEY"},
+			action: "listItems",
+			check: func(t *testing.T, req *http.Request) {
+				require.Equal(t, "Bearer key-123", req.Header.Get("Authorization"))
+			},
+		},
+		{
+			name:   "basic",
+			auth:   &types.ToolAPIAuth{Scheme: "basic", Username: "bot", Secret: "API_KEY"},
+			action: "listItems",
+			check: func(t *testing.T, req *http.Request) {
+				username, password, ok := req.BasicAuth()
+				require.True(t, ok)
+				require.Equal(t, "bot", username)
+				require.Equal(t, "key-123", password)
+			},
+		},
+		{
+			name:   "operation without security",
+			auth:   &types.ToolAPIAuth{Secret: "API_KEY"},
+			action: "getPublic",
+			check: func(t *testing.T, req *http.Request) {
+				require.Empty(t, req.Header.Get("X-API-Key"))
+				require.Empty(t, req.URL.RawQuery)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := authenticatedRequest(t, strategy, securedTool("https://auth.example.com", tt.auth), tt.action)
+			require.NoError(t, err)
+			tt.check(t, req)
+		})
+	}
+}
+
+func Test_authenticateRequest_MissingSecret(t *testing.T) {
+	strategy, _ := newAuthTestStrategy(t,
+		&types.Secret{Name: "API_KEY", Value: []byte("other-app-key"), AppID: "app-2"},
+	)
+
+	_, err := authenticatedRequest(t, strategy, securedTool("https://auth.example.com", &types.ToolAPIAuth{Secret: "API_KEY"}), "listItems")
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrMissingCredentials))
+}
+
+func Test_authenticateRequest_OrganizationSecret(t *testing.T) {
+	strategy, st := newAuthTestStrategy(t,
+		&types.Secret{Owner: "org-1", OwnerType: types.OwnerTypeOrg, Name: "API_KEY", Value: []byte("org-key")},
+	)
+
+	st.EXPECT().GetApp(gomock.Any(), "app-1").Return(&types.App{ID: "app-1", Owner: "org-1", OwnerType: types.OwnerTypeOrg}, nil)
+
+	req, err := authenticatedRequest(t, strategy, securedTool("https://auth.example.com", &types.ToolAPIAuth{Secret: "API_KEY"}), "listItems", WithAppID("app-1"))
+	require.NoError(t, err)
+	require.Equal(t, "org-key", req.Header.Get("X-API-Key"))
+}
+
+// Test_authenticateRequest_UserSecretNotShared checks the secrets of the user
+// running the app are never sent as the shared credentials the app author
+// configured
+func Test_authenticateRequest_UserSecretNotShared(t *testing.T) {
+	strategy, st := newAuthTestStrategy(t,
+		&types.Secret{Owner: "user-1", Name: "OPENAI_API_KEY", Value: []byte("sk-user")},
+	)
+
+	st.EXPECT().GetApp(gomock.Any(), "app-1").Return(&types.App{ID: "app-1", Owner: "org-1", OwnerType: types.OwnerTypeOrg}, nil)
+
+	_, err := authenticatedRequest(t, strategy, securedTool("https://auth.example.com", &types.ToolAPIAuth{Secret: "OPENAI_API_KEY"}), "listItems", WithAppID("app-1"))
+	require.ErrorIs(t, err, ErrMissingCredentials)
+}
+
+func Test_authenticateRequest_ClientCredentials(t *testing.T) {
+	var tokenRequests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/token", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "client_credentials", r.Form.Get("grant_type"))
+		require.Equal(t, "reports.read", r.Form.Get("scope"))
+
+		clientID, clientSecret, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "client-1", clientID)
+		require.Equal(t, "client-secret", clientSecret)
+
+		tokenRequests++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":3600}`, tokenRequests)
+	}))
+	defer srv.Close()
+
+	strategy, _ := newAuthTestStrategy(t, &types.Secret{Name: "CLIENT_SECRET", Value: []byte("client-secret")})
+	tool := securedTool(srv.URL, &types.ToolAPIAuth{ClientID: "client-1", Secret: "CLIENT_SECRET"})
+
+	for i := 0; i < 2; i++ {
+		req, err := authenticatedRequest(t, strategy, tool, "listReports")
+		require.NoError(t, err)
+		require.Equal(t, "Bearer token-1", req.Header.Get("Authorization"))
+	}
+
+	// The token is cached until it expires
+	require.Equal(t, 1, tokenRequests)
+}
+
+func Test_authenticateRequest_UserToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "refresh_token", r.Form.Get("grant_type"))
+		require.Equal(t, "refresh-1", r.Form.Get("refresh_token"))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"fresh-token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer srv.Close()
+
+	expired, err := json.Marshal(&oauth2.Token{
+		AccessToken:  "stale-token",
+		TokenType:    "Bearer",
+		RefreshToken: "refresh-1",
+		Expiry:       time.Now().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+
+	secret := &types.Secret{ID: "secret-1", Owner: "user-1", Name: "REPORTS_TOKEN", Value: expired}
+	strategy, st := newAuthTestStrategy(t, secret)
+
+	st.EXPECT().GetSecret(gomock.Any(), "secret-1").Return(secret, nil)
+
+	// The refreshed token is stored for the next calls
+	st.EXPECT().UpdateSecret(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, updated *types.Secret) (*types.Secret, error) {
+		require.Equal(t, "secret-1", updated.ID)
+
+		var token oauth2.Token
+		require.NoError(t, json.Unmarshal(updated.Value, &token))
+		require.Equal(t, "fresh-token", token.AccessToken)
+		require.Equal(t, "refresh-1", token.RefreshToken)
+		return updated, nil
+	})
+
+	tool := securedTool(srv.URL, &types.ToolAPIAuth{ClientID: "client-1", UserSecret: "REPORTS_TOKEN"})
+
+	req, err := authenticatedRequest(t, strategy, tool, "listReports")
+	require.NoError(t, err)
+	require.Equal(t, "Bearer fresh-token", req.Header.Get("Authorization"))
+}
+
+func Test_authenticateRequest_UserTokenConcurrentRefresh(t *testing.T) {
+	var refreshes atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		refreshes.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"fresh-token","token_type":"Bearer","refresh_token":"refresh-2","expires_in":3600}`))
+	}))
+	defer srv.Close()
+
+	expired, err := json.Marshal(&oauth2.Token{
+		AccessToken:  "stale-token",
+		RefreshToken: "refresh-1",
+		Expiry:       time.Now().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+
+	strategy, st := newAuthTestStrategy(t, &types.Secret{ID: "secret-1", Owner: "user-1", Name: "REPORTS_TOKEN", Value: expired})
+
+	// The stored secret has the token of the first refresh
+	var (
+		mu     sync.Mutex
+		stored = expired
+	)
+	st.EXPECT().GetSecret(gomock.Any(), "secret-1").DoAndReturn(func(_ context.Context, _ string) (*types.Secret, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return &types.Secret{ID: "secret-1", Owner: "user-1", Name: "REPORTS_TOKEN", Value: stored}, nil
+	}).Times(2)
+	st.EXPECT().UpdateSecret(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, updated *types.Secret) (*types.Secret, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		stored = updated.Value
+		return updated, nil
+	})
+
+	tool := securedTool(srv.URL, &types.ToolAPIAuth{ClientID: "client-1", UserSecret: "REPORTS_TOKEN"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, err := authenticatedRequest(t, strategy, tool, "listReports")
+			assert.NoError(t, err)
+			assert.Equal(t, "Bearer fresh-token", req.Header.Get("Authorization"))
+		}()
+	}
+	wg.Wait()
+
+	// The second action uses the token of the first refresh
+	require.Equal(t, int32(1), refreshes.Load())
+	require.Empty(t, strategy.secretLocks)
+}
+
+func Test_evictTokenSources(t *testing.T) {
+	strategy, _ := newAuthTestStrategy(t)
+
+	now := time.Now()
+	strategy.tokenSources["idle"] = &tokenSourceCacheEntry{lastUsed: now.Add(-2 * tokenSourceIdleTimeout)}
+	strategy.tokenSources["used"] = &tokenSourceCacheEntry{lastUsed: now.Add(-time.Minute)}
+
+	strategy.evictTokenSources(now)
+
+	require.Len(t, strategy.tokenSources, 1)
+	require.Contains(t, strategy.tokenSources, "used")
+}
+
+func Test_authenticateRequest_UserTokenNotConnected(t *testing.T) {
+	strategy, _ := newAuthTestStrategy(t)
+	tool := securedTool("https://auth.example.com", &types.ToolAPIAuth{UserSecret: "REPORTS_TOKEN"})
+
+	_, err := authenticatedRequest(t, strategy, tool, "listReports")
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrMissingCredentials))
+}
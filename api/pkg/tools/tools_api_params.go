@@ -0,0 +1,317 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// apiOperation is the operation of an action with the parameters that apply
+// to it, the ones of the path and of the operation itself
+type apiOperation struct {
+	path       string
+	method     string
+	operation  *openapi3.Operation
+	parameters openapi3.Parameters
+}
+
+func findOperation(schema *openapi3.T, operationID string) *apiOperation {
+	for path, pathItem := range schema.Paths.Map() {
+		for method, operation := range pathItem.Operations() {
+			if operation.OperationID != operationID {
+				continue
+			}
+
+			// Operation parameters override the path ones with the same
+			// name and location
+			parameters := append(openapi3.Parameters{}, operation.Parameters...)
+			for _, param := range pathItem.Parameters {
+				if param.Value != nil && operation.Parameters.GetByInAndName(param.Value.In, param.Value.Name) == nil {
+					parameters = append(parameters, param)
+				}
+			}
+
+			return &apiOperation{
+				path:       path,
+				method:     method,
+				operation:  operation,
+				parameters: parameters,
+			}
+		}
+	}
+	return nil
+}
+
+// requestBody returns the request body of the operation with its JSON or form
+// content, nil if it has none we can send
+func (o *apiOperation) requestBody() (*openapi3.RequestBody, string, *openapi3.MediaType) {
+	if o.operation.RequestBody == nil || o.operation.RequestBody.Value == nil {
+		return nil, "", nil
+	}
+
+	body := o.operation.RequestBody.Value
+	for _, contentType := range []string{contentTypeJSON, contentTypeForm} {
+		if media := body.Content.Get(contentType); media != nil {
+			return body, contentType, media
+		}
+	}
+	return nil, "", nil
+}
+
+// ErrInvalidParameters is returned when the parameters of an action don't
+// match its schema
+var ErrInvalidParameters = errors.New("invalid parameters")
+
+const (
+	contentTypeJSON = "application/json"
+	contentTypeForm = "application/x-www-form-urlencoded"
+)
+
+// validateParameters checks the parameters against the schema of the
+// operation. The values are converted to the types of the schema first as
+// models often send numbers and booleans as strings. Required parameters the
+// tool config sets don't have to be passed.
+func validateParameters(o *apiOperation, api *types.ToolAPIConfig, params map[string]any) (map[string]any, error) {
+	validated := make(map[string]any)
+
+	for _, param := range o.parameters {
+		p := param.Value
+		if p == nil {
+			continue
+		}
+
+		value, ok := params[p.Name]
+		if !ok || value == nil {
+			if p.Required && !configuredParameter(api, p) {
+				return nil, fmt.Errorf("missing required parameter %s", p.Name)
+			}
+			continue
+		}
+
+		value = coerceValue(p.Schema, value)
+		if p.Schema != nil && p.Schema.Value != nil {
+			if err := p.Schema.Value.VisitJSON(value, openapi3.VisitAsRequest()); err != nil {
+				return nil, fmt.Errorf("invalid parameter %s: %w", p.Name, err)
+			}
+		}
+		validated[p.Name] = value
+	}
+
+	body, _, media := o.requestBody()
+	if body == nil {
+		return validated, nil
+	}
+
+	value, ok := params[bodyParameter]
+	if !ok || value == nil {
+		value, ok = bodyFromParameters(o, media.Schema, params)
+	}
+	if !ok {
+		if body.Required {
+			return nil, fmt.Errorf("missing required request body")
+		}
+		return validated, nil
+	}
+
+	value = coerceValue(media.Schema, value)
+	if media.Schema != nil && media.Schema.Value != nil {
+		if err := media.Schema.Value.VisitJSON(value, openapi3.VisitAsRequest()); err != nil {
+			return nil, fmt.Errorf("invalid request body: %w", err)
+		}
+	}
+	validated[bodyParameter] = value
+
+	return validated, nil
+}
+
+// configuredParameter reports whether the tool sets the parameter itself, with
+// the query (which includes the app query parameters of the session) or the
+// headers of its config
+func configuredParameter(api *types.ToolAPIConfig, p *openapi3.Parameter) bool {
+	if api == nil {
+		return false
+	}
+
+	switch p.In {
+	case openapi3.ParameterInQuery:
+		_, ok := api.Query[p.Name]
+		return ok
+	case openapi3.ParameterInHeader:
+		for name := range api.Headers {
+			if strings.EqualFold(name, p.Name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// bodyFromParameters collects the properties of the request body that were
+// passed as parameters instead of under the body parameter. Names of the
+// path, query, header and cookie parameters are sent as those only.
+func bodyFromParameters(o *apiOperation, schema *openapi3.SchemaRef, params map[string]any) (map[string]any, bool) {
+	if schema == nil || schema.Value == nil || len(schema.Value.Properties) == 0 {
+		return nil, false
+	}
+
+	declared := make(map[string]bool, len(o.parameters))
+	for _, param := range o.parameters {
+		if param.Value != nil {
+			declared[param.Value.Name] = true
+		}
+	}
+
+	body := make(map[string]any)
+	for name := range schema.Value.Properties {
+		if declared[name] {
+			continue
+		}
+		if value, ok := params[name]; ok {
+			body[name] = value
+		}
+	}
+
+	return body, len(body) > 0
+}
+
+// coerceValue converts the value to the type of the schema where it can,
+// anything else is left for the validation to report
+func coerceValue(ref *openapi3.SchemaRef, value any) any {
+	if ref == nil || ref.Value == nil {
+		return value
+	}
+	s := ref.Value
+
+	switch v := value.(type) {
+	case string:
+		switch {
+		case s.Type.Is(openapi3.TypeInteger), s.Type.Is(openapi3.TypeNumber):
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f
+			}
+		case s.Type.Is(openapi3.TypeBoolean):
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b
+			}
+		case s.Type.Is(openapi3.TypeArray):
+			var items []any
+			if err := json.Unmarshal([]byte(v), &items); err != nil {
+				items = nil
+				for _, item := range strings.Split(v, ",") {
+					items = append(items, strings.TrimSpace(item))
+				}
+			}
+			return coerceValue(ref, items)
+		case s.Type.Is(openapi3.TypeObject):
+			var object map[string]any
+			if err := json.Unmarshal([]byte(v), &object); err == nil {
+				return coerceValue(ref, object)
+			}
+		}
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case []string:
+		items := make([]any, 0, len(v))
+		for _, item := range v {
+			items = append(items, item)
+		}
+		return coerceValue(ref, items)
+	case []any:
+		if s.Items == nil {
+			return v
+		}
+		items := make([]any, 0, len(v))
+		for _, item := range v {
+			items = append(items, coerceValue(s.Items, item))
+		}
+		return items
+	case map[string]any:
+		object := make(map[string]any, len(v))
+		for name, item := range v {
+			object[name] = coerceValue(s.Properties[name], item)
+		}
+		return object
+	}
+
+	return value
+}
+
+// formatValue formats a scalar for paths, queries and headers, arrays are
+// comma separated and objects JSON encoded
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, formatValue(item))
+		}
+		return strings.Join(items, ",")
+	case nil:
+		return ""
+	default:
+		bts, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(bts)
+	}
+}
+
+// addQueryValue adds the value to the query, arrays are repeated and the
+// properties of objects added separately like the default form style of
+// OpenAPI does
+func addQueryValue(q url.Values, name string, value any) {
+	switch v := value.(type) {
+	case []any:
+		for _, item := range v {
+			q.Add(name, formatValue(item))
+		}
+	case map[string]any:
+		for key, item := range v {
+			q.Add(key, formatValue(item))
+		}
+	default:
+		q.Add(name, formatValue(v))
+	}
+}
+
+// encodeRequestBody encodes the body for the content type of the operation
+func encodeRequestBody(contentType string, body any) (io.Reader, error) {
+	if contentType != contentTypeForm {
+		bts, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		return bytes.NewReader(bts), nil
+	}
+
+	object, ok := body.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("form request body must be an object, got %T", body)
+	}
+
+	form := url.Values{}
+	for key, value := range object {
+		addQueryValue(form, key, value)
+	}
+	return strings.NewReader(form.Encode()), nil
+}
@@ -0,0 +1,349 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+const ordersAPISpec = `openapi: "3.0.0"
+info:
+  version: 1.0.0
+  title: Orders
+paths:
+  /stores/{storeId}/orders:
+    parameters:
+      - name: storeId
+        in: path
+        required: true
+        schema:
+          type: integer
+    post:
+      summary: Create an order
+      operationId: createOrder
+      parameters:
+        - name: X-Request-Id
+          in: header
+          required: false
+          schema:
+            type: string
+        - name: dryRun
+          in: query
+          required: false
+          schema:
+            type: boolean
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Order'
+      responses:
+        '201':
+          description: Created
+    get:
+      summary: List orders
+      operationId: listOrders
+      parameters:
+        - name: status
+          in: query
+          schema:
+            type: array
+            items:
+              type: string
+              enum: [open, shipped, cancelled]
+      responses:
+        '200':
+          description: Orders
+  /feedback:
+    post:
+      summary: Send feedback
+      operationId: sendFeedback
+      requestBody:
+        content:
+          application/x-www-form-urlencoded:
+            schema:
+              type: object
+              properties:
+                rating:
+                  type: integer
+                comment:
+                  type: string
+      responses:
+        '204':
+          description: Sent
+components:
+  schemas:
+    Order:
+      type: object
+      required:
+        - items
+      properties:
+        items:
+          type: array
+          items:
+            type: object
+            required:
+              - sku
+              - quantity
+            properties:
+              sku:
+                type: string
+              quantity:
+                type: integer
+                minimum: 1
+        express:
+          type: boolean
+`
+
+func ordersTool() *types.Tool {
+	return &types.Tool{
+		ID:       "tool-orders",
+		Name:     "orders",
+		ToolType: types.ToolTypeAPI,
+		Config: types.ToolConfig{
+			API: &types.ToolAPIConfig{
+				URL:    "https://example.com/api",
+				Schema: ordersAPISpec,
+			},
+		},
+	}
+}
+
+func newTestStrategy(t *testing.T) *ChainStrategy {
+	strategy, err := NewChainStrategy(&config.ServerConfig{}, nil, nil, nil)
+	require.NoError(t, err)
+	return strategy
+}
+
+func Test_prepareRequest_JSONBody(t *testing.T) {
+	strategy := newTestStrategy(t)
+
+	req, err := strategy.prepareRequest(context.Background(), ordersTool(), "createOrder", map[string]any{
+		"storeId":      "42",
+		"dryRun":       "true",
+		"X-Request-Id": "req-1",
+		"body": map[string]any{
+			"items": []any{
+				map[string]any{"sku": "ABC-1", "quantity": "2"},
+			},
+			"express": true,
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "POST", req.Method)
+	require.Equal(t, "https://example.com/api/stores/42/orders?dryRun=true", req.URL.String())
+	require.Equal(t, "req-1", req.Header.Get("X-Request-Id"))
+	require.Equal(t, "application/json", req.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"items":[{"sku":"ABC-1","quantity":2}],"express":true}`, string(body))
+}
+
+func Test_prepareRequest_BodyFromParameters(t *testing.T) {
+	strategy := newTestStrategy(t)
+
+	// Models using the prompts sometimes pass the body properties as
+	// parameters
+	req, err := strategy.prepareRequest(context.Background(), ordersTool(), "createOrder", map[string]any{
+		"storeId": float64(42),
+		"items":   `[{"sku": "ABC-1", "quantity": 1}]`,
+	})
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"items":[{"sku":"ABC-1","quantity":1}]}`, string(body))
+}
+
+func Test_prepareRequest_InvalidParameters(t *testing.T) {
+	strategy := newTestStrategy(t)
+
+	tests := []struct {
+		name   string
+		action string
+		params map[string]any
+	}{
+		{
+			name:   "missing path parameter",
+			action: "createOrder",
+			params: map[string]any{"body": map[string]any{"items": []any{}}},
+		},
+		{
+			name:   "missing body",
+			action: "createOrder",
+			params: map[string]any{"storeId": 42},
+		},
+		{
+			name:   "wrong type",
+			action: "createOrder",
+			params: map[string]any{"storeId": "main", "body": map[string]any{"items": []any{}}},
+		},
+		{
+			name:   "below minimum",
+			action: "createOrder",
+			params: map[string]any{"storeId": 42, "body": map[string]any{"items": []any{map[string]any{"sku": "ABC-1", "quantity": 0}}}},
+		},
+		{
+			name:   "not in enum",
+			action: "listOrders",
+			params: map[string]any{"storeId": 42, "status": []any{"lost"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := strategy.prepareRequest(context.Background(), ordersTool(), tt.action, tt.params)
+			require.Error(t, err)
+			require.True(t, errors.Is(err, ErrInvalidParameters))
+		})
+	}
+}
+
+func Test_prepareRequest_ArrayQuery(t *testing.T) {
+	strategy := newTestStrategy(t)
+
+	req, err := strategy.prepareRequest(context.Background(), ordersTool(), "listOrders", map[string]any{
+		"storeId": 42,
+		"status":  "open, shipped",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "GET", req.Method)
+	require.Equal(t, "https://example.com/api/stores/42/orders?status=open&status=shipped", req.URL.String())
+	require.Nil(t, req.Body)
+}
+
+func Test_prepareRequest_FormBody(t *testing.T) {
+	strategy := newTestStrategy(t)
+
+	req, err := strategy.prepareRequest(context.Background(), ordersTool(), "sendFeedback", map[string]any{
+		"body": map[string]any{"rating": "5", "comment": "Great service"},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "application/x-www-form-urlencoded", req.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	require.Equal(t, "comment=Great+service&rating=5", string(body))
+}
+
+func Test_GetParametersFromSchema_Body(t *testing.T) {
+	params, err := GetParametersFromSchema(ordersAPISpec, "createOrder")
+	require.NoError(t, err)
+	require.Len(t, params, 4)
+
+	names := make(map[string]*Parameter)
+	for _, param := range params {
+		names[param.Name] = param
+	}
+
+	require.True(t, names["storeId"].Required)
+	require.Equal(t, ParameterTypeInteger, names["storeId"].Type)
+	require.Equal(t, ParameterTypeBoolean, names["dryRun"].Type)
+	require.True(t, names["body"].Required)
+	require.Equal(t, ParameterTypeObject, names["body"].Type)
+	require.Equal(t, []string{"items"}, names["body"].Schema["required"])
+}
+
+const notesAPISpec = `openapi: "3.0.0"
+info:
+  version: 1.0.0
+  title: Notes
+paths:
+  /notes:
+    post:
+      summary: Add a note to a job
+      operationId: addNote
+      parameters:
+        - name: job_id
+          in: query
+          required: true
+          schema:
+            type: string
+        - name: X-Tenant
+          in: header
+          required: true
+          schema:
+            type: string
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                text:
+                  type: string
+                job_id:
+                  type: string
+                X-Tenant:
+                  type: string
+      responses:
+        '201':
+          description: Created
+`
+
+func notesTool() *types.Tool {
+	return &types.Tool{
+		ID:       "tool-notes",
+		Name:     "notes",
+		ToolType: types.ToolTypeAPI,
+		Config: types.ToolConfig{
+			API: &types.ToolAPIConfig{
+				URL:    "https://example.com/api",
+				Schema: notesAPISpec,
+			},
+		},
+	}
+}
+
+func Test_prepareRequest_BodyFromParameters_ExcludesParameters(t *testing.T) {
+	strategy := newTestStrategy(t)
+
+	req, err := strategy.prepareRequest(context.Background(), notesTool(), "addNote", map[string]any{
+		"text":     "Called the customer",
+		"job_id":   "job-1",
+		"X-Tenant": "acme",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "https://example.com/api/notes?job_id=job-1", req.URL.String())
+	require.Equal(t, "acme", req.Header.Get("X-Tenant"))
+
+	// The query and header parameters aren't sent in the body as well
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"text":"Called the customer"}`, string(body))
+}
+
+func Test_prepareRequest_RequiredParametersFromConfig(t *testing.T) {
+	strategy := newTestStrategy(t)
+
+	tool := notesTool()
+	tool.Config.API.Query = map[string]string{"job_id": "job-1"}
+	tool.Config.API.Headers = map[string]string{"x-tenant": "acme"}
+
+	req, err := strategy.prepareRequest(context.Background(), tool, "addNote", map[string]any{
+		"text": "Called the customer",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "https://example.com/api/notes?job_id=job-1", req.URL.String())
+	require.Equal(t, "acme", req.Header.Get("X-Tenant"))
+
+	// Without the config they are missing
+	_, err = strategy.prepareRequest(context.Background(), notesTool(), "addNote", map[string]any{
+		"text": "Called the customer",
+	})
+	require.ErrorIs(t, err, ErrInvalidParameters)
+	require.ErrorContains(t, err, "missing required parameter")
+}
@@ -28,7 +28,7 @@ func (c *ChainStrategy) interpretResponse(ctx context.Context, client oai.Client
 
 func (c *ChainStrategy) handleSuccessResponse(ctx context.Context, client oai.Client, sessionID, interactionID string, tool *types.Tool, history []*types.ToolHistoryMessage, body []byte) (*RunActionResponse, error) {
 	messages := c.prepareSuccessMessages(tool, history, body)
-	req := c.prepareChatCompletionRequest(messages, false, tool.Config.API.Model)
+	req := c.prepareChatCompletionRequest(messages, false, apiConfig(tool).Model)
 
 	ctx = c.setContextAndStep(ctx, sessionID, interactionID, types.LLMCallStepInterpretResponse)
 
@@ -49,7 +49,7 @@ func (c *ChainStrategy) handleSuccessResponse(ctx context.Context, client oai.Cl
 
 func (c *ChainStrategy) handleSuccessResponseStream(ctx context.Context, client oai.Client, sessionID, interactionID string, tool *types.Tool, history []*types.ToolHistoryMessage, body []byte) (*openai.ChatCompletionStream, error) {
 	messages := c.prepareSuccessMessages(tool, history, body)
-	req := c.prepareChatCompletionRequest(messages, true, tool.Config.API.Model)
+	req := c.prepareChatCompletionRequest(messages, true, apiConfig(tool).Model)
 
 	ctx = c.setContextAndStep(ctx, sessionID, interactionID, types.LLMCallStepInterpretResponse)
 
@@ -67,8 +67,8 @@ func (c *ChainStrategy) handleSuccessResponseStream(ctx context.Context, client
 
 func (c *ChainStrategy) handleErrorResponse(ctx context.Context, client oai.Client, sessionID, interactionID string, tool *types.Tool, statusCode int, body []byte) (*RunActionResponse, error) {
 	systemPrompt := errorResponsePrompt
-	if tool.Config.API.ResponseErrorTemplate != "" {
-		systemPrompt = tool.Config.API.ResponseErrorTemplate
+	if apiConfig(tool).ResponseErrorTemplate != "" {
+		systemPrompt = apiConfig(tool).ResponseErrorTemplate
 	}
 
 	messages := []openai.ChatCompletionMessage{
@@ -88,8 +88,8 @@ func (c *ChainStrategy) handleErrorResponse(ctx context.Context, client oai.Clie
 		Messages: messages,
 	}
 	// override with tool model if specified
-	if tool.Config.API.Model != "" {
-		req.Model = tool.Config.API.Model
+	if apiConfig(tool).Model != "" {
+		req.Model = apiConfig(tool).Model
 	}
 
 	ctx = oai.SetContextValues(ctx, &oai.ContextValues{
@@ -132,8 +132,8 @@ func (c *ChainStrategy) interpretResponseStream(ctx context.Context, client oai.
 
 func (c *ChainStrategy) prepareSuccessMessages(tool *types.Tool, history []*types.ToolHistoryMessage, body []byte) []openai.ChatCompletionMessage {
 	systemPrompt := successResponsePrompt
-	if tool.Config.API.ResponseSuccessTemplate != "" {
-		systemPrompt = tool.Config.API.ResponseSuccessTemplate
+	if apiConfig(tool).ResponseSuccessTemplate != "" {
+		systemPrompt = apiConfig(tool).ResponseSuccessTemplate
 	}
 
 	messages := []openai.ChatCompletionMessage{
@@ -166,6 +166,15 @@ func (c *ChainStrategy) prepareSuccessMessages(tool *types.Tool, history []*type
 	return messages
 }
 
+// apiConfig returns the API config of the tool, the responses of the other
+// tools are presented with the defaults
+func apiConfig(tool *types.Tool) *types.ToolAPIConfig {
+	if tool.Config.API != nil {
+		return tool.Config.API
+	}
+	return &types.ToolAPIConfig{}
+}
+
 func (c *ChainStrategy) prepareChatCompletionRequest(messages []openai.ChatCompletionMessage, stream bool, overrideModel string) openai.ChatCompletionRequest {
 	req := openai.ChatCompletionRequest{
 		Stream:   stream,
@@ -7,7 +7,6 @@ import (
 	"net/http"
 	"time"
 
-	oai "github.com/helixml/helix/api/pkg/openai"
 	"github.com/helixml/helix/api/pkg/types"
 
 	"github.com/avast/retry-go/v4"
@@ -20,6 +19,12 @@ const (
 	delayBetweenAPIRetries = 50 * time.Millisecond
 )
 
+// Parameters of the GPTScript and Zapier tools when they run with parameters
+const (
+	GPTScriptInputParameter = "input"
+	ZapierPromptParameter   = "prompt"
+)
+
 type RunActionResponse struct {
 	Message    string `json:"message"`     // Interpreted message
 	RawMessage string `json:"raw_message"` // Raw message from the API
@@ -43,7 +48,7 @@ func (c *ChainStrategy) RunAction(ctx context.Context, sessionID, interactionID
 	case types.ToolTypeAPI:
 		return retry.DoWithData(
 			func() (*RunActionResponse, error) {
-				return c.runAPIAction(ctx, opts.client, sessionID, interactionID, tool, history, action)
+				return c.runAPIAction(ctx, opts, sessionID, interactionID, tool, history, action)
 			},
 			retry.Attempts(apiActionRetries),
 			retry.Delay(delayBetweenAPIRetries),
@@ -51,6 +56,8 @@ func (c *ChainStrategy) RunAction(ctx context.Context, sessionID, interactionID
 		)
 	case types.ToolTypeZapier:
 		return c.RunZapierAction(ctx, opts.client, tool, history, action)
+	case types.ToolTypeMCP:
+		return c.runMCPAction(ctx, opts, sessionID, interactionID, tool, history, action)
 	default:
 		return nil, fmt.Errorf("unknown tool type: %s", tool.ToolType)
 	}
@@ -71,35 +78,37 @@ func (c *ChainStrategy) RunActionStream(ctx context.Context, sessionID, interact
 	case types.ToolTypeGPTScript:
 		return c.RunGPTScriptActionStream(ctx, tool, history, action)
 	case types.ToolTypeAPI:
-		return c.runAPIActionStream(ctx, opts.client, sessionID, interactionID, tool, history, action)
+		return c.runAPIActionStream(ctx, opts, sessionID, interactionID, tool, history, action)
 	case types.ToolTypeZapier:
 		return c.RunZapierActionStream(ctx, opts.client, tool, history, action)
+	case types.ToolTypeMCP:
+		return c.runMCPActionStream(ctx, opts, sessionID, interactionID, tool, history, action)
 	default:
 		return nil, fmt.Errorf("unknown tool type: %s", tool.ToolType)
 	}
 }
 
-func (c *ChainStrategy) runAPIAction(ctx context.Context, client oai.Client, sessionID, interactionID string, tool *types.Tool, history []*types.ToolHistoryMessage, action string) (*RunActionResponse, error) {
-	resp, err := c.callAPI(ctx, client, sessionID, interactionID, tool, history, action)
+func (c *ChainStrategy) runAPIAction(ctx context.Context, opts Options, sessionID, interactionID string, tool *types.Tool, history []*types.ToolHistoryMessage, action string) (*RunActionResponse, error) {
+	resp, err := c.callAPI(ctx, opts, sessionID, interactionID, tool, history, action)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call api: %w", err)
 	}
 	defer resp.Body.Close()
 
-	return c.interpretResponse(ctx, client, sessionID, interactionID, tool, history, resp)
+	return c.interpretResponse(ctx, opts.client, sessionID, interactionID, tool, history, resp)
 }
 
-func (c *ChainStrategy) runAPIActionStream(ctx context.Context, client oai.Client, sessionID, interactionID string, tool *types.Tool, history []*types.ToolHistoryMessage, action string) (*openai.ChatCompletionStream, error) {
-	resp, err := c.callAPI(ctx, client, sessionID, interactionID, tool, history, action)
+func (c *ChainStrategy) runAPIActionStream(ctx context.Context, opts Options, sessionID, interactionID string, tool *types.Tool, history []*types.ToolHistoryMessage, action string) (*openai.ChatCompletionStream, error) {
+	resp, err := c.callAPI(ctx, opts, sessionID, interactionID, tool, history, action)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call api: %w", err)
 	}
 	defer resp.Body.Close()
 
-	return c.interpretResponseStream(ctx, client, sessionID, interactionID, tool, history, resp)
+	return c.interpretResponseStream(ctx, opts.client, sessionID, interactionID, tool, history, resp)
 }
 
-func (c *ChainStrategy) callAPI(ctx context.Context, client oai.Client, sessionID, interactionID string, tool *types.Tool, history []*types.ToolHistoryMessage, action string) (*http.Response, error) {
+func (c *ChainStrategy) callAPI(ctx context.Context, opts Options, sessionID, interactionID string, tool *types.Tool, history []*types.ToolHistoryMessage, action string) (*http.Response, error) {
 	// Validate whether action is valid
 	if action == "" {
 		return nil, fmt.Errorf("action is required")
@@ -121,7 +130,7 @@ func (c *ChainStrategy) callAPI(ctx context.Context, client oai.Client, sessionI
 	started := time.Now()
 
 	// Get API request parameters
-	params, err := c.getAPIRequestParameters(ctx, client, sessionID, interactionID, tool, history, action)
+	params, err := c.getAPIRequestParameters(ctx, opts, sessionID, interactionID, tool, history, action)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get api request parameters: %w", err)
 	}
@@ -139,6 +148,10 @@ func (c *ChainStrategy) callAPI(ctx context.Context, client oai.Client, sessionI
 		return nil, fmt.Errorf("failed to prepare request: %w", err)
 	}
 
+	if err := c.authenticateRequest(ctx, opts, tool, action, req); err != nil {
+		return nil, fmt.Errorf("failed to authenticate request: %w", err)
+	}
+
 	log.Info().
 		Str("tool", tool.Name).
 		Str("action", action).
@@ -187,7 +200,27 @@ func (c *ChainStrategy) RunAPIActionWithParameters(ctx context.Context, req *typ
 
 	if req.Parameters == nil {
 		// Initialize empty parameters map, some API actions don't require parameters
-		req.Parameters = make(map[string]string)
+		req.Parameters = make(map[string]any)
+	}
+
+	switch req.Tool.ToolType {
+	case types.ToolTypeGPTScript:
+		return c.runActionWithInput(ctx, opts, req, GPTScriptInputParameter, false)
+	case types.ToolTypeZapier:
+		return c.runActionWithInput(ctx, opts, req, ZapierPromptParameter, true)
+	case types.ToolTypeMCP:
+		text, isError, err := c.callMCPTool(ctx, opts, req.Tool, req.Action, req.Parameters)
+		if err != nil {
+			return nil, err
+		}
+		if isError {
+			return &types.RunAPIActionResponse{Error: text}, nil
+		}
+		return &types.RunAPIActionResponse{Response: text}, nil
+	case types.ToolTypeAPI:
+		// Called below
+	default:
+		return nil, fmt.Errorf("unknown tool type: %s", req.Tool.ToolType)
 	}
 
 	log.Info().
@@ -200,6 +233,10 @@ func (c *ChainStrategy) RunAPIActionWithParameters(ctx context.Context, req *typ
 		return nil, fmt.Errorf("failed to prepare request: %w", err)
 	}
 
+	if err := c.authenticateRequest(ctx, opts, req.Tool, req.Action, httpRequest); err != nil {
+		return nil, fmt.Errorf("failed to authenticate request: %w", err)
+	}
+
 	resp, err := c.httpClient.Do(httpRequest)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make api call: %w", err)
@@ -213,3 +250,40 @@ func (c *ChainStrategy) RunAPIActionWithParameters(ctx context.Context, req *typ
 
 	return &types.RunAPIActionResponse{Response: string(body)}, nil
 }
+
+// runActionWithInput runs the GPTScript or Zapier tool with the parameter as
+// the message of the user
+func (c *ChainStrategy) runActionWithInput(ctx context.Context, opts Options, req *types.RunAPIActionRequest, parameter string, required bool) (*types.RunAPIActionResponse, error) {
+	var input string
+	if value, ok := req.Parameters[parameter]; ok {
+		input, ok = value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s must be a string", ErrInvalidParameters, parameter)
+		}
+	}
+	if input == "" && required {
+		return nil, fmt.Errorf("%w: %s is required", ErrInvalidParameters, parameter)
+	}
+
+	history := []*types.ToolHistoryMessage{
+		{
+			Role:    openai.ChatMessageRoleUser,
+			Content: input,
+		},
+	}
+
+	var (
+		resp *RunActionResponse
+		err  error
+	)
+	if req.Tool.ToolType == types.ToolTypeZapier {
+		resp, err = c.RunZapierAction(ctx, opts.client, req.Tool, history, req.Action)
+	} else {
+		resp, err = c.RunGPTScriptAction(ctx, req.Tool, history, req.Action)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.RunAPIActionResponse{Response: resp.Message, Error: resp.Error}, nil
+}
@@ -96,3 +96,38 @@ func (suite *ActionTestSuite) TestAction_runGPTScriptAction_ReceiveInput() {
 	fmt.Println("U:", history[0].Content)
 	fmt.Println("A:", resp.Message)
 }
+
+func (suite *ActionTestSuite) TestAction_RunAPIActionWithParameters_gptScript() {
+	suite.executor.EXPECT().ExecuteScript(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, script *types.GptScript) (*types.GptScriptResponse, error) {
+			suite.Equal("Hello World", script.Input)
+			return &types.GptScriptResponse{
+				Output: `Hello World`,
+			}, nil
+		})
+
+	echoGptScript := &types.Tool{
+		Name:     "echo",
+		ToolType: types.ToolTypeGPTScript,
+		Config: types.ToolConfig{
+			GPTScript: &types.ToolGPTScriptConfig{
+				Script: echoGPT,
+			},
+		},
+	}
+
+	resp, err := suite.strategy.RunAPIActionWithParameters(suite.ctx, &types.RunAPIActionRequest{
+		Tool:       echoGptScript,
+		Action:     "echo",
+		Parameters: map[string]any{GPTScriptInputParameter: "Hello World"},
+	})
+	suite.Require().NoError(err)
+	suite.Equal("Hello World", resp.Response)
+
+	_, err = suite.strategy.RunAPIActionWithParameters(suite.ctx, &types.RunAPIActionRequest{
+		Tool:       echoGptScript,
+		Action:     "echo",
+		Parameters: map[string]any{GPTScriptInputParameter: 42},
+	})
+	suite.ErrorIs(err, ErrInvalidParameters)
+}
@@ -0,0 +1,546 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog/log"
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/helixml/helix/api/pkg/data"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// MCP tools connect to external Model Context Protocol servers, the tools the
+// servers list are the actions. The sessions with the servers are kept open
+// per user, app and server until they are idle, so stateful servers keep
+// their state between the calls. The listed tools are cached for a while.
+
+// ErrMCPStdioDisabled is returned for the MCP tools with commands when the
+// server doesn't allow them
+var ErrMCPStdioDisabled = errors.New("mcp servers with commands are disabled, set TOOLS_MCP_STDIO_ENABLED to enable them")
+
+const mcpClientName = "helix"
+
+const mcpParametersPrompt = `You are an intelligent machine learning model that calls tools. Produce the arguments of the tool for the last user message, taken from the conversation, as a JSON object following the JSON schema below. Leave out optional arguments the user didn't mention and use sensible defaults for the required ones.
+
+**Response Format:** Always respond with JSON without any commentary, wrapped in markdown json tags, for example:
+` + "```" + `json
+{
+  "argumentName": "argumentValue"
+}
+` + "```" + `
+
+JSON schema of the arguments:
+
+%s`
+
+type mcpToolsCacheEntry struct {
+	tools   []*types.ToolMCPTool
+	expires time.Time
+}
+
+// mcpPooledSession is an open session with an MCP server, it's closed once no
+// call used it for the idle timeout
+type mcpPooledSession struct {
+	session mcpSession
+	active  int  // Calls using the session
+	evicted bool // Failed sessions are closed once their calls are done
+	timer   *time.Timer
+}
+
+// loadMCPTools lists the tools of the MCP servers so they can be picked as
+// actions. Servers that can't be reached are left out with their tools.
+func (c *ChainStrategy) loadMCPTools(ctx context.Context, opts Options, tools []*types.Tool) {
+	for _, tool := range tools {
+		if tool.ToolType != types.ToolTypeMCP || tool.Config.MCP == nil || len(tool.Config.MCP.Tools) > 0 {
+			continue
+		}
+
+		mcpTools, err := c.listMCPTools(ctx, opts, tool)
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Str("tool", tool.Name).
+				Msg("failed to list mcp tools, skipping")
+			continue
+		}
+		tool.Config.MCP.Tools = mcpTools
+	}
+}
+
+// listMCPTools returns the tools of the MCP server, cached per user and app as
+// the secrets of the server depend on them
+func (c *ChainStrategy) listMCPTools(ctx context.Context, opts Options, tool *types.Tool) ([]*types.ToolMCPTool, error) {
+	key, err := mcpServerKey(opts, tool.Config.MCP)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mcpToolsMu.Lock()
+	entry, ok := c.mcpTools[key]
+	c.mcpToolsMu.Unlock()
+
+	if ok && time.Now().Before(entry.expires) {
+		return entry.tools, nil
+	}
+
+	ctx, cancel := c.mcpContext(ctx)
+	defer cancel()
+
+	client, release, err := c.getMCPSession(ctx, opts, tool)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := client.ListTools(ctx, mcp.ListToolsRequest{})
+	release(err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tools of mcp server: %w", err)
+	}
+
+	var mcpTools []*types.ToolMCPTool
+	for _, t := range result.Tools {
+		mcpTools = append(mcpTools, &types.ToolMCPTool{
+			Name:        t.Name,
+			Description: t.Description,
+			InputSchema: mcpInputSchema(t.InputSchema),
+		})
+	}
+
+	c.mcpToolsMu.Lock()
+	c.mcpTools[key] = &mcpToolsCacheEntry{
+		tools:   mcpTools,
+		expires: time.Now().Add(c.cfg.Tools.MCPToolsCacheTTL),
+	}
+	c.mcpToolsMu.Unlock()
+
+	return mcpTools, nil
+}
+
+// callMCPTool calls the tool of the MCP server, the text of the result is
+// returned with whether the tool failed
+func (c *ChainStrategy) callMCPTool(ctx context.Context, opts Options, tool *types.Tool, action string, arguments map[string]any) (string, bool, error) {
+	ctx, cancel := c.mcpContext(ctx)
+	defer cancel()
+
+	client, release, err := c.getMCPSession(ctx, opts, tool)
+	if err != nil {
+		return "", false, err
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = action
+	request.Params.Arguments = arguments
+
+	log.Info().
+		Str("tool", tool.Name).
+		Str("action", action).
+		Msg("calling mcp tool")
+
+	result, err := client.CallTool(ctx, request)
+	release(err)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to call mcp tool %s: %w", action, err)
+	}
+
+	return mcpResultText(result), result.IsError, nil
+}
+
+func (c *ChainStrategy) mcpContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.cfg.Tools.MCPTimeout > 0 {
+		return context.WithTimeout(ctx, c.cfg.Tools.MCPTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// getMCPSession returns the open session with the MCP server of the user and
+// app, it's connected if there is none. The returned function gives the
+// session back with the error of the call, failed sessions aren't reused.
+func (c *ChainStrategy) getMCPSession(ctx context.Context, opts Options, tool *types.Tool) (mcpSession, func(error), error) {
+	idleTimeout := c.cfg.Tools.MCPSessionIdleTimeout
+	if idleTimeout <= 0 {
+		session, err := c.connectMCP(ctx, opts, tool)
+		if err != nil {
+			return nil, nil, err
+		}
+		return session, func(error) { closeMCPClient(session, tool) }, nil
+	}
+
+	key, err := mcpServerKey(opts, tool.Config.MCP)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mcpSessionsMu.Lock()
+	pooled := c.acquireMCPSession(key, tool)
+	c.mcpSessionsMu.Unlock()
+
+	if pooled != nil {
+		return pooled.session, c.releaseMCPSession(key, pooled, tool), nil
+	}
+
+	session, err := c.connectMCP(ctx, opts, tool)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mcpSessionsMu.Lock()
+	defer c.mcpSessionsMu.Unlock()
+
+	// Another call may have connected in the meantime
+	if pooled := c.acquireMCPSession(key, tool); pooled != nil {
+		go closeMCPClient(session, tool)
+		return pooled.session, c.releaseMCPSession(key, pooled, tool), nil
+	}
+
+	pooled = &mcpPooledSession{session: session, active: 1}
+	pooled.timer = time.AfterFunc(idleTimeout, func() {
+		c.closeIdleMCPSession(key, pooled, tool)
+	})
+	pooled.timer.Stop()
+	c.mcpSessions[key] = pooled
+
+	return session, c.releaseMCPSession(key, pooled, tool), nil
+}
+
+// acquireMCPSession returns the pooled session if its connection is still
+// open, the lock must be held
+func (c *ChainStrategy) acquireMCPSession(key string, tool *types.Tool) *mcpPooledSession {
+	pooled, ok := c.mcpSessions[key]
+	if !ok {
+		return nil
+	}
+
+	if closed, ok := pooled.session.(interface{ closed() bool }); ok && closed.closed() {
+		// The server ended the event stream
+		delete(c.mcpSessions, key)
+		pooled.evicted = true
+		if pooled.active == 0 {
+			go closeMCPClient(pooled.session, tool)
+		}
+		return nil
+	}
+
+	pooled.active++
+	pooled.timer.Stop()
+	return pooled
+}
+
+func (c *ChainStrategy) releaseMCPSession(key string, pooled *mcpPooledSession, tool *types.Tool) func(error) {
+	return func(err error) {
+		c.mcpSessionsMu.Lock()
+		defer c.mcpSessionsMu.Unlock()
+
+		pooled.active--
+
+		if err != nil && !pooled.evicted {
+			pooled.evicted = true
+			if c.mcpSessions[key] == pooled {
+				delete(c.mcpSessions, key)
+			}
+		}
+
+		switch {
+		case pooled.active > 0:
+		case pooled.evicted:
+			go closeMCPClient(pooled.session, tool)
+		default:
+			pooled.timer.Reset(c.cfg.Tools.MCPSessionIdleTimeout)
+		}
+	}
+}
+
+// closeIdleMCPSession closes the session unless it was used again
+func (c *ChainStrategy) closeIdleMCPSession(key string, pooled *mcpPooledSession, tool *types.Tool) {
+	c.mcpSessionsMu.Lock()
+	if pooled.active > 0 || pooled.evicted {
+		c.mcpSessionsMu.Unlock()
+		return
+	}
+	pooled.evicted = true
+	if c.mcpSessions[key] == pooled {
+		delete(c.mcpSessions, key)
+	}
+	c.mcpSessionsMu.Unlock()
+
+	log.Debug().Str("tool", tool.Name).Msg("closing idle mcp session")
+	closeMCPClient(pooled.session, tool)
+}
+
+// connectMCP starts the MCP server of the tool or connects to its event
+// stream and initializes the session. The session is open until it's closed.
+func (c *ChainStrategy) connectMCP(ctx context.Context, opts Options, tool *types.Tool) (mcpSession, error) {
+	cfg := tool.Config.MCP
+	if cfg == nil {
+		return nil, fmt.Errorf("tool %s does not have an MCP config", tool.Name)
+	}
+
+	var client mcpSession
+
+	switch {
+	case cfg.URL != "":
+		// Not the retrying client, the calls of the tools aren't idempotent
+		sseClient, err := newMCPSSEClient(ctx, http.DefaultClient, cfg.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to mcp server %s: %w", cfg.URL, err)
+		}
+		client = sseClient
+	case cfg.Command != "":
+		if !c.cfg.Tools.MCPStdioEnabled {
+			return nil, ErrMCPStdioDisabled
+		}
+
+		env, err := c.mcpEnv(ctx, opts, tool)
+		if err != nil {
+			return nil, err
+		}
+
+		stdioClient, err := mcpclient.NewStdioMCPClient(cfg.Command, env, cfg.Args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start mcp server %s: %w", cfg.Command, err)
+		}
+		client = stdioClient
+	default:
+		return nil, fmt.Errorf("tool %s needs either the command or the URL of the MCP server", tool.Name)
+	}
+
+	request := mcp.InitializeRequest{}
+	request.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	request.Params.ClientInfo = mcp.Implementation{
+		Name:    mcpClientName,
+		Version: data.GetHelixVersion(),
+	}
+
+	if _, err := client.Initialize(ctx, request); err != nil {
+		closeMCPClient(client, tool)
+		return nil, fmt.Errorf("failed to initialize mcp server: %w", err)
+	}
+
+	return client, nil
+}
+
+// mcpEnv returns the environment of the command with the values of its
+// secrets, in a stable order
+func (c *ChainStrategy) mcpEnv(ctx context.Context, opts Options, tool *types.Tool) ([]string, error) {
+	cfg := tool.Config.MCP
+
+	var env []string
+	for name, value := range cfg.Env {
+		env = append(env, name+"="+value)
+	}
+
+	for name, secret := range cfg.Secrets {
+		value, err := c.secretValue(ctx, opts, tool, secret)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, name+"="+value)
+	}
+
+	sort.Strings(env)
+
+	return env, nil
+}
+
+func closeMCPClient(client mcpSession, tool *types.Tool) {
+	if err := client.Close(); err != nil {
+		log.Debug().Err(err).Str("tool", tool.Name).Msg("failed to close mcp client")
+	}
+}
+
+// mcpServerKey identifies the server of the user and app, the secrets of the
+// server depend on them
+func mcpServerKey(opts Options, cfg *types.ToolMCPConfig) (string, error) {
+	// The listed tools aren't part of the key
+	server := *cfg
+	server.Tools = nil
+
+	bts, err := json.Marshal(server)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal mcp config: %w", err)
+	}
+
+	return opts.owner + "/" + opts.appID + "/" + string(bts), nil
+}
+
+func mcpInputSchema(schema mcp.ToolInputSchema) map[string]any {
+	properties := schema.Properties
+	if properties == nil {
+		properties = map[string]any{}
+	}
+
+	converted := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(schema.Required) > 0 {
+		converted["required"] = schema.Required
+	}
+	return converted
+}
+
+// mcpResultText joins the text contents of the result, the other contents
+// are kept as JSON
+func mcpResultText(result *mcp.CallToolResult) string {
+	var parts []string
+
+	for _, content := range result.Content {
+		if m, ok := content.(map[string]any); ok && m["type"] == "text" {
+			if text, ok := m["text"].(string); ok {
+				parts = append(parts, text)
+				continue
+			}
+		}
+		bts, err := json.Marshal(content)
+		if err != nil {
+			continue
+		}
+		parts = append(parts, string(bts))
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+func findMCPTool(tool *types.Tool, action string) *types.ToolMCPTool {
+	if tool.Config.MCP == nil {
+		return nil
+	}
+	for _, t := range tool.Config.MCP.Tools {
+		if t.Name == action {
+			return t
+		}
+	}
+	return nil
+}
+
+func mcpFunction(t *types.ToolMCPTool) *openai.FunctionDefinition {
+	parameters := t.InputSchema
+	if parameters == nil {
+		parameters = emptyParameters()
+	}
+
+	return &openai.FunctionDefinition{
+		Name:        functionName(t.Name),
+		Description: t.Description,
+		Parameters:  parameters,
+	}
+}
+
+// getMCPToolArguments asks the model for the arguments of the tool of the MCP
+// server, taken from the conversation
+func (c *ChainStrategy) getMCPToolArguments(ctx context.Context, opts Options, sessionID, interactionID string, tool *types.Tool, history []*types.ToolHistoryMessage, action string) (map[string]any, error) {
+	if findMCPTool(tool, action) == nil {
+		mcpTools, err := c.listMCPTools(ctx, opts, tool)
+		if err != nil {
+			return nil, err
+		}
+		tool.Config.MCP.Tools = mcpTools
+	}
+
+	mcpTool := findMCPTool(tool, action)
+	if mcpTool == nil {
+		return nil, fmt.Errorf("action %s is not found in the tool %s", action, tool.Name)
+	}
+
+	model := c.cfg.Tools.Model
+	if opts.model != "" {
+		model = opts.model
+	}
+
+	// The function call that picked the action already has the arguments
+	if opts.arguments != nil {
+		return opts.arguments, nil
+	}
+
+	ctx = c.setContextAndStep(ctx, sessionID, interactionID, types.LLMCallStepPrepareAPIRequest)
+
+	if opts.nativeToolCalling {
+		started := time.Now()
+		arguments, err := c.getFunctionArguments(ctx, opts.client, model, mcpFunction(mcpTool), history)
+		if err == nil {
+			return arguments, nil
+		}
+		logFunctionCallingFallback(err, types.LLMCallStepPrepareAPIRequest, started)
+	}
+
+	schema, err := json.MarshalIndent(mcpFunction(mcpTool).Parameters, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal input schema: %w", err)
+	}
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: fmt.Sprintf(mcpParametersPrompt, schema),
+		},
+	}
+	for _, msg := range history {
+		if msg.Role != openai.ChatMessageRoleSystem {
+			messages = append(messages, openai.ChatCompletionMessage{
+				Role:    msg.Role,
+				Content: msg.Content,
+			})
+		}
+	}
+	messages[len(messages)-1].Content += "\nReturn the corresponding json for the last user input"
+
+	resp, err := opts.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:    model,
+		Messages: messages,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get response from inference API: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from inference API")
+	}
+
+	return unmarshalParams(resp.Choices[0].Message.Content)
+}
+
+// runMCPAction calls the tool of the MCP server with the arguments from the
+// conversation and has the model present the result
+func (c *ChainStrategy) runMCPAction(ctx context.Context, opts Options, sessionID, interactionID string, tool *types.Tool, history []*types.ToolHistoryMessage, action string) (*RunActionResponse, error) {
+	text, isError, err := c.callMCPToolFromHistory(ctx, opts, sessionID, interactionID, tool, history, action)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.handleSuccessResponse(ctx, opts.client, sessionID, interactionID, tool, history, []byte(text))
+	if err != nil {
+		return nil, err
+	}
+	if isError {
+		resp.Error = text
+	}
+
+	return resp, nil
+}
+
+func (c *ChainStrategy) runMCPActionStream(ctx context.Context, opts Options, sessionID, interactionID string, tool *types.Tool, history []*types.ToolHistoryMessage, action string) (*openai.ChatCompletionStream, error) {
+	text, _, err := c.callMCPToolFromHistory(ctx, opts, sessionID, interactionID, tool, history, action)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.handleSuccessResponseStream(ctx, opts.client, sessionID, interactionID, tool, history, []byte(text))
+}
+
+func (c *ChainStrategy) callMCPToolFromHistory(ctx context.Context, opts Options, sessionID, interactionID string, tool *types.Tool, history []*types.ToolHistoryMessage, action string) (string, bool, error) {
+	arguments, err := c.getMCPToolArguments(ctx, opts, sessionID, interactionID, tool, history, action)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get arguments for mcp tool %s: %w", action, err)
+	}
+
+	return c.callMCPTool(ctx, opts, tool, action, arguments)
+}
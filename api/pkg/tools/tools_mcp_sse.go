@@ -0,0 +1,309 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxMCPEventSize is the largest event read from the stream, tool results can
+// be large
+const maxMCPEventSize = 10 * 1024 * 1024
+
+// mcpSession is the part of the MCP clients the tools use
+type mcpSession interface {
+	Initialize(ctx context.Context, request mcp.InitializeRequest) (*mcp.InitializeResult, error)
+	ListTools(ctx context.Context, request mcp.ListToolsRequest) (*mcp.ListToolsResult, error)
+	CallTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error)
+	Close() error
+}
+
+// mcpSSEClient talks to MCP servers with the HTTP with SSE transport: the
+// responses come on the event stream and the requests are posted to the
+// endpoint the stream starts with. The SSE client of mcp-go drops the
+// parameters of the requests and the errors of the responses.
+type mcpSSEClient struct {
+	httpClient *http.Client
+	endpoint   string
+	stream     io.ReadCloser
+	cancel     context.CancelFunc
+	requestID  atomic.Int64
+
+	mu        sync.Mutex
+	responses map[int64]chan *jsonRPCResponse
+	done      chan struct{}
+}
+
+var _ mcpSession = &mcpSSEClient{}
+
+type jsonRPCResponse struct {
+	ID     *int64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// newMCPSSEClient opens the event stream and waits for the endpoint of the
+// requests. The stream outlives the context so the session can be reused, it
+// is open until the client is closed.
+func newMCPSSEClient(ctx context.Context, httpClient *http.Client, streamURL string) (*mcpSSEClient, error) {
+	base, err := url.Parse(streamURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mcp server URL: %w", err)
+	}
+
+	streamCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, streamURL, nil)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to connect to the event stream: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("unexpected status code of the event stream: %d", resp.StatusCode)
+	}
+
+	c := &mcpSSEClient{
+		httpClient: httpClient,
+		stream:     resp.Body,
+		cancel:     cancel,
+		responses:  make(map[int64]chan *jsonRPCResponse),
+		done:       make(chan struct{}),
+	}
+
+	endpoints := make(chan string, 1)
+	go c.readEvents(endpoints)
+
+	select {
+	case endpoint := <-endpoints:
+		ref, err := url.Parse(endpoint)
+		if err != nil {
+			_ = c.Close()
+			return nil, fmt.Errorf("invalid endpoint %s: %w", endpoint, err)
+		}
+		resolved := base.ResolveReference(ref)
+		if resolved.Scheme != base.Scheme || resolved.Host != base.Host {
+			_ = c.Close()
+			return nil, fmt.Errorf("endpoint %s is not on the origin of the mcp server", endpoint)
+		}
+		c.endpoint = resolved.String()
+	case <-c.done:
+		_ = c.Close()
+		return nil, fmt.Errorf("event stream closed before the endpoint was sent")
+	case <-ctx.Done():
+		_ = c.Close()
+		return nil, fmt.Errorf("failed waiting for the endpoint: %w", ctx.Err())
+	}
+
+	return c, nil
+}
+
+// readEvents passes the responses to the requests waiting for them until the
+// stream ends
+func (c *mcpSSEClient) readEvents(endpoints chan<- string) {
+	defer func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		close(c.done)
+	}()
+
+	scanner := bufio.NewScanner(c.stream)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMCPEventSize)
+
+	var event string
+	var data []string
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case line == "":
+			c.handleEvent(event, strings.Join(data, "\n"), endpoints)
+			event, data = "", nil
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		}
+	}
+}
+
+func (c *mcpSSEClient) handleEvent(event, data string, endpoints chan<- string) {
+	switch event {
+	case "endpoint":
+		select {
+		case endpoints <- data:
+		default:
+		}
+	case "message":
+		var resp jsonRPCResponse
+		if err := json.Unmarshal([]byte(data), &resp); err != nil || resp.ID == nil {
+			// Notifications and requests of the server aren't used
+			return
+		}
+
+		c.mu.Lock()
+		ch, ok := c.responses[*resp.ID]
+		delete(c.responses, *resp.ID)
+		c.mu.Unlock()
+
+		if ok {
+			ch <- &resp
+		}
+	}
+}
+
+func (c *mcpSSEClient) request(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	id := c.requestID.Add(1)
+
+	ch := make(chan *jsonRPCResponse, 1)
+	c.mu.Lock()
+	c.responses[id] = ch
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.responses, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.post(ctx, map[string]any{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      id,
+		"method":  method,
+		"params":  params,
+	}); err != nil {
+		return nil, err
+	}
+
+	var resp *jsonRPCResponse
+	select {
+	case resp = <-ch:
+	case <-c.done:
+		// The response may have come right before the end of the stream
+		select {
+		case resp = <-ch:
+		default:
+			return nil, fmt.Errorf("event stream closed waiting for the response to %s", method)
+		}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if resp.Error != nil {
+		return nil, fmt.Errorf("mcp server returned error %d: %s", resp.Error.Code, resp.Error.Message)
+	}
+	return resp.Result, nil
+}
+
+func (c *mcpSSEClient) post(ctx context.Context, message map[string]any) error {
+	bts, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(bts))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("message failed with status %d: %s", resp.StatusCode, body)
+	}
+
+	return nil
+}
+
+func (c *mcpSSEClient) Initialize(ctx context.Context, request mcp.InitializeRequest) (*mcp.InitializeResult, error) {
+	result, err := c.request(ctx, "initialize", request.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	var initialized mcp.InitializeResult
+	if err := json.Unmarshal(result, &initialized); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal initialize result: %w", err)
+	}
+
+	if err := c.post(ctx, map[string]any{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"method":  "notifications/initialized",
+	}); err != nil {
+		return nil, err
+	}
+
+	return &initialized, nil
+}
+
+func (c *mcpSSEClient) ListTools(ctx context.Context, request mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
+	result, err := c.request(ctx, "tools/list", request.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	var tools mcp.ListToolsResult
+	if err := json.Unmarshal(result, &tools); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tools: %w", err)
+	}
+	return &tools, nil
+}
+
+func (c *mcpSSEClient) CallTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	result, err := c.request(ctx, "tools/call", request.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	var called mcp.CallToolResult
+	if err := json.Unmarshal(result, &called); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tool result: %w", err)
+	}
+	return &called, nil
+}
+
+// Close ends the event stream
+func (c *mcpSSEClient) Close() error {
+	c.cancel()
+	return c.stream.Close()
+}
+
+// closed reports whether the event stream has ended, the session can't be
+// used anymore
+func (c *mcpSSEClient) closed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
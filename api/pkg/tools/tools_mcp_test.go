@@ -0,0 +1,291 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	openai_ext "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/helixml/helix/api/pkg/config"
+	"github.com/helixml/helix/api/pkg/openai"
+	"github.com/helixml/helix/api/pkg/store"
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func newMCPTestServer(t *testing.T) *httptest.Server {
+	s := server.NewMCPServer("weather", "1.0.0")
+
+	s.AddTool(mcp.NewTool("getForecast",
+		mcp.WithDescription("Get the weather forecast for a city"),
+		mcp.WithString("city", mcp.Required()),
+		mcp.WithNumber("days"),
+	), func(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(fmt.Sprintf("Sunny in %v for %v days", request.Params.Arguments["city"], request.Params.Arguments["days"])), nil
+	})
+
+	s.AddTool(mcp.NewTool("getAlerts",
+		mcp.WithDescription("Get the weather alerts"),
+	), func(_ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultError("alerts are unavailable"), nil
+	})
+
+	ts := server.NewTestServer(s)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func newMCPTestStrategy(t *testing.T, client openai.Client) *ChainStrategy {
+	cfg := &config.ServerConfig{}
+	cfg.Tools.Model = "gpt-4o"
+	cfg.Tools.MCPTimeout = 10 * time.Second
+	cfg.Tools.MCPToolsCacheTTL = time.Minute
+
+	strategy, err := NewChainStrategy(cfg, nil, nil, client)
+	require.NoError(t, err)
+	return strategy
+}
+
+func weatherMCPTool(url string) *types.Tool {
+	return &types.Tool{
+		Name:        "weather",
+		Description: "Weather forecasts",
+		ToolType:    types.ToolTypeMCP,
+		Config: types.ToolConfig{
+			MCP: &types.ToolMCPConfig{
+				URL: url + "/sse",
+			},
+		},
+	}
+}
+
+func Test_listMCPTools(t *testing.T) {
+	ts := newMCPTestServer(t)
+	strategy := newMCPTestStrategy(t, nil)
+	tool := weatherMCPTool(ts.URL)
+
+	mcpTools, err := strategy.listMCPTools(context.Background(), strategy.getDefaultOptions(), tool)
+	require.NoError(t, err)
+	require.Len(t, mcpTools, 2)
+
+	forecast := mcpTools[0]
+	if forecast.Name != "getForecast" {
+		forecast = mcpTools[1]
+	}
+	require.Equal(t, "getForecast", forecast.Name)
+	require.Equal(t, "Get the weather forecast for a city", forecast.Description)
+	require.Equal(t, "object", forecast.InputSchema["type"])
+	require.ElementsMatch(t, []any{"city"}, forecast.InputSchema["required"])
+	require.Contains(t, forecast.InputSchema["properties"], "days")
+
+	// The tools are cached, the server isn't needed anymore
+	ts.Close()
+
+	cached, err := strategy.listMCPTools(context.Background(), strategy.getDefaultOptions(), tool)
+	require.NoError(t, err)
+	require.Equal(t, mcpTools, cached)
+}
+
+func Test_loadMCPTools(t *testing.T) {
+	ts := newMCPTestServer(t)
+	strategy := newMCPTestStrategy(t, nil)
+
+	tool := weatherMCPTool(ts.URL)
+	unreachable := weatherMCPTool("http://127.0.0.1:1")
+	unreachable.Name = "unreachable"
+
+	tools := []*types.Tool{unreachable, tool}
+	strategy.loadMCPTools(context.Background(), strategy.getDefaultOptions(), tools)
+
+	require.Empty(t, unreachable.Config.MCP.Tools)
+	require.Len(t, tool.Config.MCP.Tools, 2)
+
+	found, ok := GetToolFromAction(tools, "getAlerts")
+	require.True(t, ok)
+	require.Equal(t, tool, found)
+
+	prompt, err := strategy.getActionableSystemPrompt(tools, strategy.getDefaultOptions())
+	require.NoError(t, err)
+	require.Contains(t, prompt.Content, "getForecast")
+
+	functions, actions, err := toolFunctions(tools)
+	require.NoError(t, err)
+	require.Len(t, functions, 2)
+	require.Equal(t, "getForecast", actions["getForecast"])
+}
+
+func Test_RunAPIActionWithParameters_MCP(t *testing.T) {
+	ts := newMCPTestServer(t)
+	strategy := newMCPTestStrategy(t, nil)
+	tool := weatherMCPTool(ts.URL)
+
+	resp, err := strategy.RunAPIActionWithParameters(context.Background(), &types.RunAPIActionRequest{
+		Tool:       tool,
+		Action:     "getForecast",
+		Parameters: map[string]any{"city": "London", "days": 3},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Sunny in London for 3 days", resp.Response)
+
+	resp, err = strategy.RunAPIActionWithParameters(context.Background(), &types.RunAPIActionRequest{
+		Tool:   tool,
+		Action: "getAlerts",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "alerts are unavailable", resp.Error)
+}
+
+func Test_RunAction_MCP(t *testing.T) {
+	ts := newMCPTestServer(t)
+
+	ctrl := gomock.NewController(t)
+	client := openai.NewMockClient(ctrl)
+	strategy := newMCPTestStrategy(t, client)
+	tool := weatherMCPTool(ts.URL)
+
+	gomock.InOrder(
+		client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, req openai_ext.ChatCompletionRequest) (openai_ext.ChatCompletionResponse, error) {
+				// The arguments are asked for with the input schema
+				require.Contains(t, req.Messages[0].Content, `"city"`)
+				return openai_ext.ChatCompletionResponse{
+					Choices: []openai_ext.ChatCompletionChoice{
+						{Message: openai_ext.ChatCompletionMessage{Content: "```json\n{\"city\": \"Paris\", \"days\": 2}\n```"}},
+					},
+				}, nil
+			}),
+		client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, req openai_ext.ChatCompletionRequest) (openai_ext.ChatCompletionResponse, error) {
+				require.Contains(t, req.Messages[len(req.Messages)-2].Content, "Sunny in Paris for 2 days")
+				return openai_ext.ChatCompletionResponse{
+					Choices: []openai_ext.ChatCompletionChoice{
+						{Message: openai_ext.ChatCompletionMessage{Content: "It will be sunny in Paris."}},
+					},
+				}, nil
+			}),
+	)
+
+	history := []*types.ToolHistoryMessage{
+		{Role: openai_ext.ChatMessageRoleUser, Content: "What's the weather in Paris for the next two days?"},
+	}
+
+	resp, err := strategy.RunAction(context.Background(), "session-1", "interaction-1", tool, history, "getForecast")
+	require.NoError(t, err)
+	require.Equal(t, "It will be sunny in Paris.", resp.Message)
+	require.Equal(t, "Sunny in Paris for 2 days", resp.RawMessage)
+	require.Empty(t, resp.Error)
+}
+
+func Test_connectMCP_StdioDisabled(t *testing.T) {
+	strategy := newMCPTestStrategy(t, nil)
+
+	tool := &types.Tool{
+		Name:     "filesystem",
+		ToolType: types.ToolTypeMCP,
+		Config: types.ToolConfig{
+			MCP: &types.ToolMCPConfig{
+				Command: "npx",
+				Args:    []string{"-y", "@modelcontextprotocol/server-filesystem", "/tmp"},
+			},
+		},
+	}
+
+	_, err := strategy.listMCPTools(context.Background(), strategy.getDefaultOptions(), tool)
+	require.ErrorIs(t, err, ErrMCPStdioDisabled)
+}
+
+func Test_mcpEnv(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := store.NewMockStore(ctrl)
+	st.EXPECT().ListSecrets(gomock.Any(), &store.ListSecretsQuery{Owner: "author-1", OwnerType: types.OwnerTypeUser}).Return([]*types.Secret{
+		{Name: "GITHUB_TOKEN", Value: []byte("ghp_123")},
+	}, nil).AnyTimes()
+
+	strategy, err := NewChainStrategy(&config.ServerConfig{}, st, nil, nil)
+	require.NoError(t, err)
+
+	opts := strategy.getDefaultOptions()
+	require.NoError(t, WithOwner("user-1")(&opts))
+
+	mcpTool := func(cfg *types.ToolMCPConfig) *types.Tool {
+		return &types.Tool{
+			Name:      "github",
+			Owner:     "author-1",
+			OwnerType: types.OwnerTypeUser,
+			ToolType:  types.ToolTypeMCP,
+			Config:    types.ToolConfig{MCP: cfg},
+		}
+	}
+
+	env, err := strategy.mcpEnv(context.Background(), opts, mcpTool(&types.ToolMCPConfig{
+		Command: "github-mcp-server",
+		Env:     map[string]string{"GITHUB_HOST": "github.com"},
+		Secrets: map[string]string{"GITHUB_PERSONAL_ACCESS_TOKEN": "GITHUB_TOKEN"},
+	}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"GITHUB_HOST=github.com", "GITHUB_PERSONAL_ACCESS_TOKEN=ghp_123"}, env)
+
+	_, err = strategy.mcpEnv(context.Background(), opts, mcpTool(&types.ToolMCPConfig{
+		Secrets: map[string]string{"API_KEY": "MISSING"},
+	}))
+	require.ErrorIs(t, err, ErrMissingCredentials)
+}
+
+func Test_getMCPSession_Pooled(t *testing.T) {
+	ts := newMCPTestServer(t)
+	strategy := newMCPTestStrategy(t, nil)
+	strategy.cfg.Tools.MCPSessionIdleTimeout = 200 * time.Millisecond
+	tool := weatherMCPTool(ts.URL)
+
+	for _, city := range []string{"London", "Paris"} {
+		resp, err := strategy.RunAPIActionWithParameters(context.Background(), &types.RunAPIActionRequest{
+			Tool:       tool,
+			Action:     "getForecast",
+			Parameters: map[string]any{"city": city, "days": 1},
+		})
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("Sunny in %s for 1 days", city), resp.Response)
+	}
+
+	strategy.mcpSessionsMu.Lock()
+	require.Len(t, strategy.mcpSessions, 1)
+	strategy.mcpSessionsMu.Unlock()
+
+	// The session is closed once it's idle
+	require.Eventually(t, func() bool {
+		strategy.mcpSessionsMu.Lock()
+		defer strategy.mcpSessionsMu.Unlock()
+		return len(strategy.mcpSessions) == 0
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func newSSETestServer(t *testing.T, events string) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(events))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func Test_newMCPSSEClient_CrossOriginEndpoint(t *testing.T) {
+	ts := newSSETestServer(t, "event: endpoint\ndata: http://evil.example.com/message\n\n")
+
+	_, err := newMCPSSEClient(context.Background(), http.DefaultClient, ts.URL+"/sse")
+	require.ErrorContains(t, err, "is not on the origin of the mcp server")
+}
+
+func Test_newMCPSSEClient_StreamClosed(t *testing.T) {
+	ts := newSSETestServer(t, "event: message\ndata: {}\n\n")
+
+	_, err := newMCPSSEClient(context.Background(), http.DefaultClient, ts.URL+"/sse")
+	require.ErrorContains(t, err, "event stream closed before the endpoint was sent")
+}
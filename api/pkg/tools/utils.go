@@ -2,29 +2,15 @@ package tools
 
 import (
 	"encoding/json"
+	"net/http"
 	"strings"
 
 	"github.com/helixml/helix/api/pkg/types"
+	"github.com/helixml/helix/api/pkg/util/jsonutil"
 )
 
-func AttemptFixJSON(data string) string {
-	// sometimes LLM just gives us a single ``` line at the start; just strip that off
-	if strings.HasPrefix(data, "```\n") {
-		data = strings.Split(data, "```\n")[1]
-	}
-
-	if strings.Contains(data, "```json") {
-		data = strings.Split(data, "```json")[1]
-	}
-	// sometimes LLMs in their wisdom puts a message after the enclosing ```json``` block
-	parts := strings.Split(data, "```")
-	data = parts[0]
-
-	return data
-}
-
 func unmarshalJSON(data string, v interface{}) error {
-	fixedData := AttemptFixJSON(data)
+	fixedData := jsonutil.AttemptFixJSON(data)
 	return json.Unmarshal([]byte(fixedData), v)
 }
 
@@ -45,7 +31,66 @@ func GetToolFromAction(tools []*types.Tool, action string) (*types.Tool, bool) {
 			if tool.Name == action {
 				return tool, true
 			}
+		case types.ToolTypeMCP:
+			if findMCPTool(tool, action) != nil {
+				return tool, true
+			}
 		}
 	}
 	return nil, false
 }
+
+// GetActionPolicy returns whether the action of the tool runs straight away,
+// needs approval or is denied. The policy of the action wins over the one for
+// mutating actions, which wins over the policy of the tool.
+func GetActionPolicy(tool *types.Tool, action string) types.ToolActionPolicy {
+	approval := GetApprovalConfig(tool)
+	if approval == nil {
+		return types.ToolActionPolicyAuto
+	}
+
+	if policy, ok := approval.Actions[action]; ok && policy != "" {
+		return policy
+	}
+
+	if approval.Mutating != "" && isMutatingAction(tool, action) {
+		return approval.Mutating
+	}
+
+	if approval.Policy != "" {
+		return approval.Policy
+	}
+
+	return types.ToolActionPolicyAuto
+}
+
+// GetApprovalConfig returns the approval config of API and MCP tools
+func GetApprovalConfig(tool *types.Tool) *types.ToolApprovalConfig {
+	switch {
+	case tool.ToolType == types.ToolTypeAPI && tool.Config.API != nil:
+		return tool.Config.API.Approval
+	case tool.ToolType == types.ToolTypeMCP && tool.Config.MCP != nil:
+		return tool.Config.MCP.Approval
+	}
+	return nil
+}
+
+// isMutatingAction checks whether the action can change anything. MCP servers
+// don't tell, so all their tools are taken as mutating.
+func isMutatingAction(tool *types.Tool, action string) bool {
+	if tool.ToolType == types.ToolTypeMCP {
+		return true
+	}
+
+	for _, a := range tool.Config.API.Actions {
+		if a.Name != action {
+			continue
+		}
+		switch strings.ToUpper(a.Method) {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func Test_GetActionPolicy(t *testing.T) {
+	ordersTool := func(approval *types.ToolApprovalConfig) *types.Tool {
+		return &types.Tool{
+			Name:     "orders",
+			ToolType: types.ToolTypeAPI,
+			Config: types.ToolConfig{
+				API: &types.ToolAPIConfig{
+					Actions: []*types.ToolAPIAction{
+						{Name: "listOrders", Method: "GET", Path: "/orders"},
+						{Name: "deleteOrder", Method: "delete", Path: "/orders/{id}"},
+					},
+					Approval: approval,
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		approval *types.ToolApprovalConfig
+		action   string
+		want     types.ToolActionPolicy
+	}{
+		{
+			name:   "no approval config",
+			action: "deleteOrder",
+			want:   types.ToolActionPolicyAuto,
+		},
+		{
+			name:     "mutating action",
+			approval: &types.ToolApprovalConfig{Mutating: types.ToolActionPolicyConfirm},
+			action:   "deleteOrder",
+			want:     types.ToolActionPolicyConfirm,
+		},
+		{
+			name:     "read only action",
+			approval: &types.ToolApprovalConfig{Mutating: types.ToolActionPolicyConfirm},
+			action:   "listOrders",
+			want:     types.ToolActionPolicyAuto,
+		},
+		{
+			name:     "tool policy",
+			approval: &types.ToolApprovalConfig{Policy: types.ToolActionPolicyDeny, Mutating: types.ToolActionPolicyConfirm},
+			action:   "listOrders",
+			want:     types.ToolActionPolicyDeny,
+		},
+		{
+			name: "action override",
+			approval: &types.ToolApprovalConfig{
+				Mutating: types.ToolActionPolicyConfirm,
+				Actions:  map[string]types.ToolActionPolicy{"deleteOrder": types.ToolActionPolicyAuto},
+			},
+			action: "deleteOrder",
+			want:   types.ToolActionPolicyAuto,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, GetActionPolicy(ordersTool(tt.approval), tt.action))
+		})
+	}
+}
+
+func Test_GetActionPolicy_MCP(t *testing.T) {
+	filesTool := func(approval *types.ToolApprovalConfig) *types.Tool {
+		return &types.Tool{
+			Name:     "files",
+			ToolType: types.ToolTypeMCP,
+			Config: types.ToolConfig{
+				MCP: &types.ToolMCPConfig{
+					Command:  "npx",
+					Tools:    []*types.ToolMCPTool{{Name: "read_file"}, {Name: "write_file"}},
+					Approval: approval,
+				},
+			},
+		}
+	}
+
+	require.Equal(t, types.ToolActionPolicyAuto, GetActionPolicy(filesTool(nil), "write_file"))
+
+	approval := &types.ToolApprovalConfig{
+		Mutating: types.ToolActionPolicyConfirm,
+		Actions:  map[string]types.ToolActionPolicy{"read_file": types.ToolActionPolicyAuto},
+	}
+	require.Equal(t, types.ToolActionPolicyConfirm, GetActionPolicy(filesTool(approval), "write_file"))
+	require.Equal(t, types.ToolActionPolicyAuto, GetActionPolicy(filesTool(approval), "read_file"))
+
+	require.Equal(t, types.ToolActionPolicyDeny, GetActionPolicy(filesTool(&types.ToolApprovalConfig{Policy: types.ToolActionPolicyDeny}), "read_file"))
+}
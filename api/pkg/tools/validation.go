@@ -126,6 +126,23 @@ func ValidateTool(tool *types.Tool, planner Planner, strict bool) error {
 		if tool.Config.Zapier.APIKey == "" {
 			return system.NewHTTPError400("API key is required for Zapier tools")
 		}
+
+	case types.ToolTypeMCP:
+		if tool.Config.MCP == nil {
+			return system.NewHTTPError400("MCP config is required for MCP tools")
+		}
+
+		if tool.Config.MCP.Command == "" && tool.Config.MCP.URL == "" {
+			return system.NewHTTPError400("command or URL is required for MCP tools")
+		}
+
+		if tool.Config.MCP.Command != "" && tool.Config.MCP.URL != "" {
+			return system.NewHTTPError400("only one of command or URL is allowed for MCP tools")
+		}
+
+		if tool.Description == "" && strict {
+			return system.NewHTTPError400("description is required for MCP tools")
+		}
 	default:
 		return system.NewHTTPError400(fmt.Sprintf("invalid tool type %s, only API tools are supported at the moment", tool.ToolType))
 	}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/helixml/helix/api/pkg/config"
 	"github.com/helixml/helix/api/pkg/controller"
+	oai "github.com/helixml/helix/api/pkg/openai"
 	"github.com/helixml/helix/api/pkg/store"
 	"github.com/helixml/helix/api/pkg/types"
 )
@@ -249,6 +250,14 @@ func (c *Cron) getCronAppTask(ctx context.Context, appID string) gocron.Task {
 			},
 		}
 
+		// Scheduled runs are queued behind interactive and API requests
+		ctx = oai.SetContextValues(ctx, &oai.ContextValues{
+			OwnerID:       app.Owner,
+			SessionID:     "n/a",
+			InteractionID: "n/a",
+		})
+		ctx = oai.SetContextPriorityClass(ctx, types.WorkloadPriorityClassCron)
+
 		resp, _, err := c.controller.ChatCompletion(ctx, &types.User{
 			ID: app.Owner,
 		}, openai.ChatCompletionRequest{
@@ -0,0 +1,78 @@
+package types
+
+import "time"
+
+// ResourceType identifies the kind of resource an access grant applies to
+type ResourceType string
+
+const (
+	ResourceTypeApp                  ResourceType = "app"
+	ResourceTypeKnowledge            ResourceType = "knowledge"
+	ResourceTypeSession              ResourceType = "session"
+	ResourceTypeSecret               ResourceType = "secret"
+	ResourceTypeProviderEndpoint     ResourceType = "provider_endpoint"
+	ResourceTypeProviderRoutingGroup ResourceType = "provider_routing_group"
+	ResourceTypeDataEntity           ResourceType = "data_entity"
+)
+
+// ResourceRole is the role a user has on a single resource. Roles are
+// ordered, each role can do everything the previous one can.
+type ResourceRole string
+
+const (
+	// ResourceRoleNone means the user has no access to the resource
+	ResourceRoleNone ResourceRole = ""
+	// ResourceRoleViewer can use the resource and read its details, history
+	// and logs (for example an app's LLM calls)
+	ResourceRoleViewer ResourceRole = "viewer"
+	// ResourceRoleEditor can additionally update the resource
+	ResourceRoleEditor ResourceRole = "editor"
+	// ResourceRoleOwner can additionally delete the resource and manage who
+	// has access to it
+	ResourceRoleOwner ResourceRole = "owner"
+)
+
+var resourceRoleRanks = map[ResourceRole]int{
+	ResourceRoleNone:   0,
+	ResourceRoleViewer: 1,
+	ResourceRoleEditor: 2,
+	ResourceRoleOwner:  3,
+}
+
+// IsValid returns true for the roles that can be granted
+func (r ResourceRole) IsValid() bool {
+	switch r {
+	case ResourceRoleViewer, ResourceRoleEditor, ResourceRoleOwner:
+		return true
+	default:
+		return false
+	}
+}
+
+// AtLeast checks whether the role is the same as or higher than the other role
+func (r ResourceRole) AtLeast(other ResourceRole) bool {
+	return resourceRoleRanks[r] >= resourceRoleRanks[other]
+}
+
+// AccessGrant gives a user, team or organization a role on a resource
+// they don't own
+type AccessGrant struct {
+	ID           string       `json:"id" gorm:"primaryKey"`
+	Created      time.Time    `json:"created"`
+	Updated      time.Time    `json:"updated"`
+	ResourceType ResourceType `json:"resource_type" gorm:"index:idx_access_grant_resource"`
+	ResourceID   string       `json:"resource_id" gorm:"index:idx_access_grant_resource"`
+	Grantee      string       `json:"grantee" gorm:"index"` // User, team or organization ID
+	GranteeType  OwnerType    `json:"grantee_type"`         // user, team or org
+	Role         ResourceRole `json:"role"`
+	GrantedBy    string       `json:"granted_by"` // User ID of who created the grant
+}
+
+// CreateAccessGrantRequest is used to give a user, team or organization
+// access to a resource. Granting to a grantee that already has a grant
+// updates the role of the existing grant.
+type CreateAccessGrantRequest struct {
+	Grantee     string       `json:"grantee"`
+	GranteeType OwnerType    `json:"grantee_type"`
+	Role        ResourceRole `json:"role"`
+}
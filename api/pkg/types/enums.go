@@ -107,6 +107,8 @@ const (
 	OwnerTypeUser   OwnerType = "user"
 	OwnerTypeRunner OwnerType = "runner"
 	OwnerTypeSystem OwnerType = "system"
+	OwnerTypeOrg    OwnerType = "org"
+	OwnerTypeTeam   OwnerType = "team"
 )
 
 type PaymentType string
@@ -132,7 +134,8 @@ const (
 	WebsocketEventSessionUpdate      WebsocketEventType = "session_update"
 	WebsocketEventWorkerTaskResponse WebsocketEventType = "worker_task_response"
 	WebsocketLLMInferenceResponse    WebsocketEventType = "llm_inference_response"
-	WebsocketEventProcessingStepInfo WebsocketEventType = "step_info" // Helix tool use, rag search, etc
+	WebsocketEventProcessingStepInfo WebsocketEventType = "step_info"     // Helix tool use, rag search, etc
+	WebsocketEventToolApproval       WebsocketEventType = "tool_approval" // Tool action waiting for approval or decided
 )
 
 type WorkerTaskResponseType string
@@ -210,7 +213,7 @@ const (
 	InferenceRuntimeOllama    InferenceRuntime = "ollama"
 	InferenceRuntimeCog       InferenceRuntime = "cog"
 	InferenceRuntimeDiffusers InferenceRuntime = "diffusers"
-	// TODO: vllm
+	InferenceRuntimeVLLM      InferenceRuntime = "vllm"
 )
 
 func ValidateRuntime(runtime string) InferenceRuntime {
@@ -219,6 +222,8 @@ func ValidateRuntime(runtime string) InferenceRuntime {
 		return InferenceRuntimeAxolotl
 	case string(InferenceRuntimeOllama):
 		return InferenceRuntimeOllama
+	case string(InferenceRuntimeVLLM):
+		return InferenceRuntimeVLLM
 	default:
 		return ""
 	}
@@ -128,8 +128,9 @@ const (
 
 type KnowledgeSource struct {
 	Filestore *KnowledgeSourceHelixFilestore `json:"filestore" yaml:"filestore"`
-	S3        *KnowledgeSourceS3             `json:"s3"`
-	GCS       *KnowledgeSourceGCS            `json:"gcs"`
+	S3        *KnowledgeSourceS3             `json:"s3" yaml:"s3"`
+	GCS       *KnowledgeSourceGCS            `json:"gcs" yaml:"gcs"`
+	Github    *KnowledgeSourceGithub         `json:"github" yaml:"github"`
 	Web       *KnowledgeSourceWeb            `json:"web"`
 	Content   *string                        `json:"text"`
 }
@@ -189,24 +190,56 @@ type KnowledgeSourceHelixFilestore struct {
 	Path string `json:"path" yaml:"path"`
 }
 
-// KnowledgeSourceS3 authentication through AWS IAM role
+// KnowledgeSourceS3 indexes the objects of an S3 bucket or S3 compatible storage
+// such as MinIO. Credentials are taken from the AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN secrets of the knowledge owner.
+// The environment variables of the server are only used when the admin enables
+// RAG_OBJECTSTORE_AMBIENT_CREDENTIALS and no custom endpoint is set.
 type KnowledgeSourceS3 struct {
-	Bucket string `json:"bucket"`
-	Path   string `json:"path"`
+	Bucket string `json:"bucket" yaml:"bucket"`
+	// Path prefix of the objects to index, e.g. "docs/"
+	Path string `json:"path" yaml:"path"`
+	// Region of the bucket, defaults to the AWS_REGION secret or us-east-1
+	Region string `json:"region" yaml:"region"`
+	// Endpoint of S3 compatible storage, e.g. http://minio:9000, defaults to
+	// the AWS_ENDPOINT_URL_S3 secret or AWS
+	Endpoint string `json:"endpoint" yaml:"endpoint"`
+	// FilterExtensions only index objects with these extensions, e.g. ".pdf"
+	FilterExtensions []string `json:"filter_extensions" yaml:"filter_extensions"`
 }
 
-// KnowledgeSourceGCS authentication through GCP service account
+// KnowledgeSourceGCS indexes the objects of a Google Cloud Storage bucket.
+// Credentials are taken from the GCS_SERVICE_ACCOUNT_KEY secret of the knowledge
+// owner (the JSON key). The application default credentials of the server are
+// only used when the admin enables RAG_OBJECTSTORE_AMBIENT_CREDENTIALS and no
+// custom endpoint is set, custom endpoints without a key are not authenticated.
 type KnowledgeSourceGCS struct {
-	Bucket string `json:"bucket"`
-	Path   string `json:"path"`
+	Bucket string `json:"bucket" yaml:"bucket"`
+	// Path prefix of the objects to index, e.g. "docs/"
+	Path string `json:"path" yaml:"path"`
+	// Endpoint of the storage API, e.g. http://fake-gcs-server:4443/storage/v1/,
+	// defaults to STORAGE_EMULATOR_HOST or Google Cloud
+	Endpoint string `json:"endpoint" yaml:"endpoint"`
+	// FilterExtensions only index objects with these extensions, e.g. ".pdf"
+	FilterExtensions []string `json:"filter_extensions" yaml:"filter_extensions"`
 }
 
+// KnowledgeSourceGithub indexes the files of a git repository. Repositories on
+// GitHub are cloned with the app's deploy key if the app has one, any other
+// repository can be set with the URL.
 type KnowledgeSourceGithub struct {
-	Owner            string   `json:"owner"`
-	Repository       string   `json:"repository"`
-	Branch           string   `json:"branch"`
-	FilterPaths      []string `json:"filter_paths"`
-	FilterExtensions []string `json:"filter_extensions"`
+	Owner      string `json:"owner" yaml:"owner"`
+	Repository string `json:"repository" yaml:"repository"`
+	// URL of the repository, for example https://gitlab.com/org/repo.git. Only
+	// https:// and ssh:// URLs are allowed. Takes precedence over the owner and
+	// repository.
+	URL string `json:"url" yaml:"url"`
+	// Branch to index, defaults to the repository's default branch
+	Branch string `json:"branch" yaml:"branch"`
+	// FilterPaths only index files under these paths, e.g. "docs/"
+	FilterPaths []string `json:"filter_paths" yaml:"filter_paths"`
+	// FilterExtensions only index files with these extensions, e.g. ".go"
+	FilterExtensions []string `json:"filter_extensions" yaml:"filter_extensions"`
 }
 
 // CrawledDocument used internally to work with the crawled data
@@ -229,6 +262,12 @@ type KnowledgeSearchResult struct {
 
 type CrawledSources struct {
 	URLs []*CrawledURL `json:"urls"`
+	// Commit of the git repository the sources were read from
+	Commit string `json:"commit,omitempty"`
+	// SettingsHash is the hash of the chunking and embedding settings the
+	// sources were indexed with. Unchanged documents are only copied into the
+	// next version if the settings are the same.
+	SettingsHash string `json:"settings_hash,omitempty"`
 	// TODO: files?
 }
 
@@ -259,6 +298,13 @@ type CrawledURL struct {
 	StatusCode int    `json:"status_code"`
 	Message    string `json:"message"`
 	DurationMs int64  `json:"duration_ms"`
+	// ContentHash of the document, used to skip re-indexing unchanged documents
+	ContentHash string `json:"content_hash,omitempty"`
+	// DocumentGroupID of the document's chunks, unchanged documents are found
+	// by it in the next version
+	DocumentGroupID string `json:"document_group_id,omitempty"`
+	// Size of the indexed document in bytes
+	Size int64 `json:"size,omitempty"`
 }
 
 type KnowledgeProgress struct {
@@ -283,6 +329,10 @@ type KnowledgeEmbeddingItem struct {
 	Content         string           // Content of the knowledge
 	ContentOffset   int              // Offset of the content in the knowledge
 	EmbeddingsModel string           // Model used to embed the knowledge
+
+	// Set by the queries, not stored
+	Distance float64 `gorm:"->;-:migration"` // Vector distance to the query embedding
+	Rank     float64 `gorm:"->;-:migration"` // Full text search rank
 }
 
 type Dimensions int
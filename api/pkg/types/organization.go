@@ -0,0 +1,71 @@
+package types
+
+import "time"
+
+// OrganizationRole defines what a member can do within an organization
+type OrganizationRole string
+
+const (
+	// OrganizationRoleOwner can manage the organization, its members, teams
+	// and any resources owned by the organization
+	OrganizationRoleOwner OrganizationRole = "owner"
+	// OrganizationRoleMember can use resources owned by the organization
+	OrganizationRoleMember OrganizationRole = "member"
+)
+
+// Organization groups users (directly or through teams) so that apps,
+// knowledge, secrets, tools and provider endpoints can be owned by the
+// organization instead of a single user.
+type Organization struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+	Name        string    `json:"name" gorm:"uniqueIndex"` // Unique, URL friendly name
+	DisplayName string    `json:"display_name"`
+	Owner       string    `json:"owner"` // User ID of the creator
+}
+
+// OrganizationMembership links a user to an organization with a role
+type OrganizationMembership struct {
+	OrganizationID string           `json:"organization_id" gorm:"primaryKey"`
+	UserID         string           `json:"user_id" gorm:"primaryKey;index"`
+	Created        time.Time        `json:"created"`
+	Updated        time.Time        `json:"updated"`
+	Role           OrganizationRole `json:"role"`
+}
+
+// Team is a group of organization members. Resources can be owned by a
+// team, in which case only the team members (and organization owners)
+// can access them.
+type Team struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	Created        time.Time `json:"created"`
+	Updated        time.Time `json:"updated"`
+	OrganizationID string    `json:"organization_id" gorm:"index"`
+	Name           string    `json:"name"`
+}
+
+// TeamMembership links an organization member to a team
+type TeamMembership struct {
+	TeamID         string    `json:"team_id" gorm:"primaryKey"`
+	UserID         string    `json:"user_id" gorm:"primaryKey;index"`
+	OrganizationID string    `json:"organization_id" gorm:"index"`
+	Created        time.Time `json:"created"`
+	Updated        time.Time `json:"updated"`
+}
+
+// CreateOrganizationMembershipRequest is used to add a user to an organization
+type CreateOrganizationMembershipRequest struct {
+	UserID string           `json:"user_id"`
+	Role   OrganizationRole `json:"role"`
+}
+
+// UpdateOrganizationMembershipRequest is used to change the role of a member
+type UpdateOrganizationMembershipRequest struct {
+	Role OrganizationRole `json:"role"`
+}
+
+// CreateTeamMembershipRequest is used to add an organization member to a team
+type CreateTeamMembershipRequest struct {
+	UserID string `json:"user_id"`
+}
@@ -1,6 +1,9 @@
 package types
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/lib/pq"
@@ -20,6 +23,18 @@ type ProviderEndpointType string
 const (
 	ProviderEndpointTypeGlobal ProviderEndpointType = "global"
 	ProviderEndpointTypeUser   ProviderEndpointType = "user"
+	ProviderEndpointTypeOrg    ProviderEndpointType = "org"
+	ProviderEndpointTypeTeam   ProviderEndpointType = "team"
+)
+
+// ProviderAPIType is the API a provider endpoint serves, requests are
+// translated from the OpenAI API for the other ones
+type ProviderAPIType string
+
+const (
+	ProviderAPITypeOpenAI    ProviderAPIType = "openai"
+	ProviderAPITypeAnthropic ProviderAPIType = "anthropic"
+	ProviderAPITypeGemini    ProviderAPIType = "gemini"
 )
 
 type ProviderEndpoint struct {
@@ -29,9 +44,10 @@ type ProviderEndpoint struct {
 	Name           string               `json:"name"`
 	Description    string               `json:"description"`
 	Models         pq.StringArray       `json:"models" gorm:"type:text[]"` // Optional
-	EndpointType   ProviderEndpointType `json:"endpoint_type"`             // global, user (TODO: orgs, teams)
+	EndpointType   ProviderEndpointType `json:"endpoint_type"`             // global, user, org, team
 	Owner          string               `json:"owner"`
-	OwnerType      OwnerType            `json:"owner_type"` // user, system, org
+	OwnerType      OwnerType            `json:"owner_type"` // user, system, org, team
+	APIType        ProviderAPIType      `json:"api_type"`   // openai (default), anthropic, gemini
 	BaseURL        string               `json:"base_url"`
 	APIKey         string               `json:"api_key"`
 	APIKeyFromFile string               `json:"api_key_file"`     // Must be mounted to the container
@@ -42,8 +58,74 @@ type ProviderEndpoint struct {
 type UpdateProviderEndpoint struct {
 	Description    string               `json:"description"`
 	Models         []string             `json:"models"`
-	EndpointType   ProviderEndpointType `json:"endpoint_type"` // global, user (TODO: orgs, teams)
+	EndpointType   ProviderEndpointType `json:"endpoint_type"` // global, user, org, team
+	APIType        ProviderAPIType      `json:"api_type"`      // openai (default), anthropic, gemini
 	BaseURL        string               `json:"base_url"`
 	APIKey         *string              `json:"api_key,omitempty"`
 	APIKeyFromFile *string              `json:"api_key_file,omitempty"` // Must be mounted to the container
 }
+
+// ProviderRoutingStrategy decides which endpoint of a routing group serves a
+// request, the other endpoints are used as fallbacks
+type ProviderRoutingStrategy string
+
+const (
+	// ProviderRoutingStrategyPriority uses the endpoints by ascending priority
+	ProviderRoutingStrategyPriority ProviderRoutingStrategy = "priority"
+	// ProviderRoutingStrategyWeightedRoundRobin spreads the requests by weight
+	ProviderRoutingStrategyWeightedRoundRobin ProviderRoutingStrategy = "weighted_round_robin"
+	// ProviderRoutingStrategyLeastLatency prefers the endpoint that answered fastest recently
+	ProviderRoutingStrategyLeastLatency ProviderRoutingStrategy = "least_latency"
+)
+
+// ProviderRoutingGroup is a named group of providers that can be used as the
+// provider of an assistant or a chat completion. Requests are routed with the
+// strategy and fall back to the other providers on rate limits and errors.
+type ProviderRoutingGroup struct {
+	ID           string                      `json:"id" gorm:"primaryKey"`
+	Created      time.Time                   `json:"created"`
+	Updated      time.Time                   `json:"updated"`
+	Name         string                      `json:"name"`
+	Description  string                      `json:"description"`
+	EndpointType ProviderEndpointType        `json:"endpoint_type"` // global, user, org, team
+	Owner        string                      `json:"owner"`
+	OwnerType    OwnerType                   `json:"owner_type"`
+	Strategy     ProviderRoutingStrategy     `json:"strategy"`
+	Members      ProviderRoutingGroupMembers `json:"members" gorm:"type:jsonb"`
+}
+
+type ProviderRoutingGroupMember struct {
+	// Provider is the name or ID of a provider endpoint, or a global provider
+	Provider string `json:"provider"`
+	// Priority orders the providers of the priority strategy, lowest first
+	Priority int `json:"priority,omitempty"`
+	// Weight of the provider in the weighted round-robin strategy, 1 if not set
+	Weight int `json:"weight,omitempty"`
+	// Model replaces the model of chat completion requests sent to the
+	// provider, providers often name the same model differently
+	Model string `json:"model,omitempty"`
+}
+
+type ProviderRoutingGroupMembers []ProviderRoutingGroupMember
+
+func (m ProviderRoutingGroupMembers) Value() (driver.Value, error) {
+	j, err := json.Marshal(m)
+	return j, err
+}
+
+func (m *ProviderRoutingGroupMembers) Scan(src interface{}) error {
+	source, ok := src.([]byte)
+	if !ok {
+		return errors.New("type assertion .([]byte) failed")
+	}
+	var result ProviderRoutingGroupMembers
+	if err := json.Unmarshal(source, &result); err != nil {
+		return err
+	}
+	*m = result
+	return nil
+}
+
+func (ProviderRoutingGroupMembers) GormDataType() string {
+	return "json"
+}
@@ -0,0 +1,48 @@
+package types
+
+import "time"
+
+// RateLimitScope is what a rate limit applies to
+type RateLimitScope string
+
+const (
+	RateLimitScopeUser     RateLimitScope = "user"
+	RateLimitScopeAPIKey   RateLimitScope = "api_key"
+	RateLimitScopeApp      RateLimitScope = "app"
+	RateLimitScopeProvider RateLimitScope = "provider"
+)
+
+// RateLimit limits the chat completions of a user, API key, app or provider,
+// limits set to 0 aren't enforced
+type RateLimit struct {
+	ID      string         `json:"id" gorm:"primaryKey"`
+	Created time.Time      `json:"created"`
+	Updated time.Time      `json:"updated"`
+	Scope   RateLimitScope `json:"scope" gorm:"uniqueIndex:idx_rate_limits_scope"`
+	// ScopeID is the user ID, app ID or provider. Providers are the ID of the
+	// provider endpoint, or the name of the providers configured on the server.
+	// API keys are set with the key and stored as its hash, prefixed with
+	// "sha256:".
+	ScopeID           string `json:"scope_id" gorm:"uniqueIndex:idx_rate_limits_scope"`
+	RequestsPerMinute int64  `json:"requests_per_minute"`
+	TokensPerDay      int64  `json:"tokens_per_day"`
+	TokensPerMonth    int64  `json:"tokens_per_month"`
+}
+
+type RateLimitPeriod string
+
+const (
+	RateLimitPeriodDay   RateLimitPeriod = "day"
+	RateLimitPeriodMonth RateLimitPeriod = "month"
+)
+
+// TokenUsage counts the tokens used in a rate limit scope during a period, the
+// period starts at midnight UTC for days and on the first of the month for months
+type TokenUsage struct {
+	Scope       RateLimitScope  `json:"scope" gorm:"primaryKey"`
+	ScopeID     string          `json:"scope_id" gorm:"primaryKey"`
+	Period      RateLimitPeriod `json:"period" gorm:"primaryKey"`
+	PeriodStart time.Time       `json:"period_start" gorm:"primaryKey"`
+	Updated     time.Time       `json:"updated"`
+	Tokens      int64           `json:"tokens"`
+}
@@ -36,6 +36,63 @@ type RunnerStatus struct {
 	TotalMemory uint64            `json:"total_memory"`
 	FreeMemory  uint64            `json:"free_memory"`
 	Labels      map[string]string `json:"labels"`
+	// Taints keep workloads off the runner unless they tolerate them
+	Taints map[string]string `json:"taints"`
+	// SlotMemory is the GPU memory measured for the slots that are running
+	SlotMemory []*RunnerSlotMemory `json:"slot_memory,omitempty"`
+}
+
+// RunnerSlotMemory is the GPU memory a slot's model measurably uses, the
+// scheduler learns the model footprints from it
+type RunnerSlotMemory struct {
+	ID      uuid.UUID `json:"id"`
+	Runtime Runtime   `json:"runtime"`
+	Model   string    `json:"model"`
+	Memory  uint64    `json:"memory"`
+}
+
+// RunnerSelector restricts the runners a workload is placed on, based on the
+// labels and taints the runners report. Selectors set on the workload, the
+// model and the app must all match.
+type RunnerSelector struct {
+	// NodeSelector labels the runner must have, e.g. gpu=a100
+	NodeSelector map[string]string `json:"node_selector,omitempty" yaml:"node_selector,omitempty"`
+	// Affinity label expressions the runner must match
+	Affinity []RunnerLabelRequirement `json:"affinity,omitempty" yaml:"affinity,omitempty"`
+	// Tolerations allow the workload on runners with matching taints, only
+	// admins can set them
+	Tolerations []RunnerToleration `json:"tolerations,omitempty" yaml:"tolerations,omitempty"`
+}
+
+type RunnerLabelOperator string
+
+const (
+	RunnerLabelOperatorIn           RunnerLabelOperator = "In"
+	RunnerLabelOperatorNotIn        RunnerLabelOperator = "NotIn"
+	RunnerLabelOperatorExists       RunnerLabelOperator = "Exists"
+	RunnerLabelOperatorDoesNotExist RunnerLabelOperator = "DoesNotExist"
+)
+
+type RunnerLabelRequirement struct {
+	Key      string              `json:"key" yaml:"key"`
+	Operator RunnerLabelOperator `json:"operator" yaml:"operator"`
+	Values   []string            `json:"values,omitempty" yaml:"values,omitempty"`
+}
+
+type RunnerTolerationOperator string
+
+const (
+	RunnerTolerationOperatorEqual  RunnerTolerationOperator = "Equal"
+	RunnerTolerationOperatorExists RunnerTolerationOperator = "Exists"
+)
+
+// RunnerToleration tolerates a runner taint, an empty key with the Exists
+// operator tolerates all taints
+type RunnerToleration struct {
+	Key string `json:"key" yaml:"key"`
+	// Operator defaults to Equal
+	Operator RunnerTolerationOperator `json:"operator,omitempty" yaml:"operator,omitempty"`
+	Value    string                   `json:"value,omitempty" yaml:"value,omitempty"`
 }
 
 type Runtime string
@@ -44,6 +101,7 @@ const (
 	RuntimeOllama    Runtime = "ollama"
 	RuntimeDiffusers Runtime = "diffusers"
 	RuntimeAxolotl   Runtime = "axolotl"
+	RuntimeVLLM      Runtime = "vllm"
 )
 
 type CreateRunnerSlotAttributes struct {
@@ -0,0 +1,75 @@
+package types
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ToolActionPolicy decides whether the action of a tool runs straight away,
+// waits for a user to approve it or never runs
+type ToolActionPolicy string
+
+const (
+	ToolActionPolicyAuto    ToolActionPolicy = "auto"
+	ToolActionPolicyConfirm ToolActionPolicy = "confirm"
+	ToolActionPolicyDeny    ToolActionPolicy = "deny"
+)
+
+// ToolApprovalConfig sets the policies for the actions of a tool, the most
+// specific one applies
+type ToolApprovalConfig struct {
+	// Policy applies to all the actions, defaults to auto
+	Policy ToolActionPolicy `json:"policy,omitempty" yaml:"policy,omitempty"`
+	// Mutating applies to the actions with POST, PUT, PATCH and DELETE methods
+	// and to all the tools of MCP servers
+	Mutating ToolActionPolicy `json:"mutating,omitempty" yaml:"mutating,omitempty"`
+	// Actions sets the policies of single actions, keyed by action name
+	Actions map[string]ToolActionPolicy `json:"actions,omitempty" yaml:"actions,omitempty"`
+	// Approvers are the users that can decide on the actions besides the
+	// owner of the session
+	Approvers []string `json:"approvers,omitempty" yaml:"approvers,omitempty"`
+}
+
+type ToolApprovalStatus string
+
+const (
+	ToolApprovalStatusPending  ToolApprovalStatus = "pending"
+	ToolApprovalStatusApproved ToolApprovalStatus = "approved"
+	ToolApprovalStatusDenied   ToolApprovalStatus = "denied"
+	ToolApprovalStatusExpired  ToolApprovalStatus = "expired"
+)
+
+// ToolApproval is a tool action that waits for a user to approve it. The
+// records are kept once decided as the audit trail of the actions.
+type ToolApproval struct {
+	ID            string    `json:"id" gorm:"primaryKey"`
+	Created       time.Time `json:"created"`
+	Updated       time.Time `json:"updated"`
+	SessionID     string    `json:"session_id" gorm:"index"`
+	InteractionID string    `json:"interaction_id"`
+	// Owner of the session the action runs in
+	Owner         string             `json:"owner" gorm:"index"`
+	OwnerType     OwnerType          `json:"owner_type"`
+	AppID         string             `json:"app_id"`
+	ToolID        string             `json:"tool_id"`
+	ToolName      string             `json:"tool_name"`
+	Action        string             `json:"action"`
+	Method        string             `json:"method"`
+	Path          string             `json:"path"`
+	Justification string             `json:"justification"`
+	Approvers     pq.StringArray     `json:"approvers" gorm:"type:text[]"`
+	Status        ToolApprovalStatus `json:"status" gorm:"index"`
+	ExpiresAt     time.Time          `json:"expires_at"`
+	DecidedBy     string             `json:"decided_by,omitempty"`
+	DecidedAt     *time.Time         `json:"decided_at,omitempty"`
+	Reason        string             `json:"reason,omitempty"`
+	// ResumedAt is when the approved action was last picked up to run
+	ResumedAt *time.Time `json:"resumed_at,omitempty"`
+}
+
+// ToolApprovalDecision approves or denies a pending tool action
+type ToolApprovalDecision struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason"`
+}
@@ -49,6 +49,10 @@ type Interaction struct {
 
 	RagResults []*SessionRAGResult `json:"rag_results"`
 
+	// Citations are the knowledge chunks that were added to the prompt
+	// when generating this response
+	Citations []*Citation `json:"citations,omitempty"`
+
 	// Model function calling, not to be mistaken with Helix tools
 	Tools []openai.Tool `json:"tools"`
 
@@ -106,6 +110,16 @@ type RAGSettings struct {
 	DisableDownloading bool             `json:"disable_downloading" yaml:"disable_downloading"` // if true, we will not download the file and send the URL to the RAG indexing endpoint
 	PromptTemplate     string           `json:"prompt_template" yaml:"prompt_template"`         // the prompt template to use for the RAG query
 
+	// SearchMode is either vector (default) or hybrid. Hybrid combines keyword
+	// search with the vector search, which helps with exact matches such as
+	// product codes and error messages.
+	SearchMode RAGSearchMode `json:"search_mode" yaml:"search_mode"`
+
+	// Reranker, if set, reorders the retrieved chunks before they are added to
+	// the prompt. More candidates are fetched than ResultsCount so the reranker
+	// can promote relevant chunks that the search ranked lower.
+	Reranker RerankerSettings `json:"reranker" yaml:"reranker"`
+
 	// RAG endpoint configuration if used with a custom RAG service
 	IndexURL  string `json:"index_url" yaml:"index_url"`   // the URL of the index endpoint (defaults to Helix RAG_INDEX_URL env var)
 	QueryURL  string `json:"query_url" yaml:"query_url"`   // the URL of the query endpoint (defaults to Helix RAG_QUERY_URL env var)
@@ -160,12 +174,22 @@ type SessionRAGQuery struct {
 	DistanceFunction  string  `json:"distance_function"`
 	MaxResults        int     `json:"max_results"`
 	ExhaustiveSearch  bool    `json:"exhaustive_search"`
+
+	SearchMode RAGSearchMode `json:"search_mode"` // vector (default) or hybrid
 }
 
 type DeleteIndexRequest struct {
 	DataEntityID string `json:"data_entity_id"`
 }
 
+// CopyIndexRequest copies the indexed chunks of the documents into another
+// data entity, used to carry unchanged documents over to a new knowledge version
+type CopyIndexRequest struct {
+	FromDataEntityID string   `json:"from_data_entity_id"`
+	ToDataEntityID   string   `json:"to_data_entity_id"`
+	DocumentGroupIDs []string `json:"document_group_ids"`
+}
+
 // the thing we load from llamaindex when we send the user prompt
 // there and it does a lookup
 type SessionRAGResult struct {
@@ -179,6 +203,71 @@ type SessionRAGResult struct {
 	ContentOffset   int     `json:"content_offset"`
 	Content         string  `json:"content"`
 	Distance        float64 `json:"distance"`
+
+	// Scores are set by the hybrid search. Lexical and semantic scores are
+	// the raw keyword and vector similarity scores (zero if the result was
+	// not found by that search), Score is the fused reciprocal-rank score.
+	LexicalScore  float64 `json:"lexical_score,omitempty"`
+	SemanticScore float64 `json:"semantic_score,omitempty"`
+	Score         float64 `json:"score,omitempty"`
+	// RerankScore is the relevance score given by the reranker
+	RerankScore float64 `json:"rerank_score,omitempty"`
+}
+
+// Citation links an assistant response back to a knowledge chunk that was
+// added to the prompt
+type Citation struct {
+	KnowledgeID      string `json:"knowledge_id,omitempty"`
+	KnowledgeVersion string `json:"knowledge_version,omitempty"`
+	// DataEntityID is set when the chunk came from a data entity RAG source
+	// instead of a knowledge
+	DataEntityID  string `json:"data_entity_id,omitempty"`
+	DocumentID    string `json:"document_id,omitempty"`
+	Source        string `json:"source,omitempty"` // URL of the crawled page or filestore path
+	ContentOffset int    `json:"content_offset"`
+	Content       string `json:"content"`
+}
+
+// ChatCompletionResponse is the OpenAI chat completion response extended
+// with the citations of the knowledge used to answer
+type ChatCompletionResponse struct {
+	openai.ChatCompletionResponse
+	Citations []*Citation `json:"citations,omitempty"`
+}
+
+// ChatCompletionStreamResponse is the OpenAI chat completion chunk extended
+// with the citations of the knowledge used to answer. Citations are sent
+// in the last chunk of the stream, it has no choices.
+type ChatCompletionStreamResponse struct {
+	openai.ChatCompletionStreamResponse
+	Citations []*Citation `json:"citations,omitempty"`
+}
+
+type RAGSearchMode string
+
+const (
+	RAGSearchModeVector RAGSearchMode = "vector"
+	RAGSearchModeHybrid RAGSearchMode = "hybrid"
+)
+
+type RerankerType string
+
+const (
+	RerankerTypeNone RerankerType = ""
+	// RerankerTypeCrossEncoder scores the chunks with a cross-encoder model served
+	// through the /rerank endpoint of an OpenAI compatible provider
+	RerankerTypeCrossEncoder RerankerType = "cross_encoder"
+	// RerankerTypeLLM asks a chat model to score the relevance of each chunk
+	RerankerTypeLLM RerankerType = "llm"
+)
+
+type RerankerSettings struct {
+	Type     RerankerType `json:"type" yaml:"type"`
+	Provider string       `json:"provider" yaml:"provider"`
+	Model    string       `json:"model" yaml:"model"`
+	// Candidates is how many results are fetched from the search and passed to
+	// the reranker - will default to 20
+	Candidates int `json:"candidates" yaml:"candidates"`
 }
 
 // gives us a quick way to add settings
@@ -222,6 +311,9 @@ type SessionMetadata struct {
 	// which assistant are we talking to?
 	AssistantID    string            `json:"assistant_id"`
 	AppQueryParams map[string]string `json:"app_query_params"` // Passing through user defined app params
+	// RunnerSelector restricts the runners the session is scheduled on, it's
+	// set from the app when the session is queued
+	RunnerSelector *RunnerSelector `json:"runner_selector,omitempty"`
 }
 
 // the packet we put a list of sessions into so pagination is supported and we know the total amount
@@ -597,6 +689,7 @@ type WebsocketEvent struct {
 	WorkerTaskResponse *RunnerTaskResponse         `json:"worker_task_response"`
 	InferenceResponse  *RunnerLLMInferenceResponse `json:"inference_response"`
 	StepInfo           *StepInfo                   `json:"step_info"`
+	ToolApproval       *ToolApproval               `json:"tool_approval,omitempty"`
 }
 
 type StepInfoType string
@@ -728,6 +821,37 @@ type SessionSummary struct {
 	AppID    string `json:"app_id,omitempty"`
 }
 
+// WorkloadPriorityClass decides which queued workloads are scheduled first,
+// workloads of the same class are shared fairly between their owners
+type WorkloadPriorityClass string
+
+const (
+	// WorkloadPriorityClassInteractive chat sessions in the UI
+	WorkloadPriorityClassInteractive WorkloadPriorityClass = "interactive"
+	// WorkloadPriorityClassAPI requests through the OpenAI compatible API
+	WorkloadPriorityClassAPI WorkloadPriorityClass = "api"
+	// WorkloadPriorityClassFinetune fine-tuning and data preparation
+	WorkloadPriorityClassFinetune WorkloadPriorityClass = "finetune"
+	// WorkloadPriorityClassCron scheduled app triggers
+	WorkloadPriorityClassCron WorkloadPriorityClass = "cron"
+)
+
+// Rank orders the classes, lower ranks are scheduled first
+func (c WorkloadPriorityClass) Rank() int {
+	switch c {
+	case WorkloadPriorityClassInteractive:
+		return 0
+	case WorkloadPriorityClassAPI:
+		return 1
+	case WorkloadPriorityClassFinetune:
+		return 2
+	case WorkloadPriorityClassCron:
+		return 3
+	default:
+		return WorkloadPriorityClassAPI.Rank()
+	}
+}
+
 type WorkloadSummary struct {
 	ID        string    `json:"id"`
 	CreatedAt time.Time `json:"created"`
@@ -737,6 +861,11 @@ type WorkloadSummary struct {
 	Runtime   string    `json:"runtime"`
 	LoraDir   string    `json:"lora_dir"`
 	Summary   string    `json:"summary"`
+	OwnerID   string    `json:"owner_id"`
+	// PriorityClass of the workload, see WorkloadPriorityClass
+	PriorityClass WorkloadPriorityClass `json:"priority_class"`
+	// QueuePosition starts at 1 for the workload that will be scheduled next
+	QueuePosition int `json:"queue_position"`
 
 	// Created       time.Time   `json:"created"`
 	// Updated       time.Time   `json:"updated"`
@@ -769,6 +898,7 @@ type DashboardRunner struct {
 	TotalMemory uint64            `json:"total_memory"`
 	FreeMemory  uint64            `json:"free_memory"`
 	Labels      map[string]string `json:"labels"`
+	Taints      map[string]string `json:"taints"`
 	Slots       []*RunnerSlot     `json:"slots"`
 }
 
@@ -886,6 +1016,7 @@ const (
 	ToolTypeAPI       ToolType = "api"
 	ToolTypeGPTScript ToolType = "gptscript"
 	ToolTypeZapier    ToolType = "zapier"
+	ToolTypeMCP       ToolType = "mcp"
 )
 
 type Tool struct {
@@ -907,6 +1038,7 @@ type ToolConfig struct {
 	API       *ToolAPIConfig       `json:"api"`
 	GPTScript *ToolGPTScriptConfig `json:"gptscript"`
 	Zapier    *ToolZapierConfig    `json:"zapier"`
+	MCP       *ToolMCPConfig       `json:"mcp"`
 }
 
 func (t ToolConfig) Value() (driver.Value, error) {
@@ -939,6 +1071,9 @@ type ToolAPIConfig struct {
 	Headers map[string]string `json:"headers" yaml:"headers"` // Headers (authentication, etc)
 	Query   map[string]string `json:"query" yaml:"query"`     // Query parameters that will be always set
 
+	Auth     *ToolAPIAuth        `json:"auth,omitempty" yaml:"auth,omitempty"`         // Credentials for the security schemes of the spec
+	Approval *ToolApprovalConfig `json:"approval,omitempty" yaml:"approval,omitempty"` // Actions that need approval before running
+
 	RequestPrepTemplate     string `json:"request_prep_template" yaml:"request_prep_template"`         // Template for request preparation, leave empty for default
 	ResponseSuccessTemplate string `json:"response_success_template" yaml:"response_success_template"` // Template for successful response, leave empty for default
 	ResponseErrorTemplate   string `json:"response_error_template" yaml:"response_error_template"`     // Template for error response, leave empty for default
@@ -946,6 +1081,29 @@ type ToolAPIConfig struct {
 	Model string `json:"model" yaml:"model"`
 }
 
+// ToolAPIAuth configures the credentials for the security schemes declared in
+// the OpenAPI spec of the tool. Only the names of the secrets are kept here,
+// their values are read from the secrets store when the actions run.
+type ToolAPIAuth struct {
+	// Scheme is the name of the security scheme to use, defaults to the first
+	// one the operation requires
+	Scheme string `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+	// Secret holds the API key, the bearer token, the basic auth password or
+	// the OAuth2 client secret. It is read from the secrets of the app owner.
+	Secret string `json:"secret,omitempty" yaml:"secret,omitempty"`
+	// Username for basic auth
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+	// ClientID for OAuth2
+	ClientID string `json:"client_id,omitempty" yaml:"client_id,omitempty"`
+	// Scopes requested for OAuth2 tokens, defaults to the ones the operation
+	// requires
+	Scopes []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	// UserSecret is the secret of each end user holding their OAuth2 token
+	// for the authorization code flow. The value is either the access token
+	// or the JSON token with its refresh token and expiry.
+	UserSecret string `json:"user_secret,omitempty" yaml:"user_secret,omitempty"`
+}
+
 // ToolApiConfig is parsed from the OpenAPI spec
 type ToolAPIAction struct {
 	Name        string `json:"name" yaml:"name"`
@@ -965,6 +1123,33 @@ type ToolZapierConfig struct {
 	MaxIterations int    `json:"max_iterations"`
 }
 
+// ToolMCPConfig connects to an external Model Context Protocol server, either
+// by running its command and talking to it over stdio or with the URL of its
+// event stream. The tools of the server are the actions of the tool.
+type ToolMCPConfig struct {
+	Command string            `json:"command,omitempty" yaml:"command,omitempty"` // Command starting the server, e.g. npx
+	Args    []string          `json:"args,omitempty" yaml:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty" yaml:"env,omitempty"` // Environment variables of the command
+	// Secrets sets environment variables of the command from secrets, keyed
+	// by variable with the secret name as the value. Only the names are kept
+	// here, the values are read from the secrets of the app owner when the
+	// server starts.
+	Secrets map[string]string `json:"secrets,omitempty" yaml:"secrets,omitempty"`
+
+	URL string `json:"url,omitempty" yaml:"url,omitempty"` // SSE endpoint of a running server
+
+	Approval *ToolApprovalConfig `json:"approval,omitempty" yaml:"approval,omitempty"` // Tools that need approval before running
+
+	Tools []*ToolMCPTool `json:"tools,omitempty" yaml:"tools,omitempty"` // Read-only, listed from the server
+}
+
+// ToolMCPTool is a tool listed by the MCP server
+type ToolMCPTool struct {
+	Name        string         `json:"name" yaml:"name"`
+	Description string         `json:"description" yaml:"description"`
+	InputSchema map[string]any `json:"input_schema,omitempty" yaml:"input_schema,omitempty"` // JSON schema of the arguments
+}
+
 type AppSource string
 
 const (
@@ -989,13 +1174,26 @@ type AssistantZapier struct {
 	MaxIterations int    `json:"max_iterations" yaml:"max_iterations"`
 }
 
+type AssistantMCP struct {
+	Name        string              `json:"name" yaml:"name"`
+	Description string              `json:"description" yaml:"description"`
+	Command     string              `json:"command,omitempty" yaml:"command,omitempty"`
+	Args        []string            `json:"args,omitempty" yaml:"args,omitempty"`
+	Env         map[string]string   `json:"env,omitempty" yaml:"env,omitempty"`
+	Secrets     map[string]string   `json:"secrets,omitempty" yaml:"secrets,omitempty"`
+	URL         string              `json:"url,omitempty" yaml:"url,omitempty"`
+	Approval    *ToolApprovalConfig `json:"approval,omitempty" yaml:"approval,omitempty"`
+}
+
 type AssistantAPI struct {
-	Name        string            `json:"name" yaml:"name"`
-	Description string            `json:"description" yaml:"description"`
-	Schema      string            `json:"schema" yaml:"schema"`
-	URL         string            `json:"url" yaml:"url"`
-	Headers     map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
-	Query       map[string]string `json:"query,omitempty" yaml:"query,omitempty"`
+	Name        string              `json:"name" yaml:"name"`
+	Description string              `json:"description" yaml:"description"`
+	Schema      string              `json:"schema" yaml:"schema"`
+	URL         string              `json:"url" yaml:"url"`
+	Headers     map[string]string   `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Query       map[string]string   `json:"query,omitempty" yaml:"query,omitempty"`
+	Auth        *ToolAPIAuth        `json:"auth,omitempty" yaml:"auth,omitempty"`
+	Approval    *ToolApprovalConfig `json:"approval,omitempty" yaml:"approval,omitempty"`
 
 	RequestPrepTemplate     string `json:"request_prep_template,omitempty" yaml:"request_prep_template,omitempty"`
 	ResponseSuccessTemplate string `json:"response_success_template,omitempty" yaml:"response_success_template,omitempty"`
@@ -1023,9 +1221,14 @@ type AssistantConfig struct {
 
 	IsActionableTemplate string `json:"is_actionable_template,omitempty" yaml:"is_actionable_template,omitempty"`
 
+	// NativeToolCalling uses the function calling of the model to pick the
+	// tool actions and their parameters instead of the prompts
+	NativeToolCalling bool `json:"native_tool_calling,omitempty" yaml:"native_tool_calling,omitempty"`
+
 	APIs       []AssistantAPI       `json:"apis,omitempty" yaml:"apis,omitempty"`
 	GPTScripts []AssistantGPTScript `json:"gptscripts,omitempty" yaml:"gptscripts,omitempty"`
 	Zapier     []AssistantZapier    `json:"zapier,omitempty" yaml:"zapier,omitempty"`
+	MCPs       []AssistantMCP       `json:"mcps,omitempty" yaml:"mcps,omitempty"`
 	Tools      []*Tool              `json:"tools,omitempty" yaml:"tools,omitempty"`
 
 	Tests []struct {
@@ -1048,6 +1251,25 @@ type AppHelixConfig struct {
 	ExternalURL string            `json:"external_url,omitempty" yaml:"external_url,omitempty"`
 	Assistants  []AssistantConfig `json:"assistants,omitempty" yaml:"assistants,omitempty"`
 	Triggers    []Trigger         `json:"triggers,omitempty" yaml:"triggers,omitempty"`
+	// RunnerSelector restricts the runners the app's models are scheduled on,
+	// e.g. to a pool of runners dedicated to a customer. Only admins can set
+	// its tolerations.
+	RunnerSelector *RunnerSelector `json:"runner_selector,omitempty" yaml:"runner_selector,omitempty"`
+	// ResponseCache reuses the answers to questions the app was already asked
+	ResponseCache *ResponseCacheConfig `json:"response_cache,omitempty" yaml:"response_cache,omitempty"`
+}
+
+// ResponseCacheConfig enables the response cache for the chat completions of an
+// app. Answers are only reused for requests with the same model, system prompt,
+// history, tools and knowledge results.
+type ResponseCacheConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// TTL is how long answers are cached, e.g. 30m, defaults to the server TTL
+	TTL string `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+	// SimilarityThreshold is the cosine similarity above which a question gets
+	// the answer of a similar one, 1 to only reuse answers to the same question.
+	// Defaults to the server threshold.
+	SimilarityThreshold float64 `json:"similarity_threshold,omitempty" yaml:"similarity_threshold,omitempty"`
 }
 
 type AppHelixConfigMetadata struct {
@@ -1389,10 +1611,14 @@ type RunnerLLMInferenceRequest struct {
 	CreatedAt time.Time
 
 	Priority      bool
+	PriorityClass WorkloadPriorityClass
 	OwnerID       string
 	SessionID     string
 	InteractionID string
 
+	// RunnerSelector restricts the runners the request is scheduled on
+	RunnerSelector *RunnerSelector
+
 	Request *openai.ChatCompletionRequest
 }
 
@@ -1434,7 +1660,7 @@ type LLMCall struct {
 	ID               string         `json:"id" gorm:"primaryKey"`
 	AppID            string         `json:"app_id" gorm:"index"`
 	UserID           string         `json:"user_id" gorm:"index"`
-	Created          time.Time      `json:"created"`
+	Created          time.Time      `json:"created" gorm:"index"`
 	Updated          time.Time      `json:"updated"`
 	SessionID        string         `json:"session_id" gorm:"index"`
 	InteractionID    string         `json:"interaction_id" gorm:"index"`
@@ -1448,12 +1674,19 @@ type LLMCall struct {
 	PromptTokens     int64
 	CompletionTokens int64
 	TotalTokens      int64
+	// CacheHit is set when the response was served from the response cache,
+	// no tokens are used then
+	CacheHit bool `json:"cache_hit"`
 }
 
 type CreateSecretRequest struct {
 	Name  string `json:"name"`
 	Value string `json:"value"`
 	AppID string `json:"app_id"`
+	// Owner and OwnerType are optional, set them to create
+	// the secret for an organization or team
+	Owner     string    `json:"owner,omitempty"`
+	OwnerType OwnerType `json:"owner_type,omitempty"`
 }
 
 type Secret struct {
@@ -1507,6 +1740,46 @@ type RunnerWorkload struct {
 	Session             *Session
 }
 
+// SchedulerWorkload is a workload waiting in the scheduler queue, stored so
+// the queue is restored when the API restarts. Sessions are queued again and
+// inference requests are failed so their callers don't wait for them.
+type SchedulerWorkload struct {
+	ID      string    `json:"id" gorm:"primaryKey"`
+	Created time.Time `json:"created"`
+	// Workload is the RunnerWorkload
+	Workload datatypes.JSON `json:"workload" gorm:"type:jsonb"`
+}
+
+// SchedulerSlot is a slot the scheduler allocated on a runner, stored so the
+// slots are adopted again instead of being deleted when the API restarts
+type SchedulerSlot struct {
+	ID       string    `json:"id" gorm:"primaryKey"`
+	Created  time.Time `json:"created"`
+	Updated  time.Time `json:"updated"`
+	RunnerID string    `json:"runner_id" gorm:"index"`
+	Running  bool      `json:"running"`
+	// Workload is the RunnerWorkload the slot was created for
+	Workload datatypes.JSON `json:"workload" gorm:"type:jsonb"`
+}
+
+// SchedulerTraceEntry is a workload enqueued in the scheduler, traces are
+// recorded as JSON lines and replayed by the scheduler simulator. Workloads
+// are recorded when enqueued and again with their duration once done.
+type SchedulerTraceEntry struct {
+	ID             string                `json:"id"`
+	Created        time.Time             `json:"created"`
+	OwnerID        string                `json:"owner_id"`
+	PriorityClass  WorkloadPriorityClass `json:"priority_class,omitempty"`
+	Priority       bool                  `json:"priority,omitempty"`
+	Model          string                `json:"model"`
+	Mode           SessionMode           `json:"mode"`
+	Runtime        Runtime               `json:"runtime"`
+	RunnerSelector *RunnerSelector       `json:"runner_selector,omitempty"`
+	// Duration is how long the work runs once started, the simulator
+	// default is used if it's not set
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
 type RunnerActualSlot struct {
 	ID         uuid.UUID                  `json:"id"`
 	Attributes RunnerActualSlotAttributes `json:"attributes"`
@@ -1519,8 +1792,11 @@ type RunnerActualSlotAttributes struct {
 }
 
 type RunAPIActionRequest struct {
-	Action     string            `json:"action"`
-	Parameters map[string]string `json:"parameters"`
+	// AssistantID selects the assistant by ID or index, defaults to the
+	// first one
+	AssistantID string         `json:"assistant_id,omitempty"`
+	Action      string         `json:"action"`
+	Parameters  map[string]any `json:"parameters"`
 
 	Tool *Tool `json:"-"` // Set internally
 }
@@ -0,0 +1,58 @@
+package types
+
+import "time"
+
+// ModelPriceAnyModel is the model of a price that applies to every model of
+// the provider that doesn't have a price of its own
+const ModelPriceAnyModel = "*"
+
+// ModelPrice is the price of a model of a provider, it's used to estimate the
+// cost of the LLM calls
+type ModelPrice struct {
+	ID       string    `json:"id" gorm:"primaryKey"`
+	Created  time.Time `json:"created"`
+	Updated  time.Time `json:"updated"`
+	Provider string    `json:"provider" gorm:"uniqueIndex:idx_model_prices_model"`
+	Model    string    `json:"model" gorm:"uniqueIndex:idx_model_prices_model"`
+	// PromptTokenPrice is the price of a million prompt tokens, in USD
+	PromptTokenPrice float64 `json:"prompt_token_price"`
+	// CompletionTokenPrice is the price of a million completion tokens, in USD
+	CompletionTokenPrice float64 `json:"completion_token_price"`
+}
+
+type UsageGroupBy string
+
+const (
+	UsageGroupByUser     UsageGroupBy = "user"
+	UsageGroupByApp      UsageGroupBy = "app"
+	UsageGroupByModel    UsageGroupBy = "model"
+	UsageGroupByProvider UsageGroupBy = "provider"
+	UsageGroupByDay      UsageGroupBy = "day"
+)
+
+// UsageEntry is the usage of a group of LLM calls, only the fields the report
+// is grouped by are set
+type UsageEntry struct {
+	// Day is the UTC day of the calls, formatted as 2006-01-02
+	Day              string  `json:"day,omitempty"`
+	UserID           string  `json:"user_id,omitempty"`
+	AppID            string  `json:"app_id,omitempty"`
+	Provider         string  `json:"provider,omitempty"`
+	Model            string  `json:"model,omitempty"`
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+	// UnpricedTokens are the tokens of models without a price, they aren't
+	// part of the cost
+	UnpricedTokens int64 `json:"unpriced_tokens"`
+}
+
+type UsageReport struct {
+	From    time.Time      `json:"from"`
+	To      time.Time      `json:"to"`
+	GroupBy []UsageGroupBy `json:"group_by"`
+	Entries []*UsageEntry  `json:"entries"`
+	Total   *UsageEntry    `json:"total"`
+}
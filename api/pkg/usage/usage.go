@@ -0,0 +1,210 @@
+package usage
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+// ParseGroupBy parses a comma separated list of the dimensions of a report,
+// e.g. "user,day"
+func ParseGroupBy(s string) ([]types.UsageGroupBy, error) {
+	var groupBy []types.UsageGroupBy
+
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		g := types.UsageGroupBy(part)
+		switch g {
+		case types.UsageGroupByUser, types.UsageGroupByApp, types.UsageGroupByModel, types.UsageGroupByProvider, types.UsageGroupByDay:
+		default:
+			return nil, fmt.Errorf("unknown group by '%s', use user, app, model, provider or day", part)
+		}
+
+		for _, existing := range groupBy {
+			if existing == g {
+				return nil, fmt.Errorf("group by '%s' is repeated", part)
+			}
+		}
+
+		groupBy = append(groupBy, g)
+	}
+
+	return groupBy, nil
+}
+
+type priceKey struct {
+	provider string
+	model    string
+}
+
+// Prices looks up the prices of the models, falling back to the price of
+// every model of the provider
+type Prices struct {
+	prices map[priceKey]*types.ModelPrice
+}
+
+func NewPrices(prices []*types.ModelPrice) *Prices {
+	p := &Prices{prices: make(map[priceKey]*types.ModelPrice, len(prices))}
+	for _, price := range prices {
+		p.prices[priceKey{provider: price.Provider, model: price.Model}] = price
+	}
+	return p
+}
+
+func (p *Prices) Get(provider, model string) (*types.ModelPrice, bool) {
+	if price, ok := p.prices[priceKey{provider: provider, model: model}]; ok {
+		return price, true
+	}
+	price, ok := p.prices[priceKey{provider: provider, model: types.ModelPriceAnyModel}]
+	return price, ok
+}
+
+// Cost estimates the cost of the tokens in USD
+func Cost(price *types.ModelPrice, promptTokens, completionTokens int64) float64 {
+	return (float64(promptTokens)*price.PromptTokenPrice + float64(completionTokens)*price.CompletionTokenPrice) / 1_000_000
+}
+
+// NewReport rolls up the usage of the LLM calls, grouped by day, user, app,
+// provider and model, to the dimensions of the report and estimates their cost
+func NewReport(from, to time.Time, groupBy []types.UsageGroupBy, rows []*types.UsageEntry, prices *Prices) *types.UsageReport {
+	report := &types.UsageReport{
+		From:    from,
+		To:      to,
+		GroupBy: groupBy,
+		Entries: []*types.UsageEntry{},
+		Total:   &types.UsageEntry{},
+	}
+
+	groups := make(map[string]*types.UsageEntry)
+
+	for _, row := range rows {
+		key := make([]string, len(groupBy))
+		entry := &types.UsageEntry{}
+		for i, g := range groupBy {
+			key[i] = groupValue(row, g)
+			setGroupValue(entry, g, key[i])
+		}
+
+		// The key parts can't contain the separator, it's a control character
+		k := strings.Join(key, "\x1f")
+		if existing, ok := groups[k]; ok {
+			entry = existing
+		} else {
+			groups[k] = entry
+			report.Entries = append(report.Entries, entry)
+		}
+
+		add(entry, row, prices)
+		add(report.Total, row, prices)
+	}
+
+	sort.SliceStable(report.Entries, func(i, j int) bool {
+		for _, g := range groupBy {
+			a, b := groupValue(report.Entries[i], g), groupValue(report.Entries[j], g)
+			if a != b {
+				return a < b
+			}
+		}
+		return false
+	})
+
+	return report
+}
+
+func add(entry, row *types.UsageEntry, prices *Prices) {
+	entry.Requests += row.Requests
+	entry.PromptTokens += row.PromptTokens
+	entry.CompletionTokens += row.CompletionTokens
+	entry.TotalTokens += row.TotalTokens
+
+	if price, ok := prices.Get(row.Provider, row.Model); ok {
+		entry.Cost += Cost(price, row.PromptTokens, row.CompletionTokens)
+	} else {
+		entry.UnpricedTokens += row.TotalTokens
+	}
+}
+
+func groupValue(entry *types.UsageEntry, g types.UsageGroupBy) string {
+	switch g {
+	case types.UsageGroupByUser:
+		return entry.UserID
+	case types.UsageGroupByApp:
+		return entry.AppID
+	case types.UsageGroupByModel:
+		return entry.Model
+	case types.UsageGroupByProvider:
+		return entry.Provider
+	case types.UsageGroupByDay:
+		return entry.Day
+	}
+	return ""
+}
+
+func setGroupValue(entry *types.UsageEntry, g types.UsageGroupBy, value string) {
+	switch g {
+	case types.UsageGroupByUser:
+		entry.UserID = value
+	case types.UsageGroupByApp:
+		entry.AppID = value
+	case types.UsageGroupByModel:
+		entry.Model = value
+	case types.UsageGroupByProvider:
+		entry.Provider = value
+	case types.UsageGroupByDay:
+		entry.Day = value
+	}
+}
+
+// Columns returns the header of the report, its dimensions followed by the
+// usage and cost
+func Columns(report *types.UsageReport) []string {
+	var columns []string
+	for _, g := range report.GroupBy {
+		columns = append(columns, string(g))
+	}
+	return append(columns, "requests", "prompt_tokens", "completion_tokens", "total_tokens", "cost", "unpriced_tokens")
+}
+
+// Row returns the values of the entry in the order of the columns
+func Row(report *types.UsageReport, entry *types.UsageEntry) []string {
+	var row []string
+	for _, g := range report.GroupBy {
+		row = append(row, groupValue(entry, g))
+	}
+	return append(row,
+		strconv.FormatInt(entry.Requests, 10),
+		strconv.FormatInt(entry.PromptTokens, 10),
+		strconv.FormatInt(entry.CompletionTokens, 10),
+		strconv.FormatInt(entry.TotalTokens, 10),
+		strconv.FormatFloat(entry.Cost, 'f', 4, 64),
+		strconv.FormatInt(entry.UnpricedTokens, 10),
+	)
+}
+
+// WriteCSV writes the entries of the report as CSV, without the total
+func WriteCSV(w io.Writer, report *types.UsageReport) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(Columns(report)); err != nil {
+		return err
+	}
+
+	for _, entry := range report.Entries {
+		if err := cw.Write(Row(report, entry)); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,63 @@
+package usage
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/helixml/helix/api/pkg/types"
+)
+
+func TestParseGroupBy(t *testing.T) {
+	groupBy, err := ParseGroupBy("user, day")
+	require.NoError(t, err)
+	require.Equal(t, []types.UsageGroupBy{types.UsageGroupByUser, types.UsageGroupByDay}, groupBy)
+
+	groupBy, err = ParseGroupBy("")
+	require.NoError(t, err)
+	require.Empty(t, groupBy)
+
+	_, err = ParseGroupBy("user,team")
+	require.ErrorContains(t, err, "unknown group by 'team'")
+
+	_, err = ParseGroupBy("user,user")
+	require.ErrorContains(t, err, "repeated")
+}
+
+func TestNewReport(t *testing.T) {
+	rows := []*types.UsageEntry{
+		{Day: "2025-01-01", UserID: "bob", Provider: "openai", Model: "gpt-4o", Requests: 2, PromptTokens: 1_000_000, CompletionTokens: 500_000, TotalTokens: 1_500_000},
+		{Day: "2025-01-01", UserID: "alice", Provider: "openai", Model: "gpt-4o-mini", Requests: 1, PromptTokens: 2_000_000, CompletionTokens: 0, TotalTokens: 2_000_000},
+		{Day: "2025-01-02", UserID: "alice", Provider: "helix", Model: "llama3", Requests: 3, PromptTokens: 10, CompletionTokens: 20, TotalTokens: 30},
+	}
+	prices := NewPrices([]*types.ModelPrice{
+		{Provider: "openai", Model: "gpt-4o", PromptTokenPrice: 2.5, CompletionTokenPrice: 10},
+		{Provider: "openai", Model: types.ModelPriceAnyModel, PromptTokenPrice: 0.15, CompletionTokenPrice: 0.6},
+	})
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	report := NewReport(from, to, []types.UsageGroupBy{types.UsageGroupByUser}, rows, prices)
+	require.Equal(t, []*types.UsageEntry{
+		{UserID: "alice", Requests: 4, PromptTokens: 2_000_010, CompletionTokens: 20, TotalTokens: 2_000_030, Cost: 0.3, UnpricedTokens: 30},
+		{UserID: "bob", Requests: 2, PromptTokens: 1_000_000, CompletionTokens: 500_000, TotalTokens: 1_500_000, Cost: 7.5},
+	}, report.Entries)
+	require.Equal(t, int64(6), report.Total.Requests)
+	require.InDelta(t, 7.8, report.Total.Cost, 1e-9)
+	require.Equal(t, int64(30), report.Total.UnpricedTokens)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, report))
+	require.Equal(t, `user,requests,prompt_tokens,completion_tokens,total_tokens,cost,unpriced_tokens
+alice,4,2000010,20,2000030,0.3000,30
+bob,2,1000000,500000,1500000,7.5000,0
+`, buf.String())
+
+	// Without dimensions there's a single entry with the total
+	report = NewReport(from, to, nil, rows, prices)
+	require.Len(t, report.Entries, 1)
+	require.Equal(t, report.Total, report.Entries[0])
+}
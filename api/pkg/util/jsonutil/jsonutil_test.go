@@ -0,0 +1,25 @@
+package jsonutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttemptFixJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{name: "plain", data: `[{"index": 0}]`, want: `[{"index": 0}]`},
+		{name: "code block", data: "```\n[{\"index\": 0}]\n```", want: "[{\"index\": 0}]\n"},
+		{name: "json code block", data: "```json\n[{\"index\": 0}]\n```\nHope this helps!", want: "\n[{\"index\": 0}]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, AttemptFixJSON(tt.data))
+		})
+	}
+}
@@ -24,9 +24,10 @@ export const INTERACTION_STATE_EDITING: IInteractionState = 'editing'
 export const INTERACTION_STATE_COMPLETE: IInteractionState = 'complete'
 export const INTERACTION_STATE_ERROR: IInteractionState = 'error'
 
-export type IWebSocketEventType = 'session_update' | 'worker_task_response'
+export type IWebSocketEventType = 'session_update' | 'worker_task_response' | 'tool_approval'
 export const WEBSOCKET_EVENT_TYPE_SESSION_UPDATE: IWebSocketEventType = 'session_update'
 export const WEBSOCKET_EVENT_TYPE_WORKER_TASK_RESPONSE: IWebSocketEventType = 'worker_task_response'
+export const WEBSOCKET_EVENT_TYPE_TOOL_APPROVAL: IWebSocketEventType = 'tool_approval'
 
 export type IWorkerTaskResponseType = 'stream' | 'progress' | 'result'
 export const WORKER_TASK_RESPONSE_TYPE_STREAM: IWorkerTaskResponseType = 'stream'
@@ -190,6 +191,17 @@ export interface IInteraction {
   data_prep_stage: ITextDataPrepStage,
   data_prep_limited: boolean,
   data_prep_limit: number,
+  citations?: ICitation[],
+}
+
+export interface ICitation {
+  knowledge_id?: string,
+  knowledge_version?: string,
+  data_entity_id?: string,
+  document_id?: string,
+  source?: string,
+  content_offset: number,
+  content: string,
 }
 
 export interface ISessionOrigin {
@@ -261,6 +273,7 @@ export interface IWebsocketEvent {
   session?: ISession,
   worker_task_response?: IWorkerTaskResponse,
   step_info?: any,
+  tool_approval?: IToolApproval,
 }
 
 export interface IServerConfig {
@@ -326,9 +339,28 @@ export interface IRunnerStatus {
   total_memory: number,
   free_memory: number,
   labels: Record<string, string>,
+  taints?: Record<string, string>,
   slots: ISlot[],
 }
 
+export interface IRunnerLabelRequirement {
+  key: string,
+  operator: 'In' | 'NotIn' | 'Exists' | 'DoesNotExist',
+  values?: string[],
+}
+
+export interface IRunnerToleration {
+  key: string,
+  operator?: 'Equal' | 'Exists',
+  value?: string,
+}
+
+export interface IRunnerSelector {
+  node_selector?: Record<string, string>,
+  affinity?: IRunnerLabelRequirement[],
+  tolerations?: IRunnerToleration[],
+}
+
 export interface ISessionFilterModel {
   mode: ISessionMode,
   model_name?: string,
@@ -363,6 +395,9 @@ export interface IQueueItem {
   runtime: string,
   lora_dir: string,
   summary: string,
+  owner_id: string,
+  priority_class: 'interactive' | 'api' | 'finetune' | 'cron',
+  queue_position: number,
 }
 
 export interface IDashboardData {
@@ -486,7 +521,7 @@ export interface IShareSessionInstructions {
   addDocumentsMode?: boolean,
 }
 
-export type IToolType = 'api' | 'gptscript' | 'zapier'
+export type IToolType = 'api' | 'gptscript' | 'zapier' | 'mcp'
 
 export interface IToolApiAction {
   name: string,
@@ -501,11 +536,57 @@ export interface IToolApiConfig {
   actions: IToolApiAction[],
   headers: Record<string, string>,
   query: Record<string, string>,
+  auth?: IToolApiAuth,
+  approval?: IToolApprovalConfig,
   request_prep_template?: string,
   response_success_template?: string,
   response_error_template?: string,
 }
 
+export interface IToolApiAuth {
+  scheme?: string,
+  secret?: string,
+  username?: string,
+  client_id?: string,
+  scopes?: string[],
+  user_secret?: string,
+}
+
+export type IToolActionPolicy = 'auto' | 'confirm' | 'deny'
+
+export interface IToolApprovalConfig {
+  policy?: IToolActionPolicy,
+  mutating?: IToolActionPolicy,
+  actions?: Record<string, IToolActionPolicy>,
+  approvers?: string[],
+}
+
+export type IToolApprovalStatus = 'pending' | 'approved' | 'denied' | 'expired'
+
+export interface IToolApproval {
+  id: string,
+  created: string,
+  updated: string,
+  session_id: string,
+  interaction_id: string,
+  owner: string,
+  owner_type: IOwnerType,
+  app_id: string,
+  tool_id: string,
+  tool_name: string,
+  action: string,
+  method: string,
+  path: string,
+  justification: string,
+  approvers: string[],
+  status: IToolApprovalStatus,
+  expires_at: string,
+  decided_by?: string,
+  decided_at?: string,
+  reason?: string,
+  resumed_at?: string,
+}
+
 export interface IToolGptScriptConfig {
   script?: string,
   script_url?: string, // If script lives on a remote server, specify the URL
@@ -517,11 +598,27 @@ export interface IToolZapierConfig {
   max_iterations?: number,
 }
 
+export interface IToolMcpTool {
+  name: string,
+  description: string,
+  input_schema?: Record<string, any>,
+}
+
+export interface IToolMcpConfig {
+  command?: string, // Command starting the server, e.g. npx
+  args?: string[],
+  env?: Record<string, string>,
+  secrets?: Record<string, string>, // Environment variable to secret name
+  url?: string, // SSE endpoint of a running server
+  approval?: IToolApprovalConfig, // Tools that need approval before running
+  tools?: IToolMcpTool[], // Read-only, listed from the server
+}
 
 export interface IToolConfig {
   api?: IToolApiConfig,
   gptscript?: IToolGptScriptConfig,
   zapier?: IToolZapierConfig,
+  mcp?: IToolMcpConfig,
 }
 
 export interface ITool {
@@ -564,6 +661,8 @@ export interface IAssistantApi {
   url: string,
   headers?: Record<string, string>,
   query?: Record<string, string>,
+  auth?: IToolApiAuth,
+  approval?: IToolApprovalConfig,
   request_prep_template?: string,
   response_success_template?: string,
   response_error_template?: string,
@@ -584,6 +683,17 @@ export interface IAssistantZapier {
   max_iterations?: number,
 }
 
+export interface IAssistantMcp {
+  name: string,
+  description: string,
+  command?: string,
+  args?: string[],
+  env?: Record<string, string>,
+  secrets?: Record<string, string>,
+  url?: string,
+  approval?: IToolApprovalConfig,
+}
+
 export interface IAssistantConfig {
   id?: string;
   name?: string;
@@ -597,9 +707,11 @@ export interface IAssistantConfig {
   rag_source_id?: string;
   lora_id?: string;
   is_actionable_template?: string;
+  native_tool_calling?: boolean;
   apis?: IAssistantApi[];
   gptscripts?: IAssistantGPTScript[];
   zapier?: IAssistantZapier[];
+  mcps?: IAssistantMcp[];
   tools?: ITool[];
   knowledge?: IKnowledgeSource[];
 }
@@ -633,10 +745,15 @@ export interface IKnowledgeSource {
     s3?: {
       bucket: string;
       path: string;
+      region?: string;
+      endpoint?: string;
+      filter_extensions?: string[];
     };
     gcs?: {
       bucket: string;
       path: string;
+      endpoint?: string;
+      filter_extensions?: string[];
     };
     filestore?: {
       path: string;
@@ -699,9 +816,17 @@ export interface IAppHelixConfig {
   assistants?: IAssistantConfig[];
   // TODO: add triggers
   external_url: string;
+  runner_selector?: IRunnerSelector;
+  response_cache?: IResponseCacheConfig;
   // Add any other properties that might be part of the helix config
 }
 
+export interface IResponseCacheConfig {
+  enabled: boolean;
+  ttl?: string;
+  similarity_threshold?: number;
+}
+
 export interface IAppGithubConfigUpdate {
   updated: string,
   hash: string,
@@ -883,6 +1008,7 @@ export interface LLMCall {
   prompt_tokens: number;
   completion_tokens: number;
   total_tokens: number;
+  cache_hit: boolean;
 }
 
 export interface PaginatedLLMCalls {
@@ -907,6 +1033,8 @@ export interface ISecret {
 
 export type IProviderEndpointType = 'global' | 'user'
 
+export type IProviderAPIType = 'openai' | 'anthropic' | 'gemini'
+
 export interface IProviderEndpoint {
   id: string
   created: string
@@ -915,6 +1043,7 @@ export interface IProviderEndpoint {
   description: string
   models?: string[]
   endpoint_type: IProviderEndpointType
+  api_type?: IProviderAPIType
   owner: string
   owner_type: IOwnerType
   base_url: string
@@ -922,3 +1051,25 @@ export interface IProviderEndpoint {
   api_key_file?: string
   default: boolean
 }
+
+export type IProviderRoutingStrategy = 'priority' | 'weighted_round_robin' | 'least_latency'
+
+export interface IProviderRoutingGroupMember {
+  provider: string
+  priority?: number
+  weight?: number
+  model?: string
+}
+
+export interface IProviderRoutingGroup {
+  id: string
+  created: string
+  updated: string
+  name: string
+  description: string
+  endpoint_type: IProviderEndpointType
+  owner: string
+  owner_type: IOwnerType
+  strategy: IProviderRoutingStrategy
+  members: IProviderRoutingGroupMember[]
+}